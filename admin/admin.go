@@ -0,0 +1,234 @@
+// Package admin provides an HTTP API to inspect and maintain a litepack cache,
+// meant to be mounted under an internal route for operations teams.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// Entry is a cache entry returned by the admin API.
+type Entry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// TTL is the remaining time-to-live, such as "59m30s", or "none" if the entry
+	// never expires.
+	TTL string `json:"ttl"`
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler returns an http.Handler that exposes the admin API of the cache.
+// The handler does not authenticate the requests: it must only be reachable by the
+// operators, for example on an internal port or behind an authentication middleware.
+//
+// Endpoints:
+//   - GET /stats: the usage of the cache
+//   - GET /keys?prefix=user:&limit=100: the keys with the given prefix, at most limit
+//   - GET /keys/{key}: the value and the TTL of an entry
+//   - DELETE /keys/{key}: deletes an entry
+//   - POST /purge: deletes the expired entries
+//   - POST /compact: runs the maintenance of the cache, see Cache.Compact
+//   - GET /entries/{key}: the entry of a key with its expiration time and metadata,
+//     see Cache.GetEntry
+//   - GET /events?since=42: the changes recorded after an event ID, see Cache.Events
+//
+// Parameters:
+//   - c: the cache
+//
+// Returns:
+//   - http.Handler: the admin API handler
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("/internal/cache/", http.StripPrefix("/internal/cache", admin.Handler(lpCache)))
+func Handler(c cache.Cache) http.Handler {
+	h := &handler{cache: c}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stats", h.stats)
+	mux.HandleFunc("GET /keys", h.keys)
+	mux.HandleFunc("GET /keys/{key...}", h.get)
+	mux.HandleFunc("DELETE /keys/{key...}", h.del)
+	mux.HandleFunc("POST /purge", h.purge)
+	mux.HandleFunc("POST /compact", h.compact)
+	mux.HandleFunc("GET /entries/{key...}", h.entry)
+	mux.HandleFunc("GET /events", h.events)
+
+	return mux
+}
+
+// handler serves the admin API of a cache.
+type handler struct {
+	cache cache.Cache
+}
+
+// stats writes the usage of the cache.
+func (h *handler) stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.cache.Stats(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// keys writes the keys with the prefix of the request.
+func (h *handler) keys(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if param := r.URL.Query().Get("limit"); param != "" {
+		var err error
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid limit"})
+			return
+		}
+	}
+
+	keys, err := h.cache.Keys(r.Context(), escapeGlob(r.URL.Query().Get("prefix"))+"*")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// get writes the entry of the key of the request.
+func (h *handler) get(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	value, err := h.cache.GetBytes(r.Context(), key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ttl, err := h.cache.GetTTL(r.Context(), key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	entry := Entry{Key: key, Value: string(value), TTL: "none"}
+	if ttl != cache.NoTTL {
+		entry.TTL = ttl.Round(time.Second).String()
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// del deletes the key of the request.
+func (h *handler) del(w http.ResponseWriter, r *http.Request) {
+	if err := h.cache.Del(r.Context(), r.PathValue("key")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// purge deletes the expired entries.
+func (h *handler) purge(w http.ResponseWriter, r *http.Request) {
+	if err := h.cache.PurgeExpiredItems(r.Context()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// compact runs the maintenance of the cache and writes its stats.
+func (h *handler) compact(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.cache.Compact(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// entry writes the entry of the key of the request, with its expiration time and metadata.
+func (h *handler) entry(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.cache.GetEntry(r.Context(), r.PathValue("key"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// events writes the events recorded after the ID of the request.
+func (h *handler) events(w http.ResponseWriter, r *http.Request) {
+	var sinceID int64
+	if param := r.URL.Query().Get("since"); param != "" {
+		var err error
+		sinceID, err = strconv.ParseInt(param, 10, 64)
+		if err != nil || sinceID < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid since"})
+			return
+		}
+	}
+
+	events, err := h.cache.Events(r.Context(), sinceID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+// writeJSON writes v as the JSON body of the response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error of the cache, with status 404 if the key was not found
+// and 501 if the event log is disabled.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, cache.ErrKeyNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, cache.ErrEventLogDisabled):
+		status = http.StatusNotImplemented
+	}
+
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// escapeGlob escapes the special characters of a GLOB pattern.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[':
+			b.WriteByte('[')
+			b.WriteRune(r)
+			b.WriteByte(']')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
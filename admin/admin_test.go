@@ -0,0 +1,231 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// stubCache is a cache that stores the values in a map.
+type stubCache struct {
+	cache.Cache
+	values  map[string][]byte
+	pattern string
+	purged  bool
+	err     error
+}
+
+func newStubCache() *stubCache {
+	return &stubCache{values: make(map[string][]byte)}
+}
+
+func (s *stubCache) Stats(context.Context) (cache.CacheStats, error) {
+	if s.err != nil {
+		return cache.CacheStats{}, s.err
+	}
+
+	return cache.CacheStats{Entries: int64(len(s.values))}, nil
+}
+
+func (s *stubCache) Keys(_ context.Context, pattern string) ([]string, error) {
+	s.pattern = pattern
+	return []string{"user:1", "user:2", "user:3"}, s.err
+}
+
+func (s *stubCache) GetBytes(_ context.Context, key string) ([]byte, error) {
+	value, ok := s.values[key]
+	if !ok {
+		return nil, cache.ErrKeyNotFound
+	}
+
+	return value, nil
+}
+
+func (s *stubCache) GetTTL(_ context.Context, key string) (time.Duration, error) {
+	if key == "persistent" {
+		return cache.NoTTL, nil
+	}
+
+	return time.Minute, nil
+}
+
+func (s *stubCache) GetEntry(_ context.Context, key string) (cache.Entry, error) {
+	value, ok := s.values[key]
+	if !ok {
+		return cache.Entry{}, cache.ErrKeyNotFound
+	}
+
+	return cache.Entry{Value: string(value), Metadata: map[string]string{"source": "test"}}, nil
+}
+
+func (s *stubCache) Events(_ context.Context, sinceID int64) ([]cache.Event, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return []cache.Event{{ID: sinceID + 1, Type: cache.EventSet, Key: "user:1"}}, nil
+}
+
+func (s *stubCache) Del(_ context.Context, key string) error {
+	delete(s.values, key)
+	return s.err
+}
+
+func (s *stubCache) PurgeExpiredItems(context.Context) error {
+	s.purged = true
+	return s.err
+}
+
+func (s *stubCache) Compact(context.Context) (cache.CompactStats, error) {
+	return cache.CompactStats{ExpiredEntries: 2}, s.err
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("should return the stats", func(t *testing.T) {
+		stub := newStubCache()
+		stub.values["key"] = []byte("value")
+
+		rec := serve(Handler(stub), http.MethodGet, "/stats")
+
+		var stats cache.CacheStats
+		_ = json.NewDecoder(rec.Body).Decode(&stats)
+		assert.Equal(t, http.StatusOK, rec.Code, "Expected status 200")
+		assert.Equal(t, int64(1), stats.Entries, "Expected the number of entries")
+	})
+
+	t.Run("should list the keys by prefix", func(t *testing.T) {
+		stub := newStubCache()
+
+		rec := serve(Handler(stub), http.MethodGet, "/keys?prefix=user*&limit=2")
+
+		var keys []string
+		_ = json.NewDecoder(rec.Body).Decode(&keys)
+		assert.Equal(t, http.StatusOK, rec.Code, "Expected status 200")
+		assert.Equal(t, "user[*]*", stub.pattern, "Expected the escaped prefix pattern")
+		assert.Equal(t, []string{"user:1", "user:2"}, keys, "Expected the limited keys")
+	})
+
+	t.Run("should reject an invalid limit", func(t *testing.T) {
+		rec := serve(Handler(newStubCache()), http.MethodGet, "/keys?limit=x")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "Expected status 400")
+	})
+
+	t.Run("should return an entry", func(t *testing.T) {
+		stub := newStubCache()
+		stub.values["user/1"] = []byte("John")
+		stub.values["persistent"] = []byte("value")
+		h := Handler(stub)
+
+		rec := serve(h, http.MethodGet, "/keys/user/1")
+		var entry Entry
+		_ = json.NewDecoder(rec.Body).Decode(&entry)
+		assert.Equal(t, http.StatusOK, rec.Code, "Expected status 200")
+		assert.Equal(t, Entry{Key: "user/1", Value: "John", TTL: "1m0s"}, entry, "Expected the entry")
+
+		rec = serve(h, http.MethodGet, "/keys/persistent")
+		_ = json.NewDecoder(rec.Body).Decode(&entry)
+		assert.Equal(t, "none", entry.TTL, "Expected no TTL")
+	})
+
+	t.Run("should return 404 when the key does not exist", func(t *testing.T) {
+		rec := serve(Handler(newStubCache()), http.MethodGet, "/keys/missing")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code, "Expected status 404")
+	})
+
+	t.Run("should delete an entry", func(t *testing.T) {
+		stub := newStubCache()
+		stub.values["key"] = []byte("value")
+
+		rec := serve(Handler(stub), http.MethodDelete, "/keys/key")
+
+		assert.Equal(t, http.StatusNoContent, rec.Code, "Expected status 204")
+		assert.NotContains(t, stub.values, "key", "Expected the key to be deleted")
+	})
+
+	t.Run("should purge the expired entries", func(t *testing.T) {
+		stub := newStubCache()
+
+		rec := serve(Handler(stub), http.MethodPost, "/purge")
+
+		assert.Equal(t, http.StatusNoContent, rec.Code, "Expected status 204")
+		assert.True(t, stub.purged, "Expected the expired entries to be purged")
+	})
+
+	t.Run("should compact the cache", func(t *testing.T) {
+		rec := serve(Handler(newStubCache()), http.MethodPost, "/compact")
+
+		var stats cache.CompactStats
+		_ = json.NewDecoder(rec.Body).Decode(&stats)
+		assert.Equal(t, http.StatusOK, rec.Code, "Expected status 200")
+		assert.Equal(t, int64(2), stats.ExpiredEntries, "Expected the compact stats")
+	})
+
+	t.Run("should return 500 when the cache fails", func(t *testing.T) {
+		stub := newStubCache()
+		stub.err = errors.New("database error")
+
+		rec := serve(Handler(stub), http.MethodPost, "/purge")
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code, "Expected status 500")
+		assert.Contains(t, rec.Body.String(), "database error", "Expected the error message")
+	})
+
+	t.Run("should return an entry with its metadata", func(t *testing.T) {
+		stub := newStubCache()
+		stub.values["user:1"] = []byte("John")
+
+		rec := serve(Handler(stub), http.MethodGet, "/entries/user:1")
+
+		var entry cache.Entry
+		_ = json.NewDecoder(rec.Body).Decode(&entry)
+		assert.Equal(t, http.StatusOK, rec.Code, "Expected status 200")
+		assert.Equal(t, cache.Entry{Value: "John", Metadata: map[string]string{"source": "test"}}, entry)
+	})
+
+	t.Run("should list the events after an ID", func(t *testing.T) {
+		rec := serve(Handler(newStubCache()), http.MethodGet, "/events?since=41")
+
+		var events []cache.Event
+		_ = json.NewDecoder(rec.Body).Decode(&events)
+		assert.Equal(t, http.StatusOK, rec.Code, "Expected status 200")
+		assert.Equal(t, []cache.Event{{ID: 42, Type: cache.EventSet, Key: "user:1"}}, events)
+	})
+
+	t.Run("should reject an invalid event ID", func(t *testing.T) {
+		rec := serve(Handler(newStubCache()), http.MethodGet, "/events?since=-1")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "Expected status 400")
+	})
+
+	t.Run("should return 501 when the event log is disabled", func(t *testing.T) {
+		stub := newStubCache()
+		stub.err = cache.ErrEventLogDisabled
+
+		rec := serve(Handler(stub), http.MethodGet, "/events")
+
+		assert.Equal(t, http.StatusNotImplemented, rec.Code, "Expected status 501")
+	})
+
+	t.Run("should reject other methods", func(t *testing.T) {
+		rec := serve(Handler(newStubCache()), http.MethodGet, "/purge")
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code, "Expected status 405")
+	})
+}
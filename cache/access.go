@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// taskFlushAccess is the name of the task that stores the buffered access times.
+const taskFlushAccess = "flush-access"
+
+// access is the buffered access of a key.
+type access struct {
+	at    time.Time
+	reads int64
+}
+
+// accessBuffer keeps the access times of the entries read since the last flush,
+// so that reads do not write to the database.
+type accessBuffer struct {
+	entries map[string]access
+	mu      sync.Mutex
+}
+
+// newAccessBuffer creates an empty access buffer.
+func newAccessBuffer() *accessBuffer {
+	return &accessBuffer{entries: make(map[string]access)}
+}
+
+// record buffers a read of the given keys at the given time.
+// It returns false if the buffer is disabled, in which case the caller
+// must update the access time itself.
+func (b *accessBuffer) record(at time.Time, keys ...string) bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		entry := b.entries[key]
+		entry.at = at
+		entry.reads++
+		b.entries[key] = entry
+	}
+
+	return true
+}
+
+// drain returns the buffered accesses and empties the buffer.
+func (b *accessBuffer) drain() map[string]access {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := b.entries
+	b.entries = make(map[string]access)
+
+	return entries
+}
+
+// flushAccess stores the buffered access times in a single transaction.
+// The accesses are dropped if the transaction fails, since they only order evictions.
+// The caller must hold the write lock.
+func (ch *cache) flushAccess(ctx context.Context) error {
+	entries := ch.access.drain()
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		queriesWithTx := queries.New(tx)
+		for key, entry := range entries {
+			err := queriesWithTx.AddAccess(ctx, queries.AddAccessParams{
+				Key:            key,
+				LastAccessedAt: entry.at,
+				Reads:          entry.reads,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("flushing access times: %w", err)
+	}
+
+	return nil
+}
+
+// flushAccessCache schedules the flush of the buffered access times on the sync interval,
+// if the access times are buffered.
+func (ch *cache) flushAccessCache(ctx context.Context) {
+	if ch.access == nil {
+		return
+	}
+
+	task := func() error {
+		ch.writeMu.RLock()
+		defer ch.writeMu.RUnlock()
+
+		err := ch.flushAccess(ctx)
+		if err != nil {
+			ch.logger.Error(ctx, err.Error())
+			return err
+		}
+
+		return nil
+	}
+
+	err := ch.addTask(taskFlushAccess, string(ch.syncInterval), task)
+	if err != nil {
+		err = fmt.Errorf("adding cron task: %w", err)
+		ch.logger.Error(ctx, err.Error())
+	}
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+	"github.com/lucasvillarinho/litepack/internal/cron"
+)
+
+func TestAccessBuffer(t *testing.T) {
+	first := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Second)
+
+	t.Run("should keep the last access time and count the reads", func(t *testing.T) {
+		buffer := newAccessBuffer()
+
+		assert.True(t, buffer.record(first, "a", "b"), "Expected the reads to be buffered")
+		assert.True(t, buffer.record(second, "a"), "Expected the read to be buffered")
+
+		entries := buffer.drain()
+
+		assert.Equal(t, map[string]access{
+			"a": {at: second, reads: 2},
+			"b": {at: first, reads: 1},
+		}, entries)
+		assert.Empty(t, buffer.drain(), "Expected the buffer to be empty after a drain")
+	})
+
+	t.Run("should not buffer if disabled", func(t *testing.T) {
+		var buffer *accessBuffer
+
+		assert.False(t, buffer.record(first, "a"), "Expected the read not to be buffered")
+		assert.Nil(t, buffer.drain(), "Expected nothing to drain")
+	})
+}
+
+func TestCache_BufferedAccess(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ctx := context.Background()
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should not update the access time on reads", func(t *testing.T) {
+		ch := &cache{
+			queries:    queries.New(db),
+			timeSource: timeSource{Timezone: time.UTC, Now: time.Now},
+			access:     newAccessBuffer(),
+		}
+
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow("value", nil))
+
+		value, err := ch.Get(ctx, "key")
+
+		assert.NoError(t, err, "Expected no error while getting the key")
+		assert.Equal(t, "value", value)
+		assert.Contains(t, ch.access.drain(), "key", "Expected the read to be buffered")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should store the buffered accesses in a transaction", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ \? WHERE key = \?`).
+					WithArgs(fixedTime, 2, "key").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlMock.ExpectCommit()
+
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				err = fn(tx)
+				assert.NoError(t, err, "Expected no error during transaction execution")
+
+				err = tx.Commit()
+				assert.NoError(t, err, "Expected no error while committing transaction")
+			}).
+			Return(nil)
+
+		ch := &cache{Database: dbMock, access: newAccessBuffer()}
+		ch.access.record(fixedTime, "key", "key")
+
+		err := ch.flushAccess(ctx)
+
+		assert.NoError(t, err, "Expected no error while flushing the accesses")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should not open a transaction without accesses", func(t *testing.T) {
+		ch := &cache{Database: dbMocks.NewDatabaseMock(t), access: newAccessBuffer()}
+
+		err := ch.flushAccess(ctx)
+
+		assert.NoError(t, err, "Expected no error while flushing the accesses")
+	})
+
+	t.Run("should return error if the transaction fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("mock tx error"))
+
+		ch := &cache{Database: dbMock, access: newAccessBuffer()}
+		ch.access.record(fixedTime, "key")
+
+		err := ch.flushAccess(ctx)
+
+		assert.EqualError(t, err, "flushing access times: mock tx error")
+	})
+
+	t.Run("should schedule the flush if the access times are buffered", func(t *testing.T) {
+		ch := &cache{
+			cron:         cron.New(time.UTC),
+			syncInterval: cron.EveryMinute,
+			access:       newAccessBuffer(),
+		}
+
+		ch.flushAccessCache(ctx)
+
+		tasks := ch.cron.Tasks()
+		assert.Len(t, tasks, 1, "Expected the flush to be scheduled")
+		assert.Equal(t, taskFlushAccess, tasks[0].Name, "Expected the flush task")
+	})
+}
@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// asyncWrite is a write waiting in the async write queue.
+type asyncWrite struct {
+	value []byte
+	ttl   time.Duration
+}
+
+// asyncWriter stores the writes of SetAsync in the background, one at a time and in
+// the order they were queued. A key queued again before it is written is coalesced:
+// only its latest value is written.
+type asyncWriter struct {
+	write   func(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	onError func(err error)
+
+	keys    chan string           // keys waiting to be written, bounded by the queue size
+	pending map[string]asyncWrite // latest write of each queued key
+	idle    chan struct{}         // closed when the queued writes are done
+	queued  int                   // writes queued or in progress
+	closed  bool
+	start   sync.Once
+	mu      sync.Mutex
+}
+
+// newAsyncWriter creates a writer with a queue of the given size.
+// The background worker is started by the first write.
+func newAsyncWriter(
+	size int,
+	write func(ctx context.Context, key string, value []byte, ttl time.Duration) error,
+	onError func(err error),
+) *asyncWriter {
+	return &asyncWriter{
+		write:   write,
+		onError: onError,
+		keys:    make(chan string, size),
+		pending: make(map[string]asyncWrite),
+	}
+}
+
+// enqueue queues a write, replacing the value of the key if it is already queued.
+func (w *asyncWriter) enqueue(key string, value []byte, ttl time.Duration) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrCacheClosed
+	}
+
+	write := asyncWrite{value: value, ttl: ttl}
+	if _, ok := w.pending[key]; ok {
+		w.pending[key] = write
+		return nil
+	}
+
+	select {
+	case w.keys <- key:
+	default:
+		return ErrWriteQueueFull
+	}
+	w.pending[key] = write
+	if w.queued == 0 {
+		w.idle = make(chan struct{})
+	}
+	w.queued++
+
+	w.start.Do(func() { go w.run() })
+
+	return nil
+}
+
+// run writes the queued keys until the queue is closed.
+func (w *asyncWriter) run() {
+	for key := range w.keys {
+		w.mu.Lock()
+		write := w.pending[key]
+		delete(w.pending, key)
+		w.mu.Unlock()
+
+		err := w.write(context.Background(), key, write.value, write.ttl)
+		if err != nil {
+			w.onError(fmt.Errorf("writing key %q asynchronously: %w", key, err))
+		}
+
+		w.mu.Lock()
+		w.queued--
+		if w.queued == 0 {
+			close(w.idle)
+		}
+		w.mu.Unlock()
+	}
+}
+
+// flush waits until the queued writes are stored.
+func (w *asyncWriter) flush(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	if w.queued == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	idle := w.idle
+	w.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting writes, waits until the queued writes are stored
+// and stops the worker.
+func (w *asyncWriter) close(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	err := w.flush(ctx)
+	close(w.keys)
+
+	return err
+}
+
+// SetAsync queues a key-value pair to be set in the background, so that the caller does
+// not wait for the database. Writes of the same key queued before it is written are
+// coalesced: only the latest value is stored.
+// Queued writes are lost if the process crashes; use FlushAsync to wait for them.
+// Reads do not see a value until it is written. Errors of the background writes
+// are logged.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - value: the cache value
+//   - ttl: the time-to-live for the cache entry
+//
+// Returns:
+//   - error: ErrWriteQueueFull if the queue is full, ErrCacheClosed if the cache is closed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err := cache.SetAsync(ctx, "last-seen:42", time.Now().String(), time.Hour)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) SetAsync(ctx context.Context, key, value string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := ch.checkKey(key); err != nil {
+		return err
+	}
+	if err := ch.checkValueSize(len(value)); err != nil {
+		return err
+	}
+
+	return ch.async.enqueue(key, []byte(value), ttl)
+}
+
+// FlushAsync waits until the writes queued by SetAsync are stored.
+// Close also waits for the queued writes.
+//
+// Parameters:
+//   - ctx: the context, cancelling it stops waiting
+//
+// Returns:
+//   - error: the context error if it is done before the writes are stored
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	_ = cache.SetAsync(ctx, "key", "value", time.Minute)
+//	err = cache.FlushAsync(ctx)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) FlushAsync(ctx context.Context) error {
+	return ch.async.flush(ctx)
+}
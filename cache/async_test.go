@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// asyncWriteRecorder records the writes of an async writer.
+type asyncWriteRecorder struct {
+	err     error
+	release chan struct{}
+	values  []string
+	mu      sync.Mutex
+}
+
+func (r *asyncWriteRecorder) write(_ context.Context, key string, value []byte, _ time.Duration) error {
+	if r.release != nil {
+		<-r.release
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values = append(r.values, key+"="+string(value))
+
+	return r.err
+}
+
+func (r *asyncWriteRecorder) written() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.values...)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should write the queued keys in order", func(t *testing.T) {
+		recorder := &asyncWriteRecorder{}
+		writer := newAsyncWriter(10, recorder.write, func(error) {})
+
+		assert.NoError(t, writer.enqueue("a", []byte("1"), time.Minute))
+		assert.NoError(t, writer.enqueue("b", []byte("2"), time.Minute))
+		assert.NoError(t, writer.flush(ctx))
+
+		assert.Equal(t, []string{"a=1", "b=2"}, recorder.written(), "Expected the writes in order")
+	})
+
+	t.Run("should coalesce writes of a queued key", func(t *testing.T) {
+		recorder := &asyncWriteRecorder{release: make(chan struct{})}
+		writer := newAsyncWriter(10, recorder.write, func(error) {})
+
+		// the first write blocks the worker, so the next ones stay queued
+		assert.NoError(t, writer.enqueue("blocker", []byte("0"), time.Minute))
+		assert.NoError(t, writer.enqueue("a", []byte("1"), time.Minute))
+		assert.NoError(t, writer.enqueue("a", []byte("2"), time.Minute))
+		close(recorder.release)
+		assert.NoError(t, writer.flush(ctx))
+
+		assert.Equal(t, []string{"blocker=0", "a=2"}, recorder.written(), "Expected the latest value")
+	})
+
+	t.Run("should return ErrWriteQueueFull if the queue is full", func(t *testing.T) {
+		recorder := &asyncWriteRecorder{release: make(chan struct{})}
+		writer := newAsyncWriter(1, recorder.write, func(error) {})
+
+		assert.NoError(t, writer.enqueue("a", []byte("1"), time.Minute))
+		// wait for the worker to take the first key, so that it blocks on the write
+		assert.Eventually(t, func() bool { return len(writer.keys) == 0 }, time.Second, time.Millisecond)
+		assert.NoError(t, writer.enqueue("b", []byte("2"), time.Minute))
+
+		err := writer.enqueue("c", []byte("3"), time.Minute)
+
+		assert.ErrorIs(t, err, ErrWriteQueueFull)
+		close(recorder.release)
+		assert.NoError(t, writer.flush(ctx))
+	})
+
+	t.Run("should stop waiting when the context is done", func(t *testing.T) {
+		recorder := &asyncWriteRecorder{release: make(chan struct{})}
+		writer := newAsyncWriter(10, recorder.write, func(error) {})
+		defer close(recorder.release)
+
+		assert.NoError(t, writer.enqueue("a", []byte("1"), time.Minute))
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := writer.flush(cancelled)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("should report the write errors", func(t *testing.T) {
+		recorder := &asyncWriteRecorder{err: fmt.Errorf("mock write error")}
+		var reported error
+		writer := newAsyncWriter(10, recorder.write, func(err error) { reported = err })
+
+		assert.NoError(t, writer.enqueue("a", []byte("1"), time.Minute))
+		assert.NoError(t, writer.flush(ctx))
+
+		assert.EqualError(t, reported, `writing key "a" asynchronously: mock write error`)
+	})
+
+	t.Run("should drain the queue and reject writes after close", func(t *testing.T) {
+		recorder := &asyncWriteRecorder{}
+		writer := newAsyncWriter(10, recorder.write, func(error) {})
+
+		assert.NoError(t, writer.enqueue("a", []byte("1"), time.Minute))
+		assert.NoError(t, writer.close(ctx))
+
+		assert.Equal(t, []string{"a=1"}, recorder.written(), "Expected the queued write")
+		assert.ErrorIs(t, writer.enqueue("b", []byte("2"), time.Minute), ErrCacheClosed)
+		assert.NoError(t, writer.close(ctx), "Expected closing twice to be a no-op")
+	})
+}
+
+func TestCache_SetAsync(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+		maxValueSize: 8,
+	}
+	setBytes := func(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+		return ch.SetBytes(ctx, key, value, ttl)
+	}
+	ch.async = newAsyncWriter(10, setBytes, func(error) {})
+
+	t.Run("should set the value in the background", func(t *testing.T) {
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.SetAsync(ctx, "key", "value", time.Minute)
+		assert.NoError(t, err, "Expected no error while queuing the write")
+
+		err = ch.FlushAsync(ctx)
+		assert.NoError(t, err, "Expected no error while flushing the writes")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should reject values larger than the max value size", func(t *testing.T) {
+		err := ch.SetAsync(ctx, "key", "a value too large", time.Minute)
+
+		assert.ErrorIs(t, err, ErrValueTooLarge)
+	})
+}
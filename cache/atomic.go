@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// GetSet sets a key-value pair and returns the previous value of the key in a single
+// transaction, so that no write is lost between the read and the write.
+// It can be used to rotate tokens or to read and reset counters.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - value: the new cache value
+//   - ttl: the time-to-live for the cache entry
+//
+// Returns:
+//   - string: the previous value, empty if the key did not exist or was expired
+//   - error: an error if the operation failed, ErrValueTooLarge if the value is too large
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	old, err := cache.GetSet(ctx, "token", newToken, time.Hour)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) GetSet(ctx context.Context, key, value string, ttl time.Duration) (string, error) {
+	if err := ch.checkKey(key); err != nil {
+		return "", err
+	}
+	if err := ch.checkValueSize(len(value)); err != nil {
+		return "", err
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	params := queries.UpsertCacheParams{
+		Key:            key,
+		Value:          []byte(value),
+		ExpiresAt:      ch.expiresAt(now, ttl),
+		LastAccessedAt: now,
+		Ttl:            int64(ttl),
+	}
+	params.Checksum = ch.checksum(params.Value)
+
+	var old []byte
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		queriesWithTx, err := lockCache(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		row, err := queriesWithTx.GetValue(ctx, queries.GetValueParams{Key: key, ExpiresAt: now})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("getting key %q: %w", key, err)
+		}
+		if err := ch.matchChecksum(key, row.Value, row.Checksum); err != nil {
+			return err
+		}
+		old = row.Value
+
+		if err := queriesWithTx.UpsertCache(ctx, params); err != nil {
+			return fmt.Errorf("setting key %q: %w", key, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		ch.logCorruptValue(ctx, key, err)
+		return "", fmt.Errorf("swapping value: %w", err)
+	}
+	ch.memory.set(key, params.Value, params.ExpiresAt)
+	ch.metrics.sets.Add(1)
+	ch.hooks.set(ctx, key)
+
+	return string(old), nil
+}
+
+// GetSet sets a key-value pair in the namespace and returns its previous value.
+func (ns *namespace) GetSet(
+	ctx context.Context,
+	key, value string,
+	ttl time.Duration,
+) (string, error) {
+	return ns.cache.GetSet(ctx, ns.key(key), value, ns.ttl(ttl))
+}
+
+// UpdateFunc computes the new value of a key from its current value.
+// exists is false if the key does not exist or is expired, in which case old is nil.
+// It returns the new value and its time-to-live; an error aborts the update.
+type UpdateFunc func(old []byte, exists bool) (value []byte, ttl time.Duration, err error)
+
+// Update replaces the value of a key with the value computed by fn from its current
+// value, in a single transaction. Concurrent updates of the cache are serialized by
+// SQLite, so no update is lost, even across processes sharing the database file.
+// fn may be called while other writers wait, so it must be fast and must not use the cache.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - fn: the function that computes the new value
+//
+// Returns:
+//   - error: an error if the operation failed, the error of fn if it failed,
+//     ErrValueTooLarge if the new value is too large
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	incr := func(old []byte, exists bool) ([]byte, time.Duration, error) {
+//		visits := 0
+//		if exists {
+//			visits, _ = strconv.Atoi(string(old))
+//		}
+//		return []byte(strconv.Itoa(visits + 1)), time.Hour, nil
+//	}
+//
+//	err = cache.Update(ctx, "visits", incr)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Update(ctx context.Context, key string, fn UpdateFunc) error {
+	if err := ch.checkKey(key); err != nil {
+		return err
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	params := queries.UpsertCacheParams{
+		Key:            key,
+		LastAccessedAt: now,
+	}
+
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		queriesWithTx, err := lockCache(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		old, err := queriesWithTx.GetValue(ctx, queries.GetValueParams{Key: key, ExpiresAt: now})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("getting key %q: %w", key, err)
+		}
+		exists := err == nil
+		if err := ch.matchChecksum(key, old.Value, old.Checksum); err != nil {
+			return err
+		}
+
+		value, ttl, err := fn(old.Value, exists)
+		if err != nil {
+			return err
+		}
+		if err := ch.checkValueSize(len(value)); err != nil {
+			return err
+		}
+
+		params.Value = value
+		params.ExpiresAt = ch.expiresAt(now, ttl)
+		params.Ttl = int64(ttl)
+		params.Checksum = ch.checksum(value)
+		if err := queriesWithTx.UpsertCache(ctx, params); err != nil {
+			return fmt.Errorf("setting key %q: %w", key, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		ch.logCorruptValue(ctx, key, err)
+		return fmt.Errorf("updating key: %w", err)
+	}
+	ch.memory.set(key, params.Value, params.ExpiresAt)
+	ch.metrics.sets.Add(1)
+	ch.hooks.set(ctx, key)
+
+	return nil
+}
+
+// Update replaces the value of a key of the namespace with the value computed by fn.
+func (ns *namespace) Update(ctx context.Context, key string, fn UpdateFunc) error {
+	withDefaultTTL := func(old []byte, exists bool) ([]byte, time.Duration, error) {
+		value, ttl, err := fn(old, exists)
+		return value, ns.ttl(ttl), err
+	}
+
+	return ns.cache.Update(ctx, ns.key(key), withDefaultTTL)
+}
+
+// lockCache takes the write lock of the database at the start of a transaction, so that
+// concurrent read-modify-write transactions wait for each other instead of failing when
+// they upgrade their read to a write.
+func lockCache(ctx context.Context, tx *sql.Tx) (*queries.Queries, error) {
+	queriesWithTx := queries.New(tx)
+	if err := queriesWithTx.LockCache(ctx); err != nil {
+		return nil, fmt.Errorf("locking cache: %w", err)
+	}
+
+	return queriesWithTx, nil
+}
@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+)
+
+func TestCache_GetSet(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	withTx := func(dbMock *dbMocks.DatabaseMock) {
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			})
+	}
+
+	newCache := func(dbMock *dbMocks.DatabaseMock) *cache {
+		return &cache{
+			Database: dbMock,
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+			maxValueSize: 8,
+		}
+	}
+
+	t.Run("should return the previous value and set the new one", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`UPDATE cache\s+SET key = key\s+WHERE 0`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT value, checksum\s+FROM cache`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow([]byte("old"), nil))
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("key", []byte("new"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		var set []string
+		ch := newCache(dbMock)
+		ch.hooks = Hooks{OnSet: func(_ context.Context, key string) { set = append(set, key) }}
+
+		old, err := ch.GetSet(ctx, "key", "new", time.Minute)
+
+		assert.NoError(t, err, "Expected no error while swapping the value")
+		assert.Equal(t, "old", old, "Expected the previous value")
+		assert.Equal(t, []string{"key"}, set, "Expected OnSet for the write")
+		assert.Equal(t, int64(1), ch.metrics.sets.Load(), "Expected the set to be counted")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should return an empty value when the key does not exist", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`UPDATE cache\s+SET key = key\s+WHERE 0`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT value, checksum\s+FROM cache`).
+			WithArgs("key", fixedTime).
+			WillReturnError(sql.ErrNoRows)
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("key", []byte("new"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		old, err := newCache(dbMock).GetSet(ctx, "key", "new", time.Minute)
+
+		assert.NoError(t, err, "Expected no error while swapping the value")
+		assert.Empty(t, old, "Expected no previous value")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should return error if the write fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`UPDATE cache\s+SET key = key\s+WHERE 0`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT value, checksum\s+FROM cache`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow([]byte("old"), nil))
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WillReturnError(fmt.Errorf("database is locked"))
+		sqlMock.ExpectRollback()
+
+		_, err := newCache(dbMock).GetSet(ctx, "key", "new", time.Minute)
+
+		assert.EqualError(t, err,
+			`swapping value: setting key "key": database is locked`)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should return ErrValueTooLarge if the value is too large", func(t *testing.T) {
+		ch := newCache(dbMocks.NewDatabaseMock(t))
+
+		_, err := ch.GetSet(ctx, "key", "too large value", time.Minute)
+
+		assert.ErrorIs(t, err, ErrValueTooLarge)
+	})
+}
+
+func TestCache_Update(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	withTx := func(dbMock *dbMocks.DatabaseMock) {
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			})
+	}
+
+	newCache := func(dbMock *dbMocks.DatabaseMock) *cache {
+		return &cache{
+			Database: dbMock,
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+			maxValueSize: 8,
+		}
+	}
+
+	expectRead := func(value []byte, err error) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`UPDATE cache\s+SET key = key\s+WHERE 0`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		query := sqlMock.ExpectQuery(`SELECT value, checksum\s+FROM cache`).WithArgs("key", fixedTime)
+		if err != nil {
+			query.WillReturnError(err)
+			return
+		}
+		query.WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow(value, nil))
+	}
+
+	t.Run("should store the value computed from the current value", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		expectRead([]byte("1"), nil)
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("key", []byte("12"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		var set []string
+		ch := newCache(dbMock)
+		ch.hooks = Hooks{OnSet: func(_ context.Context, key string) { set = append(set, key) }}
+
+		err := ch.Update(ctx, "key", func(old []byte, exists bool) ([]byte, time.Duration, error) {
+			assert.True(t, exists, "Expected the key to exist")
+			return append(old, '2'), time.Minute, nil
+		})
+
+		assert.NoError(t, err, "Expected no error while updating the key")
+		assert.Equal(t, []string{"key"}, set, "Expected OnSet for the write")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should report a missing key to the function", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		expectRead(nil, sql.ErrNoRows)
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("key", []byte("1"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		err := newCache(dbMock).Update(ctx, "key",
+			func(old []byte, exists bool) ([]byte, time.Duration, error) {
+				assert.False(t, exists, "Expected the key not to exist")
+				assert.Nil(t, old, "Expected no current value")
+				return []byte("1"), time.Minute, nil
+			})
+
+		assert.NoError(t, err, "Expected no error while updating the key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should abort the update if the function fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+		errInvalid := fmt.Errorf("invalid value")
+
+		expectRead([]byte("x"), nil)
+		sqlMock.ExpectRollback()
+
+		err := newCache(dbMock).Update(ctx, "key",
+			func([]byte, bool) ([]byte, time.Duration, error) {
+				return nil, 0, errInvalid
+			})
+
+		assert.ErrorIs(t, err, errInvalid, "Expected the error of the function")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should abort the update if the new value is too large", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		expectRead([]byte("x"), nil)
+		sqlMock.ExpectRollback()
+
+		err := newCache(dbMock).Update(ctx, "key",
+			func([]byte, bool) ([]byte, time.Duration, error) {
+				return []byte("too large value"), time.Minute, nil
+			})
+
+		assert.ErrorIs(t, err, ErrValueTooLarge)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// batchOp is an operation collected by a batch.
+type batchOp struct {
+	value []byte
+	key   string
+	ttl   time.Duration
+	del   bool
+}
+
+// Batch collects Set and Del operations and applies them in a single transaction,
+// so that bulk refreshes do not pay one transaction per entry.
+// The operations are applied in the order they were added when Exec is called;
+// either all of them are stored or none is.
+//
+// A Batch is not safe for concurrent use.
+type Batch struct {
+	cache  *cache
+	prefix string
+	ops    []batchOp
+
+	// defaultTTL replaces the zero TTLs of the writes, 0 means none
+	defaultTTL time.Duration
+}
+
+// Batch returns an empty batch of operations on the cache.
+//
+// Returns:
+//   - *Batch: the batch
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err = cache.Batch().
+//		Set("user:1", "John", time.Hour).
+//		Set("user:2", "Jane", time.Hour).
+//		Del("user:3").
+//		Exec(ctx)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Batch() *Batch {
+	return &Batch{cache: ch}
+}
+
+// Batch returns an empty batch of operations on the namespace.
+func (ns *namespace) Batch() *Batch {
+	return &Batch{cache: ns.cache, prefix: ns.prefix, defaultTTL: ns.ttl(0)}
+}
+
+// Set adds a write of a key-value pair with the given TTL to the batch.
+func (b *Batch) Set(key, value string, ttl time.Duration) *Batch {
+	if ttl == 0 {
+		ttl = b.defaultTTL
+	}
+	b.ops = append(b.ops, batchOp{key: b.prefix + key, value: []byte(value), ttl: ttl})
+	return b
+}
+
+// Del adds a delete of a key to the batch.
+func (b *Batch) Del(key string) *Batch {
+	b.ops = append(b.ops, batchOp{key: b.prefix + key, del: true})
+	return b
+}
+
+// Len returns the number of operations in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Exec applies the operations of the batch in a single transaction and empties the batch.
+// If an operation fails, the transaction is rolled back and the batch is kept.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the operation failed, ErrValueTooLarge if a value is too large
+func (b *Batch) Exec(ctx context.Context) error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+
+	ch := b.cache
+	for _, op := range b.ops {
+		if op.del {
+			continue
+		}
+		if err := ch.checkKey(op.key); err != nil {
+			return err
+		}
+		if err := ch.checkValueSize(len(op.value)); err != nil {
+			return err
+		}
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		queriesWithTx := queries.New(tx)
+		for _, op := range b.ops {
+			if op.del {
+				if err := ch.deleteKey(ctx, queriesWithTx, op.key); err != nil {
+					return fmt.Errorf("deleting key %q: %w", op.key, err)
+				}
+				continue
+			}
+
+			params := queries.UpsertCacheParams{
+				Key:            op.key,
+				Value:          op.value,
+				ExpiresAt:      ch.expiresAt(now, op.ttl),
+				LastAccessedAt: now,
+				Ttl:            int64(op.ttl),
+				Checksum:       ch.checksum(op.value),
+			}
+			if err := queriesWithTx.UpsertCache(ctx, params); err != nil {
+				return fmt.Errorf("setting key %q: %w", op.key, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("executing batch: %w", err)
+	}
+
+	for _, op := range b.ops {
+		if op.del {
+			ch.memory.del(op.key)
+			ch.metrics.deletes.Add(1)
+			ch.hooks.delete(ctx, op.key)
+			continue
+		}
+
+		ch.memory.set(op.key, op.value, ch.expiresAt(now, op.ttl))
+		ch.metrics.sets.Add(1)
+		ch.hooks.set(ctx, op.key)
+	}
+	b.ops = nil
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+)
+
+func TestCache_Batch(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	withTx := func(dbMock *dbMocks.DatabaseMock) {
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			})
+	}
+
+	newCache := func(dbMock *dbMocks.DatabaseMock) *cache {
+		return &cache{
+			Database: dbMock,
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+			maxValueSize: 8,
+		}
+	}
+
+	t.Run("should apply the operations in a single transaction", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("a", []byte("1"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE key = \?`).
+			WithArgs("b").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		var set, deleted []string
+		ch := newCache(dbMock)
+		ch.hooks = Hooks{
+			OnSet:    func(_ context.Context, key string) { set = append(set, key) },
+			OnDelete: func(_ context.Context, key string) { deleted = append(deleted, key) },
+		}
+
+		batch := ch.Batch().Set("a", "1", time.Minute).Del("b")
+		assert.Equal(t, 2, batch.Len(), "Expected two operations in the batch")
+
+		err := batch.Exec(ctx)
+
+		assert.NoError(t, err, "Expected no error while executing the batch")
+		assert.Zero(t, batch.Len(), "Expected the batch to be emptied")
+		assert.Equal(t, []string{"a"}, set, "Expected OnSet for the write")
+		assert.Equal(t, []string{"b"}, deleted, "Expected OnDelete for the delete")
+		assert.Equal(t, int64(1), ch.Metrics().Sets, "Expected one set")
+		assert.Equal(t, int64(1), ch.Metrics().Deletes, "Expected one delete")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should prefix the keys of a namespace", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("users:1", []byte("John"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		err := newCache(dbMock).Namespace("users").Batch().Set("1", "John", time.Minute).Exec(ctx)
+
+		assert.NoError(t, err, "Expected no error while executing the batch")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should roll back and keep the batch if an operation fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE key = \?`).
+			WithArgs("b").
+			WillReturnError(fmt.Errorf("mock delete error"))
+		sqlMock.ExpectRollback()
+
+		batch := newCache(dbMock).Batch().Set("a", "1", time.Minute).Del("b")
+
+		err := batch.Exec(ctx)
+
+		assert.EqualError(t, err, `executing batch: deleting key "b": mock delete error`)
+		assert.Equal(t, 2, batch.Len(), "Expected the batch to be kept")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should reject values larger than the max value size", func(t *testing.T) {
+		batch := newCache(dbMocks.NewDatabaseMock(t)).Batch().Set("a", "a value too large", 0)
+
+		err := batch.Exec(ctx)
+
+		assert.ErrorIs(t, err, ErrValueTooLarge)
+	})
+
+	t.Run("should do nothing for an empty batch", func(t *testing.T) {
+		err := newCache(dbMocks.NewDatabaseMock(t)).Batch().Exec(ctx)
+
+		assert.NoError(t, err, "Expected no error for an empty batch")
+	})
+}
@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	"github.com/lucasvillarinho/litepack/database"
+	"github.com/lucasvillarinho/litepack/retry"
+)
+
+// SetB sets a key-value pair in the cache with the given TTL, storing the key as a BLOB.
+// It is meant for binary keys, such as raw SHA-256 digests, that would otherwise need
+// to be hex encoded, doubling the size of the keys and of their index.
+//
+// Binary keys never match the string keys, even with the same bytes: SetB(ctx,
+// []byte("a"), ...) and Set(ctx, "a", ...) store two entries. The entries are purged
+// and evicted like the others, but they bypass the memory tier and the hooks, and the
+// key pattern of the cache doesn't apply to them.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the binary cache key
+//   - value: the cache value
+//   - ttl: the time-to-live for the cache entry, 0 or less for an entry that never expires
+//
+// Returns:
+//   - error: an error if the operation failed, ErrInvalidKey if the key is empty or
+//     exceeds the max key length
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	digest := sha256.Sum256(content)
+//	err = cache.SetB(ctx, digest[:], thumbnail, time.Hour)
+func (ch *cache) SetB(ctx context.Context, key []byte, value []byte, ttl time.Duration) error {
+	if err := ch.checkBlobKey(key); err != nil {
+		return err
+	}
+	if err := ch.checkValueSize(len(value)); err != nil {
+		return err
+	}
+
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	attempt := 0
+	maxAttempts := 2
+
+	setFunc := func() error {
+		attempt++
+		now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+		params := queries.UpsertCacheByBlobKeyParams{
+			Key:            key,
+			Value:          value,
+			ExpiresAt:      ch.expiresAt(now, ttl),
+			LastAccessedAt: now,
+			Ttl:            int64(ttl),
+			Checksum:       ch.checksum(value),
+		}
+
+		if err := ch.queries.UpsertCacheByBlobKey(ctx, params); err != nil {
+			// If the database is full, purge the cache and try again.
+			if database.IsDBFullError(err) && attempt < maxAttempts {
+				if purgeErr := ch.purgeItens(ctx); purgeErr != nil {
+					return fmt.Errorf("error purging cache: %w", purgeErr)
+				}
+			}
+			return fmt.Errorf("error setting cache: %w", err)
+		}
+
+		return nil
+	}
+
+	err := ch.retryBusy(ctx, func() error {
+		attempt = 0
+		return retry.Do(
+			ctx,
+			setFunc,
+			retry.WithMaxAttempts(maxAttempts),
+			retry.WithBackoff(0, 0),
+			retry.WithRetryIf(database.IsDBFullError),
+		)
+	})
+	if err != nil {
+		return err
+	}
+	ch.metrics.sets.Add(1)
+
+	return nil
+}
+
+// GetB retrieves a value from the cache by a binary key set with SetB.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the binary cache key
+//
+// Returns:
+//   - []byte: the cache value
+//   - error: an error if the operation failed, ErrKeyExpired if the entry expired,
+//     ErrKeyNotFound if the key does not exist,
+//     ErrCorruptValue if the value does not match its checksum,
+//     ErrInvalidKey if the key is empty or exceeds the max key length
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	digest := sha256.Sum256(content)
+//	thumbnail, err := cache.GetB(ctx, digest[:])
+func (ch *cache) GetB(ctx context.Context, key []byte) ([]byte, error) {
+	if err := ch.checkBlobKey(key); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	row, err := ch.reads().GetEntryByBlobKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ch.metrics.misses.Add(1)
+			return nil, ErrKeyNotFound
+		}
+
+		return nil, fmt.Errorf("error getting value: %w", err)
+	}
+	if !row.ExpiresAt.After(now) {
+		ch.metrics.misses.Add(1)
+		return nil, ErrKeyExpired
+	}
+	if err := ch.verifyChecksum(ctx, hex.EncodeToString(key), row.Value, row.Checksum); err != nil {
+		return nil, err
+	}
+	ch.metrics.hits.Add(1)
+
+	// Skip the access time update while the cache is quiesced.
+	if !ch.writeMu.TryRLock() {
+		return row.Value, nil
+	}
+	defer ch.writeMu.RUnlock()
+
+	err = ch.queries.UpdateLastAccessedAtByBlobKey(ctx, queries.UpdateLastAccessedAtByBlobKeyParams{
+		LastAccessedAt: now,
+		Key:            key,
+	})
+	if err != nil {
+		ch.logger.Error(ctx, fmt.Sprintf("error updating last accessed at: %v", err))
+	}
+
+	return row.Value, nil
+}
+
+// DelB deletes a key-value pair set with SetB from the cache.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the binary cache key
+//
+// Returns:
+//   - error: an error if the operation failed, ErrInvalidKey if the key is empty or
+//     exceeds the max key length
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err = cache.DelB(ctx, digest[:])
+func (ch *cache) DelB(ctx context.Context, key []byte) error {
+	if err := ch.checkBlobKey(key); err != nil {
+		return err
+	}
+
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	err := ch.retryBusy(ctx, func() error {
+		return ch.queries.DeleteKeyByBlobKey(ctx, key)
+	})
+	if err != nil {
+		return fmt.Errorf("deleting key: %w", err)
+	}
+	ch.metrics.deletes.Add(1)
+
+	return nil
+}
+
+// checkBlobKey returns ErrInvalidKey if the binary key is empty or exceeds the max key
+// length of the cache.
+func (ch *cache) checkBlobKey(key []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("%w: empty key", ErrInvalidKey)
+	}
+
+	return ch.checkKeyLength(len(key))
+}
+
+// SetB sets a binary key-value pair in the namespace with the given TTL.
+func (ns *namespace) SetB(ctx context.Context, key []byte, value []byte, ttl time.Duration) error {
+	return ns.cache.SetB(ctx, ns.blobKey(key), value, ns.ttl(ttl))
+}
+
+// GetB retrieves a value from the namespace by binary key.
+func (ns *namespace) GetB(ctx context.Context, key []byte) ([]byte, error) {
+	return ns.cache.GetB(ctx, ns.blobKey(key))
+}
+
+// DelB deletes a binary key-value pair from the namespace.
+func (ns *namespace) DelB(ctx context.Context, key []byte) error {
+	return ns.cache.DelB(ctx, ns.blobKey(key))
+}
+
+// blobKey returns the stored key of a binary key of the namespace.
+func (ns *namespace) blobKey(key []byte) []byte {
+	return append([]byte(ns.prefix), key...)
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_BlobKeys(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+	ch := &cache{
+		queries:      queries.New(db),
+		maxKeyLength: 32,
+		timeSource: timeSource{
+			Timezone: time.UTC,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+	key := []byte{0xde, 0xad, 0x00, 0xbe, 0xef}
+	columns := []string{"value", "checksum", "expires_at"}
+
+	t.Run("should store the key as a blob", func(t *testing.T) {
+		sqlMock.ExpectExec(`VALUES \(CAST\(\? AS BLOB\), \?, \?, \?, \?, \?, \?4\)`).
+			WithArgs(key, []byte("value"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.SetB(ctx, key, []byte("value"), time.Hour)
+
+		assert.NoError(t, err, "Expected no error while setting a binary key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return the value of a live entry and record the access", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, expires_at FROM cache WHERE key = CAST\(\? AS BLOB\)`).
+			WithArgs(key).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow([]byte("value"), nil, fixedTime.Add(time.Hour)))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?`).
+			WithArgs(fixedTime, key).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		value, err := ch.GetB(ctx, key)
+
+		assert.NoError(t, err, "Expected no error while getting a binary key")
+		assert.Equal(t, []byte("value"), value)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrKeyExpired for an expired entry", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, expires_at FROM cache WHERE key = CAST\(\? AS BLOB\)`).
+			WithArgs(key).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow([]byte("value"), nil, fixedTime))
+
+		_, err := ch.GetB(ctx, key)
+
+		assert.ErrorIs(t, err, ErrKeyExpired, "Expected ErrKeyExpired for an expired entry")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrKeyNotFound for a missing key", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, expires_at FROM cache WHERE key = CAST\(\? AS BLOB\)`).
+			WithArgs(key).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := ch.GetB(ctx, key)
+
+		assert.ErrorIs(t, err, ErrKeyNotFound, "Expected ErrKeyNotFound for a missing key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should delete the entry of the key", func(t *testing.T) {
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE key = CAST\(\? AS BLOB\)`).
+			WithArgs(key).
+			WillReturnError(fmt.Errorf("mock delete error"))
+
+		err := ch.DelB(ctx, key)
+
+		assert.EqualError(t, err, "deleting key: mock delete error")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrInvalidKey for empty or long keys", func(t *testing.T) {
+		for _, invalid := range [][]byte{nil, make([]byte, 33)} {
+			err := ch.SetB(ctx, invalid, []byte("value"), time.Hour)
+			assert.ErrorIs(t, err, ErrInvalidKey, "Expected ErrInvalidKey for %x", invalid)
+
+			_, err = ch.GetB(ctx, invalid)
+			assert.ErrorIs(t, err, ErrInvalidKey, "Expected ErrInvalidKey for %x", invalid)
+		}
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
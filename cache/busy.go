@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/database"
+	"github.com/lucasvillarinho/litepack/retry"
+)
+
+// busyAttempts is the number of attempts of a write that fails because another
+// connection or process holds the lock of the database.
+const busyAttempts = 5
+
+// retryBusy runs fn and retries it with backoff while it fails because the database
+// is locked, for example by another process that shares the cache file.
+func (ch *cache) retryBusy(ctx context.Context, fn func() error) error {
+	return retry.Do(
+		ctx,
+		fn,
+		retry.WithMaxAttempts(busyAttempts),
+		retry.WithBackoff(10*time.Millisecond, 500*time.Millisecond),
+		retry.WithRetryIf(database.IsBusyError),
+	)
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_RetryBusy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ch := &cache{
+		timeSource: timeSource{
+			Timezone: time.UTC,
+			Now:      time.Now,
+		},
+		queries: queries.New(db),
+	}
+
+	t.Run("Should retry a delete while the database is locked", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM cache WHERE key = ?`).
+			WithArgs("key").
+			WillReturnError(fmt.Errorf("database is locked"))
+		mock.ExpectExec(`DELETE FROM cache WHERE key = ?`).
+			WithArgs("key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.Del(context.Background(), "key")
+
+		assert.NoError(t, err, "Expected the delete to succeed once the lock is released")
+		assert.NoError(t, mock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should give up after the max attempts", func(t *testing.T) {
+		for range busyAttempts {
+			mock.ExpectExec(`DELETE FROM cache WHERE key = ?`).
+				WithArgs("key").
+				WillReturnError(fmt.Errorf("database is locked"))
+		}
+
+		err := ch.Del(context.Background(), "key")
+
+		assert.EqualError(t, err, "deleting key: database is locked")
+		assert.NoError(t, mock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should not retry other errors", func(t *testing.T) {
+		mock.ExpectQuery(`DELETE FROM cache WHERE expires_at <= \? AND pinned = 0 RETURNING key`).
+			WillReturnError(fmt.Errorf("disk I/O error"))
+
+		err := ch.PurgeExpiredItems(context.Background())
+
+		assert.EqualError(t, err, "purging expired cache: disk I/O error")
+		assert.NoError(t, mock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -3,16 +3,28 @@ package cache
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	crf "github.com/robfig/cron/v3"
+	"golang.org/x/sync/singleflight"
 
+	"github.com/lucasvillarinho/litepack"
 	"github.com/lucasvillarinho/litepack/cache/queries"
 	"github.com/lucasvillarinho/litepack/database"
 	"github.com/lucasvillarinho/litepack/internal/cron"
-	"github.com/lucasvillarinho/litepack/internal/helpers"
+	"github.com/lucasvillarinho/litepack/internal/eventlog"
 	"github.com/lucasvillarinho/litepack/internal/log"
+	"github.com/lucasvillarinho/litepack/retry"
 )
 
 // timeSource is used to get the current time.
@@ -24,18 +36,158 @@ type timeSource struct {
 // ErrKeyNotFound is returned when a key is not found in the cache.
 var ErrKeyNotFound = fmt.Errorf("key not found")
 
+// ErrKeyExpired is returned when a key is read after its entry expired and before the
+// entry was purged. It wraps ErrKeyNotFound, so errors.Is(err, ErrKeyNotFound) is also
+// true for expired keys.
+var ErrKeyExpired = fmt.Errorf("key expired: %w", ErrKeyNotFound)
+
+// ErrVersionMismatch is returned when a versioned write finds the entry changed since it was read.
+var ErrVersionMismatch = fmt.Errorf("version mismatch")
+
+// ErrValueTooLarge is returned when a value exceeds the max value size of the cache.
+var ErrValueTooLarge = fmt.Errorf("value too large")
+
+// ErrInvalidKey is returned when a key is empty, longer than the max key length or
+// doesn't match the key pattern of the cache.
+var ErrInvalidKey = fmt.Errorf("invalid key")
+
+// ErrInvalidOption is returned by NewCache when an option has a value out of its range.
+var ErrInvalidOption = fmt.Errorf("invalid option")
+
+// ErrDependenciesDisabled is returned by SetDerived when the cache was created without
+// WithDependencies.
+var ErrDependenciesDisabled = fmt.Errorf("dependencies disabled")
+
+// ErrWriteQueueFull is returned by SetAsync when the async write queue is full.
+var ErrWriteQueueFull = fmt.Errorf("write queue full")
+
+// ErrCacheClosed is returned when the cache is used after it was closed.
+var ErrCacheClosed = fmt.Errorf("cache is closed")
+
+// ErrCorruptValue is returned when a value read from the database doesn't match the
+// checksum it was stored with, see WithChecksums.
+var ErrCorruptValue = fmt.Errorf("corrupt value")
+
+// NoTTL is the TTL reported for entries that never expire. Writes with a TTL of 0 or
+// less, such as NoTTL, store entries that never expire.
+const NoTTL time.Duration = -1
+
+// neverExpires is the expiration time stored for entries that never expire.
+var neverExpires = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
 // cache is a simple key-value store backed by an SQLite database.
 type cache struct {
 	timeSource timeSource
 	cron       cron.Cron
 	database.Database
-	logger log.Logger
+
+	// sharedCron is set if the scheduler was given by WithScheduler: the cache removes
+	// its tasks from the scheduler instead of stopping it
+	sharedCron bool
+	// taskIDs are the entries of the tasks the cache added to the scheduler
+	taskIDs []crf.EntryID
+	tasksMu sync.Mutex
+	// tasksPaused skips the runs of the tasks while the cache is quiesced
+	tasksPaused atomic.Bool
+
+	logger Logger
+	codec  Codec
 
 	// purge configuration, puging is used to delete cache entries
 	purgePercent float64
 	purgeTimeout time.Duration
+
+	// busyTimeout is how long a connection waits for the lock of the database
+	busyTimeout time.Duration
+
+	// synchronous is how often SQLite waits for the writes to reach the disk,
+	// empty for the default of the driver
+	synchronous database.SynchronousMode
+
+	// mmapSize is the max number of bytes of the database mapped in memory by each
+	// connection, 0 if memory-mapped I/O is disabled
+	mmapSize int
+
+	// queryHooks are called after each query run on the database
+	queryHooks []database.QueryHook
+
+	// dsnParams are the query parameters of the DSN of the database
+	dsnParams url.Values
+
 	syncInterval cron.Interval
 
+	// purgeSchedule is the schedule of the purge that runs above purgeWatermark,
+	// the fraction of the max database size in use; 0 disables it
+	purgeSchedule  cron.Interval
+	purgeWatermark float64
+
+	// optimizeSchedule is the schedule of the update of the query planner statistics,
+	// empty if disabled
+	optimizeSchedule cron.Interval
+
+	// incrementalVacuumPages is the number of pages freed by each incremental vacuum step,
+	// 0 means purges run a full vacuum
+	incrementalVacuumPages int
+
+	// opTimeout bounds the duration of Get, Set and Del, 0 means no timeout
+	opTimeout time.Duration
+
+	// deleteExpiredOnRead deletes the expired entries found by reads
+	deleteExpiredOnRead bool
+
+	// slidingTTL pushes the expiration of the entries forward on each read
+	slidingTTL bool
+
+	// checksums stores a checksum with each value and verifies it on the reads
+	checksums bool
+
+	// evictionPolicy selects the entries deleted when the database is full
+	evictionPolicy EvictionPolicy
+
+	// hooks are called when the entries of the cache change
+	hooks Hooks
+
+	// events records the changes of the entries, nil if the event log is disabled;
+	// eventRetention is how long the events are kept
+	events         eventlog.EventLog
+	eventRetention time.Duration
+
+	// tombstoneRetention is how long the tombstones of the deleted keys are kept,
+	// 0 if the deleted keys are removed immediately
+	tombstoneRetention time.Duration
+
+	// dependencies cascades the writes and deletes of the keys to the keys derived
+	// from them
+	dependencies bool
+
+	// maxValueSize is the max size of a value in bytes, 0 means unlimited
+	maxValueSize int
+
+	// maxKeyLength is the max length of a key in bytes, 0 means unlimited;
+	// keyPattern restricts the characters of the keys, if set
+	maxKeyLength int
+	keyPattern   *regexp.Regexp
+
+	// keyPrefix is prepended to the keys of every operation, if set
+	keyPrefix string
+
+	// maxCacheBytes is the budget of bytes stored by the values, 0 means unlimited
+	maxCacheBytes int
+
+	// namespacePolicies override the configuration for the keys of the namespaces,
+	// by namespace prefix
+	namespacePolicies map[string]namespacePolicy
+	namespacesMu      sync.RWMutex
+
+	// observer receives the operations of the cache, if set
+	observer Observer
+
+	// manager closes the cache with the other stores of the application, if set
+	manager *litepack.Manager
+
+	// budgetPurge schedules the purge that keeps the stored bytes under the budgets once
+	budgetPurge sync.Once
+
 	// database configuration
 	path      string
 	dbName    string
@@ -43,13 +195,107 @@ type cache struct {
 	pageSize  int
 	maxDBSize int
 	queries   *queries.Queries
+
+	// readQueries run the reads on the read pool, if enabled by WithReadPool
+	readQueries  *queries.Queries
+	readPoolSize int
+
+	// purge counters, reported by Stats
+	purgeCounters purgeCounters
+
+	// operation counters, reported by Metrics
+	metrics metrics
+
+	// loads deduplicates concurrent loader calls for the same key
+	loads singleflight.Group
+
+	// gets deduplicates concurrent database reads of the same key
+	gets singleflight.Group
+
+	// memory is the in-memory tier consulted before the database, nil if disabled
+	memory *memoryTier
+
+	// access buffers the access times of reads, nil if they are written on each read
+	access *accessBuffer
+
+	// warmup pre-populates the cache when it is created
+	warmup warmup
+
+	// async stores the writes of SetAsync in the background
+	async          *asyncWriter
+	asyncQueueSize int
+
+	// background is the context of the background jobs, cancelled when the cache is closed
+	background       context.Context
+	cancelBackground context.CancelFunc
+
+	// jobs tracks the goroutines started by the cache
+	jobs sync.WaitGroup
+
+	// writeMu is held for reading by every write and for writing while the cache is quiesced.
+	writeMu sync.RWMutex
 }
 
 // Cache is a simple key-value store backed by an SQLite database.
 type Cache interface {
-	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	Set(ctx context.Context, key string, value string, ttl time.Duration, opts ...SetOption) error
+	SetBytes(ctx context.Context, key string, value []byte, ttl time.Duration, opts ...SetOption) error
+	SetWithExpireAt(ctx context.Context, key, value string, expireAt time.Time) error
+	SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+	SetAsync(ctx context.Context, key string, value string, ttl time.Duration) error
+	FlushAsync(ctx context.Context) error
+	Append(ctx context.Context, key string, suffix string) error
 	Get(ctx context.Context, key string) (string, error)
+	GetBytes(ctx context.Context, key string) ([]byte, error)
+	GetStale(ctx context.Context, key string) (string, time.Duration, error)
+	GetEntry(ctx context.Context, key string) (Entry, error)
+	SetB(ctx context.Context, key []byte, value []byte, ttl time.Duration) error
+	GetB(ctx context.Context, key []byte) ([]byte, error)
+	DelB(ctx context.Context, key []byte) error
+	SetValue(ctx context.Context, key string, value any, ttl time.Duration) error
+	GetValue(ctx context.Context, key string, dest any) error
+	GetWithVersion(ctx context.Context, key string) (string, int64, error)
+	SetIfVersion(ctx context.Context, key, value string, version int64, ttl time.Duration) error
+	GetSet(ctx context.Context, key, value string, ttl time.Duration) (string, error)
+	Update(ctx context.Context, key string, fn UpdateFunc) error
+	GetTTL(ctx context.Context, key string) (time.Duration, error)
+	Persist(ctx context.Context, key string) error
+	Rename(ctx context.Context, oldKey, newKey string) error
+	DelPrefix(ctx context.Context, prefix string) (int64, error)
+	DelPattern(ctx context.Context, pattern string) (int64, error)
+	Pin(ctx context.Context, key string) error
+	Unpin(ctx context.Context, key string) error
+	SetDerived(ctx context.Context, key string, parents ...string) error
+	GetRange(ctx context.Context, key string, offset, length int) (string, error)
+	MGet(ctx context.Context, keys ...string) (map[string]string, error)
+	Prefetch(ctx context.Context, keys []string) error
 	Del(ctx context.Context, key string) error
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	Count(ctx context.Context) (int64, error)
+	Flush(ctx context.Context) error
+	PurgeExpiredItems(ctx context.Context) error
+	PurgeItens(ctx context.Context) (PurgeReport, error)
+	PurgePreview(ctx context.Context) (PurgeReport, error)
+	Batch() *Batch
+	Export(ctx context.Context, w io.Writer) error
+	Import(ctx context.Context, r io.Reader) error
+	GetOrSet(ctx context.Context, key string, ttl time.Duration, loader LoaderFunc) (string, error)
+	GetOrSetMulti(
+		ctx context.Context,
+		keys []string,
+		ttl time.Duration,
+		loader MultiLoaderFunc,
+	) (map[string]string, error)
+	Stats(ctx context.Context) (CacheStats, error)
+	SchedulerStats(ctx context.Context) []TaskStats
+	Metrics() Metrics
+	Quiesce(ctx context.Context) (resume func(), err error)
+	Snapshot(ctx context.Context, destPath string) error
+	Compact(ctx context.Context) (CompactStats, error)
+	Ping(ctx context.Context) error
+	Events(ctx context.Context, sinceID int64) ([]Event, error)
+	Tombstones(ctx context.Context, since time.Time) ([]Tombstone, error)
+	Namespace(name string, opts ...NamespaceOption) Cache
 	database.Database
 }
 
@@ -64,19 +310,59 @@ type Cache interface {
 //
 // Returns:
 //   - cache: the cache instance
-//   - error: an error if the operation failed
+//   - error: an error if the operation failed, ErrInvalidOption if an option is out
+//     of its range
 //
 // Configuration defaults:
 //   - syncInterval: 1 second
 //   - timezone: UTC
+//   - codec: JSON
+//   - evictionPolicy: LRU
+//   - asyncQueueSize: 1024
 //
 // Configuration options:
 //   - WithSyncInterval: sets a custom sync interval for the cache.
+//   - WithScheduler: sets the scheduler of the background jobs.
 //   - WithPath: sets the path to the cache database.
+//   - WithInMemory: creates the cache in memory.
 //   - WithTimezone: sets a custom timezone for the cache.
+//   - WithClock: sets the function that returns the current time.
 //   - WithPurgePercent: sets the percentage of cache entries to purge.
 //   - WithPurgeTimeout: sets the timeout for purging cache entries.
-//   - WithDBOptions: sets the database options.
+//   - WithEvictionPolicy: sets the policy that selects the entries to purge.
+//   - WithEvictionLFU: purges the least frequently used entries.
+//   - WithMaxDBSize: sets the max size of the database file.
+//   - WithPageSize: sets the page size of the database.
+//   - WithSQLiteCacheSize: sets the memory used by SQLite to cache pages.
+//   - WithCodec: sets the codec used by SetValue and GetValue.
+//   - WithMaxValueSize: sets the max size of a value.
+//   - WithMaxCacheBytes: sets the budget of bytes stored by the values.
+//   - WithMemoryTier: enables an in-memory tier in front of the database.
+//   - WithHooks: sets callbacks invoked when entries change.
+//   - WithAsyncQueueSize: sets the size of the SetAsync write queue.
+//   - WithBufferedAccessUpdates: buffers the access times of reads.
+//   - WithPurgeSchedule: purges the cache on a schedule when it crosses a high watermark.
+//   - WithOptimizeSchedule: sets the schedule of the update of the query planner statistics.
+//   - WithAutoVacuumIncremental: frees pages incrementally instead of a full vacuum.
+//   - WithOpTimeout: sets the timeout of Get, Set and Del.
+//   - WithBusyTimeout: sets how long a connection waits for the lock of the database.
+//   - WithSynchronous: sets how often SQLite waits for the writes to reach the disk.
+//   - WithMmapSize: sets the bytes of the database mapped in memory.
+//   - WithQueryHook: sets a function called after each query run on the database.
+//   - WithReadPool: runs the reads on a pool of read-only connections.
+//   - WithWarmup: sets a function that pre-populates the cache.
+//   - WithAsyncWarmup: sets a function that pre-populates the cache in the background.
+//   - WithLogger: sets the logger of the errors of the background jobs.
+//   - WithDeleteExpiredOnRead: deletes the expired entries found by reads.
+//   - WithEventLog: records the changes of the entries, see Events.
+//   - WithSlidingTTL: pushes the expiration of the entries forward on each read.
+//   - WithInstrumentation: reports the operations of the cache to an observer.
+//   - WithChecksums: stores a checksum with each value and verifies it on the reads.
+//   - WithMaxKeyLength: sets the max length of a key in bytes.
+//   - WithKeyPattern: restricts the keys to the ones matching a pattern.
+//   - WithKeyPrefix: prepends a prefix to the keys of every operation.
+//   - WithDependencies: deletes the keys derived from a key when it changes, see SetDerived.
+//   - WithTombstones: keeps a tombstone of the deleted keys, see Tombstones.
 //
 // Example:
 //
@@ -88,6 +374,7 @@ func NewCache(ctx context.Context, opts ...Option) (Cache, error) {
 	c := &cache{
 		purgePercent: 0.2,              // 20%
 		purgeTimeout: 30 * time.Second, // 30 seconds
+		busyTimeout:  5 * time.Second,  // 5 seconds
 		dbName:       "lpack_cache.db",
 		cacheSize:    64 * 1024 * 1024,  // 64 MB
 		pageSize:     4096,              // 4 KB
@@ -96,44 +383,151 @@ func NewCache(ctx context.Context, opts ...Option) (Cache, error) {
 			Timezone: time.UTC,
 			Now:      time.Now,
 		},
-		syncInterval: cron.EveryMinute,
-		cron:         cron.New(time.UTC),
+		syncInterval:     cron.EveryMinute,
+		optimizeSchedule: cron.EveryHour,
+		cron:             cron.New(time.UTC),
+		codec:            JSONCodec{},
+		evictionPolicy:   LRUPolicy{},
+		asyncQueueSize:   1024,
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
-	/// database is used to store cache entries
-	cacheDB, err := database.NewDatabase(ctx, c.path, c.dbName)
-	if err != nil {
+	// fail before creating the database if an option is out of its range
+	if err := c.validate(); err != nil {
 		return nil, err
 	}
-	c.Database = cacheDB
 
-	// logger is used to log errors when setting cache entries
-	logger, err := log.NewLogger(ctx, c.Database)
+	// background jobs outlive ctx, they are stopped by Close
+	c.background, c.cancelBackground = context.WithCancel(context.WithoutCancel(ctx))
+
+	/// database is used to store cache entries, every connection waits for the lock
+	// of the database held by other processes instead of failing
+	dbOpts := []database.Option{
+		database.WithBusyTimeout(c.busyTimeout),
+		database.WithSynchronous(c.synchronous),
+		database.WithMmapSize(c.mmapSize),
+	}
+	for _, hook := range c.queryHooks {
+		dbOpts = append(dbOpts, database.WithQueryHook(hook))
+	}
+	for key, values := range c.dsnParams {
+		for _, value := range values {
+			dbOpts = append(dbOpts, database.WithDSNParam(key, value))
+		}
+	}
+	cacheDB, err := database.NewDatabase(ctx, c.path, c.dbName, dbOpts...)
 	if err != nil {
-		return nil, fmt.Errorf("error creating logger: %w", err)
+		return nil, err
 	}
-	c.logger = logger
+	c.Database = cacheDB
 
-	// create database if it does not exist and apply database options
+	// apply the database options, before any table is created since the page
+	// size and the auto vacuum mode can only be changed on an empty database
 	err = c.setupCacheDatabase(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error setting up cache: %w", err)
 	}
 
+	// logger is used to log errors when setting cache entries,
+	// the errors are stored in the log table unless a logger is given
+	if c.logger == nil {
+		logger, err := log.NewLogger(ctx, c.Database)
+		if err != nil {
+			return nil, fmt.Errorf("error creating logger: %w", err)
+		}
+		c.logger = logger
+	}
+
+	// event log records the changes of the entries, if enabled
+	err = c.setupEventLog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error creating event log: %w", err)
+	}
+
+	// dependencies delete the keys derived from the changed keys, if enabled
+	c.setupDependencies()
+
+	// async stores the writes of SetAsync, its errors are logged
+	setAsync := func(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+		return c.SetBytes(ctx, key, value, ttl)
+	}
+	c.async = newAsyncWriter(c.asyncQueueSize, setAsync, func(err error) {
+		c.logger.Error(context.Background(), err.Error())
+	})
+
 	// create cache table if it does not exist and apply indexes
 	err = c.setupCacheTable(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error setting up cache queries: %w", err)
 	}
 
+	// prepare the cache queries, so that their SQL is parsed once
+	err = c.prepareQueries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up cache queries: %w", err)
+	}
+
+	// open the read-only connections of the reads, if enabled
+	err = c.setupReadPool(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up read pool: %w", err)
+	}
+
+	// schedule the purge that keeps the stored bytes under the budget
+	c.purgeOverBudgetCache(c.background)
+
+	// schedule the purge that runs when the database crosses the high watermark
+	c.purgeOverWatermarkCache(c.background)
+
+	// schedule the incremental vacuum that frees the pages of deleted entries
+	c.incrementalVacuumCache(c.background)
+
+	// schedule the update of the query planner statistics
+	c.optimizeCache(c.background)
+
+	// schedule the flush of the buffered access times
+	c.flushAccessCache(c.background)
+
+	// schedule the deletion of the events older than the retention
+	c.trimEventsCache(c.background)
+
+	// schedule the deletion of the tombstones older than the retention
+	c.purgeTombstonesCache(c.background)
+
 	// start the cron job to clear expired cache items
-	go c.purgeExpiredItensCache(ctx)
+	c.jobs.Add(1)
+	go func() {
+		defer c.jobs.Done()
+		c.purgeExpiredItensCache(c.background)
+	}()
+
+	// track the cache so it can be closed with the other stores of the application
+	if c.manager != nil {
+		c.manager.Register(filepath.Join(c.path, c.dbName), c)
+	}
 
-	return c, nil
+	// isolate the keys of the instance behind its prefix, if any
+	var view Cache = c
+	if c.keyPrefix != "" {
+		view = &namespace{cache: c, prefix: c.keyPrefix}
+	}
+
+	// pre-populate the cache before serving traffic, through the prefix of the instance
+	err = c.warmUp(ctx, view)
+	if err != nil {
+		_ = c.Close(ctx)
+		return nil, err
+	}
+
+	// report the operations to the observer, if any
+	if c.observer != nil {
+		return &instrumentedCache{Cache: view, observer: c.observer}, nil
+	}
+
+	return view, nil
 }
 
 // Set sets a key-value pair in the cache with the given TTL.
@@ -144,10 +538,11 @@ func NewCache(ctx context.Context, opts ...Option) (Cache, error) {
 //   - ctx: the context
 //   - key: the cache key
 //   - value: the cache value
-//   - ttl: the time-to-live for the cache entry (in seconds)
+//   - ttl: the time-to-live for the cache entry, 0 or less for an entry that never expires
+//   - opts: the options of the entry, such as WithMetadata
 //
 // Returns:
-//   - error: an error if the operation failed
+//   - error: an error if the operation failed, ErrInvalidKey if the key is invalid
 //
 // Example:
 //
@@ -158,43 +553,366 @@ func NewCache(ctx context.Context, opts ...Option) (Cache, error) {
 //	if err != nil {
 //		return err
 //	}
-func (ch *cache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+func (ch *cache) Set(
+	ctx context.Context,
+	key, value string,
+	ttl time.Duration,
+	opts ...SetOption,
+) error {
+	return ch.SetBytes(ctx, key, []byte(value), ttl, opts...)
+}
+
+// SetBytes sets a key-value pair in the cache with the given TTL, storing the value as is.
+// It is meant for binary payloads, such as protobuf messages or compressed data.
+// If the key already exists, it is updated with the new value and TTL.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - value: the cache value
+//   - ttl: the time-to-live for the cache entry, 0 or less for an entry that never expires
+//   - opts: the options of the entry, such as WithMetadata
+//
+// Returns:
+//   - error: an error if the operation failed, ErrInvalidKey if the key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	payload, err := proto.Marshal(user)
+//	err = cache.SetBytes(ctx, "user:1", payload, 10*time.Second)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) SetBytes(
+	ctx context.Context,
+	key string,
+	value []byte,
+	ttl time.Duration,
+	opts ...SetOption,
+) error {
+	metadata, err := newSetOptions(opts).encodeMetadata()
+	if err != nil {
+		return err
+	}
+
+	return ch.setBytes(ctx, key, value, ttl, metadata, func(now time.Time) time.Time {
+		return ch.expiresAt(now, ttl)
+	})
+}
+
+// SetWithExpireAt sets a key-value pair in the cache that expires at the given time,
+// for callers that already know the deadline of the entry, such as the expiry of a token.
+// A zero time stores an entry that never expires; a time in the past stores an expired entry.
+// The expiration of the entry doesn't slide with WithSlidingTTL.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - value: the cache value
+//   - expireAt: the expiration time of the cache entry
+//
+// Returns:
+//   - error: an error if the operation failed, ErrInvalidKey if the key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err := cache.SetWithExpireAt(ctx, "token", token.AccessToken, token.Expiry)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) SetWithExpireAt(ctx context.Context, key, value string, expireAt time.Time) error {
+	return ch.setBytes(ctx, key, []byte(value), 0, sql.NullString{}, func(time.Time) time.Time {
+		if expireAt.IsZero() {
+			return neverExpires.In(ch.timeSource.Timezone)
+		}
+
+		return expireAt.In(ch.timeSource.Timezone)
+	})
+}
+
+// setBytes stores a key-value pair in the cache with its metadata, with the expiration
+// computed by expiresAt from the time of the write. The ttl is stored to slide the
+// expiration of the entry.
+func (ch *cache) setBytes(
+	ctx context.Context,
+	key string,
+	value []byte,
+	ttl time.Duration,
+	metadata sql.NullString,
+	expiresAt func(now time.Time) time.Time,
+) error {
+	if err := ch.checkKey(key); err != nil {
+		return err
+	}
+	if err := ch.checkValueSize(len(value)); err != nil {
+		return err
+	}
+
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
 	attempt := 0
 	maxAttempts := 2
 
-	retryFunc := func() error {
+	setFunc := func() error {
 		attempt++
 		now := ch.timeSource.Now().In(ch.timeSource.Timezone)
-		expiresAt := now.Add(ttl)
-
 		params := queries.UpsertCacheParams{
 			Key:            key,
-			Value:          []byte(value),
-			ExpiresAt:      expiresAt,
+			Value:          value,
+			ExpiresAt:      expiresAt(now),
 			LastAccessedAt: now,
+			Ttl:            int64(ttl),
+			Checksum:       ch.checksum(value),
+			Metadata:       metadata,
 		}
 
-		if err := ch.queries.UpsertCache(context.Background(), params); err != nil {
+		if err := ch.queries.UpsertCache(ctx, params); err != nil {
 			// If the database is full, purge the cache and try again.
 
 			if database.IsDBFullError(err) && attempt < maxAttempts {
-				if err = ch.PurgeItens(ctx); err != nil {
-					return fmt.Errorf("error purging cache: %w", err)
+				if purgeErr := ch.purgeItens(ctx); purgeErr != nil {
+					return fmt.Errorf("error purging cache: %w", purgeErr)
+				}
+			}
+			return fmt.Errorf("error setting cache: %w", err)
+		}
+		ch.memory.set(key, value, params.ExpiresAt)
+
+		return nil
+	}
+
+	// Retry the set operation if the database is full or locked
+	err := ch.retryBusy(ctx, func() error {
+		attempt = 0
+		return retry.Do(
+			ctx,
+			setFunc,
+			retry.WithMaxAttempts(maxAttempts),
+			retry.WithBackoff(0, 0),
+			retry.WithRetryIf(database.IsDBFullError),
+		)
+	})
+	if err != nil {
+		return err
+	}
+	ch.metrics.sets.Add(1)
+	ch.hooks.set(ctx, key)
+	return nil
+}
+
+// SetNX sets a key-value pair in the cache only if the key does not exist or is expired.
+// It can be used for "first writer wins" semantics, such as lightweight locks
+// and idempotency guards.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - value: the cache value
+//   - ttl: the time-to-live for the cache entry, 0 or less for an entry that never expires
+//
+// Returns:
+//   - bool: true if the value was set, false if the key already exists
+//   - error: an error if the operation failed, ErrInvalidKey if the key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	ok, err := cache.SetNX(ctx, "lock:job", "worker-1", 30*time.Second)
+//	if err != nil {
+//		return err
+//	}
+//	if !ok {
+//		return errors.New("job already running")
+//	}
+func (ch *cache) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	if err := ch.checkKey(key); err != nil {
+		return false, err
+	}
+	if err := ch.checkValueSize(len(value)); err != nil {
+		return false, err
+	}
+
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	attempt := 0
+	maxAttempts := 2
+	stored := false
+
+	setFunc := func() error {
+		attempt++
+		now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+		params := queries.UpsertCacheIfExpiredParams{
+			Key:            key,
+			Value:          []byte(value),
+			ExpiresAt:      ch.expiresAt(now, ttl),
+			LastAccessedAt: now,
+			Ttl:            int64(ttl),
+		}
+		params.Checksum = ch.checksum(params.Value)
+
+		_, err := ch.queries.UpsertCacheIfExpired(ctx, params)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The key exists, nothing to set.
+			return nil
+		}
+		if err != nil {
+			// If the database is full, purge the cache and try again.
+			if database.IsDBFullError(err) && attempt < maxAttempts {
+				if purgeErr := ch.purgeItens(ctx); purgeErr != nil {
+					return fmt.Errorf("error purging cache: %w", purgeErr)
 				}
 			}
 			return fmt.Errorf("error setting cache: %w", err)
 		}
+		ch.memory.set(key, params.Value, params.ExpiresAt)
+		stored = true
 
 		return nil
 	}
 
-	// Retry the set operation if the database is full
-	if err := helpers.Retry(ctx, retryFunc, maxAttempts); err != nil {
+	// Retry the set operation if the database is full or locked
+	err := ch.retryBusy(ctx, func() error {
+		attempt = 0
+		return retry.Do(
+			ctx,
+			setFunc,
+			retry.WithMaxAttempts(maxAttempts),
+			retry.WithBackoff(0, 0),
+			retry.WithRetryIf(database.IsDBFullError),
+		)
+	})
+	if err != nil {
+		return false, err
+	}
+	if !stored {
+		return false, nil
+	}
+	ch.metrics.sets.Add(1)
+	ch.hooks.set(ctx, key)
+
+	return true, nil
+}
+
+// Append appends a suffix to the value of an existing key in a single statement.
+// The expiration of the entry is preserved. With WithChecksums, the value is verified
+// and its checksum is recomputed in a transaction instead.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - suffix: the value to append
+//
+// Returns:
+//   - error: an error if the operation failed, ErrKeyNotFound if the key does not exist,
+//     ErrValueTooLarge if the value would exceed the max value size,
+//     ErrCorruptValue if the value does not match its checksum,
+//     ErrInvalidKey if the key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err := cache.Append(ctx, "log", "line\n")
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Append(ctx context.Context, key, suffix string) error {
+	if err := ch.checkKey(key); err != nil {
 		return err
 	}
+	if err := ch.checkValueSize(len(suffix)); err != nil {
+		return err
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	params := queries.AppendValueParams{
+		Key:            key,
+		Suffix:         []byte(suffix),
+		LastAccessedAt: now,
+		Now:            now,
+		MaxSize:        math.MaxInt64,
+	}
+	if ch.maxValueSize > 0 {
+		params.MaxSize = int64(ch.maxValueSize)
+	}
+
+	var (
+		updated int64
+		err     error
+	)
+	if ch.checksums {
+		updated, err = ch.appendWithChecksum(ctx, params)
+	} else {
+		updated, err = ch.queries.AppendValue(ctx, params)
+	}
+	if err != nil {
+		return fmt.Errorf("error appending value: %w", err)
+	}
+	ch.memory.del(key)
+	if updated == 0 {
+		return ch.appendMiss(ctx, key, len(suffix), now)
+	}
+	ch.metrics.sets.Add(1)
+	ch.hooks.set(ctx, key)
+
 	return nil
 }
 
+// appendWithChecksum appends the suffix of params to the value of its key in a
+// transaction, verifying the value and storing the checksum of the new one.
+// It returns 0 if the key does not exist or the new value would be too large.
+func (ch *cache) appendWithChecksum(ctx context.Context, params queries.AppendValueParams) (int64, error) {
+	var updated int64
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		queriesWithTx, err := lockCache(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		row, err := queriesWithTx.GetValue(ctx, queries.GetValueParams{
+			Key:       params.Key,
+			ExpiresAt: params.Now,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+
+			return fmt.Errorf("getting key %q: %w", params.Key, err)
+		}
+		if err := ch.matchChecksum(params.Key, row.Value, row.Checksum); err != nil {
+			return err
+		}
+
+		params.Checksum = ch.checksum(append(row.Value, params.Suffix...))
+		updated, err = queriesWithTx.AppendValue(ctx, params)
+		return err
+	})
+	ch.logCorruptValue(ctx, params.Key, err)
+
+	return updated, err
+}
+
 // Get retrieves a value from the cache by key.
 //
 // Parameters:
@@ -203,7 +921,10 @@ func (ch *cache) Set(ctx context.Context, key, value string, ttl time.Duration)
 //
 // Returns:
 //   - string: the cache value
-//   - error: an error if the operation failed
+//   - error: an error if the operation failed, ErrKeyExpired if the entry expired,
+//     ErrKeyNotFound if the key does not exist,
+//     ErrCorruptValue if the value does not match its checksum,
+//     ErrInvalidKey if the key is invalid
 //
 // Example:
 //
@@ -215,31 +936,322 @@ func (ch *cache) Set(ctx context.Context, key, value string, ttl time.Duration)
 //		return err
 //	}
 func (ch *cache) Get(ctx context.Context, key string) (string, error) {
-	paramsGet := queries.GetValueParams{
-		Key:       key,
-		ExpiresAt: time.Now().In(ch.timeSource.Timezone),
+	value, err := ch.GetBytes(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// GetBytes retrieves a value from the cache by key as stored, without converting it to a string.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//
+// Returns:
+//   - []byte: the cache value
+//   - error: an error if the operation failed, ErrKeyExpired if the entry expired,
+//     ErrKeyNotFound if the key does not exist,
+//     ErrCorruptValue if the value does not match its checksum,
+//     ErrInvalidKey if the key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	payload, err := cache.GetBytes(ctx, "user:1")
+//	if err != nil {
+//		return err
+//	}
+//	err = proto.Unmarshal(payload, user)
+func (ch *cache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	if err := ch.checkKey(key); err != nil {
+		return nil, err
 	}
 
-	value, err := ch.queries.GetValue(ctx, paramsGet)
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	if ch.memory != nil {
+		return ch.getBytesThroughMemory(ctx, key)
+	}
+
+	value, err := ch.getValue(ctx, key, ch.timeSource.Now().In(ch.timeSource.Timezone))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return "", ErrKeyNotFound
+		if isMiss(err) {
+			ch.metrics.misses.Add(1)
 		}
 
-		return "", fmt.Errorf("error getting value: %w", err)
+		return nil, err
+	}
+	ch.metrics.hits.Add(1)
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	ch.slideExpiration(ctx, key, now)
+
+	// Buffer the access time instead of writing it, if enabled.
+	if ch.access.record(now, key) {
+		return value, nil
+	}
+
+	// Skip the access time update while the cache is quiesced.
+	if !ch.writeMu.TryRLock() {
+		return value, nil
 	}
+	defer ch.writeMu.RUnlock()
 
 	paramsUpdate := queries.UpdateLastAccessedAtParams{
-		LastAccessedAt: time.Now().In(ch.timeSource.Timezone),
+		LastAccessedAt: now,
 		Key:            key,
 	}
 
 	err = ch.queries.UpdateLastAccessedAt(ctx, paramsUpdate)
 	if err != nil {
-		fmt.Printf("error updating last accessed at: %v\n", err)
+		ch.logger.Error(ctx, fmt.Sprintf("error updating last accessed at: %v", err))
 	}
 
-	return string(value), nil
+	return value, nil
+}
+
+// GetTTL returns the remaining time-to-live of a key.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//
+// Returns:
+//   - time.Duration: the remaining time-to-live, NoTTL if the entry never expires
+//   - error: an error if the operation failed, ErrInvalidKey if the key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	ttl, err := cache.GetTTL(ctx, "key") // ttl: 9s
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := ch.checkKey(key); err != nil {
+		return 0, err
+	}
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	params := queries.GetExpiresAtParams{
+		Key:       key,
+		ExpiresAt: now,
+	}
+
+	expiresAt, err := ch.reads().GetExpiresAt(ctx, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ch.missError(ctx, key, now)
+		}
+
+		return 0, fmt.Errorf("error getting ttl: %w", err)
+	}
+
+	if !expiresAt.Before(neverExpires) {
+		return NoTTL, nil
+	}
+
+	return expiresAt.Sub(now), nil
+}
+
+// Persist removes the expiration of a key, so that the entry never expires.
+// The entry can still be removed by Del or by purging when the database is full.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//
+// Returns:
+//   - error: an error if the operation failed, ErrKeyNotFound if the key does not exist,
+//     ErrInvalidKey if the key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err := cache.Persist(ctx, "config")
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Persist(ctx context.Context, key string) error {
+	if err := ch.checkKey(key); err != nil {
+		return err
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	params := queries.UpdateExpiresAtParams{
+		Key:       key,
+		ExpiresAt: neverExpires.In(ch.timeSource.Timezone),
+		Now:       ch.timeSource.Now().In(ch.timeSource.Timezone),
+	}
+
+	updated, err := ch.queries.UpdateExpiresAt(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error persisting key: %w", err)
+	}
+	ch.memory.del(key)
+	if updated == 0 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+// GetRange retrieves a part of a value from the cache by key, so that large values can be
+// read in chunks instead of being loaded in memory at once.
+// If the offset is beyond the end of the value, an empty string is returned.
+// Reading a range does not refresh the last accessed at timestamp of the entry.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - offset: the zero-based byte offset to start reading from
+//   - length: the maximum number of bytes to read
+//
+// Returns:
+//   - string: the part of the cache value
+//   - error: an error if the operation failed, ErrInvalidKey if the key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	chunk, err := cache.GetRange(ctx, "file", 0, 64*1024) // first 64 KB
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) GetRange(ctx context.Context, key string, offset, length int) (string, error) {
+	if err := ch.checkKey(key); err != nil {
+		return "", err
+	}
+	if offset < 0 || length <= 0 {
+		return "", fmt.Errorf("invalid range: offset %d, length %d", offset, length)
+	}
+
+	params := queries.GetValueRangeParams{
+		Key:       key,
+		ExpiresAt: ch.timeSource.Now().In(ch.timeSource.Timezone),
+		Start:     int64(offset) + 1, // substr is 1-indexed
+		Length:    int64(length),
+	}
+
+	chunk, err := ch.reads().GetValueRange(ctx, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ch.metrics.misses.Add(1)
+			return "", ch.missError(ctx, key, params.ExpiresAt)
+		}
+
+		return "", fmt.Errorf("error getting value range: %w", err)
+	}
+	ch.metrics.hits.Add(1)
+
+	return string(chunk), nil
+}
+
+// MGet retrieves multiple values from the cache in a single query.
+// Keys that do not exist or are expired are omitted from the result.
+//
+// Parameters:
+//   - ctx: the context
+//   - keys: the cache keys
+//
+// Returns:
+//   - map[string]string: the cache values by key
+//   - error: an error if the operation failed, ErrInvalidKey if a key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	values, err := cache.MGet(ctx, "key1", "key2") // values: map[key1:test]
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	for _, key := range keys {
+		if err := ch.checkKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return ch.getMulti(ctx, keys)
+}
+
+// Prefetch loads the live entries of the given keys into the memory tier, if enabled,
+// and marks them as accessed in a single statement, so that entries the application is
+// about to need are served from memory and are not purged before they are read. The
+// missing, expired and deleted keys are skipped.
+//
+// Parameters:
+//   - ctx: the context
+//   - keys: the cache keys
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err := cache.Prefetch(ctx, []string{"session:1", "user:1"})
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Prefetch(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	rows, err := ch.reads().GetValues(ctx, queries.GetValuesParams{
+		Keys:      keys,
+		ExpiresAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("prefetching keys: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	live := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if err := ch.verifyChecksum(ctx, row.Key, row.Value, row.Checksum); err != nil {
+			return fmt.Errorf("prefetching keys: %w", err)
+		}
+		ch.memory.set(row.Key, row.Value, row.ExpiresAt)
+		live = append(live, row.Key)
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	params := queries.UpdateLastAccessedAtByKeysParams{
+		LastAccessedAt: now,
+		Keys:           live,
+		ExpiresAt:      now,
+	}
+
+	err = ch.queries.UpdateLastAccessedAtByKeys(ctx, params)
+	if err != nil {
+		return fmt.Errorf("prefetching keys: %w", err)
+	}
+
+	return nil
 }
 
 // Del deletes a key-value pair from the cache.
@@ -250,7 +1262,7 @@ func (ch *cache) Get(ctx context.Context, key string) (string, error) {
 //   - key: the cache key
 //
 // Returns:
-//   - error: an error if the operation failed
+//   - error: an error if the operation failed, ErrInvalidKey if the key is invalid
 //
 // Example:
 //
@@ -259,18 +1271,36 @@ func (ch *cache) Get(ctx context.Context, key string) (string, error) {
 //
 //	err := cache.Del(ctx, "key") // no error
 func (ch *cache) Del(ctx context.Context, key string) error {
-	err := ch.queries.DeleteKey(ctx, key)
+	if err := ch.checkKey(key); err != nil {
+		return err
+	}
+
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	err := ch.retryBusy(ctx, func() error {
+		return ch.deleteKey(ctx, ch.queries, key)
+	})
 	if err != nil {
 		return fmt.Errorf("deleting key: %w", err)
 	}
+	ch.memory.del(key)
+	ch.metrics.deletes.Add(1)
+	ch.hooks.delete(ctx, key)
 
 	return nil
 }
 
-// Close closes the cache and stops jobs.
+// Close waits for the writes queued by SetAsync, stops the background jobs and waits
+// for the running ones, stores the buffered access times and closes the cache.
+// If ctx is done before the background jobs finish, the cache is closed anyway and
+// the context error is returned.
 //
 // Parameters:
-//   - ctx: the context
+//   - ctx: the context, it bounds the time spent waiting
 //
 // Returns:
 //   - error: an error if the operation failed
@@ -280,6 +1310,133 @@ func (ch *cache) Del(ctx context.Context, key string) error {
 //	cache, err := cache.NewCache(ctx)
 //	defer cache.Close(ctx)
 func (ch *cache) Close(ctx context.Context) error {
-	ch.cron.Stop()
-	return ch.Database.Close(ctx)
+	err := ch.async.close(ctx)
+	if err != nil {
+		err = fmt.Errorf("flushing async writes: %w", err)
+	}
+
+	err = errors.Join(err, ch.stopBackground(ctx))
+	ch.writeMu.RLock()
+	err = errors.Join(err, ch.flushAccess(ctx))
+	ch.writeMu.RUnlock()
+
+	ch.memory.clear()
+	if ch.manager != nil {
+		ch.manager.Unregister(ch)
+	}
+	return errors.Join(err, ch.closeReadQueries(), ch.queries.Close(), ch.Database.Close(ctx))
+}
+
+// Destroy stops jobs, closes the cache and deletes the cache database file.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// ⚠️ WARNING: This operation is irreversible and will delete all data stored in the cache.
+func (ch *cache) Destroy(ctx context.Context) error {
+	// stop the worker, a failed flush is not reported since the data is deleted anyway
+	_ = ch.async.close(ctx)
+	_ = ch.stopBackground(ctx)
+	ch.memory.clear()
+	if ch.manager != nil {
+		ch.manager.Unregister(ch)
+	}
+	_ = ch.closeReadQueries()
+	_ = ch.queries.Close()
+	return ch.Database.Destroy(ctx)
+}
+
+// expiresAt returns the expiration time of an entry written at now with the given TTL.
+// Entries with a TTL of 0 or less never expire.
+func (ch *cache) expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return neverExpires.In(ch.timeSource.Timezone)
+	}
+
+	return now.Add(ttl)
+}
+
+// withOpTimeout returns a context bounded by the operation timeout of the cache, if any.
+// The deadline of ctx is kept if it is earlier.
+func (ch *cache) withOpTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ch.opTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, ch.opTimeout)
+}
+
+// checkValueSize returns ErrValueTooLarge if size exceeds the max value size of the cache.
+func (ch *cache) checkValueSize(size int) error {
+	if ch.maxValueSize > 0 && size > ch.maxValueSize {
+		return fmt.Errorf(
+			"%w: %d bytes exceeds the limit of %d bytes",
+			ErrValueTooLarge,
+			size,
+			ch.maxValueSize,
+		)
+	}
+
+	return nil
+}
+
+// appendMiss returns the error of an Append that updated no row: ErrValueTooLarge if the
+// key exists, since the appended value would exceed the max value size, ErrKeyNotFound
+// otherwise.
+func (ch *cache) appendMiss(ctx context.Context, key string, size int, now time.Time) error {
+	if ch.maxValueSize <= 0 {
+		return ErrKeyNotFound
+	}
+
+	_, err := ch.queries.GetExpiresAt(ctx, queries.GetExpiresAtParams{Key: key, ExpiresAt: now})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrKeyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error appending value: %w", err)
+	}
+
+	return fmt.Errorf(
+		"%w: appending %d bytes to key %q exceeds the limit of %d bytes",
+		ErrValueTooLarge,
+		size,
+		key,
+		ch.maxValueSize,
+	)
+}
+
+// checkKey returns ErrInvalidKey if the key is empty, exceeds the max key length or
+// doesn't match the key pattern of the cache.
+func (ch *cache) checkKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty key", ErrInvalidKey)
+	}
+
+	if err := ch.checkKeyLength(len(key)); err != nil {
+		return err
+	}
+
+	if ch.keyPattern != nil && !ch.keyPattern.MatchString(key) {
+		return fmt.Errorf("%w: %q does not match %s", ErrInvalidKey, key, ch.keyPattern)
+	}
+
+	return nil
+}
+
+// checkKeyLength returns ErrInvalidKey if a key of the given length in bytes exceeds
+// the max key length of the cache.
+func (ch *cache) checkKeyLength(length int) error {
+	if ch.maxKeyLength > 0 && length > ch.maxKeyLength {
+		return fmt.Errorf(
+			"%w: %d bytes exceeds the limit of %d bytes",
+			ErrInvalidKey,
+			length,
+			ch.maxKeyLength,
+		)
+	}
+
+	return nil
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"regexp"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 
 	"github.com/lucasvillarinho/litepack/cache/queries"
 	"github.com/lucasvillarinho/litepack/database/mocks"
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
 )
 
 func TestCache_Get(t *testing.T) {
@@ -23,6 +26,7 @@ func TestCache_Get(t *testing.T) {
 	ch := &cache{
 		timeSource: timeSource{
 			Timezone: time.UTC,
+			Now:      time.Now,
 		},
 		queries: queries.New(db),
 	}
@@ -31,11 +35,11 @@ func TestCache_Get(t *testing.T) {
 		expectedValue := "cached_data"
 		key := "existing_key"
 
-		mock.ExpectQuery(`SELECT value FROM cache WHERE`).
+		mock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
 			WithArgs(key, sqlmock.AnyArg()).
-			WillReturnRows(sqlmock.NewRows([]string{"value"}).
-				AddRow(expectedValue))
-		mock.ExpectExec(`UPDATE cache SET last_accessed_at = \? WHERE key = \?`).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).
+				AddRow(expectedValue, nil))
+		mock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
 			WithArgs(sqlmock.AnyArg(), key).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -49,7 +53,7 @@ func TestCache_Get(t *testing.T) {
 	t.Run(
 		"Should return empty string if key does not exist (sql.ErrNoRows) and ErrKeyNotFound",
 		func(t *testing.T) {
-			mock.ExpectQuery(`SELECT value FROM cache WHERE`).
+			mock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
 				WithArgs("non_existing_key", sqlmock.AnyArg()).
 				WillReturnError(sql.ErrNoRows)
 
@@ -61,7 +65,7 @@ func TestCache_Get(t *testing.T) {
 	)
 
 	t.Run("Should return error if query fails", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT value FROM cache WHERE`).
+		mock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
 			WithArgs("error_key", sqlmock.AnyArg()).
 			WillReturnError(sql.ErrConnDone)
 
@@ -75,14 +79,20 @@ func TestCache_Get(t *testing.T) {
 		expectedValue := "cached_data"
 		key := "existing_key"
 
-		mock.ExpectQuery(`SELECT value FROM cache WHERE`).
+		mock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
 			WithArgs(key, sqlmock.AnyArg()).
-			WillReturnRows(sqlmock.NewRows([]string{"value"}).
-				AddRow(expectedValue))
-		mock.ExpectExec(`UPDATE cache SET last_accessed_at = \? WHERE key = \?`).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).
+				AddRow(expectedValue, nil))
+		mock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
 			WithArgs(sqlmock.AnyArg(), key).
 			WillReturnError(sql.ErrConnDone)
 
+		logger := logMocks.NewLoggerMock(t)
+		logger.EXPECT().
+			Error(context.Background(), "error updating last accessed at: sql: connection is already closed").
+			Once()
+		ch.logger = logger
+
 		value, err := ch.Get(context.Background(), key)
 
 		assert.Equal(t, expectedValue, value, "Expected cached value to match")
@@ -98,6 +108,7 @@ func TestCache_Del(t *testing.T) {
 	ch := &cache{
 		timeSource: timeSource{
 			Timezone: time.UTC,
+			Now:      time.Now,
 		},
 		queries: queries.New(db),
 	}
@@ -140,6 +151,20 @@ func TestCache_Del(t *testing.T) {
 		)
 		assert.NoError(t, mock.ExpectationsWereMet(), "Not all expectations were met")
 	})
+
+	t.Run("Should fail when the operation timeout elapses", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM cache WHERE key = ?`).
+			WithArgs("slow_key").
+			WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		slow := &cache{queries: queries.New(db), opTimeout: 10 * time.Millisecond}
+
+		err := slow.Del(context.Background(), "slow_key")
+
+		assert.ErrorIs(t, err, sqlmock.ErrCancelled, "Expected the delete to be cancelled")
+		assert.NoError(t, mock.ExpectationsWereMet(), "Not all expectations were met")
+	})
 }
 
 func TestCache_Set(t *testing.T) {
@@ -157,7 +182,8 @@ func TestCache_Set(t *testing.T) {
 			Timezone: tz,
 			Now:      func() time.Time { return fixedTime },
 		},
-		purgePercent: 0.2,
+		purgePercent:   0.2,
+		evictionPolicy: LRUPolicy{},
 	}
 
 	t.Run("should successfully set a cache item", func(t *testing.T) {
@@ -168,12 +194,15 @@ func TestCache_Set(t *testing.T) {
 		expectedExpiresAt := fixedTime.Add(ttl)
 		expectedLastAccessedAt := fixedTime
 
-		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at\) VALUES \(\?, \?, \?, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\) VALUES \(\?, \?, \?, \?, \?, \?, \?4, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
 			WithArgs(
 				key,
 				[]byte(value),
 				expectedExpiresAt,
 				expectedLastAccessedAt,
+				int64(ttl),
+				nil,
+				nil,
 			).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -194,12 +223,15 @@ func TestCache_Set(t *testing.T) {
 		ch.Database = dbMock
 
 		// First attempt to set the cache item
-		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at\) VALUES \(\?, \?, \?, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\) VALUES \(\?, \?, \?, \?, \?, \?, \?4, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
 			WithArgs(
 				key,
 				[]byte(value),
 				expectedExpiresAt,
 				expectedLastAccessedAt,
+				int64(ttl),
+				nil,
+				nil,
 			).
 			WillReturnError(fmt.Errorf("database or disk is full"))
 
@@ -207,9 +239,9 @@ func TestCache_Set(t *testing.T) {
 		sqlMock.ExpectBegin()
 		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
-		sqlMock.ExpectExec(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache ORDER BY last_accessed_at ASC LIMIT \? \)`).
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT \? \) RETURNING key`).
 			WithArgs(20).
-			WillReturnResult(sqlmock.NewResult(1, 20))
+			WillReturnRows(keyRows(20))
 		sqlMock.ExpectCommit()
 
 		dbMock.EXPECT().
@@ -217,8 +249,8 @@ func TestCache_Set(t *testing.T) {
 			Return(nil).
 			Times(1)
 		dbMock.EXPECT().
-			ExecWithTx(mock.Anything, mock.Anything).
-			Run(func(ctx context.Context, fn func(*sql.Tx) error) {
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
 				tx, err := db.Begin()
 				assert.NoError(t, err, "Expected no error while beginning transaction")
 
@@ -232,12 +264,15 @@ func TestCache_Set(t *testing.T) {
 			Times(1)
 
 		// Retry the set operation
-		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at\) VALUES \(\?, \?, \?, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\) VALUES \(\?, \?, \?, \?, \?, \?, \?4, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
 			WithArgs(
 				key,
 				[]byte(value),
 				expectedExpiresAt,
 				expectedLastAccessedAt,
+				int64(ttl),
+				nil,
+				nil,
 			).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -258,12 +293,15 @@ func TestCache_Set(t *testing.T) {
 		ch.Database = dbMock
 
 		// First attempt to set the cache item
-		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at\) VALUES \(\?, \?, \?, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\) VALUES \(\?, \?, \?, \?, \?, \?, \?4, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
 			WithArgs(
 				key,
 				[]byte(value),
 				expectedExpiresAt,
 				expectedLastAccessedAt,
+				int64(ttl),
+				nil,
+				nil,
 			).
 			WillReturnError(fmt.Errorf("database or disk is full"))
 
@@ -272,9 +310,9 @@ func TestCache_Set(t *testing.T) {
 		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).
 				AddRow(100))
-		sqlMock.ExpectExec(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache ORDER BY last_accessed_at ASC LIMIT \? \)`).
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT \? \) RETURNING key`).
 			WithArgs(20).
-			WillReturnResult(sqlmock.NewResult(1, 20))
+			WillReturnRows(keyRows(20))
 		sqlMock.ExpectCommit()
 
 		dbMock.EXPECT().
@@ -282,8 +320,8 @@ func TestCache_Set(t *testing.T) {
 			Return(nil).
 			Times(1)
 		dbMock.EXPECT().
-			ExecWithTx(mock.Anything, mock.Anything).
-			Run(func(ctx context.Context, fn func(*sql.Tx) error) {
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
 				tx, err := db.Begin()
 				assert.NoError(t, err, "Expected no error while beginning transaction")
 
@@ -297,12 +335,15 @@ func TestCache_Set(t *testing.T) {
 			Times(1)
 
 		// Retry the set operation
-		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at\) VALUES \(\?, \?, \?, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\) VALUES \(\?, \?, \?, \?, \?, \?, \?4, \?\) ON CONFLICT \(key\) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`).
 			WithArgs(
 				key,
 				[]byte(value),
 				expectedExpiresAt,
 				expectedLastAccessedAt,
+				int64(ttl),
+				nil,
+				nil,
 			).
 			WillReturnError(fmt.Errorf("database or disk is full"))
 
@@ -318,3 +359,818 @@ func TestCache_Set(t *testing.T) {
 		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
 	})
 }
+
+func TestCache_MGet(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("Should return the existing keys in a single query", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?,\?,\?\) AND expires_at > \?`).
+			WithArgs("a", "b", "c", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}).
+				AddRow("a", []byte("1"), fixedTime.Add(time.Hour), nil).
+				AddRow("c", []byte("3"), fixedTime.Add(time.Hour), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key IN \(\?,\?\) AND expires_at > \?`).
+			WithArgs(fixedTime, "a", "c", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		values, err := ch.MGet(context.Background(), "a", "b", "c")
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.Equal(t, map[string]string{"a": "1", "c": "3"}, values)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return an empty map without querying when no keys are given", func(t *testing.T) {
+		values, err := ch.MGet(context.Background())
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.Empty(t, values, "Expected empty result")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return error if query fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs("a", fixedTime).
+			WillReturnError(sql.ErrConnDone)
+
+		values, err := ch.MGet(context.Background(), "a")
+
+		assert.Error(t, err, "Expected error for failing query")
+		assert.Nil(t, values)
+	})
+}
+
+func TestCache_Prefetch(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		memory:  newMemoryTier(10, 0),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("Should load the live keys into memory and update them in one statement", func(t *testing.T) {
+		defer ch.memory.clear()
+
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?,\?,\?\) AND expires_at > \?`).
+			WithArgs("a", "b", "c", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}).
+				AddRow("a", []byte("1"), fixedTime.Add(time.Hour), nil).
+				AddRow("b", []byte("2"), fixedTime.Add(time.Hour), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key IN \(\?,\?\) AND expires_at > \?`).
+			WithArgs(fixedTime, "a", "b", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err := ch.Prefetch(context.Background(), []string{"a", "b", "c"})
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		value, ok := ch.memory.get("a", fixedTime)
+		assert.True(t, ok, "Expected the key to be loaded into memory")
+		assert.Equal(t, []byte("1"), value)
+		_, ok = ch.memory.get("c", fixedTime)
+		assert.False(t, ok, "Expected the missing key not to be loaded into memory")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should do nothing when no keys are given", func(t *testing.T) {
+		err := ch.Prefetch(context.Background(), nil)
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should not update anything when no key is live", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs("a", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}))
+
+		err := ch.Prefetch(context.Background(), []string{"a"})
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return error if SELECT query fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs("a", fixedTime).
+			WillReturnError(fmt.Errorf("mock select error"))
+
+		err := ch.Prefetch(context.Background(), []string{"a"})
+
+		assert.Error(t, err, "Expected error for failing query")
+		assert.Equal(t, "prefetching keys: mock select error", err.Error())
+	})
+
+	t.Run("Should return error if UPDATE query fails", func(t *testing.T) {
+		defer ch.memory.clear()
+
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs("a", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}).
+				AddRow("a", []byte("1"), fixedTime.Add(time.Hour), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs(fixedTime, "a", fixedTime).
+			WillReturnError(fmt.Errorf("mock update error"))
+
+		err := ch.Prefetch(context.Background(), []string{"a"})
+
+		assert.Error(t, err, "Expected error for failing query")
+		assert.Equal(t, "prefetching keys: mock update error", err.Error())
+	})
+}
+
+func TestCache_SetNX(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+		purgePercent:   0.2,
+		evictionPolicy: LRUPolicy{},
+	}
+
+	t.Run("should set the value if the key does not exist", func(t *testing.T) {
+		sqlMock.ExpectQuery(`INSERT INTO cache .* WHERE cache.expires_at <= excluded.last_accessed_at RETURNING value`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("value")))
+
+		ok, err := ch.SetNX(context.Background(), "key", "value", time.Minute)
+
+		assert.NoError(t, err, "Expected no error when setting a new key")
+		assert.True(t, ok, "Expected the value to be set")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should not set the value if the key exists", func(t *testing.T) {
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil).
+			WillReturnError(sql.ErrNoRows)
+
+		ok, err := ch.SetNX(context.Background(), "key", "value", time.Minute)
+
+		assert.NoError(t, err, "Expected no error when the key exists")
+		assert.False(t, ok, "Expected the value not to be set")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the query fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil).
+			WillReturnError(fmt.Errorf("mock insert error"))
+
+		ok, err := ch.SetNX(context.Background(), "key", "value", time.Minute)
+
+		assert.Error(t, err, "Expected an error when the query fails")
+		assert.Equal(t, "error setting cache: mock insert error", err.Error())
+		assert.False(t, ok)
+	})
+
+	t.Run("should retry while the database is locked", func(t *testing.T) {
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil).
+			WillReturnError(fmt.Errorf("database is locked"))
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("value")))
+
+		ok, err := ch.SetNX(context.Background(), "key", "value", time.Minute)
+
+		assert.NoError(t, err, "Expected the set to succeed once the lock is released")
+		assert.True(t, ok, "Expected the value to be set")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should purge the cache and retry if the database is full", func(t *testing.T) {
+		dbMock := mocks.NewDatabaseMock(t)
+		ch.Database = dbMock
+
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil).
+			WillReturnError(fmt.Errorf("database or disk is full"))
+
+		// Purge the cache and retry the set operation
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT \? \) RETURNING key`).
+			WithArgs(20).
+			WillReturnRows(keyRows(20))
+		sqlMock.ExpectCommit()
+
+		dbMock.EXPECT().
+			Vacuum(mock.Anything).
+			Return(nil).
+			Times(1)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			}).
+			Times(1)
+
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("value")))
+
+		ok, err := ch.SetNX(context.Background(), "key", "value", time.Minute)
+
+		assert.NoError(t, err, "Expected no error after purging the cache")
+		assert.True(t, ok, "Expected the value to be set")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should fail when the operation timeout elapses", func(t *testing.T) {
+		timed := &cache{
+			queries:   queries.New(db),
+			opTimeout: 10 * time.Millisecond,
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}
+
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Minute), fixedTime, int64(time.Minute), nil).
+			WillDelayFor(time.Second).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("value")))
+
+		ok, err := timed.SetNX(context.Background(), "key", "value", time.Minute)
+
+		assert.ErrorIs(t, err, sqlmock.ErrCancelled, "Expected the set to be cancelled")
+		assert.False(t, ok)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestCache_GetRange(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("Should return the requested part of the value", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT substr\(value, \?, \?\) AS chunk FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs(int64(5), int64(3), "key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"chunk"}).AddRow([]byte("efg")))
+
+		chunk, err := ch.GetRange(context.Background(), "key", 4, 3)
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.Equal(t, "efg", chunk)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return ErrKeyNotFound if key does not exist", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT substr\(value, \?, \?\) AS chunk FROM cache`).
+			WithArgs(int64(1), int64(3), "missing", fixedTime).
+			WillReturnError(sql.ErrNoRows)
+
+		chunk, err := ch.GetRange(context.Background(), "missing", 0, 3)
+
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.Empty(t, chunk)
+	})
+
+	t.Run("Should return error for an invalid range", func(t *testing.T) {
+		chunk, err := ch.GetRange(context.Background(), "key", -1, 0)
+
+		assert.Error(t, err, "Expected error for invalid range")
+		assert.Equal(t, "invalid range: offset -1, length 0", err.Error())
+		assert.Empty(t, chunk)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return error if query fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT substr\(value, \?, \?\) AS chunk FROM cache`).
+			WithArgs(int64(1), int64(3), "key", fixedTime).
+			WillReturnError(sql.ErrConnDone)
+
+		chunk, err := ch.GetRange(context.Background(), "key", 0, 3)
+
+		assert.Error(t, err, "Expected error for failing query")
+		assert.Empty(t, chunk)
+	})
+}
+
+func TestCache_GetTTL(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("Should return the remaining ttl of the key", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT expires_at FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"expires_at"}).
+				AddRow(fixedTime.Add(90 * time.Second)))
+
+		ttl, err := ch.GetTTL(context.Background(), "key")
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.Equal(t, 90*time.Second, ttl)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return NoTTL if the key never expires", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT expires_at FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"expires_at"}).AddRow(neverExpires))
+
+		ttl, err := ch.GetTTL(context.Background(), "key")
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.Equal(t, NoTTL, ttl)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return ErrKeyNotFound if key does not exist", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT expires_at FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("missing", fixedTime).
+			WillReturnError(sql.ErrNoRows)
+
+		ttl, err := ch.GetTTL(context.Background(), "missing")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.Zero(t, ttl)
+	})
+
+	t.Run("Should return error if query fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT expires_at FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("key", fixedTime).
+			WillReturnError(sql.ErrConnDone)
+
+		ttl, err := ch.GetTTL(context.Background(), "key")
+
+		assert.Error(t, err, "Expected error for failing query")
+		assert.Equal(t, "error getting ttl: sql: connection is already closed", err.Error())
+		assert.Zero(t, ttl)
+	})
+}
+
+func TestCache_Persist(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("Should remove the expiration of the key", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET expires_at = \?, ttl = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs(neverExpires.In(tz), int64(0), "key", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := ch.Persist(context.Background(), "key")
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return ErrKeyNotFound if key does not exist", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET expires_at = \?, ttl = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs(neverExpires.In(tz), int64(0), "missing", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := ch.Persist(context.Background(), "missing")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("Should return error if UPDATE query fails", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET expires_at = \?, ttl = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs(neverExpires.In(tz), int64(0), "key", fixedTime).
+			WillReturnError(fmt.Errorf("mock update error"))
+
+		err := ch.Persist(context.Background(), "key")
+
+		assert.Error(t, err, "Expected error for failing query")
+		assert.Equal(t, "error persisting key: mock update error", err.Error())
+	})
+}
+
+func TestCache_Append(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("Should append the suffix to the value", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET value = CAST\(value \|\| \? AS BLOB\), last_accessed_at = \?, checksum = \?, updated_at = \?2, version = version \+ 1 WHERE key = \? AND expires_at > \? AND length\(value\) \+ length\(\?1\) <= \?`).
+			WithArgs([]byte("-suffix"), fixedTime, nil, "key", fixedTime, int64(math.MaxInt64)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := ch.Append(context.Background(), "key", "-suffix")
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return ErrKeyNotFound if key does not exist", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET value = CAST\(value \|\| \? AS BLOB\)`).
+			WithArgs([]byte("-suffix"), fixedTime, nil, "missing", fixedTime, int64(math.MaxInt64)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := ch.Append(context.Background(), "missing", "-suffix")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("Should return error if UPDATE query fails", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET value = CAST\(value \|\| \? AS BLOB\)`).
+			WithArgs([]byte("-suffix"), fixedTime, nil, "key", fixedTime, int64(math.MaxInt64)).
+			WillReturnError(fmt.Errorf("mock update error"))
+
+		err := ch.Append(context.Background(), "key", "-suffix")
+
+		assert.Error(t, err, "Expected error for failing query")
+		assert.Equal(t, "error appending value: mock update error", err.Error())
+	})
+
+	t.Run("Should return ErrValueTooLarge if the value would exceed the limit", func(t *testing.T) {
+		ch := &cache{queries: ch.queries, timeSource: ch.timeSource, maxValueSize: 8}
+		sqlMock.ExpectExec(`UPDATE cache SET value = CAST\(value \|\| \? AS BLOB\)`).
+			WithArgs([]byte("-suffix"), fixedTime, nil, "key", fixedTime, int64(8)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT expires_at FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"expires_at"}).AddRow(fixedTime.Add(time.Hour)))
+
+		err := ch.Append(context.Background(), "key", "-suffix")
+
+		assert.ErrorIs(t, err, ErrValueTooLarge)
+		assert.Equal(
+			t,
+			`value too large: appending 7 bytes to key "key" exceeds the limit of 8 bytes`,
+			err.Error(),
+		)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return ErrKeyNotFound if the key does not exist with a limit", func(t *testing.T) {
+		ch := &cache{queries: ch.queries, timeSource: ch.timeSource, maxValueSize: 8}
+		sqlMock.ExpectExec(`UPDATE cache SET value = CAST\(value \|\| \? AS BLOB\)`).
+			WithArgs([]byte("-suffix"), fixedTime, nil, "missing", fixedTime, int64(8)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT expires_at FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("missing", fixedTime).
+			WillReturnError(sql.ErrNoRows)
+
+		err := ch.Append(context.Background(), "missing", "-suffix")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
+
+func TestCache_SetBytes(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should store binary values as is", func(t *testing.T) {
+		value := []byte{0x1f, 0x8b, 0x00, 0xff}
+
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("key", value, fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.SetBytes(context.Background(), "key", value, time.Hour)
+
+		assert.NoError(t, err, "Expected no error when setting binary value")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the insert fails", func(t *testing.T) {
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("key", []byte{0x00}, fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, nil).
+			WillReturnError(fmt.Errorf("mock insert error"))
+
+		err := ch.SetBytes(context.Background(), "key", []byte{0x00}, time.Hour)
+
+		assert.Error(t, err, "Expected an error when the insert fails")
+		assert.Equal(t, "error setting cache: mock insert error", err.Error())
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should store entries that never expire for a zero or negative TTL", func(t *testing.T) {
+		for _, ttl := range []time.Duration{0, NoTTL} {
+			sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+				WithArgs("key", []byte{0x00}, neverExpires.In(tz), fixedTime, int64(ttl), nil, nil).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+
+			err := ch.SetBytes(context.Background(), "key", []byte{0x00}, ttl)
+
+			assert.NoError(t, err, "Expected no error when setting the value")
+		}
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestCache_SetWithExpireAt(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should store the expiration time in the timezone of the cache", func(t *testing.T) {
+		expireAt := time.Date(2024, 11, 22, 10, 30, 0, 0, time.FixedZone("BRT", -3*60*60))
+
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("token", []byte("value"), expireAt.In(tz), fixedTime, int64(0), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.SetWithExpireAt(context.Background(), "token", "value", expireAt)
+
+		assert.NoError(t, err, "Expected no error when setting the value")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should store an entry that never expires for a zero time", func(t *testing.T) {
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("token", []byte("value"), neverExpires.In(tz), fixedTime, int64(0), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.SetWithExpireAt(context.Background(), "token", "value", time.Time{})
+
+		assert.NoError(t, err, "Expected no error when setting the value")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestCache_GetBytes(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ch := &cache{
+		timeSource: timeSource{
+			Timezone: time.UTC,
+			Now:      time.Now,
+		},
+		queries: queries.New(db),
+	}
+
+	t.Run("should return binary values as stored", func(t *testing.T) {
+		value := []byte{0x1f, 0x8b, 0x00, 0xff}
+
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow(value, nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
+			WithArgs(sqlmock.AnyArg(), "key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		got, err := ch.GetBytes(context.Background(), "key")
+
+		assert.NoError(t, err, "Expected no error when getting binary value")
+		assert.Equal(t, value, got)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrKeyNotFound if the key does not exist", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("missing", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		got, err := ch.GetBytes(context.Background(), "missing")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound, "Expected ErrKeyNotFound for a missing key")
+		assert.Nil(t, got)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestCache_MaxValueSize(t *testing.T) {
+	ctx := context.Background()
+	ch := &cache{maxValueSize: 4}
+
+	t.Run("should reject values larger than the limit on Set", func(t *testing.T) {
+		err := ch.Set(ctx, "key", "too large", time.Hour)
+
+		assert.ErrorIs(t, err, ErrValueTooLarge, "Expected ErrValueTooLarge for a large value")
+		assert.Equal(t, "value too large: 9 bytes exceeds the limit of 4 bytes", err.Error())
+	})
+
+	t.Run("should reject values larger than the limit on SetNX", func(t *testing.T) {
+		ok, err := ch.SetNX(ctx, "key", "too large", time.Hour)
+
+		assert.ErrorIs(t, err, ErrValueTooLarge, "Expected ErrValueTooLarge for a large value")
+		assert.False(t, ok)
+	})
+
+	t.Run("should reject suffixes larger than the limit on Append", func(t *testing.T) {
+		err := ch.Append(ctx, "key", "too large")
+
+		assert.ErrorIs(t, err, ErrValueTooLarge, "Expected ErrValueTooLarge for a large suffix")
+	})
+
+	t.Run("should reject loaded values larger than the limit", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		ch := &cache{
+			queries:      queries.New(db),
+			maxValueSize: 4,
+			timeSource:   timeSource{Timezone: time.UTC, Now: time.Now},
+		}
+
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		value, err := ch.GetOrSet(ctx, "key", time.Hour, func(_ context.Context) (string, error) {
+			return "too large", nil
+		})
+
+		assert.ErrorIs(t, err, ErrValueTooLarge, "Expected ErrValueTooLarge for a large value")
+		assert.Empty(t, value)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should not limit values when the limit is zero", func(t *testing.T) {
+		ch := &cache{}
+
+		assert.NoError(t, ch.checkValueSize(1<<30), "Expected no limit when the size is zero")
+	})
+}
+
+func TestCache_KeyValidation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should reject empty keys", func(t *testing.T) {
+		ch := &cache{}
+
+		err := ch.Set(ctx, "", "value", time.Hour)
+		assert.ErrorIs(t, err, ErrInvalidKey, "Expected ErrInvalidKey on Set")
+		assert.Equal(t, "invalid key: empty key", err.Error())
+
+		_, err = ch.Get(ctx, "")
+		assert.ErrorIs(t, err, ErrInvalidKey, "Expected ErrInvalidKey on Get")
+
+		err = ch.Del(ctx, "")
+		assert.ErrorIs(t, err, ErrInvalidKey, "Expected ErrInvalidKey on Del")
+	})
+
+	t.Run("should reject keys longer than the limit", func(t *testing.T) {
+		ch := &cache{maxKeyLength: 4}
+
+		_, err := ch.SetNX(ctx, "too long", "value", time.Hour)
+
+		assert.ErrorIs(t, err, ErrInvalidKey, "Expected ErrInvalidKey for a long key")
+		assert.Equal(t, "invalid key: 8 bytes exceeds the limit of 4 bytes", err.Error())
+		assert.NoError(t, ch.checkKey("key"), "Expected keys within the limit to be valid")
+	})
+
+	t.Run("should reject keys not matching the pattern", func(t *testing.T) {
+		ch := &cache{keyPattern: regexp.MustCompile(`^[a-z:]+$`)}
+
+		err := ch.Batch().Set("user 1", "value", time.Hour).Exec(ctx)
+
+		assert.ErrorIs(t, err, ErrInvalidKey, "Expected ErrInvalidKey for a key with a space")
+		assert.Equal(t, `invalid key: "user 1" does not match ^[a-z:]+$`, err.Error())
+		assert.NoError(t, ch.checkKey("user:one"), "Expected matching keys to be valid")
+	})
+
+	t.Run("should reject invalid keys before reaching the database", func(t *testing.T) {
+		ch := &cache{}
+
+		tests := []struct {
+			name string
+			call func(key string) error
+		}{
+			{
+				name: "Append",
+				call: func(key string) error { return ch.Append(ctx, key, "suffix") },
+			},
+			{
+				name: "Persist",
+				call: func(key string) error { return ch.Persist(ctx, key) },
+			},
+			{
+				name: "GetTTL",
+				call: func(key string) error {
+					_, err := ch.GetTTL(ctx, key)
+					return err
+				},
+			},
+			{
+				name: "GetRange",
+				call: func(key string) error {
+					_, err := ch.GetRange(ctx, key, 0, 1)
+					return err
+				},
+			},
+			{
+				name: "MGet",
+				call: func(key string) error {
+					_, err := ch.MGet(ctx, "key", key)
+					return err
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := tt.call("")
+
+				assert.ErrorIs(t, err, ErrInvalidKey, "Expected ErrInvalidKey on %s", tt.name)
+			})
+		}
+	})
+}
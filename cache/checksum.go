@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// castagnoli is the CRC32 table of the checksums of the values, hardware accelerated
+// on most platforms.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// checksum returns the checksum stored with a value, or NULL if checksums are disabled.
+func (ch *cache) checksum(value []byte) sql.NullInt64 {
+	if !ch.checksums {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: int64(crc32.Checksum(value, castagnoli)), Valid: true}
+}
+
+// verifyChecksum checks a value read from the database against its stored checksum
+// and logs the mismatches.
+// Values stored without a checksum, before checksums were enabled, are not verified.
+func (ch *cache) verifyChecksum(
+	ctx context.Context,
+	key string,
+	value []byte,
+	checksum sql.NullInt64,
+) error {
+	err := ch.matchChecksum(key, value, checksum)
+	ch.logCorruptValue(ctx, key, err)
+
+	return err
+}
+
+// matchChecksum checks a value against its stored checksum without logging the mismatch.
+// It is used in the transactions: the logger writes through the connection they hold, so
+// the mismatch is logged with logCorruptValue once the transaction is over.
+func (ch *cache) matchChecksum(key string, value []byte, checksum sql.NullInt64) error {
+	if !ch.checksums || !checksum.Valid {
+		return nil
+	}
+
+	if int64(crc32.Checksum(value, castagnoli)) != checksum.Int64 {
+		return fmt.Errorf("reading key %q: %w", key, ErrCorruptValue)
+	}
+
+	return nil
+}
+
+// logCorruptValue logs the checksum mismatch of a key if err is ErrCorruptValue.
+func (ch *cache) logCorruptValue(ctx context.Context, key string, err error) {
+	if errors.Is(err, ErrCorruptValue) {
+		ch.logger.Error(ctx, fmt.Sprintf("checksum mismatch for key %q", key))
+	}
+}
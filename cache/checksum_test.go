@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"hash/crc32"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
+)
+
+func TestCache_Checksums(t *testing.T) {
+	ctx := context.Background()
+	value := []byte("value")
+	checksum := sql.NullInt64{Int64: int64(crc32.Checksum(value, castagnoli)), Valid: true}
+
+	t.Run("should not compute checksums when disabled", func(t *testing.T) {
+		ch := &cache{}
+
+		assert.False(t, ch.checksum(value).Valid, "Expected no checksum")
+		assert.NoError(t, ch.verifyChecksum(ctx, "key", []byte("other"), checksum),
+			"Expected the values not to be verified")
+	})
+
+	t.Run("should compute the CRC32 of the value", func(t *testing.T) {
+		ch := &cache{checksums: true}
+
+		assert.Equal(t, checksum, ch.checksum(value))
+		assert.NoError(t, ch.verifyChecksum(ctx, "key", value, checksum),
+			"Expected the value to match its checksum")
+	})
+
+	t.Run("should not verify the values stored without a checksum", func(t *testing.T) {
+		ch := &cache{checksums: true}
+
+		err := ch.verifyChecksum(ctx, "key", value, sql.NullInt64{})
+
+		assert.NoError(t, err, "Expected the value not to be verified")
+	})
+
+	t.Run("should return ErrCorruptValue when the value does not match", func(t *testing.T) {
+		logger := logMocks.NewLoggerMock(t)
+		logger.EXPECT().Error(ctx, `checksum mismatch for key "key"`)
+		ch := &cache{checksums: true, logger: logger}
+
+		err := ch.verifyChecksum(ctx, "key", []byte("other"), checksum)
+
+		assert.ErrorIs(t, err, ErrCorruptValue, "Expected ErrCorruptValue")
+	})
+
+	t.Run("should store the checksum with the value and verify it on Get", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+		logger := logMocks.NewLoggerMock(t)
+		logger.EXPECT().Error(mock.Anything, `checksum mismatch for key "key"`)
+		ch := &cache{
+			checksums: true,
+			logger:    logger,
+			queries:   queries.New(db),
+			access:    newAccessBuffer(),
+			timeSource: timeSource{
+				Timezone: time.UTC,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}
+
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("key", value, fixedTime.Add(time.Minute), fixedTime, int64(time.Minute),
+				checksum.Int64, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).
+				AddRow([]byte("other"), checksum.Int64))
+
+		err = ch.SetBytes(ctx, "key", value, time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		_, err = ch.Get(ctx, "key")
+		assert.ErrorIs(t, err, ErrCorruptValue, "Expected ErrCorruptValue")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should verify the value and store the checksum of the appended one", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			})
+		ch := &cache{
+			Database:  dbMock,
+			checksums: true,
+			timeSource: timeSource{
+				Timezone: time.UTC,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}
+		appended := int64(crc32.Checksum([]byte("value-suffix"), castagnoli))
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`UPDATE cache\s+SET key = key\s+WHERE 0`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow(value, checksum.Int64))
+		sqlMock.ExpectExec(`UPDATE cache SET value = CAST\(value \|\| \? AS BLOB\)`).
+			WithArgs([]byte("-suffix"), fixedTime, appended, "key", fixedTime, int64(math.MaxInt64)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+
+		err = ch.Append(ctx, "key", "-suffix")
+
+		assert.NoError(t, err, "Expected no error while appending to the key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should not append to a corrupted value", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			})
+		logger := logMocks.NewLoggerMock(t)
+		logger.EXPECT().Error(mock.Anything, `checksum mismatch for key "key"`)
+		ch := &cache{
+			Database:  dbMock,
+			checksums: true,
+			logger:    logger,
+			timeSource: timeSource{
+				Timezone: time.UTC,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`UPDATE cache\s+SET key = key\s+WHERE 0`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow([]byte("other"), checksum.Int64))
+		sqlMock.ExpectRollback()
+
+		err = ch.Append(ctx, "key", "-suffix")
+
+		assert.ErrorIs(t, err, ErrCorruptValue, "Expected ErrCorruptValue")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// coalesce runs read once for the concurrent calls with the same flight and returns its
+// result to all of them, reporting whether it was shared.
+// The read outlives the callers that give up, so that it isn't cancelled for the ones
+// still waiting; it is bounded by the operation timeout instead.
+func (ch *cache) coalesce(
+	ctx context.Context,
+	flight string,
+	read func(ctx context.Context) (any, error),
+) (any, bool, error) {
+	result := ch.gets.DoChan(flight, func() (any, error) {
+		readCtx, cancel := ch.withOpTimeout(context.WithoutCancel(ctx))
+		defer cancel()
+
+		return read(readCtx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case res := <-result:
+		return res.Val, res.Shared, res.Err
+	}
+}
+
+// getValue reads the live value of a key from the database, sharing the query between
+// the concurrent reads of the key. It returns ErrKeyNotFound or ErrKeyExpired on a miss.
+func (ch *cache) getValue(ctx context.Context, key string, now time.Time) ([]byte, error) {
+	val, shared, err := ch.coalesce(ctx, "value:"+key, func(ctx context.Context) (any, error) {
+		row, err := ch.reads().GetValue(ctx, queries.GetValueParams{
+			Key:       key,
+			ExpiresAt: now,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ch.missError(ctx, key, now)
+			}
+
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		if err := ch.verifyChecksum(ctx, key, row.Value, row.Checksum); err != nil {
+			return nil, err
+		}
+
+		return row.Value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Each caller gets its own copy, so that changing it doesn't affect the others.
+	value := val.([]byte)
+	if shared {
+		value = bytes.Clone(value)
+	}
+
+	return value, nil
+}
+
+// getEntry reads the live entry of a key from the database, sharing the query between
+// the concurrent reads of the key. It returns ErrKeyNotFound or ErrKeyExpired on a miss.
+func (ch *cache) getEntry(
+	ctx context.Context,
+	key string,
+	now time.Time,
+) (queries.GetEntryRow, error) {
+	val, shared, err := ch.coalesce(ctx, "entry:"+key, func(ctx context.Context) (any, error) {
+		entry, err := ch.reads().GetEntry(ctx, queries.GetEntryParams{
+			Key:       key,
+			ExpiresAt: now,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ch.missError(ctx, key, now)
+			}
+
+			return nil, fmt.Errorf("error getting value: %w", err)
+		}
+		if err := ch.verifyChecksum(ctx, key, entry.Value, entry.Checksum); err != nil {
+			return nil, err
+		}
+
+		return entry, nil
+	})
+	if err != nil {
+		return queries.GetEntryRow{}, err
+	}
+
+	entry := val.(queries.GetEntryRow)
+	if shared {
+		entry.Value = bytes.Clone(entry.Value)
+	}
+
+	return entry, nil
+}
+
+// isMiss reports whether the error of a read is a miss.
+func isMiss(err error) bool {
+	return errors.Is(err, ErrKeyNotFound) || errors.Is(err, ErrKeyExpired)
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_CoalesceGets(t *testing.T) {
+	ctx := context.Background()
+
+	newCache := func(t *testing.T) (*cache, sqlmock.Sqlmock) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		t.Cleanup(func() { db.Close() })
+
+		return &cache{
+			timeSource: timeSource{Timezone: time.UTC, Now: time.Now},
+			queries:    queries.New(db),
+			access:     newAccessBuffer(),
+		}, sqlMock
+	}
+
+	t.Run("should share a single query between concurrent reads", func(t *testing.T) {
+		ch, sqlMock := newCache(t)
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("hot", sqlmock.AnyArg()).
+			WillDelayFor(100 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow("value", nil))
+
+		var wg sync.WaitGroup
+		values := make([][]byte, 10)
+		errs := make([]error, 10)
+		for i := range values {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				values[i], errs[i] = ch.GetBytes(ctx, "hot")
+			}()
+		}
+		wg.Wait()
+
+		for i := range values {
+			assert.NoError(t, errs[i], "Expected no error for the shared read")
+			assert.Equal(t, []byte("value"), values[i])
+		}
+		values[0][0] = 'V'
+		assert.Equal(t, []byte("value"), values[1], "Expected each read to get its own copy")
+		assert.Equal(t, int64(10), ch.metrics.hits.Load(), "Expected a hit per read")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should share the misses", func(t *testing.T) {
+		ch, sqlMock := newCache(t)
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("missing", sqlmock.AnyArg()).
+			WillDelayFor(100 * time.Millisecond).
+			WillReturnError(sql.ErrNoRows)
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache WHERE key = \? AND expires_at <= \?`).
+			WithArgs("missing", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := ch.GetBytes(ctx, "missing")
+				assert.ErrorIs(t, err, ErrKeyNotFound, "Expected ErrKeyNotFound for every read")
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(5), ch.metrics.misses.Load(), "Expected a miss per read")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should stop waiting when the context of a read is done", func(t *testing.T) {
+		ch, sqlMock := newCache(t)
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("slow", sqlmock.AnyArg()).
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow("value", nil))
+
+		done := make(chan []byte)
+		go func() {
+			value, _ := ch.GetBytes(ctx, "slow")
+			done <- value
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		readCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := ch.GetBytes(readCtx, "slow")
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded, "Expected the read to give up")
+		assert.Equal(t, []byte("value"), <-done, "Expected the other read to get the value")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Codec encodes and decodes the values stored with SetValue and GetValue.
+// Implementations must be safe for concurrent use.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes values as JSON. It is the default codec of the cache.
+type JSONCodec struct{}
+
+// Marshal encodes v as JSON.
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes the JSON data into v.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec encodes values with encoding/gob.
+// Interface values must be registered with gob.Register before they are encoded.
+type GobCodec struct{}
+
+// Marshal encodes v with gob.
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the gob data into v.
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// SetValue encodes a value with the codec of the cache and stores it with the given TTL.
+// If the key already exists, it is updated with the new value and TTL.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - value: the value to encode
+//   - ttl: the time-to-live for the cache entry
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithCodec(cache.GobCodec{}))
+//	defer cache.Close(ctx)
+//
+//	err := cache.SetValue(ctx, "user:1", User{Name: "John"}, 10*time.Second)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) SetValue(ctx context.Context, key string, value any, ttl time.Duration) error {
+	data, err := ch.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding value: %w", err)
+	}
+
+	return ch.SetBytes(ctx, key, data, ttl)
+}
+
+// GetValue retrieves a value from the cache by key and decodes it into dest
+// with the codec of the cache.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - dest: a pointer to the value to decode into
+//
+// Returns:
+//   - error: an error if the operation failed, ErrKeyNotFound if the key does not exist
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	var user User
+//	err := cache.GetValue(ctx, "user:1", &user)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) GetValue(ctx context.Context, key string, dest any) error {
+	data, err := ch.GetBytes(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	err = ch.codec.Unmarshal(data, dest)
+	if err != nil {
+		return fmt.Errorf("decoding value: %w", err)
+	}
+
+	return nil
+}
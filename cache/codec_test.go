@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+type codecUser struct {
+	Name string
+	Age  int
+}
+
+func TestCodecs(t *testing.T) {
+	codecs := map[string]Codec{
+		"json": JSONCodec{},
+		"gob":  GobCodec{},
+	}
+
+	for name, codec := range codecs {
+		t.Run(name+" should round-trip structs", func(t *testing.T) {
+			data, err := codec.Marshal(codecUser{Name: "John", Age: 30})
+			assert.NoError(t, err, "Expected no error while encoding")
+
+			var user codecUser
+			err = codec.Unmarshal(data, &user)
+
+			assert.NoError(t, err, "Expected no error while decoding")
+			assert.Equal(t, codecUser{Name: "John", Age: 30}, user)
+		})
+
+		t.Run(name+" should return error for invalid data", func(t *testing.T) {
+			var user codecUser
+			err := codec.Unmarshal([]byte("invalid"), &user)
+
+			assert.Error(t, err, "Expected an error while decoding invalid data")
+		})
+	}
+}
+
+type failingCodec struct{}
+
+func (failingCodec) Marshal(_ any) ([]byte, error) {
+	return nil, fmt.Errorf("mock marshal error")
+}
+
+func (failingCodec) Unmarshal(_ []byte, _ any) error {
+	return fmt.Errorf("mock unmarshal error")
+}
+
+func TestCache_SetValue(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		codec:   JSONCodec{},
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should store the encoded value", func(t *testing.T) {
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("user:1", []byte(`{"Name":"John","Age":30}`), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.SetValue(context.Background(), "user:1", codecUser{Name: "John", Age: 30}, time.Hour)
+
+		assert.NoError(t, err, "Expected no error when setting the value")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the value cannot be encoded", func(t *testing.T) {
+		ch := &cache{codec: failingCodec{}}
+
+		err := ch.SetValue(context.Background(), "user:1", codecUser{}, time.Hour)
+
+		assert.Error(t, err, "Expected an error when encoding fails")
+		assert.Equal(t, "encoding value: mock marshal error", err.Error())
+	})
+}
+
+func TestCache_GetValue(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ch := &cache{
+		queries: queries.New(db),
+		codec:   JSONCodec{},
+		timeSource: timeSource{
+			Timezone: time.UTC,
+			Now:      time.Now,
+		},
+	}
+
+	t.Run("should decode the stored value", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("user:1", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow([]byte(`{"Name":"John","Age":30}`), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
+			WithArgs(sqlmock.AnyArg(), "user:1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		var user codecUser
+		err := ch.GetValue(context.Background(), "user:1", &user)
+
+		assert.NoError(t, err, "Expected no error when getting the value")
+		assert.Equal(t, codecUser{Name: "John", Age: 30}, user)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrKeyNotFound if the key does not exist", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("missing", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		var user codecUser
+		err := ch.GetValue(context.Background(), "missing", &user)
+
+		assert.ErrorIs(t, err, ErrKeyNotFound, "Expected ErrKeyNotFound for a missing key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the value cannot be decoded", func(t *testing.T) {
+		ch.codec = failingCodec{}
+		defer func() { ch.codec = JSONCodec{} }()
+
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("user:1", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow([]byte("invalid"), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
+			WithArgs(sqlmock.AnyArg(), "user:1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		var user codecUser
+		err := ch.GetValue(context.Background(), "user:1", &user)
+
+		assert.Error(t, err, "Expected an error when decoding fails")
+		assert.Equal(t, "decoding value: mock unmarshal error", err.Error())
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// SetDerived declares that the entry of key was derived from the entries of parents, so
+// that writing or deleting any of the parents deletes the entry of key, and the entries
+// derived from it, in cascade. The cache must be created with WithDependencies.
+//
+// The cascade follows the changes reported to the hooks: the writes, deletes, evictions
+// and expirations of the parents, see Hooks. Namespace flushes, binary keys and writes
+// of other processes sharing the database don't cascade.
+//
+// A write or delete of a parent drops the dependencies of the deleted entries, so call
+// SetDerived again each time a derived entry is rebuilt. A derived entry keeps its
+// parents when it is written, and drops them when it is deleted.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the key of the derived entry
+//   - parents: the keys the entry was derived from
+//
+// Returns:
+//   - error: an error if the operation failed, ErrDependenciesDisabled if the cache was
+//     created without WithDependencies, ErrInvalidKey if a key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithDependencies())
+//	defer cache.Close(ctx)
+//
+//	err = cache.Set(ctx, "page:home", html, time.Hour)
+//	err = cache.SetDerived(ctx, "page:home", "fragment:header", "fragment:footer")
+//	err = cache.Set(ctx, "fragment:header", header, time.Hour) // deletes page:home
+func (ch *cache) SetDerived(ctx context.Context, key string, parents ...string) error {
+	if !ch.dependencies {
+		return ErrDependenciesDisabled
+	}
+	if err := ch.checkKey(key); err != nil {
+		return err
+	}
+	for _, parent := range parents {
+		if err := ch.checkKey(parent); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	err := ch.retryBusy(ctx, func() error {
+		return ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			queriesWithTx := ch.queries.WithTx(tx)
+			for _, parent := range parents {
+				params := queries.AddDependencyParams{
+					Parent: parent,
+					Child:  key,
+				}
+				if err := queriesWithTx.AddDependency(ctx, params); err != nil {
+					return fmt.Errorf("adding dependency on %q: %w", parent, err)
+				}
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("error setting dependencies: %w", err)
+	}
+
+	return nil
+}
+
+// setupDependencies deletes the keys derived from the changed keys, if enabled.
+func (ch *cache) setupDependencies() {
+	if !ch.dependencies {
+		return
+	}
+
+	ch.hooks = ch.dependencyHooks(ch.hooks)
+}
+
+// dependencyHooks returns hooks that call hooks and then delete the keys derived from
+// the changed keys.
+func (ch *cache) dependencyHooks(hooks Hooks) Hooks {
+	return Hooks{
+		OnSet: func(ctx context.Context, key string) {
+			hooks.set(ctx, key)
+			ch.invalidateDependents(ctx, hooks, false, key)
+		},
+		OnDelete: func(ctx context.Context, key string) {
+			hooks.delete(ctx, key)
+			ch.invalidateDependents(ctx, hooks, true, key)
+		},
+		OnEvict: func(ctx context.Context, keys []string) {
+			hooks.evict(ctx, keys)
+			ch.invalidateDependents(ctx, hooks, true, keys...)
+		},
+		OnExpire: func(ctx context.Context, keys []string) {
+			hooks.expire(ctx, keys)
+			ch.invalidateDependents(ctx, hooks, true, keys...)
+		},
+	}
+}
+
+// invalidateDependents deletes the entries derived from the keys and their dependencies,
+// and the parents of the keys if they were deleted. The deleted entries are reported to
+// the OnDelete hook.
+// The keys are already changed, so a failure is logged instead of returned.
+func (ch *cache) invalidateDependents(
+	ctx context.Context,
+	hooks Hooks,
+	deleted bool,
+	keys ...string,
+) {
+	var dependents []string
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		queriesWithTx := ch.queries.WithTx(tx)
+		for _, key := range keys {
+			keyDependents, err := queriesWithTx.DeleteDependents(ctx, key)
+			if err != nil {
+				return fmt.Errorf("deleting dependents of %q: %w", key, err)
+			}
+			dependents = append(dependents, keyDependents...)
+
+			if err := queriesWithTx.DeleteDependencies(ctx, key); err != nil {
+				return fmt.Errorf("deleting dependencies of %q: %w", key, err)
+			}
+			if !deleted {
+				continue
+			}
+			if err := queriesWithTx.DeleteParents(ctx, key); err != nil {
+				return fmt.Errorf("deleting parents of %q: %w", key, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		ch.logger.Error(ctx, fmt.Sprintf("error invalidating dependents: %v", err))
+		return
+	}
+
+	for _, dependent := range dependents {
+		ch.memory.del(dependent)
+		hooks.delete(ctx, dependent)
+	}
+}
+
+// SetDerived declares that the entry of a key of the namespace was derived from the
+// entries of parents of the namespace.
+func (ns *namespace) SetDerived(ctx context.Context, key string, parents ...string) error {
+	namespaced := make([]string, len(parents))
+	for i, parent := range parents {
+		namespaced[i] = ns.key(parent)
+	}
+
+	return ns.cache.SetDerived(ctx, ns.key(key), namespaced...)
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
+)
+
+// withTx runs the function given to WithTx in a transaction of db.
+func withTx(t *testing.T, db *sql.DB) func(context.Context, *sql.TxOptions, func(*sql.Tx) error) error {
+	return func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while beginning transaction")
+
+		if err := fn(tx); err != nil {
+			assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+			return fmt.Errorf("error rolling back transaction: %w", err)
+		}
+
+		return tx.Commit()
+	}
+}
+
+func TestCache_SetDerived(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	t.Run("should add a dependency on each parent", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(withTx(t, db))
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache_dependencies \(parent, child\)`).
+			WithArgs("user:1", "report:1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec(`INSERT INTO cache_dependencies \(parent, child\)`).
+			WithArgs("orders:1", "report:1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		ch := &cache{queries: queries.New(db), Database: dbMock, dependencies: true}
+
+		err := ch.SetDerived(ctx, "report:1", "user:1", "orders:1")
+
+		assert.NoError(t, err, "Expected no error while setting the dependencies")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return an error if adding a dependency fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(withTx(t, db))
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache_dependencies \(parent, child\)`).
+			WithArgs("user:1", "report:1").
+			WillReturnError(fmt.Errorf("mock insert error"))
+		sqlMock.ExpectRollback()
+
+		ch := &cache{queries: queries.New(db), Database: dbMock, dependencies: true}
+
+		err := ch.SetDerived(ctx, "report:1", "user:1")
+
+		assert.EqualError(t, err, "error setting dependencies: error rolling back transaction: "+
+			`adding dependency on "user:1": mock insert error`)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrDependenciesDisabled without WithDependencies", func(t *testing.T) {
+		ch := &cache{}
+
+		err := ch.SetDerived(ctx, "report:1", "user:1")
+
+		assert.ErrorIs(t, err, ErrDependenciesDisabled)
+	})
+
+	t.Run("should return ErrInvalidKey for an invalid parent", func(t *testing.T) {
+		ch := &cache{dependencies: true}
+
+		err := ch.SetDerived(ctx, "report:1", "")
+
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
+
+func TestCache_DependencyHooks(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	t.Run("should delete the dependents of a written key", func(t *testing.T) {
+		var set, deleted []string
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(withTx(t, db))
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`WITH RECURSIVE dependents\(key\) AS .* DELETE FROM cache WHERE`).
+			WithArgs("user:1").
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("report:1").AddRow("page:1"))
+		sqlMock.ExpectExec(`WITH RECURSIVE dependents\(key\) AS .* DELETE FROM cache_dependencies`).
+			WithArgs("user:1").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		sqlMock.ExpectCommit()
+
+		ch := &cache{queries: queries.New(db), Database: dbMock}
+
+		hooks := ch.dependencyHooks(Hooks{
+			OnSet:    func(_ context.Context, key string) { set = append(set, key) },
+			OnDelete: func(_ context.Context, key string) { deleted = append(deleted, key) },
+		})
+		hooks.set(ctx, "user:1")
+
+		assert.Equal(t, []string{"user:1"}, set)
+		assert.Equal(t, []string{"report:1", "page:1"}, deleted, "Expected the dependents to be deleted")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should drop the parents of the deleted keys", func(t *testing.T) {
+		var expired []string
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(withTx(t, db))
+
+		sqlMock.ExpectBegin()
+		for _, key := range []string{"a", "b"} {
+			sqlMock.ExpectQuery(`DELETE FROM cache WHERE`).
+				WithArgs(key).
+				WillReturnRows(sqlmock.NewRows([]string{"key"}))
+			sqlMock.ExpectExec(`DELETE FROM cache_dependencies WHERE parent IN`).
+				WithArgs(key).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			sqlMock.ExpectExec(`DELETE FROM cache_dependencies WHERE child = \?`).
+				WithArgs(key).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		sqlMock.ExpectCommit()
+
+		ch := &cache{queries: queries.New(db), Database: dbMock}
+
+		hooks := ch.dependencyHooks(Hooks{
+			OnExpire: func(_ context.Context, keys []string) { expired = keys },
+		})
+		hooks.expire(ctx, []string{"a", "b"})
+
+		assert.Equal(t, []string{"a", "b"}, expired)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should log the errors of the invalidation", func(t *testing.T) {
+		logger := logMocks.NewLoggerMock(t)
+		logger.EXPECT().
+			Error(ctx, "error invalidating dependents: database error")
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("database error"))
+
+		ch := &cache{Database: dbMock, logger: logger}
+
+		ch.dependencyHooks(Hooks{}).delete(ctx, "user:1")
+	})
+}
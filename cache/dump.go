@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// dumpPageSize is the number of entries read or written at once by Export and Import.
+const dumpPageSize = 1000
+
+// dumpEntry is a line of a cache dump.
+type dumpEntry struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+	Value     []byte    `json:"value"`
+}
+
+// Export writes the live entries of the cache to w as JSON lines, one entry per line
+// with its key, its base64 encoded value and its expiration time.
+// The entries are read in pages, so that large caches are not loaded in memory at once.
+// The dump can be loaded in another cache with Import.
+//
+// Parameters:
+//   - ctx: the context
+//   - w: the writer of the dump
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	file, err := os.Create("cache.jsonl")
+//	defer file.Close()
+//
+//	err = cache.Export(ctx, file)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Export(ctx context.Context, w io.Writer) error {
+	return ch.export(ctx, w, "", "")
+}
+
+// Import reads a dump written by Export from r and stores its entries, replacing the
+// existing entries with the same keys. Entries that expired since the export are skipped.
+// The entries are stored in transactions of up to 1000 entries; if an error occurs,
+// the entries stored before it are kept.
+//
+// Parameters:
+//   - ctx: the context
+//   - r: the reader of the dump
+//
+// Returns:
+//   - error: an error if the operation failed, ErrValueTooLarge if a value is too large
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	file, err := os.Open("cache.jsonl")
+//	defer file.Close()
+//
+//	err = cache.Import(ctx, file)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Import(ctx context.Context, r io.Reader) error {
+	return ch.importEntries(ctx, r, "")
+}
+
+// Export writes the live entries of the namespace to w, without the namespace prefix.
+func (ns *namespace) Export(ctx context.Context, w io.Writer) error {
+	return ns.cache.export(ctx, w, ns.prefix, ns.prefixEnd())
+}
+
+// Import stores the entries of a dump in the namespace.
+func (ns *namespace) Import(ctx context.Context, r io.Reader) error {
+	return ns.cache.importEntries(ctx, r, ns.prefix)
+}
+
+// export writes the live entries whose keys are in [prefix, keyTo) to w, removing the
+// prefix from their keys. An empty keyTo means no upper bound.
+func (ch *cache) export(ctx context.Context, w io.Writer, prefix, keyTo string) error {
+	encoder := json.NewEncoder(w)
+	params := queries.ListLiveEntriesParams{
+		KeyFrom: prefix,
+		KeyTo:   keyTo,
+		Now:     ch.timeSource.Now().In(ch.timeSource.Timezone),
+		Limit:   dumpPageSize,
+	}
+
+	for {
+		rows, err := ch.queries.ListLiveEntries(ctx, params)
+		if err != nil {
+			return fmt.Errorf("listing entries: %w", err)
+		}
+
+		for _, row := range rows {
+			entry := dumpEntry{
+				Key:       strings.TrimPrefix(row.Key, prefix),
+				Value:     row.Value,
+				ExpiresAt: row.ExpiresAt,
+			}
+			if err := encoder.Encode(entry); err != nil {
+				return fmt.Errorf("writing entry: %w", err)
+			}
+		}
+
+		if len(rows) < dumpPageSize {
+			return nil
+		}
+
+		// The next page starts right after the last key, "\x00" is the smallest suffix.
+		params.KeyFrom = rows[len(rows)-1].Key + "\x00"
+	}
+}
+
+// importEntries reads a dump from r and stores its entries with the given key prefix.
+func (ch *cache) importEntries(ctx context.Context, r io.Reader, prefix string) error {
+	decoder := json.NewDecoder(r)
+	page := make([]dumpEntry, 0, dumpPageSize)
+
+	for {
+		var entry dumpEntry
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading entry: %w", err)
+		}
+
+		if err := ch.checkValueSize(len(entry.Value)); err != nil {
+			return err
+		}
+
+		entry.Key = prefix + entry.Key
+		if err := ch.checkKey(entry.Key); err != nil {
+			return err
+		}
+		page = append(page, entry)
+		if len(page) == dumpPageSize {
+			if err := ch.storeEntries(ctx, page); err != nil {
+				return err
+			}
+			page = page[:0]
+		}
+	}
+
+	return ch.storeEntries(ctx, page)
+}
+
+// storeEntries stores the given entries of a dump in a single transaction,
+// skipping the expired ones.
+func (ch *cache) storeEntries(ctx context.Context, entries []dumpEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	stored := make([]dumpEntry, 0, len(entries))
+
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		queriesWithTx := queries.New(tx)
+		for _, entry := range entries {
+			if !entry.ExpiresAt.After(now) {
+				continue
+			}
+
+			params := queries.UpsertCacheParams{
+				Key:            entry.Key,
+				Value:          entry.Value,
+				ExpiresAt:      entry.ExpiresAt.In(ch.timeSource.Timezone),
+				LastAccessedAt: now,
+				Checksum:       ch.checksum(entry.Value),
+			}
+			if err := queriesWithTx.UpsertCache(ctx, params); err != nil {
+				return fmt.Errorf("setting key %q: %w", entry.Key, err)
+			}
+			stored = append(stored, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("importing entries: %w", err)
+	}
+
+	for _, entry := range stored {
+		ch.memory.set(entry.Key, entry.Value, entry.ExpiresAt)
+		ch.hooks.set(ctx, entry.Key)
+	}
+	ch.metrics.sets.Add(int64(len(stored)))
+
+	return nil
+}
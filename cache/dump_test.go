@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+)
+
+func TestCache_Export(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	expiresAt := fixedTime.Add(time.Hour)
+	ctx := context.Background()
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should write the live entries as JSON lines", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at FROM cache`).
+			WithArgs("", "", "", fixedTime, dumpPageSize).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at"}).
+				AddRow("a", []byte("1"), expiresAt).
+				AddRow("b", []byte("2"), expiresAt))
+
+		var buf bytes.Buffer
+		err := ch.Export(ctx, &buf)
+
+		expected := `{"expires_at":"2024-11-22T13:00:00Z","key":"a","value":"MQ=="}` + "\n" +
+			`{"expires_at":"2024-11-22T13:00:00Z","key":"b","value":"Mg=="}` + "\n"
+		assert.NoError(t, err, "Expected no error while exporting")
+		assert.Equal(t, expected, buf.String())
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should export the keys of a namespace without the prefix", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at FROM cache`).
+			WithArgs("users:", "users;", "users;", fixedTime, dumpPageSize).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at"}).
+				AddRow("users:1", []byte("John"), expiresAt))
+
+		var buf bytes.Buffer
+		err := ch.Namespace("users").Export(ctx, &buf)
+
+		assert.NoError(t, err, "Expected no error while exporting")
+		assert.Contains(t, buf.String(), `"key":"1"`)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return an error if listing the entries fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at FROM cache`).
+			WillReturnError(fmt.Errorf("mock select error"))
+
+		err := ch.Export(ctx, &bytes.Buffer{})
+
+		assert.EqualError(t, err, "listing entries: mock select error")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestCache_Import(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	withTx := func(dbMock *dbMocks.DatabaseMock) {
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			})
+	}
+
+	newCache := func(dbMock *dbMocks.DatabaseMock) *cache {
+		return &cache{
+			Database: dbMock,
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+			maxValueSize: 8,
+		}
+	}
+
+	dump := `{"expires_at":"2024-11-22T13:00:00Z","key":"a","value":"MQ=="}` + "\n" +
+		`{"expires_at":"2024-11-22T11:00:00Z","key":"expired","value":"Mg=="}` + "\n"
+
+	t.Run("should store the entries that did not expire", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("a", []byte("1"), fixedTime.Add(time.Hour), fixedTime, int64(0), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		var set []string
+		ch := newCache(dbMock)
+		ch.hooks = Hooks{
+			OnSet: func(_ context.Context, key string) { set = append(set, key) },
+		}
+
+		err := ch.Import(ctx, strings.NewReader(dump))
+
+		assert.NoError(t, err, "Expected no error while importing")
+		assert.Equal(t, []string{"a"}, set, "Expected OnSet for the stored entry")
+		assert.Equal(t, int64(1), ch.Metrics().Sets, "Expected one set")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should prefix the keys of a namespace", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("users:a", []byte("1"), fixedTime.Add(time.Hour), fixedTime, int64(0), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		err := newCache(dbMock).Namespace("users").Import(ctx, strings.NewReader(dump))
+
+		assert.NoError(t, err, "Expected no error while importing")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return an error if storing an entry fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		withTx(dbMock)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WillReturnError(fmt.Errorf("mock insert error"))
+		sqlMock.ExpectRollback()
+
+		err := newCache(dbMock).Import(ctx, strings.NewReader(dump))
+
+		assert.EqualError(t, err, `importing entries: setting key "a": mock insert error`)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return an error for a malformed dump", func(t *testing.T) {
+		err := newCache(dbMocks.NewDatabaseMock(t)).Import(ctx, strings.NewReader("{"))
+
+		assert.ErrorContains(t, err, "reading entry:")
+	})
+
+	t.Run("should reject values larger than the max value size", func(t *testing.T) {
+		line := `{"expires_at":"2024-11-22T13:00:00Z","key":"a","value":"YSB2YWx1ZSB0b28gbGFyZ2U="}`
+
+		err := newCache(dbMocks.NewDatabaseMock(t)).Import(ctx, strings.NewReader(line))
+
+		assert.ErrorIs(t, err, ErrValueTooLarge)
+	})
+}
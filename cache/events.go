@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/internal/eventlog"
+)
+
+// taskTrimEvents is the name of the task that deletes the events older than the retention.
+const taskTrimEvents = "trim-events"
+
+// eventsPageSize is the max number of events returned by Events.
+const eventsPageSize = 1000
+
+// ErrEventLogDisabled is returned by Events when the cache was created without WithEventLog.
+var ErrEventLogDisabled = fmt.Errorf("event log disabled")
+
+// EventType is the kind of change recorded by an Event.
+type EventType string
+
+const (
+	EventSet    EventType = "set"    // the entry of the key was written
+	EventDelete EventType = "delete" // the entry of the key was deleted
+	EventExpire EventType = "expire" // the expired entry of the key was deleted
+	EventEvict  EventType = "evict"  // the entry of the key was deleted to free space
+	EventFlush  EventType = "flush"  // every entry whose key starts with Key was deleted
+)
+
+// Event is a change of the keyspace recorded by the event log.
+type Event struct {
+	At   time.Time `json:"at"`
+	Type EventType `json:"type"`
+	Key  string    `json:"key"`
+	ID   int64     `json:"id"`
+}
+
+// Events returns the events recorded after sinceID, in the order they happened, so that
+// a remote copy of the cache can be synchronized incrementally: apply the events and
+// call Events again with the ID of the last one. At most 1000 events are returned per
+// call; an empty result means the copy is up to date.
+//
+// The events older than the retention of the log are deleted, so a copy that falls
+// further behind must be rebuilt, for example with Export.
+//
+// Parameters:
+//   - ctx: the context
+//   - sinceID: the ID of the last event applied, 0 to read from the oldest event kept
+//
+// Returns:
+//   - []Event: the events
+//   - error: ErrEventLogDisabled if the cache was created without WithEventLog
+//
+// Example:
+//
+//	events, err := cache.Events(ctx, lastID)
+//	for _, event := range events {
+//		apply(event)
+//		lastID = event.ID
+//	}
+func (ch *cache) Events(ctx context.Context, sinceID int64) ([]Event, error) {
+	return ch.listEvents(ctx, sinceID, "")
+}
+
+// Events returns the events of the keys of the namespace recorded after sinceID,
+// without the prefix of the namespace. Flushes of the namespace, or of the namespaces
+// and the cache that contain it, are returned with an empty key.
+func (ns *namespace) Events(ctx context.Context, sinceID int64) ([]Event, error) {
+	events, err := ns.listEvents(ctx, sinceID, ns.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range events {
+		if events[i].Type == EventFlush && len(events[i].Key) <= len(ns.prefix) {
+			events[i].Key = ""
+			continue
+		}
+		events[i].Key = strings.TrimPrefix(events[i].Key, ns.prefix)
+	}
+
+	return events, nil
+}
+
+// listEvents returns the events after sinceID of the keys that start with prefix.
+func (ch *cache) listEvents(ctx context.Context, sinceID int64, prefix string) ([]Event, error) {
+	if ch.events == nil {
+		return nil, ErrEventLogDisabled
+	}
+
+	rows, err := ch.events.List(ctx, sinceID, escapeGlob(prefix)+"*", prefix, eventsPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("getting events: %w", err)
+	}
+
+	events := make([]Event, len(rows))
+	for i, row := range rows {
+		events[i] = Event{
+			ID:   row.ID,
+			Type: EventType(row.Type),
+			Key:  row.Key,
+			At:   row.CreatedAt,
+		}
+	}
+
+	return events, nil
+}
+
+// recordEvent appends an event to the log, if enabled.
+// The change is already stored, so a failure is logged instead of returned.
+func (ch *cache) recordEvent(ctx context.Context, eventType EventType, keys ...string) {
+	if ch.events == nil {
+		return
+	}
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	for _, key := range keys {
+		err := ch.events.Record(ctx, string(eventType), key, now)
+		if err != nil {
+			ch.logger.Error(ctx, fmt.Sprintf("error recording %s event: %v", eventType, err))
+		}
+	}
+}
+
+// eventHooks returns hooks that record the changes in the event log and then call hooks.
+func (ch *cache) eventHooks(hooks Hooks) Hooks {
+	return Hooks{
+		OnSet: func(ctx context.Context, key string) {
+			ch.recordEvent(ctx, EventSet, key)
+			hooks.set(ctx, key)
+		},
+		OnDelete: func(ctx context.Context, key string) {
+			ch.recordEvent(ctx, EventDelete, key)
+			hooks.delete(ctx, key)
+		},
+		OnEvict: func(ctx context.Context, keys []string) {
+			ch.recordEvent(ctx, EventEvict, keys...)
+			hooks.evict(ctx, keys)
+		},
+		OnExpire: func(ctx context.Context, keys []string) {
+			ch.recordEvent(ctx, EventExpire, keys...)
+			hooks.expire(ctx, keys)
+		},
+	}
+}
+
+// setupEventLog creates the event log and records the changes of the entries in it,
+// if enabled.
+func (ch *cache) setupEventLog(ctx context.Context) error {
+	if ch.eventRetention <= 0 {
+		return nil
+	}
+
+	events, err := eventlog.NewEventLog(ctx, ch.Database)
+	if err != nil {
+		return err
+	}
+	ch.events = events
+	ch.hooks = ch.eventHooks(ch.hooks)
+
+	return nil
+}
+
+// trimEvents deletes the events older than the retention of the log.
+func (ch *cache) trimEvents(ctx context.Context) error {
+	before := ch.timeSource.Now().In(ch.timeSource.Timezone).Add(-ch.eventRetention)
+
+	_, err := ch.events.Trim(ctx, before)
+	if err != nil {
+		return fmt.Errorf("trimming event log: %w", err)
+	}
+
+	return nil
+}
+
+// trimEventsCache schedules the deletion of the events older than the retention
+// on the sync interval, if the event log is enabled.
+func (ch *cache) trimEventsCache(ctx context.Context) {
+	if ch.events == nil {
+		return
+	}
+
+	task := func() error {
+		err := ch.trimEvents(ctx)
+		if err != nil {
+			ch.logger.Error(ctx, err.Error())
+			return err
+		}
+
+		return nil
+	}
+
+	err := ch.addTask(taskTrimEvents, string(ch.syncInterval), task)
+	if err != nil {
+		err = fmt.Errorf("adding cron task: %w", err)
+		ch.logger.Error(ctx, err.Error())
+	}
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/internal/eventlog/queries"
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
+)
+
+// recordedEvent is an event recorded by eventLogStub.
+type recordedEvent struct {
+	eventType string
+	key       string
+}
+
+// eventLogStub is an in-memory eventlog.EventLog.
+type eventLogStub struct {
+	err      error
+	recorded []recordedEvent
+	listed   []queries.CacheEvent
+	pattern  string
+	prefix   string
+	trimmed  time.Time
+}
+
+func (s *eventLogStub) Record(_ context.Context, eventType, key string, _ time.Time) error {
+	s.recorded = append(s.recorded, recordedEvent{eventType: eventType, key: key})
+	return s.err
+}
+
+func (s *eventLogStub) List(
+	_ context.Context,
+	_ int64,
+	pattern, prefix string,
+	_ int64,
+) ([]queries.CacheEvent, error) {
+	s.pattern, s.prefix = pattern, prefix
+	return s.listed, s.err
+}
+
+func (s *eventLogStub) Trim(_ context.Context, before time.Time) (int64, error) {
+	s.trimmed = before
+	return 0, s.err
+}
+
+func TestCache_Events(t *testing.T) {
+	ctx := context.Background()
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should return ErrEventLogDisabled without an event log", func(t *testing.T) {
+		ch := &cache{}
+
+		events, err := ch.Events(ctx, 0)
+
+		assert.ErrorIs(t, err, ErrEventLogDisabled)
+		assert.Nil(t, events)
+	})
+
+	t.Run("should return the events of every key", func(t *testing.T) {
+		stub := &eventLogStub{listed: []queries.CacheEvent{
+			{ID: 1, Type: "set", Key: "key", CreatedAt: fixedTime},
+		}}
+		ch := &cache{events: stub}
+
+		events, err := ch.Events(ctx, 0)
+
+		assert.NoError(t, err, "Expected no error while getting the events")
+		assert.Equal(t, []Event{{ID: 1, Type: EventSet, Key: "key", At: fixedTime}}, events)
+		assert.Equal(t, "*", stub.pattern)
+	})
+
+	t.Run("should return the events of the namespace without its prefix", func(t *testing.T) {
+		stub := &eventLogStub{listed: []queries.CacheEvent{
+			{ID: 1, Type: "set", Key: "users:1", CreatedAt: fixedTime},
+			{ID: 2, Type: "flush", Key: "users:sessions:", CreatedAt: fixedTime},
+			{ID: 3, Type: "flush", Key: "users:", CreatedAt: fixedTime},
+			{ID: 4, Type: "flush", Key: "", CreatedAt: fixedTime},
+		}}
+		ch := &cache{events: stub}
+
+		events, err := ch.Namespace("users").Events(ctx, 0)
+
+		assert.NoError(t, err, "Expected no error while getting the events")
+		assert.Equal(t, []Event{
+			{ID: 1, Type: EventSet, Key: "1", At: fixedTime},
+			{ID: 2, Type: EventFlush, Key: "sessions:", At: fixedTime},
+			{ID: 3, Type: EventFlush, Key: "", At: fixedTime},
+			{ID: 4, Type: EventFlush, Key: "", At: fixedTime},
+		}, events)
+		assert.Equal(t, "users:*", stub.pattern)
+		assert.Equal(t, "users:", stub.prefix)
+	})
+
+	t.Run("should return an error if listing fails", func(t *testing.T) {
+		ch := &cache{events: &eventLogStub{err: fmt.Errorf("list error")}}
+
+		_, err := ch.Events(ctx, 0)
+
+		assert.ErrorContains(t, err, "getting events: list error")
+	})
+}
+
+func TestCache_EventHooks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should record the changes and call the hooks", func(t *testing.T) {
+		stub := &eventLogStub{}
+		var set, deleted, evicted, expired []string
+		ch := &cache{
+			events:     stub,
+			timeSource: timeSource{Now: time.Now, Timezone: time.UTC},
+		}
+
+		hooks := ch.eventHooks(Hooks{
+			OnSet:    func(_ context.Context, key string) { set = append(set, key) },
+			OnDelete: func(_ context.Context, key string) { deleted = append(deleted, key) },
+			OnEvict:  func(_ context.Context, keys []string) { evicted = keys },
+			OnExpire: func(_ context.Context, keys []string) { expired = keys },
+		})
+		hooks.set(ctx, "a")
+		hooks.delete(ctx, "b")
+		hooks.evict(ctx, []string{"c"})
+		hooks.expire(ctx, []string{"d", "e"})
+
+		assert.Equal(t, []recordedEvent{
+			{eventType: "set", key: "a"},
+			{eventType: "delete", key: "b"},
+			{eventType: "evict", key: "c"},
+			{eventType: "expire", key: "d"},
+			{eventType: "expire", key: "e"},
+		}, stub.recorded)
+		assert.Equal(t, []string{"a"}, set)
+		assert.Equal(t, []string{"b"}, deleted)
+		assert.Equal(t, []string{"c"}, evicted)
+		assert.Equal(t, []string{"d", "e"}, expired)
+	})
+
+	t.Run("should log the errors of the event log", func(t *testing.T) {
+		stub := &eventLogStub{err: fmt.Errorf("record error")}
+		logger := logMocks.NewLoggerMock(t)
+		logger.EXPECT().Error(ctx, "error recording set event: record error")
+		ch := &cache{
+			events:     stub,
+			logger:     logger,
+			timeSource: timeSource{Now: time.Now, Timezone: time.UTC},
+		}
+
+		ch.eventHooks(Hooks{}).set(ctx, "key")
+
+		assert.Len(t, stub.recorded, 1, "Expected the event to be recorded once")
+	})
+}
+
+func TestCache_TrimEvents(t *testing.T) {
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should delete the events older than the retention", func(t *testing.T) {
+		stub := &eventLogStub{}
+		ch := &cache{
+			events:         stub,
+			eventRetention: time.Hour,
+			timeSource:     timeSource{Now: func() time.Time { return fixedTime }, Timezone: time.UTC},
+		}
+
+		err := ch.trimEvents(context.Background())
+
+		assert.NoError(t, err, "Expected no error while trimming the events")
+		assert.Equal(t, fixedTime.Add(-time.Hour), stub.trimmed)
+	})
+
+	t.Run("should return an error if trimming fails", func(t *testing.T) {
+		ch := &cache{
+			events:     &eventLogStub{err: fmt.Errorf("trim error")},
+			timeSource: timeSource{Now: time.Now, Timezone: time.UTC},
+		}
+
+		err := ch.trimEvents(context.Background())
+
+		assert.ErrorContains(t, err, "trimming event log: trim error")
+	})
+}
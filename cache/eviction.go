@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// EvictionPolicy selects and deletes the entries evicted when the database is full.
+//
+// Evict runs inside the purge transaction and must delete at most count entries
+// of the cache table, returning the keys deleted.
+// The cache table has the columns key, value, expires_at, last_accessed_at, version,
+// access_count and pinned; entries with pinned = 1 must not be deleted.
+//
+// Example:
+//
+//	type evictLargest struct{}
+//
+//	func (evictLargest) Evict(ctx context.Context, tx *sql.Tx, count int64) ([]string, error) {
+//		rows, err := tx.QueryContext(ctx, `DELETE FROM cache WHERE key IN (
+//			SELECT key FROM cache WHERE pinned = 0 ORDER BY length(value) DESC LIMIT ?)
+//			RETURNING key`, count)
+//		if err != nil {
+//			return nil, err
+//		}
+//		defer rows.Close()
+//
+//		var keys []string
+//		for rows.Next() {
+//			var key string
+//			if err := rows.Scan(&key); err != nil {
+//				return nil, err
+//			}
+//			keys = append(keys, key)
+//		}
+//		return keys, rows.Err()
+//	}
+//
+//	cache, err := cache.NewCache(ctx, cache.WithEvictionPolicy(evictLargest{}))
+type EvictionPolicy interface {
+	Evict(ctx context.Context, tx *sql.Tx, count int64) ([]string, error)
+}
+
+// LRUPolicy evicts the least recently used entries, in ascending order of their
+// last accessed at timestamp. Pinned entries are skipped. It is the default eviction policy.
+type LRUPolicy struct{}
+
+// Evict deletes the count least recently used entries.
+func (LRUPolicy) Evict(ctx context.Context, tx *sql.Tx, count int64) ([]string, error) {
+	keys, err := queries.New(tx).DeleteKeysByLimit(ctx, count)
+	if err != nil {
+		return nil, fmt.Errorf("deleting least recently used entries: %w", err)
+	}
+
+	return keys, nil
+}
+
+// LFUPolicy evicts the least frequently used entries, in ascending order of the number
+// of reads of each entry; ties are broken by the last accessed at timestamp.
+// Pinned entries are skipped.
+// Unlike LRU, a scan that reads many entries once does not evict the frequently read ones.
+type LFUPolicy struct{}
+
+// Evict deletes the count least frequently used entries.
+func (LFUPolicy) Evict(ctx context.Context, tx *sql.Tx, count int64) ([]string, error) {
+	keys, err := queries.New(tx).DeleteLeastFrequentlyUsed(ctx, count)
+	if err != nil {
+		return nil, fmt.Errorf("deleting least frequently used entries: %w", err)
+	}
+
+	return keys, nil
+}
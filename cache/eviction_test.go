@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// evictionPolicyStub records the eviction requests and returns a batch of keys per request.
+type evictionPolicyStub struct {
+	err     error
+	batches [][]string
+	count   int64
+}
+
+func (p *evictionPolicyStub) Evict(_ context.Context, _ *sql.Tx, count int64) ([]string, error) {
+	p.count = count
+	if len(p.batches) == 0 {
+		return nil, p.err
+	}
+
+	batch := p.batches[0]
+	p.batches = p.batches[1:]
+	return batch, p.err
+}
+
+// keyRows returns the rows of count keys deleted by a DELETE ... RETURNING key query.
+func keyRows(count int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"key"})
+	for i := range count {
+		rows.AddRow(fmt.Sprintf("key-%d", i))
+	}
+
+	return rows
+}
+
+func TestEviction_LRUPolicy(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ctx := context.Background()
+
+	t.Run("should delete the least recently used entries", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT \? \) RETURNING key`).
+			WithArgs(10).
+			WillReturnRows(keyRows(10))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		deleted, err := LRUPolicy{}.Evict(ctx, tx, 10)
+
+		assert.NoError(t, err, "Expected no error while evicting entries")
+		assert.Len(t, deleted, 10, "Expected 10 entries to be deleted")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if delete fails", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN`).
+			WithArgs(10).
+			WillReturnError(fmt.Errorf("mock delete error"))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		_, err = LRUPolicy{}.Evict(ctx, tx, 10)
+
+		assert.EqualError(
+			t,
+			err,
+			"deleting least recently used entries: mock delete error",
+			"Error message should match",
+		)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestEviction_LFUPolicy(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ctx := context.Background()
+
+	t.Run("should delete the least frequently used entries", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY access_count ASC, last_accessed_at ASC LIMIT \? \) RETURNING key`).
+			WithArgs(10).
+			WillReturnRows(keyRows(10))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		deleted, err := LFUPolicy{}.Evict(ctx, tx, 10)
+
+		assert.NoError(t, err, "Expected no error while evicting entries")
+		assert.Len(t, deleted, 10, "Expected 10 entries to be deleted")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if delete fails", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN`).
+			WithArgs(10).
+			WillReturnError(fmt.Errorf("mock delete error"))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		_, err = LFUPolicy{}.Evict(ctx, tx, 10)
+
+		assert.EqualError(
+			t,
+			err,
+			"deleting least frequently used entries: mock delete error",
+			"Error message should match",
+		)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestEviction_CustomPolicy(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ctx := context.Background()
+
+	t.Run("should purge with the configured policy", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(50))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		policy := &evictionPolicyStub{batches: [][]string{{"a", "b", "c"}}}
+		ch := &cache{
+			queries:        queries.New(tx),
+			evictionPolicy: policy,
+		}
+
+		deleted, err := ch.purgeEntriesByPercentage(ctx, tx, 0.2)
+
+		assert.NoError(t, err, "Expected no error while purging entries")
+		assert.Equal(t, int64(10), policy.count, "Expected the policy to be asked for 10 entries")
+		assert.Equal(t, []string{"a", "b", "c"}, deleted, "Expected the keys deleted by the policy")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the policy fails", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(50))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		ch := &cache{
+			queries:        queries.New(tx),
+			evictionPolicy: &evictionPolicyStub{err: fmt.Errorf("mock policy error")},
+		}
+
+		_, err = ch.purgeEntriesByPercentage(ctx, tx, 0.2)
+
+		assert.EqualError(t, err, "delete entries: mock policy error", "Error message should match")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// missError returns the error of a read that found no live entry for the key:
+// ErrKeyExpired if the key has an expired entry, ErrKeyNotFound otherwise.
+// The expired entry is deleted if WithDeleteExpiredOnRead is set.
+func (ch *cache) missError(ctx context.Context, key string, now time.Time) error {
+	expired, err := ch.queries.CountExpiredKey(ctx, queries.CountExpiredKeyParams{
+		Key:       key,
+		ExpiresAt: now,
+	})
+	if err != nil || expired == 0 {
+		return ErrKeyNotFound
+	}
+
+	if ch.deleteExpiredOnRead {
+		ch.deleteExpired(ctx, key, now)
+	}
+
+	return ErrKeyExpired
+}
+
+// deleteExpired deletes the expired entry of a key, unless it is pinned.
+// The entry is left for the purge if the cache is quiesced or the delete fails.
+func (ch *cache) deleteExpired(ctx context.Context, key string, now time.Time) {
+	if !ch.writeMu.TryRLock() {
+		return
+	}
+	defer ch.writeMu.RUnlock()
+
+	deleted, err := ch.queries.DeleteExpiredKey(ctx, queries.DeleteExpiredKeyParams{
+		Key:       key,
+		ExpiresAt: now,
+	})
+	if err != nil {
+		ch.logger.Error(ctx, fmt.Sprintf("error deleting expired key: %v", err))
+		return
+	}
+	if deleted == 0 {
+		return
+	}
+
+	ch.memory.del(key)
+	ch.hooks.expire(ctx, []string{key})
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_MissError(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	expectCount := func(count int64) {
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM cache\s+WHERE key = \? AND expires_at <= \?`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+
+	t.Run("should return ErrKeyNotFound if the key has no entry", func(t *testing.T) {
+		expectCount(0)
+		ch := &cache{queries: queries.New(db)}
+
+		err := ch.missError(ctx, "key", fixedTime)
+
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.NotErrorIs(t, err, ErrKeyExpired)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should return ErrKeyExpired if the entry expired", func(t *testing.T) {
+		expectCount(1)
+		ch := &cache{queries: queries.New(db)}
+
+		err := ch.missError(ctx, "key", fixedTime)
+
+		assert.ErrorIs(t, err, ErrKeyExpired)
+		assert.ErrorIs(t, err, ErrKeyNotFound, "Expected ErrKeyExpired to wrap ErrKeyNotFound")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should delete the expired entry if enabled", func(t *testing.T) {
+		expectCount(1)
+		sqlMock.ExpectExec(`DELETE FROM cache\s+WHERE key = \? AND expires_at <= \? AND pinned = 0`).
+			WithArgs("key", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		var expired []string
+		ch := &cache{
+			queries:             queries.New(db),
+			deleteExpiredOnRead: true,
+			hooks: Hooks{OnExpire: func(_ context.Context, keys []string) {
+				expired = append(expired, keys...)
+			}},
+		}
+
+		err := ch.missError(ctx, "key", fixedTime)
+
+		assert.ErrorIs(t, err, ErrKeyExpired)
+		assert.Equal(t, []string{"key"}, expired, "Expected OnExpire for the deleted entry")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should return ErrKeyNotFound if the lookup fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\)`).WillReturnError(fmt.Errorf("database is locked"))
+		ch := &cache{queries: queries.New(db)}
+
+		err := ch.missError(ctx, "key", fixedTime)
+
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.NotErrorIs(t, err, ErrKeyExpired)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+}
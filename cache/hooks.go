@@ -0,0 +1,64 @@
+package cache
+
+import "context"
+
+// Hooks are callbacks invoked when the entries of the cache change, for example to keep
+// an in-process map or a downstream cache coherent with the cache.
+// Nil hooks are skipped.
+//
+// Hooks run synchronously after the change is stored, so they must be fast and must not
+// write to the cache. The keys are the keys stored in the database, including the prefix
+// of the namespace they were written through.
+// Flush does not call OnDelete since the deleted keys are not known.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithHooks(cache.Hooks{
+//		OnDelete: func(ctx context.Context, key string) {
+//			local.Delete(key)
+//		},
+//	}))
+type Hooks struct {
+	// OnSet is called when an entry is written by Set, SetNX, Append, SetIfVersion, GetSet,
+	// Update, GetOrSet or GetOrSetMulti.
+	OnSet func(ctx context.Context, key string)
+
+	// OnDelete is called when an entry is deleted by Del, DelPrefix or DelPattern.
+	OnDelete func(ctx context.Context, key string)
+
+	// OnEvict is called with the entries deleted by the eviction policy when the
+	// database is full or over the byte budget.
+	OnEvict func(ctx context.Context, keys []string)
+
+	// OnExpire is called with the expired entries deleted by the expired entries purge,
+	// or by reads if WithDeleteExpiredOnRead is set.
+	OnExpire func(ctx context.Context, keys []string)
+}
+
+// set calls OnSet, if defined.
+func (h Hooks) set(ctx context.Context, key string) {
+	if h.OnSet != nil {
+		h.OnSet(ctx, key)
+	}
+}
+
+// delete calls OnDelete, if defined.
+func (h Hooks) delete(ctx context.Context, key string) {
+	if h.OnDelete != nil {
+		h.OnDelete(ctx, key)
+	}
+}
+
+// evict calls OnEvict, if defined and there are evicted keys.
+func (h Hooks) evict(ctx context.Context, keys []string) {
+	if h.OnEvict != nil && len(keys) > 0 {
+		h.OnEvict(ctx, keys)
+	}
+}
+
+// expire calls OnExpire, if defined and there are expired keys.
+func (h Hooks) expire(ctx context.Context, keys []string) {
+	if h.OnExpire != nil && len(keys) > 0 {
+		h.OnExpire(ctx, keys)
+	}
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+)
+
+func TestCache_Hooks(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	var set, deleted []string
+	var evicted, expired [][]string
+	hooks := Hooks{
+		OnSet:    func(_ context.Context, key string) { set = append(set, key) },
+		OnDelete: func(_ context.Context, key string) { deleted = append(deleted, key) },
+		OnEvict:  func(_ context.Context, keys []string) { evicted = append(evicted, keys) },
+		OnExpire: func(_ context.Context, keys []string) { expired = append(expired, keys) },
+	}
+
+	newCache := func() *cache {
+		return &cache{
+			queries: queries.New(db),
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+			hooks: hooks,
+		}
+	}
+
+	t.Run("should call OnSet after a write", func(t *testing.T) {
+		ch := newCache()
+
+		sqlMock.ExpectExec(`INSERT INTO cache`).
+			WithArgs("key", []byte("value"), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(time.Minute), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.Set(ctx, "key", "value", time.Minute)
+
+		assert.NoError(t, err, "Expected no error while setting the key")
+		assert.Equal(t, []string{"key"}, set, "Expected OnSet to be called with the key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should call OnDelete after a delete", func(t *testing.T) {
+		ch := newCache()
+
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE key = ?`).
+			WithArgs("key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.Del(ctx, "key")
+
+		assert.NoError(t, err, "Expected no error while deleting the key")
+		assert.Equal(t, []string{"key"}, deleted, "Expected OnDelete to be called with the key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should call OnExpire with the expired keys", func(t *testing.T) {
+		ch := newCache()
+
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE expires_at <= \? AND pinned = 0 RETURNING key`).
+			WithArgs(fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("a").AddRow("b"))
+
+		err := ch.PurgeExpiredItems(ctx)
+
+		assert.NoError(t, err, "Expected no error while purging expired items")
+		assert.Equal(t, [][]string{{"a", "b"}}, expired, "Expected OnExpire with the keys")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should not call OnExpire without expired keys", func(t *testing.T) {
+		ch := newCache()
+		expired = nil
+
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE expires_at <= \? AND pinned = 0 RETURNING key`).
+			WithArgs(fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key"}))
+
+		err := ch.PurgeExpiredItems(ctx)
+
+		assert.NoError(t, err, "Expected no error while purging expired items")
+		assert.Empty(t, expired, "Expected OnExpire not to be called")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should call OnEvict with the evicted keys", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+				sqlMock.ExpectCommit()
+
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				err = fn(tx)
+				assert.NoError(t, err, "Expected no error during transaction execution")
+
+				err = tx.Commit()
+				assert.NoError(t, err, "Expected no error while committing transaction")
+			}).
+			Return(nil)
+		dbMock.EXPECT().
+			Vacuum(ctx).
+			Return(nil)
+
+		ch := newCache()
+		ch.Database = dbMock
+		ch.purgePercent = 0.2
+		ch.evictionPolicy = &evictionPolicyStub{batches: [][]string{{"a", "b"}}}
+
+		err := ch.purgeItens(ctx)
+
+		assert.NoError(t, err, "Expected no error while purging items")
+		assert.Equal(t, [][]string{{"a", "b"}}, evicted, "Expected OnEvict with the keys")
+		assert.Equal(t, int64(2), ch.purgeCounters.evictedEntries.Load(), "Expected 2 evictions")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -0,0 +1,621 @@
+package cache
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"time"
+)
+
+// Observer receives a callback before and after each operation of the cache, for
+// example to record custom metrics or traces without litepack depending on them.
+// The callbacks run synchronously, so they must be fast.
+//
+// Example:
+//
+//	type tracer struct{}
+//
+//	func (tracer) Begin(ctx context.Context, op cache.Operation) context.Context {
+//		ctx, _ = otel.Tracer("litepack").Start(ctx, "cache."+op.Name)
+//		return ctx
+//	}
+//
+//	func (tracer) End(ctx context.Context, op cache.Operation, res cache.OperationResult) {
+//		span := trace.SpanFromContext(ctx)
+//		if res.Err != nil {
+//			span.RecordError(res.Err)
+//		}
+//		span.End()
+//	}
+//
+//	cache, err := cache.NewCache(ctx, cache.WithInstrumentation(tracer{}))
+type Observer interface {
+	// Begin is called before the operation runs; the returned context is passed to the
+	// operation and to End.
+	Begin(ctx context.Context, op Operation) context.Context
+
+	// End is called after the operation returns.
+	End(ctx context.Context, op Operation, res OperationResult)
+}
+
+// Operation describes a call to a method of the cache.
+type Operation struct {
+	// Name is the name of the method, such as "Get".
+	Name string
+
+	// Key is the key, prefix or pattern given to the method, if any.
+	// The keys of a namespace are given without the prefix of the namespace.
+	Key string
+
+	// Keys are the keys given to MGet, Prefetch and GetOrSetMulti.
+	Keys []string
+
+	// Read reports whether the method reads a single key, so that the result tells
+	// a hit from a miss.
+	Read bool
+}
+
+// OperationResult describes the outcome of an operation.
+type OperationResult struct {
+	Err      error
+	Duration time.Duration
+
+	// Hit reports whether a read found a live entry, false for the other operations.
+	Hit bool
+}
+
+// instrumentedCache is a Cache that reports the operations of the cache to an observer.
+// The methods of the database are not reported.
+type instrumentedCache struct {
+	Cache
+	observer Observer
+}
+
+// observe runs the operation between the callbacks of the observer.
+func (ic *instrumentedCache) observe(
+	ctx context.Context,
+	op Operation,
+	fn func(ctx context.Context) error,
+) error {
+	ctx = ic.observer.Begin(ctx, op)
+	start := time.Now()
+
+	err := fn(ctx)
+
+	ic.observer.End(ctx, op, OperationResult{
+		Err:      err,
+		Duration: time.Since(start),
+		Hit:      op.Read && err == nil,
+	})
+
+	return err
+}
+
+// Namespace returns a view of the namespace whose operations are reported too.
+func (ic *instrumentedCache) Namespace(name string, opts ...NamespaceOption) Cache {
+	return &instrumentedCache{Cache: ic.Cache.Namespace(name, opts...), observer: ic.observer}
+}
+
+// Set reports the Set operation to the observer.
+func (ic *instrumentedCache) Set(
+	ctx context.Context,
+	key, value string,
+	ttl time.Duration,
+	opts ...SetOption,
+) error {
+	return ic.observe(ctx, Operation{Name: "Set", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.Set(ctx, key, value, ttl, opts...)
+	})
+}
+
+// SetBytes reports the SetBytes operation to the observer.
+func (ic *instrumentedCache) SetBytes(
+	ctx context.Context,
+	key string,
+	value []byte,
+	ttl time.Duration,
+	opts ...SetOption,
+) error {
+	return ic.observe(ctx, Operation{Name: "SetBytes", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.SetBytes(ctx, key, value, ttl, opts...)
+	})
+}
+
+// SetWithExpireAt reports the SetWithExpireAt operation to the observer.
+func (ic *instrumentedCache) SetWithExpireAt(
+	ctx context.Context,
+	key, value string,
+	expireAt time.Time,
+) error {
+	op := Operation{Name: "SetWithExpireAt", Key: key}
+	return ic.observe(ctx, op, func(ctx context.Context) error {
+		return ic.Cache.SetWithExpireAt(ctx, key, value, expireAt)
+	})
+}
+
+// SetNX reports the SetNX operation to the observer.
+func (ic *instrumentedCache) SetNX(
+	ctx context.Context,
+	key, value string,
+	ttl time.Duration,
+) (bool, error) {
+	var ok bool
+	err := ic.observe(ctx, Operation{Name: "SetNX", Key: key}, func(ctx context.Context) error {
+		var err error
+		ok, err = ic.Cache.SetNX(ctx, key, value, ttl)
+		return err
+	})
+
+	return ok, err
+}
+
+// SetAsync reports the SetAsync operation to the observer.
+func (ic *instrumentedCache) SetAsync(
+	ctx context.Context,
+	key, value string,
+	ttl time.Duration,
+) error {
+	return ic.observe(ctx, Operation{Name: "SetAsync", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.SetAsync(ctx, key, value, ttl)
+	})
+}
+
+// FlushAsync reports the FlushAsync operation to the observer.
+func (ic *instrumentedCache) FlushAsync(ctx context.Context) error {
+	return ic.observe(ctx, Operation{Name: "FlushAsync"}, ic.Cache.FlushAsync)
+}
+
+// Append reports the Append operation to the observer.
+func (ic *instrumentedCache) Append(ctx context.Context, key, suffix string) error {
+	return ic.observe(ctx, Operation{Name: "Append", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.Append(ctx, key, suffix)
+	})
+}
+
+// Get reports the Get operation to the observer.
+func (ic *instrumentedCache) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	op := Operation{Name: "Get", Key: key, Read: true}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		value, err = ic.Cache.Get(ctx, key)
+		return err
+	})
+
+	return value, err
+}
+
+// GetBytes reports the GetBytes operation to the observer.
+func (ic *instrumentedCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	op := Operation{Name: "GetBytes", Key: key, Read: true}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		value, err = ic.Cache.GetBytes(ctx, key)
+		return err
+	})
+
+	return value, err
+}
+
+// SetValue reports the SetValue operation to the observer.
+func (ic *instrumentedCache) SetValue(
+	ctx context.Context,
+	key string,
+	value any,
+	ttl time.Duration,
+) error {
+	return ic.observe(ctx, Operation{Name: "SetValue", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.SetValue(ctx, key, value, ttl)
+	})
+}
+
+// GetValue reports the GetValue operation to the observer.
+func (ic *instrumentedCache) GetValue(ctx context.Context, key string, dest any) error {
+	op := Operation{Name: "GetValue", Key: key, Read: true}
+	return ic.observe(ctx, op, func(ctx context.Context) error {
+		return ic.Cache.GetValue(ctx, key, dest)
+	})
+}
+
+// GetStale reports the GetStale operation to the observer.
+func (ic *instrumentedCache) GetStale(
+	ctx context.Context,
+	key string,
+) (string, time.Duration, error) {
+	var value string
+	var age time.Duration
+	op := Operation{Name: "GetStale", Key: key, Read: true}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		value, age, err = ic.Cache.GetStale(ctx, key)
+		return err
+	})
+
+	return value, age, err
+}
+
+// GetEntry reports the GetEntry operation to the observer.
+func (ic *instrumentedCache) GetEntry(ctx context.Context, key string) (Entry, error) {
+	var entry Entry
+	op := Operation{Name: "GetEntry", Key: key, Read: true}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		entry, err = ic.Cache.GetEntry(ctx, key)
+		return err
+	})
+
+	return entry, err
+}
+
+// SetB reports the SetB operation to the observer, with the key hex encoded.
+func (ic *instrumentedCache) SetB(
+	ctx context.Context,
+	key []byte,
+	value []byte,
+	ttl time.Duration,
+) error {
+	op := Operation{Name: "SetB", Key: hex.EncodeToString(key)}
+	return ic.observe(ctx, op, func(ctx context.Context) error {
+		return ic.Cache.SetB(ctx, key, value, ttl)
+	})
+}
+
+// GetB reports the GetB operation to the observer, with the key hex encoded.
+func (ic *instrumentedCache) GetB(ctx context.Context, key []byte) ([]byte, error) {
+	var value []byte
+	op := Operation{Name: "GetB", Key: hex.EncodeToString(key), Read: true}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		value, err = ic.Cache.GetB(ctx, key)
+		return err
+	})
+
+	return value, err
+}
+
+// DelB reports the DelB operation to the observer, with the key hex encoded.
+func (ic *instrumentedCache) DelB(ctx context.Context, key []byte) error {
+	op := Operation{Name: "DelB", Key: hex.EncodeToString(key)}
+	return ic.observe(ctx, op, func(ctx context.Context) error {
+		return ic.Cache.DelB(ctx, key)
+	})
+}
+
+// GetWithVersion reports the GetWithVersion operation to the observer.
+func (ic *instrumentedCache) GetWithVersion(
+	ctx context.Context,
+	key string,
+) (string, int64, error) {
+	var value string
+	var version int64
+	op := Operation{Name: "GetWithVersion", Key: key, Read: true}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		value, version, err = ic.Cache.GetWithVersion(ctx, key)
+		return err
+	})
+
+	return value, version, err
+}
+
+// SetIfVersion reports the SetIfVersion operation to the observer.
+func (ic *instrumentedCache) SetIfVersion(
+	ctx context.Context,
+	key, value string,
+	version int64,
+	ttl time.Duration,
+) error {
+	op := Operation{Name: "SetIfVersion", Key: key}
+	return ic.observe(ctx, op, func(ctx context.Context) error {
+		return ic.Cache.SetIfVersion(ctx, key, value, version, ttl)
+	})
+}
+
+// GetSet reports the GetSet operation to the observer.
+func (ic *instrumentedCache) GetSet(
+	ctx context.Context,
+	key, value string,
+	ttl time.Duration,
+) (string, error) {
+	var old string
+	err := ic.observe(ctx, Operation{Name: "GetSet", Key: key}, func(ctx context.Context) error {
+		var err error
+		old, err = ic.Cache.GetSet(ctx, key, value, ttl)
+		return err
+	})
+
+	return old, err
+}
+
+// Update reports the Update operation to the observer.
+func (ic *instrumentedCache) Update(ctx context.Context, key string, fn UpdateFunc) error {
+	return ic.observe(ctx, Operation{Name: "Update", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.Update(ctx, key, fn)
+	})
+}
+
+// GetTTL reports the GetTTL operation to the observer.
+func (ic *instrumentedCache) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	var ttl time.Duration
+	op := Operation{Name: "GetTTL", Key: key, Read: true}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		ttl, err = ic.Cache.GetTTL(ctx, key)
+		return err
+	})
+
+	return ttl, err
+}
+
+// Persist reports the Persist operation to the observer.
+func (ic *instrumentedCache) Persist(ctx context.Context, key string) error {
+	return ic.observe(ctx, Operation{Name: "Persist", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.Persist(ctx, key)
+	})
+}
+
+// Rename reports the Rename operation to the observer.
+func (ic *instrumentedCache) Rename(ctx context.Context, oldKey, newKey string) error {
+	return ic.observe(ctx, Operation{Name: "Rename", Key: oldKey}, func(ctx context.Context) error {
+		return ic.Cache.Rename(ctx, oldKey, newKey)
+	})
+}
+
+// DelPrefix reports the DelPrefix operation to the observer.
+func (ic *instrumentedCache) DelPrefix(ctx context.Context, prefix string) (int64, error) {
+	var deleted int64
+	op := Operation{Name: "DelPrefix", Key: prefix}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		deleted, err = ic.Cache.DelPrefix(ctx, prefix)
+		return err
+	})
+
+	return deleted, err
+}
+
+// DelPattern reports the DelPattern operation to the observer.
+func (ic *instrumentedCache) DelPattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	op := Operation{Name: "DelPattern", Key: pattern}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		deleted, err = ic.Cache.DelPattern(ctx, pattern)
+		return err
+	})
+
+	return deleted, err
+}
+
+// Pin reports the Pin operation to the observer.
+func (ic *instrumentedCache) Pin(ctx context.Context, key string) error {
+	return ic.observe(ctx, Operation{Name: "Pin", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.Pin(ctx, key)
+	})
+}
+
+// Unpin reports the Unpin operation to the observer.
+func (ic *instrumentedCache) Unpin(ctx context.Context, key string) error {
+	return ic.observe(ctx, Operation{Name: "Unpin", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.Unpin(ctx, key)
+	})
+}
+
+// SetDerived reports the SetDerived operation to the observer.
+func (ic *instrumentedCache) SetDerived(ctx context.Context, key string, parents ...string) error {
+	op := Operation{Name: "SetDerived", Key: key}
+	return ic.observe(ctx, op, func(ctx context.Context) error {
+		return ic.Cache.SetDerived(ctx, key, parents...)
+	})
+}
+
+// GetRange reports the GetRange operation to the observer.
+func (ic *instrumentedCache) GetRange(
+	ctx context.Context,
+	key string,
+	offset, length int,
+) (string, error) {
+	var value string
+	op := Operation{Name: "GetRange", Key: key, Read: true}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		value, err = ic.Cache.GetRange(ctx, key, offset, length)
+		return err
+	})
+
+	return value, err
+}
+
+// MGet reports the MGet operation to the observer.
+func (ic *instrumentedCache) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	var values map[string]string
+	err := ic.observe(ctx, Operation{Name: "MGet", Keys: keys}, func(ctx context.Context) error {
+		var err error
+		values, err = ic.Cache.MGet(ctx, keys...)
+		return err
+	})
+
+	return values, err
+}
+
+// Prefetch reports the Prefetch operation to the observer.
+func (ic *instrumentedCache) Prefetch(ctx context.Context, keys []string) error {
+	op := Operation{Name: "Prefetch", Keys: keys}
+	return ic.observe(ctx, op, func(ctx context.Context) error {
+		return ic.Cache.Prefetch(ctx, keys)
+	})
+}
+
+// Del reports the Del operation to the observer.
+func (ic *instrumentedCache) Del(ctx context.Context, key string) error {
+	return ic.observe(ctx, Operation{Name: "Del", Key: key}, func(ctx context.Context) error {
+		return ic.Cache.Del(ctx, key)
+	})
+}
+
+// Keys reports the Keys operation to the observer.
+func (ic *instrumentedCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	err := ic.observe(ctx, Operation{Name: "Keys", Key: pattern}, func(ctx context.Context) error {
+		var err error
+		keys, err = ic.Cache.Keys(ctx, pattern)
+		return err
+	})
+
+	return keys, err
+}
+
+// Count reports the Count operation to the observer.
+func (ic *instrumentedCache) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := ic.observe(ctx, Operation{Name: "Count"}, func(ctx context.Context) error {
+		var err error
+		count, err = ic.Cache.Count(ctx)
+		return err
+	})
+
+	return count, err
+}
+
+// Flush reports the Flush operation to the observer.
+func (ic *instrumentedCache) Flush(ctx context.Context) error {
+	return ic.observe(ctx, Operation{Name: "Flush"}, ic.Cache.Flush)
+}
+
+// PurgeExpiredItems reports the PurgeExpiredItems operation to the observer.
+func (ic *instrumentedCache) PurgeExpiredItems(ctx context.Context) error {
+	return ic.observe(ctx, Operation{Name: "PurgeExpiredItems"}, ic.Cache.PurgeExpiredItems)
+}
+
+// Export reports the Export operation to the observer.
+func (ic *instrumentedCache) Export(ctx context.Context, w io.Writer) error {
+	return ic.observe(ctx, Operation{Name: "Export"}, func(ctx context.Context) error {
+		return ic.Cache.Export(ctx, w)
+	})
+}
+
+// Import reports the Import operation to the observer.
+func (ic *instrumentedCache) Import(ctx context.Context, r io.Reader) error {
+	return ic.observe(ctx, Operation{Name: "Import"}, func(ctx context.Context) error {
+		return ic.Cache.Import(ctx, r)
+	})
+}
+
+// GetOrSet reports the GetOrSet operation to the observer.
+func (ic *instrumentedCache) GetOrSet(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	loader LoaderFunc,
+) (string, error) {
+	var value string
+	err := ic.observe(ctx, Operation{Name: "GetOrSet", Key: key}, func(ctx context.Context) error {
+		var err error
+		value, err = ic.Cache.GetOrSet(ctx, key, ttl, loader)
+		return err
+	})
+
+	return value, err
+}
+
+// GetOrSetMulti reports the GetOrSetMulti operation to the observer.
+func (ic *instrumentedCache) GetOrSetMulti(
+	ctx context.Context,
+	keys []string,
+	ttl time.Duration,
+	loader MultiLoaderFunc,
+) (map[string]string, error) {
+	var values map[string]string
+	op := Operation{Name: "GetOrSetMulti", Keys: keys}
+	err := ic.observe(ctx, op, func(ctx context.Context) error {
+		var err error
+		values, err = ic.Cache.GetOrSetMulti(ctx, keys, ttl, loader)
+		return err
+	})
+
+	return values, err
+}
+
+// Stats reports the Stats operation to the observer.
+func (ic *instrumentedCache) Stats(ctx context.Context) (CacheStats, error) {
+	var stats CacheStats
+	err := ic.observe(ctx, Operation{Name: "Stats"}, func(ctx context.Context) error {
+		var err error
+		stats, err = ic.Cache.Stats(ctx)
+		return err
+	})
+
+	return stats, err
+}
+
+// SchedulerStats reports the SchedulerStats operation to the observer.
+func (ic *instrumentedCache) SchedulerStats(ctx context.Context) []TaskStats {
+	var tasks []TaskStats
+	_ = ic.observe(ctx, Operation{Name: "SchedulerStats"}, func(ctx context.Context) error {
+		tasks = ic.Cache.SchedulerStats(ctx)
+		return nil
+	})
+
+	return tasks
+}
+
+// Quiesce reports the Quiesce operation to the observer.
+func (ic *instrumentedCache) Quiesce(ctx context.Context) (func(), error) {
+	var resume func()
+	err := ic.observe(ctx, Operation{Name: "Quiesce"}, func(ctx context.Context) error {
+		var err error
+		resume, err = ic.Cache.Quiesce(ctx)
+		return err
+	})
+
+	return resume, err
+}
+
+// Snapshot reports the Snapshot operation to the observer.
+func (ic *instrumentedCache) Snapshot(ctx context.Context, destPath string) error {
+	return ic.observe(ctx, Operation{Name: "Snapshot"}, func(ctx context.Context) error {
+		return ic.Cache.Snapshot(ctx, destPath)
+	})
+}
+
+// Compact reports the Compact operation to the observer.
+func (ic *instrumentedCache) Compact(ctx context.Context) (CompactStats, error) {
+	var stats CompactStats
+	err := ic.observe(ctx, Operation{Name: "Compact"}, func(ctx context.Context) error {
+		var err error
+		stats, err = ic.Cache.Compact(ctx)
+		return err
+	})
+
+	return stats, err
+}
+
+// Ping reports the Ping operation to the observer.
+func (ic *instrumentedCache) Ping(ctx context.Context) error {
+	return ic.observe(ctx, Operation{Name: "Ping"}, ic.Cache.Ping)
+}
+
+// Events reports the Events operation to the observer.
+func (ic *instrumentedCache) Events(ctx context.Context, sinceID int64) ([]Event, error) {
+	var events []Event
+	err := ic.observe(ctx, Operation{Name: "Events"}, func(ctx context.Context) error {
+		var err error
+		events, err = ic.Cache.Events(ctx, sinceID)
+		return err
+	})
+
+	return events, err
+}
+
+// Tombstones reports the Tombstones operation to the observer.
+func (ic *instrumentedCache) Tombstones(ctx context.Context, since time.Time) ([]Tombstone, error) {
+	var tombstones []Tombstone
+	err := ic.observe(ctx, Operation{Name: "Tombstones"}, func(ctx context.Context) error {
+		var err error
+		tombstones, err = ic.Cache.Tombstones(ctx, since)
+		return err
+	})
+
+	return tombstones, err
+}
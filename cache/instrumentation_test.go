@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxKey struct{}
+
+// recordingObserver records the operations reported to it.
+type recordingObserver struct {
+	begins  []Operation
+	results []OperationResult
+	ctxs    []context.Context
+}
+
+func (o *recordingObserver) Begin(ctx context.Context, op Operation) context.Context {
+	o.begins = append(o.begins, op)
+	return context.WithValue(ctx, ctxKey{}, op.Name)
+}
+
+func (o *recordingObserver) End(ctx context.Context, _ Operation, res OperationResult) {
+	o.ctxs = append(o.ctxs, ctx)
+	o.results = append(o.results, res)
+}
+
+// observedCacheStub is a Cache whose reads and writes are stubbed.
+type observedCacheStub struct {
+	Cache
+	values map[string]string
+	ctxs   []context.Context
+}
+
+func (c *observedCacheStub) Get(ctx context.Context, key string) (string, error) {
+	c.ctxs = append(c.ctxs, ctx)
+	value, ok := c.values[key]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return value, nil
+}
+
+func (c *observedCacheStub) Set(ctx context.Context, key, _ string, _ time.Duration, _ ...SetOption) error {
+	c.ctxs = append(c.ctxs, ctx)
+	return fmt.Errorf("database is locked")
+}
+
+func (c *observedCacheStub) Namespace(name string, _ ...NamespaceOption) Cache {
+	return &observedCacheStub{values: map[string]string{name + ":key": "value"}}
+}
+
+func TestInstrumentedCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should report hits and misses of the reads", func(t *testing.T) {
+		observer := &recordingObserver{}
+		stub := &observedCacheStub{values: map[string]string{"key": "value"}}
+		ic := &instrumentedCache{Cache: stub, observer: observer}
+
+		value, err := ic.Get(ctx, "key")
+		assert.NoError(t, err, "Expected no error for a live key")
+		assert.Equal(t, "value", value)
+
+		_, err = ic.Get(ctx, "missing")
+		assert.ErrorIs(t, err, ErrKeyNotFound, "Expected ErrKeyNotFound for a missing key")
+
+		assert.Equal(t, []Operation{
+			{Name: "Get", Key: "key", Read: true},
+			{Name: "Get", Key: "missing", Read: true},
+		}, observer.begins)
+		assert.True(t, observer.results[0].Hit, "Expected a hit for a live key")
+		assert.NoError(t, observer.results[0].Err)
+		assert.False(t, observer.results[1].Hit, "Expected a miss for a missing key")
+		assert.ErrorIs(t, observer.results[1].Err, ErrKeyNotFound)
+	})
+
+	t.Run("should report the errors of the writes", func(t *testing.T) {
+		observer := &recordingObserver{}
+		ic := &instrumentedCache{Cache: &observedCacheStub{}, observer: observer}
+
+		err := ic.Set(ctx, "key", "value", time.Minute)
+
+		assert.EqualError(t, err, "database is locked")
+		assert.Equal(t, []Operation{{Name: "Set", Key: "key"}}, observer.begins)
+		assert.EqualError(t, observer.results[0].Err, "database is locked")
+		assert.False(t, observer.results[0].Hit, "Expected writes not to report hits")
+	})
+
+	t.Run("should pass the context returned by Begin", func(t *testing.T) {
+		observer := &recordingObserver{}
+		stub := &observedCacheStub{}
+		ic := &instrumentedCache{Cache: stub, observer: observer}
+
+		_, _ = ic.Get(ctx, "key")
+
+		assert.Equal(t, "Get", stub.ctxs[0].Value(ctxKey{}), "Expected the operation context")
+		assert.Equal(t, "Get", observer.ctxs[0].Value(ctxKey{}), "Expected End to get it too")
+	})
+
+	t.Run("should report the operations of a namespace", func(t *testing.T) {
+		observer := &recordingObserver{}
+		ic := &instrumentedCache{Cache: &observedCacheStub{}, observer: observer}
+
+		value, err := ic.Namespace("users").Get(ctx, "users:key")
+
+		assert.NoError(t, err, "Expected no error for a live key")
+		assert.Equal(t, "value", value)
+		assert.Equal(t, []Operation{{Name: "Get", Key: "users:key", Read: true}}, observer.begins)
+	})
+}
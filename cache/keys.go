@@ -0,0 +1,243 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// Keys returns the keys of the non-expired entries that match the given glob pattern,
+// ordered by key. The pattern follows the SQLite GLOB syntax and is case sensitive:
+//   - "*" matches any sequence of characters
+//   - "?" matches exactly one character
+//   - "[abc]" matches one of the enclosed characters
+//
+// An empty pattern matches every key.
+//
+// Parameters:
+//   - ctx: the context
+//   - pattern: the glob pattern (e.g., "user:*")
+//
+// Returns:
+//   - []string: the matching keys
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	keys, err := cache.Keys(ctx, "user:*") // keys: [user:1 user:2]
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	params := queries.ListKeysParams{
+		Pattern: pattern,
+		Now:     ch.timeSource.Now().In(ch.timeSource.Timezone),
+	}
+
+	keys, err := ch.queries.ListKeys(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("error listing keys: %w", err)
+	}
+
+	if keys == nil {
+		keys = []string{}
+	}
+
+	return keys, nil
+}
+
+// Count returns the number of non-expired entries in the cache.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - int64: the number of entries
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	count, err := cache.Count(ctx) // count: 2
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Count(ctx context.Context) (int64, error) {
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+
+	count, err := ch.queries.CountLiveEntries(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("counting entries: %w", err)
+	}
+
+	return count, nil
+}
+
+// DelPrefix deletes every entry whose key starts with the given prefix in a single
+// statement, for example to invalidate a group of keys after a deploy.
+// An empty prefix deletes every entry.
+//
+// Parameters:
+//   - ctx: the context
+//   - prefix: the prefix of the keys to delete, matched literally
+//
+// Returns:
+//   - int64: the number of deleted entries
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	deleted, err := cache.DelPrefix(ctx, "user:42:")
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) DelPrefix(ctx context.Context, prefix string) (int64, error) {
+	return ch.DelPattern(ctx, escapeGlob(prefix)+"*")
+}
+
+// DelPattern deletes every entry whose key matches the given glob pattern in a single
+// statement. The pattern uses the syntax of Keys.
+//
+// Parameters:
+//   - ctx: the context
+//   - pattern: the glob pattern of the keys to delete
+//
+// Returns:
+//   - int64: the number of deleted entries
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	deleted, err := cache.DelPattern(ctx, "session:*:v1")
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) DelPattern(ctx context.Context, pattern string) (int64, error) {
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	keys, err := ch.deleteKeysByPattern(ctx, pattern)
+	if err != nil {
+		return 0, fmt.Errorf("deleting keys: %w", err)
+	}
+
+	for _, key := range keys {
+		ch.memory.del(key)
+		ch.hooks.delete(ctx, key)
+	}
+	ch.metrics.deletes.Add(int64(len(keys)))
+
+	return int64(len(keys)), nil
+}
+
+// Rename moves the entry of oldKey to newKey in a single statement, keeping its value,
+// expiration, access time and pin. If newKey already exists, it is replaced.
+// It can be used to stage an entry under a temporary key and publish it once complete,
+// without copying the value.
+//
+// Parameters:
+//   - ctx: the context
+//   - oldKey: the key of the entry
+//   - newKey: the new key of the entry
+//
+// Returns:
+//   - error: an error if the operation failed, ErrKeyNotFound if oldKey does not exist
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err = cache.Set(ctx, "report:tmp", report, time.Hour)
+//	err = cache.Rename(ctx, "report:tmp", "report")
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Rename(ctx context.Context, oldKey, newKey string) error {
+	if err := ch.checkKey(newKey); err != nil {
+		return err
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	params := queries.RenameKeyParams{
+		OldKey: oldKey,
+		NewKey: newKey,
+		Now:    ch.timeSource.Now().In(ch.timeSource.Timezone),
+	}
+
+	renamed, err := ch.queries.RenameKey(ctx, params)
+	if err != nil {
+		return fmt.Errorf("renaming key: %w", err)
+	}
+	if renamed == 0 {
+		return ErrKeyNotFound
+	}
+	if oldKey == newKey {
+		return nil
+	}
+
+	ch.memory.del(oldKey)
+	ch.memory.del(newKey)
+	ch.hooks.delete(ctx, oldKey)
+	ch.hooks.set(ctx, newKey)
+
+	return nil
+}
+
+// Flush deletes every entry of the cache, and the dependencies declared with SetDerived.
+// Unlike Destroy, the database file is kept, so other stores sharing it keep working.
+// The freed pages are reused by new entries; call Vacuum to shrink the database file.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err := cache.Flush(ctx)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Flush(ctx context.Context) error {
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	err := ch.queries.DeleteAllCache(ctx)
+	if err != nil {
+		return fmt.Errorf("flushing cache: %w", err)
+	}
+	ch.memory.clear()
+	ch.recordEvent(ctx, EventFlush, "")
+
+	if ch.dependencies {
+		err = ch.queries.DeleteAllDependencies(ctx)
+		if err != nil {
+			return fmt.Errorf("flushing dependencies: %w", err)
+		}
+	}
+
+	return nil
+}
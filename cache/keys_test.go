@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_Keys(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should return the keys matching the pattern", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key FROM cache WHERE key GLOB \? AND expires_at > \? ORDER BY key`).
+			WithArgs("user:*", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).
+				AddRow("user:1").
+				AddRow("user:2"))
+
+		keys, err := ch.Keys(context.Background(), "user:*")
+
+		assert.NoError(t, err, "Expected no error while listing keys")
+		assert.Equal(t, []string{"user:1", "user:2"}, keys)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should match every key when the pattern is empty", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key FROM cache WHERE key GLOB \?`).
+			WithArgs("*", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key"}))
+
+		keys, err := ch.Keys(context.Background(), "")
+
+		assert.NoError(t, err, "Expected no error while listing keys")
+		assert.Equal(t, []string{}, keys)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the query fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key FROM cache WHERE key GLOB \?`).
+			WithArgs("*", fixedTime).
+			WillReturnError(fmt.Errorf("mock select error"))
+
+		keys, err := ch.Keys(context.Background(), "*")
+
+		assert.Error(t, err, "Expected an error when the query fails")
+		assert.Equal(t, "error listing keys: mock select error", err.Error())
+		assert.Nil(t, keys)
+	})
+}
+
+func TestCache_Count(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should return the number of live entries", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache WHERE expires_at > \?`).
+			WithArgs(fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+		count, err := ch.Count(context.Background())
+
+		assert.NoError(t, err, "Expected no error while counting entries")
+		assert.Equal(t, int64(42), count)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the query fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache WHERE expires_at > \?`).
+			WithArgs(fixedTime).
+			WillReturnError(fmt.Errorf("mock select error"))
+
+		count, err := ch.Count(context.Background())
+
+		assert.Error(t, err, "Expected an error when the query fails")
+		assert.Equal(t, "counting entries: mock select error", err.Error())
+		assert.Zero(t, count)
+	})
+}
+
+func TestCache_Flush(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ch := &cache{
+		queries: queries.New(db),
+	}
+
+	t.Run("should delete every entry", func(t *testing.T) {
+		sqlMock.ExpectExec(`DELETE FROM cache`).
+			WillReturnResult(sqlmock.NewResult(0, 10))
+
+		err := ch.Flush(context.Background())
+
+		assert.NoError(t, err, "Expected no error while flushing the cache")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the query fails", func(t *testing.T) {
+		sqlMock.ExpectExec(`DELETE FROM cache`).
+			WillReturnError(fmt.Errorf("mock delete error"))
+
+		err := ch.Flush(context.Background())
+
+		assert.Error(t, err, "Expected an error when the query fails")
+		assert.Equal(t, "flushing cache: mock delete error", err.Error())
+	})
+}
+
+func TestCache_Rename(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	newCache := func() *cache {
+		return &cache{
+			queries: queries.New(db),
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}
+	}
+
+	t.Run("should move the entry to the new key", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE OR REPLACE cache SET key = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs("report", "report:tmp", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		var set, deleted []string
+		ch := newCache()
+		ch.hooks = Hooks{
+			OnSet:    func(_ context.Context, key string) { set = append(set, key) },
+			OnDelete: func(_ context.Context, key string) { deleted = append(deleted, key) },
+		}
+
+		err := ch.Rename(context.Background(), "report:tmp", "report")
+
+		assert.NoError(t, err, "Expected no error while renaming the key")
+		assert.Equal(t, []string{"report"}, set, "Expected OnSet for the new key")
+		assert.Equal(t, []string{"report:tmp"}, deleted, "Expected OnDelete for the old key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrKeyNotFound if the key does not exist", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE OR REPLACE cache SET key = \?`).
+			WithArgs("report", "missing", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := newCache().Rename(context.Background(), "missing", "report")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the update fails", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE OR REPLACE cache SET key = \?`).
+			WillReturnError(fmt.Errorf("mock update error"))
+
+		err := newCache().Rename(context.Background(), "report:tmp", "report")
+
+		assert.EqualError(t, err, "renaming key: mock update error")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestCache_DelPattern(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ch := &cache{queries: queries.New(db)}
+
+	t.Run("should delete the keys matching the pattern", func(t *testing.T) {
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key GLOB \? RETURNING key`).
+			WithArgs("session:*:v1").
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).
+				AddRow("session:1:v1").
+				AddRow("session:2:v1"))
+
+		var deleted []string
+		ch.hooks = Hooks{
+			OnDelete: func(_ context.Context, key string) { deleted = append(deleted, key) },
+		}
+
+		count, err := ch.DelPattern(context.Background(), "session:*:v1")
+
+		assert.NoError(t, err, "Expected no error while deleting keys")
+		assert.Equal(t, int64(2), count)
+		assert.Equal(t, []string{"session:1:v1", "session:2:v1"}, deleted)
+		assert.Equal(t, int64(2), ch.Metrics().Deletes, "Expected two deletes")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should delete the keys with the literal prefix", func(t *testing.T) {
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key GLOB \? RETURNING key`).
+			WithArgs("user[*]:*").
+			WillReturnRows(sqlmock.NewRows([]string{"key"}))
+
+		count, err := ch.DelPrefix(context.Background(), "user*:")
+
+		assert.NoError(t, err, "Expected no error while deleting keys")
+		assert.Zero(t, count)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the delete fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key GLOB \?`).
+			WillReturnError(fmt.Errorf("mock delete error"))
+
+		_, err := ch.DelPrefix(context.Background(), "user:")
+
+		assert.EqualError(t, err, "deleting keys: mock delete error")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
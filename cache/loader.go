@@ -0,0 +1,322 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// LoaderFunc loads the value of a key that was not found in the cache.
+type LoaderFunc func(ctx context.Context) (string, error)
+
+// MultiLoaderFunc loads the values for the keys that were not found in the cache.
+// Keys missing from the returned map are treated as not found.
+type MultiLoaderFunc func(ctx context.Context, missing []string) (map[string]string, error)
+
+// GetOrSet retrieves a value from the cache by key and loads it with the given loader on a miss.
+// Concurrent misses of the same key in the process share a single loader call, so a burst
+// of requests for a cold key reaches the upstream service only once.
+// The loaded value is stored only if no other caller stored a live value for the key in the
+// meantime; in that case the stored value wins and is returned instead of the loaded one.
+// The shared load outlives the callers that give up, so that it isn't cancelled for the
+// ones still waiting; it is bounded by the operation timeout instead.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - ttl: the time-to-live for the loaded cache entry
+//   - loader: the function used to load the value on a miss
+//
+// Returns:
+//   - string: the cached or loaded value
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	value, err := cache.GetOrSet(ctx, "key", time.Minute, func(ctx context.Context) (string, error) {
+//		return loadFromUpstream(ctx, "key")
+//	})
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) GetOrSet(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	loader LoaderFunc,
+) (string, error) {
+	value, err := ch.Get(ctx, key)
+	if err == nil {
+		return value, nil
+	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		return "", err
+	}
+
+	// Run a single loader per key, concurrent misses wait for its result.
+	result := ch.loads.DoChan(key, func() (any, error) {
+		loadCtx, cancel := ch.withOpTimeout(context.WithoutCancel(ctx))
+		defer cancel()
+
+		return ch.loadAndStore(loadCtx, key, ttl, loader)
+	})
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return "", res.Err
+		}
+
+		return res.Val.(string), nil
+	}
+}
+
+// loadAndStore loads the value of a key with the given loader and stores it in the cache.
+// If another caller stored a live value in the meantime, that value is returned instead.
+func (ch *cache) loadAndStore(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	loader LoaderFunc,
+) (string, error) {
+	value, err := loader(ctx)
+	if err != nil {
+		return "", fmt.Errorf("loading key: %w", err)
+	}
+	if err := ch.checkValueSize(len(value)); err != nil {
+		return "", err
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	params := queries.UpsertCacheIfExpiredParams{
+		Key:            key,
+		Value:          []byte(value),
+		ExpiresAt:      ch.expiresAt(now, ttl),
+		LastAccessedAt: now,
+		Ttl:            int64(ttl),
+	}
+	params.Checksum = ch.checksum(params.Value)
+
+	stored, err := ch.queries.UpsertCacheIfExpired(ctx, params)
+	if err == nil {
+		ch.memory.set(key, stored, params.ExpiresAt)
+		ch.metrics.sets.Add(1)
+		ch.hooks.set(ctx, key)
+		return string(stored), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("error setting cache: %w", err)
+	}
+
+	// Another caller stored a live value after our miss, return it instead.
+	return ch.Get(ctx, key)
+}
+
+// GetOrSetMulti retrieves multiple keys from the cache in a single query and
+// loads the missing ones with the given loader.
+// The loader is called at most once, and only with the keys that were not found.
+// The loaded values are stored in the cache in a single transaction with the given TTL;
+// the values the loader returns for other keys are ignored.
+//
+// Parameters:
+//   - ctx: the context
+//   - keys: the cache keys
+//   - ttl: the time-to-live for the loaded cache entries
+//   - loader: the function used to load the missing keys
+//
+// Returns:
+//   - map[string]string: the cached and loaded values by key
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	values, err := cache.GetOrSetMulti(ctx, []string{"a", "b"}, time.Minute,
+//		func(ctx context.Context, missing []string) (map[string]string, error) {
+//			return loadFromUpstream(ctx, missing)
+//		},
+//	)
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) GetOrSetMulti(
+	ctx context.Context,
+	keys []string,
+	ttl time.Duration,
+	loader MultiLoaderFunc,
+) (map[string]string, error) {
+	keys = uniqueKeys(keys)
+	values, err := ch.getMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	loaded, err := loader(ctx, missing)
+	if err != nil {
+		return nil, fmt.Errorf("loading missing keys: %w", err)
+	}
+
+	// Only the missing keys are stored, the loader may return others.
+	found := make(map[string]string, len(missing))
+	for _, key := range missing {
+		if value, ok := loaded[key]; ok {
+			found[key] = value
+		}
+	}
+	if len(found) == 0 {
+		return values, nil
+	}
+
+	err = ch.setMulti(ctx, found, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range found {
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// uniqueKeys returns the keys without duplicates, in the order of their first occurrence.
+func uniqueKeys(keys []string) []string {
+	seen := make(map[string]struct{}, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, key)
+	}
+
+	return unique
+}
+
+// getMulti retrieves the non-expired values of the given keys in a single query
+// and refreshes their last accessed at timestamp.
+func (ch *cache) getMulti(ctx context.Context, keys []string) (map[string]string, error) {
+	keys = uniqueKeys(keys)
+	values := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	rows, err := ch.reads().GetValues(ctx, queries.GetValuesParams{
+		Keys:      keys,
+		ExpiresAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting values: %w", err)
+	}
+	ch.metrics.hits.Add(int64(len(rows)))
+	ch.metrics.misses.Add(int64(len(keys) - len(rows)))
+	if len(rows) == 0 {
+		return values, nil
+	}
+
+	found := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if err := ch.verifyChecksum(ctx, row.Key, row.Value, row.Checksum); err != nil {
+			return nil, err
+		}
+		values[row.Key] = string(row.Value)
+		found = append(found, row.Key)
+	}
+
+	// Buffer the access times instead of writing them, if enabled.
+	if ch.access.record(now, found...) {
+		return values, nil
+	}
+
+	// Skip the access time update while the cache is quiesced.
+	if !ch.writeMu.TryRLock() {
+		return values, nil
+	}
+	defer ch.writeMu.RUnlock()
+
+	err = ch.queries.UpdateLastAccessedAtByKeys(ctx, queries.UpdateLastAccessedAtByKeysParams{
+		LastAccessedAt: now,
+		Keys:           found,
+		ExpiresAt:      now,
+	})
+	if err != nil {
+		ch.logger.Error(ctx, fmt.Sprintf("error updating last accessed at: %v", err))
+	}
+
+	return values, nil
+}
+
+// setMulti stores the given key-value pairs in a single transaction.
+func (ch *cache) setMulti(ctx context.Context, values map[string]string, ttl time.Duration) error {
+	for key, value := range values {
+		if err := ch.checkKey(key); err != nil {
+			return err
+		}
+		if err := ch.checkValueSize(len(value)); err != nil {
+			return err
+		}
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	expiresAt := ch.expiresAt(now, ttl)
+
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		queriesWithTx := queries.New(tx)
+		for key, value := range values {
+			params := queries.UpsertCacheParams{
+				Key:            key,
+				Value:          []byte(value),
+				ExpiresAt:      expiresAt,
+				LastAccessedAt: now,
+				Ttl:            int64(ttl),
+			}
+			params.Checksum = ch.checksum(params.Value)
+
+			if err := queriesWithTx.UpsertCache(ctx, params); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error setting cache: %w", err)
+	}
+	for key, value := range values {
+		ch.memory.set(key, []byte(value), expiresAt)
+		ch.hooks.set(ctx, key)
+	}
+	ch.metrics.sets.Add(int64(len(values)))
+
+	return nil
+}
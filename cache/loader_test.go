@@ -0,0 +1,432 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
+)
+
+func TestCache_GetOrSet(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should return the cached value without calling the loader", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow("cached", nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
+			WithArgs(sqlmock.AnyArg(), "key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		value, err := ch.GetOrSet(ctx, "key", time.Hour, func(_ context.Context) (string, error) {
+			t.Fatal("loader should not be called")
+			return "", nil
+		})
+
+		assert.NoError(t, err, "Expected no error for a cached key")
+		assert.Equal(t, "cached", value)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should load and store the value on a miss", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+		sqlMock.ExpectQuery(`INSERT INTO cache .* WHERE cache.expires_at <= excluded.last_accessed_at RETURNING value`).
+			WithArgs("key", []byte("loaded"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("loaded")))
+
+		value, err := ch.GetOrSet(ctx, "key", time.Hour, func(_ context.Context) (string, error) {
+			return "loaded", nil
+		})
+
+		assert.NoError(t, err, "Expected no error when loading the key")
+		assert.Equal(t, "loaded", value)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return the value stored by a concurrent caller", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("loaded"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil).
+			WillReturnError(sql.ErrNoRows)
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow("winner", nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
+			WithArgs(sqlmock.AnyArg(), "key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		value, err := ch.GetOrSet(ctx, "key", time.Hour, func(_ context.Context) (string, error) {
+			return "loaded", nil
+		})
+
+		assert.NoError(t, err, "Expected no error when another caller won")
+		assert.Equal(t, "winner", value)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the loader fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		value, err := ch.GetOrSet(ctx, "key", time.Hour, func(_ context.Context) (string, error) {
+			return "", fmt.Errorf("upstream error")
+		})
+
+		assert.Error(t, err, "Expected an error when the loader fails")
+		assert.Equal(t, "loading key: upstream error", err.Error())
+		assert.Empty(t, value)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if storing the value fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("loaded"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil).
+			WillReturnError(fmt.Errorf("mock insert error"))
+
+		value, err := ch.GetOrSet(ctx, "key", time.Hour, func(_ context.Context) (string, error) {
+			return "loaded", nil
+		})
+
+		assert.Error(t, err, "Expected an error when storing the value fails")
+		assert.Equal(t, "error setting cache: mock insert error", err.Error())
+		assert.Empty(t, value)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestCache_GetOrSet_Singleflight(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+	sqlMock.MatchExpectationsInOrder(false)
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	callers := 10
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	for range callers {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+	}
+	sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+		WithArgs("key", []byte("loaded"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("loaded")))
+
+	var loads atomic.Int64
+	loader := func(_ context.Context) (string, error) {
+		loads.Add(1)
+		// Hold the load until every caller missed the key.
+		for ch.metrics.misses.Load() < int64(callers) {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond)
+		return "loaded", nil
+	}
+
+	var wg sync.WaitGroup
+	values := make([]string, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], errs[i] = ch.GetOrSet(context.Background(), "key", time.Hour, loader)
+		}()
+
+		// Start the next caller once this one missed, so that the reads are not coalesced.
+		for ch.metrics.misses.Load() < int64(i+1) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), loads.Load(), "Expected the loader to run once for concurrent misses")
+	for i := range callers {
+		assert.NoError(t, errs[i], "Expected no error for caller %d", i)
+		assert.Equal(t, "loaded", values[i], "Expected caller %d to share the loaded value", i)
+	}
+	assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+}
+
+func TestCache_GetOrSet_CancelledCaller(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+	sqlMock.MatchExpectationsInOrder(false)
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	for range 2 {
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+	}
+	sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+		WithArgs("key", []byte("loaded"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("loaded")))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (string, error) {
+		close(started)
+		<-release
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		return "loaded", nil
+	}
+
+	// The first caller starts the load and gives up while it runs.
+	firstCtx, cancel := context.WithCancel(ctx)
+	first := make(chan error)
+	go func() {
+		_, err := ch.GetOrSet(firstCtx, "key", time.Hour, loader)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan string)
+	go func() {
+		value, err := ch.GetOrSet(ctx, "key", time.Hour, loader)
+		assert.NoError(t, err, "Expected no error for the caller still waiting")
+		second <- value
+	}()
+	for ch.metrics.misses.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled, "Expected the first caller to give up")
+	close(release)
+
+	assert.Equal(t, "loaded", <-second, "Expected the load not to be cancelled by the first caller")
+	assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+}
+
+func TestCache_GetOrSetMulti(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	newCache := func(t *testing.T) (*cache, *dbMocks.DatabaseMock) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		return &cache{
+			queries:  queries.New(db),
+			Database: dbMock,
+			logger:   logMocks.NewLoggerMock(t),
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}, dbMock
+	}
+
+	t.Run("should return hits without calling the loader", func(t *testing.T) {
+		ch, _ := newCache(t)
+
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?,\?\) AND expires_at > \?`).
+			WithArgs("a", "b", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}).
+				AddRow("a", []byte("1"), fixedTime.Add(time.Hour), nil).
+				AddRow("b", []byte("2"), fixedTime.Add(time.Hour), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key IN \(\?,\?\) AND expires_at > \?`).
+			WithArgs(fixedTime, "a", "b", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		values, err := ch.GetOrSetMulti(ctx, []string{"a", "b"}, time.Hour,
+			func(_ context.Context, _ []string) (map[string]string, error) {
+				t.Fatal("loader should not be called")
+				return nil, nil
+			},
+		)
+
+		assert.NoError(t, err, "Expected no error when all keys are cached")
+		assert.Equal(t, map[string]string{"a": "1", "b": "2"}, values)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should load and store only the missing keys", func(t *testing.T) {
+		ch, dbMock := newCache(t)
+
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?,\?\) AND expires_at > \?`).
+			WithArgs("a", "b", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}).
+				AddRow("a", []byte("1"), fixedTime.Add(time.Hour), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs(fixedTime, "a", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("b", []byte("2"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				err = fn(tx)
+				assert.NoError(t, err, "Expected no error during transaction execution")
+
+				err = tx.Commit()
+				assert.NoError(t, err, "Expected no error while committing transaction")
+			}).
+			Return(nil)
+
+		var loaderKeys []string
+		values, err := ch.GetOrSetMulti(ctx, []string{"a", "b"}, time.Hour,
+			func(_ context.Context, missing []string) (map[string]string, error) {
+				loaderKeys = missing
+				return map[string]string{"b": "2"}, nil
+			},
+		)
+
+		assert.NoError(t, err, "Expected no error when loading missing keys")
+		assert.Equal(t, []string{"b"}, loaderKeys, "Loader should receive only missing keys")
+		assert.Equal(t, map[string]string{"a": "1", "b": "2"}, values)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should dedupe the keys and ignore the keys the loader was not asked for", func(t *testing.T) {
+		ch, dbMock := newCache(t)
+
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?,\?\) AND expires_at > \?`).
+			WithArgs("a", "b", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}).
+				AddRow("a", []byte("1"), fixedTime.Add(time.Hour), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs(fixedTime, "a", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("b", []byte("2"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			})
+
+		var loaderKeys []string
+		values, err := ch.GetOrSetMulti(ctx, []string{"a", "b", "b", "a"}, time.Hour,
+			func(_ context.Context, missing []string) (map[string]string, error) {
+				loaderKeys = missing
+				return map[string]string{"b": "2", "c": "3"}, nil
+			},
+		)
+
+		assert.NoError(t, err, "Expected no error when loading missing keys")
+		assert.Equal(t, []string{"b"}, loaderKeys, "Loader should receive each missing key once")
+		assert.Equal(t, map[string]string{"a": "1", "b": "2"}, values)
+		assert.Equal(t, int64(1), ch.metrics.hits.Load(), "Expected one hit")
+		assert.Equal(t, int64(1), ch.metrics.misses.Load(), "Expected one miss")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the loader fails", func(t *testing.T) {
+		ch, _ := newCache(t)
+
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs("a", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}))
+
+		values, err := ch.GetOrSetMulti(ctx, []string{"a"}, time.Hour,
+			func(_ context.Context, _ []string) (map[string]string, error) {
+				return nil, fmt.Errorf("upstream error")
+			},
+		)
+
+		assert.Error(t, err, "Expected an error when the loader fails")
+		assert.Equal(t, "loading missing keys: upstream error", err.Error())
+		assert.Nil(t, values)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the query fails", func(t *testing.T) {
+		ch, _ := newCache(t)
+
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs("a", fixedTime).
+			WillReturnError(fmt.Errorf("mock select error"))
+
+		values, err := ch.GetOrSetMulti(ctx, []string{"a"}, time.Hour,
+			func(_ context.Context, _ []string) (map[string]string, error) {
+				t.Fatal("loader should not be called")
+				return nil, nil
+			},
+		)
+
+		assert.Error(t, err, "Expected an error when the query fails")
+		assert.Equal(t, "error getting values: mock select error", err.Error())
+		assert.Nil(t, values)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
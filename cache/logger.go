@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger logs the errors of the background jobs of the cache.
+// By default the errors are stored in the log table of the database; use WithLogger
+// to send them to the logger of the application instead.
+type Logger interface {
+	Error(ctx context.Context, msg string)
+}
+
+// slogLogger is a Logger that writes to a *slog.Logger.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger that writes the errors to the given *slog.Logger.
+// Loggers of other libraries, such as zap, can be used through their slog handler.
+//
+// Parameters:
+//   - logger: the slog logger
+//
+// Returns:
+//   - Logger: the cache logger
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithLogger(cache.NewSlogLogger(slog.Default())))
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+// Error logs an error message.
+func (l slogLogger) Error(ctx context.Context, msg string) {
+	l.logger.ErrorContext(ctx, msg, slog.String("component", "litepack"))
+}
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	t.Run("should write the errors to the slog logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+		logger.Error(context.Background(), "purging cache: database is locked")
+
+		assert.Contains(t, buf.String(), "level=ERROR", "Expected an error record")
+		assert.Contains(t, buf.String(), `msg="purging cache: database is locked"`,
+			"Expected the message")
+		assert.Contains(t, buf.String(), "component=litepack", "Expected the component")
+	})
+}
@@ -0,0 +1,383 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	crf "github.com/robfig/cron/v3"
+
+	"github.com/lucasvillarinho/litepack/internal/cron"
+)
+
+// taskOptimize is the name of the task that updates the query planner statistics.
+const taskOptimize = "optimize"
+
+// Quiesce stores the writes queued by SetAsync and the buffered access times, pauses the
+// background jobs, checkpoints the WAL into the database file, and holds new writes until
+// resume is called.
+// While the cache is quiesced the database file can be copied consistently by external
+// tools (rsync, filesystem snapshots) without closing the application.
+// Reads keep being served, but they do not refresh the last accessed at timestamp.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - resume: the function that resumes writes and background jobs, safe to call more than once
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	resume, err := cache.Quiesce(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer resume()
+//
+//	err = copyFile("lpack_cache.db", "/backups/lpack_cache.db")
+func (ch *cache) Quiesce(ctx context.Context) (func(), error) {
+	// The queued writes take the write lock, so they are stored before it is held.
+	err := ch.async.flush(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("quiescing cache: flushing async writes: %w", err)
+	}
+
+	ch.writeMu.RLock()
+	err = ch.flushAccess(ctx)
+	ch.writeMu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("quiescing cache: %w", err)
+	}
+
+	// Wait for in-flight writes and background jobs to finish.
+	ch.tasksPaused.Store(true)
+	ch.writeMu.Lock()
+	if !ch.sharedCron {
+		ch.cron.Stop()
+	}
+
+	var once sync.Once
+	resume := func() {
+		once.Do(func() {
+			if !ch.sharedCron {
+				ch.cron.Start()
+			}
+			ch.tasksPaused.Store(false)
+			ch.writeMu.Unlock()
+		})
+	}
+
+	err = ch.Database.Checkpoint(ctx)
+	if err != nil {
+		resume()
+		return nil, fmt.Errorf("quiescing cache: %w", err)
+	}
+
+	return resume, nil
+}
+
+// Snapshot writes a consistent copy of the cache database to destPath using VACUUM INTO.
+// The copy is taken from a read transaction, so reads and writes keep being served while
+// it is written, and it is compacted: it does not include the free pages of the database.
+// Writes made after the snapshot starts are not included.
+//
+// Parameters:
+//   - ctx: the context
+//   - destPath: the path of the copy, it must not exist
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err = cache.Snapshot(ctx, "/backups/lpack_cache.db")
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Snapshot(ctx context.Context, destPath string) error {
+	err := ch.Database.Exec(ctx, "VACUUM INTO ?;", destPath)
+	if err != nil {
+		return fmt.Errorf("taking snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Ping checks that the database of the cache is open, readable and writable, so that
+// readiness probes detect a closed, corrupted or locked cache file before the cache
+// serves traffic. It reads the cache table and runs a write that changes no rows.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the database cannot be read or written
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	http.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+//		if err := cache.Ping(r.Context()); err != nil {
+//			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//		}
+//	})
+func (ch *cache) Ping(ctx context.Context) error {
+	err := ch.Database.Exec(ctx, "SELECT 1 FROM cache LIMIT 1;")
+	if err != nil {
+		return fmt.Errorf("pinging cache: reading: %w", err)
+	}
+
+	err = ch.Database.Exec(ctx, "UPDATE cache SET key = key WHERE 0;")
+	if err != nil {
+		return fmt.Errorf("pinging cache: writing: %w", err)
+	}
+
+	return nil
+}
+
+// CompactStep describes a step run by Compact.
+type CompactStep struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// CompactStats describes a run of Compact.
+type CompactStats struct {
+	Steps          []CompactStep `json:"steps"`
+	ExpiredEntries int64         `json:"expired_entries"`
+	SizeBefore     int64         `json:"size_before"`
+	SizeAfter      int64         `json:"size_after"`
+	Duration       time.Duration `json:"duration"`
+}
+
+// Compact runs the maintenance of the cache in one call: it deletes the expired entries,
+// vacuums the database, updates the query planner statistics with PRAGMA optimize and
+// checkpoints the WAL into the database file.
+// Writes wait while it runs, so it is meant to be scheduled off-hours.
+// If a step fails, the stats of the steps that completed are returned with the error.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - CompactStats: the duration of each step and the size of the database before and after
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	stats, err := cache.Compact(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	log.Printf("compacted %d bytes in %s", stats.SizeBefore-stats.SizeAfter, stats.Duration)
+func (ch *cache) Compact(ctx context.Context) (CompactStats, error) {
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	start := time.Now()
+	var stats CompactStats
+
+	before, err := ch.queries.GetPageStats(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("getting page stats: %w", err)
+	}
+	stats.SizeBefore = before.PageCount * before.PageSize
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"delete-expired", func() error {
+			now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+			expired, err := ch.queries.DeleteExpiredCache(ctx, now)
+			if err != nil {
+				return fmt.Errorf("deleting expired cache: %w", err)
+			}
+			ch.purgeCounters.expiredPurges.Add(1)
+			ch.hooks.expire(ctx, expired)
+			stats.ExpiredEntries = int64(len(expired))
+
+			return nil
+		}},
+		{"vacuum", func() error { return ch.Database.Vacuum(ctx) }},
+		{"optimize", func() error { return ch.Database.Optimize(ctx) }},
+		{"checkpoint", func() error { return ch.Database.Checkpoint(ctx) }},
+	}
+
+	for _, step := range steps {
+		stepStart := time.Now()
+		if err := step.run(); err != nil {
+			stats.Duration = time.Since(start)
+			return stats, fmt.Errorf("compacting cache: %w", err)
+		}
+		stats.Steps = append(stats.Steps, CompactStep{
+			Name:     step.name,
+			Duration: time.Since(stepStart),
+		})
+	}
+
+	after, err := ch.queries.GetPageStats(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("getting page stats: %w", err)
+	}
+	stats.SizeAfter = after.PageCount * after.PageSize
+	stats.Duration = time.Since(start)
+
+	return stats, nil
+}
+
+// optimizeCache schedules the update of the query planner statistics, if enabled, so
+// that the plans of the purge and expiry queries follow the growth of the cache table.
+func (ch *cache) optimizeCache(ctx context.Context) {
+	if ch.optimizeSchedule == "" {
+		return
+	}
+
+	task := func() error {
+		err := ch.Database.Optimize(ctx)
+		if err != nil {
+			ch.logger.Error(ctx, err.Error())
+			return err
+		}
+
+		return nil
+	}
+
+	err := ch.addTask(taskOptimize, string(ch.optimizeSchedule), task)
+	if err != nil {
+		err = fmt.Errorf("adding cron task: %w", err)
+		ch.logger.Error(ctx, err.Error())
+	}
+}
+
+// StopJobs stops the background jobs of the cache, such as the job that deletes
+// expired entries. It is called by the litepack manager before closing the cache.
+// A scheduler given by WithScheduler is not stopped: the jobs of the cache are removed
+// from it instead.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the operation failed
+func (ch *cache) StopJobs(_ context.Context) error {
+	ch.stopTasks()
+	return nil
+}
+
+// addTask schedules a task of the cache and records its entry, so that the task is
+// removed from the scheduler when the cache is closed.
+func (ch *cache) addTask(name, schedule string, task cron.TaskFunc) error {
+	return ch.trackTask(ch.cron.AddTask(name, schedule, ch.pausable(task)))
+}
+
+// addTaskAndExec schedules a task of the cache like addTask and runs it immediately.
+func (ch *cache) addTaskAndExec(name, schedule string, task cron.TaskFunc) error {
+	return ch.trackTask(ch.cron.AddTaskAndExec(name, schedule, ch.pausable(task)))
+}
+
+// trackTask records the entry of a task added to the scheduler.
+func (ch *cache) trackTask(entryID crf.EntryID, err error) error {
+	if err != nil {
+		return err
+	}
+
+	ch.tasksMu.Lock()
+	ch.taskIDs = append(ch.taskIDs, entryID)
+	ch.tasksMu.Unlock()
+
+	return nil
+}
+
+// pausable skips the runs of a task while the cache is quiesced, since a scheduler
+// given by WithScheduler is not stopped.
+func (ch *cache) pausable(task cron.TaskFunc) cron.TaskFunc {
+	return func() error {
+		if ch.tasksPaused.Load() {
+			return nil
+		}
+
+		return task()
+	}
+}
+
+// ownedTasks returns the entries of the tasks the cache added to a scheduler given by
+// WithScheduler, or nil if the cache created the scheduler.
+func (ch *cache) ownedTasks() map[crf.EntryID]struct{} {
+	if !ch.sharedCron {
+		return nil
+	}
+
+	ch.tasksMu.Lock()
+	defer ch.tasksMu.Unlock()
+
+	owned := make(map[crf.EntryID]struct{}, len(ch.taskIDs))
+	for _, entryID := range ch.taskIDs {
+		owned[entryID] = struct{}{}
+	}
+
+	return owned
+}
+
+// stopTasks removes the tasks of the cache from the scheduler and stops the scheduler
+// if the cache created it.
+func (ch *cache) stopTasks() {
+	ch.tasksMu.Lock()
+	taskIDs := ch.taskIDs
+	ch.taskIDs = nil
+	ch.tasksMu.Unlock()
+
+	for _, entryID := range taskIDs {
+		ch.cron.Remove(entryID)
+	}
+	if !ch.sharedCron {
+		ch.cron.Stop()
+	}
+}
+
+// stopBackground cancels the background jobs of the cache and waits until the running
+// ones finish, or until ctx is done.
+func (ch *cache) stopBackground(ctx context.Context) error {
+	if ch.cancelBackground != nil {
+		ch.cancelBackground()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ch.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		ch.stopTasks()
+		return fmt.Errorf("waiting for background jobs: %w", ctx.Err())
+	}
+
+	// The tasks of a shared scheduler are removed, the scheduler keeps running.
+	if ch.sharedCron {
+		ch.stopTasks()
+		return nil
+	}
+
+	err := ch.cron.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("waiting for background jobs: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,386 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	crf "github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+	"github.com/lucasvillarinho/litepack/internal/cron"
+	cronMocks "github.com/lucasvillarinho/litepack/internal/cron/mocks"
+)
+
+func TestCache_Quiesce(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should hold writes until resumed", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		cronMock := cronMocks.NewCronMock(t)
+
+		cronMock.EXPECT().Stop().Once()
+		dbMock.EXPECT().Checkpoint(mock.Anything).Return(nil).Once()
+		cronMock.EXPECT().Start().Once()
+
+		ch := &cache{
+			Database: dbMock,
+			cron:     cronMock,
+		}
+
+		resume, err := ch.Quiesce(ctx)
+		assert.NoError(t, err, "Expected no error while quiescing the cache")
+
+		released := make(chan struct{})
+		go func() {
+			ch.writeMu.RLock()
+			defer ch.writeMu.RUnlock()
+			close(released)
+		}()
+
+		select {
+		case <-released:
+			t.Fatal("Expected writes to be held while quiesced")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		resume()
+		resume()
+
+		select {
+		case <-released:
+		case <-time.After(time.Second):
+			t.Fatal("Expected writes to be released after resume")
+		}
+	})
+
+	t.Run("should resume and return error if the checkpoint fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		cronMock := cronMocks.NewCronMock(t)
+
+		cronMock.EXPECT().Stop().Once()
+		dbMock.EXPECT().Checkpoint(mock.Anything).Return(fmt.Errorf("database is locked")).Once()
+		cronMock.EXPECT().Start().Once()
+
+		ch := &cache{
+			Database: dbMock,
+			cron:     cronMock,
+		}
+
+		resume, err := ch.Quiesce(ctx)
+
+		assert.Error(t, err, "Expected an error when the checkpoint fails")
+		assert.Equal(t, "quiescing cache: database is locked", err.Error())
+		assert.Nil(t, resume)
+		assert.True(t, ch.writeMu.TryLock(), "Expected writes to be released after a failure")
+	})
+
+	t.Run("should return error if the async writes are not stored", func(t *testing.T) {
+		cronMock := cronMocks.NewCronMock(t)
+
+		ch := &cache{
+			cron:  cronMock,
+			async: &asyncWriter{queued: 1, idle: make(chan struct{})},
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		resume, err := ch.Quiesce(ctx)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, resume)
+		assert.True(t, ch.writeMu.TryLock(), "Expected writes not to be held after a failure")
+	})
+
+	t.Run("should pause the tasks without stopping a shared scheduler", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		cronMock := cronMocks.NewCronMock(t)
+
+		dbMock.EXPECT().Checkpoint(mock.Anything).Return(nil).Once()
+
+		ch := &cache{
+			Database:   dbMock,
+			cron:       cronMock,
+			sharedCron: true,
+		}
+
+		runs := 0
+		task := ch.pausable(func() error {
+			runs++
+			return nil
+		})
+
+		resume, err := ch.Quiesce(ctx)
+		assert.NoError(t, err, "Expected no error while quiescing the cache")
+
+		assert.NoError(t, task())
+		assert.Equal(t, 0, runs, "Expected the task to be skipped while quiesced")
+
+		resume()
+
+		assert.NoError(t, task())
+		assert.Equal(t, 1, runs, "Expected the task to run after resume")
+	})
+}
+
+func TestCache_Snapshot(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should copy the database with VACUUM INTO", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().Exec(mock.Anything, "VACUUM INTO ?;", "backup.db").Return(nil).Once()
+
+		ch := &cache{Database: dbMock}
+
+		err := ch.Snapshot(ctx, "backup.db")
+
+		assert.NoError(t, err, "Expected no error while taking the snapshot")
+	})
+
+	t.Run("should return error if the copy fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			Exec(mock.Anything, "VACUUM INTO ?;", "backup.db").
+			Return(fmt.Errorf("output file already exists")).
+			Once()
+
+		ch := &cache{Database: dbMock}
+
+		err := ch.Snapshot(ctx, "backup.db")
+
+		assert.EqualError(t, err, "taking snapshot: output file already exists")
+	})
+}
+
+func TestCache_Ping(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should read and write the database", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().Exec(mock.Anything, "SELECT 1 FROM cache LIMIT 1;").Return(nil).Once()
+		dbMock.EXPECT().Exec(mock.Anything, "UPDATE cache SET key = key WHERE 0;").Return(nil).Once()
+
+		ch := &cache{Database: dbMock}
+
+		err := ch.Ping(ctx)
+
+		assert.NoError(t, err, "Expected no error while pinging the cache")
+	})
+
+	t.Run("should return error if the database cannot be read", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			Exec(mock.Anything, "SELECT 1 FROM cache LIMIT 1;").
+			Return(fmt.Errorf("database disk image is malformed")).
+			Once()
+
+		ch := &cache{Database: dbMock}
+
+		err := ch.Ping(ctx)
+
+		assert.EqualError(t, err, "pinging cache: reading: database disk image is malformed")
+	})
+
+	t.Run("should return error if the database cannot be written", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().Exec(mock.Anything, "SELECT 1 FROM cache LIMIT 1;").Return(nil).Once()
+		dbMock.EXPECT().
+			Exec(mock.Anything, "UPDATE cache SET key = key WHERE 0;").
+			Return(fmt.Errorf("database is locked")).
+			Once()
+
+		ch := &cache{Database: dbMock}
+
+		err := ch.Ping(ctx)
+
+		assert.EqualError(t, err, "pinging cache: writing: database is locked")
+	})
+}
+
+func TestCache_Compact(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	pageStats := func(pageCount int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"page_count", "page_size", "freelist_count"}).
+			AddRow(pageCount, 4096, 0)
+	}
+
+	newCache := func(dbMock *dbMocks.DatabaseMock) *cache {
+		return &cache{
+			Database: dbMock,
+			queries:  queries.New(db),
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}
+	}
+
+	t.Run("should run every maintenance step", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().Vacuum(mock.Anything).Return(nil).Once()
+		dbMock.EXPECT().Optimize(mock.Anything).Return(nil).Once()
+		dbMock.EXPECT().Checkpoint(mock.Anything).Return(nil).Once()
+
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count`).
+			WillReturnRows(pageStats(10))
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE expires_at <= \? AND pinned = 0 RETURNING key`).
+			WithArgs(fixedTime).
+			WillReturnRows(keyRows(3))
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count`).
+			WillReturnRows(pageStats(4))
+
+		stats, err := newCache(dbMock).Compact(ctx)
+
+		assert.NoError(t, err, "Expected no error while compacting the cache")
+		assert.Equal(t, int64(3), stats.ExpiredEntries)
+		assert.Equal(t, int64(10*4096), stats.SizeBefore)
+		assert.Equal(t, int64(4*4096), stats.SizeAfter)
+		names := []string{}
+		for _, step := range stats.Steps {
+			names = append(names, step.Name)
+		}
+		assert.Equal(t, []string{"delete-expired", "vacuum", "optimize", "checkpoint"}, names)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return the completed steps if a step fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().Vacuum(mock.Anything).Return(fmt.Errorf("vacuuming: database is locked")).Once()
+
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count`).
+			WillReturnRows(pageStats(10))
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE expires_at <= \?`).
+			WillReturnRows(keyRows(0))
+
+		stats, err := newCache(dbMock).Compact(ctx)
+
+		assert.EqualError(t, err, "compacting cache: vacuuming: database is locked")
+		assert.Len(t, stats.Steps, 1, "Expected the completed step to be reported")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestCache_StopBackground(t *testing.T) {
+	t.Run("should cancel and wait for the background jobs", func(t *testing.T) {
+		cronMock := cronMocks.NewCronMock(t)
+		cronMock.EXPECT().Shutdown(mock.Anything).Return(nil).Once()
+
+		background, cancel := context.WithCancel(context.Background())
+		ch := &cache{cron: cronMock, background: background, cancelBackground: cancel}
+
+		finished := false
+		ch.jobs.Add(1)
+		go func() {
+			defer ch.jobs.Done()
+			<-ch.background.Done()
+			time.Sleep(10 * time.Millisecond)
+			finished = true
+		}()
+
+		err := ch.stopBackground(context.Background())
+
+		assert.NoError(t, err, "Expected no error while stopping the background jobs")
+		assert.True(t, finished, "Expected the job to finish before returning")
+	})
+
+	t.Run("should stop waiting when the context is done", func(t *testing.T) {
+		cronMock := cronMocks.NewCronMock(t)
+		cronMock.EXPECT().Stop().Once()
+
+		ch := &cache{cron: cronMock}
+		ch.jobs.Add(1)
+		defer ch.jobs.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := ch.stopBackground(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("should return error if the running tasks do not finish", func(t *testing.T) {
+		cronMock := cronMocks.NewCronMock(t)
+		cronMock.EXPECT().Shutdown(mock.Anything).Return(context.DeadlineExceeded).Once()
+
+		ch := &cache{cron: cronMock}
+
+		err := ch.stopBackground(context.Background())
+
+		assert.EqualError(t, err, "waiting for background jobs: context deadline exceeded")
+	})
+
+	t.Run("should remove the tasks without stopping a shared scheduler", func(t *testing.T) {
+		cronMock := cronMocks.NewCronMock(t)
+		cronMock.EXPECT().Remove(crf.EntryID(1)).Once()
+		cronMock.EXPECT().Remove(crf.EntryID(2)).Once()
+
+		ch := &cache{cron: cronMock, sharedCron: true, taskIDs: []crf.EntryID{1, 2}}
+
+		err := ch.stopBackground(context.Background())
+
+		assert.NoError(t, err, "Expected no error while stopping the background jobs")
+		assert.Empty(t, ch.taskIDs, "Expected the tasks to be forgotten")
+	})
+}
+
+func TestCache_StopJobs(t *testing.T) {
+	t.Run("should stop the background jobs", func(t *testing.T) {
+		cronMock := cronMocks.NewCronMock(t)
+		cronMock.EXPECT().Stop().Once()
+
+		ch := &cache{cron: cronMock}
+
+		err := ch.StopJobs(context.Background())
+
+		assert.NoError(t, err, "Expected no error while stopping jobs")
+	})
+
+	t.Run("should remove the tasks of the cache from a shared scheduler", func(t *testing.T) {
+		cronMock := cronMocks.NewCronMock(t)
+		cronMock.EXPECT().Remove(crf.EntryID(3)).Once()
+
+		ch := &cache{cron: cronMock, sharedCron: true, taskIDs: []crf.EntryID{3}}
+
+		err := ch.StopJobs(context.Background())
+
+		assert.NoError(t, err, "Expected no error while stopping jobs")
+	})
+}
+
+func TestCache_optimizeCache(t *testing.T) {
+	t.Run("should schedule the optimize task if it is enabled", func(t *testing.T) {
+		ch := &cache{
+			cron:             cron.New(time.UTC),
+			optimizeSchedule: cron.EveryHour,
+		}
+
+		ch.optimizeCache(context.Background())
+
+		tasks := ch.cron.Tasks()
+		assert.Len(t, tasks, 1, "Expected the optimize task to be scheduled")
+		assert.Equal(t, taskOptimize, tasks[0].Name, "Expected the optimize task")
+		assert.Equal(t, string(cron.EveryHour), tasks[0].Schedule)
+	})
+
+	t.Run("should not schedule the optimize task if it is disabled", func(t *testing.T) {
+		ch := &cache{cron: cron.New(time.UTC)}
+
+		ch.optimizeCache(context.Background())
+
+		assert.Empty(t, ch.cron.Tasks(), "Expected no task to be scheduled")
+	})
+}
@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// memoryTier is a bounded in-memory LRU of cache entries consulted before the database.
+// It is safe for concurrent use, and a nil tier is a disabled tier.
+type memoryTier struct {
+	items      map[string]*list.Element
+	lru        *list.List // front is the most recently used entry
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+}
+
+// memoryEntry is an entry of the memory tier.
+type memoryEntry struct {
+	expiresAt time.Time
+	key       string
+	value     []byte
+}
+
+// newMemoryTier creates a memory tier bounded by the given number of entries and bytes.
+// A bound of 0 or less is unlimited.
+func newMemoryTier(maxEntries, maxBytes int) *memoryTier {
+	return &memoryTier{
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+// get returns a copy of the value of a non-expired entry and marks it as recently used.
+func (m *memoryTier) get(key string, now time.Time) ([]byte, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expiresAt.After(now) {
+		m.remove(elem)
+		return nil, false
+	}
+
+	m.lru.MoveToFront(elem)
+
+	return append([]byte(nil), entry.value...), true
+}
+
+// set stores a copy of the value and evicts the least recently used entries over the bounds.
+// Values larger than the byte bound are not stored.
+func (m *memoryTier) set(key string, value []byte, expiresAt time.Time) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.items[key]; ok {
+		m.remove(elem)
+	}
+
+	if m.maxBytes > 0 && len(value) > m.maxBytes {
+		return
+	}
+
+	entry := &memoryEntry{
+		key:       key,
+		value:     append([]byte(nil), value...),
+		expiresAt: expiresAt,
+	}
+	m.items[key] = m.lru.PushFront(entry)
+	m.bytes += len(entry.value)
+
+	for m.overLimit() {
+		m.remove(m.lru.Back())
+	}
+}
+
+// del removes an entry.
+func (m *memoryTier) del(key string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.items[key]; ok {
+		m.remove(elem)
+	}
+}
+
+// delPrefix removes the entries whose key starts with the given prefix.
+func (m *memoryTier) delPrefix(prefix string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, elem := range m.items {
+		if strings.HasPrefix(key, prefix) {
+			m.remove(elem)
+		}
+	}
+}
+
+// clear removes every entry.
+func (m *memoryTier) clear() {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items = make(map[string]*list.Element)
+	m.lru.Init()
+	m.bytes = 0
+}
+
+// len returns the number of entries and the bytes used by their values.
+func (m *memoryTier) len() (int, int) {
+	if m == nil {
+		return 0, 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.items), m.bytes
+}
+
+// overLimit reports whether the tier exceeds one of its bounds.
+// The caller must hold the lock.
+func (m *memoryTier) overLimit() bool {
+	if m.maxEntries > 0 && len(m.items) > m.maxEntries {
+		return true
+	}
+
+	return m.maxBytes > 0 && m.bytes > m.maxBytes
+}
+
+// remove removes an element from the tier.
+// The caller must hold the lock.
+func (m *memoryTier) remove(elem *list.Element) {
+	entry := m.lru.Remove(elem).(*memoryEntry)
+	delete(m.items, entry.key)
+	m.bytes -= len(entry.value)
+}
+
+// getBytesThroughMemory retrieves a value from the memory tier, falling back to the
+// database on a miss and keeping the entry in memory for the next reads.
+func (ch *cache) getBytesThroughMemory(ctx context.Context, key string) ([]byte, error) {
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+
+	if value, ok := ch.memory.get(key, now); ok {
+		ch.metrics.hits.Add(1)
+		ch.slideExpiration(ctx, key, now)
+		return value, nil
+	}
+
+	entry, err := ch.getEntry(ctx, key, now)
+	if err != nil {
+		if isMiss(err) {
+			ch.metrics.misses.Add(1)
+		}
+
+		return nil, err
+	}
+	ch.metrics.hits.Add(1)
+	ch.memory.set(key, entry.Value, entry.ExpiresAt)
+	ch.slideExpiration(ctx, key, now)
+
+	// Buffer the access time instead of writing it, if enabled.
+	if ch.access.record(now, key) {
+		return entry.Value, nil
+	}
+
+	// Skip the access time update while the cache is quiesced.
+	if !ch.writeMu.TryRLock() {
+		return entry.Value, nil
+	}
+	defer ch.writeMu.RUnlock()
+
+	err = ch.queries.UpdateLastAccessedAt(ctx, queries.UpdateLastAccessedAtParams{
+		LastAccessedAt: now,
+		Key:            key,
+	})
+	if err != nil {
+		ch.logger.Error(ctx, fmt.Sprintf("error updating last accessed at: %v", err))
+	}
+
+	return entry.Value, nil
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
+)
+
+func TestMemoryTier(t *testing.T) {
+	now := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	t.Run("should return stored values until they expire", func(t *testing.T) {
+		m := newMemoryTier(0, 0)
+		m.set("key", []byte("value"), later)
+
+		value, ok := m.get("key", now)
+		assert.True(t, ok, "Expected a hit before expiration")
+		assert.Equal(t, []byte("value"), value)
+
+		_, ok = m.get("key", later)
+		assert.False(t, ok, "Expected a miss after expiration")
+
+		entries, _ := m.len()
+		assert.Zero(t, entries, "Expected the expired entry to be removed")
+	})
+
+	t.Run("should evict the least recently used entry over the entry bound", func(t *testing.T) {
+		m := newMemoryTier(2, 0)
+		m.set("a", []byte("1"), later)
+		m.set("b", []byte("2"), later)
+		_, _ = m.get("a", now)
+		m.set("c", []byte("3"), later)
+
+		_, ok := m.get("b", now)
+		assert.False(t, ok, "Expected the least recently used entry to be evicted")
+		_, ok = m.get("a", now)
+		assert.True(t, ok, "Expected the recently used entry to be kept")
+		_, ok = m.get("c", now)
+		assert.True(t, ok, "Expected the new entry to be kept")
+	})
+
+	t.Run("should evict entries over the byte bound", func(t *testing.T) {
+		m := newMemoryTier(0, 8)
+		m.set("a", []byte("1234"), later)
+		m.set("b", []byte("5678"), later)
+		m.set("c", []byte("90"), later)
+
+		entries, bytes := m.len()
+		assert.Equal(t, 2, entries)
+		assert.Equal(t, 6, bytes)
+		_, ok := m.get("a", now)
+		assert.False(t, ok, "Expected the oldest entry to be evicted")
+	})
+
+	t.Run("should not store values larger than the byte bound", func(t *testing.T) {
+		m := newMemoryTier(0, 4)
+		m.set("key", []byte("v"), later)
+		m.set("key", []byte("too large"), later)
+
+		_, ok := m.get("key", now)
+		assert.False(t, ok, "Expected the previous value to be dropped")
+	})
+
+	t.Run("should not share the value with callers", func(t *testing.T) {
+		m := newMemoryTier(0, 0)
+		value := []byte("value")
+		m.set("key", value, later)
+		value[0] = 'X'
+
+		got, _ := m.get("key", now)
+		got[1] = 'X'
+
+		again, _ := m.get("key", now)
+		assert.Equal(t, []byte("value"), again)
+	})
+
+	t.Run("should delete entries by key, prefix and all", func(t *testing.T) {
+		m := newMemoryTier(0, 0)
+		m.set("users:1", []byte("1"), later)
+		m.set("users:2", []byte("2"), later)
+		m.set("orders:1", []byte("3"), later)
+
+		m.del("users:1")
+		_, ok := m.get("users:1", now)
+		assert.False(t, ok, "Expected the key to be deleted")
+
+		m.delPrefix("users:")
+		entries, _ := m.len()
+		assert.Equal(t, 1, entries, "Expected only the other prefix to be kept")
+
+		m.clear()
+		entries, bytes := m.len()
+		assert.Zero(t, entries)
+		assert.Zero(t, bytes)
+	})
+
+	t.Run("should be a no-op when disabled", func(t *testing.T) {
+		var m *memoryTier
+		m.set("key", []byte("value"), later)
+		m.del("key")
+		m.delPrefix("key")
+		m.clear()
+
+		_, ok := m.get("key", now)
+		assert.False(t, ok)
+	})
+}
+
+func TestCache_MemoryTier(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	newCache := func(t *testing.T) *cache {
+		return &cache{
+			queries: queries.New(db),
+			logger:  logMocks.NewLoggerMock(t),
+			memory:  newMemoryTier(10, 0),
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}
+	}
+
+	t.Run("should serve repeated reads from memory", func(t *testing.T) {
+		ch := newCache(t)
+
+		sqlMock.ExpectQuery(`SELECT value, expires_at, checksum FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "expires_at", "checksum"}).
+				AddRow([]byte("value"), fixedTime.Add(time.Hour), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
+			WithArgs(fixedTime, "key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		for range 3 {
+			value, err := ch.Get(ctx, "key")
+			assert.NoError(t, err, "Expected no error when getting the value")
+			assert.Equal(t, "value", value)
+		}
+
+		assert.Equal(t, int64(3), ch.Metrics().Hits)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected a single database read")
+	})
+
+	t.Run("should write through on Set and invalidate on Del", func(t *testing.T) {
+		ch := newCache(t)
+
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE key = ?`).
+			WithArgs("key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectQuery(`SELECT value, expires_at, checksum FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "expires_at", "checksum"}))
+
+		assert.NoError(t, ch.Set(ctx, "key", "value", time.Hour), "Expected no error while setting")
+
+		value, err := ch.Get(ctx, "key")
+		assert.NoError(t, err, "Expected the value to be served from memory")
+		assert.Equal(t, "value", value)
+
+		assert.NoError(t, ch.Del(ctx, "key"), "Expected no error while deleting")
+
+		_, err = ch.Get(ctx, "key")
+		assert.ErrorIs(t, err, ErrKeyNotFound, "Expected the deleted key to be read from the database")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should clear memory on Flush", func(t *testing.T) {
+		ch := newCache(t)
+		ch.memory.set("key", []byte("value"), fixedTime.Add(time.Hour))
+
+		sqlMock.ExpectExec(`DELETE FROM cache`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, ch.Flush(ctx), "Expected no error while flushing")
+
+		entries, _ := ch.memory.len()
+		assert.Zero(t, entries, "Expected the memory tier to be cleared")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// SetOption is a function that configures an entry written by Set or SetBytes.
+type SetOption func(*setOptions)
+
+// setOptions are the options of an entry written by Set or SetBytes.
+type setOptions struct {
+	metadata map[string]string
+}
+
+// newSetOptions returns the options configured by opts.
+func newSetOptions(opts []SetOption) setOptions {
+	var o setOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// encodeMetadata returns the metadata of the entry encoded as JSON, or NULL if it has none.
+func (o setOptions) encodeMetadata() (sql.NullString, error) {
+	if len(o.metadata) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	encoded, err := json.Marshal(o.metadata)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("encoding metadata: %w", err)
+	}
+
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// WithMetadata stores metadata alongside the value of the entry, such as its source URL,
+// version or content type, so that it doesn't have to be encoded into the value.
+// The metadata is returned by GetEntry and replaced by each write of the key, except
+// Append and SetIfVersion that keep it.
+//
+// Example:
+//
+//	err := cache.Set(ctx, "page:home", html, time.Hour, cache.WithMetadata(map[string]string{
+//		"source":       "https://example.com",
+//		"content-type": "text/html",
+//	}))
+func WithMetadata(metadata map[string]string) SetOption {
+	return func(o *setOptions) {
+		o.metadata = metadata
+	}
+}
+
+// Entry is a cache entry returned by GetEntry.
+type Entry struct {
+	// ExpiresAt is the expiration time of the entry, zero if it never expires.
+	ExpiresAt time.Time `json:"expires_at"`
+	// Metadata is the metadata stored with WithMetadata, nil if the entry has none.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Value is the value of the entry.
+	Value string `json:"value"`
+	// Version is the version of the entry, see GetWithVersion.
+	Version int64 `json:"version"`
+}
+
+// GetEntry retrieves an entry from the cache by key, with its metadata, expiration time
+// and version.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//
+// Returns:
+//   - Entry: the cache entry
+//   - error: an error if the operation failed, ErrKeyExpired if the entry expired,
+//     ErrKeyNotFound if the key does not exist,
+//     ErrCorruptValue if the value does not match its checksum,
+//     ErrInvalidKey if the key is invalid
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	entry, err := cache.GetEntry(ctx, "page:home")
+//	if err != nil {
+//		return err
+//	}
+//	w.Header().Set("Content-Type", entry.Metadata["content-type"])
+func (ch *cache) GetEntry(ctx context.Context, key string) (Entry, error) {
+	if err := ch.checkKey(key); err != nil {
+		return Entry{}, err
+	}
+
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	row, err := ch.reads().GetEntryWithMetadata(ctx, queries.GetEntryWithMetadataParams{
+		Key:       key,
+		ExpiresAt: now,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ch.metrics.misses.Add(1)
+			return Entry{}, ch.missError(ctx, key, now)
+		}
+
+		return Entry{}, fmt.Errorf("error getting entry: %w", err)
+	}
+	if err := ch.verifyChecksum(ctx, key, row.Value, row.Checksum); err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{
+		Value:   string(row.Value),
+		Version: row.Version,
+	}
+	if row.ExpiresAt.Before(neverExpires) {
+		entry.ExpiresAt = row.ExpiresAt
+	}
+	if row.Metadata.Valid {
+		err = json.Unmarshal([]byte(row.Metadata.String), &entry.Metadata)
+		if err != nil {
+			return Entry{}, fmt.Errorf("decoding metadata: %w", err)
+		}
+	}
+	ch.metrics.hits.Add(1)
+
+	// Buffer the access time instead of writing it, if enabled.
+	if ch.access.record(now, key) {
+		return entry, nil
+	}
+
+	// Skip the access time update while the cache is quiesced.
+	if !ch.writeMu.TryRLock() {
+		return entry, nil
+	}
+	defer ch.writeMu.RUnlock()
+
+	err = ch.queries.UpdateLastAccessedAt(ctx, queries.UpdateLastAccessedAtParams{
+		LastAccessedAt: now,
+		Key:            key,
+	})
+	if err != nil {
+		ch.logger.Error(ctx, fmt.Sprintf("error updating last accessed at: %v", err))
+	}
+
+	return entry, nil
+}
+
+// GetEntry retrieves an entry from the namespace by key, with its metadata.
+func (ns *namespace) GetEntry(ctx context.Context, key string) (Entry, error) {
+	return ns.cache.GetEntry(ctx, ns.key(key))
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_Metadata(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: time.UTC,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+	columns := []string{"value", "checksum", "metadata", "expires_at", "version"}
+
+	t.Run("should store the metadata as JSON", func(t *testing.T) {
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil,
+				`{"content-type":"text/html","source":"https://example.com"}`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := ch.Set(ctx, "key", "value", time.Hour, WithMetadata(map[string]string{
+			"source":       "https://example.com",
+			"content-type": "text/html",
+		}))
+
+		assert.NoError(t, err, "Expected no error while setting the key with metadata")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return the entry with its metadata", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, metadata, expires_at, version FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow([]byte("value"), nil, `{"source":"https://example.com"}`, fixedTime.Add(time.Hour), 3))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?`).
+			WithArgs(fixedTime, "key").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		entry, err := ch.GetEntry(ctx, "key")
+
+		assert.NoError(t, err, "Expected no error while getting the entry")
+		assert.Equal(t, Entry{
+			Value:     "value",
+			Metadata:  map[string]string{"source": "https://example.com"},
+			ExpiresAt: fixedTime.Add(time.Hour),
+			Version:   3,
+		}, entry)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return a zero expiration for entries that never expire", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, metadata, expires_at, version FROM cache`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow([]byte("value"), nil, nil, neverExpires, 1))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?`).
+			WithArgs(fixedTime, "key").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		entry, err := ch.GetEntry(ctx, "key")
+
+		assert.NoError(t, err, "Expected no error while getting the entry")
+		assert.True(t, entry.ExpiresAt.IsZero(), "Expected a zero expiration")
+		assert.Nil(t, entry.Metadata, "Expected no metadata")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrKeyNotFound for a missing key", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, metadata, expires_at, version FROM cache`).
+			WithArgs("missing", fixedTime).
+			WillReturnError(sql.ErrNoRows)
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache WHERE key = \? AND expires_at <= \?`).
+			WithArgs("missing", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		_, err := ch.GetEntry(ctx, "missing")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound, "Expected ErrKeyNotFound for a missing key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -0,0 +1,55 @@
+package cache
+
+import "sync/atomic"
+
+// Metrics holds the operation counters of the cache since it was opened.
+type Metrics struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Sets      int64 `json:"sets"`
+	Deletes   int64 `json:"deletes"`
+	Evictions int64 `json:"evictions"`
+}
+
+// HitRatio returns the ratio of reads that found a live entry, between 0 and 1.
+// It returns 0 if no reads were made.
+func (m Metrics) HitRatio() float64 {
+	reads := m.Hits + m.Misses
+	if reads == 0 {
+		return 0
+	}
+
+	return float64(m.Hits) / float64(reads)
+}
+
+// metrics counts the operations executed by the cache.
+type metrics struct {
+	hits    atomic.Int64 // reads that found a live entry
+	misses  atomic.Int64 // reads of missing or expired entries
+	sets    atomic.Int64 // entries written
+	deletes atomic.Int64 // keys deleted with Del
+}
+
+// Metrics returns the hits, misses, sets, deletes and evictions counted since the cache
+// was opened. Evictions are the entries deleted to free space when the database is full.
+// Reads done by MGet and GetOrSetMulti count one hit or miss per key.
+//
+// Returns:
+//   - Metrics: the operation counters
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	metrics := cache.Metrics()
+//	log.Printf("hit ratio: %.2f", metrics.HitRatio())
+func (ch *cache) Metrics() Metrics {
+	return Metrics{
+		Hits:      ch.metrics.hits.Load(),
+		Misses:    ch.metrics.misses.Load(),
+		Sets:      ch.metrics.sets.Load(),
+		Deletes:   ch.metrics.deletes.Load(),
+		Evictions: ch.purgeCounters.evictedEntries.Load(),
+	}
+}
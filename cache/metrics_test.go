@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_Metrics(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	newCache := func() *cache {
+		return &cache{
+			queries: queries.New(db),
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}
+	}
+
+	t.Run("should count hits and misses", func(t *testing.T) {
+		ch := newCache()
+
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("key", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "checksum"}).AddRow("value", nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
+			WithArgs(sqlmock.AnyArg(), "key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectQuery(`SELECT value, checksum FROM cache WHERE`).
+			WithArgs("missing", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		_, _ = ch.Get(ctx, "key")
+		_, _ = ch.Get(ctx, "missing")
+
+		metrics := ch.Metrics()
+
+		assert.Equal(t, int64(1), metrics.Hits, "Expected one hit")
+		assert.Equal(t, int64(1), metrics.Misses, "Expected one miss")
+		assert.Equal(t, 0.5, metrics.HitRatio())
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should count one hit or miss per key on multi reads", func(t *testing.T) {
+		ch := newCache()
+
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?,\?,\?\) AND expires_at > \?`).
+			WithArgs("a", "b", "c", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}).
+				AddRow("a", []byte("1"), fixedTime.Add(time.Hour), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs(fixedTime, "a", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := ch.MGet(ctx, "a", "b", "c")
+		assert.NoError(t, err, "Expected no error while getting values")
+
+		metrics := ch.Metrics()
+
+		assert.Equal(t, int64(1), metrics.Hits, "Expected one hit")
+		assert.Equal(t, int64(2), metrics.Misses, "Expected two misses")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should count sets, deletes and evictions", func(t *testing.T) {
+		ch := newCache()
+		ch.purgeCounters.evictedEntries.Add(20)
+
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("key", []byte("value"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE key = ?`).
+			WithArgs("key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		assert.NoError(t, ch.Set(ctx, "key", "value", time.Hour), "Expected no error while setting")
+		assert.NoError(t, ch.Del(ctx, "key"), "Expected no error while deleting")
+
+		assert.Equal(t, Metrics{Sets: 1, Deletes: 1, Evictions: 20}, ch.Metrics())
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestMetrics_HitRatio(t *testing.T) {
+	t.Run("should return zero without reads", func(t *testing.T) {
+		assert.Zero(t, Metrics{Sets: 10}.HitRatio())
+	})
+
+	t.Run("should return the ratio of hits", func(t *testing.T) {
+		assert.Equal(t, 0.75, Metrics{Hits: 3, Misses: 1}.HitRatio())
+	})
+}
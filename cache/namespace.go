@@ -0,0 +1,384 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// namespaceSeparator separates the namespace from the key in the stored keys.
+const namespaceSeparator = ":"
+
+// namespace is a view of the cache whose keys are stored with a prefix,
+// isolating them from the keys of the cache and of other namespaces.
+type namespace struct {
+	*cache
+	prefix string
+}
+
+// Namespace returns a view of the cache whose keys are isolated from the keys of the
+// cache and of other namespaces. The keys are stored as "<name>:<key>" in the same
+// database, so Keys, Count, Flush and Stats only see the entries of the namespace.
+// Namespaces can be nested, e.g. cache.Namespace("users").Namespace("sessions").
+//
+// The view shares the database, the background jobs and the metrics of the cache:
+// closing or destroying the view closes or destroys the cache, and flushing the cache
+// deletes the entries of every namespace.
+//
+// The options override the purge percent, the default TTL and the max bytes of the cache
+// for the namespace and for the namespaces nested in it without options of their own.
+// They replace the options given by a previous call with the same name; a call without
+// options keeps them.
+//
+// Parameters:
+//   - name: the namespace name
+//   - opts: the namespace options
+//
+// Returns:
+//   - Cache: the namespaced view of the cache
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	users := cache.Namespace("users")
+//	err = users.Set(ctx, "1", "John", time.Minute) // stored as users:1
+//	err = users.Flush(ctx)                         // deletes only users:*
+//
+//	thumbs := cache.Namespace("thumbs",
+//		cache.WithNamespacePurgePercent(0.8),
+//		cache.WithNamespaceDefaultTTL(time.Hour),
+//	)
+//	err = thumbs.Set(ctx, "1", data, 0) // expires in an hour
+func (ch *cache) Namespace(name string, opts ...NamespaceOption) Cache {
+	ns := &namespace{cache: ch, prefix: name + namespaceSeparator}
+	if len(opts) > 0 {
+		ch.setNamespacePolicy(ns.prefix, opts)
+	}
+
+	return ns
+}
+
+// Namespace returns a view nested in the namespace.
+func (ns *namespace) Namespace(name string, opts ...NamespaceOption) Cache {
+	nested := &namespace{cache: ns.cache, prefix: ns.prefix + name + namespaceSeparator}
+	if len(opts) > 0 {
+		ns.setNamespacePolicy(nested.prefix, opts)
+	}
+
+	return nested
+}
+
+// Set sets a key-value pair in the namespace with the given TTL.
+func (ns *namespace) Set(
+	ctx context.Context,
+	key, value string,
+	ttl time.Duration,
+	opts ...SetOption,
+) error {
+	return ns.cache.Set(ctx, ns.key(key), value, ns.ttl(ttl), opts...)
+}
+
+// SetBytes sets a key-value pair in the namespace with the given TTL, storing the value as is.
+func (ns *namespace) SetBytes(
+	ctx context.Context,
+	key string,
+	value []byte,
+	ttl time.Duration,
+	opts ...SetOption,
+) error {
+	return ns.cache.SetBytes(ctx, ns.key(key), value, ns.ttl(ttl), opts...)
+}
+
+// SetWithExpireAt sets a key-value pair in the namespace that expires at the given time.
+func (ns *namespace) SetWithExpireAt(
+	ctx context.Context,
+	key, value string,
+	expireAt time.Time,
+) error {
+	return ns.cache.SetWithExpireAt(ctx, ns.key(key), value, expireAt)
+}
+
+// SetNX sets a key-value pair in the namespace only if the key does not exist or is expired.
+func (ns *namespace) SetNX(
+	ctx context.Context,
+	key, value string,
+	ttl time.Duration,
+) (bool, error) {
+	return ns.cache.SetNX(ctx, ns.key(key), value, ns.ttl(ttl))
+}
+
+// SetValue encodes a value with the codec of the cache and stores it in the namespace.
+func (ns *namespace) SetValue(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return ns.cache.SetValue(ctx, ns.key(key), value, ns.ttl(ttl))
+}
+
+// Append appends a suffix to the value of an existing key of the namespace.
+func (ns *namespace) Append(ctx context.Context, key, suffix string) error {
+	return ns.cache.Append(ctx, ns.key(key), suffix)
+}
+
+// Get retrieves a value from the namespace by key.
+func (ns *namespace) Get(ctx context.Context, key string) (string, error) {
+	return ns.cache.Get(ctx, ns.key(key))
+}
+
+// GetBytes retrieves a value from the namespace by key as stored.
+func (ns *namespace) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	return ns.cache.GetBytes(ctx, ns.key(key))
+}
+
+// GetValue retrieves a value from the namespace by key and decodes it into dest.
+func (ns *namespace) GetValue(ctx context.Context, key string, dest any) error {
+	return ns.cache.GetValue(ctx, ns.key(key), dest)
+}
+
+// GetWithVersion retrieves a value from the namespace by key along with its version.
+func (ns *namespace) GetWithVersion(ctx context.Context, key string) (string, int64, error) {
+	return ns.cache.GetWithVersion(ctx, ns.key(key))
+}
+
+// SetIfVersion sets a key-value pair in the namespace only if the entry still has
+// the given version.
+func (ns *namespace) SetIfVersion(
+	ctx context.Context,
+	key, value string,
+	version int64,
+	ttl time.Duration,
+) error {
+	return ns.cache.SetIfVersion(ctx, ns.key(key), value, version, ns.ttl(ttl))
+}
+
+// GetTTL returns the remaining time-to-live of a key of the namespace.
+func (ns *namespace) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	return ns.cache.GetTTL(ctx, ns.key(key))
+}
+
+// Persist removes the expiration of a key of the namespace.
+func (ns *namespace) Persist(ctx context.Context, key string) error {
+	return ns.cache.Persist(ctx, ns.key(key))
+}
+
+// DelPrefix deletes the entries of the namespace whose key starts with the given prefix.
+func (ns *namespace) DelPrefix(ctx context.Context, prefix string) (int64, error) {
+	return ns.cache.DelPrefix(ctx, ns.key(prefix))
+}
+
+// DelPattern deletes the entries of the namespace whose key matches the given glob pattern.
+func (ns *namespace) DelPattern(ctx context.Context, pattern string) (int64, error) {
+	return ns.cache.DelPattern(ctx, escapeGlob(ns.prefix)+pattern)
+}
+
+// Rename moves an entry of the namespace to another key of the namespace.
+func (ns *namespace) Rename(ctx context.Context, oldKey, newKey string) error {
+	return ns.cache.Rename(ctx, ns.key(oldKey), ns.key(newKey))
+}
+
+// SetAsync queues a key-value pair of the namespace to be set in the background.
+func (ns *namespace) SetAsync(ctx context.Context, key, value string, ttl time.Duration) error {
+	return ns.cache.SetAsync(ctx, ns.key(key), value, ns.ttl(ttl))
+}
+
+// Pin marks a key of the namespace as pinned.
+func (ns *namespace) Pin(ctx context.Context, key string) error {
+	return ns.cache.Pin(ctx, ns.key(key))
+}
+
+// Unpin removes the pin of a key of the namespace.
+func (ns *namespace) Unpin(ctx context.Context, key string) error {
+	return ns.cache.Unpin(ctx, ns.key(key))
+}
+
+// GetRange retrieves a chunk of the value of a key of the namespace.
+func (ns *namespace) GetRange(ctx context.Context, key string, offset, length int) (string, error) {
+	return ns.cache.GetRange(ctx, ns.key(key), offset, length)
+}
+
+// MGet retrieves multiple values from the namespace in a single query.
+func (ns *namespace) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	values, err := ns.cache.getMulti(ctx, ns.keys(keys))
+	if err != nil {
+		return nil, err
+	}
+
+	return ns.trimValues(values), nil
+}
+
+// Prefetch marks the given keys of the namespace as accessed.
+func (ns *namespace) Prefetch(ctx context.Context, keys []string) error {
+	return ns.cache.Prefetch(ctx, ns.keys(keys))
+}
+
+// Del deletes a key-value pair from the namespace.
+func (ns *namespace) Del(ctx context.Context, key string) error {
+	return ns.cache.Del(ctx, ns.key(key))
+}
+
+// GetOrSet retrieves a value from the namespace and loads it with the given loader on a miss.
+func (ns *namespace) GetOrSet(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	loader LoaderFunc,
+) (string, error) {
+	return ns.cache.GetOrSet(ctx, ns.key(key), ns.ttl(ttl), loader)
+}
+
+// GetOrSetMulti retrieves multiple keys from the namespace and loads the missing ones
+// with the given loader. The loader receives and returns keys without the namespace prefix.
+func (ns *namespace) GetOrSetMulti(
+	ctx context.Context,
+	keys []string,
+	ttl time.Duration,
+	loader MultiLoaderFunc,
+) (map[string]string, error) {
+	prefixedLoader := func(ctx context.Context, missing []string) (map[string]string, error) {
+		loaded, err := loader(ctx, ns.trimKeys(missing))
+		if err != nil {
+			return nil, err
+		}
+
+		prefixed := make(map[string]string, len(loaded))
+		for key, value := range loaded {
+			prefixed[ns.key(key)] = value
+		}
+
+		return prefixed, nil
+	}
+
+	values, err := ns.cache.GetOrSetMulti(ctx, ns.keys(keys), ns.ttl(ttl), prefixedLoader)
+	if err != nil {
+		return nil, err
+	}
+
+	return ns.trimValues(values), nil
+}
+
+// Keys returns the keys of the namespace that match the given glob pattern,
+// without the namespace prefix.
+func (ns *namespace) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	keys, err := ns.cache.Keys(ctx, escapeGlob(ns.prefix)+pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return ns.trimKeys(keys), nil
+}
+
+// Count returns the number of non-expired entries in the namespace.
+func (ns *namespace) Count(ctx context.Context) (int64, error) {
+	params := queries.CountLiveEntriesInRangeParams{
+		KeyFrom: ns.prefix,
+		KeyTo:   ns.prefixEnd(),
+		Now:     ns.timeSource.Now().In(ns.timeSource.Timezone),
+	}
+
+	count, err := ns.queries.CountLiveEntriesInRange(ctx, params)
+	if err != nil {
+		return 0, fmt.Errorf("counting entries: %w", err)
+	}
+
+	return count, nil
+}
+
+// Flush deletes every entry of the namespace.
+func (ns *namespace) Flush(ctx context.Context) error {
+	ns.writeMu.RLock()
+	defer ns.writeMu.RUnlock()
+
+	params := queries.DeleteCacheInRangeParams{
+		KeyFrom: ns.prefix,
+		KeyTo:   ns.prefixEnd(),
+	}
+
+	err := ns.queries.DeleteCacheInRange(ctx, params)
+	if err != nil {
+		return fmt.Errorf("flushing cache: %w", err)
+	}
+	ns.memory.delPrefix(ns.prefix)
+	ns.recordEvent(ctx, EventFlush, ns.prefix)
+
+	return nil
+}
+
+// Stats returns the usage of the namespace. Entries and ValueBytes are scoped to the
+// namespace; the other fields describe the database shared with the cache.
+func (ns *namespace) Stats(ctx context.Context) (CacheStats, error) {
+	params := queries.GetCacheUsageInRangeParams{
+		KeyFrom: ns.prefix,
+		KeyTo:   ns.prefixEnd(),
+		Now:     ns.timeSource.Now().In(ns.timeSource.Timezone),
+	}
+
+	usage, err := ns.queries.GetCacheUsageInRange(ctx, params)
+	if err != nil {
+		return CacheStats{}, fmt.Errorf("getting cache usage: %w", err)
+	}
+
+	return ns.statsWithUsage(ctx, usage.Entries, usage.ValueBytes)
+}
+
+// key returns the stored key of a key of the namespace.
+func (ns *namespace) key(key string) string {
+	return ns.prefix + key
+}
+
+// keys returns the stored keys of the given keys of the namespace.
+func (ns *namespace) keys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = ns.key(key)
+	}
+
+	return prefixed
+}
+
+// trimKeys removes the namespace prefix from the given stored keys.
+func (ns *namespace) trimKeys(keys []string) []string {
+	trimmed := make([]string, len(keys))
+	for i, key := range keys {
+		trimmed[i] = strings.TrimPrefix(key, ns.prefix)
+	}
+
+	return trimmed
+}
+
+// trimValues removes the namespace prefix from the keys of the given values.
+func (ns *namespace) trimValues(values map[string]string) map[string]string {
+	trimmed := make(map[string]string, len(values))
+	for key, value := range values {
+		trimmed[strings.TrimPrefix(key, ns.prefix)] = value
+	}
+
+	return trimmed
+}
+
+// prefixEnd returns the smallest key greater than every key of the namespace.
+func (ns *namespace) prefixEnd() string {
+	return prefixEnd(ns.prefix)
+}
+
+// escapeGlob escapes the GLOB special characters of s so it matches literally.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[':
+			b.WriteByte('[')
+			b.WriteRune(r)
+			b.WriteByte(']')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
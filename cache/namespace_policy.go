@@ -0,0 +1,290 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// NamespaceOption is a function that configures the policy of a namespace.
+type NamespaceOption func(*namespacePolicy)
+
+// namespacePolicy overrides the configuration of the cache for the keys of a namespace.
+type namespacePolicy struct {
+	// purgePercent is the percentage of the entries of the namespace deleted by a purge,
+	// used instead of the purge percent of the cache if hasPurgePercent is set
+	purgePercent    float64
+	hasPurgePercent bool
+
+	// defaultTTL is the TTL of the writes of the namespace with a zero TTL, 0 means none
+	defaultTTL time.Duration
+
+	// maxBytes is the budget of bytes stored by the values of the namespace, 0 means unlimited
+	maxBytes int
+}
+
+// WithNamespacePurgePercent sets the percentage of the entries of the namespace deleted
+// when the database is full, instead of the purge percent of the cache.
+// A low-priority namespace can be purged aggressively with a high percentage, while
+// a percentage of 0 protects a critical namespace from the purges.
+// The percentage must be between 0 and 1.
+func WithNamespacePurgePercent(percent float64) NamespaceOption {
+	return func(p *namespacePolicy) {
+		p.purgePercent = percent
+		p.hasPurgePercent = true
+	}
+}
+
+// WithNamespaceDefaultTTL sets the TTL of the writes of the namespace with a zero TTL.
+// Writes with NoTTL still store entries that never expire.
+func WithNamespaceDefaultTTL(ttl time.Duration) NamespaceOption {
+	return func(p *namespacePolicy) {
+		p.defaultTTL = ttl
+	}
+}
+
+// WithNamespaceMaxBytes sets the budget of bytes stored by the values of the namespace.
+// When the namespace exceeds the budget, its least recently used entries are evicted
+// on the sync interval, like WithMaxCacheBytes does for the whole cache.
+func WithNamespaceMaxBytes(size int) NamespaceOption {
+	return func(p *namespacePolicy) {
+		p.maxBytes = size
+	}
+}
+
+// setNamespacePolicy registers the policy of the namespace with the given prefix,
+// replacing the previous one, and schedules the purge that keeps the namespaces under
+// their budget the first time one is set.
+func (ch *cache) setNamespacePolicy(prefix string, opts []NamespaceOption) {
+	policy := namespacePolicy{}
+	for _, opt := range opts {
+		opt(&policy)
+	}
+
+	ch.namespacesMu.Lock()
+	if ch.namespacePolicies == nil {
+		ch.namespacePolicies = make(map[string]namespacePolicy)
+	}
+	ch.namespacePolicies[prefix] = policy
+	ch.namespacesMu.Unlock()
+
+	if policy.maxBytes > 0 {
+		ch.scheduleBudgetPurge(ch.background)
+	}
+}
+
+// namespacePolicy returns the policy of the innermost namespace with a policy that
+// contains the given prefix, so that nested namespaces inherit the policy of their parent.
+func (ch *cache) namespacePolicy(prefix string) namespacePolicy {
+	ch.namespacesMu.RLock()
+	defer ch.namespacesMu.RUnlock()
+
+	var policy namespacePolicy
+	longest := -1
+	for p, candidate := range ch.namespacePolicies {
+		if strings.HasPrefix(prefix, p) && len(p) > longest {
+			policy, longest = candidate, len(p)
+		}
+	}
+
+	return policy
+}
+
+// ttl returns the TTL of a write of the namespace, the default TTL of the namespace
+// if the given TTL is zero. Writes with NoTTL never expire, even with a default TTL.
+func (ns *namespace) ttl(ttl time.Duration) time.Duration {
+	if ttl != 0 {
+		return ttl
+	}
+
+	if policy := ns.namespacePolicy(ns.prefix); policy.defaultTTL > 0 {
+		return policy.defaultTTL
+	}
+
+	return ttl
+}
+
+// keyRange is a range of keys, from the inclusive key from to the exclusive key to;
+// an empty to means the range is unbounded.
+type keyRange struct {
+	from string
+	to   string
+}
+
+// purgeRange is a range of keys purged with its own percentage.
+type purgeRange struct {
+	keyRange
+	percent float64
+}
+
+// purgeRanges splits the keyspace into ranges purged with the purge percent of the
+// innermost namespace that contains them, or the purge percent of the cache.
+// It returns nil if no namespace overrides the purge percent.
+func (ch *cache) purgeRanges() []purgeRange {
+	ch.namespacesMu.RLock()
+	defer ch.namespacesMu.RUnlock()
+
+	overrides := make(map[string]float64)
+	bounds := []string{""}
+	for prefix, policy := range ch.namespacePolicies {
+		if !policy.hasPurgePercent {
+			continue
+		}
+		overrides[prefix] = policy.purgePercent
+		bounds = append(bounds, prefix, prefixEnd(prefix))
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+
+	var ranges []purgeRange
+	for i, from := range bounds {
+		to := ""
+		if i+1 < len(bounds) {
+			to = bounds[i+1]
+		}
+
+		percent, longest := ch.purgePercent, -1
+		for prefix, override := range overrides {
+			inside := from >= prefix && to != "" && to <= prefixEnd(prefix)
+			if inside && len(prefix) > longest {
+				percent, longest = override, len(prefix)
+			}
+		}
+
+		// Merge the adjacent ranges purged with the same percentage.
+		if n := len(ranges); n > 0 && ranges[n-1].percent == percent {
+			ranges[n-1].to = to
+			continue
+		}
+		ranges = append(ranges, purgeRange{
+			keyRange: keyRange{from: from, to: to},
+			percent:  percent,
+		})
+	}
+
+	return ranges
+}
+
+// purgeEntriesByRange deletes the percentage of each range of entries, the least
+// recently used first, and returns the keys deleted.
+func (ch *cache) purgeEntriesByRange(
+	ctx context.Context,
+	tx *sql.Tx,
+	ranges []purgeRange,
+) ([]string, error) {
+	queriesWithTx := queries.New(tx)
+
+	var deleted []string
+	for _, r := range ranges {
+		if r.percent < 0 || r.percent > 1 {
+			return nil, fmt.Errorf("invalid percentage: %f", r.percent)
+		}
+
+		usage, err := queriesWithTx.GetTotalUsageInRange(
+			ctx,
+			queries.GetTotalUsageInRangeParams{KeyFrom: r.from, KeyTo: r.to},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("count entries: %w", err)
+		}
+
+		count := int64(float64(usage.Entries) * r.percent)
+		if count == 0 {
+			continue
+		}
+
+		keys, err := queriesWithTx.DeleteKeysByLimitInRange(
+			ctx,
+			queries.DeleteKeysByLimitInRangeParams{KeyFrom: r.from, KeyTo: r.to, Limit: count},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("delete entries: %w", err)
+		}
+		deleted = append(deleted, keys...)
+	}
+
+	return deleted, nil
+}
+
+// namespaceBudgets returns the key ranges of the namespaces with a budget of bytes.
+func (ch *cache) namespaceBudgets() map[keyRange]int64 {
+	ch.namespacesMu.RLock()
+	defer ch.namespacesMu.RUnlock()
+
+	budgets := make(map[keyRange]int64)
+	for prefix, policy := range ch.namespacePolicies {
+		if policy.maxBytes > 0 {
+			budgets[keyRange{from: prefix, to: prefixEnd(prefix)}] = int64(policy.maxBytes)
+		}
+	}
+
+	return budgets
+}
+
+// evictRangeToBudget evicts the least recently used entries of a range until the bytes
+// stored by their values drop below the budget, and returns the keys deleted.
+func (ch *cache) evictRangeToBudget(
+	ctx context.Context,
+	tx *sql.Tx,
+	r keyRange,
+	budget int64,
+) ([]string, error) {
+	queriesWithTx := queries.New(tx)
+	params := queries.GetTotalUsageInRangeParams{KeyFrom: r.from, KeyTo: r.to}
+
+	usage, err := queriesWithTx.GetTotalUsageInRange(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("getting namespace usage: %w", err)
+	}
+
+	var evicted []string
+	for usage.ValueBytes > budget && usage.Entries > 0 {
+		// Estimate the entries to delete from the average value size.
+		average := usage.ValueBytes / usage.Entries
+		count := (usage.ValueBytes-budget)/max(average, 1) + 1
+
+		deleted, err := queriesWithTx.DeleteKeysByLimitInRange(
+			ctx,
+			queries.DeleteKeysByLimitInRangeParams{
+				KeyFrom: r.from,
+				KeyTo:   r.to,
+				Limit:   min(count, usage.Entries),
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("delete entries: %w", err)
+		}
+
+		// Only pinned entries are left, stop instead of looping forever.
+		if len(deleted) == 0 {
+			break
+		}
+		evicted = append(evicted, deleted...)
+
+		usage, err = queriesWithTx.GetTotalUsageInRange(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("getting namespace usage: %w", err)
+		}
+	}
+
+	return evicted, nil
+}
+
+// prefixEnd returns the smallest key greater than every key that starts with prefix.
+// The prefixes of the namespaces end with the separator, so incrementing the last byte
+// is enough.
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	end[len(end)-1]++
+
+	return string(end)
+}
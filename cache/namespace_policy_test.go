@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_NamespacePolicy(t *testing.T) {
+	t.Run("should use the policy of the innermost namespace", func(t *testing.T) {
+		ch := &cache{}
+		ch.Namespace("users", WithNamespaceDefaultTTL(time.Hour))
+		ch.Namespace("users").Namespace("sessions", WithNamespaceDefaultTTL(time.Minute))
+
+		assert.Equal(t, time.Hour, ch.namespacePolicy("users:").defaultTTL)
+		assert.Equal(t, time.Minute, ch.namespacePolicy("users:sessions:").defaultTTL)
+		assert.Equal(t, time.Hour, ch.namespacePolicy("users:profiles:").defaultTTL,
+			"Expected nested namespaces to inherit the policy")
+		assert.Zero(t, ch.namespacePolicy("orders:").defaultTTL)
+	})
+
+	t.Run("should keep the policy when the namespace is created without options", func(t *testing.T) {
+		ch := &cache{}
+		ch.Namespace("users", WithNamespaceDefaultTTL(time.Hour))
+		users := ch.Namespace("users").(*namespace)
+
+		assert.Equal(t, time.Hour, users.ttl(0), "Expected the default TTL for a zero TTL")
+		assert.Equal(t, time.Minute, users.ttl(time.Minute), "Expected the given TTL")
+	})
+
+	t.Run("should use the default TTL in the batches of the namespace", func(t *testing.T) {
+		ch := &cache{}
+
+		batch := ch.Namespace("users", WithNamespaceDefaultTTL(time.Hour)).Batch().Set("1", "John", 0)
+
+		assert.Equal(t, time.Hour, batch.ops[0].ttl)
+	})
+}
+
+func TestCache_PurgeRanges(t *testing.T) {
+	t.Run("should return nil without purge percent overrides", func(t *testing.T) {
+		ch := &cache{purgePercent: 0.2}
+		ch.Namespace("users", WithNamespaceDefaultTTL(time.Hour))
+
+		assert.Nil(t, ch.purgeRanges())
+	})
+
+	t.Run("should split the keyspace by namespace", func(t *testing.T) {
+		ch := &cache{purgePercent: 0.2}
+		ch.Namespace("thumbs", WithNamespacePurgePercent(0.8))
+		ch.Namespace("users", WithNamespacePurgePercent(0))
+		ch.Namespace("users").Namespace("sessions", WithNamespacePurgePercent(0.5))
+
+		assert.Equal(t, []purgeRange{
+			{keyRange: keyRange{from: "", to: "thumbs:"}, percent: 0.2},
+			{keyRange: keyRange{from: "thumbs:", to: "thumbs;"}, percent: 0.8},
+			{keyRange: keyRange{from: "thumbs;", to: "users:"}, percent: 0.2},
+			{keyRange: keyRange{from: "users:", to: "users:sessions:"}, percent: 0},
+			{keyRange: keyRange{from: "users:sessions:", to: "users:sessions;"}, percent: 0.5},
+			{keyRange: keyRange{from: "users:sessions;", to: "users;"}, percent: 0},
+			{keyRange: keyRange{from: "users;", to: ""}, percent: 0.2},
+		}, ch.purgeRanges())
+	})
+}
+
+func TestCache_PurgeEntriesByRange(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ctx := context.Background()
+	usageRows := func(entries, bytes int64) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(entries, bytes)
+	}
+
+	t.Run("should delete the percentage of each range", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries`).
+			WithArgs("", "thumbs:", "thumbs:").
+			WillReturnRows(usageRows(10, 100))
+		sqlMock.ExpectQuery(`DELETE FROM cache\s+WHERE key IN`).
+			WithArgs("", "thumbs:", "thumbs:", int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("a").AddRow("b"))
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries`).
+			WithArgs("thumbs:", "thumbs;", "thumbs;").
+			WillReturnRows(usageRows(1, 10))
+		sqlMock.ExpectCommit()
+
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+
+		ch := &cache{}
+		deleted, err := ch.purgeEntriesByRange(ctx, tx, []purgeRange{
+			{keyRange: keyRange{from: "", to: "thumbs:"}, percent: 0.2},
+			{keyRange: keyRange{from: "thumbs:", to: "thumbs;"}, percent: 0.5},
+		})
+		assert.NoError(t, tx.Commit())
+
+		assert.NoError(t, err, "Expected no error while purging the ranges")
+		assert.Equal(t, []string{"a", "b"}, deleted)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return an error for an invalid percentage", func(t *testing.T) {
+		ch := &cache{}
+
+		_, err := ch.purgeEntriesByRange(ctx, nil, []purgeRange{{percent: 2}})
+
+		assert.ErrorContains(t, err, "invalid percentage")
+	})
+
+	t.Run("should evict the namespace until it is under its budget", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries`).
+			WithArgs("users:", "users;", "users;").
+			WillReturnRows(usageRows(10, 1000))
+		sqlMock.ExpectQuery(`DELETE FROM cache\s+WHERE key IN`).
+			WithArgs("users:", "users;", "users;", int64(6)).
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("users:1"))
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries`).
+			WithArgs("users:", "users;", "users;").
+			WillReturnRows(usageRows(9, 400))
+		sqlMock.ExpectCommit()
+
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+
+		ch := &cache{}
+		evicted, err := ch.evictRangeToBudget(ctx, tx, keyRange{from: "users:", to: "users;"}, 500)
+		assert.NoError(t, tx.Commit())
+
+		assert.NoError(t, err, "Expected no error while evicting the namespace")
+		assert.Equal(t, []string{"users:1"}, evicted)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return an error if getting the usage fails", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries`).
+			WillReturnError(fmt.Errorf("mock usage error"))
+		sqlMock.ExpectRollback()
+
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+
+		ch := &cache{queries: queries.New(db)}
+		_, err = ch.evictRangeToBudget(ctx, tx, keyRange{from: "users:", to: "users;"}, 500)
+		assert.NoError(t, tx.Rollback())
+
+		assert.ErrorContains(t, err, "getting namespace usage: mock usage error")
+	})
+}
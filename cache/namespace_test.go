@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
+)
+
+func TestCache_Namespace(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	ch := &cache{
+		queries: queries.New(db),
+		logger:  logMocks.NewLoggerMock(t),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+	users := ch.Namespace("users")
+
+	t.Run("should store keys with the namespace prefix", func(t *testing.T) {
+		sqlMock.ExpectExec(`INSERT INTO cache \(key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata\)`).
+			WithArgs("users:1", []byte("John"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := users.Set(ctx, "1", "John", time.Hour)
+
+		assert.NoError(t, err, "Expected no error when setting a namespaced key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should pin a namespaced key", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET pinned = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs(1, "users:1", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := users.Pin(ctx, "1")
+
+		assert.NoError(t, err, "Expected no error when pinning a namespaced key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should rename a key within the namespace", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE OR REPLACE cache SET key = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs("users:2", "users:1", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := users.Rename(ctx, "1", "2")
+
+		assert.NoError(t, err, "Expected no error when renaming a namespaced key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should delete keys by prefix within the namespace", func(t *testing.T) {
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key GLOB \? RETURNING key`).
+			WithArgs("users:42:*").
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("users:42:name"))
+
+		count, err := users.DelPrefix(ctx, "42:")
+
+		assert.NoError(t, err, "Expected no error when deleting namespaced keys")
+		assert.Equal(t, int64(1), count)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should prefix nested namespaces", func(t *testing.T) {
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE key = ?`).
+			WithArgs("users:sessions:1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := users.Namespace("sessions").Del(ctx, "1")
+
+		assert.NoError(t, err, "Expected no error when deleting a nested key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return values keyed without the prefix", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?,\?\) AND expires_at > \?`).
+			WithArgs("users:1", "users:2", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}).
+				AddRow("users:1", []byte("John"), fixedTime.Add(time.Hour), nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs(fixedTime, "users:1", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		values, err := users.MGet(ctx, "1", "2")
+
+		assert.NoError(t, err, "Expected no error when getting namespaced values")
+		assert.Equal(t, map[string]string{"1": "John"}, values)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should list keys of the namespace without the prefix", func(t *testing.T) {
+		weird := ch.Namespace("a*b")
+
+		sqlMock.ExpectQuery(`SELECT key FROM cache WHERE key GLOB \? AND expires_at > \? ORDER BY key`).
+			WithArgs("a[*]b:user*", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("a*b:user1"))
+
+		keys, err := weird.Keys(ctx, "user*")
+
+		assert.NoError(t, err, "Expected no error when listing namespaced keys")
+		assert.Equal(t, []string{"user1"}, keys)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should count only the entries of the namespace", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache WHERE key >= \? AND key < \? AND expires_at > \?`).
+			WithArgs("users:", "users;", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		count, err := users.Count(ctx)
+
+		assert.NoError(t, err, "Expected no error when counting namespaced entries")
+		assert.Equal(t, int64(3), count)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should flush only the entries of the namespace", func(t *testing.T) {
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE key >= \? AND key < \?`).
+			WithArgs("users:", "users;").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		err := users.Flush(ctx)
+
+		assert.NoError(t, err, "Expected no error when flushing the namespace")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the flush fails", func(t *testing.T) {
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE key >= \? AND key < \?`).
+			WithArgs("users:", "users;").
+			WillReturnError(fmt.Errorf("mock delete error"))
+
+		err := users.Flush(ctx)
+
+		assert.Error(t, err, "Expected an error when the flush fails")
+		assert.Equal(t, "flushing cache: mock delete error", err.Error())
+	})
+
+	t.Run("should pass keys without the prefix to the loader", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT key, value, expires_at, checksum FROM cache WHERE key IN \(\?\) AND expires_at > \?`).
+			WithArgs("users:1", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value", "expires_at", "checksum"}))
+
+		var loaderKeys []string
+		_, err := users.GetOrSetMulti(ctx, []string{"1"}, time.Hour,
+			func(_ context.Context, missing []string) (map[string]string, error) {
+				loaderKeys = missing
+				return nil, nil
+			},
+		)
+
+		assert.NoError(t, err, "Expected no error when nothing was loaded")
+		assert.Equal(t, []string{"1"}, loaderKeys, "Loader should receive keys without the prefix")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestEscapeGlob(t *testing.T) {
+	assert.Equal(t, "users", escapeGlob("users"))
+	assert.Equal(t, "a[*]b[?]c[[]d]", escapeGlob("a*b?c[d]"))
+}
@@ -1,8 +1,14 @@
 package cache
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
 	"time"
 
+	"github.com/lucasvillarinho/litepack"
+	"github.com/lucasvillarinho/litepack/database"
 	"github.com/lucasvillarinho/litepack/internal/cron"
 )
 
@@ -17,14 +23,39 @@ func WithSyncInterval(interval cron.Interval) Option {
 	}
 }
 
+// WithScheduler sets the scheduler of the background jobs of the cache, such as the
+// purges, instead of a new one, for example to share it with other subsystems or to
+// run the jobs on demand in tests. The cache starts the scheduler, and removes its jobs
+// from it on Close without stopping it, so that the jobs of the other subsystems keep
+// running.
+func WithScheduler(scheduler cron.Cron) Option {
+	return func(c *cache) {
+		c.cron = scheduler
+		c.sharedCron = true
+	}
+}
+
 // WithPath sets the path to the cache database.
 // The cache is automatically created if it does not exist.
+// A path of ":memory:" creates an in-memory cache, see WithInMemory.
+// A URI filename, such as "file:/data/cache.db?cache=private" or
+// "file:workers?mode=memory&cache=shared", is passed to the driver as it is, in place of
+// the path and the name of the database file.
 func WithPath(path string) Option {
 	return func(c *cache) {
 		c.path = path
 	}
 }
 
+// WithInMemory creates the cache in memory instead of on disk.
+// Nothing is written to disk and the entries are lost when the cache is closed,
+// which suits tests and ephemeral workloads.
+func WithInMemory() Option {
+	return func(c *cache) {
+		c.path = database.InMemory
+	}
+}
+
 // WithTimezone sets a custom timezone for the cache.
 func WithTimezone(timezone *time.Location) Option {
 	return func(c *cache) {
@@ -32,6 +63,15 @@ func WithTimezone(timezone *time.Location) Option {
 	}
 }
 
+// WithClock sets the function that returns the current time, time.Now by default.
+// The expiration, the sliding TTL and the eviction of the entries follow it, so that
+// applications and tests can control them with a frozen or simulated clock.
+func WithClock(now func() time.Time) Option {
+	return func(c *cache) {
+		c.timeSource.Now = now
+	}
+}
+
 // WithPurgePercent sets the percentage of cache entries to delete when purging.
 func WithPurgePercent(percent float64) Option {
 	return func(c *cache) {
@@ -39,9 +79,531 @@ func WithPurgePercent(percent float64) Option {
 	}
 }
 
-// WithPurgeTimeout sets the timeout for purging cache entries.
+// WithPurgeTimeout sets the timeout for purging cache entries, 30 seconds by default.
+// A purge that exceeds it is aborted and its transaction rolled back; a zero timeout
+// disables it.
 func WithPurgeTimeout(timeout time.Duration) Option {
 	return func(c *cache) {
 		c.purgeTimeout = timeout
 	}
 }
+
+// WithPurgeSchedule schedules a purge that runs on the given interval and deletes the
+// purge percent of the entries with the eviction policy when the pages in use exceed
+// the high watermark, a fraction of the max database size between 0 and 1.
+// It trims the cache before writes fail because the database is full.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithPurgeSchedule("*/5 * * * *", 0.8))
+func WithPurgeSchedule(interval cron.Interval, highWatermark float64) Option {
+	return func(c *cache) {
+		c.purgeSchedule = interval
+		c.purgeWatermark = highWatermark
+	}
+}
+
+// WithOptimizeSchedule sets the schedule of the update of the query planner statistics
+// with PRAGMA optimize, every hour by default, so that the plans of the purge and expiry
+// queries follow the growth of the cache. An empty schedule disables it.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithOptimizeSchedule(cron.Every30Minutes))
+func WithOptimizeSchedule(interval cron.Interval) Option {
+	return func(c *cache) {
+		c.optimizeSchedule = interval
+	}
+}
+
+// WithAutoVacuumIncremental enables the incremental auto vacuum of SQLite: the pages of
+// deleted entries are returned to the file system pagesPerStep at a time, every sync
+// interval and after purges, instead of a full VACUUM that blocks writers on large files.
+// An existing database is vacuumed once when it is opened to switch to this mode.
+func WithAutoVacuumIncremental(pagesPerStep int) Option {
+	return func(c *cache) {
+		c.incrementalVacuumPages = pagesPerStep
+	}
+}
+
+// WithOpTimeout sets the max duration of Get, Set and Del, so that a database locked by
+// another process fails the call when the timeout elapses instead of blocking it.
+// The timeout applies on top of the deadline of the context given to the call.
+// A timeout of 0 disables it.
+func WithOpTimeout(timeout time.Duration) Option {
+	return func(c *cache) {
+		c.opTimeout = timeout
+	}
+}
+
+// WithMaxDBSize sets the max size of the database file in bytes, 512 MB by default.
+// Writes that would grow the database past it purge the cache, see WithPurgePercent.
+func WithMaxDBSize(size int) Option {
+	return func(c *cache) {
+		c.maxDBSize = size
+	}
+}
+
+// WithPageSize sets the page size of the database in bytes, 4 KB by default.
+// It must be a power of two between 512 and 65536, and only applies to new databases.
+func WithPageSize(size int) Option {
+	return func(c *cache) {
+		c.pageSize = size
+	}
+}
+
+// WithSQLiteCacheSize sets the memory used by SQLite to cache the pages of the
+// database in bytes, 64 MB by default.
+func WithSQLiteCacheSize(size int) Option {
+	return func(c *cache) {
+		c.cacheSize = size
+	}
+}
+
+// WithBusyTimeout sets how long a connection waits for the lock of the database held
+// by another connection or process, 5 seconds by default, instead of failing with
+// "database is locked". Set, Del and the purges also retry a few times with backoff
+// when the lock is not released in time.
+func WithBusyTimeout(timeout time.Duration) Option {
+	return func(c *cache) {
+		c.busyTimeout = timeout
+	}
+}
+
+// WithSynchronous sets how often SQLite waits for the writes of the cache to reach the
+// disk, by default the mode of the driver: NORMAL for mattn and FULL for modernc.
+// SynchronousNormal suits most caches: the cache runs in WAL mode, where a power loss can
+// lose the last writes but doesn't corrupt the database, and the writes don't wait for
+// the disk on each commit.
+func WithSynchronous(mode database.SynchronousMode) Option {
+	return func(c *cache) {
+		c.synchronous = mode
+	}
+}
+
+// WithMmapSize sets the max number of bytes of the cache database mapped in memory by
+// each connection, 0 by default, which disables memory-mapped I/O. The reads of the
+// mapped pages skip a copy from the file system, which lowers the latency of Get on large
+// databases; a size above the max database size maps the whole database.
+func WithMmapSize(size int) Option {
+	return func(c *cache) {
+		c.mmapSize = size
+	}
+}
+
+// WithDSNParam adds a query parameter to the DSN of the cache database, read by the
+// driver when it opens each connection, so that the setting applies to every connection
+// of the pools, see database.WithDSNParam.
+func WithDSNParam(key, value string) Option {
+	return func(c *cache) {
+		if c.dsnParams == nil {
+			c.dsnParams = url.Values{}
+		}
+		c.dsnParams.Add(key, value)
+	}
+}
+
+// WithQueryHook calls the hook after each query run on the cache database, including
+// the queries of the logger and of the event log, with the query, its arguments, how
+// long it took and its error. Unlike WithInstrumentation, which reports the operations
+// of the cache, it reports the SQL they run, see database.WithQueryHook.
+func WithQueryHook(hook database.QueryHook) Option {
+	return func(c *cache) {
+		if hook != nil {
+			c.queryHooks = append(c.queryHooks, hook)
+		}
+	}
+}
+
+// WithReadPool opens the cache with a single write connection and a pool of the given
+// number of read-only connections, used by Get, GetBytes, GetValue, MGet, GetTTL,
+// GetRange and GetWithVersion. In WAL mode the reads don't wait for the writes, so a
+// long purge doesn't stall them. The access times of the reads are buffered, see
+// WithBufferedAccessUpdates, so that they don't wait for the writes either.
+// In-memory caches keep a single pool.
+func WithReadPool(size int) Option {
+	return func(c *cache) {
+		c.readPoolSize = size
+		if c.access == nil {
+			c.access = newAccessBuffer()
+		}
+	}
+}
+
+// WithEvictionPolicy sets the policy that selects the entries deleted when the database
+// is full. The default policy evicts the least recently used entries, see LRUPolicy.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(c *cache) {
+		c.evictionPolicy = policy
+	}
+}
+
+// WithEvictionLFU evicts the least frequently used entries when the database is full,
+// instead of the least recently used ones. It suits read-heavy workloads with periodic
+// scans, see LFUPolicy.
+func WithEvictionLFU() Option {
+	return func(c *cache) {
+		c.evictionPolicy = LFUPolicy{}
+	}
+}
+
+// WithCodec sets the codec used by SetValue and GetValue to encode and decode values.
+func WithCodec(codec Codec) Option {
+	return func(c *cache) {
+		c.codec = codec
+	}
+}
+
+// WithMaxValueSize sets the max size of a value in bytes.
+// Writes of larger values fail with ErrValueTooLarge instead of filling the database
+// and purging unrelated entries; Append rejects suffixes larger than the limit.
+// A size of 0 disables the limit.
+func WithMaxValueSize(size int) Option {
+	return func(c *cache) {
+		c.maxValueSize = size
+	}
+}
+
+// WithMaxCacheBytes sets the budget of bytes stored by the values of the cache.
+// A background task runs every sync interval and evicts entries with the eviction
+// policy until the size of the stored values drops below the budget, before the
+// database reaches its max size and writes fail.
+// A budget of 0 disables the task.
+func WithMaxCacheBytes(size int) Option {
+	return func(c *cache) {
+		c.maxCacheBytes = size
+	}
+}
+
+// WithHooks sets the callbacks invoked when entries are written, deleted, evicted
+// or expired, see Hooks.
+func WithHooks(hooks Hooks) Option {
+	return func(c *cache) {
+		c.hooks = hooks
+	}
+}
+
+// WithAsyncQueueSize sets the number of keys that SetAsync can queue before it
+// returns ErrWriteQueueFull.
+func WithAsyncQueueSize(size int) Option {
+	return func(c *cache) {
+		c.asyncQueueSize = size
+	}
+}
+
+// WithBufferedAccessUpdates buffers the access times of reads in memory instead of
+// updating them on every read, and stores them in a single transaction every sync
+// interval, before purging and on Close.
+// Reads no longer write to the database; the buffered access times are lost if the
+// process crashes.
+func WithBufferedAccessUpdates() Option {
+	return func(c *cache) {
+		c.access = newAccessBuffer()
+	}
+}
+
+// WithWarmup sets a function that pre-populates the cache before NewCache returns,
+// so that the cache is warm before serving traffic. NewCache fails if the function does.
+//
+// Example:
+//
+//	warmup := func(ctx context.Context, set cache.Setter) error {
+//		return set.Set(ctx, "config", loadConfig(), time.Hour)
+//	}
+//	cache, err := cache.NewCache(ctx, cache.WithWarmup(warmup))
+func WithWarmup(fn WarmupFunc) Option {
+	return func(c *cache) {
+		c.warmup = warmup{fn: fn}
+	}
+}
+
+// WithAsyncWarmup sets a function that pre-populates the cache in the background after
+// NewCache returns, so that a slow warm-up does not delay the startup.
+// Reads miss the keys that are not loaded yet, and the error of the function is logged.
+func WithAsyncWarmup(fn WarmupFunc) Option {
+	return func(c *cache) {
+		c.warmup = warmup{fn: fn, async: true}
+	}
+}
+
+// WithMemoryTier enables a bounded in-memory LRU tier in front of the database.
+// Reads of hot keys are served from memory without querying the database or updating
+// their last accessed at timestamp. Writes go through to both tiers, and deletes,
+// flushes and purges invalidate the memory tier.
+// The tier is local to the process: writes from other processes sharing the database
+// file are not seen until the entry is evicted or expires.
+// A bound of 0 is unlimited.
+func WithMemoryTier(maxEntries, maxBytes int) Option {
+	return func(c *cache) {
+		c.memory = newMemoryTier(maxEntries, maxBytes)
+	}
+}
+
+// WithLogger sets the logger of the errors of the background jobs, such as the purges
+// and the async writes, instead of the log table of the database.
+// NewSlogLogger adapts a *slog.Logger.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithLogger(cache.NewSlogLogger(slog.Default())))
+func WithLogger(logger Logger) Option {
+	return func(c *cache) {
+		c.logger = logger
+	}
+}
+
+// WithDeleteExpiredOnRead deletes the expired entry of a key when a read finds it,
+// instead of leaving it for the next purge of expired entries. The read still returns
+// ErrKeyExpired. Pinned entries are not deleted.
+func WithDeleteExpiredOnRead() Option {
+	return func(c *cache) {
+		c.deleteExpiredOnRead = true
+	}
+}
+
+// WithEventLog records the sets, deletes, expirations, evictions and flushes of the
+// entries in the cache_events table, so that a remote copy of the cache can be
+// synchronized incrementally with Events. The events older than retention are deleted
+// on the sync interval. A retention of 0 or less disables the log.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithEventLog(24*time.Hour))
+func WithEventLog(retention time.Duration) Option {
+	return func(c *cache) {
+		c.eventRetention = retention
+	}
+}
+
+// WithSlidingTTL makes reads push the expiration of an entry forward by the TTL it was
+// written with, so that entries expire after a period of inactivity, like sessions.
+// Get, GetBytes, GetValue and GetOrSet slide the expiration; MGet, GetRange and the
+// other reads do not. Entries without a TTL, such as persisted or imported entries,
+// keep their expiration.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithSlidingTTL())
+//	err = cache.Set(ctx, "session:1", token, 30*time.Minute) // expires 30m after the last read
+func WithSlidingTTL() Option {
+	return func(c *cache) {
+		c.slidingTTL = true
+	}
+}
+
+// WithInstrumentation reports the operations of the cache to the observer, with their
+// duration, error and, for the reads, whether they found a live entry. It is the
+// extension point to build custom metrics and traces, see Observer.
+func WithInstrumentation(observer Observer) Option {
+	return func(c *cache) {
+		c.observer = observer
+	}
+}
+
+// WithChecksums stores a CRC32 checksum with each value and verifies it when the value is
+// read, so that values corrupted on disk are reported with ErrCorruptValue instead of
+// being returned. Values stored before checksums were enabled are not verified;
+// GetRange and Export don't verify the values either. Append verifies the value and
+// stores the checksum of the new one in a transaction.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithChecksums())
+//	value, err := cache.Get(ctx, "key")
+//	if errors.Is(err, cache.ErrCorruptValue) {
+//		err = cache.Del(ctx, "key")
+//	}
+func WithChecksums() Option {
+	return func(c *cache) {
+		c.checksums = true
+	}
+}
+
+// WithMaxKeyLength sets the max length of a key in bytes, including the prefix of its
+// namespace. Writes, reads and deletes of longer keys fail with ErrInvalidKey.
+// A length of 0 disables the limit.
+func WithMaxKeyLength(length int) Option {
+	return func(c *cache) {
+		c.maxKeyLength = length
+	}
+}
+
+// WithKeyPattern restricts the keys to the ones matching pattern, including the prefix
+// of their namespace. Writes, reads and deletes of other keys fail with ErrInvalidKey.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithKeyPattern(regexp.MustCompile(`^[a-z0-9:_-]+$`)))
+func WithKeyPattern(pattern *regexp.Regexp) Option {
+	return func(c *cache) {
+		c.keyPattern = pattern
+	}
+}
+
+// WithKeyPrefix prepends prefix to the keys of every operation of the cache, so that
+// services sharing a database don't see each other's keys. The cache behaves like a
+// namespace: Keys, Count, Flush and Stats only see the entries with the prefix, and the
+// keys are returned without it. Unlike Namespace, no separator is added to the prefix.
+// Key validation applies to the prefixed keys.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithPath(shared), cache.WithKeyPrefix("svc-a:"))
+//	err = cache.Set(ctx, "1", "John", time.Minute) // stored as svc-a:1
+func WithKeyPrefix(prefix string) Option {
+	return func(c *cache) {
+		c.keyPrefix = prefix
+	}
+}
+
+// WithTombstones makes Del, DelPrefix, DelPattern and the deletes of a Batch keep a
+// tombstone of the deleted keys for retention, so that synchronization jobs can read
+// the deletions with Tombstones. A tombstone is the row of the entry, without its value,
+// that reads treat as missing; writing the key again replaces it. The tombstones older
+// than retention are deleted on the sync interval. A retention of 0 or less disables
+// the tombstones.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithTombstones(24*time.Hour))
+func WithTombstones(retention time.Duration) Option {
+	return func(c *cache) {
+		c.tombstoneRetention = retention
+	}
+}
+
+// WithDependencies tracks the keys derived from other keys, declared with SetDerived, so
+// that writing or deleting a key deletes the entries derived from it, and the entries
+// derived from those, in cascade. It adds a transaction to each write and delete.
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithDependencies())
+//	err = cache.Set(ctx, "report:1", report, time.Hour)
+//	err = cache.SetDerived(ctx, "report:1", "user:1", "orders:1")
+//	err = cache.Del(ctx, "user:1") // deletes report:1
+func WithDependencies() Option {
+	return func(c *cache) {
+		c.dependencies = true
+	}
+}
+
+// WithManager registers the cache in the manager, so that the cache is closed
+// gracefully with the other stores of the application by CloseAll. The cache
+// unregisters itself when closed or destroyed. The cache is not registered in any
+// manager by default.
+//
+// Example:
+//
+//	manager := litepack.NewManager()
+//	defer manager.CloseAll(ctx)
+//	cache, err := cache.NewCache(ctx, cache.WithManager(manager))
+func WithManager(manager *litepack.Manager) Option {
+	return func(c *cache) {
+		c.manager = manager
+	}
+}
+
+// validate returns an error wrapping ErrInvalidOption for each option out of its range.
+func (c *cache) validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidOption, fmt.Sprintf(format, args...)))
+	}
+
+	if err := c.syncInterval.Validate(); err != nil {
+		invalid("sync interval %q: %v", c.syncInterval, err)
+	}
+	if c.purgeSchedule != "" {
+		if err := c.purgeSchedule.Validate(); err != nil {
+			invalid("purge schedule %q: %v", c.purgeSchedule, err)
+		}
+		if c.purgeWatermark <= 0 || c.purgeWatermark > 1 {
+			invalid("high watermark %v must be greater than 0 and at most 1", c.purgeWatermark)
+		}
+	}
+	if c.optimizeSchedule != "" {
+		if err := c.optimizeSchedule.Validate(); err != nil {
+			invalid("optimize schedule %q: %v", c.optimizeSchedule, err)
+		}
+	}
+	if c.purgePercent < 0 || c.purgePercent > 1 {
+		invalid("purge percent %v must be between 0 and 1", c.purgePercent)
+	}
+	if c.timeSource.Timezone == nil {
+		invalid("timezone must not be nil")
+	}
+	if c.timeSource.Now == nil {
+		invalid("clock must not be nil")
+	}
+	if c.maxDBSize <= 0 {
+		invalid("max database size %d must be positive", c.maxDBSize)
+	}
+	if c.pageSize < 512 || c.pageSize > 65536 || c.pageSize&(c.pageSize-1) != 0 {
+		invalid("page size %d must be a power of two between 512 and 65536", c.pageSize)
+	}
+	if c.asyncQueueSize <= 0 {
+		invalid("async queue size %d must be positive", c.asyncQueueSize)
+	}
+	if c.evictionPolicy == nil {
+		invalid("eviction policy must not be nil")
+	}
+	if c.codec == nil {
+		invalid("codec must not be nil")
+	}
+
+	for key := range c.dsnParams {
+		if key == "" {
+			invalid("DSN parameter must have a name")
+		}
+	}
+
+	switch c.synchronous {
+	case "", database.SynchronousOff, database.SynchronousNormal, database.SynchronousFull:
+	default:
+		invalid("synchronous mode %q must be OFF, NORMAL or FULL", c.synchronous)
+	}
+
+	type duration struct {
+		name  string
+		value time.Duration
+	}
+	durations := []duration{
+		{"purge timeout", c.purgeTimeout},
+		{"op timeout", c.opTimeout},
+		{"busy timeout", c.busyTimeout},
+		{"event log retention", c.eventRetention},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			invalid("%s %s must not be negative", d.name, d.value)
+		}
+	}
+
+	type size struct {
+		name  string
+		value int
+	}
+	sizes := []size{
+		{"sqlite cache size", c.cacheSize},
+		{"read pool size", c.readPoolSize},
+		{"mmap size", c.mmapSize},
+		{"incremental vacuum pages", c.incrementalVacuumPages},
+		{"max value size", c.maxValueSize},
+		{"max cache bytes", c.maxCacheBytes},
+		{"max key length", c.maxKeyLength},
+	}
+	if c.memory != nil {
+		sizes = append(sizes,
+			size{"memory tier max entries", c.memory.maxEntries},
+			size{"memory tier max bytes", c.memory.maxBytes},
+		)
+	}
+	for _, s := range sizes {
+		if s.value < 0 {
+			invalid("%s %d must not be negative", s.name, s.value)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,11 +1,16 @@
 package cache
 
 import (
+	"context"
+	"log/slog"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lucasvillarinho/litepack"
+	"github.com/lucasvillarinho/litepack/database"
 	"github.com/lucasvillarinho/litepack/internal/cron"
 )
 
@@ -39,7 +44,7 @@ func TestCacheOptions(t *testing.T) {
 
 	t.Run("WithPurgePercent", func(t *testing.T) {
 		c := &cache{}
-		percent := 25.0
+		percent := 0.25
 
 		WithPurgePercent(percent)(c)
 
@@ -54,4 +59,390 @@ func TestCacheOptions(t *testing.T) {
 
 		assert.Equal(t, timeout, c.purgeTimeout, "purgeTimeout should be set correctly")
 	})
+	t.Run("WithEvictionPolicy", func(t *testing.T) {
+		c := &cache{}
+
+		WithEvictionPolicy(LRUPolicy{})(c)
+
+		assert.Equal(t, LRUPolicy{}, c.evictionPolicy, "evictionPolicy should be set correctly")
+	})
+	t.Run("WithEvictionLFU", func(t *testing.T) {
+		c := &cache{}
+
+		WithEvictionLFU()(c)
+
+		assert.Equal(t, LFUPolicy{}, c.evictionPolicy, "evictionPolicy should be LFU")
+	})
+	t.Run("WithMaxCacheBytes", func(t *testing.T) {
+		c := &cache{}
+
+		WithMaxCacheBytes(1 << 20)(c)
+
+		assert.Equal(t, 1<<20, c.maxCacheBytes, "maxCacheBytes should be set correctly")
+	})
+	t.Run("WithHooks", func(t *testing.T) {
+		c := &cache{}
+		onSet := func(context.Context, string) {}
+
+		WithHooks(Hooks{OnSet: onSet})(c)
+
+		assert.NotNil(t, c.hooks.OnSet, "OnSet hook should be set")
+		assert.Nil(t, c.hooks.OnDelete, "OnDelete hook should not be set")
+	})
+	t.Run("WithAsyncQueueSize", func(t *testing.T) {
+		c := &cache{}
+
+		WithAsyncQueueSize(64)(c)
+
+		assert.Equal(t, 64, c.asyncQueueSize, "asyncQueueSize should be set correctly")
+	})
+	t.Run("WithBufferedAccessUpdates", func(t *testing.T) {
+		c := &cache{}
+
+		WithBufferedAccessUpdates()(c)
+
+		assert.NotNil(t, c.access, "access buffer should be enabled")
+	})
+	t.Run("WithWarmup", func(t *testing.T) {
+		c := &cache{}
+
+		WithWarmup(func(context.Context, Setter) error { return nil })(c)
+
+		assert.NotNil(t, c.warmup.fn, "warm-up function should be set")
+		assert.False(t, c.warmup.async, "warm-up should run synchronously")
+	})
+	t.Run("WithAsyncWarmup", func(t *testing.T) {
+		c := &cache{}
+
+		WithAsyncWarmup(func(context.Context, Setter) error { return nil })(c)
+
+		assert.NotNil(t, c.warmup.fn, "warm-up function should be set")
+		assert.True(t, c.warmup.async, "warm-up should run asynchronously")
+	})
+	t.Run("WithPurgeSchedule", func(t *testing.T) {
+		c := &cache{}
+
+		WithPurgeSchedule(cron.Every5Minutes, 0.8)(c)
+
+		assert.Equal(t, cron.Every5Minutes, c.purgeSchedule, "purgeSchedule should be set correctly")
+		assert.Equal(t, 0.8, c.purgeWatermark, "purgeWatermark should be set correctly")
+	})
+	t.Run("WithAutoVacuumIncremental", func(t *testing.T) {
+		c := &cache{}
+
+		WithAutoVacuumIncremental(64)(c)
+
+		assert.Equal(t, 64, c.incrementalVacuumPages, "incrementalVacuumPages should be set correctly")
+	})
+	t.Run("WithOpTimeout", func(t *testing.T) {
+		c := &cache{}
+
+		WithOpTimeout(time.Second)(c)
+
+		assert.Equal(t, time.Second, c.opTimeout, "opTimeout should be set correctly")
+	})
+	t.Run("WithCodec", func(t *testing.T) {
+		c := &cache{}
+
+		WithCodec(GobCodec{})(c)
+
+		assert.Equal(t, GobCodec{}, c.codec, "codec should be set correctly")
+	})
+	t.Run("WithMaxValueSize", func(t *testing.T) {
+		c := &cache{}
+
+		WithMaxValueSize(1024)(c)
+
+		assert.Equal(t, 1024, c.maxValueSize, "maxValueSize should be set correctly")
+	})
+	t.Run("WithMemoryTier", func(t *testing.T) {
+		c := &cache{}
+
+		WithMemoryTier(100, 1024)(c)
+
+		assert.NotNil(t, c.memory, "memory tier should be enabled")
+		assert.Equal(t, 100, c.memory.maxEntries, "maxEntries should be set correctly")
+		assert.Equal(t, 1024, c.memory.maxBytes, "maxBytes should be set correctly")
+	})
+	t.Run("WithInMemory", func(t *testing.T) {
+		c := &cache{}
+
+		WithInMemory()(c)
+
+		assert.Equal(t, ":memory:", c.path, "path should be set to an in-memory database")
+	})
+	t.Run("WithLogger", func(t *testing.T) {
+		c := &cache{}
+		logger := NewSlogLogger(slog.Default())
+
+		WithLogger(logger)(c)
+
+		assert.Equal(t, logger, c.logger, "logger should be set correctly")
+	})
+	t.Run("WithDeleteExpiredOnRead", func(t *testing.T) {
+		c := &cache{}
+
+		WithDeleteExpiredOnRead()(c)
+
+		assert.True(t, c.deleteExpiredOnRead, "deleteExpiredOnRead should be enabled")
+	})
+	t.Run("WithEventLog", func(t *testing.T) {
+		c := &cache{}
+
+		WithEventLog(time.Hour)(c)
+
+		assert.Equal(t, time.Hour, c.eventRetention, "eventRetention should be set correctly")
+	})
+	t.Run("WithSlidingTTL", func(t *testing.T) {
+		c := &cache{}
+
+		WithSlidingTTL()(c)
+
+		assert.True(t, c.slidingTTL, "slidingTTL should be enabled")
+	})
+	t.Run("WithMaxDBSize", func(t *testing.T) {
+		c := &cache{}
+
+		WithMaxDBSize(1024 * 1024)(c)
+
+		assert.Equal(t, 1024*1024, c.maxDBSize, "maxDBSize should be set correctly")
+	})
+	t.Run("WithPageSize", func(t *testing.T) {
+		c := &cache{}
+
+		WithPageSize(8192)(c)
+
+		assert.Equal(t, 8192, c.pageSize, "pageSize should be set correctly")
+	})
+	t.Run("WithSQLiteCacheSize", func(t *testing.T) {
+		c := &cache{}
+
+		WithSQLiteCacheSize(8 * 1024 * 1024)(c)
+
+		assert.Equal(t, 8*1024*1024, c.cacheSize, "cacheSize should be set correctly")
+	})
+	t.Run("WithClock", func(t *testing.T) {
+		c := &cache{}
+		frozen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		WithClock(func() time.Time { return frozen })(c)
+
+		assert.Equal(t, frozen, c.timeSource.Now(), "clock should be set correctly")
+	})
+	t.Run("WithScheduler", func(t *testing.T) {
+		c := &cache{}
+		scheduler := cron.New(time.UTC)
+
+		WithScheduler(scheduler)(c)
+
+		assert.Equal(t, scheduler, c.cron, "scheduler should be set correctly")
+	})
+	t.Run("WithInstrumentation", func(t *testing.T) {
+		c := &cache{}
+		observer := &recordingObserver{}
+
+		WithInstrumentation(observer)(c)
+
+		assert.Equal(t, observer, c.observer, "observer should be set correctly")
+	})
+	t.Run("WithBusyTimeout", func(t *testing.T) {
+		c := &cache{}
+
+		WithBusyTimeout(time.Second)(c)
+
+		assert.Equal(t, time.Second, c.busyTimeout, "busyTimeout should be set correctly")
+	})
+	t.Run("WithSynchronous", func(t *testing.T) {
+		c := &cache{}
+
+		WithSynchronous(database.SynchronousNormal)(c)
+
+		assert.Equal(t, database.SynchronousNormal, c.synchronous, "synchronous should be set correctly")
+	})
+	t.Run("WithOptimizeSchedule", func(t *testing.T) {
+		c := &cache{}
+
+		WithOptimizeSchedule(cron.Every30Minutes)(c)
+
+		assert.Equal(t, cron.Every30Minutes, c.optimizeSchedule, "optimizeSchedule should be set correctly")
+	})
+	t.Run("WithMmapSize", func(t *testing.T) {
+		c := &cache{}
+
+		WithMmapSize(256 * 1024 * 1024)(c)
+
+		assert.Equal(t, 256*1024*1024, c.mmapSize, "mmapSize should be set correctly")
+	})
+	t.Run("WithQueryHook", func(t *testing.T) {
+		c := &cache{}
+		hook := func(context.Context, string, []interface{}, time.Duration, error) {}
+
+		WithQueryHook(hook)(c)
+		WithQueryHook(nil)(c)
+
+		assert.Len(t, c.queryHooks, 1, "only the non-nil hook should be added")
+	})
+	t.Run("WithReadPool", func(t *testing.T) {
+		c := &cache{}
+
+		WithReadPool(4)(c)
+
+		assert.Equal(t, 4, c.readPoolSize, "readPoolSize should be set correctly")
+		assert.NotNil(t, c.access, "access times should be buffered")
+	})
+	t.Run("WithChecksums", func(t *testing.T) {
+		c := &cache{}
+
+		WithChecksums()(c)
+
+		assert.True(t, c.checksums, "checksums should be enabled")
+	})
+	t.Run("WithMaxKeyLength", func(t *testing.T) {
+		c := &cache{}
+
+		WithMaxKeyLength(250)(c)
+
+		assert.Equal(t, 250, c.maxKeyLength, "maxKeyLength should be set correctly")
+	})
+	t.Run("WithKeyPattern", func(t *testing.T) {
+		c := &cache{}
+		pattern := regexp.MustCompile(`^[a-z:]+$`)
+
+		WithKeyPattern(pattern)(c)
+
+		assert.Equal(t, pattern, c.keyPattern, "keyPattern should be set correctly")
+	})
+
+	t.Run("WithKeyPrefix", func(t *testing.T) {
+		c := &cache{}
+
+		WithKeyPrefix("svc-a:")(c)
+
+		assert.Equal(t, "svc-a:", c.keyPrefix, "keyPrefix should be set correctly")
+	})
+	t.Run("WithTombstones", func(t *testing.T) {
+		c := &cache{}
+
+		WithTombstones(time.Hour)(c)
+
+		assert.Equal(t, time.Hour, c.tombstoneRetention, "tombstoneRetention should be set correctly")
+	})
+	t.Run("WithDSNParam", func(t *testing.T) {
+		c := &cache{}
+
+		WithDSNParam("_journal_mode", "WAL")(c)
+		WithDSNParam("_pragma", "foreign_keys(1)")(c)
+
+		assert.Equal(t, "WAL", c.dsnParams.Get("_journal_mode"), "dsnParams should be set correctly")
+		assert.Equal(t, "foreign_keys(1)", c.dsnParams.Get("_pragma"), "dsnParams should be set correctly")
+	})
+	t.Run("WithDependencies", func(t *testing.T) {
+		c := &cache{}
+
+		WithDependencies()(c)
+
+		assert.True(t, c.dependencies, "dependencies should be enabled")
+	})
+	t.Run("WithManager", func(t *testing.T) {
+		c := &cache{}
+		manager := litepack.NewManager()
+
+		WithManager(manager)(c)
+
+		assert.Same(t, manager, c.manager, "manager should be set correctly")
+	})
+}
+
+func TestCacheOptions_validate(t *testing.T) {
+	valid := func() *cache {
+		return &cache{
+			purgePercent:   0.2,
+			maxDBSize:      1024 * 1024,
+			pageSize:       4096,
+			asyncQueueSize: 16,
+			syncInterval:   cron.EveryMinute,
+			evictionPolicy: LRUPolicy{},
+			codec:          JSONCodec{},
+			timeSource:     timeSource{Timezone: time.UTC, Now: time.Now},
+		}
+	}
+
+	t.Run("should accept valid options", func(t *testing.T) {
+		assert.NoError(t, valid().validate())
+	})
+
+	t.Run("should reject the options out of their range", func(t *testing.T) {
+		tests := map[string]struct {
+			opt Option
+			err string
+		}{
+			"purge percent": {
+				opt: WithPurgePercent(25.0),
+				err: "invalid option: purge percent 25 must be between 0 and 1",
+			},
+			"sync interval": {
+				opt: WithSyncInterval("every minute"),
+				err: `invalid option: sync interval "every minute": expected exactly 5 fields, found 2: [every minute]`,
+			},
+			"page size": {
+				opt: WithPageSize(1000),
+				err: "invalid option: page size 1000 must be a power of two between 512 and 65536",
+			},
+			"busy timeout": {
+				opt: WithBusyTimeout(-time.Second),
+				err: "invalid option: busy timeout -1s must not be negative",
+			},
+			"synchronous mode": {
+				opt: WithSynchronous("SOMETIMES"),
+				err: `invalid option: synchronous mode "SOMETIMES" must be OFF, NORMAL or FULL`,
+			},
+			"optimize schedule": {
+				opt: WithOptimizeSchedule("hourly"),
+				err: `invalid option: optimize schedule "hourly": expected exactly 5 fields, found 1: [hourly]`,
+			},
+			"DSN parameter": {
+				opt: WithDSNParam("", "WAL"),
+				err: "invalid option: DSN parameter must have a name",
+			},
+			"mmap size": {
+				opt: WithMmapSize(-1),
+				err: "invalid option: mmap size -1 must not be negative",
+			},
+			"max value size": {
+				opt: WithMaxValueSize(-1),
+				err: "invalid option: max value size -1 must not be negative",
+			},
+			"high watermark": {
+				opt: WithPurgeSchedule(cron.Every5Minutes, 1.5),
+				err: "invalid option: high watermark 1.5 must be greater than 0 and at most 1",
+			},
+			"eviction policy": {
+				opt: WithEvictionPolicy(nil),
+				err: "invalid option: eviction policy must not be nil",
+			},
+		}
+
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				c := valid()
+				tt.opt(c)
+
+				err := c.validate()
+
+				assert.ErrorIs(t, err, ErrInvalidOption)
+				assert.EqualError(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("should report every invalid option", func(t *testing.T) {
+		c := valid()
+		WithPurgePercent(-1)(c)
+		WithOpTimeout(-time.Second)(c)
+
+		err := c.validate()
+
+		assert.EqualError(t, err, "invalid option: purge percent -1 must be between 0 and 1\n"+
+			"invalid option: op timeout -1s must not be negative")
+	})
 }
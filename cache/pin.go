@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// Pin marks a key as pinned, so that the entry is never evicted by the purges that run
+// when the database is full or over the byte budget, nor deleted by the expired
+// entries purge.
+// Pinning does not change the TTL: once expired, a pinned entry is no longer returned
+// but its row is kept until it is unpinned. Use Persist to keep it readable.
+// Pinned entries can still be removed by Del or Flush.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//
+// Returns:
+//   - error: an error if the operation failed, ErrKeyNotFound if the key does not exist
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err = cache.Set(ctx, "config", config, time.Hour)
+//	err = cache.Pin(ctx, "config")
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Pin(ctx context.Context, key string) error {
+	err := ch.setPinned(ctx, key, true)
+	if err != nil {
+		return fmt.Errorf("error pinning key: %w", err)
+	}
+
+	return nil
+}
+
+// Unpin removes the pin of a key, so that the entry can be evicted and expired again.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//
+// Returns:
+//   - error: an error if the operation failed, ErrKeyNotFound if the key does not exist
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	err := cache.Unpin(ctx, "config")
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) Unpin(ctx context.Context, key string) error {
+	err := ch.setPinned(ctx, key, false)
+	if err != nil {
+		return fmt.Errorf("error unpinning key: %w", err)
+	}
+
+	return nil
+}
+
+// setPinned sets the pinned flag of a live entry.
+func (ch *cache) setPinned(ctx context.Context, key string, pinned bool) error {
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	params := queries.SetPinnedParams{
+		Key: key,
+		Now: ch.timeSource.Now().In(ch.timeSource.Timezone),
+	}
+	if pinned {
+		params.Pinned = 1
+	}
+
+	updated, err := ch.queries.SetPinned(ctx, params)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
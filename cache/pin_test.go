@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_Pin(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("Should pin the key", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET pinned = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs(1, "key", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := ch.Pin(context.Background(), "key")
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should unpin the key", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET pinned = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs(0, "key", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := ch.Unpin(context.Background(), "key")
+
+		assert.NoError(t, err, "Expected no error, but got: %v", err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("Should return ErrKeyNotFound if key does not exist", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET pinned = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs(1, "missing", fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := ch.Pin(context.Background(), "missing")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("Should return error if UPDATE query fails", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET pinned = \? WHERE key = \? AND expires_at > \?`).
+			WithArgs(0, "key", fixedTime).
+			WillReturnError(fmt.Errorf("mock update error"))
+
+		err := ch.Unpin(context.Background(), "key")
+
+		assert.Error(t, err, "Expected error for failing query")
+		assert.Equal(t, "error unpinning key: mock update error", err.Error())
+	})
+}
@@ -3,36 +3,107 @@ package cache
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"time"
+	"sync/atomic"
 
 	"github.com/lucasvillarinho/litepack/cache/queries"
 )
 
+// taskPurgeExpired is the name of the task that deletes expired cache entries.
+const taskPurgeExpired = "purge-expired"
+
+// taskPurgeBudget is the name of the task that keeps the stored bytes under the budget.
+const taskPurgeBudget = "purge-budget"
+
+// taskIncrementalVacuum is the name of the task that frees pages incrementally.
+const taskIncrementalVacuum = "incremental-vacuum"
+
+// taskPurgeWatermark is the name of the task that purges the cache above the high watermark.
+const taskPurgeWatermark = "purge-watermark"
+
+// purgeCounters counts the purges executed since the cache was opened.
+type purgeCounters struct {
+	expiredPurges  atomic.Int64 // runs of the expired entries purge
+	sizePurges     atomic.Int64 // runs of the purges triggered by a full database or budget
+	evictedEntries atomic.Int64 // entries deleted by the size purges
+}
+
 // PurgeItens deletes a percentage of the cache entries.
-// The entries are deleted in ascending order of last accessed at timestamp (LRU).
+// The entries are selected by the eviction policy, by default in ascending order
+// of last accessed at timestamp (LRU).
 // The percentage must be between 0 and 1.
 //
 // Parameters:
 //   - ctx: the context
 //
 // Returns:
+//   - PurgeReport: the entries and bytes deleted, also returned if the vacuum fails
 //   - error: an error if the operation failed
-func (ch *cache) PurgeItens(ctx context.Context) error {
-	err := ch.Database.ExecWithTx(ctx, func(tx *sql.Tx) error {
-		err := ch.purgeEntriesByPercentage(ctx, tx, ch.purgePercent)
-		if err != nil {
-			return err
-		}
+func (ch *cache) PurgeItens(ctx context.Context) (PurgeReport, error) {
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
 
-		return nil
+	var report PurgeReport
+	err := ch.runPurge(ctx, func(ctx context.Context) error {
+		return ch.purge(ctx, &report)
+	})
+
+	return report, err
+}
+
+// purgeItens deletes a percentage of the cache entries and vacuums the database.
+// The caller must hold the write lock.
+func (ch *cache) purgeItens(ctx context.Context) error {
+	return ch.runPurge(ctx, func(ctx context.Context) error {
+		return ch.purge(ctx, nil)
+	})
+}
+
+// runPurge runs a purge, aborting it when the purge timeout is exceeded so that it
+// can't block the writers indefinitely.
+func (ch *cache) runPurge(ctx context.Context, purge func(ctx context.Context) error) error {
+	if ch.purgeTimeout <= 0 {
+		return ch.retryBusy(ctx, func() error { return purge(ctx) })
+	}
+
+	purgeCtx, cancel := context.WithTimeout(ctx, ch.purgeTimeout)
+	defer cancel()
+
+	err := ch.retryBusy(purgeCtx, func() error { return purge(purgeCtx) })
+	if err != nil && errors.Is(purgeCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		ch.logger.Error(
+			ctx,
+			fmt.Sprintf("purge aborted after the timeout of %s: %s", ch.purgeTimeout, err),
+		)
+		return fmt.Errorf("purge timed out after %s: %w", ch.purgeTimeout, err)
+	}
+
+	return err
+}
+
+// purge deletes a percentage of the cache entries and vacuums the database.
+// It fills the report with the entries deleted, if given.
+func (ch *cache) purge(ctx context.Context, report *PurgeReport) error {
+	// Store the buffered access times, so that the eviction policy sees the recent reads.
+	if err := ch.flushAccess(ctx); err != nil {
+		ch.logger.Error(ctx, err.Error())
+	}
+
+	var evicted []string
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		deleted, err := ch.purgeEntries(ctx, tx, report)
+		evicted = deleted
+		return err
 	})
 
 	if err != nil {
 		return fmt.Errorf("purging cache: %w", err)
 	}
+	ch.purgeCounters.sizePurges.Add(1)
+	ch.evicted(ctx, evicted)
 
-	err = ch.Database.Vacuum(ctx)
+	err = ch.vacuum(ctx)
 	if err != nil {
 		return fmt.Errorf("vacuuming cache: %w", err)
 	}
@@ -40,7 +111,56 @@ func (ch *cache) PurgeItens(ctx context.Context) error {
 	return nil
 }
 
-// PurgeExpiredItems removes expired items from the cache.
+// purgeEntries deletes the entries selected by the purge and returns their keys.
+// If a report is given, it is filled with the entries and bytes deleted.
+func (ch *cache) purgeEntries(
+	ctx context.Context,
+	tx *sql.Tx,
+	report *PurgeReport,
+) ([]string, error) {
+	var before queries.GetTotalUsageRow
+	if report != nil {
+		usage, err := queries.New(tx).GetTotalUsage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting cache usage: %w", err)
+		}
+		before = usage
+	}
+
+	var deleted []string
+	var err error
+	// Purge the namespaces that override the purge percent separately.
+	if ranges := ch.purgeRanges(); ranges != nil {
+		deleted, err = ch.purgeEntriesByRange(ctx, tx, ranges)
+	} else {
+		deleted, err = ch.purgeEntriesByPercentage(ctx, tx, ch.purgePercent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if report != nil {
+		after, err := queries.New(tx).GetTotalUsage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting cache usage: %w", err)
+		}
+		*report = newPurgeReport(deleted, before.ValueBytes-after.ValueBytes)
+	}
+
+	return deleted, nil
+}
+
+// vacuum returns the pages freed by a purge to the file system, with a full vacuum or
+// a step of the incremental vacuum if it is enabled.
+func (ch *cache) vacuum(ctx context.Context) error {
+	if ch.incrementalVacuumPages > 0 {
+		return ch.Database.IncrementalVacuum(ctx, ch.incrementalVacuumPages)
+	}
+
+	return ch.Database.Vacuum(ctx)
+}
+
+// PurgeExpiredItems removes expired items from the cache, except the pinned ones.
 //
 // Parameters:
 //   - ctx: context.Context to handle cancellations or timeouts
@@ -48,53 +168,112 @@ func (ch *cache) PurgeItens(ctx context.Context) error {
 // Returns:
 //   - error: any error encountered during the operation
 func (ch *cache) PurgeExpiredItems(ctx context.Context) error {
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
 	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
-	err := ch.queries.DeleteExpiredCache(ctx, now)
+	var expired []string
+	err := ch.retryBusy(ctx, func() error {
+		var err error
+		expired, err = ch.queries.DeleteExpiredCache(ctx, now)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("purging expired cache: %w", err)
 	}
+	ch.purgeCounters.expiredPurges.Add(1)
+	ch.hooks.expire(ctx, expired)
 	return nil
 }
 
-// purgeEntriesByPercentage deletes a percentage of the cache entries.
-func (ch *cache) purgeEntriesByPercentage(ctx context.Context, tx *sql.Tx, percent float64) error {
+// incrementalVacuumCache schedules a step of the incremental vacuum on the sync interval,
+// if the incremental vacuum is enabled.
+func (ch *cache) incrementalVacuumCache(ctx context.Context) {
+	if ch.incrementalVacuumPages <= 0 {
+		return
+	}
+
+	task := func() error {
+		ch.writeMu.RLock()
+		defer ch.writeMu.RUnlock()
+
+		err := ch.Database.IncrementalVacuum(ctx, ch.incrementalVacuumPages)
+		if err != nil {
+			ch.logger.Error(ctx, err.Error())
+			return err
+		}
+
+		return nil
+	}
+
+	err := ch.addTask(taskIncrementalVacuum, string(ch.syncInterval), task)
+	if err != nil {
+		err = fmt.Errorf("adding cron task: %w", err)
+		ch.logger.Error(ctx, err.Error())
+	}
+}
+
+// purgeEntriesByPercentage deletes a percentage of the cache entries
+// and returns the keys deleted.
+func (ch *cache) purgeEntriesByPercentage(
+	ctx context.Context,
+	tx *sql.Tx,
+	percent float64,
+) ([]string, error) {
 	if percent < 0 || percent > 1 {
-		return fmt.Errorf("invalid percentage: %f", percent)
+		return nil, fmt.Errorf("invalid percentage: %f", percent)
 	}
 
 	queriesWityTx := queries.New(tx)
 
 	totalEntries, err := queriesWityTx.CountCacheEntries(ctx)
 	if err != nil {
-		return fmt.Errorf("count entries: %w", err)
+		return nil, fmt.Errorf("count entries: %w", err)
 	}
 
 	// Calculate the number of entries to delete.
 	totalEntriesToDelete := int64(float64(totalEntries) * percent)
 	if totalEntriesToDelete == 0 {
-		return nil
+		return nil, nil
 	}
 
-	err = queriesWityTx.DeleteKeysByLimit(ctx, totalEntriesToDelete)
+	deleted, err := ch.evictionPolicy.Evict(ctx, tx, totalEntriesToDelete)
 	if err != nil {
-		return fmt.Errorf("delete entries: %w", err)
+		return nil, fmt.Errorf("delete entries: %w", err)
 	}
 
-	return nil
+	return deleted, nil
+}
+
+// evicted records the keys deleted by a size purge and removes them from the memory tier.
+func (ch *cache) evicted(ctx context.Context, keys []string) {
+	ch.purgeCounters.evictedEntries.Add(int64(len(keys)))
+	for _, key := range keys {
+		ch.memory.del(key)
+	}
+	ch.hooks.evict(ctx, keys)
 }
 
 // purgeExpiredItensCache clears expired cache items periodically.
 func (ch *cache) purgeExpiredItensCache(ctx context.Context) {
-	task := func() {
-		err := ch.queries.DeleteExpiredCache(ctx, time.Now().In(ch.timeSource.Timezone))
+	task := func() error {
+		ch.writeMu.RLock()
+		defer ch.writeMu.RUnlock()
+
+		now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+		expired, err := ch.queries.DeleteExpiredCache(ctx, now)
 		if err != nil {
 			err = fmt.Errorf("deleting expired cache: %w", err)
 			ch.logger.Error(ctx, err.Error())
-			return
+			return err
 		}
+		ch.purgeCounters.expiredPurges.Add(1)
+		ch.hooks.expire(ctx, expired)
+
+		return nil
 	}
 
-	_, err := ch.cron.AddAndExec(string(ch.syncInterval), task)
+	err := ch.addTaskAndExec(taskPurgeExpired, string(ch.syncInterval), task)
 	if err != nil {
 		err = fmt.Errorf("adding cron task: %w", err)
 		ch.logger.Error(ctx, err.Error())
@@ -103,3 +282,163 @@ func (ch *cache) purgeExpiredItensCache(ctx context.Context) {
 
 	ch.cron.Start()
 }
+
+// purgeOverBudget evicts entries until the bytes stored by the values drop below
+// the max bytes of each namespace with a budget and the max cache bytes.
+func (ch *cache) purgeOverBudget(ctx context.Context) error {
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	// Store the buffered access times, so that the eviction policy sees the recent reads.
+	if err := ch.flushAccess(ctx); err != nil {
+		ch.logger.Error(ctx, err.Error())
+	}
+
+	budgets := ch.namespaceBudgets()
+	var evicted []string
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		for r, budget := range budgets {
+			deleted, err := ch.evictRangeToBudget(ctx, tx, r, budget)
+			if err != nil {
+				return err
+			}
+			evicted = append(evicted, deleted...)
+		}
+
+		if ch.maxCacheBytes <= 0 {
+			return nil
+		}
+
+		deleted, err := ch.evictToBudget(ctx, tx, int64(ch.maxCacheBytes))
+		if err != nil {
+			return err
+		}
+		evicted = append(evicted, deleted...)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("purging cache over budget: %w", err)
+	}
+	if len(evicted) == 0 {
+		return nil
+	}
+	ch.purgeCounters.sizePurges.Add(1)
+	ch.evicted(ctx, evicted)
+
+	return nil
+}
+
+// evictToBudget evicts entries with the eviction policy until the bytes stored by
+// the values drop below the budget, and returns the keys deleted.
+func (ch *cache) evictToBudget(ctx context.Context, tx *sql.Tx, budget int64) ([]string, error) {
+	queriesWithTx := queries.New(tx)
+
+	usage, err := queriesWithTx.GetTotalUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting cache usage: %w", err)
+	}
+
+	var evicted []string
+	for usage.ValueBytes > budget && usage.Entries > 0 {
+		// Estimate the entries to delete from the average value size.
+		average := usage.ValueBytes / usage.Entries
+		count := (usage.ValueBytes-budget)/max(average, 1) + 1
+
+		deleted, err := ch.evictionPolicy.Evict(ctx, tx, min(count, usage.Entries))
+		if err != nil {
+			return nil, fmt.Errorf("delete entries: %w", err)
+		}
+
+		// The policy did not delete anything, stop instead of looping forever.
+		if len(deleted) == 0 {
+			break
+		}
+		evicted = append(evicted, deleted...)
+
+		usage, err = queriesWithTx.GetTotalUsage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting cache usage: %w", err)
+		}
+	}
+
+	return evicted, nil
+}
+
+// purgeOverBudgetCache schedules the purge that keeps the stored bytes under the budget,
+// if a budget is configured.
+func (ch *cache) purgeOverBudgetCache(ctx context.Context) {
+	if ch.maxCacheBytes <= 0 {
+		return
+	}
+
+	ch.scheduleBudgetPurge(ctx)
+}
+
+// scheduleBudgetPurge schedules the purge that keeps the stored bytes under the budgets
+// of the cache and of the namespaces on the sync interval, once.
+func (ch *cache) scheduleBudgetPurge(ctx context.Context) {
+	ch.budgetPurge.Do(func() { ch.addBudgetPurgeTask(ctx) })
+}
+
+// addBudgetPurgeTask adds the task that keeps the stored bytes under the budgets.
+func (ch *cache) addBudgetPurgeTask(ctx context.Context) {
+	task := func() error {
+		err := ch.purgeOverBudget(ctx)
+		if err != nil {
+			ch.logger.Error(ctx, err.Error())
+			return err
+		}
+
+		return nil
+	}
+
+	err := ch.addTask(taskPurgeBudget, string(ch.syncInterval), task)
+	if err != nil {
+		err = fmt.Errorf("adding cron task: %w", err)
+		ch.logger.Error(ctx, err.Error())
+	}
+}
+
+// purgeOverWatermark purges the cache with purgeItens if the pages in use exceed the
+// high watermark of the max database size.
+func (ch *cache) purgeOverWatermark(ctx context.Context) error {
+	pages, err := ch.queries.GetPageStats(ctx)
+	if err != nil {
+		return fmt.Errorf("getting page stats: %w", err)
+	}
+
+	used := (pages.PageCount - pages.FreelistCount) * pages.PageSize
+	if float64(used) < ch.purgeWatermark*float64(ch.maxDBSize) {
+		return nil
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	return ch.purgeItens(ctx)
+}
+
+// purgeOverWatermarkCache schedules the purge that runs when the database crosses the
+// high watermark, if a purge schedule is configured.
+func (ch *cache) purgeOverWatermarkCache(ctx context.Context) {
+	if ch.purgeWatermark <= 0 {
+		return
+	}
+
+	task := func() error {
+		err := ch.purgeOverWatermark(ctx)
+		if err != nil {
+			ch.logger.Error(ctx, err.Error())
+			return err
+		}
+
+		return nil
+	}
+
+	err := ch.addTask(taskPurgeWatermark, string(ch.purgeSchedule), task)
+	if err != nil {
+		err = fmt.Errorf("adding cron task: %w", err)
+		ch.logger.Error(ctx, err.Error())
+	}
+}
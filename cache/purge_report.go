@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxReportPrefixes is the number of key prefixes listed by a purge report.
+const maxReportPrefixes = 10
+
+// errPurgePreview rolls back the transaction of a purge preview.
+var errPurgePreview = fmt.Errorf("purge preview")
+
+// PurgeReport describes the entries deleted by a purge, or that a purge would delete.
+type PurgeReport struct {
+	// Entries is the number of entries deleted.
+	Entries int64 `json:"entries"`
+	// Bytes is the number of bytes of the values deleted.
+	Bytes int64 `json:"bytes"`
+	// Prefixes are the key prefixes with the most entries deleted, in descending order
+	// of entries, up to 10. The prefix of a key is the part up to its first ":",
+	// keys without a ":" are reported with an empty prefix.
+	Prefixes []PurgePrefix `json:"prefixes"`
+}
+
+// PurgePrefix is the number of entries deleted by a purge with a key prefix.
+type PurgePrefix struct {
+	Prefix  string `json:"prefix"`
+	Entries int64  `json:"entries"`
+}
+
+// PurgePreview reports the entries and bytes that PurgeItens would delete at the current
+// purge percent, without deleting them. The purge runs with the eviction policy and the
+// namespace purge percents of the cache in a transaction that is rolled back, so it
+// blocks the writers like a purge while it runs.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - PurgeReport: the entries and bytes that would be deleted
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	report, err := cache.PurgePreview(ctx)
+//	for _, prefix := range report.Prefixes {
+//		fmt.Printf("%s: %d entries\n", prefix.Prefix, prefix.Entries)
+//	}
+func (ch *cache) PurgePreview(ctx context.Context) (PurgeReport, error) {
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	var report PurgeReport
+	err := ch.runPurge(ctx, func(ctx context.Context) error {
+		return ch.previewPurge(ctx, &report)
+	})
+
+	return report, err
+}
+
+// previewPurge fills the report with the entries a purge would delete, rolling back the
+// deletes.
+func (ch *cache) previewPurge(ctx context.Context, report *PurgeReport) error {
+	// Store the buffered access times, so that the eviction policy sees the recent reads.
+	if err := ch.flushAccess(ctx); err != nil {
+		ch.logger.Error(ctx, err.Error())
+	}
+
+	err := ch.Database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		if _, err := ch.purgeEntries(ctx, tx, report); err != nil {
+			return err
+		}
+
+		return errPurgePreview
+	})
+	if err != nil && !errors.Is(err, errPurgePreview) {
+		return fmt.Errorf("previewing purge: %w", err)
+	}
+
+	return nil
+}
+
+// newPurgeReport returns the report of a purge that deleted the given keys and bytes.
+func newPurgeReport(keys []string, bytes int64) PurgeReport {
+	counts := make(map[string]int64)
+	for _, key := range keys {
+		prefix, _, found := strings.Cut(key, namespaceSeparator)
+		if !found {
+			prefix = ""
+		}
+		counts[prefix]++
+	}
+
+	prefixes := make([]PurgePrefix, 0, len(counts))
+	for prefix, entries := range counts {
+		prefixes = append(prefixes, PurgePrefix{Prefix: prefix, Entries: entries})
+	}
+	slices.SortFunc(prefixes, func(a, b PurgePrefix) int {
+		if a.Entries != b.Entries {
+			return cmp.Compare(b.Entries, a.Entries)
+		}
+		return strings.Compare(a.Prefix, b.Prefix)
+	})
+	if len(prefixes) > maxReportPrefixes {
+		prefixes = prefixes[:maxReportPrefixes]
+	}
+
+	return PurgeReport{
+		Entries:  int64(len(keys)),
+		Bytes:    bytes,
+		Prefixes: prefixes,
+	}
+}
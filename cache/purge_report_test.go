@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+)
+
+func TestCache_PurgePreview(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ctx := context.Background()
+
+	t.Run("should report the entries to purge and roll them back", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(10, 1000))
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(7, 650))
+		sqlMock.ExpectRollback()
+
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				err = fn(tx)
+				assert.ErrorIs(t, err, errPurgePreview, "Expected the preview to roll back")
+				assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+
+				return fmt.Errorf("error rolling back transaction: %w", err)
+			})
+
+		ch := &cache{
+			queries:        queries.New(db),
+			Database:       dbMock,
+			purgePercent:   0.3,
+			evictionPolicy: &evictionPolicyStub{batches: [][]string{{"users:1", "users:2", "a"}}},
+		}
+
+		report, err := ch.PurgePreview(ctx)
+
+		assert.NoError(t, err, "Expected no error while previewing the purge")
+		assert.Equal(t, PurgeReport{
+			Entries: 3,
+			Bytes:   350,
+			Prefixes: []PurgePrefix{
+				{Prefix: "users", Entries: 2},
+				{Prefix: "", Entries: 1},
+			},
+		}, report)
+		assert.Equal(t, int64(0), ch.purgeCounters.sizePurges.Load(), "Expected no purge")
+		assert.Equal(t, int64(0), ch.purgeCounters.evictedEntries.Load(), "Expected no evictions")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+		dbMock.AssertNotCalled(t, "Vacuum", mock.Anything)
+	})
+
+	t.Run("should return error if the purge fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("database error"))
+
+		ch := &cache{Database: dbMock, purgePercent: 0.2, evictionPolicy: LRUPolicy{}}
+
+		_, err := ch.PurgePreview(ctx)
+
+		assert.EqualError(t, err, "previewing purge: database error")
+	})
+}
+
+func TestNewPurgeReport(t *testing.T) {
+	t.Run("should group the keys by prefix in descending order of entries", func(t *testing.T) {
+		report := newPurgeReport([]string{"b:1", "a:1", "b:2", "c", "a:2:x", "b:3"}, 600)
+
+		assert.Equal(t, PurgeReport{
+			Entries: 6,
+			Bytes:   600,
+			Prefixes: []PurgePrefix{
+				{Prefix: "b", Entries: 3},
+				{Prefix: "a", Entries: 2},
+				{Prefix: "", Entries: 1},
+			},
+		}, report)
+	})
+
+	t.Run("should list up to 10 prefixes", func(t *testing.T) {
+		keys := make([]string, 0, 12)
+		for i := range 12 {
+			keys = append(keys, fmt.Sprintf("p%02d:key", i))
+		}
+
+		report := newPurgeReport(keys, 0)
+
+		assert.Len(t, report.Prefixes, maxReportPrefixes)
+		assert.Equal(t, "p00", report.Prefixes[0].Prefix, "Expected the ties sorted by prefix")
+	})
+}
@@ -17,6 +17,9 @@ import (
 	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
 )
 
+// usageQuery matches the query of the entries and bytes stored by the values.
+const usageQuery = `SELECT COUNT\(\*\) AS entries, CAST\(COALESCE\(SUM\(LENGTH\(value\)\), 0\) AS INTEGER\) AS value_bytes FROM cache`
+
 func TestPurge_PurgeItens(t *testing.T) {
 	db, sqlMock, err := sqlmock.New()
 	assert.NoError(t, err, "Expected no error while creating sqlmock")
@@ -28,19 +31,23 @@ func TestPurge_PurgeItens(t *testing.T) {
 		dbMock := dbMocks.NewDatabaseMock(t)
 
 		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(100, 10000))
 		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
-		sqlMock.ExpectExec(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache ORDER BY last_accessed_at ASC LIMIT \? \)`).
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT \? \) RETURNING key`).
 			WithArgs(20).
-			WillReturnResult(sqlmock.NewResult(1, 20))
+			WillReturnRows(keyRows(20))
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(80, 8000))
 		sqlMock.ExpectCommit()
 
 		dbMock.EXPECT().
 			Vacuum(ctx).
 			Return(nil)
 		dbMock.EXPECT().
-			ExecWithTx(mock.Anything, mock.Anything).
-			Run(func(ctx context.Context, fn func(*sql.Tx) error) {
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
 				tx, err := db.Begin()
 				assert.NoError(t, err, "Expected no error while beginning transaction")
 
@@ -53,14 +60,17 @@ func TestPurge_PurgeItens(t *testing.T) {
 			Return(nil)
 
 		ch := &cache{
-			queries:      queries.New(db),
-			purgePercent: 0.2,
-			Database:     dbMock,
+			queries:        queries.New(db),
+			purgePercent:   0.2,
+			evictionPolicy: LRUPolicy{},
+			Database:       dbMock,
 		}
 
-		err := ch.PurgeItens(context.Background())
+		report, err := ch.PurgeItens(context.Background())
 
 		assert.NoError(t, err, "Expected no error while purging and vacuuming the database")
+		assert.Equal(t, int64(20), report.Entries, "Expected the entries deleted")
+		assert.Equal(t, int64(2000), report.Bytes, "Expected the bytes deleted")
 		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
 		dbMock.AssertExpectations(t)
 	})
@@ -69,13 +79,15 @@ func TestPurge_PurgeItens(t *testing.T) {
 		dbMock := dbMocks.NewDatabaseMock(t)
 
 		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(100, 10000))
 		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
 			WillReturnError(fmt.Errorf("database error"))
 		sqlMock.ExpectRollback()
 
 		dbMock.EXPECT().
-			ExecWithTx(mock.Anything, mock.Anything).
-			Run(func(ctx context.Context, fn func(*sql.Tx) error) {
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
 				tx, err := db.Begin()
 				assert.NoError(t, err, "Expected no error while beginning transaction")
 
@@ -88,12 +100,13 @@ func TestPurge_PurgeItens(t *testing.T) {
 			Return(fmt.Errorf("unexpected error"))
 
 		ch := &cache{
-			queries:      queries.New(db),
-			purgePercent: 0.2,
-			Database:     dbMock,
+			queries:        queries.New(db),
+			purgePercent:   0.2,
+			evictionPolicy: LRUPolicy{},
+			Database:       dbMock,
 		}
 
-		err := ch.PurgeItens(context.Background())
+		_, err := ch.PurgeItens(context.Background())
 
 		assert.Error(t, err, "Expected error while purging items")
 		assert.Equal(
@@ -110,16 +123,20 @@ func TestPurge_PurgeItens(t *testing.T) {
 		dbMock := dbMocks.NewDatabaseMock(t)
 
 		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(100, 10000))
 		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
-		sqlMock.ExpectExec(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache ORDER BY last_accessed_at ASC LIMIT \? \)`).
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT \? \) RETURNING key`).
 			WithArgs(20).
-			WillReturnResult(sqlmock.NewResult(1, 20))
+			WillReturnRows(keyRows(20))
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(80, 8000))
 		sqlMock.ExpectCommit()
 
 		dbMock.EXPECT().
-			ExecWithTx(mock.Anything, mock.Anything).
-			Run(func(ctx context.Context, fn func(*sql.Tx) error) {
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
 				tx, err := db.Begin()
 				assert.NoError(t, err, "Expected no error while beginning transaction")
 
@@ -136,14 +153,16 @@ func TestPurge_PurgeItens(t *testing.T) {
 			Return(fmt.Errorf("unexpected error"))
 
 		ch := &cache{
-			queries:      queries.New(db),
-			purgePercent: 0.2,
-			Database:     dbMock,
+			queries:        queries.New(db),
+			purgePercent:   0.2,
+			evictionPolicy: LRUPolicy{},
+			Database:       dbMock,
 		}
 
-		err := ch.PurgeItens(context.Background())
+		report, err := ch.PurgeItens(context.Background())
 
 		assert.Error(t, err, "Expected error while vacuuming")
+		assert.Equal(t, int64(20), report.Entries, "Expected the report of the committed purge")
 		assert.Equal(
 			t,
 			"vacuuming cache: unexpected error",
@@ -153,6 +172,61 @@ func TestPurge_PurgeItens(t *testing.T) {
 		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
 		dbMock.AssertExpectations(t)
 	})
+
+	t.Run("should abort the purge when the timeout is exceeded", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		loggerMock := logMocks.NewLoggerMock(t)
+
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		loggerMock.EXPECT().
+			Error(ctx, "purge aborted after the timeout of 10ms: purging cache: context deadline exceeded")
+
+		ch := &cache{
+			queries:        queries.New(db),
+			purgePercent:   0.2,
+			purgeTimeout:   10 * time.Millisecond,
+			evictionPolicy: LRUPolicy{},
+			Database:       dbMock,
+			logger:         loggerMock,
+		}
+
+		_, err := ch.PurgeItens(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded, "Expected the purge to time out")
+		assert.Equal(
+			t,
+			"purge timed out after 10ms: purging cache: context deadline exceeded",
+			err.Error(),
+		)
+		dbMock.AssertNotCalled(t, "Vacuum", mock.Anything)
+	})
+
+	t.Run("should not report a timeout when the context is cancelled", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		loggerMock := logMocks.NewLoggerMock(t)
+
+		ch := &cache{
+			queries:        queries.New(db),
+			purgePercent:   0.2,
+			purgeTimeout:   time.Minute,
+			evictionPolicy: LRUPolicy{},
+			Database:       dbMock,
+			logger:         loggerMock,
+		}
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := ch.PurgeItens(cancelled)
+
+		assert.ErrorIs(t, err, context.Canceled, "Expected the purge to be cancelled")
+		dbMock.AssertNotCalled(t, "WithTx", mock.Anything, mock.Anything, mock.Anything)
+		loggerMock.AssertNotCalled(t, "Error", mock.Anything, mock.Anything)
+	})
 }
 
 func TestPurge_PurgeWithTransaction(t *testing.T) {
@@ -169,17 +243,19 @@ func TestPurge_PurgeWithTransaction(t *testing.T) {
 		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).
 				AddRow(100))
-		mock.ExpectExec(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache ORDER BY last_accessed_at ASC LIMIT \? \)`).
+		mock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT \? \) RETURNING key`).
 			WithArgs(20).
-			WillReturnResult(sqlmock.NewResult(1, 20))
+			WillReturnRows(keyRows(20))
 
 		ch := &cache{
-			queries: queries.New(tx),
+			queries:        queries.New(tx),
+			evictionPolicy: LRUPolicy{},
 		}
 
-		err = ch.purgeEntriesByPercentage(context.Background(), tx, 0.2)
+		deleted, err := ch.purgeEntriesByPercentage(context.Background(), tx, 0.2)
 
 		assert.NoError(t, err, "Expected no error while purging entries")
+		assert.Len(t, deleted, 20, "Expected 20 entries to be deleted")
 		assert.NoError(t, mock.ExpectationsWereMet(), "Not all expectations were met")
 	})
 
@@ -190,10 +266,11 @@ func TestPurge_PurgeWithTransaction(t *testing.T) {
 		assert.NoError(t, err, "Expected no error while starting transaction")
 
 		ch := &cache{
-			queries: queries.New(tx),
+			queries:        queries.New(tx),
+			evictionPolicy: LRUPolicy{},
 		}
 
-		err = ch.purgeEntriesByPercentage(context.Background(), tx, 1.2)
+		_, err = ch.purgeEntriesByPercentage(context.Background(), tx, 1.2)
 
 		assert.Error(t, err, "Expected an error for invalid percentage")
 		assert.Equal(t, "invalid percentage: 1.200000", err.Error(), "Error message should match")
@@ -209,10 +286,11 @@ func TestPurge_PurgeWithTransaction(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 
 		ch := &cache{
-			queries: queries.New(tx),
+			queries:        queries.New(tx),
+			evictionPolicy: LRUPolicy{},
 		}
 
-		err = ch.purgeEntriesByPercentage(context.Background(), tx, 0.2)
+		_, err = ch.purgeEntriesByPercentage(context.Background(), tx, 0.2)
 
 		assert.NoError(t, err, "Expected no error while purging entries")
 		assert.NoError(t, mock.ExpectationsWereMet(), "Not all expectations were met")
@@ -228,10 +306,11 @@ func TestPurge_PurgeWithTransaction(t *testing.T) {
 			WillReturnError(fmt.Errorf("mock select error"))
 
 		ch := &cache{
-			queries: queries.New(tx),
+			queries:        queries.New(tx),
+			evictionPolicy: LRUPolicy{},
 		}
 
-		err = ch.purgeEntriesByPercentage(context.Background(), tx, 0.2)
+		_, err = ch.purgeEntriesByPercentage(context.Background(), tx, 0.2)
 
 		assert.Error(t, err, "Expected an error for failing SELECT query")
 		assert.Equal(
@@ -252,20 +331,21 @@ func TestPurge_PurgeWithTransaction(t *testing.T) {
 		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).
 				AddRow(100))
-		mock.ExpectExec(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache ORDER BY last_accessed_at ASC LIMIT \? \)`).
+		mock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT \? \) RETURNING key`).
 			WithArgs(20).
 			WillReturnError(fmt.Errorf("mock delete error"))
 
 		ch := &cache{
-			queries: queries.New(tx),
+			queries:        queries.New(tx),
+			evictionPolicy: LRUPolicy{},
 		}
 
-		err = ch.purgeEntriesByPercentage(context.Background(), tx, 0.2)
+		_, err = ch.purgeEntriesByPercentage(context.Background(), tx, 0.2)
 
 		assert.Error(t, err, "Expected an error for failing DELETE query")
 		assert.Equal(
 			t,
-			"delete entries: mock delete error",
+			"delete entries: deleting least recently used entries: mock delete error",
 			err.Error(),
 			"Error message should match",
 		)
@@ -293,9 +373,9 @@ func TestPurge_purgeExpiredItensCache(t *testing.T) {
 	}
 
 	t.Run("should clear expired itens from cache", func(t *testing.T) {
-		sqlMock.ExpectExec(`DELETE FROM cache WHERE expires_at <= \?`).
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE expires_at <= \?`).
 			WithArgs(sqlmock.AnyArg()).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+			WillReturnRows(keyRows(1))
 
 		ch.purgeExpiredItensCache(ctx)
 
@@ -307,7 +387,7 @@ func TestPurge_purgeExpiredItensCache(t *testing.T) {
 		err := fmt.Errorf("unexpected error")
 		errMock := fmt.Errorf("expired cache: %w", err)
 
-		sqlMock.ExpectExec(`DELETE FROM cache WHERE expires_at <= \?`).
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE expires_at <= \?`).
 			WithArgs(sqlmock.AnyArg()).
 			WillReturnError(errMock)
 
@@ -331,18 +411,22 @@ func TestPurgeItens(t *testing.T) {
 		dbMock := dbMocks.NewDatabaseMock(t)
 
 		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(100, 10000))
 		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
-		sqlMock.ExpectExec(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache ORDER BY last_accessed_at ASC LIMIT \? \)`).
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN \( SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT \? \) RETURNING key`).
 			WithArgs(20).
-			WillReturnResult(sqlmock.NewResult(1, 20))
+			WillReturnRows(keyRows(20))
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(80, 8000))
 		sqlMock.ExpectCommit()
 
 		dbMock.EXPECT().
 			Vacuum(ctx).Return(nil)
 		dbMock.EXPECT().
-			ExecWithTx(mock.Anything, mock.Anything).
-			Run(func(ctx context.Context, fn func(*sql.Tx) error) {
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
 				tx, err := db.Begin()
 				assert.NoError(t, err, "Expected no error while beginning transaction")
 
@@ -355,15 +439,373 @@ func TestPurgeItens(t *testing.T) {
 			Return(nil)
 
 		ch := &cache{
-			queries:      queries.New(db),
-			purgePercent: 0.2,
-			Database:     dbMock,
+			queries:        queries.New(db),
+			purgePercent:   0.2,
+			evictionPolicy: LRUPolicy{},
+			Database:       dbMock,
 		}
 
-		err := ch.PurgeItens(context.Background())
+		report, err := ch.PurgeItens(context.Background())
 
 		assert.NoError(t, err, "Expected no error while purging and vacuuming the database")
+		assert.Equal(t, int64(20), report.Entries, "Expected the entries deleted")
+		assert.Equal(t, int64(2000), report.Bytes, "Expected the bytes deleted")
 		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
 		dbMock.AssertExpectations(t)
 	})
 }
+
+func TestPurge_evictToBudget(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ctx := context.Background()
+
+	t.Run("should evict entries until the usage drops below the budget", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(10, 1000))
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(7, 700))
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(5, 480))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		policy := &evictionPolicyStub{batches: [][]string{{"a", "b", "c"}, {"d", "e"}}}
+		ch := &cache{evictionPolicy: policy}
+
+		evicted, err := ch.evictToBudget(ctx, tx, 500)
+
+		assert.NoError(t, err, "Expected no error while evicting entries")
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, evicted, "Expected the evicted keys")
+		assert.Equal(t, int64(3), policy.count, "Expected the last batch to be estimated")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should not evict entries under the budget", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(10, 100))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		policy := &evictionPolicyStub{}
+		ch := &cache{evictionPolicy: policy}
+
+		evicted, err := ch.evictToBudget(ctx, tx, 500)
+
+		assert.NoError(t, err, "Expected no error while evicting entries")
+		assert.Zero(t, evicted, "Expected no entries to be evicted")
+		assert.Zero(t, policy.count, "Expected the policy not to be called")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should stop if the policy does not delete entries", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(10, 1000))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		ch := &cache{evictionPolicy: &evictionPolicyStub{}}
+
+		evicted, err := ch.evictToBudget(ctx, tx, 500)
+
+		assert.NoError(t, err, "Expected no error while evicting entries")
+		assert.Zero(t, evicted, "Expected no entries to be evicted")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the policy fails", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(10, 1000))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		ch := &cache{evictionPolicy: &evictionPolicyStub{err: fmt.Errorf("mock policy error")}}
+
+		_, err = ch.evictToBudget(ctx, tx, 500)
+
+		assert.EqualError(t, err, "delete entries: mock policy error", "Error message should match")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the usage query fails", func(t *testing.T) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(usageQuery).
+			WillReturnError(fmt.Errorf("mock usage error"))
+
+		tx, err := db.Begin()
+		assert.NoError(t, err, "Expected no error while starting transaction")
+
+		ch := &cache{evictionPolicy: &evictionPolicyStub{}}
+
+		_, err = ch.evictToBudget(ctx, tx, 500)
+
+		assert.EqualError(
+			t,
+			err,
+			"getting cache usage: mock usage error",
+			"Error message should match",
+		)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestPurge_purgeOverBudgetCache(t *testing.T) {
+	t.Run("should schedule the purge if a budget is set", func(t *testing.T) {
+		ch := &cache{
+			cron:          cron.New(time.UTC),
+			syncInterval:  cron.EveryMinute,
+			maxCacheBytes: 1024,
+		}
+
+		ch.purgeOverBudgetCache(context.Background())
+
+		tasks := ch.cron.Tasks()
+		assert.Len(t, tasks, 1, "Expected the budget purge to be scheduled")
+		assert.Equal(t, taskPurgeBudget, tasks[0].Name, "Expected the budget purge task")
+	})
+
+	t.Run("should not schedule the purge without a budget", func(t *testing.T) {
+		ch := &cache{
+			cron:         cron.New(time.UTC),
+			syncInterval: cron.EveryMinute,
+		}
+
+		ch.purgeOverBudgetCache(context.Background())
+
+		assert.Empty(t, ch.cron.Tasks(), "Expected no task to be scheduled")
+	})
+}
+
+func TestPurge_purgeOverBudget(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ctx := context.Background()
+
+	t.Run("should count the evicted entries", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries`).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(10, 1000))
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries`).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(4, 400))
+		sqlMock.ExpectCommit()
+
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				err = fn(tx)
+				assert.NoError(t, err, "Expected no error during transaction execution")
+
+				err = tx.Commit()
+				assert.NoError(t, err, "Expected no error while committing transaction")
+			}).
+			Return(nil)
+
+		ch := &cache{
+			Database:       dbMock,
+			evictionPolicy: &evictionPolicyStub{batches: [][]string{{"a", "b", "c", "d", "e", "f"}}},
+			maxCacheBytes:  500,
+		}
+
+		err := ch.purgeOverBudget(ctx)
+
+		assert.NoError(t, err, "Expected no error while purging over budget")
+		assert.Equal(t, int64(1), ch.purgeCounters.sizePurges.Load(), "Expected one size purge")
+		assert.Equal(t, int64(6), ch.purgeCounters.evictedEntries.Load(), "Expected 6 evictions")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the transaction fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("mock tx error"))
+
+		ch := &cache{Database: dbMock, maxCacheBytes: 500}
+
+		err := ch.purgeOverBudget(ctx)
+
+		assert.EqualError(
+			t,
+			err,
+			"purging cache over budget: mock tx error",
+			"Error message should match",
+		)
+		assert.Zero(t, ch.purgeCounters.sizePurges.Load(), "Expected no size purge")
+	})
+}
+
+func TestPurge_purgeOverWatermarkCache(t *testing.T) {
+	t.Run("should schedule the purge if a watermark is set", func(t *testing.T) {
+		ch := &cache{
+			cron:           cron.New(time.UTC),
+			purgeSchedule:  cron.Every5Minutes,
+			purgeWatermark: 0.8,
+		}
+
+		ch.purgeOverWatermarkCache(context.Background())
+
+		tasks := ch.cron.Tasks()
+		assert.Len(t, tasks, 1, "Expected the watermark purge to be scheduled")
+		assert.Equal(t, taskPurgeWatermark, tasks[0].Name, "Expected the watermark purge task")
+		assert.Equal(t, string(cron.Every5Minutes), tasks[0].Schedule)
+	})
+
+	t.Run("should not schedule the purge without a watermark", func(t *testing.T) {
+		ch := &cache{cron: cron.New(time.UTC)}
+
+		ch.purgeOverWatermarkCache(context.Background())
+
+		assert.Empty(t, ch.cron.Tasks(), "Expected no task to be scheduled")
+	})
+}
+
+func TestPurge_purgeOverWatermark(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	ctx := context.Background()
+
+	pageStats := func(pageCount, freePages int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"page_count", "page_size", "freelist_count"}).
+			AddRow(pageCount, 4096, freePages)
+	}
+
+	t.Run("should purge the cache above the watermark", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count`).
+			WillReturnRows(pageStats(90, 5))
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM cache`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
+		sqlMock.ExpectQuery(`DELETE FROM cache WHERE key IN`).
+			WithArgs(20).
+			WillReturnRows(keyRows(20))
+		sqlMock.ExpectCommit()
+
+		dbMock.EXPECT().Vacuum(ctx).Return(nil)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+				assert.NoError(t, fn(tx), "Expected no error during transaction execution")
+
+				return tx.Commit()
+			})
+
+		ch := &cache{
+			queries:        queries.New(db),
+			Database:       dbMock,
+			purgePercent:   0.2,
+			purgeWatermark: 0.8,
+			maxDBSize:      100 * 4096,
+			evictionPolicy: LRUPolicy{},
+		}
+
+		err := ch.purgeOverWatermark(ctx)
+
+		assert.NoError(t, err, "Expected no error while purging the cache")
+		assert.Equal(t, int64(20), ch.purgeCounters.evictedEntries.Load())
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should not purge the cache below the watermark", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count`).
+			WillReturnRows(pageStats(90, 20))
+
+		ch := &cache{
+			queries:        queries.New(db),
+			purgeWatermark: 0.8,
+			maxDBSize:      100 * 4096,
+		}
+
+		err := ch.purgeOverWatermark(ctx)
+
+		assert.NoError(t, err, "Expected no error below the watermark")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the page stats query fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count`).
+			WillReturnError(fmt.Errorf("mock pragma error"))
+
+		ch := &cache{queries: queries.New(db), purgeWatermark: 0.8}
+
+		err := ch.purgeOverWatermark(ctx)
+
+		assert.EqualError(t, err, "getting page stats: mock pragma error")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestPurge_incrementalVacuumCache(t *testing.T) {
+	t.Run("should schedule the incremental vacuum if it is enabled", func(t *testing.T) {
+		ch := &cache{
+			cron:                   cron.New(time.UTC),
+			syncInterval:           cron.EveryMinute,
+			incrementalVacuumPages: 64,
+		}
+
+		ch.incrementalVacuumCache(context.Background())
+
+		tasks := ch.cron.Tasks()
+		assert.Len(t, tasks, 1, "Expected the incremental vacuum to be scheduled")
+		assert.Equal(t, taskIncrementalVacuum, tasks[0].Name, "Expected the incremental vacuum task")
+	})
+
+	t.Run("should not schedule the incremental vacuum if it is disabled", func(t *testing.T) {
+		ch := &cache{
+			cron:         cron.New(time.UTC),
+			syncInterval: cron.EveryMinute,
+		}
+
+		ch.incrementalVacuumCache(context.Background())
+
+		assert.Empty(t, ch.cron.Tasks(), "Expected no task to be scheduled")
+	})
+}
+
+func TestPurge_vacuum(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should run a step of the incremental vacuum if it is enabled", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().IncrementalVacuum(ctx, 64).Return(nil).Once()
+
+		ch := &cache{Database: dbMock, incrementalVacuumPages: 64}
+
+		err := ch.vacuum(ctx)
+
+		assert.NoError(t, err, "Expected no error while vacuuming")
+	})
+
+	t.Run("should run a full vacuum by default", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().Vacuum(ctx).Return(nil).Once()
+
+		ch := &cache{Database: dbMock}
+
+		err := ch.vacuum(ctx)
+
+		assert.NoError(t, err, "Expected no error while vacuuming")
+	})
+}
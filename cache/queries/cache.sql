@@ -1,11 +1,12 @@
 -- name: GetValue :one
-SELECT value
+SELECT value, checksum
 FROM cache
 WHERE key = ? AND expires_at > ?;
 
 -- name: UpdateLastAccessedAt :exec
 UPDATE cache
-SET last_accessed_at = ?
+SET last_accessed_at = ?,
+    access_count = access_count + 1
 WHERE key = ?;
 
 
@@ -24,14 +25,27 @@ FROM cache
 ORDER BY last_accessed_at ASC
 LIMIT ?;
 
--- name: DeleteKeysByLimit :exec
+-- name: DeleteKeysByLimit :many
 DELETE FROM cache
 WHERE key IN (
     SELECT key
     FROM cache
+    WHERE pinned = 0
     ORDER BY last_accessed_at ASC
     LIMIT ?
-);
+)
+RETURNING key;
+
+-- name: DeleteLeastFrequentlyUsed :many
+DELETE FROM cache
+WHERE key IN (
+    SELECT key
+    FROM cache
+    WHERE pinned = 0
+    ORDER BY access_count ASC, last_accessed_at ASC
+    LIMIT ?
+)
+RETURNING key;
 
 -- name: CreateCacheDatabase :exec
 CREATE TABLE IF NOT EXISTS cache (
@@ -39,19 +53,341 @@ CREATE TABLE IF NOT EXISTS cache (
     value BLOB,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     expires_at TIMESTAMP NOT NULL,
-    last_accessed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    last_accessed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    access_count INTEGER NOT NULL DEFAULT 0,
+    pinned INTEGER NOT NULL DEFAULT 0,
+    ttl INTEGER NOT NULL DEFAULT 0,
+    checksum INTEGER,
+    updated_at TIMESTAMP,
+    metadata TEXT,
+    deleted_at TIMESTAMP
 );
 
 
 -- name: UpsertCache :exec
-INSERT INTO cache (key, value, expires_at, last_accessed_at)
-VALUES (?, ?, ?, ?)
+INSERT INTO cache (key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?4, ?)
+ON CONFLICT (key) DO UPDATE
+SET value = excluded.value,
+    expires_at = excluded.expires_at,
+    last_accessed_at = excluded.last_accessed_at,
+    ttl = excluded.ttl,
+    checksum = excluded.checksum,
+    updated_at = excluded.updated_at,
+    metadata = excluded.metadata,
+    pinned = CASE WHEN cache.deleted_at IS NULL THEN cache.pinned ELSE 0 END,
+    deleted_at = NULL,
+    version = cache.version + 1;
+
+
+-- name: DeleteExpiredCache :many
+DELETE FROM cache
+WHERE expires_at <= ? AND pinned = 0
+RETURNING key;
+
+-- name: GetValues :many
+SELECT key, value, expires_at, checksum
+FROM cache
+WHERE key IN (sqlc.slice('keys')) AND expires_at > ?;
+
+-- name: UpdateLastAccessedAtByKeys :exec
+UPDATE cache
+SET last_accessed_at = ?,
+    access_count = access_count + 1
+WHERE key IN (sqlc.slice('keys')) AND expires_at > ?;
+
+-- name: UpsertCacheIfExpired :one
+INSERT INTO cache (key, value, expires_at, last_accessed_at, ttl, checksum, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?4)
+ON CONFLICT (key) DO UPDATE
+SET value = excluded.value,
+    expires_at = excluded.expires_at,
+    last_accessed_at = excluded.last_accessed_at,
+    ttl = excluded.ttl,
+    checksum = excluded.checksum,
+    updated_at = excluded.updated_at,
+    metadata = NULL,
+    pinned = CASE WHEN cache.deleted_at IS NULL THEN cache.pinned ELSE 0 END,
+    deleted_at = NULL,
+    version = cache.version + 1
+WHERE cache.expires_at <= excluded.last_accessed_at
+RETURNING value;
+
+-- name: GetValueRange :one
+SELECT substr(value, sqlc.arg(start), sqlc.arg(length)) AS chunk
+FROM cache
+WHERE key = sqlc.arg(key) AND expires_at > sqlc.arg(expires_at);
+
+-- name: GetExpiresAt :one
+SELECT expires_at
+FROM cache
+WHERE key = ? AND expires_at > ?;
+
+-- name: UpdateExpiresAt :execrows
+UPDATE cache
+SET expires_at = sqlc.arg(expires_at),
+    ttl = sqlc.arg(ttl)
+WHERE key = sqlc.arg(key) AND expires_at > sqlc.arg(now);
+
+-- name: AppendValue :execrows
+UPDATE cache
+SET value = CAST(value || sqlc.arg(suffix) AS BLOB),
+    last_accessed_at = sqlc.arg(last_accessed_at),
+    checksum = sqlc.narg(checksum),
+    updated_at = sqlc.arg(last_accessed_at),
+    version = version + 1
+WHERE key = sqlc.arg(key) AND expires_at > sqlc.arg(now)
+    AND length(value) + length(sqlc.arg(suffix)) <= sqlc.arg(max_size);
+
+-- name: ListKeys :many
+SELECT key
+FROM cache
+WHERE key GLOB sqlc.arg(pattern) AND expires_at > sqlc.arg(now)
+ORDER BY key;
+
+-- name: DeleteAllCache :exec
+DELETE FROM cache;
+
+-- name: CountLiveEntries :one
+SELECT COUNT(*)
+FROM cache
+WHERE expires_at > ?;
+
+-- name: GetCacheUsage :one
+SELECT COUNT(*) AS entries,
+       CAST(COALESCE(SUM(LENGTH(value)), 0) AS INTEGER) AS value_bytes
+FROM cache
+WHERE expires_at > ?;
+
+-- name: GetPageStats :one
+SELECT page_count, page_size, freelist_count
+FROM pragma_page_count(), pragma_page_size(), pragma_freelist_count();
+
+-- name: CountLiveEntriesInRange :one
+SELECT COUNT(*)
+FROM cache
+WHERE key >= sqlc.arg(key_from) AND key < sqlc.arg(key_to) AND expires_at > sqlc.arg(now);
+
+-- name: DeleteCacheInRange :exec
+DELETE FROM cache
+WHERE key >= sqlc.arg(key_from) AND key < sqlc.arg(key_to);
+
+-- name: GetCacheUsageInRange :one
+SELECT COUNT(*) AS entries,
+       CAST(COALESCE(SUM(LENGTH(value)), 0) AS INTEGER) AS value_bytes
+FROM cache
+WHERE key >= sqlc.arg(key_from) AND key < sqlc.arg(key_to) AND expires_at > sqlc.arg(now);
+
+-- name: GetValueWithVersion :one
+SELECT value, version, checksum
+FROM cache
+WHERE key = ? AND expires_at > ?;
+
+-- name: UpdateCacheIfVersion :execrows
+UPDATE cache
+SET value = ?,
+    expires_at = ?,
+    last_accessed_at = ?,
+    ttl = ?,
+    checksum = ?,
+    updated_at = ?3,
+    version = version + 1
+WHERE key = ? AND version = ? AND expires_at > sqlc.arg(now);
+
+-- name: GetEntry :one
+SELECT value, expires_at, checksum
+FROM cache
+WHERE key = ? AND expires_at > ?;
+
+-- name: GetTotalUsage :one
+SELECT COUNT(*) AS entries,
+       CAST(COALESCE(SUM(LENGTH(value)), 0) AS INTEGER) AS value_bytes
+FROM cache;
+
+-- name: SetPinned :execrows
+UPDATE cache
+SET pinned = sqlc.arg(pinned)
+WHERE key = sqlc.arg(key) AND expires_at > sqlc.arg(now);
+
+-- name: AddAccess :exec
+UPDATE cache
+SET last_accessed_at = sqlc.arg(last_accessed_at),
+    access_count = access_count + sqlc.arg(reads)
+WHERE key = sqlc.arg(key);
+
+-- name: ListLiveEntries :many
+SELECT key, value, expires_at
+FROM cache
+WHERE key >= sqlc.arg(key_from)
+  AND (sqlc.arg(key_to) = '' OR key < sqlc.arg(key_to))
+  AND expires_at > sqlc.arg(now)
+ORDER BY key
+LIMIT sqlc.arg(limit);
+
+-- name: RenameKey :execrows
+UPDATE OR REPLACE cache
+SET key = sqlc.arg(new_key)
+WHERE key = sqlc.arg(old_key) AND expires_at > sqlc.arg(now);
+
+-- name: DeleteKeysByPattern :many
+DELETE FROM cache
+WHERE key GLOB sqlc.arg(pattern)
+RETURNING key;
+
+-- name: LockCache :exec
+UPDATE cache
+SET key = key
+WHERE 0;
+
+-- name: CountExpiredKey :one
+SELECT COUNT(*)
+FROM cache
+WHERE key = ? AND expires_at <= ? AND deleted_at IS NULL;
+
+-- name: DeleteExpiredKey :execrows
+DELETE FROM cache
+WHERE key = ? AND expires_at <= ? AND pinned = 0;
+
+-- name: GetEntryTTL :one
+SELECT ttl
+FROM cache
+WHERE key = ? AND expires_at > ?;
+
+-- name: SlideExpiresAt :exec
+UPDATE cache
+SET expires_at = sqlc.arg(expires_at)
+WHERE key = sqlc.arg(key) AND ttl = sqlc.arg(ttl) AND expires_at > sqlc.arg(now);
+
+-- name: GetTotalUsageInRange :one
+SELECT COUNT(*) AS entries,
+       CAST(COALESCE(SUM(LENGTH(value)), 0) AS INTEGER) AS value_bytes
+FROM cache
+WHERE key >= sqlc.arg(key_from) AND (sqlc.arg(key_to) = '' OR key < sqlc.arg(key_to));
+
+-- name: DeleteKeysByLimitInRange :many
+DELETE FROM cache
+WHERE key IN (
+    SELECT key
+    FROM cache
+    WHERE key >= sqlc.arg(key_from)
+      AND (sqlc.arg(key_to) = '' OR key < sqlc.arg(key_to))
+      AND pinned = 0
+    ORDER BY last_accessed_at ASC
+    LIMIT sqlc.arg(limit)
+)
+RETURNING key;
+
+-- name: GetStaleEntry :one
+SELECT value, checksum, updated_at, created_at
+FROM cache
+WHERE key = ? AND deleted_at IS NULL;
+
+-- name: UpsertCacheByBlobKey :exec
+INSERT INTO cache (key, value, expires_at, last_accessed_at, ttl, checksum, updated_at)
+VALUES (CAST(sqlc.arg(key) AS BLOB), ?, ?, ?, ?, ?, ?4)
 ON CONFLICT (key) DO UPDATE
 SET value = excluded.value,
     expires_at = excluded.expires_at,
-    last_accessed_at = excluded.last_accessed_at;
+    last_accessed_at = excluded.last_accessed_at,
+    ttl = excluded.ttl,
+    checksum = excluded.checksum,
+    updated_at = excluded.updated_at,
+    metadata = NULL,
+    pinned = CASE WHEN cache.deleted_at IS NULL THEN cache.pinned ELSE 0 END,
+    deleted_at = NULL,
+    version = cache.version + 1;
+
+-- name: GetEntryByBlobKey :one
+SELECT value, checksum, expires_at
+FROM cache
+WHERE key = CAST(sqlc.arg(key) AS BLOB);
+
+-- name: UpdateLastAccessedAtByBlobKey :exec
+UPDATE cache
+SET last_accessed_at = ?,
+    access_count = access_count + 1
+WHERE key = CAST(sqlc.arg(key) AS BLOB);
 
+-- name: DeleteKeyByBlobKey :exec
+DELETE FROM cache
+WHERE key = CAST(sqlc.arg(key) AS BLOB);
+
+-- name: GetEntryWithMetadata :one
+SELECT value, checksum, metadata, expires_at, version
+FROM cache
+WHERE key = ? AND expires_at > ?;
+
+-- name: CreateDependenciesTable :exec
+CREATE TABLE IF NOT EXISTS cache_dependencies (
+    parent TEXT NOT NULL,
+    child TEXT NOT NULL,
+    PRIMARY KEY (parent, child)
+) WITHOUT ROWID;
+
+-- name: AddDependency :exec
+INSERT INTO cache_dependencies (parent, child)
+VALUES (?, ?)
+ON CONFLICT (parent, child) DO NOTHING;
+
+-- name: DeleteDependents :many
+WITH RECURSIVE dependents(key) AS (
+    SELECT child FROM cache_dependencies WHERE parent = sqlc.arg(key)
+    UNION
+    SELECT cache_dependencies.child
+    FROM cache_dependencies
+    JOIN dependents ON cache_dependencies.parent = dependents.key
+)
+DELETE FROM cache
+WHERE key IN (SELECT key FROM dependents) AND key <> sqlc.arg(key)
+RETURNING key;
+
+-- name: DeleteDependencies :exec
+WITH RECURSIVE dependents(key) AS (
+    VALUES (sqlc.arg(key))
+    UNION
+    SELECT cache_dependencies.child
+    FROM cache_dependencies
+    JOIN dependents ON cache_dependencies.parent = dependents.key
+)
+DELETE FROM cache_dependencies
+WHERE parent IN (SELECT key FROM dependents)
+   OR (child IN (SELECT key FROM dependents) AND child <> sqlc.arg(key));
+
+-- name: DeleteParents :exec
+DELETE FROM cache_dependencies
+WHERE child = ?;
+
+-- name: DeleteAllDependencies :exec
+DELETE FROM cache_dependencies;
+
+-- name: TombstoneKey :exec
+UPDATE cache
+SET value = NULL,
+    checksum = NULL,
+    metadata = NULL,
+    pinned = 1,
+    expires_at = sqlc.arg(now),
+    deleted_at = sqlc.arg(now)
+WHERE key = sqlc.arg(key) AND deleted_at IS NULL;
+
+-- name: TombstoneKeysByPattern :many
+UPDATE cache
+SET value = NULL,
+    checksum = NULL,
+    metadata = NULL,
+    pinned = 1,
+    expires_at = sqlc.arg(now),
+    deleted_at = sqlc.arg(now)
+WHERE key GLOB sqlc.arg(pattern) AND deleted_at IS NULL
+RETURNING key;
+
+-- name: ListTombstones :many
+SELECT key, deleted_at
+FROM cache
+WHERE deleted_at > ?
+ORDER BY deleted_at, key;
 
--- name: DeleteExpiredCache :exec
+-- name: PurgeTombstones :execrows
 DELETE FROM cache
-WHERE expires_at <= ?;
\ No newline at end of file
+WHERE deleted_at <= ?;
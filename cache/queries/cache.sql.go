@@ -7,87 +7,1028 @@ package queries
 
 import (
 	"context"
+	"database/sql"
+	"strings"
 	"time"
 )
 
+const addAccess = `-- name: AddAccess :exec
+UPDATE cache
+SET last_accessed_at = ?,
+    access_count = access_count + ?
+WHERE key = ?
+`
+
+type AddAccessParams struct {
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+	Key            string    `json:"key"`
+	Reads          int64     `json:"reads"`
+}
+
+func (q *Queries) AddAccess(ctx context.Context, arg AddAccessParams) error {
+	_, err := q.exec(ctx, q.addAccessStmt, addAccess, arg.LastAccessedAt, arg.Reads, arg.Key)
+	return err
+}
+
+const addDependency = `-- name: AddDependency :exec
+INSERT INTO cache_dependencies (parent, child)
+VALUES (?, ?)
+ON CONFLICT (parent, child) DO NOTHING
+`
+
+type AddDependencyParams struct {
+	Parent string `json:"parent"`
+	Child  string `json:"child"`
+}
+
+func (q *Queries) AddDependency(ctx context.Context, arg AddDependencyParams) error {
+	_, err := q.exec(ctx, q.addDependencyStmt, addDependency, arg.Parent, arg.Child)
+	return err
+}
+
+const appendValue = `-- name: AppendValue :execrows
+UPDATE cache
+SET value = CAST(value || ? AS BLOB),
+    last_accessed_at = ?,
+    checksum = ?,
+    updated_at = ?2,
+    version = version + 1
+WHERE key = ? AND expires_at > ?
+    AND length(value) + length(?1) <= ?
+`
+
+type AppendValueParams struct {
+	LastAccessedAt time.Time     `json:"last_accessed_at"`
+	Now            time.Time     `json:"now"`
+	Key            string        `json:"key"`
+	Suffix         []byte        `json:"suffix"`
+	Checksum       sql.NullInt64 `json:"checksum"`
+	MaxSize        int64         `json:"max_size"`
+}
+
+func (q *Queries) AppendValue(ctx context.Context, arg AppendValueParams) (int64, error) {
+	result, err := q.exec(ctx, q.appendValueStmt, appendValue,
+		arg.Suffix,
+		arg.LastAccessedAt,
+		arg.Checksum,
+		arg.Key,
+		arg.Now,
+		arg.MaxSize,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const countCacheEntries = `-- name: CountCacheEntries :one
 SELECT COUNT(*)
 FROM cache
 `
 
-func (q *Queries) CountCacheEntries(ctx context.Context) (int64, error) {
-	row := q.queryRow(ctx, q.countCacheEntriesStmt, countCacheEntries)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+func (q *Queries) CountCacheEntries(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countCacheEntriesStmt, countCacheEntries)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countExpiredKey = `-- name: CountExpiredKey :one
+SELECT COUNT(*)
+FROM cache
+WHERE key = ? AND expires_at <= ? AND deleted_at IS NULL
+`
+
+type CountExpiredKeyParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+}
+
+func (q *Queries) CountExpiredKey(ctx context.Context, arg CountExpiredKeyParams) (int64, error) {
+	row := q.queryRow(ctx, q.countExpiredKeyStmt, countExpiredKey, arg.Key, arg.ExpiresAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countLiveEntries = `-- name: CountLiveEntries :one
+SELECT COUNT(*)
+FROM cache
+WHERE expires_at > ?
+`
+
+func (q *Queries) CountLiveEntries(ctx context.Context, expiresAt time.Time) (int64, error) {
+	row := q.queryRow(ctx, q.countLiveEntriesStmt, countLiveEntries, expiresAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countLiveEntriesInRange = `-- name: CountLiveEntriesInRange :one
+SELECT COUNT(*)
+FROM cache
+WHERE key >= ? AND key < ? AND expires_at > ?
+`
+
+type CountLiveEntriesInRangeParams struct {
+	Now     time.Time `json:"now"`
+	KeyFrom string    `json:"key_from"`
+	KeyTo   string    `json:"key_to"`
+}
+
+func (q *Queries) CountLiveEntriesInRange(ctx context.Context, arg CountLiveEntriesInRangeParams) (int64, error) {
+	row := q.queryRow(ctx, q.countLiveEntriesInRangeStmt, countLiveEntriesInRange, arg.KeyFrom, arg.KeyTo, arg.Now)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createCacheDatabase = `-- name: CreateCacheDatabase :exec
+CREATE TABLE IF NOT EXISTS cache (
+    key TEXT PRIMARY KEY,
+    value BLOB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    last_accessed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    access_count INTEGER NOT NULL DEFAULT 0,
+    pinned INTEGER NOT NULL DEFAULT 0,
+    ttl INTEGER NOT NULL DEFAULT 0,
+    checksum INTEGER,
+    updated_at TIMESTAMP,
+    metadata TEXT,
+    deleted_at TIMESTAMP
+)
+`
+
+func (q *Queries) CreateCacheDatabase(ctx context.Context) error {
+	_, err := q.exec(ctx, q.createCacheDatabaseStmt, createCacheDatabase)
+	return err
+}
+
+const createDependenciesTable = `-- name: CreateDependenciesTable :exec
+CREATE TABLE IF NOT EXISTS cache_dependencies (
+    parent TEXT NOT NULL,
+    child TEXT NOT NULL,
+    PRIMARY KEY (parent, child)
+) WITHOUT ROWID
+`
+
+func (q *Queries) CreateDependenciesTable(ctx context.Context) error {
+	_, err := q.exec(ctx, q.createDependenciesTableStmt, createDependenciesTable)
+	return err
+}
+
+const deleteAllCache = `-- name: DeleteAllCache :exec
+DELETE FROM cache
+`
+
+func (q *Queries) DeleteAllCache(ctx context.Context) error {
+	_, err := q.exec(ctx, q.deleteAllCacheStmt, deleteAllCache)
+	return err
+}
+
+const deleteAllDependencies = `-- name: DeleteAllDependencies :exec
+DELETE FROM cache_dependencies
+`
+
+func (q *Queries) DeleteAllDependencies(ctx context.Context) error {
+	_, err := q.exec(ctx, q.deleteAllDependenciesStmt, deleteAllDependencies)
+	return err
+}
+
+const deleteCacheInRange = `-- name: DeleteCacheInRange :exec
+DELETE FROM cache
+WHERE key >= ? AND key < ?
+`
+
+type DeleteCacheInRangeParams struct {
+	KeyFrom string `json:"key_from"`
+	KeyTo   string `json:"key_to"`
+}
+
+func (q *Queries) DeleteCacheInRange(ctx context.Context, arg DeleteCacheInRangeParams) error {
+	_, err := q.exec(ctx, q.deleteCacheInRangeStmt, deleteCacheInRange, arg.KeyFrom, arg.KeyTo)
+	return err
+}
+
+const deleteDependencies = `-- name: DeleteDependencies :exec
+WITH RECURSIVE dependents(key) AS (
+    VALUES (?1)
+    UNION
+    SELECT cache_dependencies.child
+    FROM cache_dependencies
+    JOIN dependents ON cache_dependencies.parent = dependents.key
+)
+DELETE FROM cache_dependencies
+WHERE parent IN (SELECT key FROM dependents)
+   OR (child IN (SELECT key FROM dependents) AND child <> ?1)
+`
+
+func (q *Queries) DeleteDependencies(ctx context.Context, key string) error {
+	_, err := q.exec(ctx, q.deleteDependenciesStmt, deleteDependencies, key)
+	return err
+}
+
+const deleteDependents = `-- name: DeleteDependents :many
+WITH RECURSIVE dependents(key) AS (
+    SELECT child FROM cache_dependencies WHERE parent = ?1
+    UNION
+    SELECT cache_dependencies.child
+    FROM cache_dependencies
+    JOIN dependents ON cache_dependencies.parent = dependents.key
+)
+DELETE FROM cache
+WHERE key IN (SELECT key FROM dependents) AND key <> ?1
+RETURNING key
+`
+
+func (q *Queries) DeleteDependents(ctx context.Context, key string) ([]string, error) {
+	rows, err := q.query(ctx, q.deleteDependentsStmt, deleteDependents, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteExpiredCache = `-- name: DeleteExpiredCache :many
+DELETE FROM cache
+WHERE expires_at <= ? AND pinned = 0
+RETURNING key
+`
+
+func (q *Queries) DeleteExpiredCache(ctx context.Context, expiresAt time.Time) ([]string, error) {
+	rows, err := q.query(ctx, q.deleteExpiredCacheStmt, deleteExpiredCache, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteExpiredKey = `-- name: DeleteExpiredKey :execrows
+DELETE FROM cache
+WHERE key = ? AND expires_at <= ? AND pinned = 0
+`
+
+type DeleteExpiredKeyParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+}
+
+func (q *Queries) DeleteExpiredKey(ctx context.Context, arg DeleteExpiredKeyParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredKeyStmt, deleteExpiredKey, arg.Key, arg.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteKey = `-- name: DeleteKey :exec
+DELETE FROM cache
+WHERE key = ?
+`
+
+func (q *Queries) DeleteKey(ctx context.Context, key string) error {
+	_, err := q.exec(ctx, q.deleteKeyStmt, deleteKey, key)
+	return err
+}
+
+const deleteKeyByBlobKey = `-- name: DeleteKeyByBlobKey :exec
+DELETE FROM cache
+WHERE key = CAST(? AS BLOB)
+`
+
+func (q *Queries) DeleteKeyByBlobKey(ctx context.Context, key []byte) error {
+	_, err := q.exec(ctx, q.deleteKeyByBlobKeyStmt, deleteKeyByBlobKey, key)
+	return err
+}
+
+const deleteKeysByLimit = `-- name: DeleteKeysByLimit :many
+DELETE FROM cache
+WHERE key IN (
+    SELECT key
+    FROM cache
+    WHERE pinned = 0
+    ORDER BY last_accessed_at ASC
+    LIMIT ?
+)
+RETURNING key
+`
+
+func (q *Queries) DeleteKeysByLimit(ctx context.Context, limit int64) ([]string, error) {
+	rows, err := q.query(ctx, q.deleteKeysByLimitStmt, deleteKeysByLimit, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteKeysByLimitInRange = `-- name: DeleteKeysByLimitInRange :many
+DELETE FROM cache
+WHERE key IN (
+    SELECT key
+    FROM cache
+    WHERE key >= ?
+      AND (? = '' OR key < ?)
+      AND pinned = 0
+    ORDER BY last_accessed_at ASC
+    LIMIT ?
+)
+RETURNING key
+`
+
+type DeleteKeysByLimitInRangeParams struct {
+	KeyFrom string `json:"key_from"`
+	KeyTo   string `json:"key_to"`
+	Limit   int64  `json:"limit"`
+}
+
+func (q *Queries) DeleteKeysByLimitInRange(ctx context.Context, arg DeleteKeysByLimitInRangeParams) ([]string, error) {
+	rows, err := q.query(ctx, q.deleteKeysByLimitInRangeStmt, deleteKeysByLimitInRange,
+		arg.KeyFrom,
+		arg.KeyTo,
+		arg.KeyTo,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteKeysByPattern = `-- name: DeleteKeysByPattern :many
+DELETE FROM cache
+WHERE key GLOB ?
+RETURNING key
+`
+
+func (q *Queries) DeleteKeysByPattern(ctx context.Context, pattern string) ([]string, error) {
+	rows, err := q.query(ctx, q.deleteKeysByPatternStmt, deleteKeysByPattern, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteLeastFrequentlyUsed = `-- name: DeleteLeastFrequentlyUsed :many
+DELETE FROM cache
+WHERE key IN (
+    SELECT key
+    FROM cache
+    WHERE pinned = 0
+    ORDER BY access_count ASC, last_accessed_at ASC
+    LIMIT ?
+)
+RETURNING key
+`
+
+func (q *Queries) DeleteLeastFrequentlyUsed(ctx context.Context, limit int64) ([]string, error) {
+	rows, err := q.query(ctx, q.deleteLeastFrequentlyUsedStmt, deleteLeastFrequentlyUsed, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteParents = `-- name: DeleteParents :exec
+DELETE FROM cache_dependencies
+WHERE child = ?
+`
+
+func (q *Queries) DeleteParents(ctx context.Context, child string) error {
+	_, err := q.exec(ctx, q.deleteParentsStmt, deleteParents, child)
+	return err
+}
+
+const getCacheUsage = `-- name: GetCacheUsage :one
+SELECT COUNT(*) AS entries,
+       CAST(COALESCE(SUM(LENGTH(value)), 0) AS INTEGER) AS value_bytes
+FROM cache
+WHERE expires_at > ?
+`
+
+type GetCacheUsageRow struct {
+	Entries    int64 `json:"entries"`
+	ValueBytes int64 `json:"value_bytes"`
+}
+
+func (q *Queries) GetCacheUsage(ctx context.Context, expiresAt time.Time) (GetCacheUsageRow, error) {
+	row := q.queryRow(ctx, q.getCacheUsageStmt, getCacheUsage, expiresAt)
+	var i GetCacheUsageRow
+	err := row.Scan(&i.Entries, &i.ValueBytes)
+	return i, err
+}
+
+const getCacheUsageInRange = `-- name: GetCacheUsageInRange :one
+SELECT COUNT(*) AS entries,
+       CAST(COALESCE(SUM(LENGTH(value)), 0) AS INTEGER) AS value_bytes
+FROM cache
+WHERE key >= ? AND key < ? AND expires_at > ?
+`
+
+type GetCacheUsageInRangeParams struct {
+	Now     time.Time `json:"now"`
+	KeyFrom string    `json:"key_from"`
+	KeyTo   string    `json:"key_to"`
+}
+
+type GetCacheUsageInRangeRow struct {
+	Entries    int64 `json:"entries"`
+	ValueBytes int64 `json:"value_bytes"`
+}
+
+func (q *Queries) GetCacheUsageInRange(ctx context.Context, arg GetCacheUsageInRangeParams) (GetCacheUsageInRangeRow, error) {
+	row := q.queryRow(ctx, q.getCacheUsageInRangeStmt, getCacheUsageInRange, arg.KeyFrom, arg.KeyTo, arg.Now)
+	var i GetCacheUsageInRangeRow
+	err := row.Scan(&i.Entries, &i.ValueBytes)
+	return i, err
+}
+
+const getEntry = `-- name: GetEntry :one
+SELECT value, expires_at, checksum
+FROM cache
+WHERE key = ? AND expires_at > ?
+`
+
+type GetEntryParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+}
+
+type GetEntryRow struct {
+	ExpiresAt time.Time     `json:"expires_at"`
+	Value     []byte        `json:"value"`
+	Checksum  sql.NullInt64 `json:"checksum"`
+}
+
+func (q *Queries) GetEntry(ctx context.Context, arg GetEntryParams) (GetEntryRow, error) {
+	row := q.queryRow(ctx, q.getEntryStmt, getEntry, arg.Key, arg.ExpiresAt)
+	var i GetEntryRow
+	err := row.Scan(&i.Value, &i.ExpiresAt, &i.Checksum)
+	return i, err
+}
+
+const getEntryByBlobKey = `-- name: GetEntryByBlobKey :one
+SELECT value, checksum, expires_at
+FROM cache
+WHERE key = CAST(? AS BLOB)
+`
+
+type GetEntryByBlobKeyRow struct {
+	ExpiresAt time.Time     `json:"expires_at"`
+	Value     []byte        `json:"value"`
+	Checksum  sql.NullInt64 `json:"checksum"`
+}
+
+func (q *Queries) GetEntryByBlobKey(ctx context.Context, key []byte) (GetEntryByBlobKeyRow, error) {
+	row := q.queryRow(ctx, q.getEntryByBlobKeyStmt, getEntryByBlobKey, key)
+	var i GetEntryByBlobKeyRow
+	err := row.Scan(&i.Value, &i.Checksum, &i.ExpiresAt)
+	return i, err
+}
+
+const getEntryTTL = `-- name: GetEntryTTL :one
+SELECT ttl
+FROM cache
+WHERE key = ? AND expires_at > ?
+`
+
+type GetEntryTTLParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+}
+
+func (q *Queries) GetEntryTTL(ctx context.Context, arg GetEntryTTLParams) (int64, error) {
+	row := q.queryRow(ctx, q.getEntryTTLStmt, getEntryTTL, arg.Key, arg.ExpiresAt)
+	var ttl int64
+	err := row.Scan(&ttl)
+	return ttl, err
+}
+
+const getEntryWithMetadata = `-- name: GetEntryWithMetadata :one
+SELECT value, checksum, metadata, expires_at, version
+FROM cache
+WHERE key = ? AND expires_at > ?
+`
+
+type GetEntryWithMetadataParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+}
+
+type GetEntryWithMetadataRow struct {
+	ExpiresAt time.Time      `json:"expires_at"`
+	Value     []byte         `json:"value"`
+	Checksum  sql.NullInt64  `json:"checksum"`
+	Metadata  sql.NullString `json:"metadata"`
+	Version   int64          `json:"version"`
+}
+
+func (q *Queries) GetEntryWithMetadata(
+	ctx context.Context,
+	arg GetEntryWithMetadataParams,
+) (GetEntryWithMetadataRow, error) {
+	row := q.queryRow(ctx, q.getEntryWithMetadataStmt, getEntryWithMetadata, arg.Key, arg.ExpiresAt)
+	var i GetEntryWithMetadataRow
+	err := row.Scan(
+		&i.Value,
+		&i.Checksum,
+		&i.Metadata,
+		&i.ExpiresAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getExpiresAt = `-- name: GetExpiresAt :one
+SELECT expires_at
+FROM cache
+WHERE key = ? AND expires_at > ?
+`
+
+type GetExpiresAtParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+}
+
+func (q *Queries) GetExpiresAt(ctx context.Context, arg GetExpiresAtParams) (time.Time, error) {
+	row := q.queryRow(ctx, q.getExpiresAtStmt, getExpiresAt, arg.Key, arg.ExpiresAt)
+	var expires_at time.Time
+	err := row.Scan(&expires_at)
+	return expires_at, err
+}
+
+const getPageStats = `-- name: GetPageStats :one
+SELECT page_count, page_size, freelist_count
+FROM pragma_page_count(), pragma_page_size(), pragma_freelist_count()
+`
+
+type GetPageStatsRow struct {
+	PageCount     int64 `json:"page_count"`
+	PageSize      int64 `json:"page_size"`
+	FreelistCount int64 `json:"freelist_count"`
+}
+
+func (q *Queries) GetPageStats(ctx context.Context) (GetPageStatsRow, error) {
+	row := q.queryRow(ctx, q.getPageStatsStmt, getPageStats)
+	var i GetPageStatsRow
+	err := row.Scan(&i.PageCount, &i.PageSize, &i.FreelistCount)
+	return i, err
+}
+
+const getStaleEntry = `-- name: GetStaleEntry :one
+SELECT value, checksum, updated_at, created_at
+FROM cache
+WHERE key = ? AND deleted_at IS NULL
+`
+
+type GetStaleEntryRow struct {
+	CreatedAt time.Time     `json:"created_at"`
+	Value     []byte        `json:"value"`
+	Checksum  sql.NullInt64 `json:"checksum"`
+	UpdatedAt sql.NullTime  `json:"updated_at"`
+}
+
+func (q *Queries) GetStaleEntry(ctx context.Context, key string) (GetStaleEntryRow, error) {
+	row := q.queryRow(ctx, q.getStaleEntryStmt, getStaleEntry, key)
+	var i GetStaleEntryRow
+	err := row.Scan(
+		&i.Value,
+		&i.Checksum,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTotalUsage = `-- name: GetTotalUsage :one
+SELECT COUNT(*) AS entries,
+       CAST(COALESCE(SUM(LENGTH(value)), 0) AS INTEGER) AS value_bytes
+FROM cache
+`
+
+type GetTotalUsageRow struct {
+	Entries    int64 `json:"entries"`
+	ValueBytes int64 `json:"value_bytes"`
+}
+
+func (q *Queries) GetTotalUsage(ctx context.Context) (GetTotalUsageRow, error) {
+	row := q.queryRow(ctx, q.getTotalUsageStmt, getTotalUsage)
+	var i GetTotalUsageRow
+	err := row.Scan(&i.Entries, &i.ValueBytes)
+	return i, err
+}
+
+const getTotalUsageInRange = `-- name: GetTotalUsageInRange :one
+SELECT COUNT(*) AS entries,
+       CAST(COALESCE(SUM(LENGTH(value)), 0) AS INTEGER) AS value_bytes
+FROM cache
+WHERE key >= ? AND (? = '' OR key < ?)
+`
+
+type GetTotalUsageInRangeParams struct {
+	KeyFrom string `json:"key_from"`
+	KeyTo   string `json:"key_to"`
+}
+
+type GetTotalUsageInRangeRow struct {
+	Entries    int64 `json:"entries"`
+	ValueBytes int64 `json:"value_bytes"`
+}
+
+func (q *Queries) GetTotalUsageInRange(ctx context.Context, arg GetTotalUsageInRangeParams) (GetTotalUsageInRangeRow, error) {
+	row := q.queryRow(ctx, q.getTotalUsageInRangeStmt, getTotalUsageInRange, arg.KeyFrom, arg.KeyTo, arg.KeyTo)
+	var i GetTotalUsageInRangeRow
+	err := row.Scan(&i.Entries, &i.ValueBytes)
+	return i, err
+}
+
+const getValue = `-- name: GetValue :one
+SELECT value, checksum
+FROM cache
+WHERE key = ? AND expires_at > ?
+`
+
+type GetValueParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+}
+
+type GetValueRow struct {
+	Value    []byte        `json:"value"`
+	Checksum sql.NullInt64 `json:"checksum"`
+}
+
+func (q *Queries) GetValue(ctx context.Context, arg GetValueParams) (GetValueRow, error) {
+	row := q.queryRow(ctx, q.getValueStmt, getValue, arg.Key, arg.ExpiresAt)
+	var i GetValueRow
+	err := row.Scan(&i.Value, &i.Checksum)
+	return i, err
+}
+
+const getValueRange = `-- name: GetValueRange :one
+SELECT substr(value, ?, ?) AS chunk
+FROM cache
+WHERE key = ? AND expires_at > ?
+`
+
+type GetValueRangeParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+	Start     int64     `json:"start"`
+	Length    int64     `json:"length"`
+}
+
+func (q *Queries) GetValueRange(ctx context.Context, arg GetValueRangeParams) ([]byte, error) {
+	row := q.queryRow(ctx, q.getValueRangeStmt, getValueRange,
+		arg.Start,
+		arg.Length,
+		arg.Key,
+		arg.ExpiresAt,
+	)
+	var chunk []byte
+	err := row.Scan(&chunk)
+	return chunk, err
+}
+
+const getValueWithVersion = `-- name: GetValueWithVersion :one
+SELECT value, version, checksum
+FROM cache
+WHERE key = ? AND expires_at > ?
+`
+
+type GetValueWithVersionParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+}
+
+type GetValueWithVersionRow struct {
+	Value    []byte        `json:"value"`
+	Version  int64         `json:"version"`
+	Checksum sql.NullInt64 `json:"checksum"`
+}
+
+func (q *Queries) GetValueWithVersion(ctx context.Context, arg GetValueWithVersionParams) (GetValueWithVersionRow, error) {
+	row := q.queryRow(ctx, q.getValueWithVersionStmt, getValueWithVersion, arg.Key, arg.ExpiresAt)
+	var i GetValueWithVersionRow
+	err := row.Scan(&i.Value, &i.Version, &i.Checksum)
+	return i, err
+}
+
+const getValues = `-- name: GetValues :many
+SELECT key, value, expires_at, checksum
+FROM cache
+WHERE key IN (/*SLICE:keys*/?) AND expires_at > ?
+`
+
+type GetValuesParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Keys      []string  `json:"keys"`
+}
+
+type GetValuesRow struct {
+	Key       string        `json:"key"`
+	Value     []byte        `json:"value"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	Checksum  sql.NullInt64 `json:"checksum"`
+}
+
+func (q *Queries) GetValues(ctx context.Context, arg GetValuesParams) ([]GetValuesRow, error) {
+	query := getValues
+	var queryParams []interface{}
+	if len(arg.Keys) > 0 {
+		for _, v := range arg.Keys {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:keys*/?", strings.Repeat(",?", len(arg.Keys))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:keys*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.ExpiresAt)
+	rows, err := q.query(ctx, nil, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetValuesRow
+	for rows.Next() {
+		var i GetValuesRow
+		if err := rows.Scan(
+			&i.Key,
+			&i.Value,
+			&i.ExpiresAt,
+			&i.Checksum,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listKeys = `-- name: ListKeys :many
+SELECT key
+FROM cache
+WHERE key GLOB ? AND expires_at > ?
+ORDER BY key
+`
+
+type ListKeysParams struct {
+	Now     time.Time `json:"now"`
+	Pattern string    `json:"pattern"`
 }
 
-const createCacheDatabase = `-- name: CreateCacheDatabase :exec
-CREATE TABLE IF NOT EXISTS cache (
-    key TEXT PRIMARY KEY,
-    value BLOB,
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    expires_at TIMESTAMP NOT NULL,
-    last_accessed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-)
+func (q *Queries) ListKeys(ctx context.Context, arg ListKeysParams) ([]string, error) {
+	rows, err := q.query(ctx, q.listKeysStmt, listKeys, arg.Pattern, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLiveEntries = `-- name: ListLiveEntries :many
+SELECT key, value, expires_at
+FROM cache
+WHERE key >= ?
+  AND (? = '' OR key < ?)
+  AND expires_at > ?
+ORDER BY key
+LIMIT ?
 `
 
-func (q *Queries) CreateCacheDatabase(ctx context.Context) error {
-	_, err := q.exec(ctx, q.createCacheDatabaseStmt, createCacheDatabase)
-	return err
+type ListLiveEntriesParams struct {
+	Now     time.Time `json:"now"`
+	KeyFrom string    `json:"key_from"`
+	KeyTo   string    `json:"key_to"`
+	Limit   int64     `json:"limit"`
 }
 
-const deleteExpiredCache = `-- name: DeleteExpiredCache :exec
-DELETE FROM cache
-WHERE expires_at <= ?
+type ListLiveEntriesRow struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Key       string    `json:"key"`
+	Value     []byte    `json:"value"`
+}
+
+func (q *Queries) ListLiveEntries(ctx context.Context, arg ListLiveEntriesParams) ([]ListLiveEntriesRow, error) {
+	rows, err := q.query(ctx, q.listLiveEntriesStmt, listLiveEntries,
+		arg.KeyFrom,
+		arg.KeyTo,
+		arg.KeyTo,
+		arg.Now,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLiveEntriesRow
+	for rows.Next() {
+		var i ListLiveEntriesRow
+		if err := rows.Scan(&i.Key, &i.Value, &i.ExpiresAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTombstones = `-- name: ListTombstones :many
+SELECT key, deleted_at
+FROM cache
+WHERE deleted_at > ?
+ORDER BY deleted_at, key
 `
 
-func (q *Queries) DeleteExpiredCache(ctx context.Context, expiresAt time.Time) error {
-	_, err := q.exec(ctx, q.deleteExpiredCacheStmt, deleteExpiredCache, expiresAt)
-	return err
+type ListTombstonesRow struct {
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	Key       string       `json:"key"`
 }
 
-const deleteKey = `-- name: DeleteKey :exec
-DELETE FROM cache
-WHERE key = ?
+func (q *Queries) ListTombstones(
+	ctx context.Context,
+	deletedAt sql.NullTime,
+) ([]ListTombstonesRow, error) {
+	rows, err := q.query(ctx, q.listTombstonesStmt, listTombstones, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTombstonesRow
+	for rows.Next() {
+		var i ListTombstonesRow
+		if err := rows.Scan(&i.Key, &i.DeletedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockCache = `-- name: LockCache :exec
+UPDATE cache
+SET key = key
+WHERE 0
 `
 
-func (q *Queries) DeleteKey(ctx context.Context, key string) error {
-	_, err := q.exec(ctx, q.deleteKeyStmt, deleteKey, key)
+func (q *Queries) LockCache(ctx context.Context) error {
+	_, err := q.exec(ctx, q.lockCacheStmt, lockCache)
 	return err
 }
 
-const deleteKeysByLimit = `-- name: DeleteKeysByLimit :exec
+const purgeTombstones = `-- name: PurgeTombstones :execrows
 DELETE FROM cache
-WHERE key IN (
-    SELECT key
-    FROM cache
-    ORDER BY last_accessed_at ASC
-    LIMIT ?
-)
+WHERE deleted_at <= ?
 `
 
-func (q *Queries) DeleteKeysByLimit(ctx context.Context, limit int64) error {
-	_, err := q.exec(ctx, q.deleteKeysByLimitStmt, deleteKeysByLimit, limit)
-	return err
+func (q *Queries) PurgeTombstones(ctx context.Context, deletedAt sql.NullTime) (int64, error) {
+	result, err := q.exec(ctx, q.purgeTombstonesStmt, purgeTombstones, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getValue = `-- name: GetValue :one
-SELECT value
-FROM cache
+const renameKey = `-- name: RenameKey :execrows
+UPDATE OR REPLACE cache
+SET key = ?
 WHERE key = ? AND expires_at > ?
 `
 
-type GetValueParams struct {
-	ExpiresAt time.Time `json:"expires_at"`
-	Key       string    `json:"key"`
+type RenameKeyParams struct {
+	Now    time.Time `json:"now"`
+	NewKey string    `json:"new_key"`
+	OldKey string    `json:"old_key"`
 }
 
-func (q *Queries) GetValue(ctx context.Context, arg GetValueParams) ([]byte, error) {
-	row := q.queryRow(ctx, q.getValueStmt, getValue, arg.Key, arg.ExpiresAt)
-	var value []byte
-	err := row.Scan(&value)
-	return value, err
+func (q *Queries) RenameKey(ctx context.Context, arg RenameKeyParams) (int64, error) {
+	result, err := q.exec(ctx, q.renameKeyStmt, renameKey, arg.NewKey, arg.OldKey, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const selectKeysToDelete = `-- name: SelectKeysToDelete :many
@@ -120,9 +1061,187 @@ func (q *Queries) SelectKeysToDelete(ctx context.Context, limit int64) ([]string
 	return items, nil
 }
 
+const setPinned = `-- name: SetPinned :execrows
+UPDATE cache
+SET pinned = ?
+WHERE key = ? AND expires_at > ?
+`
+
+type SetPinnedParams struct {
+	Now    time.Time `json:"now"`
+	Key    string    `json:"key"`
+	Pinned int64     `json:"pinned"`
+}
+
+func (q *Queries) SetPinned(ctx context.Context, arg SetPinnedParams) (int64, error) {
+	result, err := q.exec(ctx, q.setPinnedStmt, setPinned, arg.Pinned, arg.Key, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const slideExpiresAt = `-- name: SlideExpiresAt :exec
+UPDATE cache
+SET expires_at = ?
+WHERE key = ? AND ttl = ? AND expires_at > ?
+`
+
+type SlideExpiresAtParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Now       time.Time `json:"now"`
+	Key       string    `json:"key"`
+	Ttl       int64     `json:"ttl"`
+}
+
+func (q *Queries) SlideExpiresAt(ctx context.Context, arg SlideExpiresAtParams) error {
+	_, err := q.exec(ctx, q.slideExpiresAtStmt, slideExpiresAt,
+		arg.ExpiresAt,
+		arg.Key,
+		arg.Ttl,
+		arg.Now,
+	)
+	return err
+}
+
+const tombstoneKey = `-- name: TombstoneKey :exec
+UPDATE cache
+SET value = NULL,
+    checksum = NULL,
+    metadata = NULL,
+    pinned = 1,
+    expires_at = ?1,
+    deleted_at = ?1
+WHERE key = ?2 AND deleted_at IS NULL
+`
+
+type TombstoneKeyParams struct {
+	Now time.Time `json:"now"`
+	Key string    `json:"key"`
+}
+
+func (q *Queries) TombstoneKey(ctx context.Context, arg TombstoneKeyParams) error {
+	_, err := q.exec(ctx, q.tombstoneKeyStmt, tombstoneKey, arg.Now, arg.Key)
+	return err
+}
+
+const tombstoneKeysByPattern = `-- name: TombstoneKeysByPattern :many
+UPDATE cache
+SET value = NULL,
+    checksum = NULL,
+    metadata = NULL,
+    pinned = 1,
+    expires_at = ?1,
+    deleted_at = ?1
+WHERE key GLOB ?2 AND deleted_at IS NULL
+RETURNING key
+`
+
+type TombstoneKeysByPatternParams struct {
+	Now     time.Time `json:"now"`
+	Pattern string    `json:"pattern"`
+}
+
+func (q *Queries) TombstoneKeysByPattern(
+	ctx context.Context,
+	arg TombstoneKeysByPatternParams,
+) ([]string, error) {
+	rows, err := q.query(ctx, q.tombstoneKeysByPatternStmt, tombstoneKeysByPattern,
+		arg.Now,
+		arg.Pattern,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateCacheIfVersion = `-- name: UpdateCacheIfVersion :execrows
+UPDATE cache
+SET value = ?,
+    expires_at = ?,
+    last_accessed_at = ?,
+    ttl = ?,
+    checksum = ?,
+    updated_at = ?3,
+    version = version + 1
+WHERE key = ? AND version = ? AND expires_at > ?
+`
+
+type UpdateCacheIfVersionParams struct {
+	ExpiresAt      time.Time     `json:"expires_at"`
+	LastAccessedAt time.Time     `json:"last_accessed_at"`
+	Now            time.Time     `json:"now"`
+	Key            string        `json:"key"`
+	Value          []byte        `json:"value"`
+	Ttl            int64         `json:"ttl"`
+	Version        int64         `json:"version"`
+	Checksum       sql.NullInt64 `json:"checksum"`
+}
+
+func (q *Queries) UpdateCacheIfVersion(ctx context.Context, arg UpdateCacheIfVersionParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateCacheIfVersionStmt, updateCacheIfVersion,
+		arg.Value,
+		arg.ExpiresAt,
+		arg.LastAccessedAt,
+		arg.Ttl,
+		arg.Checksum,
+		arg.Key,
+		arg.Version,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateExpiresAt = `-- name: UpdateExpiresAt :execrows
+UPDATE cache
+SET expires_at = ?,
+    ttl = ?
+WHERE key = ? AND expires_at > ?
+`
+
+type UpdateExpiresAtParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Now       time.Time `json:"now"`
+	Key       string    `json:"key"`
+	Ttl       int64     `json:"ttl"`
+}
+
+func (q *Queries) UpdateExpiresAt(ctx context.Context, arg UpdateExpiresAtParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateExpiresAtStmt, updateExpiresAt,
+		arg.ExpiresAt,
+		arg.Ttl,
+		arg.Key,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateLastAccessedAt = `-- name: UpdateLastAccessedAt :exec
 UPDATE cache
-SET last_accessed_at = ?
+SET last_accessed_at = ?,
+    access_count = access_count + 1
 WHERE key = ?
 `
 
@@ -136,20 +1255,86 @@ func (q *Queries) UpdateLastAccessedAt(ctx context.Context, arg UpdateLastAccess
 	return err
 }
 
+const updateLastAccessedAtByBlobKey = `-- name: UpdateLastAccessedAtByBlobKey :exec
+UPDATE cache
+SET last_accessed_at = ?,
+    access_count = access_count + 1
+WHERE key = CAST(? AS BLOB)
+`
+
+type UpdateLastAccessedAtByBlobKeyParams struct {
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+	Key            []byte    `json:"key"`
+}
+
+func (q *Queries) UpdateLastAccessedAtByBlobKey(
+	ctx context.Context,
+	arg UpdateLastAccessedAtByBlobKeyParams,
+) error {
+	_, err := q.exec(
+		ctx,
+		q.updateLastAccessedAtByBlobKeyStmt,
+		updateLastAccessedAtByBlobKey,
+		arg.LastAccessedAt,
+		arg.Key,
+	)
+	return err
+}
+
+const updateLastAccessedAtByKeys = `-- name: UpdateLastAccessedAtByKeys :exec
+UPDATE cache
+SET last_accessed_at = ?,
+    access_count = access_count + 1
+WHERE key IN (/*SLICE:keys*/?) AND expires_at > ?
+`
+
+type UpdateLastAccessedAtByKeysParams struct {
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+	Keys           []string  `json:"keys"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func (q *Queries) UpdateLastAccessedAtByKeys(ctx context.Context, arg UpdateLastAccessedAtByKeysParams) error {
+	query := updateLastAccessedAtByKeys
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.LastAccessedAt)
+	if len(arg.Keys) > 0 {
+		for _, v := range arg.Keys {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:keys*/?", strings.Repeat(",?", len(arg.Keys))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:keys*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.ExpiresAt)
+	_, err := q.exec(ctx, nil, query, queryParams...)
+	return err
+}
+
 const upsertCache = `-- name: UpsertCache :exec
-INSERT INTO cache (key, value, expires_at, last_accessed_at)
-VALUES (?, ?, ?, ?)
+INSERT INTO cache (key, value, expires_at, last_accessed_at, ttl, checksum, updated_at, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?4, ?)
 ON CONFLICT (key) DO UPDATE
 SET value = excluded.value,
     expires_at = excluded.expires_at,
-    last_accessed_at = excluded.last_accessed_at
+    last_accessed_at = excluded.last_accessed_at,
+    ttl = excluded.ttl,
+    checksum = excluded.checksum,
+    updated_at = excluded.updated_at,
+    metadata = excluded.metadata,
+    pinned = CASE WHEN cache.deleted_at IS NULL THEN cache.pinned ELSE 0 END,
+    deleted_at = NULL,
+    version = cache.version + 1
 `
 
 type UpsertCacheParams struct {
-	ExpiresAt      time.Time `json:"expires_at"`
-	LastAccessedAt time.Time `json:"last_accessed_at"`
-	Key            string    `json:"key"`
-	Value          []byte    `json:"value"`
+	ExpiresAt      time.Time      `json:"expires_at"`
+	LastAccessedAt time.Time      `json:"last_accessed_at"`
+	Key            string         `json:"key"`
+	Value          []byte         `json:"value"`
+	Ttl            int64          `json:"ttl"`
+	Checksum       sql.NullInt64  `json:"checksum"`
+	Metadata       sql.NullString `json:"metadata"`
 }
 
 func (q *Queries) UpsertCache(ctx context.Context, arg UpsertCacheParams) error {
@@ -158,6 +1343,87 @@ func (q *Queries) UpsertCache(ctx context.Context, arg UpsertCacheParams) error
 		arg.Value,
 		arg.ExpiresAt,
 		arg.LastAccessedAt,
+		arg.Ttl,
+		arg.Checksum,
+		arg.Metadata,
 	)
 	return err
 }
+
+const upsertCacheByBlobKey = `-- name: UpsertCacheByBlobKey :exec
+INSERT INTO cache (key, value, expires_at, last_accessed_at, ttl, checksum, updated_at)
+VALUES (CAST(? AS BLOB), ?, ?, ?, ?, ?, ?4)
+ON CONFLICT (key) DO UPDATE
+SET value = excluded.value,
+    expires_at = excluded.expires_at,
+    last_accessed_at = excluded.last_accessed_at,
+    ttl = excluded.ttl,
+    checksum = excluded.checksum,
+    updated_at = excluded.updated_at,
+    metadata = NULL,
+    pinned = CASE WHEN cache.deleted_at IS NULL THEN cache.pinned ELSE 0 END,
+    deleted_at = NULL,
+    version = cache.version + 1
+`
+
+type UpsertCacheByBlobKeyParams struct {
+	ExpiresAt      time.Time     `json:"expires_at"`
+	LastAccessedAt time.Time     `json:"last_accessed_at"`
+	Key            []byte        `json:"key"`
+	Value          []byte        `json:"value"`
+	Ttl            int64         `json:"ttl"`
+	Checksum       sql.NullInt64 `json:"checksum"`
+}
+
+func (q *Queries) UpsertCacheByBlobKey(ctx context.Context, arg UpsertCacheByBlobKeyParams) error {
+	_, err := q.exec(ctx, q.upsertCacheByBlobKeyStmt, upsertCacheByBlobKey,
+		arg.Key,
+		arg.Value,
+		arg.ExpiresAt,
+		arg.LastAccessedAt,
+		arg.Ttl,
+		arg.Checksum,
+	)
+	return err
+}
+
+const upsertCacheIfExpired = `-- name: UpsertCacheIfExpired :one
+INSERT INTO cache (key, value, expires_at, last_accessed_at, ttl, checksum, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?4)
+ON CONFLICT (key) DO UPDATE
+SET value = excluded.value,
+    expires_at = excluded.expires_at,
+    last_accessed_at = excluded.last_accessed_at,
+    ttl = excluded.ttl,
+    checksum = excluded.checksum,
+    updated_at = excluded.updated_at,
+    metadata = NULL,
+    pinned = CASE WHEN cache.deleted_at IS NULL THEN cache.pinned ELSE 0 END,
+    deleted_at = NULL,
+    version = cache.version + 1
+WHERE cache.expires_at <= excluded.last_accessed_at
+RETURNING value
+`
+
+type UpsertCacheIfExpiredParams struct {
+	ExpiresAt      time.Time     `json:"expires_at"`
+	LastAccessedAt time.Time     `json:"last_accessed_at"`
+	Key            string        `json:"key"`
+	Value          []byte        `json:"value"`
+	Ttl            int64         `json:"ttl"`
+	Checksum       sql.NullInt64 `json:"checksum"`
+}
+
+func (q *Queries) UpsertCacheIfExpired(ctx context.Context, arg UpsertCacheIfExpiredParams) ([]byte, error) {
+	row := q.queryRow(ctx, q.upsertCacheIfExpiredStmt, upsertCacheIfExpired,
+		arg.Key,
+		arg.Value,
+		arg.ExpiresAt,
+		arg.LastAccessedAt,
+		arg.Ttl,
+		arg.Checksum,
+	)
+	var value []byte
+	err := row.Scan(&value)
+	return value, err
+}
@@ -24,83 +24,467 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.addAccessStmt, err = db.PrepareContext(ctx, addAccess); err != nil {
+		return nil, fmt.Errorf("error preparing query AddAccess: %w", err)
+	}
+	if q.addDependencyStmt, err = db.PrepareContext(ctx, addDependency); err != nil {
+		return nil, fmt.Errorf("error preparing query AddDependency: %w", err)
+	}
+	if q.appendValueStmt, err = db.PrepareContext(ctx, appendValue); err != nil {
+		return nil, fmt.Errorf("error preparing query AppendValue: %w", err)
+	}
 	if q.countCacheEntriesStmt, err = db.PrepareContext(ctx, countCacheEntries); err != nil {
 		return nil, fmt.Errorf("error preparing query CountCacheEntries: %w", err)
 	}
+	if q.countExpiredKeyStmt, err = db.PrepareContext(ctx, countExpiredKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CountExpiredKey: %w", err)
+	}
+	if q.countLiveEntriesStmt, err = db.PrepareContext(ctx, countLiveEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query CountLiveEntries: %w", err)
+	}
+	if q.countLiveEntriesInRangeStmt, err = db.PrepareContext(ctx, countLiveEntriesInRange); err != nil {
+		return nil, fmt.Errorf("error preparing query CountLiveEntriesInRange: %w", err)
+	}
 	if q.createCacheDatabaseStmt, err = db.PrepareContext(ctx, createCacheDatabase); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateCacheDatabase: %w", err)
 	}
+	if q.createDependenciesTableStmt, err = db.PrepareContext(ctx, createDependenciesTable); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateDependenciesTable: %w", err)
+	}
+	if q.deleteAllCacheStmt, err = db.PrepareContext(ctx, deleteAllCache); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAllCache: %w", err)
+	}
+	if q.deleteAllDependenciesStmt, err = db.PrepareContext(ctx, deleteAllDependencies); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAllDependencies: %w", err)
+	}
+	if q.deleteCacheInRangeStmt, err = db.PrepareContext(ctx, deleteCacheInRange); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteCacheInRange: %w", err)
+	}
+	if q.deleteDependenciesStmt, err = db.PrepareContext(ctx, deleteDependencies); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteDependencies: %w", err)
+	}
+	if q.deleteDependentsStmt, err = db.PrepareContext(ctx, deleteDependents); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteDependents: %w", err)
+	}
 	if q.deleteExpiredCacheStmt, err = db.PrepareContext(ctx, deleteExpiredCache); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteExpiredCache: %w", err)
 	}
+	if q.deleteExpiredKeyStmt, err = db.PrepareContext(ctx, deleteExpiredKey); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredKey: %w", err)
+	}
 	if q.deleteKeyStmt, err = db.PrepareContext(ctx, deleteKey); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteKey: %w", err)
 	}
+	if q.deleteKeyByBlobKeyStmt, err = db.PrepareContext(ctx, deleteKeyByBlobKey); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteKeyByBlobKey: %w", err)
+	}
 	if q.deleteKeysByLimitStmt, err = db.PrepareContext(ctx, deleteKeysByLimit); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteKeysByLimit: %w", err)
 	}
+	if q.deleteKeysByLimitInRangeStmt, err = db.PrepareContext(ctx, deleteKeysByLimitInRange); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteKeysByLimitInRange: %w", err)
+	}
+	if q.deleteKeysByPatternStmt, err = db.PrepareContext(ctx, deleteKeysByPattern); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteKeysByPattern: %w", err)
+	}
+	if q.deleteLeastFrequentlyUsedStmt, err = db.PrepareContext(ctx, deleteLeastFrequentlyUsed); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLeastFrequentlyUsed: %w", err)
+	}
+	if q.deleteParentsStmt, err = db.PrepareContext(ctx, deleteParents); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteParents: %w", err)
+	}
+	if q.getCacheUsageStmt, err = db.PrepareContext(ctx, getCacheUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCacheUsage: %w", err)
+	}
+	if q.getCacheUsageInRangeStmt, err = db.PrepareContext(ctx, getCacheUsageInRange); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCacheUsageInRange: %w", err)
+	}
+	if q.getEntryStmt, err = db.PrepareContext(ctx, getEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEntry: %w", err)
+	}
+	if q.getEntryByBlobKeyStmt, err = db.PrepareContext(ctx, getEntryByBlobKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEntryByBlobKey: %w", err)
+	}
+	if q.getEntryTTLStmt, err = db.PrepareContext(ctx, getEntryTTL); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEntryTTL: %w", err)
+	}
+	if q.getEntryWithMetadataStmt, err = db.PrepareContext(ctx, getEntryWithMetadata); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEntryWithMetadata: %w", err)
+	}
+	if q.getExpiresAtStmt, err = db.PrepareContext(ctx, getExpiresAt); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExpiresAt: %w", err)
+	}
+	if q.getPageStatsStmt, err = db.PrepareContext(ctx, getPageStats); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPageStats: %w", err)
+	}
+	if q.getStaleEntryStmt, err = db.PrepareContext(ctx, getStaleEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query GetStaleEntry: %w", err)
+	}
+	if q.getTotalUsageStmt, err = db.PrepareContext(ctx, getTotalUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTotalUsage: %w", err)
+	}
+	if q.getTotalUsageInRangeStmt, err = db.PrepareContext(ctx, getTotalUsageInRange); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTotalUsageInRange: %w", err)
+	}
 	if q.getValueStmt, err = db.PrepareContext(ctx, getValue); err != nil {
 		return nil, fmt.Errorf("error preparing query GetValue: %w", err)
 	}
+	if q.getValueRangeStmt, err = db.PrepareContext(ctx, getValueRange); err != nil {
+		return nil, fmt.Errorf("error preparing query GetValueRange: %w", err)
+	}
+	if q.getValueWithVersionStmt, err = db.PrepareContext(ctx, getValueWithVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query GetValueWithVersion: %w", err)
+	}
+	if q.getValuesStmt, err = db.PrepareContext(ctx, getValues); err != nil {
+		return nil, fmt.Errorf("error preparing query GetValues: %w", err)
+	}
+	if q.listKeysStmt, err = db.PrepareContext(ctx, listKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query ListKeys: %w", err)
+	}
+	if q.listLiveEntriesStmt, err = db.PrepareContext(ctx, listLiveEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLiveEntries: %w", err)
+	}
+	if q.listTombstonesStmt, err = db.PrepareContext(ctx, listTombstones); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTombstones: %w", err)
+	}
+	if q.lockCacheStmt, err = db.PrepareContext(ctx, lockCache); err != nil {
+		return nil, fmt.Errorf("error preparing query LockCache: %w", err)
+	}
+	if q.purgeTombstonesStmt, err = db.PrepareContext(ctx, purgeTombstones); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeTombstones: %w", err)
+	}
+	if q.renameKeyStmt, err = db.PrepareContext(ctx, renameKey); err != nil {
+		return nil, fmt.Errorf("error preparing query RenameKey: %w", err)
+	}
 	if q.selectKeysToDeleteStmt, err = db.PrepareContext(ctx, selectKeysToDelete); err != nil {
 		return nil, fmt.Errorf("error preparing query SelectKeysToDelete: %w", err)
 	}
+	if q.setPinnedStmt, err = db.PrepareContext(ctx, setPinned); err != nil {
+		return nil, fmt.Errorf("error preparing query SetPinned: %w", err)
+	}
+	if q.slideExpiresAtStmt, err = db.PrepareContext(ctx, slideExpiresAt); err != nil {
+		return nil, fmt.Errorf("error preparing query SlideExpiresAt: %w", err)
+	}
+	if q.tombstoneKeyStmt, err = db.PrepareContext(ctx, tombstoneKey); err != nil {
+		return nil, fmt.Errorf("error preparing query TombstoneKey: %w", err)
+	}
+	if q.tombstoneKeysByPatternStmt, err = db.PrepareContext(ctx, tombstoneKeysByPattern); err != nil {
+		return nil, fmt.Errorf("error preparing query TombstoneKeysByPattern: %w", err)
+	}
+	if q.updateCacheIfVersionStmt, err = db.PrepareContext(ctx, updateCacheIfVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateCacheIfVersion: %w", err)
+	}
+	if q.updateExpiresAtStmt, err = db.PrepareContext(ctx, updateExpiresAt); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateExpiresAt: %w", err)
+	}
 	if q.updateLastAccessedAtStmt, err = db.PrepareContext(ctx, updateLastAccessedAt); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateLastAccessedAt: %w", err)
 	}
+	if q.updateLastAccessedAtByBlobKeyStmt, err = db.PrepareContext(ctx, updateLastAccessedAtByBlobKey); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateLastAccessedAtByBlobKey: %w", err)
+	}
+	if q.updateLastAccessedAtByKeysStmt, err = db.PrepareContext(ctx, updateLastAccessedAtByKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateLastAccessedAtByKeys: %w", err)
+	}
 	if q.upsertCacheStmt, err = db.PrepareContext(ctx, upsertCache); err != nil {
 		return nil, fmt.Errorf("error preparing query UpsertCache: %w", err)
 	}
+	if q.upsertCacheByBlobKeyStmt, err = db.PrepareContext(ctx, upsertCacheByBlobKey); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertCacheByBlobKey: %w", err)
+	}
+	if q.upsertCacheIfExpiredStmt, err = db.PrepareContext(ctx, upsertCacheIfExpired); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertCacheIfExpired: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.addAccessStmt != nil {
+		if cerr := q.addAccessStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addAccessStmt: %w", cerr)
+		}
+	}
+	if q.addDependencyStmt != nil {
+		if cerr := q.addDependencyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addDependencyStmt: %w", cerr)
+		}
+	}
+	if q.appendValueStmt != nil {
+		if cerr := q.appendValueStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing appendValueStmt: %w", cerr)
+		}
+	}
 	if q.countCacheEntriesStmt != nil {
 		if cerr := q.countCacheEntriesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing countCacheEntriesStmt: %w", cerr)
 		}
 	}
+	if q.countExpiredKeyStmt != nil {
+		if cerr := q.countExpiredKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countExpiredKeyStmt: %w", cerr)
+		}
+	}
+	if q.countLiveEntriesStmt != nil {
+		if cerr := q.countLiveEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countLiveEntriesStmt: %w", cerr)
+		}
+	}
+	if q.countLiveEntriesInRangeStmt != nil {
+		if cerr := q.countLiveEntriesInRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countLiveEntriesInRangeStmt: %w", cerr)
+		}
+	}
 	if q.createCacheDatabaseStmt != nil {
 		if cerr := q.createCacheDatabaseStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createCacheDatabaseStmt: %w", cerr)
 		}
 	}
+	if q.createDependenciesTableStmt != nil {
+		if cerr := q.createDependenciesTableStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createDependenciesTableStmt: %w", cerr)
+		}
+	}
+	if q.deleteAllCacheStmt != nil {
+		if cerr := q.deleteAllCacheStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAllCacheStmt: %w", cerr)
+		}
+	}
+	if q.deleteAllDependenciesStmt != nil {
+		if cerr := q.deleteAllDependenciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAllDependenciesStmt: %w", cerr)
+		}
+	}
+	if q.deleteCacheInRangeStmt != nil {
+		if cerr := q.deleteCacheInRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteCacheInRangeStmt: %w", cerr)
+		}
+	}
+	if q.deleteDependenciesStmt != nil {
+		if cerr := q.deleteDependenciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteDependenciesStmt: %w", cerr)
+		}
+	}
+	if q.deleteDependentsStmt != nil {
+		if cerr := q.deleteDependentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteDependentsStmt: %w", cerr)
+		}
+	}
 	if q.deleteExpiredCacheStmt != nil {
 		if cerr := q.deleteExpiredCacheStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteExpiredCacheStmt: %w", cerr)
 		}
 	}
+	if q.deleteExpiredKeyStmt != nil {
+		if cerr := q.deleteExpiredKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredKeyStmt: %w", cerr)
+		}
+	}
 	if q.deleteKeyStmt != nil {
 		if cerr := q.deleteKeyStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteKeyStmt: %w", cerr)
 		}
 	}
+	if q.deleteKeyByBlobKeyStmt != nil {
+		if cerr := q.deleteKeyByBlobKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteKeyByBlobKeyStmt: %w", cerr)
+		}
+	}
 	if q.deleteKeysByLimitStmt != nil {
 		if cerr := q.deleteKeysByLimitStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteKeysByLimitStmt: %w", cerr)
 		}
 	}
+	if q.deleteKeysByLimitInRangeStmt != nil {
+		if cerr := q.deleteKeysByLimitInRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteKeysByLimitInRangeStmt: %w", cerr)
+		}
+	}
+	if q.deleteKeysByPatternStmt != nil {
+		if cerr := q.deleteKeysByPatternStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteKeysByPatternStmt: %w", cerr)
+		}
+	}
+	if q.deleteLeastFrequentlyUsedStmt != nil {
+		if cerr := q.deleteLeastFrequentlyUsedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLeastFrequentlyUsedStmt: %w", cerr)
+		}
+	}
+	if q.deleteParentsStmt != nil {
+		if cerr := q.deleteParentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteParentsStmt: %w", cerr)
+		}
+	}
+	if q.getCacheUsageStmt != nil {
+		if cerr := q.getCacheUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCacheUsageStmt: %w", cerr)
+		}
+	}
+	if q.getCacheUsageInRangeStmt != nil {
+		if cerr := q.getCacheUsageInRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCacheUsageInRangeStmt: %w", cerr)
+		}
+	}
+	if q.getEntryStmt != nil {
+		if cerr := q.getEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEntryStmt: %w", cerr)
+		}
+	}
+	if q.getEntryByBlobKeyStmt != nil {
+		if cerr := q.getEntryByBlobKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEntryByBlobKeyStmt: %w", cerr)
+		}
+	}
+	if q.getEntryTTLStmt != nil {
+		if cerr := q.getEntryTTLStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEntryTTLStmt: %w", cerr)
+		}
+	}
+	if q.getEntryWithMetadataStmt != nil {
+		if cerr := q.getEntryWithMetadataStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEntryWithMetadataStmt: %w", cerr)
+		}
+	}
+	if q.getExpiresAtStmt != nil {
+		if cerr := q.getExpiresAtStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExpiresAtStmt: %w", cerr)
+		}
+	}
+	if q.getPageStatsStmt != nil {
+		if cerr := q.getPageStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPageStatsStmt: %w", cerr)
+		}
+	}
+	if q.getStaleEntryStmt != nil {
+		if cerr := q.getStaleEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getStaleEntryStmt: %w", cerr)
+		}
+	}
+	if q.getTotalUsageStmt != nil {
+		if cerr := q.getTotalUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTotalUsageStmt: %w", cerr)
+		}
+	}
+	if q.getTotalUsageInRangeStmt != nil {
+		if cerr := q.getTotalUsageInRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTotalUsageInRangeStmt: %w", cerr)
+		}
+	}
 	if q.getValueStmt != nil {
 		if cerr := q.getValueStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getValueStmt: %w", cerr)
 		}
 	}
+	if q.getValueRangeStmt != nil {
+		if cerr := q.getValueRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getValueRangeStmt: %w", cerr)
+		}
+	}
+	if q.getValueWithVersionStmt != nil {
+		if cerr := q.getValueWithVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getValueWithVersionStmt: %w", cerr)
+		}
+	}
+	if q.getValuesStmt != nil {
+		if cerr := q.getValuesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getValuesStmt: %w", cerr)
+		}
+	}
+	if q.listKeysStmt != nil {
+		if cerr := q.listKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listKeysStmt: %w", cerr)
+		}
+	}
+	if q.listLiveEntriesStmt != nil {
+		if cerr := q.listLiveEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLiveEntriesStmt: %w", cerr)
+		}
+	}
+	if q.listTombstonesStmt != nil {
+		if cerr := q.listTombstonesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTombstonesStmt: %w", cerr)
+		}
+	}
+	if q.lockCacheStmt != nil {
+		if cerr := q.lockCacheStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockCacheStmt: %w", cerr)
+		}
+	}
+	if q.purgeTombstonesStmt != nil {
+		if cerr := q.purgeTombstonesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeTombstonesStmt: %w", cerr)
+		}
+	}
+	if q.renameKeyStmt != nil {
+		if cerr := q.renameKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing renameKeyStmt: %w", cerr)
+		}
+	}
 	if q.selectKeysToDeleteStmt != nil {
 		if cerr := q.selectKeysToDeleteStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing selectKeysToDeleteStmt: %w", cerr)
 		}
 	}
+	if q.setPinnedStmt != nil {
+		if cerr := q.setPinnedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setPinnedStmt: %w", cerr)
+		}
+	}
+	if q.slideExpiresAtStmt != nil {
+		if cerr := q.slideExpiresAtStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing slideExpiresAtStmt: %w", cerr)
+		}
+	}
+	if q.tombstoneKeyStmt != nil {
+		if cerr := q.tombstoneKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing tombstoneKeyStmt: %w", cerr)
+		}
+	}
+	if q.tombstoneKeysByPatternStmt != nil {
+		if cerr := q.tombstoneKeysByPatternStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing tombstoneKeysByPatternStmt: %w", cerr)
+		}
+	}
+	if q.updateCacheIfVersionStmt != nil {
+		if cerr := q.updateCacheIfVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateCacheIfVersionStmt: %w", cerr)
+		}
+	}
+	if q.updateExpiresAtStmt != nil {
+		if cerr := q.updateExpiresAtStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateExpiresAtStmt: %w", cerr)
+		}
+	}
 	if q.updateLastAccessedAtStmt != nil {
 		if cerr := q.updateLastAccessedAtStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateLastAccessedAtStmt: %w", cerr)
 		}
 	}
+	if q.updateLastAccessedAtByBlobKeyStmt != nil {
+		if cerr := q.updateLastAccessedAtByBlobKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateLastAccessedAtByBlobKeyStmt: %w", cerr)
+		}
+	}
+	if q.updateLastAccessedAtByKeysStmt != nil {
+		if cerr := q.updateLastAccessedAtByKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateLastAccessedAtByKeysStmt: %w", cerr)
+		}
+	}
 	if q.upsertCacheStmt != nil {
 		if cerr := q.upsertCacheStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing upsertCacheStmt: %w", cerr)
 		}
 	}
+	if q.upsertCacheByBlobKeyStmt != nil {
+		if cerr := q.upsertCacheByBlobKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertCacheByBlobKeyStmt: %w", cerr)
+		}
+	}
+	if q.upsertCacheIfExpiredStmt != nil {
+		if cerr := q.upsertCacheIfExpiredStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertCacheIfExpiredStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -138,31 +522,127 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                       DBTX
-	tx                       *sql.Tx
-	countCacheEntriesStmt    *sql.Stmt
-	createCacheDatabaseStmt  *sql.Stmt
-	deleteExpiredCacheStmt   *sql.Stmt
-	deleteKeyStmt            *sql.Stmt
-	deleteKeysByLimitStmt    *sql.Stmt
-	getValueStmt             *sql.Stmt
-	selectKeysToDeleteStmt   *sql.Stmt
-	updateLastAccessedAtStmt *sql.Stmt
-	upsertCacheStmt          *sql.Stmt
+	db                                DBTX
+	tx                                *sql.Tx
+	addAccessStmt                     *sql.Stmt
+	addDependencyStmt                 *sql.Stmt
+	appendValueStmt                   *sql.Stmt
+	countCacheEntriesStmt             *sql.Stmt
+	countExpiredKeyStmt               *sql.Stmt
+	countLiveEntriesStmt              *sql.Stmt
+	countLiveEntriesInRangeStmt       *sql.Stmt
+	createCacheDatabaseStmt           *sql.Stmt
+	createDependenciesTableStmt       *sql.Stmt
+	deleteAllCacheStmt                *sql.Stmt
+	deleteAllDependenciesStmt         *sql.Stmt
+	deleteCacheInRangeStmt            *sql.Stmt
+	deleteDependenciesStmt            *sql.Stmt
+	deleteDependentsStmt              *sql.Stmt
+	deleteExpiredCacheStmt            *sql.Stmt
+	deleteExpiredKeyStmt              *sql.Stmt
+	deleteKeyStmt                     *sql.Stmt
+	deleteKeyByBlobKeyStmt            *sql.Stmt
+	deleteKeysByLimitStmt             *sql.Stmt
+	deleteKeysByLimitInRangeStmt      *sql.Stmt
+	deleteKeysByPatternStmt           *sql.Stmt
+	deleteLeastFrequentlyUsedStmt     *sql.Stmt
+	deleteParentsStmt                 *sql.Stmt
+	getCacheUsageStmt                 *sql.Stmt
+	getCacheUsageInRangeStmt          *sql.Stmt
+	getEntryStmt                      *sql.Stmt
+	getEntryByBlobKeyStmt             *sql.Stmt
+	getEntryTTLStmt                   *sql.Stmt
+	getEntryWithMetadataStmt          *sql.Stmt
+	getExpiresAtStmt                  *sql.Stmt
+	getPageStatsStmt                  *sql.Stmt
+	getStaleEntryStmt                 *sql.Stmt
+	getTotalUsageStmt                 *sql.Stmt
+	getTotalUsageInRangeStmt          *sql.Stmt
+	getValueStmt                      *sql.Stmt
+	getValueRangeStmt                 *sql.Stmt
+	getValueWithVersionStmt           *sql.Stmt
+	getValuesStmt                     *sql.Stmt
+	listKeysStmt                      *sql.Stmt
+	listLiveEntriesStmt               *sql.Stmt
+	listTombstonesStmt                *sql.Stmt
+	lockCacheStmt                     *sql.Stmt
+	purgeTombstonesStmt               *sql.Stmt
+	renameKeyStmt                     *sql.Stmt
+	selectKeysToDeleteStmt            *sql.Stmt
+	setPinnedStmt                     *sql.Stmt
+	slideExpiresAtStmt                *sql.Stmt
+	tombstoneKeyStmt                  *sql.Stmt
+	tombstoneKeysByPatternStmt        *sql.Stmt
+	updateCacheIfVersionStmt          *sql.Stmt
+	updateExpiresAtStmt               *sql.Stmt
+	updateLastAccessedAtStmt          *sql.Stmt
+	updateLastAccessedAtByBlobKeyStmt *sql.Stmt
+	updateLastAccessedAtByKeysStmt    *sql.Stmt
+	upsertCacheStmt                   *sql.Stmt
+	upsertCacheByBlobKeyStmt          *sql.Stmt
+	upsertCacheIfExpiredStmt          *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                       tx,
-		tx:                       tx,
-		countCacheEntriesStmt:    q.countCacheEntriesStmt,
-		createCacheDatabaseStmt:  q.createCacheDatabaseStmt,
-		deleteExpiredCacheStmt:   q.deleteExpiredCacheStmt,
-		deleteKeyStmt:            q.deleteKeyStmt,
-		deleteKeysByLimitStmt:    q.deleteKeysByLimitStmt,
-		getValueStmt:             q.getValueStmt,
-		selectKeysToDeleteStmt:   q.selectKeysToDeleteStmt,
-		updateLastAccessedAtStmt: q.updateLastAccessedAtStmt,
-		upsertCacheStmt:          q.upsertCacheStmt,
+		db:                                tx,
+		tx:                                tx,
+		addAccessStmt:                     q.addAccessStmt,
+		addDependencyStmt:                 q.addDependencyStmt,
+		appendValueStmt:                   q.appendValueStmt,
+		countCacheEntriesStmt:             q.countCacheEntriesStmt,
+		countExpiredKeyStmt:               q.countExpiredKeyStmt,
+		countLiveEntriesStmt:              q.countLiveEntriesStmt,
+		countLiveEntriesInRangeStmt:       q.countLiveEntriesInRangeStmt,
+		createCacheDatabaseStmt:           q.createCacheDatabaseStmt,
+		createDependenciesTableStmt:       q.createDependenciesTableStmt,
+		deleteAllCacheStmt:                q.deleteAllCacheStmt,
+		deleteAllDependenciesStmt:         q.deleteAllDependenciesStmt,
+		deleteCacheInRangeStmt:            q.deleteCacheInRangeStmt,
+		deleteDependenciesStmt:            q.deleteDependenciesStmt,
+		deleteDependentsStmt:              q.deleteDependentsStmt,
+		deleteExpiredCacheStmt:            q.deleteExpiredCacheStmt,
+		deleteExpiredKeyStmt:              q.deleteExpiredKeyStmt,
+		deleteKeyStmt:                     q.deleteKeyStmt,
+		deleteKeyByBlobKeyStmt:            q.deleteKeyByBlobKeyStmt,
+		deleteKeysByLimitStmt:             q.deleteKeysByLimitStmt,
+		deleteKeysByLimitInRangeStmt:      q.deleteKeysByLimitInRangeStmt,
+		deleteKeysByPatternStmt:           q.deleteKeysByPatternStmt,
+		deleteLeastFrequentlyUsedStmt:     q.deleteLeastFrequentlyUsedStmt,
+		deleteParentsStmt:                 q.deleteParentsStmt,
+		getCacheUsageStmt:                 q.getCacheUsageStmt,
+		getCacheUsageInRangeStmt:          q.getCacheUsageInRangeStmt,
+		getEntryStmt:                      q.getEntryStmt,
+		getEntryByBlobKeyStmt:             q.getEntryByBlobKeyStmt,
+		getEntryTTLStmt:                   q.getEntryTTLStmt,
+		getEntryWithMetadataStmt:          q.getEntryWithMetadataStmt,
+		getExpiresAtStmt:                  q.getExpiresAtStmt,
+		getPageStatsStmt:                  q.getPageStatsStmt,
+		getStaleEntryStmt:                 q.getStaleEntryStmt,
+		getTotalUsageStmt:                 q.getTotalUsageStmt,
+		getTotalUsageInRangeStmt:          q.getTotalUsageInRangeStmt,
+		getValueStmt:                      q.getValueStmt,
+		getValueRangeStmt:                 q.getValueRangeStmt,
+		getValueWithVersionStmt:           q.getValueWithVersionStmt,
+		getValuesStmt:                     q.getValuesStmt,
+		listKeysStmt:                      q.listKeysStmt,
+		listLiveEntriesStmt:               q.listLiveEntriesStmt,
+		listTombstonesStmt:                q.listTombstonesStmt,
+		lockCacheStmt:                     q.lockCacheStmt,
+		purgeTombstonesStmt:               q.purgeTombstonesStmt,
+		renameKeyStmt:                     q.renameKeyStmt,
+		selectKeysToDeleteStmt:            q.selectKeysToDeleteStmt,
+		setPinnedStmt:                     q.setPinnedStmt,
+		slideExpiresAtStmt:                q.slideExpiresAtStmt,
+		tombstoneKeyStmt:                  q.tombstoneKeyStmt,
+		tombstoneKeysByPatternStmt:        q.tombstoneKeysByPatternStmt,
+		updateCacheIfVersionStmt:          q.updateCacheIfVersionStmt,
+		updateExpiresAtStmt:               q.updateExpiresAtStmt,
+		updateLastAccessedAtStmt:          q.updateLastAccessedAtStmt,
+		updateLastAccessedAtByBlobKeyStmt: q.updateLastAccessedAtByBlobKeyStmt,
+		updateLastAccessedAtByKeysStmt:    q.updateLastAccessedAtByKeysStmt,
+		upsertCacheStmt:                   q.upsertCacheStmt,
+		upsertCacheByBlobKeyStmt:          q.upsertCacheByBlobKeyStmt,
+		upsertCacheIfExpiredStmt:          q.upsertCacheIfExpiredStmt,
 	}
 }
@@ -5,13 +5,27 @@
 package queries
 
 import (
+	"database/sql"
 	"time"
 )
 
 type Cache struct {
-	CreatedAt      time.Time `json:"created_at"`
-	ExpiresAt      time.Time `json:"expires_at"`
-	LastAccessedAt time.Time `json:"last_accessed_at"`
-	Key            string    `json:"key"`
-	Value          []byte    `json:"value"`
+	CreatedAt      time.Time      `json:"created_at"`
+	ExpiresAt      time.Time      `json:"expires_at"`
+	LastAccessedAt time.Time      `json:"last_accessed_at"`
+	Key            string         `json:"key"`
+	Value          []byte         `json:"value"`
+	Version        int64          `json:"version"`
+	AccessCount    int64          `json:"access_count"`
+	Pinned         int64          `json:"pinned"`
+	Ttl            int64          `json:"ttl"`
+	Checksum       sql.NullInt64  `json:"checksum"`
+	UpdatedAt      sql.NullTime   `json:"updated_at"`
+	Metadata       sql.NullString `json:"metadata"`
+	DeletedAt      sql.NullTime   `json:"deleted_at"`
+}
+
+type CacheDependency struct {
+	Parent string `json:"parent"`
+	Child  string `json:"child"`
 }
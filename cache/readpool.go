@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// setupReadPool opens the pool of read-only connections of the reads, if enabled,
+// and prepares their queries on it.
+func (ch *cache) setupReadPool(ctx context.Context) error {
+	if ch.readPoolSize <= 0 {
+		return nil
+	}
+
+	err := ch.Database.OpenReadPool(ctx, ch.readPoolSize)
+	if err != nil {
+		return fmt.Errorf("opening read pool: %w", err)
+	}
+
+	prepared, err := queries.Prepare(ctx, ch.Database.GetReadEngine(ctx))
+	if err != nil {
+		return fmt.Errorf("preparing read queries: %w", err)
+	}
+	ch.readQueries = prepared
+
+	return nil
+}
+
+// reads returns the queries of the reads, run on the read pool if it is open.
+func (ch *cache) reads() *queries.Queries {
+	if ch.readQueries != nil {
+		return ch.readQueries
+	}
+
+	return ch.queries
+}
+
+// closeReadQueries closes the prepared statements of the reads, if any.
+func (ch *cache) closeReadQueries() error {
+	if ch.readQueries == nil {
+		return nil
+	}
+
+	return ch.readQueries.Close()
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	dbMocks "github.com/lucasvillarinho/litepack/database/mocks"
+)
+
+func TestCache_SetupReadPool(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should not open the read pool if it is disabled", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		ch := &cache{Database: dbMock}
+
+		err := ch.setupReadPool(ctx)
+
+		assert.NoError(t, err, "Expected no error without a read pool")
+		assert.Nil(t, ch.readQueries, "Expected the reads to use the write queries")
+		dbMock.AssertNotCalled(t, "OpenReadPool", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return an error if preparing the reads fails", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+		sqlMock.ExpectPrepare("UPDATE cache").
+			WillReturnError(fmt.Errorf("mock prepare error"))
+
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().OpenReadPool(mock.Anything, 4).Return(nil).Once()
+		dbMock.EXPECT().GetReadEngine(mock.Anything).Return(db).Once()
+		ch := &cache{Database: dbMock, readPoolSize: 4}
+
+		err = ch.setupReadPool(ctx)
+
+		assert.EqualError(
+			t,
+			err,
+			"preparing read queries: error preparing query AddAccess: mock prepare error",
+		)
+		assert.Nil(t, ch.readQueries, "Expected the reads to use the write queries")
+	})
+
+	t.Run("should return an error if opening the read pool fails", func(t *testing.T) {
+		dbMock := dbMocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			OpenReadPool(mock.Anything, 4).
+			Return(fmt.Errorf("unable to open database file")).
+			Once()
+		ch := &cache{Database: dbMock, readPoolSize: 4}
+
+		err := ch.setupReadPool(ctx)
+
+		assert.EqualError(t, err, "opening read pool: unable to open database file")
+		assert.Nil(t, ch.readQueries, "Expected the reads to use the write queries")
+	})
+
+	t.Run("should run the reads on the read pool once it is open", func(t *testing.T) {
+		writes, reads := &queries.Queries{}, &queries.Queries{}
+		ch := &cache{queries: writes}
+
+		assert.Same(t, writes, ch.reads(), "Expected the reads to use the write queries")
+
+		ch.readQueries = reads
+		assert.Same(t, reads, ch.reads(), "Expected the reads to use the read pool")
+	})
+}
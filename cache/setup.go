@@ -2,25 +2,67 @@ package cache
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/lucasvillarinho/litepack/cache/queries"
+	"github.com/lucasvillarinho/litepack/database/migrate"
 )
 
-// setupCache sets up the cache with the given configuration.
-func (ch *cache) setupCacheTable(ctx context.Context) error {
-	// Set up the cache queries.
-	ch.queries = queries.New(ch.Database.GetEngine(ctx))
+// migrations are the versioned changes of the schema of the cache tables.
+var migrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create_cache",
+		Up:      createCacheTable,
+		Down:    migrate.SQL(`DROP TABLE IF EXISTS cache`),
+	},
+	{
+		Version: 2,
+		Name:    "create_cache_dependencies",
+		Up:      createDependenciesTable,
+		Down:    migrate.SQL(`DROP TABLE IF EXISTS cache_dependencies`),
+	},
+}
+
+// legacyColumns are the columns added to the cache table before the migrations, missing
+// from the tables created by older versions.
+var legacyColumns = []string{
+	`ALTER TABLE cache ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+	`ALTER TABLE cache ADD COLUMN access_count INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE cache ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE cache ADD COLUMN ttl INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE cache ADD COLUMN checksum INTEGER`,
+	`ALTER TABLE cache ADD COLUMN updated_at TIMESTAMP`,
+	`ALTER TABLE cache ADD COLUMN metadata TEXT`,
+	`ALTER TABLE cache ADD COLUMN deleted_at TIMESTAMP`,
+}
 
-	// create the cache table if it does not exist
-	err := ch.queries.CreateCacheDatabase(ctx)
+// createCacheTable creates the cache table and its indexes.
+// The table may predate the migrations, so the columns added since are added to it.
+func createCacheTable(ctx context.Context, tx *sql.Tx) error {
+	err := queries.New(tx).CreateCacheDatabase(ctx)
 	if err != nil {
 		return fmt.Errorf("creating table: %w", err)
 	}
 
-	// create the index key_expires_at if it does not exist
-	sqlIndexKeyExpiresAt := `CREATE INDEX IF NOT EXISTS idx_key_expires_at ON cache(key, expires_at)`
-	err = ch.Database.Exec(ctx, sqlIndexKeyExpiresAt)
+	for _, sqlAddColumn := range legacyColumns {
+		_, err = tx.ExecContext(ctx, sqlAddColumn)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("adding column: %w", err)
+		}
+	}
+
+	// the index last_accessed_at lets the purges of the least recently used entries
+	// read the entries in order instead of sorting the table, and the index of the
+	// tombstones lets listing and purging them skip the live entries
+	err = migrate.SQL(
+		`CREATE INDEX IF NOT EXISTS idx_key_expires_at ON cache(key, expires_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_last_accessed_at ON cache(last_accessed_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_deleted_at
+			ON cache(deleted_at) WHERE deleted_at IS NOT NULL`,
+	)(ctx, tx)
 	if err != nil {
 		return fmt.Errorf("creating index: %w", err)
 	}
@@ -28,19 +70,72 @@ func (ch *cache) setupCacheTable(ctx context.Context) error {
 	return nil
 }
 
+// createDependenciesTable creates the table of the keys derived from other keys, see
+// SetDerived, and the index of the parents of a key, so that deleting a key drops its
+// dependencies without scanning the table.
+func createDependenciesTable(ctx context.Context, tx *sql.Tx) error {
+	err := queries.New(tx).CreateDependenciesTable(ctx)
+	if err != nil {
+		return fmt.Errorf("creating dependencies table: %w", err)
+	}
+
+	err = migrate.SQL(
+		`CREATE INDEX IF NOT EXISTS idx_dependencies_child ON cache_dependencies(child)`,
+	)(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("creating dependencies index: %w", err)
+	}
+
+	return nil
+}
+
+// setupCacheTable applies the migrations of the cache tables and sets up the queries.
+func (ch *cache) setupCacheTable(ctx context.Context) error {
+	ch.queries = queries.New(ch.Database.GetEngine(ctx))
+
+	err := migrate.NewMigrator(ch.Database, "cache").Migrate(ctx, migrations)
+	if err != nil {
+		return fmt.Errorf("migrating tables: %w", err)
+	}
+
+	return nil
+}
+
+// prepareQueries replaces the cache queries with prepared statements, so that the SQL
+// of each query is parsed once instead of on every call.
+// It must run after the cache table is set up, since the statements reference its columns.
+func (ch *cache) prepareQueries(ctx context.Context) error {
+	prepared, err := queries.Prepare(ctx, ch.Database.GetEngine(ctx))
+	if err != nil {
+		return fmt.Errorf("preparing queries: %w", err)
+	}
+	ch.queries = prepared
+
+	return nil
+}
+
 // setupCacheDatabase sets up the cache database with the given configuration.
 func (ch *cache) setupCacheDatabase(ctx context.Context) error {
-	err := ch.Database.SetJournalModeWal(ctx)
+	// The page size can't be changed once the database is in WAL mode.
+	err := ch.Database.SetPageSize(ctx, ch.pageSize)
 	if err != nil {
-		return fmt.Errorf("setting journal mode: %w", err)
+		return fmt.Errorf("setting page size: %w", err)
 	}
 
-	err = ch.Database.SetPageSize(ctx, ch.pageSize)
+	err = ch.Database.SetJournalModeWal(ctx)
 	if err != nil {
-		return fmt.Errorf("setting page size: %w", err)
+		return fmt.Errorf("setting journal mode: %w", err)
+	}
+
+	if ch.incrementalVacuumPages > 0 {
+		err = ch.Database.SetAutoVacuumIncremental(ctx)
+		if err != nil {
+			return fmt.Errorf("setting auto vacuum: %w", err)
+		}
 	}
 
-	err = ch.Database.SetCacheSize(ctx, ch.cacheSize)
+	// Cache size is the number of pages that SQLite keeps in memory.
+	err = ch.Database.SetCacheSize(ctx, ch.cacheSize/ch.pageSize)
 	if err != nil {
 		return fmt.Errorf("setting cache size: %w", err)
 	}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"testing"
@@ -10,93 +11,314 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
-	"github.com/lucasvillarinho/litepack/cache/queries"
+	"github.com/lucasvillarinho/litepack/database"
+	"github.com/lucasvillarinho/litepack/database/migrate"
 	"github.com/lucasvillarinho/litepack/database/mocks"
 )
 
 func TestCache_Setup(t *testing.T) {
-	db, sqlMock, err := sqlmock.New()
-	assert.NoError(t, err, "Expected no error while creating sqlmock")
-	defer db.Close()
+	// expectMigrations expects the migrations table to be read, returning the applied versions.
+	expectMigrations := func(sqlMock sqlmock.Sqlmock, versions ...int) {
+		sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS lpack_schema_migrations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		rows := sqlmock.NewRows([]string{"version"})
+		for _, version := range versions {
+			rows.AddRow(version)
+		}
+		sqlMock.ExpectQuery("SELECT version FROM lpack_schema_migrations").
+			WithArgs("cache").
+			WillReturnRows(rows)
+	}
 
-	dbMock := mocks.NewDatabaseMock(t)
+	// expectMigration expects the transaction of a migration to lock the migrations table
+	// and to find the version not applied.
+	expectMigration := func(sqlMock sqlmock.Sqlmock, version int) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec("UPDATE lpack_schema_migrations SET version = version WHERE 0").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM lpack_schema_migrations`).
+			WithArgs("cache", version).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
 
-	t.Run("should create the cache table successfully", func(t *testing.T) {
+	// expectCacheTable expects the first migration to create the cache table.
+	expectCacheTable := func(sqlMock sqlmock.Sqlmock, addColumnErr error) {
+		expectMigration(sqlMock, 1)
 		sqlMock.ExpectExec("(?i)CREATE TABLE IF NOT EXISTS cache").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		for range legacyColumns {
+			sqlMock.ExpectExec("ALTER TABLE cache ADD COLUMN").
+				WillReturnError(addColumnErr)
+		}
+		for range 3 {
+			sqlMock.ExpectExec("CREATE INDEX IF NOT EXISTS").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		sqlMock.ExpectExec("INSERT INTO lpack_schema_migrations").
+			WithArgs("cache", 1, "create_cache").
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+	}
 
+	newCache := func(t *testing.T, db *sql.DB) *cache {
 		dbMock := mocks.NewDatabaseMock(t)
 		dbMock.EXPECT().
 			GetEngine(mock.Anything).
 			Return(db)
-
 		dbMock.EXPECT().
-			Exec(mock.Anything, mock.Anything).
-			Return(nil)
+			GetUserVersion(mock.Anything).
+			Return(migrate.Layout, nil)
+		dbMock.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
 
-		ch := &cache{
-			queries:  queries.New(db),
-			Database: dbMock,
-		}
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
 
-		err := ch.setupCacheTable(context.Background())
+				return tx.Commit()
+			}).
+			Maybe()
+
+		return &cache{Database: dbMock}
+	}
 
-		assert.NoError(t, err, "Expected no error while creating the cache table")
+	t.Run("should apply the migrations of the cache tables", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		expectMigrations(sqlMock)
+		expectCacheTable(sqlMock, errors.New("duplicate column name: version"))
+		expectMigration(sqlMock, 2)
+		sqlMock.ExpectExec("(?i)CREATE TABLE IF NOT EXISTS cache_dependencies").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectExec("CREATE INDEX IF NOT EXISTS idx_dependencies_child").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectExec("INSERT INTO lpack_schema_migrations").
+			WithArgs("cache", 2, "create_cache_dependencies").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		ch := newCache(t, db)
+		err = ch.setupCacheTable(context.Background())
+
+		assert.NoError(t, err, "Expected no error while creating the cache tables")
+		assert.NotNil(t, ch.queries, "Expected the queries to be set up")
 		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
 	})
 
-	t.Run("should return an error if table creation fails", func(t *testing.T) {
-		sqlMock.ExpectExec("(?i)CREATE TABLE IF NOT EXISTS cache").
-			WillReturnError(fmt.Errorf("mock create table error"))
+	t.Run("should skip the applied migrations", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		expectMigrations(sqlMock, 1, 2)
+
+		err = newCache(t, db).setupCacheTable(context.Background())
+
+		assert.NoError(t, err, "Expected no error when the migrations are applied")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should upgrade the layout of a database created before the migrations", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		expectMigrations(sqlMock, 1, 2)
 
+		dbMock := mocks.NewDatabaseMock(t)
 		dbMock.EXPECT().
 			GetEngine(mock.Anything).
 			Return(db)
+		dbMock.EXPECT().
+			GetUserVersion(mock.Anything).
+			Return(0, nil)
+		dbMock.EXPECT().
+			SetUserVersion(mock.Anything, migrate.Layout).
+			Return(nil)
 
-		ch := &cache{
-			queries:  queries.New(db),
-			Database: dbMock,
-		}
+		ch := &cache{Database: dbMock}
+		err = ch.setupCacheTable(context.Background())
+
+		assert.NoError(t, err, "Expected no error while upgrading the layout")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
 
+	t.Run("should refuse a database with a newer layout", func(t *testing.T) {
+		dbMock := mocks.NewDatabaseMock(t)
+		dbMock.EXPECT().
+			GetEngine(mock.Anything).
+			Return(nil)
+		dbMock.EXPECT().
+			GetUserVersion(mock.Anything).
+			Return(migrate.Layout+1, nil)
+
+		ch := &cache{Database: dbMock}
 		err := ch.setupCacheTable(context.Background())
 
-		assert.Error(t, err, "Expected an error when table creation fails")
-		assert.Equal(
+		assert.ErrorIs(t, err, migrate.ErrUnsupportedLayout)
+	})
+
+	t.Run("should return an error if table creation fails", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		expectMigrations(sqlMock)
+		expectMigration(sqlMock, 1)
+		sqlMock.ExpectExec("(?i)CREATE TABLE IF NOT EXISTS cache").
+			WillReturnError(fmt.Errorf("mock create table error"))
+		sqlMock.ExpectRollback()
+
+		err = newCache(t, db).setupCacheTable(context.Background())
+
+		assert.EqualError(
 			t,
-			"creating table: mock create table error",
-			err.Error(),
-			"Expected error message to match",
+			err,
+			"migrating tables: applying migration 1 create_cache of cache: "+
+				"creating table: mock create table error",
 		)
 		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
 	})
 
-	t.Run("should return an error if index creation fails", func(t *testing.T) {
+	t.Run("should return an error if adding a column fails", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		expectMigrations(sqlMock)
+		expectMigration(sqlMock, 1)
 		sqlMock.ExpectExec("(?i)CREATE TABLE IF NOT EXISTS cache").
-			WillReturnResult(sqlmock.NewResult(1, 1))
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectExec("ALTER TABLE cache ADD COLUMN version").
+			WillReturnError(errors.New("database is locked"))
+		sqlMock.ExpectRollback()
+
+		err = newCache(t, db).setupCacheTable(context.Background())
+
+		assert.ErrorContains(t, err, "adding column: database is locked")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return an error if creating the dependencies table fails", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err, "Expected no error while creating sqlmock")
+		defer db.Close()
+
+		expectMigrations(sqlMock)
+		expectCacheTable(sqlMock, errors.New("duplicate column name: version"))
+		expectMigration(sqlMock, 2)
+		sqlMock.ExpectExec("(?i)CREATE TABLE IF NOT EXISTS cache_dependencies").
+			WillReturnError(errors.New("database is locked"))
+		sqlMock.ExpectRollback()
+
+		err = newCache(t, db).setupCacheTable(context.Background())
+
+		assert.ErrorContains(t, err, "creating dependencies table: database is locked")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should add the missing columns to a cache table of an older version", func(t *testing.T) {
+		ctx := context.Background()
+		db, err := database.NewDatabase(ctx, database.InMemory, "")
+		assert.NoError(t, err, "Expected no error while creating the database")
+		defer db.Close(ctx)
+
+		err = db.Exec(ctx, `CREATE TABLE cache (
+			key TEXT PRIMARY KEY,
+			value BLOB,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL,
+			last_accessed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			version INTEGER NOT NULL DEFAULT 1
+		)`)
+		assert.NoError(t, err, "Expected no error while creating the old cache table")
+
+		ch := &cache{Database: db}
+		err = ch.setupCacheTable(ctx)
+
+		assert.NoError(t, err, "Expected no error while migrating the old cache table")
+		var count int
+		err = db.QueryRow(
+			ctx,
+			`SELECT COUNT(*) FROM pragma_table_info('cache') WHERE name = 'deleted_at'`,
+		).Scan(&count)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count, "Expected the deleted_at column to be added")
+	})
+}
+
+func TestCache_PrepareQueries(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	t.Run("should return an error if preparing a query fails", func(t *testing.T) {
+		sqlMock.ExpectPrepare("UPDATE cache").
+			WillReturnError(fmt.Errorf("mock prepare error"))
 
 		dbMock := mocks.NewDatabaseMock(t)
 		dbMock.EXPECT().
 			GetEngine(mock.Anything).
 			Return(db)
 
-		dbMock.EXPECT().
-			Exec(mock.Anything, mock.Anything).
-			Return(errors.New("unexpected error"))
-
-		ch := &cache{
-			queries:  queries.New(db),
-			Database: dbMock,
-		}
+		ch := &cache{Database: dbMock}
 
-		err := ch.setupCacheTable(context.Background())
+		err := ch.prepareQueries(context.Background())
 
-		assert.Error(t, err, "Expected an error when index creation fails")
+		assert.Error(t, err, "Expected an error when preparing a query fails")
 		assert.Equal(
 			t,
-			"creating index: unexpected error",
+			"preparing queries: error preparing query AddAccess: mock prepare error",
 			err.Error(),
-			"Expected error message to match",
 		)
+		assert.Nil(t, ch.queries, "Expected the queries not to be replaced")
 		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
 	})
 }
+
+func TestCache_SetupCacheDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	newCache := func(dbMock *mocks.DatabaseMock) *cache {
+		return &cache{
+			Database:               dbMock,
+			pageSize:               4096,
+			cacheSize:              4096 * 256,
+			maxDBSize:              4096 * 100,
+			incrementalVacuumPages: 64,
+		}
+	}
+
+	t.Run("should enable the incremental auto vacuum", func(t *testing.T) {
+		dbMock := mocks.NewDatabaseMock(t)
+		dbMock.EXPECT().SetJournalModeWal(mock.Anything).Return(nil).Once()
+		dbMock.EXPECT().SetAutoVacuumIncremental(mock.Anything).Return(nil).Once()
+		dbMock.EXPECT().SetPageSize(mock.Anything, 4096).Return(nil).Once()
+		dbMock.EXPECT().SetCacheSize(mock.Anything, 256).Return(nil).Once()
+		dbMock.EXPECT().SetMaxPageCount(mock.Anything, 100).Return(nil).Once()
+
+		err := newCache(dbMock).setupCacheDatabase(ctx)
+
+		assert.NoError(t, err, "Expected no error while setting up the database")
+	})
+
+	t.Run("should return an error if enabling the incremental auto vacuum fails", func(t *testing.T) {
+		dbMock := mocks.NewDatabaseMock(t)
+		dbMock.EXPECT().SetPageSize(mock.Anything, 4096).Return(nil).Once()
+		dbMock.EXPECT().SetJournalModeWal(mock.Anything).Return(nil).Once()
+		dbMock.EXPECT().
+			SetAutoVacuumIncremental(mock.Anything).
+			Return(fmt.Errorf("database is locked")).
+			Once()
+
+		err := newCache(dbMock).setupCacheDatabase(ctx)
+
+		assert.EqualError(t, err, "setting auto vacuum: database is locked")
+	})
+}
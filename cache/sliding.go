@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// slideExpiration pushes the expiration of a key that was read forward by the TTL the
+// entry was written with, if sliding expiration is enabled. Entries without a TTL, such
+// as persisted or imported entries, keep their expiration.
+// The expiration is left as is while the cache is quiesced.
+func (ch *cache) slideExpiration(ctx context.Context, key string, now time.Time) {
+	if !ch.slidingTTL {
+		return
+	}
+
+	if !ch.writeMu.TryRLock() {
+		return
+	}
+	defer ch.writeMu.RUnlock()
+
+	ttl, err := ch.queries.GetEntryTTL(ctx, queries.GetEntryTTLParams{
+		Key:       key,
+		ExpiresAt: now,
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			ch.logger.Error(ctx, fmt.Sprintf("error getting ttl: %v", err))
+		}
+		return
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	// The TTL is checked again, so a concurrent write with another TTL is not overridden.
+	err = ch.queries.SlideExpiresAt(ctx, queries.SlideExpiresAtParams{
+		Key:       key,
+		Ttl:       ttl,
+		ExpiresAt: now.Add(time.Duration(ttl)),
+		Now:       now,
+	})
+	if err != nil {
+		ch.logger.Error(ctx, fmt.Sprintf("error sliding expiration: %v", err))
+	}
+}
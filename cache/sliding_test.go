@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
+)
+
+func TestCache_SlideExpiration(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	expectTTL := func(ttl time.Duration) {
+		sqlMock.ExpectQuery(`SELECT ttl\s+FROM cache\s+WHERE key = \? AND expires_at > \?`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"ttl"}).AddRow(int64(ttl)))
+	}
+
+	t.Run("should push the expiration forward by the ttl of the entry", func(t *testing.T) {
+		expectTTL(time.Minute)
+		sqlMock.ExpectExec(`UPDATE cache\s+SET expires_at = \?\s+WHERE key = \? AND ttl = \?`).
+			WithArgs(fixedTime.Add(time.Minute), "key", int64(time.Minute), fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		ch := &cache{queries: queries.New(db), slidingTTL: true}
+
+		ch.slideExpiration(ctx, "key", fixedTime)
+
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should keep the expiration of entries without a ttl", func(t *testing.T) {
+		expectTTL(0)
+		ch := &cache{queries: queries.New(db), slidingTTL: true}
+
+		ch.slideExpiration(ctx, "key", fixedTime)
+
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+
+	t.Run("should not slide the expiration if disabled", func(t *testing.T) {
+		ch := &cache{queries: queries.New(db)}
+
+		ch.slideExpiration(ctx, "key", fixedTime)
+
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected no queries to be run")
+	})
+
+	t.Run("should log the errors", func(t *testing.T) {
+		expectTTL(time.Minute)
+		sqlMock.ExpectExec(`UPDATE cache\s+SET expires_at = \?`).
+			WillReturnError(fmt.Errorf("mock update error"))
+		logger := logMocks.NewLoggerMock(t)
+		logger.EXPECT().Error(ctx, "error sliding expiration: mock update error")
+		ch := &cache{queries: queries.New(db), slidingTTL: true, logger: logger}
+
+		ch.slideExpiration(ctx, "key", fixedTime)
+
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Expected all expectations to be met")
+	})
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetStale retrieves the last value stored for a key even if its entry expired, along with
+// its age, the time since the value was stored. It is meant to serve stale values as a
+// fallback when their source is unavailable; expired entries are only kept until they
+// are purged.
+// GetStale doesn't refresh the access time of the entry nor slide its expiration.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//
+// Returns:
+//   - string: the last stored value
+//   - time.Duration: the age of the value
+//   - error: an error if the operation failed, ErrKeyNotFound if the key has no entry,
+//     ErrCorruptValue if the value does not match its checksum
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	rates, err := fetchRates(ctx)
+//	if err != nil {
+//		stale, age, staleErr := cache.GetStale(ctx, "rates")
+//		if staleErr != nil || age > 24*time.Hour {
+//			return err
+//		}
+//		rates = stale
+//	}
+func (ch *cache) GetStale(ctx context.Context, key string) (string, time.Duration, error) {
+	if err := ch.checkKey(key); err != nil {
+		return "", 0, err
+	}
+
+	ctx, cancel := ch.withOpTimeout(ctx)
+	defer cancel()
+
+	row, err := ch.reads().GetStaleEntry(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", 0, ErrKeyNotFound
+		}
+
+		return "", 0, fmt.Errorf("error getting stale value: %w", err)
+	}
+	if err := ch.verifyChecksum(ctx, key, row.Value, row.Checksum); err != nil {
+		return "", 0, err
+	}
+
+	// Entries stored before the write time was recorded fall back to their creation time.
+	storedAt := row.CreatedAt
+	if row.UpdatedAt.Valid {
+		storedAt = row.UpdatedAt.Time
+	}
+
+	return string(row.Value), max(ch.timeSource.Now().Sub(storedAt), 0), nil
+}
+
+// GetStale retrieves the last value stored for a key of the namespace, even if it expired.
+func (ns *namespace) GetStale(ctx context.Context, key string) (string, time.Duration, error) {
+	return ns.cache.GetStale(ctx, ns.key(key))
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_GetStale(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: time.UTC,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+	columns := []string{"value", "checksum", "updated_at", "created_at"}
+
+	t.Run("should return the value and the time since it was stored", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, updated_at, created_at FROM cache WHERE key = \?`).
+			WithArgs("key").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow([]byte("stale"), nil, fixedTime.Add(-time.Hour), fixedTime.Add(-2*time.Hour)))
+
+		value, age, err := ch.GetStale(ctx, "key")
+
+		assert.NoError(t, err, "Expected no error while getting the stale value")
+		assert.Equal(t, "stale", value)
+		assert.Equal(t, time.Hour, age)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should use the creation time of the entries without a write time", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, updated_at, created_at FROM cache WHERE key = \?`).
+			WithArgs("key").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow([]byte("stale"), nil, nil, fixedTime.Add(-2*time.Hour)))
+
+		_, age, err := ch.GetStale(ctx, "key")
+
+		assert.NoError(t, err, "Expected no error while getting the stale value")
+		assert.Equal(t, 2*time.Hour, age)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrKeyNotFound if the key has no entry", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, updated_at, created_at FROM cache WHERE key = \?`).
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+
+		_, _, err := ch.GetStale(ctx, "missing")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound, "Expected ErrKeyNotFound for a missing key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the query fails", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, checksum, updated_at, created_at FROM cache WHERE key = \?`).
+			WithArgs("key").
+			WillReturnError(fmt.Errorf("mock query error"))
+
+		_, _, err := ch.GetStale(ctx, "key")
+
+		assert.EqualError(t, err, "error getting stale value: mock query error")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
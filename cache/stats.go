@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CacheStats describes the usage of the cache.
+type CacheStats struct {
+	Tasks          []TaskStats `json:"tasks"`
+	Entries        int64       `json:"entries"`
+	ValueBytes     int64       `json:"value_bytes"`
+	DBSize         int64       `json:"db_size"`
+	MaxDBSize      int64       `json:"max_db_size"`
+	PageSize       int64       `json:"page_size"`
+	FreePages      int64       `json:"free_pages"`
+	ExpiredPurges  int64       `json:"expired_purges"`
+	SizePurges     int64       `json:"size_purges"`
+	EvictedEntries int64       `json:"evicted_entries"`
+	MemoryEntries  int64       `json:"memory_entries"`
+	MemoryBytes    int64       `json:"memory_bytes"`
+}
+
+// Stats returns the usage of the cache: the number of live entries, the bytes used by
+// their values, the size of the database file and the purges executed since the cache
+// was opened.
+// When DBSize approaches MaxDBSize the cache starts purging entries on writes; FreePages
+// are pages that can be reused by new entries before the database file grows.
+// MemoryEntries and MemoryBytes describe the in-memory tier, if enabled.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - CacheStats: the usage of the cache
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	stats, err := cache.Stats(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	log.Printf("cache is %d%% full", stats.DBSize*100/stats.MaxDBSize)
+func (ch *cache) Stats(ctx context.Context) (CacheStats, error) {
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+
+	usage, err := ch.queries.GetCacheUsage(ctx, now)
+	if err != nil {
+		return CacheStats{}, fmt.Errorf("getting cache usage: %w", err)
+	}
+
+	return ch.statsWithUsage(ctx, usage.Entries, usage.ValueBytes)
+}
+
+// statsWithUsage completes the given entry usage with the database and purge stats.
+func (ch *cache) statsWithUsage(
+	ctx context.Context,
+	entries, valueBytes int64,
+) (CacheStats, error) {
+	pages, err := ch.queries.GetPageStats(ctx)
+	if err != nil {
+		return CacheStats{}, fmt.Errorf("getting page stats: %w", err)
+	}
+
+	memoryEntries, memoryBytes := ch.memory.len()
+
+	return CacheStats{
+		Entries:        entries,
+		ValueBytes:     valueBytes,
+		DBSize:         pages.PageCount * pages.PageSize,
+		MaxDBSize:      int64(ch.maxDBSize),
+		PageSize:       pages.PageSize,
+		FreePages:      pages.FreelistCount,
+		ExpiredPurges:  ch.purgeCounters.expiredPurges.Load(),
+		SizePurges:     ch.purgeCounters.sizePurges.Load(),
+		EvictedEntries: ch.purgeCounters.evictedEntries.Load(),
+		MemoryEntries:  int64(memoryEntries),
+		MemoryBytes:    int64(memoryBytes),
+		Tasks:          ch.SchedulerStats(ctx),
+	}, nil
+}
+
+// TaskStats describes the state of a background task of the cache.
+type TaskStats struct {
+	LastRun             time.Time     `json:"last_run"`
+	NextRun             time.Time     `json:"next_run"`
+	Name                string        `json:"name"`
+	Schedule            string        `json:"schedule"`
+	LastError           string        `json:"last_error,omitempty"`
+	Runs                int64         `json:"runs"`
+	Failures            int64         `json:"failures"`
+	ConsecutiveFailures int64         `json:"consecutive_failures"`
+	LastDuration        time.Duration `json:"last_duration"`
+	TotalDuration       time.Duration `json:"total_duration"`
+}
+
+// SchedulerStats returns the state of the background tasks of the cache,
+// such as the job that deletes expired entries.
+// A task with consecutive failures indicates that the cache is not being maintained.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - []TaskStats: the state of each registered task
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	for _, task := range cache.SchedulerStats(ctx) {
+//		if task.ConsecutiveFailures > 0 {
+//			log.Printf("task %s is failing: %s", task.Name, task.LastError)
+//		}
+//	}
+func (ch *cache) SchedulerStats(_ context.Context) []TaskStats {
+	tasks := ch.cron.Tasks()
+	owned := ch.ownedTasks()
+
+	stats := make([]TaskStats, 0, len(tasks))
+	for _, task := range tasks {
+		// A scheduler given by WithScheduler also runs the tasks of other subsystems.
+		if owned != nil {
+			if _, ok := owned[task.ID]; !ok {
+				continue
+			}
+		}
+		stats = append(stats, TaskStats{
+			Name:                task.Name,
+			Schedule:            task.Schedule,
+			LastRun:             task.LastRun,
+			NextRun:             task.NextRun,
+			LastError:           task.LastError,
+			Runs:                task.Runs,
+			Failures:            task.Failures,
+			ConsecutiveFailures: task.ConsecutiveFailures,
+			LastDuration:        task.LastDuration,
+			TotalDuration:       task.TotalDuration,
+		})
+	}
+
+	return stats
+}
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	crf "github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	"github.com/lucasvillarinho/litepack/internal/cron"
+	cronMocks "github.com/lucasvillarinho/litepack/internal/cron/mocks"
+)
+
+func TestCache_SchedulerStats(t *testing.T) {
+	t.Run("should report the state of the scheduled tasks", func(t *testing.T) {
+		lastRun := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+		cronMock := cronMocks.NewCronMock(t)
+		cronMock.EXPECT().
+			Tasks().
+			Return([]cron.TaskStatus{
+				{
+					ID:                  1,
+					Name:                taskPurgeExpired,
+					Schedule:            string(cron.EveryMinute),
+					LastRun:             lastRun,
+					NextRun:             lastRun.Add(time.Minute),
+					LastError:           "database is locked",
+					Runs:                10,
+					Failures:            3,
+					ConsecutiveFailures: 3,
+					LastDuration:        time.Second,
+					TotalDuration:       10 * time.Second,
+				},
+			})
+
+		ch := &cache{cron: cronMock}
+
+		stats := ch.SchedulerStats(context.Background())
+
+		assert.Equal(t, []TaskStats{
+			{
+				Name:                taskPurgeExpired,
+				Schedule:            string(cron.EveryMinute),
+				LastRun:             lastRun,
+				NextRun:             lastRun.Add(time.Minute),
+				LastError:           "database is locked",
+				Runs:                10,
+				Failures:            3,
+				ConsecutiveFailures: 3,
+				LastDuration:        time.Second,
+				TotalDuration:       10 * time.Second,
+			},
+		}, stats)
+	})
+
+	t.Run("should report only the tasks of the cache on a shared scheduler", func(t *testing.T) {
+		cronMock := cronMocks.NewCronMock(t)
+		cronMock.EXPECT().
+			Tasks().
+			Return([]cron.TaskStatus{
+				{ID: 1, Name: taskPurgeExpired, Schedule: string(cron.EveryMinute)},
+				{ID: 2, Name: "report", Schedule: string(cron.EveryMinute)},
+			})
+
+		ch := &cache{cron: cronMock, sharedCron: true, taskIDs: []crf.EntryID{1}}
+
+		stats := ch.SchedulerStats(context.Background())
+
+		assert.Equal(t, []TaskStats{
+			{Name: taskPurgeExpired, Schedule: string(cron.EveryMinute)},
+		}, stats)
+	})
+}
+
+func TestCache_Stats(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	newCache := func(t *testing.T) *cache {
+		cronMock := cronMocks.NewCronMock(t)
+		cronMock.EXPECT().Tasks().Return([]cron.TaskStatus{}).Maybe()
+
+		return &cache{
+			queries:   queries.New(db),
+			cron:      cronMock,
+			maxDBSize: 1024 * 1024,
+			timeSource: timeSource{
+				Timezone: tz,
+				Now:      func() time.Time { return fixedTime },
+			},
+		}
+	}
+
+	t.Run("should report the usage of the cache", func(t *testing.T) {
+		ch := newCache(t)
+		ch.purgeCounters.expiredPurges.Add(5)
+		ch.purgeCounters.sizePurges.Add(1)
+		ch.purgeCounters.evictedEntries.Add(20)
+
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries, .* FROM cache WHERE expires_at > \?`).
+			WithArgs(fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(10, 2048))
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count FROM pragma_page_count\(\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"page_count", "page_size", "freelist_count"}).
+				AddRow(16, 4096, 2))
+
+		stats, err := ch.Stats(context.Background())
+
+		assert.NoError(t, err, "Expected no error while getting stats")
+		assert.Equal(t, CacheStats{
+			Tasks:          []TaskStats{},
+			Entries:        10,
+			ValueBytes:     2048,
+			DBSize:         16 * 4096,
+			MaxDBSize:      1024 * 1024,
+			PageSize:       4096,
+			FreePages:      2,
+			ExpiredPurges:  5,
+			SizePurges:     1,
+			EvictedEntries: 20,
+		}, stats)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the usage query fails", func(t *testing.T) {
+		ch := newCache(t)
+
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries`).
+			WithArgs(fixedTime).
+			WillReturnError(fmt.Errorf("mock select error"))
+
+		stats, err := ch.Stats(context.Background())
+
+		assert.Error(t, err, "Expected an error when the usage query fails")
+		assert.Equal(t, "getting cache usage: mock select error", err.Error())
+		assert.Equal(t, CacheStats{}, stats)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the page stats query fails", func(t *testing.T) {
+		ch := newCache(t)
+
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) AS entries`).
+			WithArgs(fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"entries", "value_bytes"}).AddRow(0, 0))
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count`).
+			WillReturnError(fmt.Errorf("mock pragma error"))
+
+		stats, err := ch.Stats(context.Background())
+
+		assert.Error(t, err, "Expected an error when the page stats query fails")
+		assert.Equal(t, "getting page stats: mock pragma error", err.Error())
+		assert.Equal(t, CacheStats{}, stats)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// taskPurgeTombstones is the name of the task that deletes the tombstones older than
+// the retention.
+const taskPurgeTombstones = "purge-tombstones"
+
+// ErrTombstonesDisabled is returned by Tombstones when the cache was created without
+// WithTombstones.
+var ErrTombstonesDisabled = fmt.Errorf("tombstones disabled")
+
+// Tombstone is a key deleted while tombstones are enabled, see WithTombstones.
+type Tombstone struct {
+	DeletedAt time.Time `json:"deleted_at"`
+	Key       string    `json:"key"`
+}
+
+// Tombstones returns the keys deleted after since, in the order they were deleted, so
+// that a synchronization job can apply the deletions instead of inferring them from
+// the keys missing in the cache. Pass the DeletedAt of the last tombstone applied to
+// read the next ones.
+//
+// The tombstones older than the retention of WithTombstones are deleted, so a job that
+// falls further behind must rebuild its copy, for example with Export.
+//
+// Parameters:
+//   - ctx: the context
+//   - since: the deletion time of the last tombstone applied, the zero time to read
+//     every tombstone kept
+//
+// Returns:
+//   - []Tombstone: the tombstones
+//   - error: ErrTombstonesDisabled if the cache was created without WithTombstones
+//
+// Example:
+//
+//	tombstones, err := cache.Tombstones(ctx, lastSync)
+//	for _, tombstone := range tombstones {
+//		remote.Delete(tombstone.Key)
+//		lastSync = tombstone.DeletedAt
+//	}
+func (ch *cache) Tombstones(ctx context.Context, since time.Time) ([]Tombstone, error) {
+	if ch.tombstoneRetention <= 0 {
+		return nil, ErrTombstonesDisabled
+	}
+
+	since = since.In(ch.timeSource.Timezone)
+	rows, err := ch.reads().ListTombstones(ctx, sql.NullTime{Time: since, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("listing tombstones: %w", err)
+	}
+
+	tombstones := make([]Tombstone, len(rows))
+	for i, row := range rows {
+		tombstones[i] = Tombstone{
+			DeletedAt: row.DeletedAt.Time,
+			Key:       row.Key,
+		}
+	}
+
+	return tombstones, nil
+}
+
+// Tombstones returns the keys of the namespace deleted after since, without the prefix
+// of the namespace.
+func (ns *namespace) Tombstones(ctx context.Context, since time.Time) ([]Tombstone, error) {
+	tombstones, err := ns.cache.Tombstones(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := make([]Tombstone, 0, len(tombstones))
+	for _, tombstone := range tombstones {
+		key, ok := strings.CutPrefix(tombstone.Key, ns.prefix)
+		if !ok {
+			continue
+		}
+		tombstone.Key = key
+		scoped = append(scoped, tombstone)
+	}
+
+	return scoped, nil
+}
+
+// deleteKey deletes the entry of the key with q, or replaces it with a tombstone if
+// tombstones are enabled.
+func (ch *cache) deleteKey(ctx context.Context, q *queries.Queries, key string) error {
+	if ch.tombstoneRetention <= 0 {
+		return q.DeleteKey(ctx, key)
+	}
+
+	return q.TombstoneKey(ctx, queries.TombstoneKeyParams{
+		Now: ch.timeSource.Now().In(ch.timeSource.Timezone),
+		Key: key,
+	})
+}
+
+// deleteKeysByPattern deletes the entries whose key matches the pattern, or replaces
+// them with tombstones if tombstones are enabled, and returns their keys.
+func (ch *cache) deleteKeysByPattern(ctx context.Context, pattern string) ([]string, error) {
+	if ch.tombstoneRetention <= 0 {
+		return ch.queries.DeleteKeysByPattern(ctx, pattern)
+	}
+
+	return ch.queries.TombstoneKeysByPattern(ctx, queries.TombstoneKeysByPatternParams{
+		Now:     ch.timeSource.Now().In(ch.timeSource.Timezone),
+		Pattern: pattern,
+	})
+}
+
+// purgeTombstones deletes the tombstones older than the retention.
+func (ch *cache) purgeTombstones(ctx context.Context) error {
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	before := ch.timeSource.Now().In(ch.timeSource.Timezone).Add(-ch.tombstoneRetention)
+
+	_, err := ch.queries.PurgeTombstones(ctx, sql.NullTime{Time: before, Valid: true})
+	if err != nil {
+		return fmt.Errorf("purging tombstones: %w", err)
+	}
+
+	return nil
+}
+
+// purgeTombstonesCache schedules the deletion of the tombstones older than the retention
+// on the sync interval, if tombstones are enabled.
+func (ch *cache) purgeTombstonesCache(ctx context.Context) {
+	if ch.tombstoneRetention <= 0 {
+		return
+	}
+
+	task := func() error {
+		err := ch.purgeTombstones(ctx)
+		if err != nil {
+			ch.logger.Error(ctx, err.Error())
+			return err
+		}
+
+		return nil
+	}
+
+	err := ch.addTask(taskPurgeTombstones, string(ch.syncInterval), task)
+	if err != nil {
+		err = fmt.Errorf("adding cron task: %w", err)
+		ch.logger.Error(ctx, err.Error())
+	}
+}
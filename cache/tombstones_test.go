@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+func TestCache_Tombstones(t *testing.T) {
+	ctx := context.Background()
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+	ch := &cache{
+		queries:            queries.New(db),
+		tombstoneRetention: time.Hour,
+		timeSource: timeSource{
+			Timezone: time.UTC,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should replace a deleted entry with a tombstone", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET value = NULL, .* pinned = 1, .* WHERE key = \?2 AND deleted_at IS NULL`).
+			WithArgs(fixedTime, "user:1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := ch.Del(ctx, "user:1")
+
+		assert.NoError(t, err, "Expected no error while deleting the key")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should replace the entries matching a pattern with tombstones", func(t *testing.T) {
+		sqlMock.ExpectQuery(`UPDATE cache SET value = NULL, .* WHERE key GLOB \?2 AND deleted_at IS NULL RETURNING key`).
+			WithArgs(fixedTime, "user:*").
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("user:1").AddRow("user:2"))
+
+		deleted, err := ch.DelPattern(ctx, "user:*")
+
+		assert.NoError(t, err, "Expected no error while deleting the keys")
+		assert.Equal(t, int64(2), deleted)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should list the tombstones after a time", func(t *testing.T) {
+		since := fixedTime.Add(-time.Minute)
+		sqlMock.ExpectQuery(`SELECT key, deleted_at FROM cache WHERE deleted_at > \? ORDER BY deleted_at, key`).
+			WithArgs(since).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "deleted_at"}).
+				AddRow("user:1", fixedTime).
+				AddRow("user:2", fixedTime))
+
+		tombstones, err := ch.Tombstones(ctx, since)
+
+		assert.NoError(t, err, "Expected no error while listing the tombstones")
+		assert.Equal(t, []Tombstone{
+			{Key: "user:1", DeletedAt: fixedTime},
+			{Key: "user:2", DeletedAt: fixedTime},
+		}, tombstones)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should delete the tombstones older than the retention", func(t *testing.T) {
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE deleted_at <= \?`).
+			WithArgs(fixedTime.Add(-time.Hour)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		err := ch.purgeTombstones(ctx)
+
+		assert.NoError(t, err, "Expected no error while purging the tombstones")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return an error if purging the tombstones fails", func(t *testing.T) {
+		sqlMock.ExpectExec(`DELETE FROM cache WHERE deleted_at <= \?`).
+			WillReturnError(fmt.Errorf("database is locked"))
+
+		err := ch.purgeTombstones(ctx)
+
+		assert.EqualError(t, err, "purging tombstones: database is locked")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrTombstonesDisabled without WithTombstones", func(t *testing.T) {
+		_, err := (&cache{}).Tombstones(ctx, time.Time{})
+
+		assert.ErrorIs(t, err, ErrTombstonesDisabled)
+	})
+}
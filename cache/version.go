@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+)
+
+// GetWithVersion retrieves a value from the cache by key along with its version.
+// The version increases every time the value of the entry is written, so it can be
+// passed to SetIfVersion to update the entry only if nobody changed it in the meantime.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//
+// Returns:
+//   - string: the cache value
+//   - int64: the version of the entry
+//   - error: an error if the operation failed, ErrKeyNotFound if the key does not exist,
+//     ErrCorruptValue if the value does not match its checksum
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	value, version, err := cache.GetWithVersion(ctx, "counter")
+//	if err != nil {
+//		return err
+//	}
+func (ch *cache) GetWithVersion(ctx context.Context, key string) (string, int64, error) {
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	params := queries.GetValueWithVersionParams{
+		Key:       key,
+		ExpiresAt: now,
+	}
+
+	row, err := ch.reads().GetValueWithVersion(ctx, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ch.metrics.misses.Add(1)
+			return "", 0, ch.missError(ctx, key, now)
+		}
+
+		return "", 0, fmt.Errorf("error getting value: %w", err)
+	}
+	if err := ch.verifyChecksum(ctx, key, row.Value, row.Checksum); err != nil {
+		return "", 0, err
+	}
+	ch.metrics.hits.Add(1)
+
+	// Buffer the access time instead of writing it, if enabled.
+	if ch.access.record(now, key) {
+		return string(row.Value), row.Version, nil
+	}
+
+	// Skip the access time update while the cache is quiesced.
+	if !ch.writeMu.TryRLock() {
+		return string(row.Value), row.Version, nil
+	}
+	defer ch.writeMu.RUnlock()
+
+	err = ch.queries.UpdateLastAccessedAt(ctx, queries.UpdateLastAccessedAtParams{
+		LastAccessedAt: now,
+		Key:            key,
+	})
+	if err != nil {
+		ch.logger.Error(ctx, fmt.Sprintf("error updating last accessed at: %v", err))
+	}
+
+	return string(row.Value), row.Version, nil
+}
+
+// SetIfVersion sets a key-value pair in the cache only if the entry still has the given
+// version, which makes it safe to update entries shared by several processes without locks.
+// A version of 0 creates the entry only if the key does not exist or is expired.
+//
+// Parameters:
+//   - ctx: the context
+//   - key: the cache key
+//   - value: the cache value
+//   - version: the version returned by GetWithVersion, or 0 to create the entry
+//   - ttl: the time-to-live for the cache entry
+//
+// Returns:
+//   - error: an error if the operation failed, ErrVersionMismatch if the entry changed
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx)
+//	defer cache.Close(ctx)
+//
+//	value, version, err := cache.GetWithVersion(ctx, "counter")
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = cache.SetIfVersion(ctx, "counter", increment(value), version, time.Hour)
+//	if errors.Is(err, cache.ErrVersionMismatch) {
+//		// another process updated the counter, read it again and retry
+//	}
+func (ch *cache) SetIfVersion(
+	ctx context.Context,
+	key, value string,
+	version int64,
+	ttl time.Duration,
+) error {
+	if version == 0 {
+		ok, err := ch.SetNX(ctx, key, value, ttl)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrVersionMismatch
+		}
+
+		return nil
+	}
+
+	if err := ch.checkKey(key); err != nil {
+		return err
+	}
+	if err := ch.checkValueSize(len(value)); err != nil {
+		return err
+	}
+
+	ch.writeMu.RLock()
+	defer ch.writeMu.RUnlock()
+
+	now := ch.timeSource.Now().In(ch.timeSource.Timezone)
+	params := queries.UpdateCacheIfVersionParams{
+		Key:            key,
+		Value:          []byte(value),
+		Version:        version,
+		ExpiresAt:      ch.expiresAt(now, ttl),
+		LastAccessedAt: now,
+		Ttl:            int64(ttl),
+		Now:            now,
+	}
+	params.Checksum = ch.checksum(params.Value)
+
+	updated, err := ch.queries.UpdateCacheIfVersion(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error setting cache: %w", err)
+	}
+	if updated == 0 {
+		return ErrVersionMismatch
+	}
+	ch.memory.set(key, params.Value, params.ExpiresAt)
+	ch.metrics.sets.Add(1)
+	ch.hooks.set(ctx, key)
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache/queries"
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
+)
+
+func TestCache_GetWithVersion(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+
+	ch := &cache{
+		queries: queries.New(db),
+		logger:  logMocks.NewLoggerMock(t),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should return the value and its version", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, version, checksum FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("key", fixedTime).
+			WillReturnRows(sqlmock.NewRows([]string{"value", "version", "checksum"}).AddRow([]byte("value"), 3, nil))
+		sqlMock.ExpectExec(`UPDATE cache SET last_accessed_at = \?, access_count = access_count \+ 1 WHERE key = \?`).
+			WithArgs(fixedTime, "key").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		value, version, err := ch.GetWithVersion(context.Background(), "key")
+
+		assert.NoError(t, err, "Expected no error when getting the value")
+		assert.Equal(t, "value", value)
+		assert.Equal(t, int64(3), version)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrKeyNotFound if the key does not exist", func(t *testing.T) {
+		sqlMock.ExpectQuery(`SELECT value, version, checksum FROM cache WHERE key = \? AND expires_at > \?`).
+			WithArgs("missing", fixedTime).
+			WillReturnError(sql.ErrNoRows)
+
+		value, version, err := ch.GetWithVersion(context.Background(), "missing")
+
+		assert.ErrorIs(t, err, ErrKeyNotFound, "Expected ErrKeyNotFound for a missing key")
+		assert.Empty(t, value)
+		assert.Zero(t, version)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
+
+func TestCache_SetIfVersion(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err, "Expected no error while creating sqlmock")
+	defer db.Close()
+
+	tz := time.FixedZone("UTC", 0)
+	fixedTime := time.Date(2024, 11, 22, 12, 0, 0, 0, tz)
+	ctx := context.Background()
+
+	ch := &cache{
+		queries: queries.New(db),
+		timeSource: timeSource{
+			Timezone: tz,
+			Now:      func() time.Time { return fixedTime },
+		},
+	}
+
+	t.Run("should update the entry if the version matches", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET value = \?, expires_at = \?, last_accessed_at = \?, ttl = \?, checksum = \?, updated_at = \?3, version = version \+ 1 WHERE key = \? AND version = \? AND expires_at > \?`).
+			WithArgs([]byte("new"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, "key", int64(3), fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := ch.SetIfVersion(ctx, "key", "new", 3, time.Hour)
+
+		assert.NoError(t, err, "Expected no error when the version matches")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrVersionMismatch if the entry changed", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET value = \?`).
+			WithArgs([]byte("new"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, "key", int64(3), fixedTime).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := ch.SetIfVersion(ctx, "key", "new", 3, time.Hour)
+
+		assert.ErrorIs(t, err, ErrVersionMismatch, "Expected ErrVersionMismatch when the entry changed")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should create the entry when the version is zero", func(t *testing.T) {
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("new"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("new")))
+
+		err := ch.SetIfVersion(ctx, "key", "new", 0, time.Hour)
+
+		assert.NoError(t, err, "Expected no error when creating the entry")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return ErrVersionMismatch if the entry exists when the version is zero", func(t *testing.T) {
+		sqlMock.ExpectQuery(`INSERT INTO cache .* RETURNING value`).
+			WithArgs("key", []byte("new"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil).
+			WillReturnError(sql.ErrNoRows)
+
+		err := ch.SetIfVersion(ctx, "key", "new", 0, time.Hour)
+
+		assert.ErrorIs(t, err, ErrVersionMismatch, "Expected ErrVersionMismatch when the entry exists")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+
+	t.Run("should return error if the update fails", func(t *testing.T) {
+		sqlMock.ExpectExec(`UPDATE cache SET value = \?`).
+			WithArgs([]byte("new"), fixedTime.Add(time.Hour), fixedTime, int64(time.Hour), nil, "key", int64(3), fixedTime).
+			WillReturnError(fmt.Errorf("mock update error"))
+
+		err := ch.SetIfVersion(ctx, "key", "new", 3, time.Hour)
+
+		assert.Error(t, err, "Expected an error when the update fails")
+		assert.Equal(t, "error setting cache: mock update error", err.Error())
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all expectations were met")
+	})
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Setter stores entries in the cache. It is the part of the cache given to a warm-up function.
+type Setter interface {
+	Set(ctx context.Context, key string, value string, ttl time.Duration, opts ...SetOption) error
+	SetBytes(ctx context.Context, key string, value []byte, ttl time.Duration, opts ...SetOption) error
+}
+
+// WarmupFunc pre-populates the cache when it is created, for example with the hot keys
+// read from a file or an upstream service.
+type WarmupFunc func(ctx context.Context, set Setter) error
+
+// warmup is the warm-up function of the cache and how it is run.
+type warmup struct {
+	fn    WarmupFunc
+	async bool
+}
+
+// warmUp runs the warm-up function, if any, with the given setter, the view of the cache
+// returned by NewCache, so that the keys get the prefix of the cache.
+// An asynchronous warm-up runs in the background and its error is logged; its context
+// is cancelled when the cache is closed, not when the context given to NewCache is.
+func (ch *cache) warmUp(ctx context.Context, set Setter) error {
+	if ch.warmup.fn == nil {
+		return nil
+	}
+
+	if ch.warmup.async {
+		ch.jobs.Add(1)
+		go func() {
+			defer ch.jobs.Done()
+			if err := ch.warmup.fn(ch.background, set); err != nil {
+				ch.logger.Error(ch.background, fmt.Sprintf("warming up cache: %v", err))
+			}
+		}()
+		return nil
+	}
+
+	err := ch.warmup.fn(ctx, set)
+	if err != nil {
+		return fmt.Errorf("warming up cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	logMocks "github.com/lucasvillarinho/litepack/internal/log/mocks"
+)
+
+func TestCache_WarmUp(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should run the warm-up with the given setter", func(t *testing.T) {
+		var setter Setter
+		ch := &cache{}
+		view := &namespace{cache: ch, prefix: "svc-a:"}
+		ch.warmup = warmup{fn: func(_ context.Context, set Setter) error {
+			setter = set
+			return nil
+		}}
+
+		err := ch.warmUp(ctx, view)
+
+		assert.NoError(t, err, "Expected no error while warming up")
+		assert.Same(t, view, setter, "Expected the view of the cache to be the setter")
+	})
+
+	t.Run("should return the error of a synchronous warm-up", func(t *testing.T) {
+		ch := &cache{}
+		ch.warmup = warmup{fn: func(context.Context, Setter) error {
+			return fmt.Errorf("upstream unavailable")
+		}}
+
+		err := ch.warmUp(ctx, ch)
+
+		assert.EqualError(t, err, "warming up cache: upstream unavailable")
+	})
+
+	t.Run("should log the error of an asynchronous warm-up", func(t *testing.T) {
+		logged := make(chan string, 1)
+		loggerMock := logMocks.NewLoggerMock(t)
+		loggerMock.EXPECT().
+			Error(mock.Anything, mock.Anything).
+			Run(func(_ context.Context, msg string) { logged <- msg }).
+			Once()
+
+		ch := &cache{logger: loggerMock, background: ctx}
+		ch.warmup = warmup{async: true, fn: func(context.Context, Setter) error {
+			return fmt.Errorf("upstream unavailable")
+		}}
+
+		err := ch.warmUp(ctx, ch)
+
+		assert.NoError(t, err, "Expected no error for an asynchronous warm-up")
+		assert.Equal(t, "warming up cache: upstream unavailable", <-logged)
+	})
+
+	t.Run("should do nothing without a warm-up", func(t *testing.T) {
+		err := (&cache{}).warmUp(ctx, nil)
+
+		assert.NoError(t, err, "Expected no error without a warm-up")
+	})
+}
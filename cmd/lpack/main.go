@@ -0,0 +1,234 @@
+// Command lpack inspects and repairs litepack cache databases.
+//
+// Usage:
+//
+//	lpack [-db path] <command> [arguments]
+//
+// The -db flag is the directory of the cache or the path of its lpack_cache.db file,
+// the current directory by default.
+//
+// Commands:
+//
+//	get <key>                    prints the value of a key
+//	set [-ttl 1h] <key> <value>  sets the value of a key
+//	del <key>                    deletes a key
+//	keys [pattern]               lists the keys matching a glob pattern, all by default
+//	stats                        prints the usage of the cache
+//	purge                        deletes the expired entries
+//	compact                      deletes the expired entries and vacuums the database
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// dbName is the name of the database file of a cache.
+const dbName = "lpack_cache.db"
+
+// errUsage is returned when the command line is invalid.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command line and returns the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lpack", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { usage(stderr) }
+	dbPath := flags.String("db", ".", "the cache directory or its "+dbName+" file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	err := execute(ctx, *dbPath, flags.Args(), stdout)
+	if errors.Is(err, errUsage) {
+		usage(stderr)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "lpack: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// usage prints the usage of the command.
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage: lpack [-db path] <command> [arguments]
+
+Commands:
+  get <key>                    prints the value of a key
+  set [-ttl 1h] <key> <value>  sets the value of a key
+  del <key>                    deletes a key
+  keys [pattern]               lists the keys matching a glob pattern
+  stats                        prints the usage of the cache
+  purge                        deletes the expired entries
+  compact                      deletes the expired entries and vacuums the database
+`)
+}
+
+// execute opens the cache and runs a command on it.
+func execute(ctx context.Context, dbPath string, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	dir, err := cacheDir(dbPath)
+	if err != nil {
+		return err
+	}
+
+	lpCache, err := cache.NewCache(ctx, cache.WithPath(dir))
+	if err != nil {
+		return fmt.Errorf("opening cache: %w", err)
+	}
+	defer lpCache.Close(ctx)
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "get":
+		return get(ctx, lpCache, args, stdout)
+	case "set":
+		return set(ctx, lpCache, args)
+	case "del":
+		return del(ctx, lpCache, args)
+	case "keys":
+		return keys(ctx, lpCache, args, stdout)
+	case "stats":
+		return stats(ctx, lpCache, stdout)
+	case "purge":
+		return lpCache.PurgeExpiredItems(ctx)
+	case "compact":
+		return compact(ctx, lpCache, stdout)
+	default:
+		return fmt.Errorf("unknown command %q: %w", command, errUsage)
+	}
+}
+
+// cacheDir returns the directory of an existing cache from the path of the directory or
+// of its database file, so that a typo does not create an empty cache.
+func cacheDir(dbPath string) (string, error) {
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return "", fmt.Errorf("opening database: %w", err)
+	}
+
+	dir := dbPath
+	if !info.IsDir() {
+		if filepath.Base(dbPath) != dbName {
+			return "", fmt.Errorf("opening database: %s is not a %s file", dbPath, dbName)
+		}
+		dir = filepath.Dir(dbPath)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, dbName)); err != nil {
+		return "", fmt.Errorf("opening database: %w", err)
+	}
+
+	return dir, nil
+}
+
+// get prints the value of a key.
+func get(ctx context.Context, lpCache cache.Cache, args []string, stdout io.Writer) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	value, err := lpCache.GetBytes(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "%s\n", value)
+	return err
+}
+
+// set sets the value of a key.
+func set(ctx context.Context, lpCache cache.Cache, args []string) error {
+	flags := flag.NewFlagSet("set", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	ttl := flags.Duration("ttl", time.Hour, "the time-to-live of the entry")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != 2 {
+		return errUsage
+	}
+
+	return lpCache.Set(ctx, flags.Arg(0), flags.Arg(1), *ttl)
+}
+
+// del deletes a key.
+func del(ctx context.Context, lpCache cache.Cache, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	return lpCache.Del(ctx, args[0])
+}
+
+// keys prints the keys matching a glob pattern, one per line.
+func keys(ctx context.Context, lpCache cache.Cache, args []string, stdout io.Writer) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+
+	pattern := "*"
+	if len(args) == 1 {
+		pattern = args[0]
+	}
+
+	matched, err := lpCache.Keys(ctx, pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range matched {
+		if _, err := fmt.Fprintln(stdout, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stats prints the usage of the cache as JSON.
+func stats(ctx context.Context, lpCache cache.Cache, stdout io.Writer) error {
+	cacheStats, err := lpCache.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(stdout, cacheStats)
+}
+
+// compact runs the maintenance of the cache and prints its stats as JSON.
+func compact(ctx context.Context, lpCache cache.Cache, stdout io.Writer) error {
+	result, err := lpCache.Compact(ctx)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(stdout, result)
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
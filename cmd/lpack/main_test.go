@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	lpCache, err := cache.NewCache(ctx, cache.WithPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = lpCache.Set(ctx, "user:1", "John", time.Hour)
+	_ = lpCache.Set(ctx, "order:1", "book", time.Hour)
+	if err := lpCache.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	lpack := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(ctx, append([]string{"-db", dir}, args...), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	t.Run("should get a value", func(t *testing.T) {
+		code, stdout, _ := lpack("get", "user:1")
+
+		assert.Equal(t, 0, code, "Expected exit code 0")
+		assert.Equal(t, "John\n", stdout, "Expected the value")
+	})
+
+	t.Run("should fail when the key does not exist", func(t *testing.T) {
+		code, _, stderr := lpack("get", "missing")
+
+		assert.Equal(t, 1, code, "Expected exit code 1")
+		assert.Contains(t, stderr, "key not found", "Expected the error")
+	})
+
+	t.Run("should set and delete a value", func(t *testing.T) {
+		code, _, _ := lpack("set", "-ttl", "10m", "user:2", "Jane")
+		assert.Equal(t, 0, code, "Expected exit code 0")
+
+		_, stdout, _ := lpack("get", "user:2")
+		assert.Equal(t, "Jane\n", stdout, "Expected the stored value")
+
+		code, _, _ = lpack("del", "user:2")
+		assert.Equal(t, 0, code, "Expected exit code 0")
+
+		code, _, _ = lpack("get", "user:2")
+		assert.Equal(t, 1, code, "Expected the key to be deleted")
+	})
+
+	t.Run("should list the keys", func(t *testing.T) {
+		code, stdout, _ := lpack("keys", "user:*")
+
+		assert.Equal(t, 0, code, "Expected exit code 0")
+		assert.Equal(t, "user:1\n", stdout, "Expected the matching keys")
+	})
+
+	t.Run("should print the stats", func(t *testing.T) {
+		code, stdout, _ := lpack("stats")
+
+		var stats cache.CacheStats
+		err := json.Unmarshal([]byte(stdout), &stats)
+		assert.Equal(t, 0, code, "Expected exit code 0")
+		assert.NoError(t, err, "Expected JSON stats")
+		assert.Equal(t, int64(2), stats.Entries, "Expected the number of entries")
+	})
+
+	t.Run("should purge and compact", func(t *testing.T) {
+		code, _, _ := lpack("purge")
+		assert.Equal(t, 0, code, "Expected exit code 0")
+
+		code, stdout, _ := lpack("compact")
+		assert.Equal(t, 0, code, "Expected exit code 0")
+		assert.Contains(t, stdout, "steps", "Expected the compact stats")
+	})
+
+	t.Run("should accept the path of the database file", func(t *testing.T) {
+		var stdout bytes.Buffer
+		code := run(ctx, []string{"-db", filepath.Join(dir, dbName), "get", "user:1"},
+			&stdout, &bytes.Buffer{})
+
+		assert.Equal(t, 0, code, "Expected exit code 0")
+		assert.Equal(t, "John\n", stdout.String(), "Expected the value")
+	})
+
+	t.Run("should not create a missing database", func(t *testing.T) {
+		var stderr bytes.Buffer
+		code := run(ctx, []string{"-db", t.TempDir(), "stats"}, &bytes.Buffer{}, &stderr)
+
+		assert.Equal(t, 1, code, "Expected exit code 1")
+		assert.Contains(t, stderr.String(), "opening database", "Expected the error")
+	})
+
+	t.Run("should print the usage of invalid commands", func(t *testing.T) {
+		code, _, stderr := lpack("unknown")
+
+		assert.Equal(t, 2, code, "Expected exit code 2")
+		assert.Contains(t, stderr, "Usage: lpack", "Expected the usage")
+	})
+}
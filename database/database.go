@@ -5,48 +5,134 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/lucasvillarinho/litepack/database/drivers"
 	"github.com/lucasvillarinho/litepack/internal/helpers"
 )
 
+// InMemory is the path or database name that selects an in-memory database.
+// The database lives while it is open and is never written to disk.
+const InMemory = helpers.InMemory
+
 type database struct {
 	engine drivers.Driver
-	dsn    string
+	// readEngine is the pool of read-only connections, if opened
+	readEngine drivers.Driver
+	dsn        string
+	// driver is the name of the driver of the engines
+	driver Driver
+	// busyTimeout is how long the connections wait for the lock of the database
+	busyTimeout time.Duration
+	// synchronous is the synchronous mode of the connections, empty for the default of
+	// the driver
+	synchronous SynchronousMode
+	// mmapSize is the max number of bytes of the database mapped in memory by each
+	// connection, 0 if memory-mapped I/O is disabled
+	mmapSize int
+	// readPoolSize is the max number of connections of the read pool, 0 if not opened
+	readPoolSize int
+	// queryHooks are called after each query run on the engines
+	queryHooks []QueryHook
+	// pool are the settings of the pools of connections
+	pool poolSettings
+	// readOnly opens the engine with read-only connections; immutable also tells SQLite
+	// that the file never changes, so it is read without locks
+	readOnly  bool
+	immutable bool
+	// dsnParams are the query parameters of the DSN, read by the driver when it opens
+	// each connection
+	dsnParams url.Values
 }
 
 type Database interface {
 	Destroy(ctx context.Context) error
 	Close(ctx context.Context) error
 	Vacuum(ctx context.Context) error
+	IncrementalVacuum(ctx context.Context, pages int) error
+	Checkpoint(ctx context.Context) error
+	Optimize(ctx context.Context) error
+	Analyze(ctx context.Context) error
+	IntegrityCheck(ctx context.Context, quick bool) ([]string, error)
+	Size(ctx context.Context) (DatabaseSize, error)
+	BackupTo(ctx context.Context, destPath string, progress func(remaining, total int)) error
+	Ping(ctx context.Context) error
 	GetEngine(ctx context.Context) drivers.Driver
-	ExecWithTx(ctx context.Context, fn func(*sql.Tx) error) error
+	GetReadEngine(ctx context.Context) drivers.Driver
+	OpenReadPool(ctx context.Context, size int) error
+	WithTx(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error
 	Exec(ctx context.Context, query string, args ...interface{}) error
+	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row
 
 	SetJournalModeWal(ctx context.Context) error
 	SetPageSize(ctx context.Context, pageSize int) error
 	SetCacheSize(ctx context.Context, cacheSize int) error
 	SetMaxPageCount(ctx context.Context, pageCount int) error
+	SetAutoVacuumIncremental(ctx context.Context) error
+	SetBusyTimeout(ctx context.Context, timeout time.Duration) error
+	SetSynchronous(ctx context.Context, mode SynchronousMode) error
+	SetMmapSize(ctx context.Context, size int) error
+	SetMaxOpenConns(ctx context.Context, n int) error
+	SetMaxIdleConns(ctx context.Context, n int) error
+	SetConnMaxLifetime(ctx context.Context, lifetime time.Duration) error
+	GetUserVersion(ctx context.Context) (int, error)
+	SetUserVersion(ctx context.Context, version int) error
+	Pragma(ctx context.Context, name string) (string, error)
+	SetPragma(ctx context.Context, name, value string) error
 	SetEngine(ctx context.Context, driver Driver) error
 }
 
 // NewDatabase creates a new database instance with the given DSN and applies any provided options.
-func NewDatabase(ctx context.Context, path, dbName string) (Database, error) {
+// If the path or the database name is InMemory, the database is created in memory.
+// If the path or the database name is a URI filename, such as "file::memory:?cache=shared"
+// or "file:/data/db.sqlite?cache=private", it is passed to the driver as the DSN.
+// The parameters of WithDSNParam are added to the DSN, as a URI filename.
+func NewDatabase(ctx context.Context, path, dbName string, opts ...Option) (Database, error) {
 	db := &database{}
+	for _, opt := range opts {
+		opt(db)
+	}
+	if db.synchronous != "" && !db.synchronous.valid() {
+		return nil, fmt.Errorf("invalid synchronous mode: %q", db.synchronous)
+	}
+	if db.mmapSize < 0 {
+		return nil, fmt.Errorf("invalid mmap size: %d", db.mmapSize)
+	}
 
-	dsn, err := helpers.CreateDSN(path, dbName)
+	for key := range db.dsnParams {
+		if key == "" {
+			return nil, fmt.Errorf("invalid DSN parameter: empty name")
+		}
+	}
+
+	dsn, err := helpers.CreateDSN(path, dbName, db.dsnParams)
 	if err != nil {
 		return nil, fmt.Errorf("error creating DSN: %w", err)
 	}
 	db.dsn = dsn
 
+	if db.readOnly && helpers.IsInMemoryDSN(dsn) {
+		return nil, fmt.Errorf("invalid read-only database: an in-memory database can't be read-only")
+	}
+
 	err = db.SetEngine(ctx, DriverMattn)
 	if err != nil {
 		return nil, fmt.Errorf("error setting up engine: %w", err)
 	}
 
+	// A read-only database is never created, so a missing file fails now.
+	if db.readOnly {
+		if err := db.engine.PingContext(ctx); err != nil {
+			_ = db.engine.Close()
+			return nil, fmt.Errorf("error opening read-only database: %w", err)
+		}
+	}
+
 	return db, nil
 }
 
@@ -164,7 +250,179 @@ func (db *database) SetMaxPageCount(ctx context.Context, maxPageCount int) error
 	return nil
 }
 
-// SetEngine creates a new database engine with the given driver and DSN.
+// SetAutoVacuumIncremental sets the auto vacuum mode to incremental, so that the free
+// pages can be returned to the file system with IncrementalVacuum.
+// A database created in another mode is vacuumed once to switch to the incremental mode.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.SetAutoVacuumIncremental(ctx)
+//	if err != nil {
+//		return err
+//	}
+func (db *database) SetAutoVacuumIncremental(ctx context.Context) error {
+	const incremental = 2
+
+	var mode int
+	err := db.engine.QueryRowContext(ctx, "PRAGMA auto_vacuum;").Scan(&mode)
+	if err != nil {
+		return fmt.Errorf("getting auto vacuum mode: %w", err)
+	}
+	if mode == incremental {
+		return nil
+	}
+
+	_, err = db.engine.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL;")
+	if err != nil {
+		return fmt.Errorf("setting auto vacuum mode: %w", err)
+	}
+
+	// The mode of an existing database only changes when it is rebuilt.
+	_, err = db.engine.ExecContext(ctx, "VACUUM;")
+	if err != nil {
+		return fmt.Errorf("setting auto vacuum mode: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserVersion returns the user version stored in the header of the database file,
+// 0 for a new database. litepack uses it to track the layout of its tables.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - int: the user version
+//   - error: an error if the operation failed
+func (db *database) GetUserVersion(ctx context.Context) (int, error) {
+	var version int
+	err := db.engine.QueryRowContext(ctx, "PRAGMA user_version;").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("getting user version: %w", err)
+	}
+
+	return version, nil
+}
+
+// SetUserVersion stores the user version in the header of the database file.
+//
+// Parameters:
+//   - ctx: the context
+//   - version: the user version
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.SetUserVersion(ctx, 2)
+//	if err != nil {
+//		return err
+//	}
+func (db *database) SetUserVersion(ctx context.Context, version int) error {
+	if version < 0 {
+		return fmt.Errorf("invalid user version: %d", version)
+	}
+
+	_, err := db.engine.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d;", version))
+	if err != nil {
+		return fmt.Errorf("setting user version: %w", err)
+	}
+
+	return nil
+}
+
+// pragmaName matches the names of the pragmas, with an optional schema such as "main.".
+var pragmaName = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*\.)?[A-Za-z_][A-Za-z0-9_]*$`)
+
+// pragmaWord matches the values of the pragmas written without quotes, such as numbers
+// and keywords.
+var pragmaWord = regexp.MustCompile(`^[+-]?[A-Za-z0-9_]+$`)
+
+// Pragma returns the value of a pragma, for the pragmas without a typed helper.
+// Pragmas returning several columns or rows, such as table_info, must be read with Query.
+// Like the typed helpers, the pragmas of a connection, such as cache_size, are read from
+// a connection of the engine.
+//
+// Parameters:
+//   - ctx: the context
+//   - name: the name of the pragma, optionally prefixed by the schema, such as "main.page_size"
+//
+// Returns:
+//   - string: the value of the pragma
+//   - error: an error if the operation failed, sql.ErrNoRows if the pragma has no value
+//
+// Example:
+//
+//	mode, err := db.Pragma(ctx, "synchronous")
+//	if err != nil {
+//		return err
+//	}
+func (db *database) Pragma(ctx context.Context, name string) (string, error) {
+	if !pragmaName.MatchString(name) {
+		return "", fmt.Errorf("invalid pragma name: %q", name)
+	}
+
+	var value sql.NullString
+	err := db.engine.QueryRowContext(ctx, fmt.Sprintf("PRAGMA %s;", name)).Scan(&value)
+	if err != nil {
+		return "", fmt.Errorf("getting pragma %s: %w", name, err)
+	}
+
+	return value.String, nil
+}
+
+// SetPragma sets the value of a pragma, for the pragmas without a typed helper.
+// Numbers and keywords are written as is, other values are quoted.
+// SQLite ignores the unknown pragmas, so a typo doesn't fail.
+//
+// Parameters:
+//   - ctx: the context
+//   - name: the name of the pragma, optionally prefixed by the schema, such as "main.page_size"
+//   - value: the value of the pragma
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.SetPragma(ctx, "temp_store", "MEMORY")
+//	if err != nil {
+//		return err
+//	}
+func (db *database) SetPragma(ctx context.Context, name, value string) error {
+	if !pragmaName.MatchString(name) {
+		return fmt.Errorf("invalid pragma name: %q", name)
+	}
+
+	if !pragmaWord.MatchString(value) {
+		value = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+
+	_, err := db.engine.ExecContext(ctx, fmt.Sprintf("PRAGMA %s = %s;", name, value))
+	if err != nil {
+		return fmt.Errorf("setting pragma %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// SetEngine creates a new database engine with the given driver and DSN, replacing and
+// closing the current engine. The driver can be one of the built-in drivers or a driver
+// added with drivers.Register.
 //
 // Parameters:
 //   - ctx: the context
@@ -183,28 +441,235 @@ func (db *database) SetMaxPageCount(ctx context.Context, maxPageCount int) error
 //		return err
 //	}
 func (db *database) SetEngine(ctx context.Context, driver Driver) error {
-	engine, err := NewEngine(DriverMattn, db.dsn)
+	engine, err := db.newEngine(driver, db.engineDSN(driver, db.mainDSN()))
 	if err != nil {
 		return fmt.Errorf("error creating driver: %w", err)
 	}
+
+	if db.engine != nil {
+		// Open a connection before closing the current engine, so that an in-memory
+		// database, dropped with its last connection, is kept.
+		if err := engine.PingContext(ctx); err != nil {
+			_ = engine.Close()
+			return fmt.Errorf("error creating driver: %w", err)
+		}
+		_ = db.engine.Close()
+	}
+
+	db.applyPoolSettings(engine, true)
 	db.engine = engine
+	db.driver = driver
+
+	return nil
+}
+
+// newEngine creates an engine with the driver and the DSN whose connections are set up
+// with the settings without a DSN parameter and whose queries call the query hooks, if any.
+func (db *database) newEngine(driver Driver, dsn string) (drivers.Driver, error) {
+	engine, err := NewEngine(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if db.mmapSize > 0 {
+		err := drivers.SetConnectQueries(engine, dsn, fmt.Sprintf("PRAGMA mmap_size = %d;", db.mmapSize))
+		if err != nil {
+			_ = engine.Close()
+			return nil, err
+		}
+	}
+
+	if len(db.queryHooks) > 0 {
+		if err := drivers.SetQueryHook(engine, dsn, db.runQueryHooks); err != nil {
+			_ = engine.Close()
+			return nil, err
+		}
+	}
+
+	return engine, nil
+}
+
+// runQueryHooks calls the query hooks in order.
+func (db *database) runQueryHooks(
+	ctx context.Context,
+	query string,
+	args []interface{},
+	duration time.Duration,
+	err error,
+) {
+	for _, hook := range db.queryHooks {
+		hook(ctx, query, args, duration, err)
+	}
+}
+
+// SetBusyTimeout sets how long each connection waits for the lock of the database held
+// by another connection or process before failing with SQLITE_BUSY, so that concurrent
+// access waits instead of failing at once. The engines are reopened to apply the timeout
+// to all their connections, see WithBusyTimeout.
+//
+// Parameters:
+//   - ctx: the context
+//   - timeout: the busy timeout, 0 restores the default of the driver
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.SetBusyTimeout(ctx, 5*time.Second)
+//	if err != nil {
+//		return err
+//	}
+func (db *database) SetBusyTimeout(ctx context.Context, timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("invalid busy timeout: %s", timeout)
+	}
+	db.busyTimeout = timeout
+
+	err := db.reopen(ctx)
+	if err != nil {
+		return fmt.Errorf("setting busy timeout: %w", err)
+	}
+
+	return nil
+}
+
+// SetSynchronous sets how often SQLite waits for the writes to reach the disk.
+// In WAL mode SynchronousNormal only waits at the checkpoints, which suits caches: a
+// power loss can lose the last transactions but doesn't corrupt the database.
+// The engines are reopened to apply the mode to all their connections, see
+// WithSynchronous.
+//
+// Parameters:
+//   - ctx: the context
+//   - mode: the synchronous mode
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.SetSynchronous(ctx, database.SynchronousNormal)
+//	if err != nil {
+//		return err
+//	}
+func (db *database) SetSynchronous(ctx context.Context, mode SynchronousMode) error {
+	if !mode.valid() {
+		return fmt.Errorf("invalid synchronous mode: %q", mode)
+	}
+	db.synchronous = mode
+
+	err := db.reopen(ctx)
+	if err != nil {
+		return fmt.Errorf("setting synchronous mode: %w", err)
+	}
 
 	return nil
 }
 
+// SetMmapSize sets the max number of bytes of the database each connection maps in
+// memory. The reads of the mapped pages skip a copy from the file system, which lowers
+// the latency of the reads of large databases. The engines are reopened to apply the
+// size to all their connections, see WithMmapSize.
+//
+// Parameters:
+//   - ctx: the context
+//   - size: the max number of bytes mapped in memory, 0 disables memory-mapped I/O
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.SetMmapSize(ctx, 256*1024*1024) // 256 MB
+//	if err != nil {
+//		return err
+//	}
+func (db *database) SetMmapSize(ctx context.Context, size int) error {
+	if size < 0 {
+		return fmt.Errorf("invalid mmap size: %d", size)
+	}
+	db.mmapSize = size
+
+	err := db.reopen(ctx)
+	if err != nil {
+		return fmt.Errorf("setting mmap size: %w", err)
+	}
+
+	return nil
+}
+
+// reopen replaces the engine and the read pool, if open, with new ones, so that the
+// settings of the connections in the DSN apply to all of them.
+func (db *database) reopen(ctx context.Context) error {
+	err := db.SetEngine(ctx, db.driver)
+	if err != nil {
+		return err
+	}
+
+	if db.readEngine != nil {
+		readEngine := db.readEngine
+		db.readEngine = nil
+		err = db.OpenReadPool(ctx, db.readPoolSize)
+		_ = readEngine.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mainDSN returns the DSN of the engine, read-only if the database was opened with
+// WithReadOnly.
+func (db *database) mainDSN() string {
+	if !db.readOnly {
+		return db.dsn
+	}
+
+	dsn := helpers.ReadOnlyDSN(db.dsn)
+	if db.immutable {
+		dsn = helpers.AppendDSNParam(dsn, "immutable", "1")
+	}
+
+	return dsn
+}
+
+// engineDSN returns the DSN with the parameters of the driver that apply the settings
+// of the connections.
+func (db *database) engineDSN(driver Driver, dsn string) string {
+	dsn = withBusyTimeout(driver, dsn, db.busyTimeout)
+	return withSynchronous(driver, dsn, db.synchronous)
+}
+
 // Destroy deletes the cache database file and closes the database connection.
+// In-memory databases are discarded when closed, so there is no file to delete.
 //
 // parameters:
 //   - ctx: the context
 //
 // ⚠️ WARNING: This operation is irreversible and will delete all data stored in the database.
 func (db *database) Destroy(ctx context.Context) error {
+	if db.readOnly {
+		return fmt.Errorf("error destroying database: the database is read-only")
+	}
+
 	err := db.Close(ctx)
 	if err != nil {
 		return fmt.Errorf("error closing database: %w", err)
 	}
 
-	if err := os.Remove(db.dsn); err != nil {
+	if helpers.IsInMemoryDSN(db.dsn) {
+		return nil
+	}
+
+	if err := os.Remove(helpers.DSNPath(db.dsn)); err != nil {
 		return fmt.Errorf("error removing database file: %w", err)
 	}
 
@@ -212,6 +677,12 @@ func (db *database) Destroy(ctx context.Context) error {
 }
 
 func (db *database) Close(_ context.Context) error {
+	if db.readEngine != nil {
+		if err := db.readEngine.Close(); err != nil {
+			return fmt.Errorf("closing read pool: %w", err)
+		}
+	}
+
 	return db.engine.Close()
 }
 
@@ -234,39 +705,387 @@ func (db *database) Vacuum(ctx context.Context) error {
 	return nil
 }
 
+// IncrementalVacuum returns up to the given number of free pages to the file system,
+// shrinking the database file without rebuilding it like Vacuum does.
+// It only frees pages if the database is in incremental auto vacuum mode,
+// see SetAutoVacuumIncremental.
+//
+// Parameters:
+//   - ctx: the context
+//   - pages: the max number of pages to free
+//
+// Returns:
+//   - error: an error if the operation failed
+func (db *database) IncrementalVacuum(ctx context.Context, pages int) error {
+	if pages <= 0 {
+		return fmt.Errorf("invalid incremental vacuum pages: %d", pages)
+	}
+
+	// The pragma frees a page on each step, so the rows must be read until the end.
+	rows, err := db.engine.QueryContext(ctx, fmt.Sprintf("PRAGMA incremental_vacuum(%d);", pages))
+	if err != nil {
+		return fmt.Errorf("vacuuming incrementally: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("vacuuming incrementally: %w", err)
+	}
+
+	return nil
+}
+
+// Checkpoint copies the content of the WAL file into the database file and truncates the WAL.
+// After a checkpoint the database file alone holds a consistent copy of the data.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the operation failed
+func (db *database) Checkpoint(ctx context.Context) error {
+	_, err := db.engine.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE);")
+	if err != nil {
+		return fmt.Errorf("checkpointing: %w", err)
+	}
+	return nil
+}
+
+// Optimize updates the statistics of the query planner for the tables whose statistics
+// are missing or stale, so that the plans of the queries follow the growth of the tables.
+// It only analyzes the tables that need it, so it is cheap enough to run periodically,
+// such as every hour.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the operation failed
+func (db *database) Optimize(ctx context.Context) error {
+	_, err := db.engine.ExecContext(ctx, "PRAGMA optimize;")
+	if err != nil {
+		return fmt.Errorf("optimizing: %w", err)
+	}
+	return nil
+}
+
+// Analyze gathers the statistics of the query planner for all the tables and indexes.
+// Unlike Optimize it reads every table, so it is meant for after a bulk load or a change
+// of the indexes.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// ⚠️ WARNING: This operation may take a long time to complete on large databases.
+func (db *database) Analyze(ctx context.Context) error {
+	_, err := db.engine.ExecContext(ctx, "ANALYZE;")
+	if err != nil {
+		return fmt.Errorf("analyzing: %w", err)
+	}
+	return nil
+}
+
+// IntegrityCheck checks the database for corruption, such as damaged pages or indexes
+// out of sync with their tables, so that a corrupted database can be rebuilt instead of
+// serving wrong data. The quick check skips the check of the indexes against their
+// tables, which makes it much faster on large databases.
+//
+// Parameters:
+//   - ctx: the context
+//   - quick: run PRAGMA quick_check instead of PRAGMA integrity_check
+//
+// Returns:
+//   - []string: the problems found, empty if the database is intact
+//   - error: an error if the check could not run
+//
+// Example:
+//
+//	problems, err := db.IntegrityCheck(ctx, true)
+//	if err != nil {
+//		return err
+//	}
+//	if len(problems) > 0 {
+//		log.Printf("database corrupted: %s", strings.Join(problems, "; "))
+//	}
+func (db *database) IntegrityCheck(ctx context.Context, quick bool) ([]string, error) {
+	query := "PRAGMA integrity_check;"
+	if quick {
+		query = "PRAGMA quick_check;"
+	}
+
+	rows, err := db.engine.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("checking integrity: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return nil, fmt.Errorf("checking integrity: %w", err)
+		}
+		problems = append(problems, problem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("checking integrity: %w", err)
+	}
+
+	// An intact database reports a single "ok" row.
+	if len(problems) == 1 && problems[0] == "ok" {
+		return nil, nil
+	}
+
+	return problems, nil
+}
+
+// DatabaseSize describes the space used by the database, see Size.
+type DatabaseSize struct {
+	// PageCount is the number of pages of the database, including the free pages
+	PageCount int64 `json:"page_count"`
+	// PageSize is the size of a page in bytes
+	PageSize int64 `json:"page_size"`
+	// FreelistCount is the number of free pages, reused by the writes or returned to the
+	// file system by a vacuum
+	FreelistCount int64 `json:"freelist_count"`
+	// FileSize is the size of the database file on disk in bytes, 0 for in-memory databases
+	FileSize int64 `json:"file_size"`
+	// WALSize is the size of the WAL file on disk in bytes, 0 if there is none
+	WALSize int64 `json:"wal_size"`
+}
+
+// Size returns the pages of the database and the size of its files on disk, so that
+// callers can evict entries above a high watermark or alert before the disk is full.
+// The pages in use are PageCount - FreelistCount; the WAL holds the writes not yet
+// checkpointed into the database file, see Checkpoint.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - DatabaseSize: the size of the database
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	size, err := db.Size(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	used := (size.PageCount - size.FreelistCount) * size.PageSize
+func (db *database) Size(ctx context.Context) (DatabaseSize, error) {
+	var size DatabaseSize
+	err := db.engine.QueryRowContext(
+		ctx,
+		`SELECT page_count, page_size, freelist_count
+		FROM pragma_page_count(), pragma_page_size(), pragma_freelist_count();`,
+	).Scan(&size.PageCount, &size.PageSize, &size.FreelistCount)
+	if err != nil {
+		return DatabaseSize{}, fmt.Errorf("getting page stats: %w", err)
+	}
+
+	if helpers.IsInMemoryDSN(db.dsn) {
+		return size, nil
+	}
+
+	path := helpers.DSNPath(db.dsn)
+	size.FileSize, err = fileSize(path)
+	if err != nil {
+		return DatabaseSize{}, fmt.Errorf("getting file size: %w", err)
+	}
+
+	size.WALSize, err = fileSize(path + "-wal")
+	if err != nil {
+		return DatabaseSize{}, fmt.Errorf("getting WAL size: %w", err)
+	}
+
+	return size, nil
+}
+
+// fileSize returns the size of the file in bytes, 0 if it does not exist.
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+// backuper is implemented by the engines that copy the database with the online backup
+// API of SQLite.
+type backuper interface {
+	Backup(ctx context.Context, destPath string, progress func(remaining, total int)) error
+}
+
+// BackupTo copies the database to the file at destPath with the online backup API of
+// SQLite, replacing the content of the file if it exists. Unlike copying the database
+// file, the copy is consistent while the database is written: writers keep being served
+// between the steps of the backup, and the backup restarts if they change the database.
+//
+// Parameters:
+//   - ctx: the context, the backup stops when it is canceled
+//   - destPath: the path of the copy
+//   - progress: called after each step with the pages left to copy and the total
+//     pages of the database, or nil
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	err := db.BackupTo(ctx, "/backups/db.sqlite", func(remaining, total int) {
+//		log.Printf("backup: %d/%d pages copied", total-remaining, total)
+//	})
+//	if err != nil {
+//		return err
+//	}
+func (db *database) BackupTo(
+	ctx context.Context,
+	destPath string,
+	progress func(remaining, total int),
+) error {
+	if destPath == "" {
+		return fmt.Errorf("invalid backup path: %q", destPath)
+	}
+
+	engine, ok := db.engine.(backuper)
+	if !ok {
+		return fmt.Errorf("backing up: not supported by the engine")
+	}
+
+	err := engine.Backup(ctx, destPath, progress)
+	if err != nil {
+		return fmt.Errorf("backing up: %w", err)
+	}
+
+	return nil
+}
+
+// Ping checks that the database file is reachable and readable: it opens a connection
+// and reads the header of the file, which fails while another process holds an
+// exclusive lock on it. No table is read or written.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: an error if the database is unreachable or locked
+//
+// Example:
+//
+//	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+//		if err := db.Ping(r.Context()); err != nil {
+//			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//		}
+//	})
+func (db *database) Ping(ctx context.Context) error {
+	err := db.engine.PingContext(ctx)
+	if err != nil {
+		return fmt.Errorf("pinging: %w", err)
+	}
+
+	var version int
+	err = db.engine.QueryRowContext(ctx, "PRAGMA schema_version;").Scan(&version)
+	if err != nil {
+		return fmt.Errorf("pinging: reading: %w", err)
+	}
+
+	return nil
+}
+
 // GetEngine returns the database engine.
 func (db *database) GetEngine(_ context.Context) drivers.Driver {
 	return db.engine
 }
 
-// ExecWithTx executes a function with a transaction.
+// WithTx executes a function in a transaction started with the given options.
+// The transaction is committed if the function succeeds, and rolled back if it returns
+// an error or panics; the panic is then raised again. If the context is canceled before
+// the commit, the transaction is rolled back and the commit fails.
 //
 // Parameters:
-//   - ctx: the context
+//   - ctx: the context of the transaction
+//   - opts: the isolation level and read-only mode of the transaction, or nil for the
+//     defaults
 //   - fn: the function to execute
 //
 // Returns:
 //   - error: an error if the operation failed
-func (db *database) ExecWithTx(_ context.Context, fn func(*sql.Tx) error) error {
-	tx, err := db.engine.Begin()
+//
+// Example:
+//
+//	err := db.WithTx(ctx, &sql.TxOptions{ReadOnly: true}, func(tx *sql.Tx) error {
+//		return tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count)
+//	})
+//	if err != nil {
+//		return err
+//	}
+func (db *database) WithTx(
+	ctx context.Context,
+	opts *sql.TxOptions,
+	fn func(*sql.Tx) error,
+) error {
+	tx, err := db.engine.BeginTx(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("error beginning transaction: %w", err)
 	}
 
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
 	err = fn(tx)
 	if err != nil {
 		_ = tx.Rollback()
 		return fmt.Errorf("error rolling back transaction: %w", err)
 	}
 
-	rollbackErr := tx.Rollback()
-	if rollbackErr != nil {
-		return errors.Join(err, rollbackErr)
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
 	}
 
 	return nil
 }
 
+// IsBusyError reports whether the error is returned because another connection or
+// process holds the lock of the database (SQLITE_BUSY).
+func IsBusyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database is busy") ||
+		strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// IsReadOnlyError reports whether the error is returned because the query tried to
+// write a database opened with WithReadOnly, or a file the process can't write
+// (SQLITE_READONLY).
+func IsReadOnlyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "readonly database") ||
+		strings.Contains(msg, "SQLITE_READONLY")
+}
+
 func IsDBFullError(err error) bool {
 	if err == nil {
 		return false
@@ -296,3 +1115,129 @@ func (db *database) Exec(ctx context.Context, query string, args ...interface{})
 
 	return nil
 }
+
+// Query executes a query that returns rows, such as a SELECT, with the given arguments.
+// The rows must be closed once read.
+//
+// Parameters:
+//   - ctx: the context
+//   - query: the query to execute
+//   - args: the query arguments
+//
+// Returns:
+//   - *sql.Rows: the rows returned by the query
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	rows, err := db.Query(ctx, "SELECT id, name FROM users WHERE active = ?", true)
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//
+//	for rows.Next() {
+//		err := rows.Scan(&id, &name)
+//	}
+func (db *database) Query(
+	ctx context.Context,
+	query string,
+	args ...interface{},
+) (*sql.Rows, error) {
+	rows, err := db.engine.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+
+	return rows, nil
+}
+
+// QueryRow executes a query that returns at most one row with the given arguments.
+// The errors of the query are returned by the Scan of the row, sql.ErrNoRows if the
+// query returned no row.
+//
+// Parameters:
+//   - ctx: the context
+//   - query: the query to execute
+//   - args: the query arguments
+//
+// Returns:
+//   - *sql.Row: the row returned by the query
+//
+// Example:
+//
+//	var name string
+//	err := db.QueryRow(ctx, "SELECT name FROM users WHERE id = ?", 1).Scan(&name)
+//	if errors.Is(err, sql.ErrNoRows) {
+//		return ErrUserNotFound
+//	}
+func (db *database) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.engine.QueryRowContext(ctx, query, args...)
+}
+
+// GetReadEngine returns the pool of read-only connections opened by OpenReadPool,
+// or the engine if no pool is open.
+func (db *database) GetReadEngine(_ context.Context) drivers.Driver {
+	if db.readEngine != nil {
+		return db.readEngine
+	}
+
+	return db.engine
+}
+
+// OpenReadPool opens a pool of read-only connections to the database, returned by
+// GetReadEngine, and limits the engine to a single connection that serializes the
+// writes. In WAL mode the readers don't wait for the writer, so a long transaction,
+// such as a purge, doesn't stall the reads.
+// In-memory databases keep a single pool, since their connections lock whole tables,
+// and so do read-only databases, whose engine only reads.
+//
+// Parameters:
+//   - ctx: the context
+//   - size: the max number of read-only connections
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.OpenReadPool(ctx, 4)
+//	if err != nil {
+//		return err
+//	}
+func (db *database) OpenReadPool(ctx context.Context, size int) error {
+	if size <= 0 {
+		return fmt.Errorf("invalid read pool size: %d", size)
+	}
+
+	if helpers.IsInMemoryDSN(db.dsn) || db.readOnly {
+		return nil
+	}
+
+	readEngine, err := db.newEngine(db.driver, db.engineDSN(db.driver, helpers.ReadOnlyDSN(db.dsn)))
+	if err != nil {
+		return fmt.Errorf("opening read pool: %w", err)
+	}
+
+	// Open a connection, so that a database that can't be read fails now.
+	var version int
+	err = readEngine.QueryRowContext(ctx, "PRAGMA schema_version;").Scan(&version)
+	if err != nil {
+		_ = readEngine.Close()
+		return fmt.Errorf("opening read pool: %w", err)
+	}
+
+	db.applyPoolSettings(readEngine, false)
+	if pool, ok := readEngine.(maxOpenConnsSetter); ok {
+		pool.SetMaxOpenConns(size)
+	}
+	if pool, ok := db.engine.(maxOpenConnsSetter); ok {
+		pool.SetMaxOpenConns(1)
+	}
+	db.readEngine = readEngine
+	db.readPoolSize = size
+
+	return nil
+}
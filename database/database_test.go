@@ -1 +1,425 @@
 package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBusyError(t *testing.T) {
+	t.Run("should detect the locked database errors of the drivers", func(t *testing.T) {
+		assert.True(t, IsBusyError(errors.New("database is locked")))
+		assert.True(t, IsBusyError(errors.New("database is locked (5) (SQLITE_BUSY)")))
+		assert.True(t, IsBusyError(fmt.Errorf("deleting key: %w", errors.New("database is locked"))))
+	})
+
+	t.Run("should not detect other errors", func(t *testing.T) {
+		assert.False(t, IsBusyError(nil))
+		assert.False(t, IsBusyError(errors.New("database or disk is full")))
+	})
+}
+
+func TestIsReadOnlyError(t *testing.T) {
+	t.Run("should detect the read-only errors of the drivers", func(t *testing.T) {
+		assert.True(t, IsReadOnlyError(errors.New("attempt to write a readonly database")))
+		assert.True(t, IsReadOnlyError(errors.New(
+			"attempt to write a readonly database (8) (SQLITE_READONLY)",
+		)))
+		assert.True(t, IsReadOnlyError(fmt.Errorf(
+			"setting key: %w", errors.New("attempt to write a readonly database"),
+		)))
+	})
+
+	t.Run("should not detect other errors", func(t *testing.T) {
+		assert.False(t, IsReadOnlyError(nil))
+		assert.False(t, IsReadOnlyError(errors.New("database is locked")))
+	})
+}
+
+func TestDatabase_Ping(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should ping and read the header of the database", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectPing()
+		sqlMock.ExpectQuery(`PRAGMA schema_version;`).
+			WillReturnRows(sqlmock.NewRows([]string{"schema_version"}).AddRow(3))
+
+		db := &database{engine: engine}
+		err = db.Ping(ctx)
+
+		assert.NoError(t, err, "Expected no error while pinging the database")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error if the database is locked", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectPing()
+		sqlMock.ExpectQuery(`PRAGMA schema_version;`).
+			WillReturnError(errors.New("database is locked"))
+
+		db := &database{engine: engine}
+		err = db.Ping(ctx)
+
+		assert.EqualError(t, err, "pinging: reading: database is locked")
+		assert.True(t, IsBusyError(err))
+	})
+}
+
+func TestDatabase_Pragma(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the value of the pragma", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectQuery(`PRAGMA main\.synchronous;`).
+			WillReturnRows(sqlmock.NewRows([]string{"synchronous"}).AddRow("1"))
+
+		db := &database{engine: engine}
+		value, err := db.Pragma(ctx, "main.synchronous")
+
+		assert.NoError(t, err, "Expected no error while reading the pragma")
+		assert.Equal(t, "1", value)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error for an invalid name", func(t *testing.T) {
+		db := &database{}
+
+		_, err := db.Pragma(ctx, "synchronous; DROP TABLE cache")
+
+		assert.EqualError(t, err, `invalid pragma name: "synchronous; DROP TABLE cache"`)
+	})
+}
+
+func TestDatabase_SetPragma(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should write numbers and keywords as is", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectExec(`PRAGMA temp_store = MEMORY;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectExec(`PRAGMA cache_size = -2000;`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		db := &database{engine: engine}
+
+		assert.NoError(t, db.SetPragma(ctx, "temp_store", "MEMORY"))
+		assert.NoError(t, db.SetPragma(ctx, "cache_size", "-2000"))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should quote the other values", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectExec(`PRAGMA application_id = 'it''s; DROP TABLE cache';`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		db := &database{engine: engine}
+		err = db.SetPragma(ctx, "application_id", "it's; DROP TABLE cache")
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error for an invalid name", func(t *testing.T) {
+		db := &database{}
+
+		err := db.SetPragma(ctx, "", "1")
+
+		assert.EqualError(t, err, `invalid pragma name: ""`)
+	})
+}
+
+func TestDatabase_Optimize(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should update the statistics of the query planner", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectExec(`PRAGMA optimize;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectExec(`ANALYZE;`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		db := &database{engine: engine}
+
+		assert.NoError(t, db.Optimize(ctx), "Expected no error while optimizing")
+		assert.NoError(t, db.Analyze(ctx), "Expected no error while analyzing")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error if the database is locked", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectExec(`PRAGMA optimize;`).WillReturnError(errors.New("database is locked"))
+		sqlMock.ExpectExec(`ANALYZE;`).WillReturnError(errors.New("database is locked"))
+
+		db := &database{engine: engine}
+
+		assert.EqualError(t, db.Optimize(ctx), "optimizing: database is locked")
+		assert.EqualError(t, db.Analyze(ctx), "analyzing: database is locked")
+	})
+}
+
+func TestDatabase_Size(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the pages of an in-memory database", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count`).
+			WillReturnRows(sqlmock.NewRows([]string{"page_count", "page_size", "freelist_count"}).
+				AddRow(10, 4096, 2))
+
+		db := &database{engine: engine, dsn: "file:lpack_memdb_1?mode=memory&cache=shared"}
+		size, err := db.Size(ctx)
+
+		assert.NoError(t, err, "Expected no error while getting the size")
+		assert.Equal(t, DatabaseSize{PageCount: 10, PageSize: 4096, FreelistCount: 2}, size)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error if the page stats query fails", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectQuery(`SELECT page_count, page_size, freelist_count`).
+			WillReturnError(errors.New("database is locked"))
+
+		db := &database{engine: engine}
+		_, err = db.Size(ctx)
+
+		assert.EqualError(t, err, "getting page stats: database is locked")
+	})
+}
+
+func TestDatabase_IntegrityCheck(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return no problem for an intact database", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectQuery(`PRAGMA integrity_check;`).
+			WillReturnRows(sqlmock.NewRows([]string{"integrity_check"}).AddRow("ok"))
+
+		db := &database{engine: engine}
+		problems, err := db.IntegrityCheck(ctx, false)
+
+		assert.NoError(t, err, "Expected no error while checking the database")
+		assert.Empty(t, problems)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return the problems found by the quick check", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectQuery(`PRAGMA quick_check;`).
+			WillReturnRows(sqlmock.NewRows([]string{"quick_check"}).
+				AddRow("*** in database main ***").
+				AddRow("Page 3: btreeInitPage() returns error code 11"))
+
+		db := &database{engine: engine}
+		problems, err := db.IntegrityCheck(ctx, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"*** in database main ***",
+			"Page 3: btreeInitPage() returns error code 11",
+		}, problems)
+	})
+
+	t.Run("should return an error if the check fails", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectQuery(`PRAGMA integrity_check;`).
+			WillReturnError(errors.New("database disk image is malformed"))
+
+		db := &database{engine: engine}
+		_, err = db.IntegrityCheck(ctx, false)
+
+		assert.EqualError(t, err, "checking integrity: database disk image is malformed")
+	})
+}
+
+func TestDatabase_WithTx(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should commit the transaction if the function succeeds", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+
+		db := &database{engine: engine}
+		err = db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE users SET active = 1")
+			return err
+		})
+
+		assert.NoError(t, err, "Expected no error while running the transaction")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back the transaction if the function fails", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectRollback()
+
+		db := &database{engine: engine}
+		err = db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			return errors.New("constraint failed")
+		})
+
+		assert.EqualError(t, err, "error rolling back transaction: constraint failed")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back the transaction if the function panics", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectRollback()
+
+		db := &database{engine: engine}
+		assert.PanicsWithValue(t, "boom", func() {
+			_ = db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+				panic("boom")
+			})
+		})
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should not commit if the context is canceled", func(t *testing.T) {
+		engine, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectRollback()
+
+		ctx, cancel := context.WithCancel(ctx)
+		db := &database{engine: engine}
+		err = db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			cancel()
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestDatabase_mainDSN(t *testing.T) {
+	t.Run("should open the file read-write by default", func(t *testing.T) {
+		db := &database{dsn: "/data/db.sqlite"}
+
+		assert.Equal(t, "/data/db.sqlite", db.mainDSN())
+	})
+
+	t.Run("should open the file read-only", func(t *testing.T) {
+		db := &database{dsn: "/data/db.sqlite", readOnly: true}
+
+		assert.Equal(t, "file:/data/db.sqlite?mode=ro", db.mainDSN())
+	})
+
+	t.Run("should open an immutable file", func(t *testing.T) {
+		db := &database{dsn: "/data/db.sqlite", readOnly: true, immutable: true}
+
+		assert.Equal(t, "file:/data/db.sqlite?mode=ro&immutable=1", db.mainDSN())
+	})
+
+	t.Run("should replace the mode of a URI filename", func(t *testing.T) {
+		db := &database{dsn: "file:/data/db.sqlite?mode=rwc&cache=private", readOnly: true}
+
+		assert.Equal(t, "file:/data/db.sqlite?cache=private&mode=ro", db.mainDSN())
+	})
+}
+
+func TestWithDSNParam(t *testing.T) {
+	t.Run("should keep the parameters in order", func(t *testing.T) {
+		db := &database{}
+
+		WithDSNParam("_pragma", "foreign_keys(1)")(db)
+		WithDSNParam("_pragma", "journal_mode(WAL)")(db)
+		WithDSNParam("cache", "shared")(db)
+
+		assert.Equal(t, []string{"foreign_keys(1)", "journal_mode(WAL)"}, db.dsnParams["_pragma"])
+		assert.Equal(t, "shared", db.dsnParams.Get("cache"))
+	})
+}
+
+func TestWithBusyTimeout(t *testing.T) {
+	t.Run("should add the busy timeout parameter of the driver to the DSN", func(t *testing.T) {
+		assert.Equal(
+			t,
+			"/data/db.sqlite?_busy_timeout=2500",
+			withBusyTimeout(DriverMattn, "/data/db.sqlite", 2500*time.Millisecond),
+		)
+		assert.Equal(
+			t,
+			"file:/data/db.sqlite?mode=ro&_pragma=busy_timeout%282500%29",
+			withBusyTimeout(DriverModernc, "file:/data/db.sqlite?mode=ro", 2500*time.Millisecond),
+		)
+	})
+
+	t.Run("should keep the DSN without a timeout or for other drivers", func(t *testing.T) {
+		assert.Equal(t, "/data/db.sqlite", withBusyTimeout(DriverMattn, "/data/db.sqlite", 0))
+		assert.Equal(t, "/data/db.sqlite", withBusyTimeout("custom", "/data/db.sqlite", time.Second))
+	})
+}
+
+func TestWithSynchronous(t *testing.T) {
+	t.Run("should add the synchronous parameter of the driver to the DSN", func(t *testing.T) {
+		assert.Equal(
+			t,
+			"/data/db.sqlite?_synchronous=NORMAL",
+			withSynchronous(DriverMattn, "/data/db.sqlite", SynchronousNormal),
+		)
+		assert.Equal(
+			t,
+			"/data/db.sqlite?_pragma=synchronous%28OFF%29",
+			withSynchronous(DriverModernc, "/data/db.sqlite", SynchronousOff),
+		)
+	})
+
+	t.Run("should keep the DSN without a mode or for other drivers", func(t *testing.T) {
+		assert.Equal(t, "/data/db.sqlite", withSynchronous(DriverMattn, "/data/db.sqlite", ""))
+		assert.Equal(t, "/data/db.sqlite", withSynchronous("custom", "/data/db.sqlite", SynchronousFull))
+	})
+}
@@ -0,0 +1,137 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+)
+
+// connectorOpener is implemented by the drivers whose connections can be set up when
+// they are opened or hooked.
+type connectorOpener interface {
+	openConnector(dsn string) (*connector, error)
+}
+
+// openConnector replaces the pool with a pool of the same driver whose connections are
+// opened by a connector of the driver, and returns the connector. The connector is
+// opened once, so that its settings can be changed until the pool is used.
+func (d *BaseDriver) openConnector(dsn string) (*connector, error) {
+	if d.connector != nil {
+		return d.connector, nil
+	}
+
+	var base driver.Connector = dsnConnector{dsn: dsn, driver: d.DB.Driver()}
+	if dc, ok := d.DB.Driver().(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("open connector: %w", err)
+		}
+		base = c
+	}
+
+	_ = d.DB.Close()
+	d.connector = &connector{Connector: base}
+	d.DB = sql.OpenDB(d.connector)
+
+	return d.connector, nil
+}
+
+// SetConnectQueries runs the queries on each connection of the driver when it is
+// opened, for the settings of the connections without a DSN parameter, such as
+// PRAGMA mmap_size. It must be called before the driver is used, since it reopens the
+// pool of the driver. The drivers embedding BaseDriver, such as the drivers added with
+// Register, support it.
+//
+// Parameters:
+//   - d: the driver
+//   - dsn: the DSN the driver was created with
+//   - queries: the queries to run
+//
+// Returns:
+//   - error: an error if the driver doesn't support connect queries
+func SetConnectQueries(d Driver, dsn string, queries ...string) error {
+	opener, ok := d.(connectorOpener)
+	if !ok {
+		return fmt.Errorf("connect queries not supported by the driver: %T", d)
+	}
+
+	connector, err := opener.openConnector(dsn)
+	if err != nil {
+		return err
+	}
+	connector.queries = append(connector.queries, queries...)
+
+	return nil
+}
+
+// dsnConnector opens the connections of a driver without a connector.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// connector runs the connect queries on the connections it opens, and hooks their
+// queries if it has a query hook.
+type connector struct {
+	driver.Connector
+	queries []string
+	hook    QueryHook
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range c.queries {
+		if err := execConn(ctx, conn, query); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("setting up connection: %w", err)
+		}
+	}
+
+	if c.hook != nil {
+		return &hookedConn{Conn: conn, hook: c.hook}, nil
+	}
+
+	return conn, nil
+}
+
+// Close closes the connector of the driver, if it holds resources, when the pool is closed.
+func (c *connector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// execConn executes a query without arguments on a connection of a driver.
+func execConn(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(nil)
+	return err
+}
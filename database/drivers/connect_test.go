@@ -0,0 +1,76 @@
+package drivers
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetConnectQueries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should run the queries on each connection", func(t *testing.T) {
+		dsn := filepath.Join(t.TempDir(), "connect.db")
+		d, err := NewMattnDriver(dsn)
+		assert.NoError(t, err)
+		defer d.Close()
+
+		err = SetConnectQueries(d, dsn, "PRAGMA mmap_size = 1048576;")
+		assert.NoError(t, err)
+
+		// the first connection is held, so that the second one is opened
+		db := d.(*driverMattn).DB
+		for i := 0; i < 2; i++ {
+			conn, err := db.Conn(ctx)
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			var size int
+			err = conn.QueryRowContext(ctx, "PRAGMA mmap_size;").Scan(&size)
+			assert.NoError(t, err)
+			assert.Equal(t, 1048576, size, "Expected connection %d to be set up", i)
+		}
+	})
+
+	t.Run("should run the queries before the query hook", func(t *testing.T) {
+		dsn := filepath.Join(t.TempDir(), "connect.db")
+		d, err := NewModerncDriver(dsn)
+		assert.NoError(t, err)
+		defer d.Close()
+
+		recorder := &recordingHook{}
+		assert.NoError(t, SetQueryHook(d, dsn, recorder.hook))
+		assert.NoError(t, SetConnectQueries(d, dsn, "PRAGMA mmap_size = 1048576;"))
+
+		var size int
+		err = d.QueryRowContext(ctx, "PRAGMA mmap_size;").Scan(&size)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1048576, size)
+		assert.Len(t, recorder.queries, 1, "Expected only the query to be hooked")
+	})
+
+	t.Run("should fail to open a connection if a query fails", func(t *testing.T) {
+		dsn := filepath.Join(t.TempDir(), "connect.db")
+		d, err := NewMattnDriver(dsn)
+		assert.NoError(t, err)
+		defer d.Close()
+
+		assert.NoError(t, SetConnectQueries(d, dsn, "NOT SQL"))
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		err = d.PingContext(ctx)
+
+		assert.ErrorContains(t, err, "setting up connection")
+	})
+
+	t.Run("should fail for a driver without BaseDriver", func(t *testing.T) {
+		err := SetConnectQueries(struct{ Driver }{}, "db.sqlite", "PRAGMA mmap_size = 0;")
+
+		assert.ErrorContains(t, err, "connect queries not supported by the driver")
+	})
+}
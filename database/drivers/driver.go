@@ -3,6 +3,7 @@ package drivers
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 type Driver interface {
@@ -10,12 +11,24 @@ type Driver interface {
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) // Adicionado
+	PingContext(ctx context.Context) error
 	Begin() (*sql.Tx, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 	Close() error
 }
 
+// backupPagesPerStep is the number of pages copied by each step of a backup. The lock of
+// the database is released between the steps, so the writers are not blocked for long.
+const backupPagesPerStep = 1024
+
+// backupRetryInterval is how long a backup waits for the lock held by a writer before
+// retrying a step.
+const backupRetryInterval = 10 * time.Millisecond
+
 type BaseDriver struct {
 	DB *sql.DB
+	// connector opens the connections of DB, if it was opened by openConnector
+	connector *connector
 }
 
 func (d *BaseDriver) ExecContext(
@@ -46,10 +59,51 @@ func (d *BaseDriver) PrepareContext(ctx context.Context, query string) (*sql.Stm
 	return d.DB.PrepareContext(ctx, query)
 }
 
+func (d *BaseDriver) PingContext(ctx context.Context) error {
+	return d.DB.PingContext(ctx)
+}
+
 func (d *BaseDriver) Begin() (*sql.Tx, error) {
 	return d.DB.Begin()
 }
 
+func (d *BaseDriver) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return d.DB.BeginTx(ctx, opts)
+}
+
 func (d *BaseDriver) Close() error {
 	return d.DB.Close()
 }
+
+// Stats returns the statistics of the pool of connections, see sql.DB.Stats.
+func (d *BaseDriver) Stats() sql.DBStats {
+	return d.DB.Stats()
+}
+
+// SetMaxOpenConns sets the max number of open connections of the pool,
+// see sql.DB.SetMaxOpenConns.
+func (d *BaseDriver) SetMaxOpenConns(n int) {
+	d.DB.SetMaxOpenConns(n)
+}
+
+// SetMaxIdleConns sets the max number of idle connections kept by the pool,
+// see sql.DB.SetMaxIdleConns.
+func (d *BaseDriver) SetMaxIdleConns(n int) {
+	d.DB.SetMaxIdleConns(n)
+}
+
+// SetConnMaxLifetime sets how long a connection of the pool may be reused,
+// see sql.DB.SetConnMaxLifetime.
+func (d *BaseDriver) SetConnMaxLifetime(lifetime time.Duration) {
+	d.DB.SetConnMaxLifetime(lifetime)
+}
+
+// waitBackupRetry waits before retrying a step of a backup that found the database locked.
+func waitBackupRetry(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(backupRetryInterval):
+		return nil
+	}
+}
@@ -0,0 +1,252 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// QueryHook is called after each query run on the connections of a driver, with the
+// query, its arguments, how long it took and its error, nil if it succeeded.
+// The queries run in transactions and through prepared statements are included; the
+// duration of a query that returns rows doesn't include the reading of the rows.
+type QueryHook func(
+	ctx context.Context,
+	query string,
+	args []interface{},
+	duration time.Duration,
+	err error,
+)
+
+// SetQueryHook calls the hook after each query run on the connections of the driver.
+// It must be called before the driver is used, since it reopens the pool of the driver.
+// The drivers embedding BaseDriver, such as the drivers added with Register, support it.
+//
+// Parameters:
+//   - d: the driver
+//   - dsn: the DSN the driver was created with
+//   - hook: the hook
+//
+// Returns:
+//   - error: an error if the driver doesn't support query hooks
+func SetQueryHook(d Driver, dsn string, hook QueryHook) error {
+	opener, ok := d.(connectorOpener)
+	if !ok {
+		return fmt.Errorf("query hooks not supported by the driver: %T", d)
+	}
+
+	connector, err := opener.openConnector(dsn)
+	if err != nil {
+		return err
+	}
+	connector.hook = hook
+
+	return nil
+}
+
+// rawConn returns the connection of the driver behind a hooked connection, so that the
+// APIs of the driver, such as the backups, can be reached through sql.Conn.Raw.
+func rawConn(conn any) any {
+	if hooked, ok := conn.(*hookedConn); ok {
+		return hooked.Conn
+	}
+
+	return conn
+}
+
+// hookedConn calls the hook after each query run on the connection. The queries the
+// driver can't run directly are prepared, and are hooked by hookedStmt.
+type hookedConn struct {
+	driver.Conn
+	hook QueryHook
+}
+
+func (c *hookedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *hookedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	checker, _ := c.Conn.(driver.NamedValueChecker)
+	return &hookedStmt{Stmt: stmt, query: query, hook: c.hook, checker: checker}, nil
+}
+
+func (c *hookedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("driver does not support read-only transactions")
+	}
+
+	return c.Conn.Begin()
+}
+
+func (c *hookedConn) ExecContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.hook(ctx, query, hookArgs(args), time.Since(start), err)
+	}
+
+	return result, err
+}
+
+func (c *hookedConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.hook(ctx, query, hookArgs(args), time.Since(start), err)
+	}
+
+	return rows, err
+}
+
+func (c *hookedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *hookedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *hookedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+func (c *hookedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+// hookedStmt calls the hook after each execution of a prepared statement.
+type hookedStmt struct {
+	driver.Stmt
+	query string
+	hook  QueryHook
+	// checker converts the arguments for the connection, if the driver has one
+	checker driver.NamedValueChecker
+}
+
+func (s *hookedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		values, err = driverValues(args)
+		if err == nil {
+			result, err = s.Stmt.Exec(values)
+		}
+	}
+	s.hook(ctx, s.query, hookArgs(args), time.Since(start), err)
+
+	return result, err
+}
+
+func (s *hookedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		values, err = driverValues(args)
+		if err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	s.hook(ctx, s.query, hookArgs(args), time.Since(start), err)
+
+	return rows, err
+}
+
+func (s *hookedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if s.checker != nil {
+		return s.checker.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+// driverValues returns the values of the arguments for the drivers without named
+// arguments.
+func driverValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("driver does not support the use of named parameters")
+		}
+		values[i] = arg.Value
+	}
+
+	return values, nil
+}
+
+// hookArgs returns the values of the arguments passed to the hook.
+func hookArgs(args []driver.NamedValue) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	return values
+}
@@ -0,0 +1,121 @@
+package drivers
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hookedQuery is a query reported to a query hook.
+type hookedQuery struct {
+	query string
+	args  []interface{}
+	err   error
+}
+
+// recordingHook records the queries reported to it.
+type recordingHook struct {
+	mu      sync.Mutex
+	queries []hookedQuery
+}
+
+func (h *recordingHook) hook(
+	_ context.Context,
+	query string,
+	args []interface{},
+	_ time.Duration,
+	err error,
+) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.queries = append(h.queries, hookedQuery{query: query, args: args, err: err})
+}
+
+func (h *recordingHook) last() hookedQuery {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.queries[len(h.queries)-1]
+}
+
+// testQueryHook runs queries on a hooked driver and checks the hook reports them.
+func testQueryHook(t *testing.T, newDriver func(string) (Driver, error)) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "hook.db")
+	d, err := newDriver(dsn)
+	assert.NoError(t, err)
+	defer d.Close()
+
+	recorder := &recordingHook{}
+	assert.NoError(t, SetQueryHook(d, dsn, recorder.hook))
+
+	t.Run("should report the queries", func(t *testing.T) {
+		_, err := d.ExecContext(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`)
+		assert.NoError(t, err)
+		_, err = d.ExecContext(ctx, `INSERT INTO items (value) VALUES (?)`, "a")
+		assert.NoError(t, err)
+
+		assert.Equal(
+			t,
+			hookedQuery{query: `INSERT INTO items (value) VALUES (?)`, args: []interface{}{"a"}},
+			recorder.last(),
+		)
+
+		var count int
+		err = d.QueryRowContext(ctx, `SELECT COUNT(*) FROM items`).Scan(&count)
+		assert.NoError(t, err)
+		assert.Equal(t, `SELECT COUNT(*) FROM items`, recorder.last().query)
+	})
+
+	t.Run("should report the queries of prepared statements and transactions", func(t *testing.T) {
+		stmt, err := d.PrepareContext(ctx, `INSERT INTO items (value) VALUES (?)`)
+		assert.NoError(t, err)
+		defer stmt.Close()
+
+		_, err = stmt.ExecContext(ctx, "b")
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"b"}, recorder.last().args)
+
+		tx, err := d.BeginTx(ctx, nil)
+		assert.NoError(t, err)
+		_, err = tx.ExecContext(ctx, `DELETE FROM items WHERE value = ?`, "b")
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+		assert.Equal(t, `DELETE FROM items WHERE value = ?`, recorder.last().query)
+	})
+
+	t.Run("should report the errors", func(t *testing.T) {
+		_, err := d.ExecContext(ctx, `SELECT * FROM missing`)
+		assert.Error(t, err)
+
+		assert.Equal(t, `SELECT * FROM missing`, recorder.last().query)
+		assert.Error(t, recorder.last().err, "Expected the hook to receive the error")
+	})
+
+	t.Run("should keep the backups", func(t *testing.T) {
+		err := d.(interface {
+			Backup(context.Context, string, func(int, int)) error
+		}).Backup(ctx, filepath.Join(t.TempDir(), "dest.db"), nil)
+
+		assert.NoError(t, err, "Expected the backup to reach the connection of the driver")
+	})
+}
+
+func TestSetQueryHook(t *testing.T) {
+	t.Run("mattn", func(t *testing.T) {
+		testQueryHook(t, NewMattnDriver)
+	})
+
+	t.Run("modernc", func(t *testing.T) {
+		testQueryHook(t, NewModerncDriver)
+	})
+
+	t.Run("should fail for a driver without BaseDriver", func(t *testing.T) {
+		err := SetQueryHook(struct{ Driver }{}, "db.sqlite", (&recordingHook{}).hook)
+
+		assert.ErrorContains(t, err, "query hooks not supported by the driver")
+	})
+}
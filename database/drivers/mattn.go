@@ -1,10 +1,11 @@
 package drivers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 type driverMattn struct {
@@ -23,3 +24,88 @@ func NewMattnDriver(dsn string) (Driver, error) {
 		},
 	}, nil
 }
+
+// Backup copies the database to the file at destPath with the online backup API of
+// SQLite, replacing its content. The copy is consistent even if the database is written
+// while it runs: the backup restarts when another connection changes the database.
+// The progress, if not nil, is called after each step with the pages left to copy.
+func (d *driverMattn) Backup(
+	ctx context.Context,
+	destPath string,
+	progress func(remaining, total int),
+) error {
+	dest, err := sql.Open("sqlite3", destPath)
+	if err != nil {
+		return fmt.Errorf("open destination: %w", err)
+	}
+	defer dest.Close()
+
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("open destination: %w", err)
+	}
+	defer destConn.Close()
+
+	srcConn, err := d.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destRaw any) error {
+		return srcConn.Raw(func(srcRaw any) error {
+			destSQLite, ok := rawConn(destRaw).(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected destination connection: %T", destRaw)
+			}
+			srcSQLite, ok := rawConn(srcRaw).(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected source connection: %T", srcRaw)
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return fmt.Errorf("start backup: %w", err)
+			}
+
+			err = stepMattnBackup(ctx, backup, progress)
+			if closeErr := backup.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("finish backup: %w", closeErr)
+			}
+
+			return err
+		})
+	})
+}
+
+// stepMattnBackup copies the pages of the backup until it is done.
+func stepMattnBackup(
+	ctx context.Context,
+	backup *sqlite3.SQLiteBackup,
+	progress func(remaining, total int),
+) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		remaining := backup.Remaining()
+		done, err := backup.Step(backupPagesPerStep)
+		if err != nil {
+			return fmt.Errorf("backup step: %w", err)
+		}
+		if progress != nil {
+			progress(backup.Remaining(), backup.PageCount())
+		}
+		if done {
+			return nil
+		}
+
+		// no page was copied, a writer holds the lock of the database
+		if backup.Remaining() == remaining && remaining > 0 {
+			if err := waitBackupRetry(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -1 +1,67 @@
 package drivers
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testBackup backs up a database with rows through the driver and checks the copy.
+func testBackup(t *testing.T, newDriver func(string) (Driver, error)) {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	src, err := newDriver(filepath.Join(dir, "src.db"))
+	assert.NoError(t, err)
+	defer src.Close()
+
+	_, err = src.ExecContext(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`)
+	assert.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		_, err = src.ExecContext(ctx, `INSERT INTO items (value) VALUES (?)`, "value")
+		assert.NoError(t, err)
+	}
+
+	var lastRemaining, lastTotal int
+	destPath := filepath.Join(dir, "dest.db")
+	err = src.(interface {
+		Backup(context.Context, string, func(int, int)) error
+	}).Backup(ctx, destPath, func(remaining, total int) {
+		lastRemaining, lastTotal = remaining, total
+	})
+
+	assert.NoError(t, err, "Expected no error while backing up")
+	assert.Equal(t, 0, lastRemaining, "Expected the progress to report the end of the backup")
+	assert.Positive(t, lastTotal, "Expected the progress to report the pages")
+
+	dest, err := newDriver(destPath)
+	assert.NoError(t, err)
+	defer dest.Close()
+
+	var count int
+	err = dest.QueryRowContext(ctx, `SELECT COUNT(*) FROM items`).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, count, "Expected the copy to hold the rows")
+}
+
+func TestMattnDriver_Backup(t *testing.T) {
+	t.Run("should copy the database", func(t *testing.T) {
+		testBackup(t, NewMattnDriver)
+	})
+
+	t.Run("should stop when the context is canceled", func(t *testing.T) {
+		src, err := NewMattnDriver(filepath.Join(t.TempDir(), "src.db"))
+		assert.NoError(t, err)
+		defer src.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err = src.(*driverMattn).Backup(ctx, filepath.Join(t.TempDir(), "dest.db"), nil)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -1,10 +1,11 @@
 package drivers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
 )
 
 type driverModernc struct {
@@ -23,3 +24,81 @@ func NewModerncDriver(dsn string) (Driver, error) {
 		},
 	}, nil
 }
+
+// moderncBackuper is implemented by the connections of modernc.org/sqlite.
+type moderncBackuper interface {
+	NewBackup(dstUri string) (*sqlite.Backup, error)
+}
+
+// Backup copies the database to the file at destPath with the online backup API of
+// SQLite, replacing its content. The copy is consistent even if the database is written
+// while it runs: the backup restarts when another connection changes the database.
+// The progress, if not nil, is called after each step with an estimate of the pages
+// left to copy, since the driver doesn't report them.
+func (d *driverModernc) Backup(
+	ctx context.Context,
+	destPath string,
+	progress func(remaining, total int),
+) error {
+	srcConn, err := d.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer srcConn.Close()
+
+	var total int
+	err = srcConn.QueryRowContext(ctx, "PRAGMA page_count;").Scan(&total)
+	if err != nil {
+		return fmt.Errorf("count pages: %w", err)
+	}
+
+	return srcConn.Raw(func(srcRaw any) error {
+		src, ok := rawConn(srcRaw).(moderncBackuper)
+		if !ok {
+			return fmt.Errorf("unexpected source connection: %T", srcRaw)
+		}
+
+		backup, err := src.NewBackup(destPath)
+		if err != nil {
+			return fmt.Errorf("start backup: %w", err)
+		}
+
+		err = stepModerncBackup(ctx, backup, total, progress)
+		if finishErr := backup.Finish(); err == nil && finishErr != nil {
+			err = fmt.Errorf("finish backup: %w", finishErr)
+		}
+
+		return err
+	})
+}
+
+// stepModerncBackup copies the pages of the backup until it is done.
+func stepModerncBackup(
+	ctx context.Context,
+	backup *sqlite.Backup,
+	total int,
+	progress func(remaining, total int),
+) error {
+	copied := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		more, err := backup.Step(backupPagesPerStep)
+		if err != nil {
+			return fmt.Errorf("backup step: %w", err)
+		}
+
+		copied = min(copied+backupPagesPerStep, total)
+		if !more {
+			copied = total
+		}
+		if progress != nil {
+			progress(total-copied, total)
+		}
+		if !more {
+			return nil
+		}
+	}
+}
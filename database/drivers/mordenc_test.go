@@ -1 +1,9 @@
 package drivers
+
+import "testing"
+
+func TestModerncDriver_Backup(t *testing.T) {
+	t.Run("should copy the database", func(t *testing.T) {
+		testBackup(t, NewModerncDriver)
+	})
+}
@@ -0,0 +1,74 @@
+package drivers
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DriverType is the name a driver is registered with.
+type DriverType string
+
+const (
+	// DriverMattn "github.com/mattn/go-sqlite3".
+	DriverMattn DriverType = "mattn"
+	// DriverModernc "modernc.org/sqlite".
+	DriverModernc DriverType = "modernc"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[DriverType]func(dsn string) (Driver, error){
+		DriverMattn:   NewMattnDriver,
+		DriverModernc: NewModerncDriver,
+	}
+)
+
+// Register makes a driver available by name, so that applications can plug custom
+// SQLite builds, such as builds with a custom VFS or with extensions compiled in.
+// It is meant to be called from an init function, and like sql.Register it panics if
+// the constructor is nil or a driver is already registered with the name.
+//
+// Parameters:
+//   - name: the name of the driver
+//   - constructor: creates an instance of the driver for a DSN
+//
+// Example:
+//
+//	func init() {
+//		drivers.Register("sqlcipher", func(dsn string) (drivers.Driver, error) {
+//			db, err := sql.Open("sqlcipher", dsn)
+//			if err != nil {
+//				return nil, err
+//			}
+//			return &drivers.BaseDriver{DB: db}, nil
+//		})
+//	}
+func Register(name DriverType, constructor func(dsn string) (Driver, error)) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if constructor == nil {
+		panic(fmt.Sprintf("drivers: register constructor of %q is nil", name))
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("drivers: register called twice for driver %q", name))
+	}
+
+	registry[name] = constructor
+}
+
+// Lookup returns the constructor of the driver registered with the name.
+//
+// Parameters:
+//   - name: the name of the driver
+//
+// Returns:
+//   - func(dsn string) (Driver, error): the constructor of the driver
+//   - bool: false if no driver is registered with the name
+func Lookup(name DriverType) (func(dsn string) (Driver, error), bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	constructor, exists := registry[name]
+	return constructor, exists
+}
@@ -0,0 +1,50 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	t.Run("should look up the built-in drivers", func(t *testing.T) {
+		_, exists := Lookup(DriverMattn)
+		assert.True(t, exists, "Expected the mattn driver to be registered")
+
+		_, exists = Lookup(DriverModernc)
+		assert.True(t, exists, "Expected the modernc driver to be registered")
+	})
+
+	t.Run("should register a custom driver", func(t *testing.T) {
+		var dsns []string
+		Register("custom", func(dsn string) (Driver, error) {
+			dsns = append(dsns, dsn)
+			return NewMattnDriver(dsn)
+		})
+
+		constructor, exists := Lookup("custom")
+		assert.True(t, exists, "Expected the custom driver to be registered")
+
+		driver, err := constructor(":memory:")
+		assert.NoError(t, err)
+		defer driver.Close()
+		assert.Equal(t, []string{":memory:"}, dsns, "Expected the custom constructor to be used")
+	})
+
+	t.Run("should not find an unknown driver", func(t *testing.T) {
+		_, exists := Lookup("unknown")
+		assert.False(t, exists)
+	})
+
+	t.Run("should panic when a driver is registered twice", func(t *testing.T) {
+		assert.Panics(t, func() {
+			Register(DriverMattn, NewMattnDriver)
+		})
+	})
+
+	t.Run("should panic for a nil constructor", func(t *testing.T) {
+		assert.Panics(t, func() {
+			Register("nil", nil)
+		})
+	})
+}
@@ -2,27 +2,26 @@ package database
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/lucasvillarinho/litepack/database/drivers"
+	"github.com/lucasvillarinho/litepack/internal/helpers"
 )
 
-type Driver string
+// Driver is the name of a driver, see drivers.Register to add drivers.
+type Driver = drivers.DriverType
 
 const (
 	// DriverMattn "github.com/mattn/go-sqlite3".
-	DriverMattn Driver = "mattn"
+	DriverMattn = drivers.DriverMattn
 	// DriverModernc r "modernc.org/sqlite".
-	DriverModernc Driver = "modernc"
+	DriverModernc = drivers.DriverModernc
 )
 
-var supportedDrivers = map[Driver]func(string) (drivers.Driver, error){
-	DriverMattn:   drivers.NewMattnDriver,
-	DriverModernc: drivers.NewModerncDriver,
-}
-
 // NewEngine creates a new instance of DriverFactory.
 func NewEngine(dt Driver, dsn string) (drivers.Driver, error) {
-	createDriverFunc, exists := supportedDrivers[dt]
+	createDriverFunc, exists := drivers.Lookup(dt)
 	if !exists {
 		return nil, fmt.Errorf("unsupported driver type: %s", dt)
 	}
@@ -34,3 +33,65 @@ func NewEngine(dt Driver, dsn string) (drivers.Driver, error) {
 
 	return driver, nil
 }
+
+// SynchronousMode is how often SQLite waits for the writes to reach the disk,
+// see SetSynchronous.
+type SynchronousMode string
+
+const (
+	// SynchronousOff doesn't wait for the writes to reach the disk. A crash of the
+	// operating system or a power loss can corrupt the database.
+	SynchronousOff SynchronousMode = "OFF"
+	// SynchronousNormal waits for the disk at the checkpoints of the WAL. A power loss
+	// can roll back the last transactions, but doesn't corrupt the database in WAL mode.
+	SynchronousNormal SynchronousMode = "NORMAL"
+	// SynchronousFull waits for the disk on each commit, the default of SQLite.
+	SynchronousFull SynchronousMode = "FULL"
+)
+
+// valid reports whether the mode is one of the synchronous modes.
+func (m SynchronousMode) valid() bool {
+	switch m {
+	case SynchronousOff, SynchronousNormal, SynchronousFull:
+		return true
+	default:
+		return false
+	}
+}
+
+// withBusyTimeout returns the DSN with the parameter of the driver that sets the busy
+// timeout of each connection. The DSN of the drivers added with drivers.Register is
+// returned as is.
+func withBusyTimeout(dt Driver, dsn string, timeout time.Duration) string {
+	if timeout <= 0 {
+		return dsn
+	}
+
+	ms := strconv.FormatInt(timeout.Milliseconds(), 10)
+	switch dt {
+	case DriverMattn:
+		return helpers.AppendDSNParam(dsn, "_busy_timeout", ms)
+	case DriverModernc:
+		return helpers.AppendDSNParam(dsn, "_pragma", "busy_timeout("+ms+")")
+	default:
+		return dsn
+	}
+}
+
+// withSynchronous returns the DSN with the parameter of the driver that sets the
+// synchronous mode of each connection. The DSN of the drivers added with
+// drivers.Register is returned as is.
+func withSynchronous(dt Driver, dsn string, mode SynchronousMode) string {
+	if mode == "" {
+		return dsn
+	}
+
+	switch dt {
+	case DriverMattn:
+		return helpers.AppendDSNParam(dsn, "_synchronous", string(mode))
+	case DriverModernc:
+		return helpers.AppendDSNParam(dsn, "_pragma", "synchronous("+string(mode)+")")
+	default:
+		return dsn
+	}
+}
@@ -0,0 +1,379 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/lucasvillarinho/litepack/database"
+)
+
+// Layout is the version of the layout of the litepack tables, stored as the user version
+// of the database. Databases without it predate the migrations, and their tables are
+// upgraded by the first migration of each module.
+const Layout = 1
+
+// ErrUnsupportedLayout is returned when the database was written by a newer version of
+// litepack, with a layout this version can't read.
+var ErrUnsupportedLayout = fmt.Errorf("unsupported database layout")
+
+// ErrInvalidMigration is returned when a migration has no version, no up step,
+// or shares its version with another migration.
+var ErrInvalidMigration = fmt.Errorf("invalid migration")
+
+// ErrIrreversibleMigration is returned when a rollback reaches a migration without
+// a down step.
+var ErrIrreversibleMigration = fmt.Errorf("irreversible migration")
+
+// Step changes the schema of the database inside the transaction of a migration.
+type Step func(ctx context.Context, tx *sql.Tx) error
+
+// Migration is a versioned change of the schema of a module.
+type Migration struct {
+	// Version orders the migrations of a module, it must be positive and unique
+	Version int
+	// Name describes the migration, it is recorded with the version
+	Name string
+	// Up applies the migration
+	Up Step
+	// Down reverts the migration, nil if it can't be reverted
+	Down Step
+}
+
+// Migrator applies the migrations of a module and records the applied versions in the
+// lpack_schema_migrations table, so that each migration runs once per database.
+type Migrator interface {
+	Migrate(ctx context.Context, migrations []Migration) error
+	Rollback(ctx context.Context, migrations []Migration, version int) error
+	Version(ctx context.Context) (int, error)
+}
+
+type migrator struct {
+	database database.Database
+	module   string
+}
+
+// NewMigrator creates a migrator of the schema of a module.
+// The modules sharing a database keep their versions apart, so each one numbers its
+// migrations from 1.
+//
+// Parameters:
+//   - db: the database
+//   - module: the name of the module, such as "cache"
+//
+// Returns:
+//   - Migrator: the migrator instance
+//
+// Example:
+//
+//	migrations := []migrate.Migration{
+//		{
+//			Version: 1,
+//			Name:    "create_users",
+//			Up:      migrate.SQL(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`),
+//			Down:    migrate.SQL(`DROP TABLE users`),
+//		},
+//	}
+//
+//	err := migrate.NewMigrator(db, "users").Migrate(ctx, migrations)
+func NewMigrator(db database.Database, module string) Migrator {
+	return &migrator{
+		database: db,
+		module:   module,
+	}
+}
+
+// SQL returns a step that executes the queries in order.
+//
+// Parameters:
+//   - queries: the queries to execute
+//
+// Returns:
+//   - Step: the step
+func SQL(queries ...string) Step {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, query := range queries {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// Migrate applies, in the order of their versions, the migrations not yet applied.
+// Each migration runs in its own transaction with the record of its version, so a
+// failed migration leaves the database at the previous version. The transaction checks
+// again that the version is not applied, so that concurrent opens of a new database
+// apply each migration once.
+//
+// Parameters:
+//   - ctx: the context
+//   - migrations: the migrations of the module
+//
+// Returns:
+//   - error: an error if the operation failed, ErrInvalidMigration if a migration
+//     is invalid, ErrUnsupportedLayout if the database has a newer layout
+func (m *migrator) Migrate(ctx context.Context, migrations []Migration) error {
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	layout, err := m.layout(ctx)
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range sorted {
+		if applied[migration.Version] {
+			continue
+		}
+
+		err := m.database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			isApplied, err := m.lockVersion(ctx, tx, migration.Version)
+			if err != nil || isApplied {
+				return err
+			}
+
+			if err := migration.Up(ctx, tx); err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO lpack_schema_migrations (module, version, name) VALUES (?, ?, ?)`,
+				m.module,
+				migration.Version,
+				migration.Name,
+			)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf(
+				"applying migration %d %s of %s: %w",
+				migration.Version,
+				migration.Name,
+				m.module,
+				err,
+			)
+		}
+	}
+
+	if layout < Layout {
+		if err := m.database.SetUserVersion(ctx, Layout); err != nil {
+			return fmt.Errorf("upgrading layout: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Rollback reverts, in the reverse order of their versions, the applied migrations
+// above the given version. Each migration runs in its own transaction with the removal
+// of the record of its version.
+//
+// Parameters:
+//   - ctx: the context
+//   - migrations: the migrations of the module
+//   - version: the version to go back to, 0 reverts every migration
+//
+// Returns:
+//   - error: an error if the operation failed, ErrIrreversibleMigration if a migration
+//     to revert has no down step, ErrUnsupportedLayout if the database has a newer layout
+func (m *migrator) Rollback(ctx context.Context, migrations []Migration, version int) error {
+	sorted, err := sortMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.layout(ctx); err != nil {
+		return err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := len(sorted) - 1; i >= 0; i-- {
+		migration := sorted[i]
+		if migration.Version <= version || !applied[migration.Version] {
+			continue
+		}
+		if migration.Down == nil {
+			return fmt.Errorf(
+				"%w: %d %s of %s",
+				ErrIrreversibleMigration,
+				migration.Version,
+				migration.Name,
+				m.module,
+			)
+		}
+
+		err := m.database.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			isApplied, err := m.lockVersion(ctx, tx, migration.Version)
+			if err != nil || !isApplied {
+				return err
+			}
+
+			if err := migration.Down(ctx, tx); err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(
+				ctx,
+				`DELETE FROM lpack_schema_migrations WHERE module = ? AND version = ?`,
+				m.module,
+				migration.Version,
+			)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf(
+				"reverting migration %d %s of %s: %w",
+				migration.Version,
+				migration.Name,
+				m.module,
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Version returns the latest applied version of the module, 0 if none was applied.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - int: the version
+//   - error: an error if the operation failed
+func (m *migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		version = max(version, v)
+	}
+
+	return version, nil
+}
+
+// layout returns the layout of the database, ErrUnsupportedLayout if it is newer than
+// Layout, so that the tables of a newer version are not read or changed.
+func (m *migrator) layout(ctx context.Context) (int, error) {
+	layout, err := m.database.GetUserVersion(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("reading layout: %w", err)
+	}
+	if layout > Layout {
+		return 0, fmt.Errorf(
+			"%w: layout %d is newer than %d, upgrade litepack to open the database",
+			ErrUnsupportedLayout,
+			layout,
+			Layout,
+		)
+	}
+
+	return layout, nil
+}
+
+// applied creates the table of the migrations, if it does not exist, and returns the
+// applied versions of the module.
+func (m *migrator) applied(ctx context.Context) (map[int]bool, error) {
+	engine := m.database.GetEngine(ctx)
+
+	_, err := engine.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS lpack_schema_migrations (
+		module TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (module, version)
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("creating migrations table: %w", err)
+	}
+
+	rows, err := engine.QueryContext(
+		ctx,
+		`SELECT version FROM lpack_schema_migrations WHERE module = ?`,
+		m.module,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("listing applied migrations: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// lockVersion takes the write lock of the database at the start of the transaction of a
+// migration, so that concurrent migrators wait for each other, and reports whether the
+// version of the module is applied.
+func (m *migrator) lockVersion(ctx context.Context, tx *sql.Tx, version int) (bool, error) {
+	_, err := tx.ExecContext(ctx, `UPDATE lpack_schema_migrations SET version = version WHERE 0`)
+	if err != nil {
+		return false, fmt.Errorf("locking migrations table: %w", err)
+	}
+
+	var count int
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM lpack_schema_migrations WHERE module = ? AND version = ?`,
+		m.module,
+		version,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("reading applied migration: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// sortMigrations validates the migrations and returns a copy sorted by version.
+func sortMigrations(migrations []Migration) ([]Migration, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	for i, migration := range sorted {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("%w: version %d", ErrInvalidMigration, migration.Version)
+		}
+		if migration.Up == nil {
+			return nil, fmt.Errorf("%w: version %d has no up step", ErrInvalidMigration, migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("%w: duplicate version %d", ErrInvalidMigration, migration.Version)
+		}
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,239 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/database"
+)
+
+func newTestDatabase(t *testing.T) database.Database {
+	t.Helper()
+
+	db, err := database.NewDatabase(context.Background(), database.InMemory, "")
+	assert.NoError(t, err, "Expected no error while creating the database")
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+	return db
+}
+
+func tableExists(t *testing.T, db database.Database, table string) bool {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow(
+		context.Background(),
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+		table,
+	).Scan(&count)
+	assert.NoError(t, err, "Expected no error while reading the schema")
+
+	return count > 0
+}
+
+var testMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_users",
+		Up:      SQL(`CREATE TABLE users (id INTEGER PRIMARY KEY)`),
+		Down:    SQL(`DROP TABLE users`),
+	},
+	{
+		Version: 2,
+		Name:    "create_roles",
+		Up:      SQL(`CREATE TABLE roles (id INTEGER PRIMARY KEY)`),
+		Down:    SQL(`DROP TABLE roles`),
+	},
+}
+
+func TestMigrator_Migrate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should apply the migrations in order and record their versions", func(t *testing.T) {
+		db := newTestDatabase(t)
+		m := NewMigrator(db, "test")
+
+		// reversed, so that the order comes from the versions
+		err := m.Migrate(ctx, []Migration{testMigrations[1], testMigrations[0]})
+
+		assert.NoError(t, err, "Expected no error while migrating")
+		assert.True(t, tableExists(t, db, "users"))
+		assert.True(t, tableExists(t, db, "roles"))
+		version, err := m.Version(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version, "Expected the latest version to be recorded")
+	})
+
+	t.Run("should skip the applied migrations", func(t *testing.T) {
+		db := newTestDatabase(t)
+		m := NewMigrator(db, "test")
+
+		assert.NoError(t, m.Migrate(ctx, testMigrations[:1]))
+		err := m.Migrate(ctx, testMigrations)
+
+		// CREATE TABLE users would fail if the first migration ran again
+		assert.NoError(t, err, "Expected the applied migrations to be skipped")
+		assert.True(t, tableExists(t, db, "roles"))
+	})
+
+	t.Run("should keep the versions of the modules apart", func(t *testing.T) {
+		db := newTestDatabase(t)
+
+		assert.NoError(t, NewMigrator(db, "users").Migrate(ctx, testMigrations[:1]))
+		err := NewMigrator(db, "roles").Migrate(ctx, []Migration{
+			{Version: 1, Name: "create_roles", Up: SQL(`CREATE TABLE roles (id INTEGER)`)},
+		})
+
+		assert.NoError(t, err, "Expected each module to number its migrations from 1")
+		assert.True(t, tableExists(t, db, "roles"))
+	})
+
+	t.Run("should roll back a failed migration", func(t *testing.T) {
+		db := newTestDatabase(t)
+		m := NewMigrator(db, "test")
+
+		err := m.Migrate(ctx, []Migration{
+			testMigrations[0],
+			{
+				Version: 2,
+				Name:    "broken",
+				Up:      SQL(`CREATE TABLE roles (id INTEGER)`, `NOT SQL`),
+			},
+		})
+
+		assert.ErrorContains(t, err, "applying migration 2 broken of test")
+		assert.True(t, tableExists(t, db, "users"), "Expected the previous migration to be kept")
+		assert.False(t, tableExists(t, db, "roles"), "Expected the failed migration to be rolled back")
+		version, err := m.Version(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, version)
+	})
+
+	t.Run("should apply each migration once when migrating concurrently", func(t *testing.T) {
+		dir := t.TempDir()
+		// slow, so that the migrators read the applied versions before the first one commits
+		migrations := []Migration{{
+			Version: 1,
+			Name:    "create_users",
+			Up: func(ctx context.Context, tx *sql.Tx) error {
+				time.Sleep(50 * time.Millisecond)
+				return SQL(`CREATE TABLE users (id INTEGER PRIMARY KEY)`)(ctx, tx)
+			},
+		}}
+
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		errs := make([]error, 4)
+		for i := range errs {
+			db, err := database.NewDatabase(ctx, dir, "test.db", database.WithBusyTimeout(5*time.Second))
+			assert.NoError(t, err, "Expected no error while opening the database")
+			t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				errs[i] = NewMigrator(db, "test").Migrate(ctx, migrations)
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		for _, err := range errs {
+			assert.NoError(t, err, "Expected the concurrent migrations to succeed")
+		}
+	})
+
+	t.Run("should upgrade the layout of the database", func(t *testing.T) {
+		db := newTestDatabase(t)
+
+		err := NewMigrator(db, "test").Migrate(ctx, testMigrations)
+
+		assert.NoError(t, err, "Expected no error while migrating")
+		layout, err := db.GetUserVersion(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, Layout, layout, "Expected the layout to be stored as the user version")
+	})
+
+	t.Run("should return ErrUnsupportedLayout for a newer layout", func(t *testing.T) {
+		db := newTestDatabase(t)
+		assert.NoError(t, db.SetUserVersion(ctx, Layout+1))
+
+		err := NewMigrator(db, "test").Migrate(ctx, testMigrations)
+
+		assert.ErrorIs(t, err, ErrUnsupportedLayout)
+		assert.False(t, tableExists(t, db, "users"), "Expected no migration to be applied")
+	})
+
+	t.Run("should return ErrInvalidMigration for invalid migrations", func(t *testing.T) {
+		m := NewMigrator(newTestDatabase(t), "test")
+		invalid := [][]Migration{
+			{{Version: 0, Up: SQL()}},
+			{{Version: 1}},
+			{{Version: 1, Up: SQL()}, {Version: 1, Up: SQL()}},
+		}
+
+		for _, migrations := range invalid {
+			err := m.Migrate(ctx, migrations)
+
+			assert.ErrorIs(t, err, ErrInvalidMigration, "Expected ErrInvalidMigration for %v", migrations)
+		}
+	})
+}
+
+func TestMigrator_Rollback(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should revert the migrations above the version", func(t *testing.T) {
+		db := newTestDatabase(t)
+		m := NewMigrator(db, "test")
+		assert.NoError(t, m.Migrate(ctx, testMigrations))
+
+		err := m.Rollback(ctx, testMigrations, 1)
+
+		assert.NoError(t, err, "Expected no error while rolling back")
+		assert.True(t, tableExists(t, db, "users"))
+		assert.False(t, tableExists(t, db, "roles"))
+		version, err := m.Version(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, version)
+	})
+
+	t.Run("should return ErrIrreversibleMigration without a down step", func(t *testing.T) {
+		db := newTestDatabase(t)
+		m := NewMigrator(db, "test")
+		migrations := []Migration{{Version: 1, Name: "create_users", Up: testMigrations[0].Up}}
+		assert.NoError(t, m.Migrate(ctx, migrations))
+
+		err := m.Rollback(ctx, migrations, 0)
+
+		assert.ErrorIs(t, err, ErrIrreversibleMigration)
+		assert.True(t, tableExists(t, db, "users"))
+	})
+
+	t.Run("should return the error of a failed down step", func(t *testing.T) {
+		db := newTestDatabase(t)
+		m := NewMigrator(db, "test")
+		migrations := []Migration{
+			{
+				Version: 1,
+				Name:    "create_users",
+				Up:      testMigrations[0].Up,
+				Down:    SQL(`DROP TABLE missing`),
+			},
+		}
+		assert.NoError(t, m.Migrate(ctx, migrations))
+
+		err := m.Rollback(ctx, migrations, 0)
+
+		assert.ErrorContains(t, err, "reverting migration 1 create_users of test")
+		version, err := m.Version(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, version, "Expected the version to be kept")
+	})
+}
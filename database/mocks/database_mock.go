@@ -11,6 +11,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	sql "database/sql"
+
+	time "time"
 )
 
 // DatabaseMock is an autogenerated mock type for the Database type
@@ -26,6 +28,146 @@ func (_m *DatabaseMock) EXPECT() *DatabaseMock_Expecter {
 	return &DatabaseMock_Expecter{mock: &_m.Mock}
 }
 
+// Analyze provides a mock function with given fields: ctx
+func (_m *DatabaseMock) Analyze(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Analyze")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_Analyze_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Analyze'
+type DatabaseMock_Analyze_Call struct {
+	*mock.Call
+}
+
+// Analyze is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *DatabaseMock_Expecter) Analyze(ctx interface{}) *DatabaseMock_Analyze_Call {
+	return &DatabaseMock_Analyze_Call{Call: _e.mock.On("Analyze", ctx)}
+}
+
+func (_c *DatabaseMock_Analyze_Call) Run(run func(ctx context.Context)) *DatabaseMock_Analyze_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_Analyze_Call) Return(_a0 error) *DatabaseMock_Analyze_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_Analyze_Call) RunAndReturn(run func(context.Context) error) *DatabaseMock_Analyze_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// BackupTo provides a mock function with given fields: ctx, destPath, progress
+func (_m *DatabaseMock) BackupTo(ctx context.Context, destPath string, progress func(int, int)) error {
+	ret := _m.Called(ctx, destPath, progress)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BackupTo")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(int, int)) error); ok {
+		r0 = rf(ctx, destPath, progress)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_BackupTo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BackupTo'
+type DatabaseMock_BackupTo_Call struct {
+	*mock.Call
+}
+
+// BackupTo is a helper method to define mock.On call
+//   - ctx context.Context
+//   - destPath string
+//   - progress func(int, int)
+func (_e *DatabaseMock_Expecter) BackupTo(ctx interface{}, destPath interface{}, progress interface{}) *DatabaseMock_BackupTo_Call {
+	return &DatabaseMock_BackupTo_Call{Call: _e.mock.On("BackupTo", ctx, destPath, progress)}
+}
+
+func (_c *DatabaseMock_BackupTo_Call) Run(run func(ctx context.Context, destPath string, progress func(int, int))) *DatabaseMock_BackupTo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(func(int, int)))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_BackupTo_Call) Return(_a0 error) *DatabaseMock_BackupTo_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_BackupTo_Call) RunAndReturn(run func(context.Context, string, func(int, int)) error) *DatabaseMock_BackupTo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Checkpoint provides a mock function with given fields: ctx
+func (_m *DatabaseMock) Checkpoint(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Checkpoint")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_Checkpoint_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Checkpoint'
+type DatabaseMock_Checkpoint_Call struct {
+	*mock.Call
+}
+
+// Checkpoint is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *DatabaseMock_Expecter) Checkpoint(ctx interface{}) *DatabaseMock_Checkpoint_Call {
+	return &DatabaseMock_Checkpoint_Call{Call: _e.mock.On("Checkpoint", ctx)}
+}
+
+func (_c *DatabaseMock_Checkpoint_Call) Run(run func(ctx context.Context)) *DatabaseMock_Checkpoint_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_Checkpoint_Call) Return(_a0 error) *DatabaseMock_Checkpoint_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_Checkpoint_Call) RunAndReturn(run func(context.Context) error) *DatabaseMock_Checkpoint_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Close provides a mock function with given fields: ctx
 func (_m *DatabaseMock) Close(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -176,59 +318,60 @@ func (_c *DatabaseMock_Exec_Call) RunAndReturn(run func(context.Context, string,
 	return _c
 }
 
-// ExecWithTx provides a mock function with given fields: ctx, fn
-func (_m *DatabaseMock) ExecWithTx(ctx context.Context, fn func(*sql.Tx) error) error {
-	ret := _m.Called(ctx, fn)
+// GetEngine provides a mock function with given fields: ctx
+func (_m *DatabaseMock) GetEngine(ctx context.Context) drivers.Driver {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ExecWithTx")
+		panic("no return value specified for GetEngine")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, func(*sql.Tx) error) error); ok {
-		r0 = rf(ctx, fn)
+	var r0 drivers.Driver
+	if rf, ok := ret.Get(0).(func(context.Context) drivers.Driver); ok {
+		r0 = rf(ctx)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(drivers.Driver)
+		}
 	}
 
 	return r0
 }
 
-// DatabaseMock_ExecWithTx_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExecWithTx'
-type DatabaseMock_ExecWithTx_Call struct {
+// DatabaseMock_GetEngine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetEngine'
+type DatabaseMock_GetEngine_Call struct {
 	*mock.Call
 }
 
-// ExecWithTx is a helper method to define mock.On call
+// GetEngine is a helper method to define mock.On call
 //   - ctx context.Context
-//   - fn func(*sql.Tx) error
-func (_e *DatabaseMock_Expecter) ExecWithTx(ctx interface{}, fn interface{}) *DatabaseMock_ExecWithTx_Call {
-	return &DatabaseMock_ExecWithTx_Call{Call: _e.mock.On("ExecWithTx", ctx, fn)}
+func (_e *DatabaseMock_Expecter) GetEngine(ctx interface{}) *DatabaseMock_GetEngine_Call {
+	return &DatabaseMock_GetEngine_Call{Call: _e.mock.On("GetEngine", ctx)}
 }
 
-func (_c *DatabaseMock_ExecWithTx_Call) Run(run func(ctx context.Context, fn func(*sql.Tx) error)) *DatabaseMock_ExecWithTx_Call {
+func (_c *DatabaseMock_GetEngine_Call) Run(run func(ctx context.Context)) *DatabaseMock_GetEngine_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(func(*sql.Tx) error))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *DatabaseMock_ExecWithTx_Call) Return(_a0 error) *DatabaseMock_ExecWithTx_Call {
+func (_c *DatabaseMock_GetEngine_Call) Return(_a0 drivers.Driver) *DatabaseMock_GetEngine_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *DatabaseMock_ExecWithTx_Call) RunAndReturn(run func(context.Context, func(*sql.Tx) error) error) *DatabaseMock_ExecWithTx_Call {
+func (_c *DatabaseMock_GetEngine_Call) RunAndReturn(run func(context.Context) drivers.Driver) *DatabaseMock_GetEngine_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetEngine provides a mock function with given fields: ctx
-func (_m *DatabaseMock) GetEngine(ctx context.Context) drivers.Driver {
+// GetReadEngine provides a mock function with given fields: ctx
+func (_m *DatabaseMock) GetReadEngine(ctx context.Context) drivers.Driver {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetEngine")
+		panic("no return value specified for GetReadEngine")
 	}
 
 	var r0 drivers.Driver
@@ -243,92 +386,101 @@ func (_m *DatabaseMock) GetEngine(ctx context.Context) drivers.Driver {
 	return r0
 }
 
-// DatabaseMock_GetEngine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetEngine'
-type DatabaseMock_GetEngine_Call struct {
+// DatabaseMock_GetReadEngine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetReadEngine'
+type DatabaseMock_GetReadEngine_Call struct {
 	*mock.Call
 }
 
-// GetEngine is a helper method to define mock.On call
+// GetReadEngine is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *DatabaseMock_Expecter) GetEngine(ctx interface{}) *DatabaseMock_GetEngine_Call {
-	return &DatabaseMock_GetEngine_Call{Call: _e.mock.On("GetEngine", ctx)}
+func (_e *DatabaseMock_Expecter) GetReadEngine(ctx interface{}) *DatabaseMock_GetReadEngine_Call {
+	return &DatabaseMock_GetReadEngine_Call{Call: _e.mock.On("GetReadEngine", ctx)}
 }
 
-func (_c *DatabaseMock_GetEngine_Call) Run(run func(ctx context.Context)) *DatabaseMock_GetEngine_Call {
+func (_c *DatabaseMock_GetReadEngine_Call) Run(run func(ctx context.Context)) *DatabaseMock_GetReadEngine_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *DatabaseMock_GetEngine_Call) Return(_a0 drivers.Driver) *DatabaseMock_GetEngine_Call {
+func (_c *DatabaseMock_GetReadEngine_Call) Return(_a0 drivers.Driver) *DatabaseMock_GetReadEngine_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *DatabaseMock_GetEngine_Call) RunAndReturn(run func(context.Context) drivers.Driver) *DatabaseMock_GetEngine_Call {
+func (_c *DatabaseMock_GetReadEngine_Call) RunAndReturn(run func(context.Context) drivers.Driver) *DatabaseMock_GetReadEngine_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetCacheSize provides a mock function with given fields: ctx, cacheSize
-func (_m *DatabaseMock) SetCacheSize(ctx context.Context, cacheSize int) error {
-	ret := _m.Called(ctx, cacheSize)
+// GetUserVersion provides a mock function with given fields: ctx
+func (_m *DatabaseMock) GetUserVersion(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SetCacheSize")
+		panic("no return value specified for GetUserVersion")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
-		r0 = rf(ctx, cacheSize)
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(int)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// DatabaseMock_SetCacheSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetCacheSize'
-type DatabaseMock_SetCacheSize_Call struct {
+// DatabaseMock_GetUserVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserVersion'
+type DatabaseMock_GetUserVersion_Call struct {
 	*mock.Call
 }
 
-// SetCacheSize is a helper method to define mock.On call
+// GetUserVersion is a helper method to define mock.On call
 //   - ctx context.Context
-//   - cacheSize int
-func (_e *DatabaseMock_Expecter) SetCacheSize(ctx interface{}, cacheSize interface{}) *DatabaseMock_SetCacheSize_Call {
-	return &DatabaseMock_SetCacheSize_Call{Call: _e.mock.On("SetCacheSize", ctx, cacheSize)}
+func (_e *DatabaseMock_Expecter) GetUserVersion(ctx interface{}) *DatabaseMock_GetUserVersion_Call {
+	return &DatabaseMock_GetUserVersion_Call{Call: _e.mock.On("GetUserVersion", ctx)}
 }
 
-func (_c *DatabaseMock_SetCacheSize_Call) Run(run func(ctx context.Context, cacheSize int)) *DatabaseMock_SetCacheSize_Call {
+func (_c *DatabaseMock_GetUserVersion_Call) Run(run func(ctx context.Context)) *DatabaseMock_GetUserVersion_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(int))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *DatabaseMock_SetCacheSize_Call) Return(_a0 error) *DatabaseMock_SetCacheSize_Call {
-	_c.Call.Return(_a0)
+func (_c *DatabaseMock_GetUserVersion_Call) Return(_a0 int, _a1 error) *DatabaseMock_GetUserVersion_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *DatabaseMock_SetCacheSize_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetCacheSize_Call {
+func (_c *DatabaseMock_GetUserVersion_Call) RunAndReturn(run func(context.Context) (int, error)) *DatabaseMock_GetUserVersion_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetEngine provides a mock function with given fields: ctx, driver
-func (_m *DatabaseMock) SetEngine(ctx context.Context, driver database.Driver) error {
-	ret := _m.Called(ctx, driver)
+// IncrementalVacuum provides a mock function with given fields: ctx, pages
+func (_m *DatabaseMock) IncrementalVacuum(ctx context.Context, pages int) error {
+	ret := _m.Called(ctx, pages)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SetEngine")
+		panic("no return value specified for IncrementalVacuum")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, database.Driver) error); ok {
-		r0 = rf(ctx, driver)
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, pages)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -336,92 +488,105 @@ func (_m *DatabaseMock) SetEngine(ctx context.Context, driver database.Driver) e
 	return r0
 }
 
-// DatabaseMock_SetEngine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetEngine'
-type DatabaseMock_SetEngine_Call struct {
+// DatabaseMock_IncrementalVacuum_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IncrementalVacuum'
+type DatabaseMock_IncrementalVacuum_Call struct {
 	*mock.Call
 }
 
-// SetEngine is a helper method to define mock.On call
+// IncrementalVacuum is a helper method to define mock.On call
 //   - ctx context.Context
-//   - driver database.Driver
-func (_e *DatabaseMock_Expecter) SetEngine(ctx interface{}, driver interface{}) *DatabaseMock_SetEngine_Call {
-	return &DatabaseMock_SetEngine_Call{Call: _e.mock.On("SetEngine", ctx, driver)}
+//   - pages int
+func (_e *DatabaseMock_Expecter) IncrementalVacuum(ctx interface{}, pages interface{}) *DatabaseMock_IncrementalVacuum_Call {
+	return &DatabaseMock_IncrementalVacuum_Call{Call: _e.mock.On("IncrementalVacuum", ctx, pages)}
 }
 
-func (_c *DatabaseMock_SetEngine_Call) Run(run func(ctx context.Context, driver database.Driver)) *DatabaseMock_SetEngine_Call {
+func (_c *DatabaseMock_IncrementalVacuum_Call) Run(run func(ctx context.Context, pages int)) *DatabaseMock_IncrementalVacuum_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(database.Driver))
+		run(args[0].(context.Context), args[1].(int))
 	})
 	return _c
 }
 
-func (_c *DatabaseMock_SetEngine_Call) Return(_a0 error) *DatabaseMock_SetEngine_Call {
+func (_c *DatabaseMock_IncrementalVacuum_Call) Return(_a0 error) *DatabaseMock_IncrementalVacuum_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *DatabaseMock_SetEngine_Call) RunAndReturn(run func(context.Context, database.Driver) error) *DatabaseMock_SetEngine_Call {
+func (_c *DatabaseMock_IncrementalVacuum_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_IncrementalVacuum_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetJournalModeWal provides a mock function with given fields: ctx
-func (_m *DatabaseMock) SetJournalModeWal(ctx context.Context) error {
-	ret := _m.Called(ctx)
+// IntegrityCheck provides a mock function with given fields: ctx, quick
+func (_m *DatabaseMock) IntegrityCheck(ctx context.Context, quick bool) ([]string, error) {
+	ret := _m.Called(ctx, quick)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SetJournalModeWal")
+		panic("no return value specified for IntegrityCheck")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
-		r0 = rf(ctx)
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]string, error)); ok {
+		return rf(ctx, quick)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []string); ok {
+		r0 = rf(ctx, quick)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, quick)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// DatabaseMock_SetJournalModeWal_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetJournalModeWal'
-type DatabaseMock_SetJournalModeWal_Call struct {
+// DatabaseMock_IntegrityCheck_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IntegrityCheck'
+type DatabaseMock_IntegrityCheck_Call struct {
 	*mock.Call
 }
 
-// SetJournalModeWal is a helper method to define mock.On call
+// IntegrityCheck is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *DatabaseMock_Expecter) SetJournalModeWal(ctx interface{}) *DatabaseMock_SetJournalModeWal_Call {
-	return &DatabaseMock_SetJournalModeWal_Call{Call: _e.mock.On("SetJournalModeWal", ctx)}
+//   - quick bool
+func (_e *DatabaseMock_Expecter) IntegrityCheck(ctx interface{}, quick interface{}) *DatabaseMock_IntegrityCheck_Call {
+	return &DatabaseMock_IntegrityCheck_Call{Call: _e.mock.On("IntegrityCheck", ctx, quick)}
 }
 
-func (_c *DatabaseMock_SetJournalModeWal_Call) Run(run func(ctx context.Context)) *DatabaseMock_SetJournalModeWal_Call {
+func (_c *DatabaseMock_IntegrityCheck_Call) Run(run func(ctx context.Context, quick bool)) *DatabaseMock_IntegrityCheck_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(bool))
 	})
 	return _c
 }
 
-func (_c *DatabaseMock_SetJournalModeWal_Call) Return(_a0 error) *DatabaseMock_SetJournalModeWal_Call {
-	_c.Call.Return(_a0)
+func (_c *DatabaseMock_IntegrityCheck_Call) Return(_a0 []string, _a1 error) *DatabaseMock_IntegrityCheck_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *DatabaseMock_SetJournalModeWal_Call) RunAndReturn(run func(context.Context) error) *DatabaseMock_SetJournalModeWal_Call {
+func (_c *DatabaseMock_IntegrityCheck_Call) RunAndReturn(run func(context.Context, bool) ([]string, error)) *DatabaseMock_IntegrityCheck_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetMaxPageCount provides a mock function with given fields: ctx, pageCount
-func (_m *DatabaseMock) SetMaxPageCount(ctx context.Context, pageCount int) error {
-	ret := _m.Called(ctx, pageCount)
+// OpenReadPool provides a mock function with given fields: ctx, size
+func (_m *DatabaseMock) OpenReadPool(ctx context.Context, size int) error {
+	ret := _m.Called(ctx, size)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SetMaxPageCount")
+		panic("no return value specified for OpenReadPool")
 	}
 
 	var r0 error
 	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
-		r0 = rf(ctx, pageCount)
+		r0 = rf(ctx, size)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -429,46 +594,46 @@ func (_m *DatabaseMock) SetMaxPageCount(ctx context.Context, pageCount int) erro
 	return r0
 }
 
-// DatabaseMock_SetMaxPageCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMaxPageCount'
-type DatabaseMock_SetMaxPageCount_Call struct {
+// DatabaseMock_OpenReadPool_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'OpenReadPool'
+type DatabaseMock_OpenReadPool_Call struct {
 	*mock.Call
 }
 
-// SetMaxPageCount is a helper method to define mock.On call
+// OpenReadPool is a helper method to define mock.On call
 //   - ctx context.Context
-//   - pageCount int
-func (_e *DatabaseMock_Expecter) SetMaxPageCount(ctx interface{}, pageCount interface{}) *DatabaseMock_SetMaxPageCount_Call {
-	return &DatabaseMock_SetMaxPageCount_Call{Call: _e.mock.On("SetMaxPageCount", ctx, pageCount)}
+//   - size int
+func (_e *DatabaseMock_Expecter) OpenReadPool(ctx interface{}, size interface{}) *DatabaseMock_OpenReadPool_Call {
+	return &DatabaseMock_OpenReadPool_Call{Call: _e.mock.On("OpenReadPool", ctx, size)}
 }
 
-func (_c *DatabaseMock_SetMaxPageCount_Call) Run(run func(ctx context.Context, pageCount int)) *DatabaseMock_SetMaxPageCount_Call {
+func (_c *DatabaseMock_OpenReadPool_Call) Run(run func(ctx context.Context, size int)) *DatabaseMock_OpenReadPool_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(int))
 	})
 	return _c
 }
 
-func (_c *DatabaseMock_SetMaxPageCount_Call) Return(_a0 error) *DatabaseMock_SetMaxPageCount_Call {
+func (_c *DatabaseMock_OpenReadPool_Call) Return(_a0 error) *DatabaseMock_OpenReadPool_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *DatabaseMock_SetMaxPageCount_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetMaxPageCount_Call {
+func (_c *DatabaseMock_OpenReadPool_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_OpenReadPool_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetPageSize provides a mock function with given fields: ctx, pageSize
-func (_m *DatabaseMock) SetPageSize(ctx context.Context, pageSize int) error {
-	ret := _m.Called(ctx, pageSize)
+// Optimize provides a mock function with given fields: ctx
+func (_m *DatabaseMock) Optimize(ctx context.Context) error {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SetPageSize")
+		panic("no return value specified for Optimize")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
-		r0 = rf(ctx, pageSize)
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -476,41 +641,40 @@ func (_m *DatabaseMock) SetPageSize(ctx context.Context, pageSize int) error {
 	return r0
 }
 
-// DatabaseMock_SetPageSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetPageSize'
-type DatabaseMock_SetPageSize_Call struct {
+// DatabaseMock_Optimize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Optimize'
+type DatabaseMock_Optimize_Call struct {
 	*mock.Call
 }
 
-// SetPageSize is a helper method to define mock.On call
+// Optimize is a helper method to define mock.On call
 //   - ctx context.Context
-//   - pageSize int
-func (_e *DatabaseMock_Expecter) SetPageSize(ctx interface{}, pageSize interface{}) *DatabaseMock_SetPageSize_Call {
-	return &DatabaseMock_SetPageSize_Call{Call: _e.mock.On("SetPageSize", ctx, pageSize)}
+func (_e *DatabaseMock_Expecter) Optimize(ctx interface{}) *DatabaseMock_Optimize_Call {
+	return &DatabaseMock_Optimize_Call{Call: _e.mock.On("Optimize", ctx)}
 }
 
-func (_c *DatabaseMock_SetPageSize_Call) Run(run func(ctx context.Context, pageSize int)) *DatabaseMock_SetPageSize_Call {
+func (_c *DatabaseMock_Optimize_Call) Run(run func(ctx context.Context)) *DatabaseMock_Optimize_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(int))
+		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *DatabaseMock_SetPageSize_Call) Return(_a0 error) *DatabaseMock_SetPageSize_Call {
+func (_c *DatabaseMock_Optimize_Call) Return(_a0 error) *DatabaseMock_Optimize_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *DatabaseMock_SetPageSize_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetPageSize_Call {
+func (_c *DatabaseMock_Optimize_Call) RunAndReturn(run func(context.Context) error) *DatabaseMock_Optimize_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Vacuum provides a mock function with given fields: ctx
-func (_m *DatabaseMock) Vacuum(ctx context.Context) error {
+// Ping provides a mock function with given fields: ctx
+func (_m *DatabaseMock) Ping(ctx context.Context) error {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Vacuum")
+		panic("no return value specified for Ping")
 	}
 
 	var r0 error
@@ -523,30 +687,1024 @@ func (_m *DatabaseMock) Vacuum(ctx context.Context) error {
 	return r0
 }
 
-// DatabaseMock_Vacuum_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Vacuum'
-type DatabaseMock_Vacuum_Call struct {
+// DatabaseMock_Ping_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Ping'
+type DatabaseMock_Ping_Call struct {
 	*mock.Call
 }
 
-// Vacuum is a helper method to define mock.On call
+// Ping is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *DatabaseMock_Expecter) Vacuum(ctx interface{}) *DatabaseMock_Vacuum_Call {
-	return &DatabaseMock_Vacuum_Call{Call: _e.mock.On("Vacuum", ctx)}
+func (_e *DatabaseMock_Expecter) Ping(ctx interface{}) *DatabaseMock_Ping_Call {
+	return &DatabaseMock_Ping_Call{Call: _e.mock.On("Ping", ctx)}
 }
 
-func (_c *DatabaseMock_Vacuum_Call) Run(run func(ctx context.Context)) *DatabaseMock_Vacuum_Call {
+func (_c *DatabaseMock_Ping_Call) Run(run func(ctx context.Context)) *DatabaseMock_Ping_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context))
 	})
 	return _c
 }
 
-func (_c *DatabaseMock_Vacuum_Call) Return(_a0 error) *DatabaseMock_Vacuum_Call {
+func (_c *DatabaseMock_Ping_Call) Return(_a0 error) *DatabaseMock_Ping_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *DatabaseMock_Vacuum_Call) RunAndReturn(run func(context.Context) error) *DatabaseMock_Vacuum_Call {
+func (_c *DatabaseMock_Ping_Call) RunAndReturn(run func(context.Context) error) *DatabaseMock_Ping_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Pragma provides a mock function with given fields: ctx, name
+func (_m *DatabaseMock) Pragma(ctx context.Context, name string) (string, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Pragma")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DatabaseMock_Pragma_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Pragma'
+type DatabaseMock_Pragma_Call struct {
+	*mock.Call
+}
+
+// Pragma is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *DatabaseMock_Expecter) Pragma(ctx interface{}, name interface{}) *DatabaseMock_Pragma_Call {
+	return &DatabaseMock_Pragma_Call{Call: _e.mock.On("Pragma", ctx, name)}
+}
+
+func (_c *DatabaseMock_Pragma_Call) Run(run func(ctx context.Context, name string)) *DatabaseMock_Pragma_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_Pragma_Call) Return(_a0 string, _a1 error) *DatabaseMock_Pragma_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DatabaseMock_Pragma_Call) RunAndReturn(run func(context.Context, string) (string, error)) *DatabaseMock_Pragma_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Query provides a mock function with given fields: ctx, query, args
+func (_m *DatabaseMock) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, query)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Query")
+	}
+
+	var r0 *sql.Rows
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) (*sql.Rows, error)); ok {
+		return rf(ctx, query, args...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *sql.Rows); ok {
+		r0 = rf(ctx, query, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*sql.Rows)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...interface{}) error); ok {
+		r1 = rf(ctx, query, args...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DatabaseMock_Query_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Query'
+type DatabaseMock_Query_Call struct {
+	*mock.Call
+}
+
+// Query is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+//   - args ...interface{}
+func (_e *DatabaseMock_Expecter) Query(ctx interface{}, query interface{}, args ...interface{}) *DatabaseMock_Query_Call {
+	return &DatabaseMock_Query_Call{Call: _e.mock.On("Query",
+		append([]interface{}{ctx, query}, args...)...)}
+}
+
+func (_c *DatabaseMock_Query_Call) Run(run func(ctx context.Context, query string, args ...interface{})) *DatabaseMock_Query_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]interface{}, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(interface{})
+			}
+		}
+		run(args[0].(context.Context), args[1].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_Query_Call) Return(_a0 *sql.Rows, _a1 error) *DatabaseMock_Query_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DatabaseMock_Query_Call) RunAndReturn(run func(context.Context, string, ...interface{}) (*sql.Rows, error)) *DatabaseMock_Query_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// QueryRow provides a mock function with given fields: ctx, query, args
+func (_m *DatabaseMock) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, query)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryRow")
+	}
+
+	var r0 *sql.Row
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *sql.Row); ok {
+		r0 = rf(ctx, query, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*sql.Row)
+		}
+	}
+
+	return r0
+}
+
+// DatabaseMock_QueryRow_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QueryRow'
+type DatabaseMock_QueryRow_Call struct {
+	*mock.Call
+}
+
+// QueryRow is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+//   - args ...interface{}
+func (_e *DatabaseMock_Expecter) QueryRow(ctx interface{}, query interface{}, args ...interface{}) *DatabaseMock_QueryRow_Call {
+	return &DatabaseMock_QueryRow_Call{Call: _e.mock.On("QueryRow",
+		append([]interface{}{ctx, query}, args...)...)}
+}
+
+func (_c *DatabaseMock_QueryRow_Call) Run(run func(ctx context.Context, query string, args ...interface{})) *DatabaseMock_QueryRow_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]interface{}, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(interface{})
+			}
+		}
+		run(args[0].(context.Context), args[1].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_QueryRow_Call) Return(_a0 *sql.Row) *DatabaseMock_QueryRow_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_QueryRow_Call) RunAndReturn(run func(context.Context, string, ...interface{}) *sql.Row) *DatabaseMock_QueryRow_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetAutoVacuumIncremental provides a mock function with given fields: ctx
+func (_m *DatabaseMock) SetAutoVacuumIncremental(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetAutoVacuumIncremental")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetAutoVacuumIncremental_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetAutoVacuumIncremental'
+type DatabaseMock_SetAutoVacuumIncremental_Call struct {
+	*mock.Call
+}
+
+// SetAutoVacuumIncremental is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *DatabaseMock_Expecter) SetAutoVacuumIncremental(ctx interface{}) *DatabaseMock_SetAutoVacuumIncremental_Call {
+	return &DatabaseMock_SetAutoVacuumIncremental_Call{Call: _e.mock.On("SetAutoVacuumIncremental", ctx)}
+}
+
+func (_c *DatabaseMock_SetAutoVacuumIncremental_Call) Run(run func(ctx context.Context)) *DatabaseMock_SetAutoVacuumIncremental_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetAutoVacuumIncremental_Call) Return(_a0 error) *DatabaseMock_SetAutoVacuumIncremental_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetAutoVacuumIncremental_Call) RunAndReturn(run func(context.Context) error) *DatabaseMock_SetAutoVacuumIncremental_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetBusyTimeout provides a mock function with given fields: ctx, timeout
+func (_m *DatabaseMock) SetBusyTimeout(ctx context.Context, timeout time.Duration) error {
+	ret := _m.Called(ctx, timeout)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetBusyTimeout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) error); ok {
+		r0 = rf(ctx, timeout)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetBusyTimeout_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetBusyTimeout'
+type DatabaseMock_SetBusyTimeout_Call struct {
+	*mock.Call
+}
+
+// SetBusyTimeout is a helper method to define mock.On call
+//   - ctx context.Context
+//   - timeout time.Duration
+func (_e *DatabaseMock_Expecter) SetBusyTimeout(ctx interface{}, timeout interface{}) *DatabaseMock_SetBusyTimeout_Call {
+	return &DatabaseMock_SetBusyTimeout_Call{Call: _e.mock.On("SetBusyTimeout", ctx, timeout)}
+}
+
+func (_c *DatabaseMock_SetBusyTimeout_Call) Run(run func(ctx context.Context, timeout time.Duration)) *DatabaseMock_SetBusyTimeout_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetBusyTimeout_Call) Return(_a0 error) *DatabaseMock_SetBusyTimeout_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetBusyTimeout_Call) RunAndReturn(run func(context.Context, time.Duration) error) *DatabaseMock_SetBusyTimeout_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetCacheSize provides a mock function with given fields: ctx, cacheSize
+func (_m *DatabaseMock) SetCacheSize(ctx context.Context, cacheSize int) error {
+	ret := _m.Called(ctx, cacheSize)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCacheSize")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, cacheSize)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetCacheSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetCacheSize'
+type DatabaseMock_SetCacheSize_Call struct {
+	*mock.Call
+}
+
+// SetCacheSize is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cacheSize int
+func (_e *DatabaseMock_Expecter) SetCacheSize(ctx interface{}, cacheSize interface{}) *DatabaseMock_SetCacheSize_Call {
+	return &DatabaseMock_SetCacheSize_Call{Call: _e.mock.On("SetCacheSize", ctx, cacheSize)}
+}
+
+func (_c *DatabaseMock_SetCacheSize_Call) Run(run func(ctx context.Context, cacheSize int)) *DatabaseMock_SetCacheSize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetCacheSize_Call) Return(_a0 error) *DatabaseMock_SetCacheSize_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetCacheSize_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetCacheSize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetConnMaxLifetime provides a mock function with given fields: ctx, lifetime
+func (_m *DatabaseMock) SetConnMaxLifetime(ctx context.Context, lifetime time.Duration) error {
+	ret := _m.Called(ctx, lifetime)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetConnMaxLifetime")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) error); ok {
+		r0 = rf(ctx, lifetime)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetConnMaxLifetime_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetConnMaxLifetime'
+type DatabaseMock_SetConnMaxLifetime_Call struct {
+	*mock.Call
+}
+
+// SetConnMaxLifetime is a helper method to define mock.On call
+//   - ctx context.Context
+//   - lifetime time.Duration
+func (_e *DatabaseMock_Expecter) SetConnMaxLifetime(ctx interface{}, lifetime interface{}) *DatabaseMock_SetConnMaxLifetime_Call {
+	return &DatabaseMock_SetConnMaxLifetime_Call{Call: _e.mock.On("SetConnMaxLifetime", ctx, lifetime)}
+}
+
+func (_c *DatabaseMock_SetConnMaxLifetime_Call) Run(run func(ctx context.Context, lifetime time.Duration)) *DatabaseMock_SetConnMaxLifetime_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetConnMaxLifetime_Call) Return(_a0 error) *DatabaseMock_SetConnMaxLifetime_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetConnMaxLifetime_Call) RunAndReturn(run func(context.Context, time.Duration) error) *DatabaseMock_SetConnMaxLifetime_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetEngine provides a mock function with given fields: ctx, driver
+func (_m *DatabaseMock) SetEngine(ctx context.Context, driver database.Driver) error {
+	ret := _m.Called(ctx, driver)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetEngine")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, database.Driver) error); ok {
+		r0 = rf(ctx, driver)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetEngine_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetEngine'
+type DatabaseMock_SetEngine_Call struct {
+	*mock.Call
+}
+
+// SetEngine is a helper method to define mock.On call
+//   - ctx context.Context
+//   - driver database.Driver
+func (_e *DatabaseMock_Expecter) SetEngine(ctx interface{}, driver interface{}) *DatabaseMock_SetEngine_Call {
+	return &DatabaseMock_SetEngine_Call{Call: _e.mock.On("SetEngine", ctx, driver)}
+}
+
+func (_c *DatabaseMock_SetEngine_Call) Run(run func(ctx context.Context, driver database.Driver)) *DatabaseMock_SetEngine_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(database.Driver))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetEngine_Call) Return(_a0 error) *DatabaseMock_SetEngine_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetEngine_Call) RunAndReturn(run func(context.Context, database.Driver) error) *DatabaseMock_SetEngine_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetJournalModeWal provides a mock function with given fields: ctx
+func (_m *DatabaseMock) SetJournalModeWal(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetJournalModeWal")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetJournalModeWal_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetJournalModeWal'
+type DatabaseMock_SetJournalModeWal_Call struct {
+	*mock.Call
+}
+
+// SetJournalModeWal is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *DatabaseMock_Expecter) SetJournalModeWal(ctx interface{}) *DatabaseMock_SetJournalModeWal_Call {
+	return &DatabaseMock_SetJournalModeWal_Call{Call: _e.mock.On("SetJournalModeWal", ctx)}
+}
+
+func (_c *DatabaseMock_SetJournalModeWal_Call) Run(run func(ctx context.Context)) *DatabaseMock_SetJournalModeWal_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetJournalModeWal_Call) Return(_a0 error) *DatabaseMock_SetJournalModeWal_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetJournalModeWal_Call) RunAndReturn(run func(context.Context) error) *DatabaseMock_SetJournalModeWal_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetMaxIdleConns provides a mock function with given fields: ctx, n
+func (_m *DatabaseMock) SetMaxIdleConns(ctx context.Context, n int) error {
+	ret := _m.Called(ctx, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMaxIdleConns")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, n)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetMaxIdleConns_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMaxIdleConns'
+type DatabaseMock_SetMaxIdleConns_Call struct {
+	*mock.Call
+}
+
+// SetMaxIdleConns is a helper method to define mock.On call
+//   - ctx context.Context
+//   - n int
+func (_e *DatabaseMock_Expecter) SetMaxIdleConns(ctx interface{}, n interface{}) *DatabaseMock_SetMaxIdleConns_Call {
+	return &DatabaseMock_SetMaxIdleConns_Call{Call: _e.mock.On("SetMaxIdleConns", ctx, n)}
+}
+
+func (_c *DatabaseMock_SetMaxIdleConns_Call) Run(run func(ctx context.Context, n int)) *DatabaseMock_SetMaxIdleConns_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetMaxIdleConns_Call) Return(_a0 error) *DatabaseMock_SetMaxIdleConns_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetMaxIdleConns_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetMaxIdleConns_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetMaxOpenConns provides a mock function with given fields: ctx, n
+func (_m *DatabaseMock) SetMaxOpenConns(ctx context.Context, n int) error {
+	ret := _m.Called(ctx, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMaxOpenConns")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, n)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetMaxOpenConns_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMaxOpenConns'
+type DatabaseMock_SetMaxOpenConns_Call struct {
+	*mock.Call
+}
+
+// SetMaxOpenConns is a helper method to define mock.On call
+//   - ctx context.Context
+//   - n int
+func (_e *DatabaseMock_Expecter) SetMaxOpenConns(ctx interface{}, n interface{}) *DatabaseMock_SetMaxOpenConns_Call {
+	return &DatabaseMock_SetMaxOpenConns_Call{Call: _e.mock.On("SetMaxOpenConns", ctx, n)}
+}
+
+func (_c *DatabaseMock_SetMaxOpenConns_Call) Run(run func(ctx context.Context, n int)) *DatabaseMock_SetMaxOpenConns_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetMaxOpenConns_Call) Return(_a0 error) *DatabaseMock_SetMaxOpenConns_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetMaxOpenConns_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetMaxOpenConns_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetMaxPageCount provides a mock function with given fields: ctx, pageCount
+func (_m *DatabaseMock) SetMaxPageCount(ctx context.Context, pageCount int) error {
+	ret := _m.Called(ctx, pageCount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMaxPageCount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, pageCount)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetMaxPageCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMaxPageCount'
+type DatabaseMock_SetMaxPageCount_Call struct {
+	*mock.Call
+}
+
+// SetMaxPageCount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pageCount int
+func (_e *DatabaseMock_Expecter) SetMaxPageCount(ctx interface{}, pageCount interface{}) *DatabaseMock_SetMaxPageCount_Call {
+	return &DatabaseMock_SetMaxPageCount_Call{Call: _e.mock.On("SetMaxPageCount", ctx, pageCount)}
+}
+
+func (_c *DatabaseMock_SetMaxPageCount_Call) Run(run func(ctx context.Context, pageCount int)) *DatabaseMock_SetMaxPageCount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetMaxPageCount_Call) Return(_a0 error) *DatabaseMock_SetMaxPageCount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetMaxPageCount_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetMaxPageCount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetMmapSize provides a mock function with given fields: ctx, size
+func (_m *DatabaseMock) SetMmapSize(ctx context.Context, size int) error {
+	ret := _m.Called(ctx, size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMmapSize")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, size)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetMmapSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMmapSize'
+type DatabaseMock_SetMmapSize_Call struct {
+	*mock.Call
+}
+
+// SetMmapSize is a helper method to define mock.On call
+//   - ctx context.Context
+//   - size int
+func (_e *DatabaseMock_Expecter) SetMmapSize(ctx interface{}, size interface{}) *DatabaseMock_SetMmapSize_Call {
+	return &DatabaseMock_SetMmapSize_Call{Call: _e.mock.On("SetMmapSize", ctx, size)}
+}
+
+func (_c *DatabaseMock_SetMmapSize_Call) Run(run func(ctx context.Context, size int)) *DatabaseMock_SetMmapSize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetMmapSize_Call) Return(_a0 error) *DatabaseMock_SetMmapSize_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetMmapSize_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetMmapSize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetPageSize provides a mock function with given fields: ctx, pageSize
+func (_m *DatabaseMock) SetPageSize(ctx context.Context, pageSize int) error {
+	ret := _m.Called(ctx, pageSize)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPageSize")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, pageSize)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetPageSize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetPageSize'
+type DatabaseMock_SetPageSize_Call struct {
+	*mock.Call
+}
+
+// SetPageSize is a helper method to define mock.On call
+//   - ctx context.Context
+//   - pageSize int
+func (_e *DatabaseMock_Expecter) SetPageSize(ctx interface{}, pageSize interface{}) *DatabaseMock_SetPageSize_Call {
+	return &DatabaseMock_SetPageSize_Call{Call: _e.mock.On("SetPageSize", ctx, pageSize)}
+}
+
+func (_c *DatabaseMock_SetPageSize_Call) Run(run func(ctx context.Context, pageSize int)) *DatabaseMock_SetPageSize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetPageSize_Call) Return(_a0 error) *DatabaseMock_SetPageSize_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetPageSize_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetPageSize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetPragma provides a mock function with given fields: ctx, name, value
+func (_m *DatabaseMock) SetPragma(ctx context.Context, name string, value string) error {
+	ret := _m.Called(ctx, name, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPragma")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, name, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetPragma_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetPragma'
+type DatabaseMock_SetPragma_Call struct {
+	*mock.Call
+}
+
+// SetPragma is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+//   - value string
+func (_e *DatabaseMock_Expecter) SetPragma(ctx interface{}, name interface{}, value interface{}) *DatabaseMock_SetPragma_Call {
+	return &DatabaseMock_SetPragma_Call{Call: _e.mock.On("SetPragma", ctx, name, value)}
+}
+
+func (_c *DatabaseMock_SetPragma_Call) Run(run func(ctx context.Context, name string, value string)) *DatabaseMock_SetPragma_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetPragma_Call) Return(_a0 error) *DatabaseMock_SetPragma_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetPragma_Call) RunAndReturn(run func(context.Context, string, string) error) *DatabaseMock_SetPragma_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetSynchronous provides a mock function with given fields: ctx, mode
+func (_m *DatabaseMock) SetSynchronous(ctx context.Context, mode database.SynchronousMode) error {
+	ret := _m.Called(ctx, mode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetSynchronous")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, database.SynchronousMode) error); ok {
+		r0 = rf(ctx, mode)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetSynchronous_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetSynchronous'
+type DatabaseMock_SetSynchronous_Call struct {
+	*mock.Call
+}
+
+// SetSynchronous is a helper method to define mock.On call
+//   - ctx context.Context
+//   - mode database.SynchronousMode
+func (_e *DatabaseMock_Expecter) SetSynchronous(ctx interface{}, mode interface{}) *DatabaseMock_SetSynchronous_Call {
+	return &DatabaseMock_SetSynchronous_Call{Call: _e.mock.On("SetSynchronous", ctx, mode)}
+}
+
+func (_c *DatabaseMock_SetSynchronous_Call) Run(run func(ctx context.Context, mode database.SynchronousMode)) *DatabaseMock_SetSynchronous_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(database.SynchronousMode))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetSynchronous_Call) Return(_a0 error) *DatabaseMock_SetSynchronous_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetSynchronous_Call) RunAndReturn(run func(context.Context, database.SynchronousMode) error) *DatabaseMock_SetSynchronous_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetUserVersion provides a mock function with given fields: ctx, version
+func (_m *DatabaseMock) SetUserVersion(ctx context.Context, version int) error {
+	ret := _m.Called(ctx, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserVersion")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_SetUserVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUserVersion'
+type DatabaseMock_SetUserVersion_Call struct {
+	*mock.Call
+}
+
+// SetUserVersion is a helper method to define mock.On call
+//   - ctx context.Context
+//   - version int
+func (_e *DatabaseMock_Expecter) SetUserVersion(ctx interface{}, version interface{}) *DatabaseMock_SetUserVersion_Call {
+	return &DatabaseMock_SetUserVersion_Call{Call: _e.mock.On("SetUserVersion", ctx, version)}
+}
+
+func (_c *DatabaseMock_SetUserVersion_Call) Run(run func(ctx context.Context, version int)) *DatabaseMock_SetUserVersion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_SetUserVersion_Call) Return(_a0 error) *DatabaseMock_SetUserVersion_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_SetUserVersion_Call) RunAndReturn(run func(context.Context, int) error) *DatabaseMock_SetUserVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Size provides a mock function with given fields: ctx
+func (_m *DatabaseMock) Size(ctx context.Context) (database.DatabaseSize, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Size")
+	}
+
+	var r0 database.DatabaseSize
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (database.DatabaseSize, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) database.DatabaseSize); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(database.DatabaseSize)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DatabaseMock_Size_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Size'
+type DatabaseMock_Size_Call struct {
+	*mock.Call
+}
+
+// Size is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *DatabaseMock_Expecter) Size(ctx interface{}) *DatabaseMock_Size_Call {
+	return &DatabaseMock_Size_Call{Call: _e.mock.On("Size", ctx)}
+}
+
+func (_c *DatabaseMock_Size_Call) Run(run func(ctx context.Context)) *DatabaseMock_Size_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_Size_Call) Return(_a0 database.DatabaseSize, _a1 error) *DatabaseMock_Size_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DatabaseMock_Size_Call) RunAndReturn(run func(context.Context) (database.DatabaseSize, error)) *DatabaseMock_Size_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Vacuum provides a mock function with given fields: ctx
+func (_m *DatabaseMock) Vacuum(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Vacuum")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_Vacuum_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Vacuum'
+type DatabaseMock_Vacuum_Call struct {
+	*mock.Call
+}
+
+// Vacuum is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *DatabaseMock_Expecter) Vacuum(ctx interface{}) *DatabaseMock_Vacuum_Call {
+	return &DatabaseMock_Vacuum_Call{Call: _e.mock.On("Vacuum", ctx)}
+}
+
+func (_c *DatabaseMock_Vacuum_Call) Run(run func(ctx context.Context)) *DatabaseMock_Vacuum_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_Vacuum_Call) Return(_a0 error) *DatabaseMock_Vacuum_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_Vacuum_Call) RunAndReturn(run func(context.Context) error) *DatabaseMock_Vacuum_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WithTx provides a mock function with given fields: ctx, opts, fn
+func (_m *DatabaseMock) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+	ret := _m.Called(ctx, opts, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithTx")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.TxOptions, func(*sql.Tx) error) error); ok {
+		r0 = rf(ctx, opts, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DatabaseMock_WithTx_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WithTx'
+type DatabaseMock_WithTx_Call struct {
+	*mock.Call
+}
+
+// WithTx is a helper method to define mock.On call
+//   - ctx context.Context
+//   - opts *sql.TxOptions
+//   - fn func(*sql.Tx) error
+func (_e *DatabaseMock_Expecter) WithTx(ctx interface{}, opts interface{}, fn interface{}) *DatabaseMock_WithTx_Call {
+	return &DatabaseMock_WithTx_Call{Call: _e.mock.On("WithTx", ctx, opts, fn)}
+}
+
+func (_c *DatabaseMock_WithTx_Call) Run(run func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error)) *DatabaseMock_WithTx_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*sql.TxOptions), args[2].(func(*sql.Tx) error))
+	})
+	return _c
+}
+
+func (_c *DatabaseMock_WithTx_Call) Return(_a0 error) *DatabaseMock_WithTx_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DatabaseMock_WithTx_Call) RunAndReturn(run func(context.Context, *sql.TxOptions, func(*sql.Tx) error) error) *DatabaseMock_WithTx_Call {
 	_c.Call.Return(run)
 	return _c
 }
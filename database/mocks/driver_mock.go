@@ -80,6 +80,65 @@ func (_c *DriverMock_Begin_Call) RunAndReturn(run func() (*sql.Tx, error)) *Driv
 	return _c
 }
 
+// BeginTx provides a mock function with given fields: ctx, opts
+func (_m *DriverMock) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	ret := _m.Called(ctx, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginTx")
+	}
+
+	var r0 *sql.Tx
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.TxOptions) (*sql.Tx, error)); ok {
+		return rf(ctx, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.TxOptions) *sql.Tx); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*sql.Tx)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *sql.TxOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DriverMock_BeginTx_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BeginTx'
+type DriverMock_BeginTx_Call struct {
+	*mock.Call
+}
+
+// BeginTx is a helper method to define mock.On call
+//   - ctx context.Context
+//   - opts *sql.TxOptions
+func (_e *DriverMock_Expecter) BeginTx(ctx interface{}, opts interface{}) *DriverMock_BeginTx_Call {
+	return &DriverMock_BeginTx_Call{Call: _e.mock.On("BeginTx", ctx, opts)}
+}
+
+func (_c *DriverMock_BeginTx_Call) Run(run func(ctx context.Context, opts *sql.TxOptions)) *DriverMock_BeginTx_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*sql.TxOptions))
+	})
+	return _c
+}
+
+func (_c *DriverMock_BeginTx_Call) Return(_a0 *sql.Tx, _a1 error) *DriverMock_BeginTx_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DriverMock_BeginTx_Call) RunAndReturn(run func(context.Context, *sql.TxOptions) (*sql.Tx, error)) *DriverMock_BeginTx_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Close provides a mock function with given fields:
 func (_m *DriverMock) Close() error {
 	ret := _m.Called()
@@ -195,6 +254,52 @@ func (_c *DriverMock_ExecContext_Call) RunAndReturn(run func(context.Context, st
 	return _c
 }
 
+// PingContext provides a mock function with given fields: ctx
+func (_m *DriverMock) PingContext(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PingContext")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DriverMock_PingContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PingContext'
+type DriverMock_PingContext_Call struct {
+	*mock.Call
+}
+
+// PingContext is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *DriverMock_Expecter) PingContext(ctx interface{}) *DriverMock_PingContext_Call {
+	return &DriverMock_PingContext_Call{Call: _e.mock.On("PingContext", ctx)}
+}
+
+func (_c *DriverMock_PingContext_Call) Run(run func(ctx context.Context)) *DriverMock_PingContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *DriverMock_PingContext_Call) Return(_a0 error) *DriverMock_PingContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *DriverMock_PingContext_Call) RunAndReturn(run func(context.Context) error) *DriverMock_PingContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // PrepareContext provides a mock function with given fields: ctx, query
 func (_m *DriverMock) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
 	ret := _m.Called(ctx, query)
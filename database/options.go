@@ -0,0 +1,152 @@
+package database
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/database/drivers"
+)
+
+// Option configures a database created by NewDatabase.
+type Option func(*database)
+
+// WithBusyTimeout sets how long each connection waits for the lock of the database held
+// by another connection or process before failing with SQLITE_BUSY.
+// The timeout is a parameter of the DSN, so it applies to every connection of the pools;
+// it is ignored by the drivers added with drivers.Register.
+//
+// Parameters:
+//   - timeout: the busy timeout, 0 keeps the default of the driver
+//
+// Example:
+//
+//	db, err := database.NewDatabase(ctx, "path/to/database", "db.sqlite",
+//		database.WithBusyTimeout(5*time.Second))
+func WithBusyTimeout(timeout time.Duration) Option {
+	return func(db *database) {
+		db.busyTimeout = timeout
+	}
+}
+
+// WithSynchronous sets how often SQLite waits for the writes to reach the disk, see
+// SetSynchronous. Like the busy timeout, the mode is a parameter of the DSN, so it applies
+// to every connection of the pools; it is ignored by the drivers added with
+// drivers.Register.
+//
+// Parameters:
+//   - mode: the synchronous mode, empty keeps the default of the driver
+//
+// Example:
+//
+//	db, err := database.NewDatabase(ctx, "path/to/database", "db.sqlite",
+//		database.WithSynchronous(database.SynchronousNormal))
+func WithSynchronous(mode SynchronousMode) Option {
+	return func(db *database) {
+		db.synchronous = mode
+	}
+}
+
+// WithMmapSize sets the max number of bytes of the database each connection maps in
+// memory, see SetMmapSize. The size is set when each connection of the pools is opened.
+//
+// Parameters:
+//   - size: the max number of bytes mapped in memory, 0 disables memory-mapped I/O,
+//     the default
+//
+// Example:
+//
+//	db, err := database.NewDatabase(ctx, "path/to/database", "db.sqlite",
+//		database.WithMmapSize(256*1024*1024))
+func WithMmapSize(size int) Option {
+	return func(db *database) {
+		db.mmapSize = size
+	}
+}
+
+// WithDSNParam adds a query parameter to the DSN of the database, which becomes a URI
+// filename, such as "file:/data/db.sqlite?_journal_mode=WAL". The driver reads the
+// parameters when it opens each connection, so unlike a PRAGMA run on the engine, which
+// reaches a single connection of the pool, they apply to every connection of the pools.
+// The parameters are specific to the driver, such as "_journal_mode" for the mattn
+// driver and "_pragma" for the modernc driver, except for the parameters of SQLite
+// itself, such as "cache" and "mode". A parameter added several times is repeated.
+//
+// Parameters:
+//   - key: the name of the parameter
+//   - value: the value of the parameter, escaped when the DSN is created
+//
+// Example:
+//
+//	db, err := database.NewDatabase(ctx, "path/to/database", "db.sqlite",
+//		database.WithDSNParam("_journal_mode", "WAL"),
+//		database.WithDSNParam("mode", "rwc"))
+func WithDSNParam(key, value string) Option {
+	return func(db *database) {
+		if db.dsnParams == nil {
+			db.dsnParams = url.Values{}
+		}
+		db.dsnParams.Add(key, value)
+	}
+}
+
+// WithReadOnly opens the database file with read-only connections, for the processes
+// that inspect a database written by another process, such as analytics jobs. The file
+// must exist, and the writes fail with an error detected by IsReadOnlyError.
+//
+// Example:
+//
+//	db, err := database.NewDatabase(ctx, "path/to/database", "db.sqlite",
+//		database.WithReadOnly())
+func WithReadOnly() Option {
+	return func(db *database) {
+		db.readOnly = true
+	}
+}
+
+// WithImmutable opens the database file read-only, see WithReadOnly, and tells SQLite
+// that the file never changes, so that it is read without locks. It suits the copies
+// of a database, such as backups and snapshots; reading a file that is being written
+// returns wrong results or errors.
+//
+// Example:
+//
+//	db, err := database.NewDatabase(ctx, "/backups", "db.sqlite", database.WithImmutable())
+func WithImmutable() Option {
+	return func(db *database) {
+		db.readOnly = true
+		db.immutable = true
+	}
+}
+
+// QueryHook is called after each query run on the database, see WithQueryHook.
+type QueryHook = drivers.QueryHook
+
+// WithQueryHook calls the hook after each query run on the connections of the database,
+// with the query, its arguments, how long it took and its error, so that the queries of
+// every module sharing the database, such as the cache, can be logged, traced or measured
+// in one place. The queries run in transactions and through prepared statements are
+// included. The hooks are called in the order they were given, on the goroutine of the
+// query, so they must be fast and safe for concurrent use.
+//
+// Parameters:
+//   - hook: the hook
+//
+// Example:
+//
+//	db, err := database.NewDatabase(ctx, "path/to/database", "db.sqlite",
+//		database.WithQueryHook(func(
+//			ctx context.Context,
+//			query string,
+//			args []interface{},
+//			duration time.Duration,
+//			err error,
+//		) {
+//			slog.DebugContext(ctx, "query", "query", query, "duration", duration, "err", err)
+//		}))
+func WithQueryHook(hook QueryHook) Option {
+	return func(db *database) {
+		if hook != nil {
+			db.queryHooks = append(db.queryHooks, hook)
+		}
+	}
+}
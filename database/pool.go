@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/database/drivers"
+	"github.com/lucasvillarinho/litepack/internal/helpers"
+)
+
+// maxOpenConnsSetter is implemented by the engines whose pool size can be limited.
+type maxOpenConnsSetter interface {
+	SetMaxOpenConns(n int)
+}
+
+// maxIdleConnsSetter is implemented by the engines whose idle connections can be limited.
+type maxIdleConnsSetter interface {
+	SetMaxIdleConns(n int)
+}
+
+// connMaxLifetimeSetter is implemented by the engines whose connections can expire.
+type connMaxLifetimeSetter interface {
+	SetConnMaxLifetime(lifetime time.Duration)
+}
+
+// poolSettings are the settings of the pools of connections, applied again when the
+// engines are reopened.
+type poolSettings struct {
+	// maxOpenConns is the max number of connections of the engine, 0 if unlimited
+	maxOpenConns int
+	// maxIdleConns is the max number of idle connections of the pools, nil for the
+	// default of database/sql
+	maxIdleConns *int
+	// connMaxLifetime is how long a connection may be reused, 0 if forever
+	connMaxLifetime time.Duration
+}
+
+// SetMaxOpenConns sets the max number of connections of the engine.
+// SQLite serializes the writes, so a single connection avoids the lock contention of
+// concurrent writers. While the read pool is open the engine keeps a single connection,
+// and the limit applies once it is closed; the size of the read pool is set by
+// OpenReadPool.
+//
+// Parameters:
+//   - ctx: the context
+//   - n: the max number of connections, 0 for unlimited
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.SetMaxOpenConns(ctx, 1)
+//	if err != nil {
+//		return err
+//	}
+func (db *database) SetMaxOpenConns(_ context.Context, n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid max open conns: %d", n)
+	}
+	if _, ok := db.engine.(maxOpenConnsSetter); !ok {
+		return fmt.Errorf("setting max open conns: not supported by the engine")
+	}
+
+	db.pool.maxOpenConns = n
+	db.applyPoolSettings(db.engine, true)
+
+	return nil
+}
+
+// SetMaxIdleConns sets the max number of idle connections kept by the engine and the
+// read pool, 2 by default. Keeping as many idle connections as open connections avoids
+// opening a connection, and reading the schema, on each query.
+// In-memory databases are dropped with their last connection, so they keep at least one.
+//
+// Parameters:
+//   - ctx: the context
+//   - n: the max number of idle connections, 0 closes the connections once used
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.SetMaxIdleConns(ctx, 4)
+//	if err != nil {
+//		return err
+//	}
+func (db *database) SetMaxIdleConns(_ context.Context, n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid max idle conns: %d", n)
+	}
+	if n == 0 && helpers.IsInMemoryDSN(db.dsn) {
+		return fmt.Errorf("invalid max idle conns: %d: an in-memory database needs an idle connection", n)
+	}
+	if _, ok := db.engine.(maxIdleConnsSetter); !ok {
+		return fmt.Errorf("setting max idle conns: not supported by the engine")
+	}
+
+	db.pool.maxIdleConns = &n
+	db.applyPoolSettings(db.engine, true)
+	if db.readEngine != nil {
+		db.applyPoolSettings(db.readEngine, false)
+	}
+
+	return nil
+}
+
+// SetConnMaxLifetime sets how long a connection of the engine and of the read pool may
+// be reused before it is closed and replaced, for example to release the memory of its
+// page cache. In-memory databases are dropped with their last connection, so their
+// connections can't expire.
+//
+// Parameters:
+//   - ctx: the context
+//   - lifetime: how long a connection may be reused, 0 for forever
+//
+// Returns:
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	db := database.NewDatabase(ctx, "path/to/database", "db.sqlite")
+//	defer db.Close(ctx)
+//	err := db.SetConnMaxLifetime(ctx, time.Hour)
+//	if err != nil {
+//		return err
+//	}
+func (db *database) SetConnMaxLifetime(_ context.Context, lifetime time.Duration) error {
+	if lifetime < 0 {
+		return fmt.Errorf("invalid conn max lifetime: %s", lifetime)
+	}
+	if lifetime > 0 && helpers.IsInMemoryDSN(db.dsn) {
+		return fmt.Errorf(
+			"invalid conn max lifetime: %s: the connections of an in-memory database can't expire",
+			lifetime,
+		)
+	}
+	if _, ok := db.engine.(connMaxLifetimeSetter); !ok {
+		return fmt.Errorf("setting conn max lifetime: not supported by the engine")
+	}
+
+	db.pool.connMaxLifetime = lifetime
+	db.applyPoolSettings(db.engine, true)
+	if db.readEngine != nil {
+		db.applyPoolSettings(db.readEngine, false)
+	}
+
+	return nil
+}
+
+// applyPoolSettings applies the settings of the pools to the engine, or to the read pool
+// if writer is false. The writes stay serialized on a single connection while the read
+// pool is open.
+func (db *database) applyPoolSettings(engine drivers.Driver, writer bool) {
+	if pool, ok := engine.(maxOpenConnsSetter); ok && writer {
+		if db.readEngine != nil {
+			pool.SetMaxOpenConns(1)
+		} else {
+			pool.SetMaxOpenConns(db.pool.maxOpenConns)
+		}
+	}
+
+	if pool, ok := engine.(maxIdleConnsSetter); ok && db.pool.maxIdleConns != nil {
+		pool.SetMaxIdleConns(*db.pool.maxIdleConns)
+	}
+
+	if pool, ok := engine.(connMaxLifetimeSetter); ok {
+		pool.SetConnMaxLifetime(db.pool.connMaxLifetime)
+	}
+}
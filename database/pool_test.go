@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_SetMaxOpenConns(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should limit the connections of the engine", func(t *testing.T) {
+		engine, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		db := &database{engine: engine}
+		err = db.SetMaxOpenConns(ctx, 3)
+
+		assert.NoError(t, err, "Expected no error while setting the max open conns")
+		assert.Equal(t, 3, engine.Stats().MaxOpenConnections)
+	})
+
+	t.Run("should keep a single writer while the read pool is open", func(t *testing.T) {
+		engine, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+		readEngine, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer readEngine.Close()
+
+		db := &database{engine: engine, readEngine: readEngine}
+		err = db.SetMaxOpenConns(ctx, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, engine.Stats().MaxOpenConnections, "Expected the writer to keep one connection")
+		assert.Equal(t, 3, db.pool.maxOpenConns, "Expected the limit to be kept for later")
+	})
+
+	t.Run("should return an error for a negative limit", func(t *testing.T) {
+		db := &database{}
+
+		err := db.SetMaxOpenConns(ctx, -1)
+
+		assert.EqualError(t, err, "invalid max open conns: -1")
+	})
+}
+
+func TestDatabase_SetMaxIdleConns(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should apply the limit to the engine and the read pool", func(t *testing.T) {
+		engine, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+		readEngine, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer readEngine.Close()
+
+		db := &database{engine: engine, readEngine: readEngine, dsn: "/data/db.sqlite"}
+		err = db.SetMaxIdleConns(ctx, 4)
+
+		assert.NoError(t, err, "Expected no error while setting the max idle conns")
+		assert.Equal(t, 4, *db.pool.maxIdleConns)
+	})
+
+	t.Run("should keep an idle connection of an in-memory database", func(t *testing.T) {
+		db := &database{dsn: "file:lpack_memdb_1?mode=memory&cache=shared"}
+
+		err := db.SetMaxIdleConns(ctx, 0)
+
+		assert.EqualError(t, err, "invalid max idle conns: 0: an in-memory database needs an idle connection")
+	})
+
+	t.Run("should return an error for a negative limit", func(t *testing.T) {
+		db := &database{}
+
+		err := db.SetMaxIdleConns(ctx, -1)
+
+		assert.EqualError(t, err, "invalid max idle conns: -1")
+	})
+}
+
+func TestDatabase_SetConnMaxLifetime(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should set the lifetime of the connections", func(t *testing.T) {
+		engine, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer engine.Close()
+
+		db := &database{engine: engine, dsn: "/data/db.sqlite"}
+		err = db.SetConnMaxLifetime(ctx, time.Hour)
+
+		assert.NoError(t, err, "Expected no error while setting the conn max lifetime")
+		assert.Equal(t, time.Hour, db.pool.connMaxLifetime)
+	})
+
+	t.Run("should not expire the connections of an in-memory database", func(t *testing.T) {
+		db := &database{dsn: "file:lpack_memdb_1?mode=memory&cache=shared"}
+
+		err := db.SetConnMaxLifetime(ctx, time.Hour)
+
+		assert.EqualError(
+			t,
+			err,
+			"invalid conn max lifetime: 1h0m0s: the connections of an in-memory database can't expire",
+		)
+	})
+
+	t.Run("should return an error for a negative lifetime", func(t *testing.T) {
+		db := &database{}
+
+		err := db.SetConnMaxLifetime(ctx, -time.Second)
+
+		assert.EqualError(t, err, "invalid conn max lifetime: -1s")
+	})
+}
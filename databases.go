@@ -0,0 +1,164 @@
+package litepack
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/lucasvillarinho/litepack/database"
+	"github.com/lucasvillarinho/litepack/database/drivers"
+)
+
+// databaseName restricts the names of the databases opened by a manager, so that a
+// name can't point to a file out of the directory of the manager.
+var databaseName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// DatabaseStats are the metrics of a database tracked by a manager.
+type DatabaseStats struct {
+	// Name is the name the database was registered with
+	Name string `json:"name"`
+	// Size are the pages and the file sizes of the database
+	Size database.DatabaseSize `json:"size"`
+	// Pool are the statistics of the pool of connections of the engine, zero if the
+	// driver doesn't report them
+	Pool sql.DBStats `json:"pool"`
+}
+
+// Sizer is implemented by stores backed by a database, such as the caches.
+// Size reports the pages and the file sizes of the database.
+type Sizer interface {
+	Size(ctx context.Context) (database.DatabaseSize, error)
+}
+
+// poolStater is implemented by the drivers that report the statistics of their pool.
+type poolStater interface {
+	Stats() sql.DBStats
+}
+
+// engineGetter is implemented by the stores that expose the engine of their database.
+type engineGetter interface {
+	GetEngine(ctx context.Context) drivers.Driver
+}
+
+// Open opens the database of the given name in the directory of the manager, in the
+// lpack_<name>.db file, and registers it, so that it is closed by CloseAll. The database
+// is opened once: the next calls with the same name return the same database, and the
+// options given to them are ignored.
+//
+// Parameters:
+//   - ctx: the context
+//   - name: the name of the database, such as "logs" or "queue", made of letters,
+//     digits, underscores and hyphens
+//   - opts: the options of the database, applied after the options of the manager
+//
+// Returns:
+//   - database.Database: the database
+//   - error: an error if the name is invalid or the database can't be opened
+//
+// Example:
+//
+//	manager := litepack.NewManager(
+//		litepack.WithDir("/var/lib/app"),
+//		litepack.WithDatabaseOptions(database.WithBusyTimeout(5*time.Second)),
+//	)
+//	defer manager.CloseAll(ctx)
+//
+//	logs, err := manager.Open(ctx, "logs")
+func (m *Manager) Open(
+	ctx context.Context,
+	name string,
+	opts ...database.Option,
+) (database.Database, error) {
+	if !databaseName.MatchString(name) {
+		return nil, fmt.Errorf("invalid database name: %q", name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if db, ok := m.databases[name]; ok {
+		return db, nil
+	}
+
+	dbOpts := append(append([]database.Option{}, m.dbOpts...), opts...)
+	db, err := database.NewDatabase(ctx, m.dir, "lpack_"+name+".db", dbOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", name, err)
+	}
+	// the file is created by the first connection, so that a bad path fails here
+	if err := db.Ping(ctx); err != nil {
+		_ = db.Close(ctx)
+		return nil, fmt.Errorf("opening %s: %w", name, err)
+	}
+
+	m.databases[name] = db
+	m.stores = append(m.stores, managedStore{name: name, store: db})
+
+	return db, nil
+}
+
+// Database returns the database of the given name opened by Open.
+//
+// Parameters:
+//   - name: the name of the database
+//
+// Returns:
+//   - database.Database: the database
+//   - bool: false if no database of the name is open
+func (m *Manager) Database(name string) (database.Database, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	db, ok := m.databases[name]
+	return db, ok
+}
+
+// Stats returns the metrics of the registered stores backed by a database, such as the
+// databases opened by Open and the caches, in the order of registration.
+// A failure in one store does not prevent the metrics of the others from being read.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - []DatabaseStats: the metrics of the databases
+//   - error: the joined errors of the stores whose metrics can't be read
+//
+// Example:
+//
+//	stats, err := manager.Stats(ctx)
+//	for _, s := range stats {
+//		log.Printf("%s: %d bytes, %d connections", s.Name, s.Size.FileSize, s.Pool.OpenConnections)
+//	}
+func (m *Manager) Stats(ctx context.Context) ([]DatabaseStats, error) {
+	m.mu.Lock()
+	stores := append([]managedStore{}, m.stores...)
+	m.mu.Unlock()
+
+	var errs []error
+	stats := make([]DatabaseStats, 0, len(stores))
+	for _, managed := range stores {
+		sizer, ok := managed.store.(Sizer)
+		if !ok {
+			continue
+		}
+
+		size, err := sizer.Size(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reading stats of %s: %w", managed.name, err))
+			continue
+		}
+
+		s := DatabaseStats{Name: managed.name, Size: size}
+		if getter, ok := managed.store.(engineGetter); ok {
+			if stater, ok := getter.GetEngine(ctx).(poolStater); ok {
+				s.Pool = stater.Stats()
+			}
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+package litepack
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/database"
+)
+
+func TestManager_Open(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should open the databases in the directory with the shared options", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager(
+			WithDir(dir),
+			WithDatabaseOptions(database.WithSynchronous(database.SynchronousFull)),
+		)
+		defer manager.CloseAll(ctx)
+
+		logs, err := manager.Open(ctx, "logs")
+		assert.NoError(t, err, "Expected no error while opening the database")
+		queue, err := manager.Open(ctx, "queue", database.WithSynchronous(database.SynchronousOff))
+		assert.NoError(t, err, "Expected no error while opening the database")
+
+		assert.FileExists(t, filepath.Join(dir, "lpack_logs.db"))
+		assert.FileExists(t, filepath.Join(dir, "lpack_queue.db"))
+		synchronous, err := logs.Pragma(ctx, "synchronous")
+		assert.NoError(t, err)
+		assert.Equal(t, "2", synchronous, "Expected the options of the manager")
+		synchronous, err = queue.Pragma(ctx, "synchronous")
+		assert.NoError(t, err)
+		assert.Equal(t, "0", synchronous, "Expected the options of Open to win")
+		assert.Equal(t, []string{"logs", "queue"}, manager.Stores())
+	})
+
+	t.Run("should return the open database of the name", func(t *testing.T) {
+		manager := NewManager(WithDir(database.InMemory))
+		defer manager.CloseAll(ctx)
+
+		first, err := manager.Open(ctx, "cache")
+		assert.NoError(t, err)
+		second, err := manager.Open(ctx, "cache")
+		assert.NoError(t, err)
+		found, ok := manager.Database("cache")
+
+		assert.Same(t, first, second, "Expected the database to be opened once")
+		assert.True(t, ok)
+		assert.Same(t, first, found)
+		assert.Equal(t, []string{"cache"}, manager.Stores())
+	})
+
+	t.Run("should return an error for an invalid name", func(t *testing.T) {
+		names := []string{"", "../logs", "logs/queue", `logs\queue`, "..", "logs.db", "logs queue"}
+		for _, name := range names {
+			dir := t.TempDir()
+			manager := NewManager(WithDir(dir))
+
+			_, err := manager.Open(ctx, name)
+
+			assert.EqualError(t, err, fmt.Sprintf("invalid database name: %q", name))
+			assert.Empty(t, manager.Stores(), "Expected no database to be opened")
+		}
+	})
+}
+
+func TestManager_Stats(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should report the databases backed stores", func(t *testing.T) {
+		var calls []string
+		manager := NewManager(WithDir(t.TempDir()))
+		defer manager.CloseAll(ctx)
+		manager.Register("store", &fakeStore{name: "store", calls: &calls})
+		db, err := manager.Open(ctx, "logs")
+		assert.NoError(t, err)
+		assert.NoError(t, db.Exec(ctx, `CREATE TABLE entries (id INTEGER PRIMARY KEY)`))
+
+		stats, err := manager.Stats(ctx)
+
+		assert.NoError(t, err, "Expected no error while reading the stats")
+		assert.Len(t, stats, 1, "Expected only the stores backed by a database")
+		assert.Equal(t, "logs", stats[0].Name)
+		assert.Positive(t, stats[0].Size.FileSize)
+		assert.Positive(t, stats[0].Pool.OpenConnections, "Expected the stats of the pool")
+	})
+}
+
+func TestManager_DestroyAll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should delete the databases and close the other stores", func(t *testing.T) {
+		var calls []string
+		dir := t.TempDir()
+		manager := NewManager(WithDir(dir))
+		manager.Register("store", &fakeStore{name: "store", calls: &calls})
+		_, err := manager.Open(ctx, "logs")
+		assert.NoError(t, err)
+
+		err = manager.DestroyAll(ctx)
+
+		assert.NoError(t, err, "Expected no error while destroying the stores")
+		assert.NoFileExists(t, filepath.Join(dir, "lpack_logs.db"))
+		assert.Equal(t, []string{"store:close"}, calls)
+		assert.Empty(t, manager.Stores(), "Expected no stores after destroying")
+		_, ok := manager.Database("logs")
+		assert.False(t, ok, "Expected the database to be forgotten")
+	})
+}
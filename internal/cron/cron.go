@@ -1,6 +1,9 @@
 package cron
 
 import (
+	"context"
+	"sort"
+	"sync"
 	"time"
 
 	crf "github.com/robfig/cron/v3"
@@ -18,16 +21,46 @@ const (
 	EveryHour      Interval = "@hourly"      // Run every hour
 )
 
+// Validate returns an error if the interval is not a valid cron schedule.
+func (i Interval) Validate() error {
+	_, err := crf.ParseStandard(string(i))
+	return err
+}
+
+// TaskFunc is a task whose failures are tracked by the scheduler.
+type TaskFunc func() error
+
+// TaskStatus describes the state of a scheduled task.
+type TaskStatus struct {
+	LastRun             time.Time // zero if the task never ran
+	NextRun             time.Time // zero if the scheduler is not running
+	Name                string
+	Schedule            string
+	LastError           string
+	ID                  crf.EntryID
+	Runs                int64
+	Failures            int64
+	ConsecutiveFailures int64
+	LastDuration        time.Duration // duration of the last run
+	TotalDuration       time.Duration // duration of all the runs
+}
+
 type Cron interface {
 	Add(schedule string, task func()) (crf.EntryID, error)
 	AddAndExec(schedule string, task func()) (crf.EntryID, error)
+	AddTask(name, schedule string, task TaskFunc) (crf.EntryID, error)
+	AddTaskAndExec(name, schedule string, task TaskFunc) (crf.EntryID, error)
 	Remove(entryID crf.EntryID)
+	Tasks() []TaskStatus
 	Start()
 	Stop()
+	Shutdown(ctx context.Context) error
 }
 
 type cron struct {
-	cron *crf.Cron
+	cron  *crf.Cron
+	tasks map[crf.EntryID]*TaskStatus
+	mu    sync.Mutex
 }
 
 // New creates a new Cron instance with a specified timezone.
@@ -43,7 +76,8 @@ func New(timezone *time.Location) Cron {
 	}
 
 	return &cron{
-		cron: crf.New(crf.WithLocation(timezone)),
+		cron:  crf.New(crf.WithLocation(timezone)),
+		tasks: make(map[crf.EntryID]*TaskStatus),
 	}
 }
 
@@ -79,12 +113,120 @@ func (c *cron) AddAndExec(schedule string, task func()) (crf.EntryID, error) {
 	return entryID, nil
 }
 
+// AddTask schedules a named task to run at the specified interval.
+// The runs and failures of the task are tracked and reported by Tasks.
+//
+// Parameters:
+//   - name: the name of the task
+//   - schedule: the cron schedule string (e.g., "*/5 * * * *")
+//   - task: the function to execute
+//
+// Returns:
+//   - cron.EntryID: the ID of the scheduled task
+//   - error: if the schedule string or task is invalid
+func (c *cron) AddTask(name, schedule string, task TaskFunc) (crf.EntryID, error) {
+	status := &TaskStatus{
+		Name:     name,
+		Schedule: schedule,
+	}
+
+	entryID, err := c.cron.AddFunc(schedule, func() { c.run(status, task) })
+	if err != nil {
+		return entryID, err
+	}
+
+	c.mu.Lock()
+	status.ID = entryID
+	c.tasks[entryID] = status
+	c.mu.Unlock()
+
+	return entryID, nil
+}
+
+// AddTaskAndExec schedules a named task to run at the specified interval
+// and executes it immediately.
+//
+// Parameters:
+//   - name: the name of the task
+//   - schedule: the cron schedule string (e.g., "*/5 * * * *")
+//   - task: the function to execute
+//
+// Returns:
+//   - cron.EntryID: the ID of the scheduled task
+//   - error: if the schedule string or task is invalid
+func (c *cron) AddTaskAndExec(name, schedule string, task TaskFunc) (crf.EntryID, error) {
+	entryID, err := c.AddTask(name, schedule, task)
+	if err != nil {
+		return entryID, err
+	}
+
+	c.mu.Lock()
+	status := c.tasks[entryID]
+	c.mu.Unlock()
+
+	c.run(status, task)
+	return entryID, nil
+}
+
 // Remove cancels a scheduled task by its EntryID.
 //
 // Parameters:
 //   - entryID: the ID of the task to remove
 func (c *cron) Remove(entryID crf.EntryID) {
 	c.cron.Remove(entryID)
+
+	c.mu.Lock()
+	delete(c.tasks, entryID)
+	c.mu.Unlock()
+}
+
+// Tasks returns the status of the tasks registered with AddTask or AddTaskAndExec.
+//
+// Returns:
+//   - []TaskStatus: the status of each task, ordered by EntryID
+func (c *cron) Tasks() []TaskStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tasks := make([]TaskStatus, 0, len(c.tasks))
+	for _, entry := range c.cron.Entries() {
+		status, ok := c.tasks[entry.ID]
+		if !ok {
+			continue
+		}
+
+		task := *status
+		task.NextRun = entry.Next
+		tasks = append(tasks, task)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+
+	return tasks
+}
+
+// run executes the task and records its outcome.
+func (c *cron) run(status *TaskStatus, task TaskFunc) {
+	start := time.Now()
+	err := task()
+	elapsed := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status.LastRun = time.Now()
+	status.LastDuration = elapsed
+	status.TotalDuration += elapsed
+	status.Runs++
+	if err != nil {
+		status.Failures++
+		status.ConsecutiveFailures++
+		status.LastError = err.Error()
+		return
+	}
+
+	status.ConsecutiveFailures = 0
+	status.LastError = ""
 }
 
 // Start begins the execution of scheduled tasks.
@@ -96,3 +238,19 @@ func (c *cron) Start() {
 func (c *cron) Stop() {
 	c.cron.Stop()
 }
+
+// Shutdown halts the execution of scheduled tasks and waits for the running tasks to finish.
+//
+// Parameters:
+//   - ctx: the context, cancelling it stops waiting
+//
+// Returns:
+//   - error: the context error if it is done before the running tasks finish
+func (c *cron) Shutdown(ctx context.Context) error {
+	select {
+	case <-c.cron.Stop().Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
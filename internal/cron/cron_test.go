@@ -1 +1,126 @@
 package cron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCron_Tasks(t *testing.T) {
+	t.Run("should track runs and consecutive failures of a task", func(t *testing.T) {
+		c := New(time.UTC)
+
+		id, err := c.AddTaskAndExec("task", string(EveryMinute), func() error {
+			return errors.New("task error")
+		})
+		assert.NoError(t, err, "Expected no error while adding the task")
+
+		tasks := c.Tasks()
+		assert.Len(t, tasks, 1, "Expected one registered task")
+		assert.Equal(t, id, tasks[0].ID)
+		assert.Equal(t, "task", tasks[0].Name)
+		assert.Equal(t, string(EveryMinute), tasks[0].Schedule)
+		assert.Equal(t, int64(1), tasks[0].Runs)
+		assert.Equal(t, int64(1), tasks[0].ConsecutiveFailures)
+		assert.Equal(t, "task error", tasks[0].LastError)
+		assert.False(t, tasks[0].LastRun.IsZero(), "Expected last run to be set")
+
+		c.(*cron).run(c.(*cron).tasks[id], func() error {
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+
+		tasks = c.Tasks()
+		assert.Equal(t, int64(2), tasks[0].Runs)
+		assert.Equal(t, int64(1), tasks[0].Failures)
+		assert.Equal(t, int64(0), tasks[0].ConsecutiveFailures)
+		assert.Empty(t, tasks[0].LastError)
+		assert.GreaterOrEqual(t, tasks[0].LastDuration, time.Millisecond,
+			"Expected the duration of the last run")
+		assert.GreaterOrEqual(t, tasks[0].TotalDuration, tasks[0].LastDuration,
+			"Expected the duration of all the runs")
+	})
+
+	t.Run("should report the next run while started", func(t *testing.T) {
+		c := New(time.UTC)
+
+		_, err := c.AddTask("task", string(EveryMinute), func() error { return nil })
+		assert.NoError(t, err, "Expected no error while adding the task")
+
+		c.Start()
+		defer c.Stop()
+
+		tasks := c.Tasks()
+		assert.Len(t, tasks, 1, "Expected one registered task")
+		assert.False(t, tasks[0].NextRun.IsZero(), "Expected next run to be set")
+		assert.Zero(t, tasks[0].Runs, "Expected task not to have run yet")
+	})
+
+	t.Run("should not report removed or unnamed tasks", func(t *testing.T) {
+		c := New(time.UTC)
+
+		id, err := c.AddTask("task", string(EveryMinute), func() error { return nil })
+		assert.NoError(t, err, "Expected no error while adding the task")
+		_, err = c.Add(string(EveryMinute), func() {})
+		assert.NoError(t, err, "Expected no error while adding the func")
+
+		c.Remove(id)
+
+		assert.Empty(t, c.Tasks(), "Expected no tasks to be reported")
+	})
+
+	t.Run("should return error for an invalid schedule", func(t *testing.T) {
+		c := New(time.UTC)
+
+		_, err := c.AddTask("task", "invalid", func() error { return nil })
+
+		assert.Error(t, err, "Expected an error for an invalid schedule")
+		assert.Empty(t, c.Tasks(), "Expected no tasks to be reported")
+	})
+}
+
+func TestCron_Shutdown(t *testing.T) {
+	t.Run("should wait for the running tasks", func(t *testing.T) {
+		c := New(time.UTC)
+
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		_, err := c.Add("@every 10ms", func() {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-release
+		})
+		assert.NoError(t, err, "Expected no error while adding the task")
+
+		c.Start()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err = c.Shutdown(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded, "Expected to stop waiting on the deadline")
+
+		close(release)
+		err = c.Shutdown(context.Background())
+		assert.NoError(t, err, "Expected no error once the running task finished")
+	})
+}
+
+func TestInterval_Validate(t *testing.T) {
+	t.Run("should accept the standard schedules and descriptors", func(t *testing.T) {
+		for _, interval := range []Interval{EveryMinute, Every5Minutes, EveryHour, "@every 30s"} {
+			assert.NoError(t, interval.Validate(), "Expected %q to be valid", interval)
+		}
+	})
+
+	t.Run("should reject invalid schedules", func(t *testing.T) {
+		for _, interval := range []Interval{"", "every minute", "* * * * * *"} {
+			assert.Error(t, interval.Validate(), "Expected %q to be invalid", interval)
+		}
+	})
+}
@@ -3,8 +3,10 @@
 package mocks
 
 import (
-	cron "github.com/robfig/cron/v3"
+	context "context"
 
+	internalcron "github.com/lucasvillarinho/litepack/internal/cron"
+	cron "github.com/robfig/cron/v3"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -78,6 +80,179 @@ func (_c *CronMock_Add_Call) RunAndReturn(run func(string, func()) (cron.EntryID
 	return _c
 }
 
+// AddAndExec provides a mock function with given fields: schedule, task
+func (_m *CronMock) AddAndExec(schedule string, task func()) (cron.EntryID, error) {
+	ret := _m.Called(schedule, task)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddAndExec")
+	}
+
+	var r0 cron.EntryID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, func()) (cron.EntryID, error)); ok {
+		return rf(schedule, task)
+	}
+	if rf, ok := ret.Get(0).(func(string, func()) cron.EntryID); ok {
+		r0 = rf(schedule, task)
+	} else {
+		r0 = ret.Get(0).(cron.EntryID)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, func()) error); ok {
+		r1 = rf(schedule, task)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CronMock_AddAndExec_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddAndExec'
+type CronMock_AddAndExec_Call struct {
+	*mock.Call
+}
+
+// AddAndExec is a helper method to define mock.On call
+//   - schedule string
+//   - task func()
+func (_e *CronMock_Expecter) AddAndExec(schedule interface{}, task interface{}) *CronMock_AddAndExec_Call {
+	return &CronMock_AddAndExec_Call{Call: _e.mock.On("AddAndExec", schedule, task)}
+}
+
+func (_c *CronMock_AddAndExec_Call) Run(run func(schedule string, task func())) *CronMock_AddAndExec_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(func()))
+	})
+	return _c
+}
+
+func (_c *CronMock_AddAndExec_Call) Return(_a0 cron.EntryID, _a1 error) *CronMock_AddAndExec_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CronMock_AddAndExec_Call) RunAndReturn(run func(string, func()) (cron.EntryID, error)) *CronMock_AddAndExec_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AddTask provides a mock function with given fields: name, schedule, task
+func (_m *CronMock) AddTask(name string, schedule string, task internalcron.TaskFunc) (cron.EntryID, error) {
+	ret := _m.Called(name, schedule, task)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddTask")
+	}
+
+	var r0 cron.EntryID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, internalcron.TaskFunc) (cron.EntryID, error)); ok {
+		return rf(name, schedule, task)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, internalcron.TaskFunc) cron.EntryID); ok {
+		r0 = rf(name, schedule, task)
+	} else {
+		r0 = ret.Get(0).(cron.EntryID)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, internalcron.TaskFunc) error); ok {
+		r1 = rf(name, schedule, task)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CronMock_AddTask_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddTask'
+type CronMock_AddTask_Call struct {
+	*mock.Call
+}
+
+// AddTask is a helper method to define mock.On call
+//   - name string
+//   - schedule string
+//   - task internalcron.TaskFunc
+func (_e *CronMock_Expecter) AddTask(name interface{}, schedule interface{}, task interface{}) *CronMock_AddTask_Call {
+	return &CronMock_AddTask_Call{Call: _e.mock.On("AddTask", name, schedule, task)}
+}
+
+func (_c *CronMock_AddTask_Call) Run(run func(name string, schedule string, task internalcron.TaskFunc)) *CronMock_AddTask_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(internalcron.TaskFunc))
+	})
+	return _c
+}
+
+func (_c *CronMock_AddTask_Call) Return(_a0 cron.EntryID, _a1 error) *CronMock_AddTask_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CronMock_AddTask_Call) RunAndReturn(run func(string, string, internalcron.TaskFunc) (cron.EntryID, error)) *CronMock_AddTask_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AddTaskAndExec provides a mock function with given fields: name, schedule, task
+func (_m *CronMock) AddTaskAndExec(name string, schedule string, task internalcron.TaskFunc) (cron.EntryID, error) {
+	ret := _m.Called(name, schedule, task)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddTaskAndExec")
+	}
+
+	var r0 cron.EntryID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, internalcron.TaskFunc) (cron.EntryID, error)); ok {
+		return rf(name, schedule, task)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, internalcron.TaskFunc) cron.EntryID); ok {
+		r0 = rf(name, schedule, task)
+	} else {
+		r0 = ret.Get(0).(cron.EntryID)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, internalcron.TaskFunc) error); ok {
+		r1 = rf(name, schedule, task)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CronMock_AddTaskAndExec_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddTaskAndExec'
+type CronMock_AddTaskAndExec_Call struct {
+	*mock.Call
+}
+
+// AddTaskAndExec is a helper method to define mock.On call
+//   - name string
+//   - schedule string
+//   - task internalcron.TaskFunc
+func (_e *CronMock_Expecter) AddTaskAndExec(name interface{}, schedule interface{}, task interface{}) *CronMock_AddTaskAndExec_Call {
+	return &CronMock_AddTaskAndExec_Call{Call: _e.mock.On("AddTaskAndExec", name, schedule, task)}
+}
+
+func (_c *CronMock_AddTaskAndExec_Call) Run(run func(name string, schedule string, task internalcron.TaskFunc)) *CronMock_AddTaskAndExec_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(internalcron.TaskFunc))
+	})
+	return _c
+}
+
+func (_c *CronMock_AddTaskAndExec_Call) Return(_a0 cron.EntryID, _a1 error) *CronMock_AddTaskAndExec_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CronMock_AddTaskAndExec_Call) RunAndReturn(run func(string, string, internalcron.TaskFunc) (cron.EntryID, error)) *CronMock_AddTaskAndExec_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Remove provides a mock function with given fields: entryID
 func (_m *CronMock) Remove(entryID cron.EntryID) {
 	_m.Called(entryID)
@@ -107,6 +282,52 @@ func (_c *CronMock_Remove_Call) Return() *CronMock_Remove_Call {
 }
 
 func (_c *CronMock_Remove_Call) RunAndReturn(run func(cron.EntryID)) *CronMock_Remove_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Shutdown provides a mock function with given fields: ctx
+func (_m *CronMock) Shutdown(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Shutdown")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CronMock_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
+type CronMock_Shutdown_Call struct {
+	*mock.Call
+}
+
+// Shutdown is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *CronMock_Expecter) Shutdown(ctx interface{}) *CronMock_Shutdown_Call {
+	return &CronMock_Shutdown_Call{Call: _e.mock.On("Shutdown", ctx)}
+}
+
+func (_c *CronMock_Shutdown_Call) Run(run func(ctx context.Context)) *CronMock_Shutdown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *CronMock_Shutdown_Call) Return(_a0 error) *CronMock_Shutdown_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CronMock_Shutdown_Call) RunAndReturn(run func(context.Context) error) *CronMock_Shutdown_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -139,7 +360,7 @@ func (_c *CronMock_Start_Call) Return() *CronMock_Start_Call {
 }
 
 func (_c *CronMock_Start_Call) RunAndReturn(run func()) *CronMock_Start_Call {
-	_c.Call.Return(run)
+	_c.Run(run)
 	return _c
 }
 
@@ -171,6 +392,53 @@ func (_c *CronMock_Stop_Call) Return() *CronMock_Stop_Call {
 }
 
 func (_c *CronMock_Stop_Call) RunAndReturn(run func()) *CronMock_Stop_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Tasks provides a mock function with given fields:
+func (_m *CronMock) Tasks() []internalcron.TaskStatus {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Tasks")
+	}
+
+	var r0 []internalcron.TaskStatus
+	if rf, ok := ret.Get(0).(func() []internalcron.TaskStatus); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]internalcron.TaskStatus)
+		}
+	}
+
+	return r0
+}
+
+// CronMock_Tasks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Tasks'
+type CronMock_Tasks_Call struct {
+	*mock.Call
+}
+
+// Tasks is a helper method to define mock.On call
+func (_e *CronMock_Expecter) Tasks() *CronMock_Tasks_Call {
+	return &CronMock_Tasks_Call{Call: _e.mock.On("Tasks")}
+}
+
+func (_c *CronMock_Tasks_Call) Run(run func()) *CronMock_Tasks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *CronMock_Tasks_Call) Return(_a0 []internalcron.TaskStatus) *CronMock_Tasks_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CronMock_Tasks_Call) RunAndReturn(run func() []internalcron.TaskStatus) *CronMock_Tasks_Call {
 	_c.Call.Return(run)
 	return _c
 }
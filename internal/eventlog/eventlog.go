@@ -0,0 +1,154 @@
+package eventlog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/database"
+	"github.com/lucasvillarinho/litepack/database/migrate"
+	"github.com/lucasvillarinho/litepack/internal/eventlog/queries"
+)
+
+// EventLog is an append-only log of the changes of the keys of a cache.
+type EventLog interface {
+	Record(ctx context.Context, eventType, key string, at time.Time) error
+	List(
+		ctx context.Context,
+		sinceID int64,
+		pattern, prefix string,
+		limit int64,
+	) ([]queries.CacheEvent, error)
+	Trim(ctx context.Context, before time.Time) (int64, error)
+}
+
+// migrations are the versioned changes of the schema of the cache_events table.
+var migrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create_cache_events",
+		Up: func(ctx context.Context, tx *sql.Tx) error {
+			q := queries.New(tx)
+			if err := q.CreateEventsTable(ctx); err != nil {
+				return err
+			}
+
+			return q.CreateEventsIndex(ctx)
+		},
+		Down: migrate.SQL(`DROP TABLE IF EXISTS cache_events`),
+	},
+}
+
+type eventLog struct {
+	queries *queries.Queries
+}
+
+// NewEventLog creates a new event log backed by the cache_events table of the database.
+// The table is created by its migrations if it does not exist.
+//
+// Parameters:
+//   - ctx: the context
+//   - db: the database
+//
+// Returns:
+//   - EventLog: the event log instance
+//   - error: an error if the operation failed
+//
+// Example:
+//
+//	events, err := eventlog.NewEventLog(ctx, db)
+//	if err != nil {
+//	  return err
+//	}
+//	err = events.Record(ctx, "set", "key", time.Now())
+func NewEventLog(ctx context.Context, db database.Database) (EventLog, error) {
+	el := &eventLog{
+		queries: queries.New(db.GetEngine(ctx)),
+	}
+
+	err := migrate.NewMigrator(db, "eventlog").Migrate(ctx, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create events table: %w", err)
+	}
+
+	return el, nil
+}
+
+// Record appends an event to the log.
+//
+// Parameters:
+//   - ctx: the context
+//   - eventType: the type of the event
+//   - key: the key changed by the event
+//   - at: the time of the event
+//
+// Returns:
+//   - error: an error if the operation failed
+func (el *eventLog) Record(ctx context.Context, eventType, key string, at time.Time) error {
+	params := queries.InsertEventParams{
+		Type:      eventType,
+		Key:       key,
+		CreatedAt: at,
+	}
+
+	err := el.queries.InsertEvent(ctx, params)
+	if err != nil {
+		return fmt.Errorf("recording event: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the events after sinceID, in the order they were recorded.
+// Only the events whose key matches the GLOB pattern are returned, and the flush
+// events whose key is a prefix of prefix, since they delete the keys of prefix too.
+//
+// Parameters:
+//   - ctx: the context
+//   - sinceID: the ID of the last event already read, 0 to read from the start
+//   - pattern: the GLOB pattern of the keys
+//   - prefix: the prefix of the keys, used to match the flush events
+//   - limit: the max number of events returned
+//
+// Returns:
+//   - []queries.CacheEvent: the events
+//   - error: an error if the operation failed
+func (el *eventLog) List(
+	ctx context.Context,
+	sinceID int64,
+	pattern, prefix string,
+	limit int64,
+) ([]queries.CacheEvent, error) {
+	params := queries.ListEventsParams{
+		ID:      sinceID,
+		Pattern: pattern,
+		Prefix:  prefix,
+		Limit:   limit,
+	}
+
+	events, err := el.queries.ListEvents(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("listing events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Trim deletes the events recorded before the given time.
+//
+// Parameters:
+//   - ctx: the context
+//   - before: the time of the oldest event kept
+//
+// Returns:
+//   - int64: the number of deleted events
+//   - error: an error if the operation failed
+func (el *eventLog) Trim(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := el.queries.DeleteEventsBefore(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("trimming events: %w", err)
+	}
+
+	return deleted, nil
+}
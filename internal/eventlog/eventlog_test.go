@@ -0,0 +1,179 @@
+package eventlog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucasvillarinho/litepack/database/migrate"
+	mdb "github.com/lucasvillarinho/litepack/database/mocks"
+	"github.com/lucasvillarinho/litepack/internal/eventlog/queries"
+)
+
+func TestNewEventLog(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	expectMigrations := func() {
+		sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS lpack_schema_migrations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery("SELECT version FROM lpack_schema_migrations").
+			WithArgs("eventlog").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec("UPDATE lpack_schema_migrations SET version = version WHERE 0").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM lpack_schema_migrations`).
+			WithArgs("eventlog", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+
+	// expectTx runs the transactions of the migrations on the sqlmock connection.
+	expectTx := func(mockDB *mdb.DatabaseMock) {
+		mockDB.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			})
+	}
+
+	t.Run("should create the event log successfully", func(t *testing.T) {
+		expectMigrations()
+		sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS cache_events").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectExec("CREATE INDEX IF NOT EXISTS idx_cache_events_created_at").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectExec("INSERT INTO lpack_schema_migrations").
+			WithArgs("eventlog", 1, "create_cache_events").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+
+		mockDB := mdb.NewDatabaseMock(t)
+		mockDB.EXPECT().
+			GetEngine(ctx).
+			Return(db)
+		mockDB.EXPECT().
+			GetUserVersion(mock.Anything).
+			Return(migrate.Layout, nil)
+		expectTx(mockDB)
+
+		el, err := NewEventLog(ctx, mockDB)
+
+		assert.NoError(t, err, "Expected no error while creating the event log")
+		assert.NotNil(t, el, "Expected a valid event log instance")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all SQL expectations were met")
+	})
+
+	t.Run("should return an error if table creation fails", func(t *testing.T) {
+		expectMigrations()
+		sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS cache_events").
+			WillReturnError(fmt.Errorf("mock create table error"))
+		sqlMock.ExpectRollback()
+
+		mockDB := mdb.NewDatabaseMock(t)
+		mockDB.EXPECT().
+			GetEngine(ctx).
+			Return(db)
+		mockDB.EXPECT().
+			GetUserVersion(mock.Anything).
+			Return(migrate.Layout, nil)
+		expectTx(mockDB)
+
+		el, err := NewEventLog(ctx, mockDB)
+
+		assert.ErrorContains(t, err, "failed to create events table")
+		assert.Nil(t, el, "Expected event log instance to be nil on error")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all SQL expectations were met")
+	})
+}
+
+func TestEventLog(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should record an event", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectExec(`INSERT INTO cache_events \(type, key, created_at\)`).
+			WithArgs("set", "key", at).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		el := &eventLog{queries: queries.New(db)}
+		err = el.Record(context.Background(), "set", "key", at)
+
+		assert.NoError(t, err, "Expected no error while recording the event")
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all SQL expectations were met")
+	})
+
+	t.Run("should list the events after an ID", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectQuery(`SELECT id, type, key, created_at\s+FROM cache_events`).
+			WithArgs(int64(1), "users:*", "users:", int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "key", "created_at"}).
+				AddRow(2, "set", "users:1", at).
+				AddRow(3, "flush", "", at))
+
+		el := &eventLog{queries: queries.New(db)}
+		events, err := el.List(context.Background(), 1, "users:*", "users:", 10)
+
+		assert.NoError(t, err, "Expected no error while listing the events")
+		assert.Equal(t, []queries.CacheEvent{
+			{ID: 2, Type: "set", Key: "users:1", CreatedAt: at},
+			{ID: 3, Type: "flush", Key: "", CreatedAt: at},
+		}, events)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all SQL expectations were met")
+	})
+
+	t.Run("should return an error if listing fails", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectQuery(`SELECT id, type, key, created_at`).
+			WillReturnError(fmt.Errorf("mock query error"))
+
+		el := &eventLog{queries: queries.New(db)}
+		events, err := el.List(context.Background(), 0, "*", "", 10)
+
+		assert.ErrorContains(t, err, "listing events: mock query error")
+		assert.Nil(t, events)
+	})
+
+	t.Run("should delete the events recorded before a time", func(t *testing.T) {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		sqlMock.ExpectExec(`DELETE FROM cache_events WHERE created_at < \?`).
+			WithArgs(at).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		el := &eventLog{queries: queries.New(db)}
+		deleted, err := el.Trim(context.Background(), at)
+
+		assert.NoError(t, err, "Expected no error while trimming the events")
+		assert.Equal(t, int64(3), deleted)
+		assert.NoError(t, sqlMock.ExpectationsWereMet(), "Not all SQL expectations were met")
+	})
+}
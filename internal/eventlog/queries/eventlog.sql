@@ -0,0 +1,24 @@
+-- name: CreateEventsTable :exec
+CREATE TABLE IF NOT EXISTS cache_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT, -- Increasing, never reused
+    type TEXT NOT NULL,
+    key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- name: CreateEventsIndex :exec
+CREATE INDEX IF NOT EXISTS idx_cache_events_created_at ON cache_events (created_at);
+
+-- name: InsertEvent :exec
+INSERT INTO cache_events (type, key, created_at) VALUES (?, ?, ?);
+
+-- name: ListEvents :many
+SELECT id, type, key, created_at
+FROM cache_events
+WHERE id > sqlc.arg(id)
+  AND (key GLOB sqlc.arg(pattern) OR (type = 'flush' AND substr(sqlc.arg(prefix), 1, length(key)) = key))
+ORDER BY id
+LIMIT sqlc.arg(limit);
+
+-- name: DeleteEventsBefore :execrows
+DELETE FROM cache_events WHERE created_at < ?;
@@ -2,25 +2,80 @@ package helpers
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 )
 
+// InMemory is the path or database name that selects an in-memory database.
+const InMemory = ":memory:"
+
+// inMemoryDatabases counts the in-memory databases created by the process,
+// giving each one a unique name.
+var inMemoryDatabases atomic.Int64
+
+// uriPrefix starts the URI filenames of SQLite, such as "file::memory:?cache=shared".
+const uriPrefix = "file:"
+
 // CreateDSN creates a DSN string for an SQLite database.
 //
 // If the path is empty, the current directory is used
 // to create the database file.
+// If the path or the database name is ":memory:", the DSN of a new in-memory database
+// in shared-cache mode is returned, so every connection of the pool sees the same data.
+// If the path or the database name is a URI filename, such as
+// "file:/data/db.sqlite?cache=private" or "file::memory:?cache=shared", it is the DSN,
+// and the other one is ignored. The in-memory URIs get the shared-cache mode if they
+// don't set a cache mode.
+// The query parameters, such as "_journal_mode" or "mode", are read by the driver when
+// it opens each connection; a database file with parameters gets a URI filename.
 //
 // Parameters:
 //   - path: the path to the database file
 //   - db: the database file name
+//   - params: the query parameters of the DSN, nil for none
 //
 // Returns:
 //   - dsn: the DSN string
 //   - error: an error if the operation failed
-func CreateDSN(path, db string) (string, error) {
+func CreateDSN(path, db string, params url.Values) (string, error) {
+	dsn, err := createDSN(path, db)
+	if err != nil {
+		return "", err
+	}
+	if len(params) == 0 {
+		return dsn, nil
+	}
+
+	if !IsURIDSN(dsn) {
+		dsn = uriPrefix + escapeURIPath(dsn)
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	return dsn + separator + params.Encode(), nil
+}
+
+// createDSN returns the DSN of the path and the database name, without parameters.
+func createDSN(path, db string) (string, error) {
 	var dsn string
 
+	if path == InMemory || db == InMemory {
+		id := inMemoryDatabases.Add(1)
+		return fmt.Sprintf("file:lpack_memdb_%d?mode=memory&cache=shared", id), nil
+	}
+
+	if IsURIDSN(path) {
+		return uriDSN(path), nil
+	}
+	if IsURIDSN(db) {
+		return uriDSN(db), nil
+	}
+
 	if path == "" {
 		currentDir, err := os.Getwd()
 		if err != nil {
@@ -37,3 +92,149 @@ func CreateDSN(path, db string) (string, error) {
 
 	return dsn, nil
 }
+
+// uriDSN returns the DSN of a URI filename, with the shared-cache mode if it is in
+// memory and doesn't set a cache mode, since each connection would get its own database.
+func uriDSN(uri string) string {
+	if IsInMemoryDSN(uri) && !hasDSNParam(uri, "cache") {
+		return AppendDSNParam(uri, "cache", "shared")
+	}
+
+	return uri
+}
+
+// IsURIDSN reports whether the DSN is a URI filename, such as "file:db.sqlite?mode=ro".
+//
+// Parameters:
+//   - dsn: the DSN string
+//
+// Returns:
+//   - bool: true if the DSN is a URI filename
+func IsURIDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, uriPrefix)
+}
+
+// IsInMemoryDSN reports whether the DSN points to an in-memory database.
+//
+// Parameters:
+//   - dsn: the DSN string
+//
+// Returns:
+//   - bool: true if the database is in memory
+func IsInMemoryDSN(dsn string) bool {
+	if dsn == InMemory {
+		return true
+	}
+	if !IsURIDSN(dsn) {
+		return false
+	}
+
+	path, _, _ := strings.Cut(strings.TrimPrefix(dsn, uriPrefix), "?")
+	return path == InMemory || strings.Contains(dsn, "mode=memory")
+}
+
+// DSNPath returns the path of the database file of the DSN, without the scheme and the
+// parameters of a URI filename.
+//
+// Parameters:
+//   - dsn: the DSN of a database file
+//
+// Returns:
+//   - string: the path of the database file
+func DSNPath(dsn string) string {
+	if !IsURIDSN(dsn) {
+		return dsn
+	}
+
+	path, _, _ := strings.Cut(strings.TrimPrefix(dsn, uriPrefix), "?")
+	path, _, _ = strings.Cut(path, "#")
+	// "file://localhost/data/db.sqlite" and "file:///data/db.sqlite" name the same file
+	if rest, ok := strings.CutPrefix(path, "//"); ok {
+		if i := strings.Index(rest, "/"); i >= 0 {
+			path = rest[i:]
+		}
+	}
+
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		return unescaped
+	}
+
+	return path
+}
+
+// ReadOnlyDSN returns the DSN of read-only connections to the database file of the DSN.
+// The mode of a URI filename is replaced, its other parameters are kept.
+//
+// Parameters:
+//   - dsn: the DSN of a database file
+//
+// Returns:
+//   - string: the read-only DSN
+func ReadOnlyDSN(dsn string) string {
+	if IsURIDSN(dsn) {
+		return AppendDSNParam(removeDSNParam(dsn, "mode"), "mode", "ro")
+	}
+
+	return uriPrefix + escapeURIPath(dsn) + "?mode=ro"
+}
+
+// escapeURIPath escapes the characters of a file path that start the query or the
+// fragment of a URI filename.
+func escapeURIPath(path string) string {
+	return strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+}
+
+// AppendDSNParam appends a query parameter to the DSN, read by the driver when it opens
+// each connection.
+//
+// Parameters:
+//   - dsn: the DSN string
+//   - key: the name of the parameter
+//   - value: the value of the parameter, escaped by the function
+//
+// Returns:
+//   - string: the DSN with the parameter
+func AppendDSNParam(dsn, key, value string) string {
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	return dsn + separator + key + "=" + url.QueryEscape(value)
+}
+
+// hasDSNParam reports whether the query of the DSN has the parameter.
+func hasDSNParam(dsn, key string) bool {
+	_, query, found := strings.Cut(dsn, "?")
+	if !found {
+		return false
+	}
+
+	for _, param := range strings.Split(query, "&") {
+		if name, _, _ := strings.Cut(param, "="); name == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeDSNParam removes a parameter from the query of the DSN.
+func removeDSNParam(dsn, key string) string {
+	base, query, found := strings.Cut(dsn, "?")
+	if !found {
+		return dsn
+	}
+
+	params := make([]string, 0)
+	for _, param := range strings.Split(query, "&") {
+		if name, _, _ := strings.Cut(param, "="); name != key {
+			params = append(params, param)
+		}
+	}
+	if len(params) == 0 {
+		return base
+	}
+
+	return base + "?" + strings.Join(params, "&")
+}
@@ -2,9 +2,11 @@ package log
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/lucasvillarinho/litepack/database"
+	"github.com/lucasvillarinho/litepack/database/migrate"
 	"github.com/lucasvillarinho/litepack/internal/log/queries"
 )
 
@@ -14,6 +16,18 @@ const (
 	LevelError Level = "ERROR"
 )
 
+// migrations are the versioned changes of the schema of the log table.
+var migrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create_log",
+		Up: func(ctx context.Context, tx *sql.Tx) error {
+			return queries.New(tx).CreateLogTable(ctx)
+		},
+		Down: migrate.SQL(`DROP TABLE IF EXISTS log`),
+	},
+}
+
 type Logger interface {
 	Error(ctx context.Context, msg string)
 }
@@ -54,7 +68,7 @@ func NewLogger(ctx context.Context, db database.Database) (Logger, error) {
 
 	lg.queries = queries.New(db.GetEngine(ctx))
 
-	err := lg.queries.CreateLogTable(ctx)
+	err := migrate.NewMigrator(db, "log").Migrate(ctx, migrations)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create log table: %w", err)
 	}
@@ -2,13 +2,16 @@ package log
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
+	"github.com/lucasvillarinho/litepack/database/migrate"
 	mdb "github.com/lucasvillarinho/litepack/database/mocks"
 	"github.com/lucasvillarinho/litepack/internal/log/queries"
 )
@@ -41,14 +44,54 @@ func TestNewLogger(t *testing.T) {
 
 	ctx := context.Background()
 
+	expectMigrations := func() {
+		sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS lpack_schema_migrations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery("SELECT version FROM lpack_schema_migrations").
+			WithArgs("log").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec("UPDATE lpack_schema_migrations SET version = version WHERE 0").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(`SELECT COUNT\(\*\) FROM lpack_schema_migrations`).
+			WithArgs("log", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+
+	// expectTx runs the transactions of the migrations on the sqlmock connection.
+	expectTx := func(mockDB *mdb.DatabaseMock) {
+		mockDB.EXPECT().
+			WithTx(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(ctx context.Context, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+				tx, err := db.Begin()
+				assert.NoError(t, err, "Expected no error while beginning transaction")
+
+				if err := fn(tx); err != nil {
+					assert.NoError(t, tx.Rollback(), "Expected no error while rolling back")
+					return err
+				}
+
+				return tx.Commit()
+			})
+	}
+
 	t.Run("should create the logger successfully", func(t *testing.T) {
+		expectMigrations()
 		sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS log").
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectExec("INSERT INTO lpack_schema_migrations").
+			WithArgs("log", 1, "create_log").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
 
 		mockDB := mdb.NewDatabaseMock(t)
 		mockDB.EXPECT().
 			GetEngine(context.Background()).
 			Return(db)
+		mockDB.EXPECT().
+			GetUserVersion(mock.Anything).
+			Return(migrate.Layout, nil)
+		expectTx(mockDB)
 
 		lg, err := NewLogger(ctx, mockDB)
 
@@ -58,13 +101,19 @@ func TestNewLogger(t *testing.T) {
 	})
 
 	t.Run("should return an error if table creation fails", func(t *testing.T) {
+		expectMigrations()
 		sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS log").
 			WillReturnError(fmt.Errorf("mock create table error"))
+		sqlMock.ExpectRollback()
 
 		mockDB := mdb.NewDatabaseMock(t)
 		mockDB.EXPECT().
 			GetEngine(context.Background()).
 			Return(db)
+		mockDB.EXPECT().
+			GetUserVersion(mock.Anything).
+			Return(migrate.Layout, nil)
+		expectTx(mockDB)
 
 		ctx := context.Background()
 		lg, err := NewLogger(ctx, mockDB)
@@ -0,0 +1,310 @@
+package litepack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/lucasvillarinho/litepack/database"
+)
+
+// Store is a litepack store whose lifecycle can be managed by a Manager.
+type Store interface {
+	Close(ctx context.Context) error
+}
+
+// BufferFlusher is implemented by stores that buffer writes in memory.
+// FlushBuffers persists the pending writes.
+type BufferFlusher interface {
+	FlushBuffers(ctx context.Context) error
+}
+
+// JobStopper is implemented by stores that run background jobs.
+// StopJobs stops the jobs so they do not run while the store is closing.
+type JobStopper interface {
+	StopJobs(ctx context.Context) error
+}
+
+// Checkpointer is implemented by stores backed by a WAL database.
+// Checkpoint copies the content of the WAL file into the database file.
+type Checkpointer interface {
+	Checkpoint(ctx context.Context) error
+}
+
+// Destroyer is implemented by stores that can delete their data.
+// Destroy closes the store and deletes its database.
+type Destroyer interface {
+	Destroy(ctx context.Context) error
+}
+
+// Manager tracks the stores opened in the process and closes them gracefully.
+// It also opens the named databases of an application, such as "cache", "logs" or
+// "queue", in a shared directory and with shared options, see Open.
+type Manager struct {
+	stores []managedStore
+	mu     sync.Mutex
+
+	// dir is the directory of the databases opened by the manager
+	dir string
+	// dbOpts are the options of every database opened by the manager
+	dbOpts []database.Option
+	// databases are the databases opened by the manager, by name
+	databases map[string]database.Database
+}
+
+// ManagerOption configures a manager created by NewManager.
+type ManagerOption func(*Manager)
+
+// WithDir sets the directory of the databases opened by the manager, the current
+// directory by default. A directory of database.InMemory opens the databases in memory.
+func WithDir(dir string) ManagerOption {
+	return func(m *Manager) {
+		m.dir = dir
+	}
+}
+
+// WithDatabaseOptions sets the options of every database opened by the manager, such as
+// the busy timeout or the synchronous mode. The options given to Open are applied after
+// them.
+func WithDatabaseOptions(opts ...database.Option) ManagerOption {
+	return func(m *Manager) {
+		m.dbOpts = append(m.dbOpts, opts...)
+	}
+}
+
+// managedStore is a store registered in the manager.
+type managedStore struct {
+	store Store
+	name  string
+}
+
+// defaultManager is the process-wide manager, see Default.
+var defaultManager = NewManager()
+
+// NewManager creates a new manager without stores.
+//
+// Parameters:
+//   - opts: the manager options
+//
+// Returns:
+//   - *Manager: the manager instance
+//
+// Example:
+//
+//	manager := litepack.NewManager()
+//	manager.Register("cache", cache)
+//	defer manager.CloseAll(ctx)
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{databases: make(map[string]database.Database)}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Default returns the process-wide manager.
+// The litepack stores are not registered in it implicitly: the application registers
+// them, for example with the WithManager option of the cache.
+//
+// Returns:
+//   - *Manager: the process-wide manager
+//
+// Example:
+//
+//	cache, err := cache.NewCache(ctx, cache.WithManager(litepack.Default()))
+//	defer litepack.Default().CloseAll(ctx)
+func Default() *Manager {
+	return defaultManager
+}
+
+// Register adds a store to the manager.
+// Stores are closed in the reverse order of registration.
+//
+// Parameters:
+//   - name: the name of the store, used in errors
+//   - store: the store
+func (m *Manager) Register(name string, store Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stores = append(m.stores, managedStore{name: name, store: store})
+}
+
+// Unregister removes a store from the manager.
+// If the store is not registered, the operation is a no-op.
+//
+// Parameters:
+//   - store: the store
+func (m *Manager) Unregister(store Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, managed := range m.stores {
+		if managed.store == store {
+			m.stores = append(m.stores[:i], m.stores[i+1:]...)
+			if m.databases[managed.name] == store {
+				delete(m.databases, managed.name)
+			}
+			return
+		}
+	}
+}
+
+// Stores returns the names of the registered stores in the order of registration.
+//
+// Returns:
+//   - []string: the names of the stores
+func (m *Manager) Stores() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.stores))
+	for _, managed := range m.stores {
+		names = append(names, managed.name)
+	}
+
+	return names
+}
+
+// CloseAll closes every registered store in the reverse order of registration.
+// Each store is shut down gracefully: buffered writes are flushed, background jobs
+// are stopped, the WAL is checkpointed and then the store is closed.
+// A failure in one store does not prevent the others from being closed.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: the joined errors of the stores that failed to shut down
+//
+// Example:
+//
+//	manager := litepack.Default()
+//	defer manager.CloseAll(ctx)
+func (m *Manager) CloseAll(ctx context.Context) error {
+	stores := m.takeStores()
+
+	var errs []error
+	for i := len(stores) - 1; i >= 0; i-- {
+		err := shutdown(ctx, stores[i].store)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("closing %s: %w", stores[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// DestroyAll closes every registered store in the reverse order of registration and
+// deletes the databases of the stores that implement Destroyer, such as the databases
+// opened by Open and the caches. The other stores are closed as by CloseAll.
+// A failure in one store does not prevent the others from being destroyed.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: the joined errors of the stores that failed to shut down
+//
+// ⚠️ WARNING: This operation is irreversible and will delete all data stored in the
+// databases of the stores.
+func (m *Manager) DestroyAll(ctx context.Context) error {
+	stores := m.takeStores()
+
+	var errs []error
+	for i := len(stores) - 1; i >= 0; i-- {
+		var err error
+		if destroyer, ok := stores[i].store.(Destroyer); ok {
+			err = destroyer.Destroy(ctx)
+		} else {
+			err = shutdown(ctx, stores[i].store)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("destroying %s: %w", stores[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// takeStores removes every store from the manager and returns them, so that they are
+// shut down without holding the lock the stores take to unregister themselves.
+func (m *Manager) takeStores() []managedStore {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stores := m.stores
+	m.stores = nil
+	clear(m.databases)
+
+	return stores
+}
+
+// CloseOnSignal closes every registered store when one of the signals is received
+// or the context is done. If no signals are given, SIGINT and SIGTERM are used.
+//
+// Parameters:
+//   - ctx: the context
+//   - signals: the signals that trigger the shutdown
+//
+// Returns:
+//   - <-chan error: receives the result of CloseAll once the shutdown completes
+//
+// Example:
+//
+//	done := litepack.Default().CloseOnSignal(ctx)
+//	// serve traffic...
+//	if err := <-done; err != nil {
+//		log.Printf("shutdown: %v", err)
+//	}
+func (m *Manager) CloseOnSignal(ctx context.Context, signals ...os.Signal) <-chan error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	done := make(chan error, 1)
+	signalCtx, stop := signal.NotifyContext(ctx, signals...)
+
+	go func() {
+		defer stop()
+
+		<-signalCtx.Done()
+		done <- m.CloseAll(context.WithoutCancel(ctx))
+	}()
+
+	return done
+}
+
+// shutdown flushes, stops, checkpoints and closes a store.
+func shutdown(ctx context.Context, store Store) error {
+	var errs []error
+
+	if flusher, ok := store.(BufferFlusher); ok {
+		if err := flusher.FlushBuffers(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("flushing buffers: %w", err))
+		}
+	}
+
+	if stopper, ok := store.(JobStopper); ok {
+		if err := stopper.StopJobs(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stopping jobs: %w", err))
+		}
+	}
+
+	if checkpointer, ok := store.(Checkpointer); ok {
+		if err := checkpointer.Checkpoint(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("checkpointing: %w", err))
+		}
+	}
+
+	if err := store.Close(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("closing: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,134 @@
+package litepack
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	closeErr error
+	calls    *[]string
+	name     string
+}
+
+func (s *fakeStore) Close(_ context.Context) error {
+	*s.calls = append(*s.calls, s.name+":close")
+	return s.closeErr
+}
+
+type fakeWALStore struct {
+	fakeStore
+}
+
+func (s *fakeWALStore) FlushBuffers(_ context.Context) error {
+	*s.calls = append(*s.calls, s.name+":flush")
+	return nil
+}
+
+func (s *fakeWALStore) StopJobs(_ context.Context) error {
+	*s.calls = append(*s.calls, s.name+":stop")
+	return nil
+}
+
+func (s *fakeWALStore) Checkpoint(_ context.Context) error {
+	*s.calls = append(*s.calls, s.name+":checkpoint")
+	return nil
+}
+
+func TestManager_CloseAll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should shut down stores in reverse order of registration", func(t *testing.T) {
+		var calls []string
+		manager := NewManager()
+		manager.Register("first", &fakeStore{name: "first", calls: &calls})
+		manager.Register("second", &fakeWALStore{fakeStore{name: "second", calls: &calls}})
+
+		err := manager.CloseAll(ctx)
+
+		assert.NoError(t, err, "Expected no error while closing stores")
+		assert.Equal(t, []string{
+			"second:flush",
+			"second:stop",
+			"second:checkpoint",
+			"second:close",
+			"first:close",
+		}, calls)
+		assert.Empty(t, manager.Stores(), "Expected no stores after closing")
+	})
+
+	t.Run("should close every store and join the errors", func(t *testing.T) {
+		var calls []string
+		manager := NewManager()
+		manager.Register("first", &fakeStore{name: "first", calls: &calls})
+		manager.Register("second", &fakeStore{
+			name:     "second",
+			calls:    &calls,
+			closeErr: errors.New("database is locked"),
+		})
+
+		err := manager.CloseAll(ctx)
+
+		assert.Error(t, err, "Expected an error while closing stores")
+		assert.Equal(t, "closing second: closing: database is locked", err.Error())
+		assert.Equal(t, []string{"second:close", "first:close"}, calls)
+	})
+}
+
+func TestManager_Unregister(t *testing.T) {
+	t.Run("should remove only the given store", func(t *testing.T) {
+		var calls []string
+		first := &fakeStore{name: "first", calls: &calls}
+		second := &fakeStore{name: "second", calls: &calls}
+		manager := NewManager()
+		manager.Register("first", first)
+		manager.Register("second", second)
+
+		manager.Unregister(first)
+		manager.Unregister(first)
+
+		assert.Equal(t, []string{"second"}, manager.Stores())
+	})
+}
+
+func TestManager_CloseOnSignal(t *testing.T) {
+	t.Run("should close the stores when the signal is received", func(t *testing.T) {
+		var calls []string
+		manager := NewManager()
+		manager.Register("store", &fakeStore{name: "store", calls: &calls})
+
+		done := manager.CloseOnSignal(context.Background(), syscall.SIGUSR1)
+		assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err, "Expected no error while closing stores")
+			assert.Equal(t, []string{"store:close"}, calls)
+		case <-time.After(time.Second):
+			t.Fatal("Expected the stores to be closed after the signal")
+		}
+	})
+
+	t.Run("should close the stores when the context is done", func(t *testing.T) {
+		var calls []string
+		manager := NewManager()
+		manager.Register("store", &fakeStore{name: "store", calls: &calls})
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := manager.CloseOnSignal(ctx)
+		cancel()
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err, "Expected no error while closing stores")
+			assert.Equal(t, []string{"store:close"}, calls)
+		case <-time.After(time.Second):
+			t.Fatal("Expected the stores to be closed after the context is done")
+		}
+	})
+}
@@ -0,0 +1,176 @@
+// Package prometheus exports the metrics of a litepack cache to Prometheus.
+// It is a module of its own, so that Prometheus is required only by the applications using it.
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// Option is a function that configures a collector.
+type Option func(*options)
+
+// options holds the configuration of a collector.
+type options struct {
+	constLabels prometheus.Labels
+	namespace   string
+	timeout     time.Duration
+}
+
+// WithNamespace sets the namespace of the metric names, "litepack" by default.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels to every metric, for example to tell apart the caches of
+// a process registered with the same registry.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(o *options) {
+		o.constLabels = labels
+	}
+}
+
+// WithTimeout bounds the duration of the queries run on each scrape, 5 seconds by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// Collector is a prometheus.Collector that reports the metrics of a cache: the hits,
+// misses, sets, deletes and evictions, the entries and the size of the database, the
+// purges and the runs of the background tasks, such as the purges, with their durations.
+type Collector struct {
+	cache   cache.Cache
+	timeout time.Duration
+
+	hits          *prometheus.Desc
+	misses        *prometheus.Desc
+	sets          *prometheus.Desc
+	deletes       *prometheus.Desc
+	evictions     *prometheus.Desc
+	entries       *prometheus.Desc
+	valueBytes    *prometheus.Desc
+	dbSize        *prometheus.Desc
+	maxDBSize     *prometheus.Desc
+	freePages     *prometheus.Desc
+	purges        *prometheus.Desc
+	taskDurations *prometheus.Desc
+	taskFailures  *prometheus.Desc
+}
+
+// NewCollector creates a collector of the metrics of the given cache.
+//
+// Parameters:
+//   - c: the cache
+//   - opts: the collector options
+//
+// Returns:
+//   - *Collector: the collector
+//
+// Example:
+//
+//	prometheus.MustRegister(lpprometheus.NewCollector(lpCache))
+func NewCollector(c cache.Cache, opts ...Option) *Collector {
+	o := &options{namespace: "litepack", timeout: 5 * time.Second}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(o.namespace, "cache", name),
+			help,
+			labels,
+			o.constLabels,
+		)
+	}
+
+	return &Collector{
+		cache:      c,
+		timeout:    o.timeout,
+		hits:       desc("hits_total", "Reads that found a live entry."),
+		misses:     desc("misses_total", "Reads of missing or expired entries."),
+		sets:       desc("sets_total", "Entries written."),
+		deletes:    desc("deletes_total", "Keys deleted."),
+		evictions:  desc("evictions_total", "Entries deleted to free space."),
+		entries:    desc("entries", "Live entries."),
+		valueBytes: desc("value_bytes", "Bytes used by the values of the live entries."),
+		dbSize:     desc("db_size_bytes", "Size of the database file."),
+		maxDBSize:  desc("max_db_size_bytes", "Size of the database file that triggers purges."),
+		freePages:  desc("free_pages", "Pages of the database file that can be reused."),
+		purges:     desc("purges_total", "Purges executed, by reason.", "reason"),
+		taskDurations: desc("task_duration_seconds",
+			"Duration of the runs of the background tasks, such as the purges.", "task"),
+		taskFailures: desc("task_failures_total", "Failed runs of the background tasks.", "task"),
+	}
+}
+
+// Describe sends the descriptors of the metrics of the collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.hits, c.misses, c.sets, c.deletes, c.evictions,
+		c.entries, c.valueBytes, c.dbSize, c.maxDBSize, c.freePages,
+		c.purges, c.taskDurations, c.taskFailures,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect sends the current metrics of the cache. The operation counters are read from
+// memory; the usage of the database is queried, so a failed query is reported as an
+// invalid metric instead of failing the scrape.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	metrics := c.cache.Metrics()
+	counter := func(desc *prometheus.Desc, value int64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(
+			desc,
+			prometheus.CounterValue,
+			float64(value),
+			labels...,
+		)
+	}
+	gauge := func(desc *prometheus.Desc, value int64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value))
+	}
+
+	counter(c.hits, metrics.Hits)
+	counter(c.misses, metrics.Misses)
+	counter(c.sets, metrics.Sets)
+	counter(c.deletes, metrics.Deletes)
+	counter(c.evictions, metrics.Evictions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	stats, err := c.cache.Stats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.entries, err)
+		return
+	}
+
+	gauge(c.entries, stats.Entries)
+	gauge(c.valueBytes, stats.ValueBytes)
+	gauge(c.dbSize, stats.DBSize)
+	gauge(c.maxDBSize, stats.MaxDBSize)
+	gauge(c.freePages, stats.FreePages)
+	counter(c.purges, stats.ExpiredPurges, "expired")
+	counter(c.purges, stats.SizePurges, "size")
+
+	for _, task := range stats.Tasks {
+		ch <- prometheus.MustNewConstSummary(
+			c.taskDurations,
+			uint64(task.Runs),
+			task.TotalDuration.Seconds(),
+			nil,
+			task.Name,
+		)
+		counter(c.taskFailures, task.Failures, task.Name)
+	}
+}
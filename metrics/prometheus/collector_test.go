@@ -0,0 +1,109 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// stubCache is a cache that reports fixed metrics.
+type stubCache struct {
+	cache.Cache
+	stats cache.CacheStats
+	err   error
+}
+
+func (s *stubCache) Metrics() cache.Metrics {
+	return cache.Metrics{Hits: 10, Misses: 2, Sets: 5, Deletes: 1, Evictions: 3}
+}
+
+func (s *stubCache) Stats(context.Context) (cache.CacheStats, error) {
+	return s.stats, s.err
+}
+
+func TestCollector(t *testing.T) {
+	t.Run("should collect the metrics of the cache", func(t *testing.T) {
+		stub := &stubCache{stats: cache.CacheStats{
+			Entries:       4,
+			ValueBytes:    128,
+			DBSize:        4096,
+			MaxDBSize:     8192,
+			ExpiredPurges: 7,
+			SizePurges:    1,
+			Tasks: []cache.TaskStats{
+				{Name: "purge-expired", Runs: 7, Failures: 1, TotalDuration: 3 * time.Second},
+			},
+		}}
+		collector := NewCollector(stub, WithConstLabels(prometheus.Labels{"cache": "users"}))
+
+		expected := `
+# HELP litepack_cache_entries Live entries.
+# TYPE litepack_cache_entries gauge
+litepack_cache_entries{cache="users"} 4
+# HELP litepack_cache_hits_total Reads that found a live entry.
+# TYPE litepack_cache_hits_total counter
+litepack_cache_hits_total{cache="users"} 10
+# HELP litepack_cache_purges_total Purges executed, by reason.
+# TYPE litepack_cache_purges_total counter
+litepack_cache_purges_total{cache="users",reason="expired"} 7
+litepack_cache_purges_total{cache="users",reason="size"} 1
+# HELP litepack_cache_task_duration_seconds Duration of the runs of the background tasks, such as the purges.
+# TYPE litepack_cache_task_duration_seconds summary
+litepack_cache_task_duration_seconds_sum{cache="users",task="purge-expired"} 3
+litepack_cache_task_duration_seconds_count{cache="users",task="purge-expired"} 7
+# HELP litepack_cache_task_failures_total Failed runs of the background tasks.
+# TYPE litepack_cache_task_failures_total counter
+litepack_cache_task_failures_total{cache="users",task="purge-expired"} 1
+`
+		err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"litepack_cache_entries",
+			"litepack_cache_hits_total",
+			"litepack_cache_purges_total",
+			"litepack_cache_task_duration_seconds",
+			"litepack_cache_task_failures_total",
+		)
+		assert.NoError(t, err, "Expected the collected metrics")
+	})
+
+	t.Run("should use the namespace", func(t *testing.T) {
+		collector := NewCollector(&stubCache{}, WithNamespace("app"))
+
+		count, err := testutil.GatherAndCount(registry(t, collector), "app_cache_hits_total")
+
+		assert.NoError(t, err, "Expected no error")
+		assert.Equal(t, 1, count, "Expected the metric in the namespace")
+	})
+
+	t.Run("should report an error when the stats cannot be read", func(t *testing.T) {
+		collector := NewCollector(&stubCache{err: errors.New("database error")})
+
+		_, err := registry(t, collector).Gather()
+
+		assert.ErrorContains(t, err, "database error", "Expected the stats error")
+	})
+
+	t.Run("should pass the lint checks", func(t *testing.T) {
+		problems, err := testutil.CollectAndLint(NewCollector(&stubCache{}))
+
+		assert.NoError(t, err, "Expected no error")
+		assert.Empty(t, problems, "Expected no lint problems")
+	})
+}
+
+// registry returns a registry with the given collector.
+func registry(t *testing.T, collector prometheus.Collector) *prometheus.Registry {
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(collector); err != nil {
+		t.Fatal(err)
+	}
+
+	return reg
+}
@@ -0,0 +1,48 @@
+// Package echoadapter exposes the litepack response cache as an echo middleware.
+// It is a module of its own, so that echo is required only by the applications using it.
+package echoadapter
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/lucasvillarinho/litepack/middleware"
+)
+
+// New returns an echo middleware that serves the cached responses and caches the
+// responses of the next handler. The route used for the TTL overrides is the path of the
+// echo route, such as "/users/:id".
+//
+// Parameters:
+//   - rc: the response cache
+//
+// Returns:
+//   - echo.MiddlewareFunc: the middleware
+//
+// Example:
+//
+//	responses := middleware.New(lpCache, middleware.WithRouteTTL("/users/:id", time.Minute))
+//	e := echo.New()
+//	e.Use(echoadapter.New(responses))
+func New(rc *middleware.ResponseCache) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if resp, ok := rc.Get(c.Request()); ok {
+				resp.Write(c.Response())
+				return nil
+			}
+
+			writer := c.Response().Writer
+			rec := middleware.NewRecorder(writer)
+			c.Response().Writer = rec
+			defer func() { c.Response().Writer = writer }()
+
+			if err := next(c); err != nil {
+				return err
+			}
+
+			rc.Store(c.Request(), c.Path(), rec.Response())
+
+			return nil
+		}
+	}
+}
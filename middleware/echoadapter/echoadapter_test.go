@@ -0,0 +1,50 @@
+package echoadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	lPCache "github.com/lucasvillarinho/litepack/cache"
+	"github.com/lucasvillarinho/litepack/middleware"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+	assert.NoError(t, err, "Expected no error while creating the cache")
+	defer lCache.Close(ctx)
+
+	t.Run("should cache the responses of echo routes", func(t *testing.T) {
+		calls := 0
+		e := echo.New()
+		e.Use(New(middleware.New(lCache,
+			middleware.WithTTL(time.Minute),
+			middleware.WithRouteTTL("/nocache/:id", 0),
+		)))
+		handler := func(c echo.Context) error {
+			calls++
+			return c.JSON(http.StatusOK, map[string]string{"id": c.Param("id")})
+		}
+		e.GET("/users/:id", handler)
+		e.GET("/nocache/:id", handler)
+
+		for range 2 {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			assert.JSONEq(t, `{"id":"1"}`, rec.Body.String(), "Expected the response body")
+			assert.Contains(t, rec.Header().Get("Content-Type"), "application/json",
+				"Expected the content type")
+		}
+		for range 2 {
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nocache/1", nil))
+		}
+
+		assert.Equal(t, 3, calls, "Expected the uncached route to be called every time")
+	})
+}
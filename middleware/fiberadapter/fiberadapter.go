@@ -0,0 +1,64 @@
+// Package fiberadapter exposes the litepack response cache as a fiber middleware.
+// It is a module of its own, so that fiber is required only by the applications using it.
+package fiberadapter
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	"github.com/lucasvillarinho/litepack/middleware"
+)
+
+// New returns a fiber middleware that serves the cached responses and caches the
+// responses of the next handlers. The route used for the TTL overrides is the path of
+// the fiber route, such as "/users/:id".
+//
+// Parameters:
+//   - rc: the response cache
+//
+// Returns:
+//   - fiber.Handler: the middleware
+//
+// Example:
+//
+//	responses := middleware.New(lpCache, middleware.WithRouteTTL("/users/:id", time.Minute))
+//	app := fiber.New()
+//	app.Use(fiberadapter.New(responses))
+func New(rc *middleware.ResponseCache) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		r, err := adaptor.ConvertRequest(c, false)
+		if err != nil {
+			return fmt.Errorf("converting request: %w", err)
+		}
+
+		if resp, ok := rc.Get(r); ok {
+			for name, values := range resp.Header {
+				for _, value := range values {
+					c.Response().Header.Add(name, value)
+				}
+			}
+			return c.Status(resp.Status).Send(resp.Body)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		header := make(http.Header)
+		c.Response().Header.VisitAll(func(name, value []byte) {
+			header.Add(string(name), string(value))
+		})
+		resp := middleware.Response{
+			Status: c.Response().StatusCode(),
+			Header: header,
+			// The body is copied since fiber reuses its buffers.
+			Body: append([]byte(nil), c.Response().Body()...),
+		}
+		rc.Store(r, c.Route().Path, resp)
+
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package fiberadapter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+
+	lPCache "github.com/lucasvillarinho/litepack/cache"
+	"github.com/lucasvillarinho/litepack/middleware"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+	assert.NoError(t, err, "Expected no error while creating the cache")
+	defer lCache.Close(ctx)
+
+	t.Run("should cache the responses of fiber routes", func(t *testing.T) {
+		calls := 0
+		app := fiber.New()
+		app.Use(New(middleware.New(lCache,
+			middleware.WithTTL(time.Minute),
+			middleware.WithRouteTTL("/nocache/:id", 0),
+		)))
+		handler := func(c *fiber.Ctx) error {
+			calls++
+			return c.JSON(fiber.Map{"id": c.Params("id")})
+		}
+		app.Get("/users/:id", handler)
+		app.Get("/nocache/:id", handler)
+
+		for range 2 {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			assert.NoError(t, err, "Expected no error while serving the request")
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, `{"id":"1"}`, string(body), "Expected the response body")
+			assert.Contains(t, resp.Header.Get("Content-Type"), "application/json",
+				"Expected the content type")
+		}
+		for range 2 {
+			_, err := app.Test(httptest.NewRequest(http.MethodGet, "/nocache/1", nil))
+			assert.NoError(t, err, "Expected no error while serving the request")
+		}
+
+		assert.Equal(t, 3, calls, "Expected the uncached route to be called every time")
+	})
+}
@@ -0,0 +1,64 @@
+// Package ginadapter exposes the litepack response cache as a gin middleware.
+// It is a module of its own, so that gin is required only by the applications using it.
+package ginadapter
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucasvillarinho/litepack/middleware"
+)
+
+// recorder is a gin.ResponseWriter that keeps a copy of the response.
+type recorder struct {
+	gin.ResponseWriter
+	rec *middleware.Recorder
+}
+
+// WriteHeader records the status and the headers of the response and writes them.
+func (w *recorder) WriteHeader(status int) {
+	w.rec.WriteHeader(status)
+}
+
+// Write records and writes a part of the body of the response.
+func (w *recorder) Write(b []byte) (int, error) {
+	return w.rec.Write(b)
+}
+
+// WriteString records and writes a part of the body of the response.
+func (w *recorder) WriteString(s string) (int, error) {
+	return w.rec.Write([]byte(s))
+}
+
+// New returns a gin middleware that serves the cached responses and caches the responses
+// of the next handlers. The route used for the TTL overrides is the full path of the
+// gin route, such as "/users/:id".
+//
+// Parameters:
+//   - rc: the response cache
+//
+// Returns:
+//   - gin.HandlerFunc: the middleware
+//
+// Example:
+//
+//	responses := middleware.New(lpCache, middleware.WithRouteTTL("/users/:id", time.Minute))
+//	router := gin.Default()
+//	router.Use(ginadapter.New(responses))
+func New(rc *middleware.ResponseCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if resp, ok := rc.Get(c.Request); ok {
+			resp.Write(c.Writer)
+			c.Abort()
+			return
+		}
+
+		w := &recorder{ResponseWriter: c.Writer}
+		// The recorder writes to the gin writer, so that gin keeps track of the response.
+		w.rec = middleware.NewRecorder(c.Writer)
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		rc.Store(c.Request, c.FullPath(), w.rec.Response())
+	}
+}
@@ -0,0 +1,51 @@
+package ginadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	lPCache "github.com/lucasvillarinho/litepack/cache"
+	"github.com/lucasvillarinho/litepack/middleware"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+	assert.NoError(t, err, "Expected no error while creating the cache")
+	defer lCache.Close(ctx)
+
+	t.Run("should cache the responses of gin routes", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		calls := 0
+		router := gin.New()
+		router.Use(New(middleware.New(lCache,
+			middleware.WithTTL(time.Minute),
+			middleware.WithRouteTTL("/nocache/:id", 0),
+		)))
+		handler := func(c *gin.Context) {
+			calls++
+			c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+		}
+		router.GET("/users/:id", handler)
+		router.GET("/nocache/:id", handler)
+
+		for range 2 {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			assert.Equal(t, `{"id":"1"}`, rec.Body.String(), "Expected the response body")
+			assert.Contains(t, rec.Header().Get("Content-Type"), "application/json",
+				"Expected the content type")
+		}
+		for range 2 {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nocache/1", nil))
+		}
+
+		assert.Equal(t, 3, calls, "Expected the uncached route to be called every time")
+	})
+}
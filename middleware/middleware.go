@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// KeyFunc returns the cache key of the response to a request.
+type KeyFunc func(r *http.Request) string
+
+// Option is a function that configures a response cache.
+type Option func(*ResponseCache)
+
+// Response is a response stored by the response cache.
+type Response struct {
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	Status int         `json:"status"`
+}
+
+// Write writes the response to w.
+func (resp Response) Write(w http.ResponseWriter) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+// ResponseCache caches the responses to GET requests in a litepack cache.
+// Only responses with status 200 are stored; responses that set cookies or whose
+// Cache-Control header contains no-store or private are never stored.
+//
+// The net/http middleware is returned by Handler; the adapters of the router frameworks
+// use Get and Store with the route pattern of the framework.
+type ResponseCache struct {
+	cache     cache.Cache
+	keyFunc   KeyFunc
+	onError   func(r *http.Request, err error)
+	routeTTLs map[string]time.Duration
+	ttl       time.Duration
+}
+
+// New creates a response cache that stores the responses in the given cache.
+// A namespace of the cache can be given to keep the responses apart from other entries.
+//
+// Parameters:
+//   - c: the cache that stores the responses
+//   - opts: the response cache options
+//
+// Returns:
+//   - *ResponseCache: the response cache
+//
+// Configuration defaults:
+//   - ttl: 1 minute
+//   - keyFunc: the request URI, path and query
+//   - onError: errors are ignored, the request is served by the handler
+//
+// Example:
+//
+//	responses := middleware.New(lpCache.Namespace("http"),
+//		middleware.WithTTL(5*time.Minute),
+//		middleware.WithRouteTTL("/users/{id}", time.Minute),
+//	)
+//	http.ListenAndServe(":8080", responses.Handler(mux))
+func New(c cache.Cache, opts ...Option) *ResponseCache {
+	rc := &ResponseCache{
+		cache:     c,
+		keyFunc:   func(r *http.Request) string { return r.URL.RequestURI() },
+		onError:   func(*http.Request, error) {},
+		routeTTLs: make(map[string]time.Duration),
+		ttl:       time.Minute,
+	}
+
+	for _, opt := range opts {
+		opt(rc)
+	}
+
+	return rc
+}
+
+// WithTTL sets the TTL of the cached responses.
+func WithTTL(ttl time.Duration) Option {
+	return func(rc *ResponseCache) {
+		rc.ttl = ttl
+	}
+}
+
+// WithRouteTTL overrides the TTL of the responses of a route. The route is the pattern
+// of the router, such as "/users/{id}" for net/http or "/users/:id" for gin and echo.
+// A TTL of 0 disables the cache for the route.
+func WithRouteTTL(route string, ttl time.Duration) Option {
+	return func(rc *ResponseCache) {
+		rc.routeTTLs[route] = ttl
+	}
+}
+
+// WithKeyFunc sets the function that returns the cache key of a request, for example to
+// vary the cached responses by a header or to ignore some query parameters.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(rc *ResponseCache) {
+		rc.keyFunc = fn
+	}
+}
+
+// WithErrorHandler sets the function called when reading or storing a response fails.
+// The request is served by the handler anyway.
+func WithErrorHandler(fn func(r *http.Request, err error)) Option {
+	return func(rc *ResponseCache) {
+		rc.onError = fn
+	}
+}
+
+// Get returns the cached response to a request, if any.
+//
+// Parameters:
+//   - r: the request
+//
+// Returns:
+//   - Response: the cached response
+//   - bool: true if a cached response was found
+func (rc *ResponseCache) Get(r *http.Request) (Response, bool) {
+	if r.Method != http.MethodGet {
+		return Response{}, false
+	}
+
+	var resp Response
+	err := rc.cache.GetValue(r.Context(), rc.keyFunc(r), &resp)
+	if err != nil {
+		if !errors.Is(err, cache.ErrKeyNotFound) {
+			rc.onError(r, err)
+		}
+		return Response{}, false
+	}
+
+	return resp, true
+}
+
+// Store caches the response to a request served by the given route, if it can be cached.
+//
+// Parameters:
+//   - r: the request
+//   - route: the route pattern that served the request, used for the TTL overrides
+//   - resp: the response
+func (rc *ResponseCache) Store(r *http.Request, route string, resp Response) {
+	ttl, ok := rc.routeTTLs[route]
+	if !ok {
+		ttl = rc.ttl
+	}
+	if r.Method != http.MethodGet || ttl <= 0 || !cacheable(resp) {
+		return
+	}
+
+	// Store the response even if the client went away, it is complete.
+	ctx := context.WithoutCancel(r.Context())
+	if err := rc.cache.SetValue(ctx, rc.keyFunc(r), resp, ttl); err != nil {
+		rc.onError(r, err)
+	}
+}
+
+// Handler returns a net/http middleware that serves the cached responses and caches the
+// responses of next. The route is the pattern of the request set by http.ServeMux,
+// or its path if it has none.
+//
+// Parameters:
+//   - next: the handler
+//
+// Returns:
+//   - http.Handler: the handler that caches the responses of next
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("GET /users/{id}", responses.Handler(usersHandler))
+func (rc *ResponseCache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if resp, ok := rc.Get(r); ok {
+			resp.Write(w)
+			return
+		}
+
+		recorder := NewRecorder(w)
+		next.ServeHTTP(recorder, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = r.URL.Path
+		}
+		rc.Store(r, route, recorder.Response())
+	})
+}
+
+// cacheable reports whether a response can be stored.
+func cacheable(resp Response) bool {
+	if resp.Status != http.StatusOK || resp.Header.Get("Set-Cookie") != "" {
+		return false
+	}
+
+	cacheControl := strings.ToLower(resp.Header.Get("Cache-Control"))
+	return !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private")
+}
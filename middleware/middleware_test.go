@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// stubCache is a cache that stores the values in a map.
+type stubCache struct {
+	cache.Cache
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func newStubCache() *stubCache {
+	return &stubCache{values: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (s *stubCache) SetValue(_ context.Context, key string, value any, ttl time.Duration) error {
+	if s.err != nil {
+		return s.err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s.values[key] = data
+	s.ttls[key] = ttl
+
+	return nil
+}
+
+func (s *stubCache) GetValue(_ context.Context, key string, dest any) error {
+	if s.err != nil {
+		return s.err
+	}
+	data, ok := s.values[key]
+	if !ok {
+		return cache.ErrKeyNotFound
+	}
+
+	return json.Unmarshal(data, dest)
+}
+
+func TestHandler(t *testing.T) {
+	newHandler := func(calls *int, status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			*calls++
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("hello"))
+		})
+	}
+
+	t.Run("should serve the cached response", func(t *testing.T) {
+		calls := 0
+		handler := New(newStubCache()).Handler(newHandler(&calls, http.StatusOK))
+
+		for range 2 {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hello?name=x", nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code, "Expected status 200")
+			assert.Equal(t, "hello", rec.Body.String(), "Expected the response body")
+			assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"), "Expected the header")
+		}
+		assert.Equal(t, 1, calls, "Expected the handler to be called once")
+	})
+
+	t.Run("should not cache other methods", func(t *testing.T) {
+		calls := 0
+		handler := New(newStubCache()).Handler(newHandler(&calls, http.StatusOK))
+
+		for range 2 {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hello", nil))
+		}
+
+		assert.Equal(t, 2, calls, "Expected the handler to be called twice")
+	})
+
+	t.Run("should not cache errors", func(t *testing.T) {
+		calls := 0
+		handler := New(newStubCache()).Handler(newHandler(&calls, http.StatusInternalServerError))
+
+		for range 2 {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hello", nil))
+		}
+
+		assert.Equal(t, 2, calls, "Expected the handler to be called twice")
+	})
+
+	t.Run("should use the TTL of the route", func(t *testing.T) {
+		calls := 0
+		stub := newStubCache()
+		rc := New(stub, WithTTL(time.Hour), WithRouteTTL("GET /users/{id}", time.Minute))
+		mux := http.NewServeMux()
+		mux.Handle("GET /users/{id}", rc.Handler(newHandler(&calls, http.StatusOK)))
+
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+		assert.Equal(t, time.Minute, stub.ttls["/users/1"], "Expected the TTL of the route")
+	})
+
+	t.Run("should not cache routes with a zero TTL", func(t *testing.T) {
+		calls := 0
+		rc := New(newStubCache(), WithRouteTTL("/hello", 0))
+		handler := rc.Handler(newHandler(&calls, http.StatusOK))
+
+		for range 2 {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+		}
+
+		assert.Equal(t, 2, calls, "Expected the handler to be called twice")
+	})
+
+	t.Run("should use the key function", func(t *testing.T) {
+		calls := 0
+		stub := newStubCache()
+		rc := New(stub, WithKeyFunc(func(r *http.Request) string {
+			return r.URL.Path + "|" + r.Header.Get("Accept-Language")
+		}))
+		handler := rc.Handler(newHandler(&calls, http.StatusOK))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello?x=1", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello?x=2", nil))
+
+		assert.Equal(t, 1, calls, "Expected the handler to be called once")
+		assert.Contains(t, stub.values, "/hello|", "Expected the key of the key function")
+	})
+
+	t.Run("should serve the request when the cache fails", func(t *testing.T) {
+		calls := 0
+		var errs []error
+		stub := newStubCache()
+		stub.err = errors.New("database error")
+		rc := New(stub, WithErrorHandler(func(_ *http.Request, err error) {
+			errs = append(errs, err)
+		}))
+
+		rec := httptest.NewRecorder()
+		rc.Handler(newHandler(&calls, http.StatusOK)).
+			ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+		assert.Equal(t, "hello", rec.Body.String(), "Expected the response body")
+		assert.Len(t, errs, 2, "Expected the read and write errors")
+	})
+}
+
+func TestCacheable(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     Response
+		expected bool
+	}{
+		{"ok", Response{Status: http.StatusOK, Header: http.Header{}}, true},
+		{"not found", Response{Status: http.StatusNotFound, Header: http.Header{}}, false},
+		{"cookie", Response{Status: http.StatusOK, Header: http.Header{"Set-Cookie": {"a=b"}}}, false},
+		{"no-store", Response{Status: http.StatusOK, Header: http.Header{"Cache-Control": {"no-store"}}}, false},
+		{"private", Response{Status: http.StatusOK, Header: http.Header{"Cache-Control": {"Private"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, cacheable(tt.resp), "Unexpected cacheable result")
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// Recorder is a http.ResponseWriter that writes the response to the wrapped writer and
+// keeps a copy of it, so that it can be stored once the handler returns.
+type Recorder struct {
+	http.ResponseWriter
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+// NewRecorder creates a recorder that writes to w.
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w}
+}
+
+// WriteHeader records the status of the response and writes it.
+func (rec *Recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write records and writes a part of the body of the response.
+// The headers are recorded by the first write, since some frameworks set them after
+// the status.
+func (rec *Recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if rec.header == nil {
+		rec.header = rec.Header().Clone()
+	}
+	rec.body.Write(b)
+
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped writer, so that http.ResponseController can reach it.
+func (rec *Recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Response returns the recorded response.
+func (rec *Recorder) Response() Response {
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := rec.header
+	if header == nil {
+		header = rec.Header().Clone()
+	}
+
+	return Response{
+		Status: status,
+		Header: header,
+		Body:   rec.body.Bytes(),
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Run("should record and write the response", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		rec := NewRecorder(w)
+
+		rec.WriteHeader(http.StatusCreated)
+		rec.Header().Set("X-Test", "1")
+		_, _ = rec.Write([]byte("a"))
+		_, _ = rec.Write([]byte("b"))
+
+		resp := rec.Response()
+		assert.Equal(t, http.StatusCreated, resp.Status, "Expected the recorded status")
+		assert.Equal(t, "1", resp.Header.Get("X-Test"), "Expected the recorded header")
+		assert.Equal(t, "ab", string(resp.Body), "Expected the recorded body")
+		assert.Equal(t, "ab", w.Body.String(), "Expected the body to be written")
+	})
+
+	t.Run("should default to status 200", func(t *testing.T) {
+		rec := NewRecorder(httptest.NewRecorder())
+
+		assert.Equal(t, http.StatusOK, rec.Response().Status, "Expected status 200")
+	})
+}
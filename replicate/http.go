@@ -0,0 +1,103 @@
+package replicate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// httpSource reads the changes of a remote cache through its admin API.
+type httpSource struct {
+	client  *http.Client
+	baseURL string
+}
+
+// HTTPSource returns a source that reads the changes of a remote cache through its
+// admin API, see admin.Handler. The remote cache must be created with
+// cache.WithEventLog.
+//
+// Parameters:
+//   - baseURL: the URL the admin API is mounted on, such as "http://primary:8080/internal/cache"
+//   - client: the HTTP client of the requests, http.DefaultClient if nil
+//
+// Returns:
+//   - Source: the source
+//
+// Example:
+//
+//	source := replicate.HTTPSource("http://primary:8080/internal/cache", nil)
+//	replicator := replicate.New(source, standby)
+func HTTPSource(baseURL string, client *http.Client) Source {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &httpSource{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Events returns the events recorded by the remote cache after sinceID.
+func (s *httpSource) Events(ctx context.Context, sinceID int64) ([]cache.Event, error) {
+	var events []cache.Event
+	err := s.get(ctx, "/events?since="+strconv.FormatInt(sinceID, 10), &events)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetEntry returns the entry of a key of the remote cache.
+func (s *httpSource) GetEntry(ctx context.Context, key string) (cache.Entry, error) {
+	var entry cache.Entry
+	err := s.get(ctx, "/entries/"+url.PathEscape(key), &entry)
+	if err != nil {
+		return cache.Entry{}, err
+	}
+
+	return entry, nil
+}
+
+// get decodes the JSON body of a GET request to the admin API into v. The statuses
+// of the errors of the cache are mapped back to the errors of the cache package.
+func (s *httpSource) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return cache.ErrKeyNotFound
+	case http.StatusNotImplemented:
+		return cache.ErrEventLogDisabled
+	default:
+		var body struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+
+		return fmt.Errorf("requesting %s: status %d: %s", path, resp.StatusCode, body.Error)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package replicate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/admin"
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// stubCache serves the events and the entries of a stubSource through the admin API.
+type stubCache struct {
+	cache.Cache
+	source *stubSource
+}
+
+func (s *stubCache) Events(ctx context.Context, sinceID int64) ([]cache.Event, error) {
+	return s.source.Events(ctx, sinceID)
+}
+
+func (s *stubCache) GetEntry(ctx context.Context, key string) (cache.Entry, error) {
+	return s.source.GetEntry(ctx, key)
+}
+
+func TestHTTPSource(t *testing.T) {
+	ctx := context.Background()
+	source := &stubSource{
+		entries: map[string]cache.Entry{
+			"pages/home page": {Value: "<html>", Metadata: map[string]string{"content-type": "text/html"}},
+		},
+		events: []cache.Event{
+			{ID: 1, Type: cache.EventSet, Key: "pages/home page"},
+			{ID: 2, Type: cache.EventDelete, Key: "user:1"},
+		},
+	}
+	server := httptest.NewServer(http.StripPrefix("/internal/cache",
+		admin.Handler(&stubCache{source: source})))
+	defer server.Close()
+
+	httpSource := HTTPSource(server.URL+"/internal/cache/", server.Client())
+
+	t.Run("should read the events after an ID", func(t *testing.T) {
+		events, err := httpSource.Events(ctx, 1)
+
+		assert.NoError(t, err, "Expected no error while reading the events")
+		assert.Equal(t, []cache.Event{{ID: 2, Type: cache.EventDelete, Key: "user:1"}}, events)
+	})
+
+	t.Run("should read the entry of a key", func(t *testing.T) {
+		entry, err := httpSource.GetEntry(ctx, "pages/home page")
+
+		assert.NoError(t, err, "Expected no error while reading the entry")
+		assert.Equal(t, source.entries["pages/home page"], entry)
+	})
+
+	t.Run("should return ErrKeyNotFound for a missing key", func(t *testing.T) {
+		_, err := httpSource.GetEntry(ctx, "missing")
+
+		assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	})
+
+	t.Run("should return ErrEventLogDisabled if the source has no event log", func(t *testing.T) {
+		source.err = cache.ErrEventLogDisabled
+		defer func() { source.err = nil }()
+
+		_, err := httpSource.Events(ctx, 0)
+
+		assert.ErrorIs(t, err, cache.ErrEventLogDisabled)
+	})
+
+	t.Run("should return the errors of the source", func(t *testing.T) {
+		source.err = errors.New("database error")
+		defer func() { source.err = nil }()
+
+		_, err := httpSource.Events(ctx, 0)
+
+		assert.EqualError(t, err, "requesting /events?since=0: status 500: database error")
+	})
+}
@@ -0,0 +1,219 @@
+// Package replicate keeps a copy of a litepack cache up to date by tailing the event log
+// of the source cache and applying its sets and deletes to a target cache, so that a
+// standby process can take over with a warm cache.
+package replicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// Source is the cache whose changes are replicated. A cache.Cache created with
+// cache.WithEventLog is a source on the same host; HTTPSource reads a remote cache
+// through its admin API.
+type Source interface {
+	Events(ctx context.Context, sinceID int64) ([]cache.Event, error)
+	GetEntry(ctx context.Context, key string) (cache.Entry, error)
+}
+
+// Target is the cache the changes are applied to, usually a cache.Cache.
+type Target interface {
+	Set(ctx context.Context, key string, value string, ttl time.Duration, opts ...cache.SetOption) error
+	Del(ctx context.Context, key string) error
+	DelPrefix(ctx context.Context, prefix string) (int64, error)
+	Flush(ctx context.Context) error
+}
+
+// Option is a function that configures a replicator.
+type Option func(*Replicator)
+
+// Replicator applies the changes of a source cache to a target cache.
+//
+// The events only carry the keys, so the value of a set is read from the source when
+// the event is applied: replaying an event is harmless, and a key written several times
+// is copied with its latest value. The entries written before the first event applied
+// are not copied; start the target from a copy of the source, for example with
+// Cache.Snapshot or Cache.Export, and resume the replication from an event recorded
+// before the copy with WithStartID.
+type Replicator struct {
+	source   Source
+	target   Target
+	onError  func(err error)
+	now      func() time.Time
+	lastID   atomic.Int64
+	interval time.Duration
+}
+
+// New creates a replicator that applies the changes of source to target.
+//
+// Parameters:
+//   - source: the cache whose changes are replicated
+//   - target: the cache the changes are applied to
+//   - opts: the replicator options
+//
+// Returns:
+//   - *Replicator: the replicator, started with Run
+//
+// Configuration defaults:
+//   - interval: 1 second
+//   - startID: 0, the oldest event kept by the source
+//   - onError: errors are ignored, the replication is retried on the next interval
+//
+// Example:
+//
+//	source, err := cache.NewCache(ctx, cache.WithPath(primary), cache.WithEventLog(time.Hour))
+//	target, err := cache.NewCache(ctx, cache.WithPath(standby))
+//
+//	replicator := replicate.New(source, target, replicate.WithInterval(500*time.Millisecond))
+//	go replicator.Run(ctx)
+func New(source Source, target Target, opts ...Option) *Replicator {
+	r := &Replicator{
+		source:   source,
+		target:   target,
+		onError:  func(error) {},
+		now:      time.Now,
+		interval: time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// WithInterval sets how often the replicator reads the new events of the source.
+func WithInterval(interval time.Duration) Option {
+	return func(r *Replicator) {
+		r.interval = interval
+	}
+}
+
+// WithStartID resumes the replication after the event with the given ID, for example
+// the LastID stored by a previous run.
+func WithStartID(id int64) Option {
+	return func(r *Replicator) {
+		r.lastID.Store(id)
+	}
+}
+
+// WithErrorHandler sets the function called when Run fails to read or apply the events.
+// The failed event is retried on the next interval.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(r *Replicator) {
+		r.onError = fn
+	}
+}
+
+// LastID returns the ID of the last event applied to the target.
+func (r *Replicator) LastID() int64 {
+	return r.lastID.Load()
+}
+
+// Run applies the changes of the source to the target on the interval until ctx is
+// done. The errors are reported to the error handler and the failed event is retried
+// on the next interval.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - error: the error of ctx once it is done
+func (r *Replicator) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := r.Sync(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync applies the events recorded by the source since the last event applied, until
+// the target is up to date.
+//
+// Parameters:
+//   - ctx: the context
+//
+// Returns:
+//   - int: the number of events applied
+//   - error: an error if the events could not be read or applied; the events applied
+//     before the error are kept
+func (r *Replicator) Sync(ctx context.Context) (int, error) {
+	applied := 0
+	for {
+		events, err := r.source.Events(ctx, r.lastID.Load())
+		if err != nil {
+			return applied, fmt.Errorf("reading events: %w", err)
+		}
+		if len(events) == 0 {
+			return applied, nil
+		}
+
+		for _, event := range events {
+			if err := r.apply(ctx, event); err != nil {
+				return applied, fmt.Errorf("applying event %d: %w", event.ID, err)
+			}
+			r.lastID.Store(event.ID)
+			applied++
+		}
+	}
+}
+
+// apply applies an event of the source to the target.
+func (r *Replicator) apply(ctx context.Context, event cache.Event) error {
+	switch event.Type {
+	case cache.EventSet:
+		return r.copy(ctx, event.Key)
+	case cache.EventDelete, cache.EventExpire, cache.EventEvict:
+		return r.target.Del(ctx, event.Key)
+	case cache.EventFlush:
+		if event.Key == "" {
+			return r.target.Flush(ctx)
+		}
+		_, err := r.target.DelPrefix(ctx, event.Key)
+		return err
+	default:
+		return nil
+	}
+}
+
+// copy copies the entry of the key from the source to the target, or deletes it from
+// the target if it is no longer live in the source.
+func (r *Replicator) copy(ctx context.Context, key string) error {
+	entry, err := r.source.GetEntry(ctx, key)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return r.target.Del(ctx, key)
+	}
+	if err != nil {
+		return fmt.Errorf("reading key %q: %w", key, err)
+	}
+
+	var ttl time.Duration
+	if !entry.ExpiresAt.IsZero() {
+		ttl = entry.ExpiresAt.Sub(r.now())
+		if ttl <= 0 {
+			return r.target.Del(ctx, key)
+		}
+	}
+
+	var opts []cache.SetOption
+	if len(entry.Metadata) > 0 {
+		opts = append(opts, cache.WithMetadata(entry.Metadata))
+	}
+
+	return r.target.Set(ctx, key, entry.Value, ttl, opts...)
+}
@@ -0,0 +1,200 @@
+package replicate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/cache"
+)
+
+// stubSource returns the events after an ID and the entries of a map.
+type stubSource struct {
+	entries map[string]cache.Entry
+	err     error
+	events  []cache.Event
+}
+
+func (s *stubSource) Events(_ context.Context, sinceID int64) ([]cache.Event, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var events []cache.Event
+	for _, event := range s.events {
+		if event.ID > sinceID {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func (s *stubSource) GetEntry(_ context.Context, key string) (cache.Entry, error) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return cache.Entry{}, cache.ErrKeyNotFound
+	}
+
+	return entry, nil
+}
+
+// stubTarget records the changes applied to it.
+type stubTarget struct {
+	err     error
+	applied []string
+	ttls    map[string]time.Duration
+	meta    map[string]map[string]string
+}
+
+func newStubTarget() *stubTarget {
+	return &stubTarget{
+		ttls: make(map[string]time.Duration),
+		meta: make(map[string]map[string]string),
+	}
+}
+
+func (s *stubTarget) Set(
+	ctx context.Context,
+	key string,
+	value string,
+	ttl time.Duration,
+	opts ...cache.SetOption,
+) error {
+	s.applied = append(s.applied, "set "+key+"="+value)
+	s.ttls[key] = ttl
+	if len(opts) > 0 {
+		s.meta[key] = map[string]string{}
+	}
+
+	return s.err
+}
+
+func (s *stubTarget) Del(_ context.Context, key string) error {
+	s.applied = append(s.applied, "del "+key)
+	return s.err
+}
+
+func (s *stubTarget) DelPrefix(_ context.Context, prefix string) (int64, error) {
+	s.applied = append(s.applied, "delprefix "+prefix)
+	return 0, s.err
+}
+
+func (s *stubTarget) Flush(context.Context) error {
+	s.applied = append(s.applied, "flush")
+	return s.err
+}
+
+func TestReplicator_Sync(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should apply the events to the target", func(t *testing.T) {
+		source := &stubSource{
+			entries: map[string]cache.Entry{
+				"user:1":   {Value: "John", ExpiresAt: now.Add(time.Hour)},
+				"config":   {Value: "on", Metadata: map[string]string{"source": "file"}},
+				"user:old": {Value: "Jane", ExpiresAt: now.Add(-time.Second)},
+			},
+			events: []cache.Event{
+				{ID: 1, Type: cache.EventSet, Key: "user:1"},
+				{ID: 2, Type: cache.EventSet, Key: "config"},
+				{ID: 3, Type: cache.EventSet, Key: "user:old"},
+				{ID: 4, Type: cache.EventSet, Key: "user:gone"},
+				{ID: 5, Type: cache.EventDelete, Key: "user:2"},
+				{ID: 6, Type: cache.EventExpire, Key: "user:3"},
+				{ID: 7, Type: cache.EventEvict, Key: "user:4"},
+				{ID: 8, Type: cache.EventFlush, Key: "sessions:"},
+				{ID: 9, Type: cache.EventFlush, Key: ""},
+			},
+		}
+		target := newStubTarget()
+		r := New(source, target)
+		r.now = func() time.Time { return now }
+
+		applied, err := r.Sync(ctx)
+
+		assert.NoError(t, err, "Expected no error while syncing")
+		assert.Equal(t, 9, applied, "Expected every event to be applied")
+		assert.Equal(t, int64(9), r.LastID(), "Expected the ID of the last event")
+		assert.Equal(t, []string{
+			"set user:1=John",
+			"set config=on",
+			"del user:old",
+			"del user:gone",
+			"del user:2",
+			"del user:3",
+			"del user:4",
+			"delprefix sessions:",
+			"flush",
+		}, target.applied)
+		assert.Equal(t, time.Hour, target.ttls["user:1"], "Expected the remaining TTL")
+		assert.Equal(t, time.Duration(0), target.ttls["config"], "Expected an entry that never expires")
+		assert.Contains(t, target.meta, "config", "Expected the metadata to be copied")
+	})
+
+	t.Run("should resume after the start ID", func(t *testing.T) {
+		source := &stubSource{
+			events: []cache.Event{
+				{ID: 1, Type: cache.EventDelete, Key: "a"},
+				{ID: 2, Type: cache.EventDelete, Key: "b"},
+			},
+		}
+		target := newStubTarget()
+
+		applied, err := New(source, target, WithStartID(1)).Sync(ctx)
+
+		assert.NoError(t, err, "Expected no error while syncing")
+		assert.Equal(t, 1, applied)
+		assert.Equal(t, []string{"del b"}, target.applied)
+	})
+
+	t.Run("should keep the position of the failed event", func(t *testing.T) {
+		source := &stubSource{
+			events: []cache.Event{{ID: 5, Type: cache.EventDelete, Key: "a"}},
+		}
+		target := newStubTarget()
+		target.err = errors.New("database is locked")
+		r := New(source, target, WithStartID(4))
+
+		_, err := r.Sync(ctx)
+
+		assert.EqualError(t, err, "applying event 5: database is locked")
+		assert.Equal(t, int64(4), r.LastID(), "Expected the failed event to be retried")
+	})
+
+	t.Run("should return an error if the events can't be read", func(t *testing.T) {
+		source := &stubSource{err: cache.ErrEventLogDisabled}
+
+		_, err := New(source, newStubTarget()).Sync(ctx)
+
+		assert.ErrorIs(t, err, cache.ErrEventLogDisabled)
+	})
+}
+
+func TestReplicator_Run(t *testing.T) {
+	t.Run("should report the errors and stop when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error, 1)
+		source := &stubSource{err: errors.New("connection refused")}
+		r := New(source, newStubTarget(),
+			WithInterval(time.Millisecond),
+			WithErrorHandler(func(err error) {
+				select {
+				case errs <- err:
+				default:
+				}
+			}),
+		)
+
+		done := make(chan error)
+		go func() { done <- r.Run(ctx) }()
+
+		assert.EqualError(t, <-errs, "reading events: connection refused")
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+}
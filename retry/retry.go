@@ -0,0 +1,160 @@
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Func is the operation executed by Do.
+type Func func() error
+
+// Option is a function that configures a retry policy.
+type Option func(*policy)
+
+// policy holds the configuration used by Do.
+type policy struct {
+	retryIf         func(error) bool
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	maxElapsedTime  time.Duration
+	multiplier      float64
+	jitter          float64
+	maxAttempts     int
+}
+
+// Do executes the function until it succeeds, the error is not retryable,
+// the attempts or the elapsed time are exhausted, or the context is done.
+// Between attempts it waits with an exponential backoff with jitter.
+//
+// Parameters:
+//   - ctx: the context
+//   - fn: the function to execute
+//   - opts: the retry options
+//
+// Returns:
+//   - error: the last error returned by the function, or the context error
+//
+// Configuration defaults:
+//   - maxAttempts: 3
+//   - initialInterval: 10 milliseconds
+//   - maxInterval: 1 second
+//   - multiplier: 2
+//   - jitter: 0.2
+//   - maxElapsedTime: unlimited
+//   - retryIf: every error is retried
+//
+// Example:
+//
+//	err := retry.Do(ctx, func() error {
+//		return db.Exec(ctx, query)
+//	}, retry.WithMaxAttempts(5), retry.WithRetryIf(database.IsBusyError))
+//	if err != nil {
+//		return err
+//	}
+func Do(ctx context.Context, fn Func, opts ...Option) error {
+	p := &policy{
+		maxAttempts:     3,
+		initialInterval: 10 * time.Millisecond,
+		maxInterval:     time.Second,
+		multiplier:      2,
+		jitter:          0.2,
+		retryIf:         func(error) bool { return true },
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	start := time.Now()
+	interval := p.initialInterval
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if !p.retryIf(err) || (p.maxAttempts > 0 && attempt >= p.maxAttempts) {
+			return err
+		}
+
+		delay := p.withJitter(interval)
+		if p.maxElapsedTime > 0 && time.Since(start)+delay > p.maxElapsedTime {
+			return err
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		interval = min(time.Duration(float64(interval)*p.multiplier), p.maxInterval)
+	}
+}
+
+// withJitter randomizes the interval by up to ± jitter percent.
+func (p *policy) withJitter(interval time.Duration) time.Duration {
+	if p.jitter <= 0 || interval <= 0 {
+		return interval
+	}
+
+	delta := p.jitter * float64(interval)
+	return time.Duration(float64(interval) - delta + rand.Float64()*(2*delta))
+}
+
+// WithMaxAttempts sets the maximum number of attempts, including the first one.
+// Zero or a negative value means the attempts are unlimited.
+func WithMaxAttempts(attempts int) Option {
+	return func(p *policy) {
+		p.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the interval before the first retry and the maximum interval between retries.
+// Zero intervals retry immediately.
+func WithBackoff(initial, maxInterval time.Duration) Option {
+	return func(p *policy) {
+		p.initialInterval = initial
+		p.maxInterval = maxInterval
+	}
+}
+
+// WithMultiplier sets the factor applied to the interval after each retry.
+func WithMultiplier(multiplier float64) Option {
+	return func(p *policy) {
+		p.multiplier = multiplier
+	}
+}
+
+// WithJitter sets the randomization factor of the interval, between 0 and 1.
+// A jitter of 0.2 waits between 80% and 120% of the interval.
+func WithJitter(jitter float64) Option {
+	return func(p *policy) {
+		p.jitter = jitter
+	}
+}
+
+// WithMaxElapsedTime stops retrying when the next attempt would start after the given duration.
+func WithMaxElapsedTime(maxElapsed time.Duration) Option {
+	return func(p *policy) {
+		p.maxElapsedTime = maxElapsed
+	}
+}
+
+// WithRetryIf sets the predicate that decides whether an error is retryable.
+// Errors for which the predicate returns false are returned immediately.
+func WithRetryIf(retryIf func(error) bool) Option {
+	return func(p *policy) {
+		p.retryIf = retryIf
+	}
+}
@@ -0,0 +1,117 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	errTemporary := errors.New("temporary error")
+	errPermanent := errors.New("permanent error")
+	ctx := context.Background()
+
+	t.Run("should return nil when the function succeeds", func(t *testing.T) {
+		calls := 0
+
+		err := Do(ctx, func() error {
+			calls++
+			return nil
+		})
+
+		assert.NoError(t, err, "Expected no error")
+		assert.Equal(t, 1, calls, "Expected a single call")
+	})
+
+	t.Run("should retry until the function succeeds", func(t *testing.T) {
+		calls := 0
+
+		err := Do(ctx, func() error {
+			calls++
+			if calls < 3 {
+				return errTemporary
+			}
+			return nil
+		}, WithBackoff(time.Millisecond, time.Millisecond))
+
+		assert.NoError(t, err, "Expected no error after retrying")
+		assert.Equal(t, 3, calls, "Expected three calls")
+	})
+
+	t.Run("should return the last error when attempts are exhausted", func(t *testing.T) {
+		calls := 0
+
+		err := Do(ctx, func() error {
+			calls++
+			return errTemporary
+		}, WithMaxAttempts(2), WithBackoff(0, 0))
+
+		assert.ErrorIs(t, err, errTemporary)
+		assert.Equal(t, 2, calls, "Expected two calls")
+	})
+
+	t.Run("should not retry errors rejected by the predicate", func(t *testing.T) {
+		calls := 0
+
+		err := Do(ctx, func() error {
+			calls++
+			return errPermanent
+		}, WithRetryIf(func(err error) bool { return errors.Is(err, errTemporary) }))
+
+		assert.ErrorIs(t, err, errPermanent)
+		assert.Equal(t, 1, calls, "Expected a single call")
+	})
+
+	t.Run("should stop when the max elapsed time would be exceeded", func(t *testing.T) {
+		calls := 0
+
+		err := Do(ctx, func() error {
+			calls++
+			return errTemporary
+		},
+			WithMaxAttempts(0),
+			WithBackoff(20*time.Millisecond, 20*time.Millisecond),
+			WithJitter(0),
+			WithMaxElapsedTime(50*time.Millisecond),
+		)
+
+		assert.ErrorIs(t, err, errTemporary)
+		assert.Equal(t, 3, calls, "Expected three calls")
+	})
+
+	t.Run("should return the context error when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+
+		err := Do(ctx, func() error {
+			calls++
+			cancel()
+			return errTemporary
+		}, WithBackoff(time.Second, time.Second))
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls, "Expected a single call")
+	})
+}
+
+func TestPolicy_withJitter(t *testing.T) {
+	t.Run("should keep the interval within the jitter bounds", func(t *testing.T) {
+		p := &policy{jitter: 0.5}
+
+		for range 100 {
+			delay := p.withJitter(100 * time.Millisecond)
+
+			assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+			assert.LessOrEqual(t, delay, 150*time.Millisecond)
+		}
+	})
+
+	t.Run("should not change the interval without jitter", func(t *testing.T) {
+		p := &policy{}
+
+		assert.Equal(t, 100*time.Millisecond, p.withJitter(100*time.Millisecond))
+	})
+}
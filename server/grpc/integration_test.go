@@ -0,0 +1,144 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	lPCache "github.com/lucasvillarinho/litepack/cache"
+	lPGrpc "github.com/lucasvillarinho/litepack/server/grpc"
+	"github.com/lucasvillarinho/litepack/server/grpc/litepackpb"
+)
+
+// selfSignedCertificate creates a certificate for localhost and a pool that trusts it.
+func selfSignedCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func TestGrpcServer(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	cert, pool := selfSignedCertificate(t)
+	server, err := lPGrpc.NewServer(lCache, lPGrpc.WithTLSConfig(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}))
+	if err != nil {
+		panic(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	go func() { _ = server.Serve(lis) }()
+	defer server.GracefulStop()
+
+	creds := credentials.NewTLS(&tls.Config{
+		RootCAs:    pool,
+		ServerName: "localhost",
+		MinVersion: tls.VersionTLS12,
+	})
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+
+	client := litepackpb.NewCacheClient(conn)
+
+	t.Run("Should set and get a value over gRPC ", func(t *testing.T) {
+		defer lCache.Del(ctx, "grpc-key")
+
+		_, err := client.Set(ctx, &litepackpb.SetRequest{
+			Key:   "grpc-key",
+			Value: []byte("value"),
+			Ttl:   durationpb.New(time.Minute),
+		})
+		assert.Nil(t, err, "Expected to set the value without error, but got: %v", err)
+
+		resp, err := client.Get(ctx, &litepackpb.GetRequest{Key: "grpc-key"})
+		assert.Nil(t, err, "Expected to get the value without error, but got: %v", err)
+		assert.Equal(t, []byte("value"), resp.GetValue(), "Expected the stored value")
+	})
+
+	t.Run("Should get multiple values over gRPC ", func(t *testing.T) {
+		defer lCache.Del(ctx, "grpc-key1")
+
+		_ = lCache.Set(ctx, "grpc-key1", "value1", time.Minute)
+
+		resp, err := client.MGet(ctx, &litepackpb.MGetRequest{Keys: []string{"grpc-key1", "grpc-key2"}})
+
+		assert.Nil(t, err, "Expected to get the values without error, but got: %v", err)
+		assert.Equal(t, map[string][]byte{"grpc-key1": []byte("value1")}, resp.GetValues(),
+			"Expected only the existing keys")
+	})
+
+	t.Run("Should delete a value over gRPC ", func(t *testing.T) {
+		_ = lCache.Set(ctx, "grpc-key", "value", time.Minute)
+
+		_, err := client.Del(ctx, &litepackpb.DelRequest{Key: "grpc-key"})
+		assert.Nil(t, err, "Expected to delete the value without error, but got: %v", err)
+
+		_, err = client.Get(ctx, &litepackpb.GetRequest{Key: "grpc-key"})
+		assert.Equal(t, codes.NotFound, status.Code(err), "Expected NotFound after delete")
+	})
+
+	t.Run("Should return the stats over gRPC ", func(t *testing.T) {
+		defer lCache.Del(ctx, "grpc-key")
+
+		_ = lCache.Set(ctx, "grpc-key", "value", time.Minute)
+
+		resp, err := client.Stats(ctx, &litepackpb.StatsRequest{})
+
+		assert.Nil(t, err, "Expected to get the stats without error, but got: %v", err)
+		assert.Equal(t, int64(1), resp.GetEntries(), "Expected the number of entries")
+	})
+}
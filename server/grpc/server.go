@@ -0,0 +1,230 @@
+// Package grpc exposes a litepack cache over gRPC, so that a sidecar can share an embedded
+// cache with the other containers of a pod.
+// It is a module of its own, with the generated code of litepackpb, so that gRPC is
+// required only by the applications using it.
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/lucasvillarinho/litepack/cache"
+	"github.com/lucasvillarinho/litepack/server/grpc/litepackpb"
+)
+
+// Option is a function that configures a gRPC server.
+type Option func(*options) error
+
+// options holds the configuration of a gRPC server.
+type options struct {
+	tlsConfig     *tls.Config
+	serverOptions []grpc.ServerOption
+}
+
+// WithTLS serves over TLS with the certificate and key in the given PEM files.
+func WithTLS(certFile, keyFile string) Option {
+	return func(o *options) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("loading certificate: %w", err)
+		}
+		o.tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+
+		return nil
+	}
+}
+
+// WithTLSConfig serves over TLS with the given configuration, for example to require
+// client certificates.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) error {
+		o.tlsConfig = config
+		return nil
+	}
+}
+
+// WithServerOptions adds options to the gRPC server, such as interceptors.
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(o *options) error {
+		o.serverOptions = append(o.serverOptions, opts...)
+		return nil
+	}
+}
+
+// NewServer creates a gRPC server that serves the cache service of the given cache.
+// Without TLS options the server accepts plaintext connections.
+//
+// Parameters:
+//   - c: the cache
+//   - opts: the server options
+//
+// Returns:
+//   - *grpc.Server: the server, started with Serve
+//   - error: an error if the TLS certificate could not be loaded
+//
+// Example:
+//
+//	server, err := lpgrpc.NewServer(lpCache, lpgrpc.WithTLS("server.crt", "server.key"))
+//	if err != nil {
+//		return err
+//	}
+//
+//	lis, err := net.Listen("tcp", ":9090")
+//	if err != nil {
+//		return err
+//	}
+//	defer server.GracefulStop()
+//
+//	err = server.Serve(lis)
+func NewServer(c cache.Cache, opts ...Option) (*grpc.Server, error) {
+	o := &options{}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	serverOptions := o.serverOptions
+	if o.tlsConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(o.tlsConfig)))
+	}
+
+	server := grpc.NewServer(serverOptions...)
+	litepackpb.RegisterCacheServer(server, NewService(c))
+
+	return server, nil
+}
+
+// Service implements the litepack cache service on a cache.
+type Service struct {
+	litepackpb.UnimplementedCacheServer
+	cache cache.Cache
+}
+
+// NewService creates the cache service of the given cache, to be registered on an
+// existing gRPC server.
+//
+// Parameters:
+//   - c: the cache
+//
+// Returns:
+//   - *Service: the cache service
+//
+// Example:
+//
+//	server := grpc.NewServer()
+//	litepackpb.RegisterCacheServer(server, lpgrpc.NewService(lpCache))
+func NewService(c cache.Cache) *Service {
+	return &Service{cache: c}
+}
+
+// Get returns the value of a key.
+func (s *Service) Get(
+	ctx context.Context,
+	req *litepackpb.GetRequest,
+) (*litepackpb.GetResponse, error) {
+	value, err := s.cache.GetBytes(ctx, req.GetKey())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &litepackpb.GetResponse{Value: value}, nil
+}
+
+// Set stores a value with a TTL.
+func (s *Service) Set(
+	ctx context.Context,
+	req *litepackpb.SetRequest,
+) (*litepackpb.SetResponse, error) {
+	if req.GetTtl() == nil {
+		return nil, status.Error(codes.InvalidArgument, "ttl is required")
+	}
+
+	err := s.cache.SetBytes(ctx, req.GetKey(), req.GetValue(), req.GetTtl().AsDuration())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &litepackpb.SetResponse{}, nil
+}
+
+// Del deletes a key.
+func (s *Service) Del(
+	ctx context.Context,
+	req *litepackpb.DelRequest,
+) (*litepackpb.DelResponse, error) {
+	if err := s.cache.Del(ctx, req.GetKey()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &litepackpb.DelResponse{}, nil
+}
+
+// MGet returns the values of multiple keys.
+func (s *Service) MGet(
+	ctx context.Context,
+	req *litepackpb.MGetRequest,
+) (*litepackpb.MGetResponse, error) {
+	values, err := s.cache.MGet(ctx, req.GetKeys()...)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &litepackpb.MGetResponse{Values: make(map[string][]byte, len(values))}
+	for key, value := range values {
+		resp.Values[key] = []byte(value)
+	}
+
+	return resp, nil
+}
+
+// Stats returns the usage of the cache.
+func (s *Service) Stats(
+	ctx context.Context,
+	_ *litepackpb.StatsRequest,
+) (*litepackpb.StatsResponse, error) {
+	stats, err := s.cache.Stats(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &litepackpb.StatsResponse{
+		Entries:        stats.Entries,
+		ValueBytes:     stats.ValueBytes,
+		DbSize:         stats.DBSize,
+		MaxDbSize:      stats.MaxDBSize,
+		PageSize:       stats.PageSize,
+		FreePages:      stats.FreePages,
+		ExpiredPurges:  stats.ExpiredPurges,
+		SizePurges:     stats.SizePurges,
+		EvictedEntries: stats.EvictedEntries,
+		MemoryEntries:  stats.MemoryEntries,
+		MemoryBytes:    stats.MemoryBytes,
+	}, nil
+}
+
+// toStatus converts an error of the cache to a gRPC status error.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, cache.ErrKeyNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, cache.ErrValueTooLarge):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, cache.ErrCacheClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,206 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/lucasvillarinho/litepack/cache"
+	"github.com/lucasvillarinho/litepack/server/grpc/litepackpb"
+)
+
+// stubCache is a cache that stores the values in a map.
+type stubCache struct {
+	cache.Cache
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func newStubCache() *stubCache {
+	return &stubCache{values: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (s *stubCache) GetBytes(_ context.Context, key string) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	value, ok := s.values[key]
+	if !ok {
+		return nil, cache.ErrKeyNotFound
+	}
+
+	return value, nil
+}
+
+func (s *stubCache) SetBytes(
+	_ context.Context,
+	key string,
+	value []byte,
+	ttl time.Duration,
+	_ ...cache.SetOption,
+) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.values[key] = value
+	s.ttls[key] = ttl
+
+	return nil
+}
+
+func (s *stubCache) Del(_ context.Context, key string) error {
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.values, key)
+
+	return nil
+}
+
+func (s *stubCache) MGet(_ context.Context, keys ...string) (map[string]string, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	values := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := s.values[key]; ok {
+			values[key] = string(value)
+		}
+	}
+
+	return values, nil
+}
+
+func (s *stubCache) Stats(context.Context) (cache.CacheStats, error) {
+	if s.err != nil {
+		return cache.CacheStats{}, s.err
+	}
+
+	return cache.CacheStats{Entries: int64(len(s.values))}, nil
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should set and get a value", func(t *testing.T) {
+		stub := newStubCache()
+		service := NewService(stub)
+
+		_, err := service.Set(ctx, &litepackpb.SetRequest{
+			Key:   "key",
+			Value: []byte("value"),
+			Ttl:   durationpb.New(time.Minute),
+		})
+		assert.NoError(t, err, "Expected no error")
+
+		resp, err := service.Get(ctx, &litepackpb.GetRequest{Key: "key"})
+		assert.NoError(t, err, "Expected no error")
+		assert.Equal(t, []byte("value"), resp.GetValue(), "Expected the stored value")
+		assert.Equal(t, time.Minute, stub.ttls["key"], "Expected the TTL of the request")
+	})
+
+	t.Run("should require a ttl", func(t *testing.T) {
+		service := NewService(newStubCache())
+
+		_, err := service.Set(ctx, &litepackpb.SetRequest{Key: "key", Value: []byte("value")})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "Expected InvalidArgument")
+	})
+
+	t.Run("should return NotFound when the key does not exist", func(t *testing.T) {
+		service := NewService(newStubCache())
+
+		_, err := service.Get(ctx, &litepackpb.GetRequest{Key: "missing"})
+
+		assert.Equal(t, codes.NotFound, status.Code(err), "Expected NotFound")
+	})
+
+	t.Run("should delete a key", func(t *testing.T) {
+		stub := newStubCache()
+		stub.values["key"] = []byte("value")
+		service := NewService(stub)
+
+		_, err := service.Del(ctx, &litepackpb.DelRequest{Key: "key"})
+
+		assert.NoError(t, err, "Expected no error")
+		assert.NotContains(t, stub.values, "key", "Expected the key to be deleted")
+	})
+
+	t.Run("should get multiple values", func(t *testing.T) {
+		stub := newStubCache()
+		stub.values["key1"] = []byte("value1")
+		service := NewService(stub)
+
+		resp, err := service.MGet(ctx, &litepackpb.MGetRequest{Keys: []string{"key1", "key2"}})
+
+		assert.NoError(t, err, "Expected no error")
+		assert.Equal(t, map[string][]byte{"key1": []byte("value1")}, resp.GetValues(),
+			"Expected only the existing keys")
+	})
+
+	t.Run("should return the stats", func(t *testing.T) {
+		stub := newStubCache()
+		stub.values["key"] = []byte("value")
+		service := NewService(stub)
+
+		resp, err := service.Stats(ctx, &litepackpb.StatsRequest{})
+
+		assert.NoError(t, err, "Expected no error")
+		assert.Equal(t, int64(1), resp.GetEntries(), "Expected the number of entries")
+	})
+
+	t.Run("should return Internal when the cache fails", func(t *testing.T) {
+		stub := newStubCache()
+		stub.err = errors.New("database error")
+		service := NewService(stub)
+
+		_, err := service.Stats(ctx, &litepackpb.StatsRequest{})
+
+		assert.Equal(t, codes.Internal, status.Code(err), "Expected Internal")
+	})
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err      error
+		name     string
+		expected codes.Code
+	}{
+		{fmt.Errorf("getting key: %w", cache.ErrKeyNotFound), "not found", codes.NotFound},
+		{cache.ErrValueTooLarge, "too large", codes.InvalidArgument},
+		{cache.ErrCacheClosed, "closed", codes.Unavailable},
+		{context.DeadlineExceeded, "deadline", codes.DeadlineExceeded},
+		{context.Canceled, "canceled", codes.Canceled},
+		{errors.New("database error"), "internal", codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, status.Code(toStatus(tt.err)), "Unexpected status code")
+		})
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	t.Run("should fail when the certificate cannot be loaded", func(t *testing.T) {
+		_, err := NewServer(newStubCache(), WithTLS("missing.crt", "missing.key"))
+
+		assert.ErrorContains(t, err, "loading certificate", "Expected a certificate error")
+	})
+
+	t.Run("should register the cache service", func(t *testing.T) {
+		server, err := NewServer(newStubCache())
+
+		assert.NoError(t, err, "Expected no error")
+		assert.Contains(t, server.GetServiceInfo(), "litepack.v1.Cache",
+			"Expected the cache service to be registered")
+	})
+}
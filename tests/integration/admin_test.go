@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/admin"
+	lPCache "github.com/lucasvillarinho/litepack/cache"
+)
+
+func TestAdminHandler(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/internal/cache/", http.StripPrefix("/internal/cache", admin.Handler(lCache)))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("Should list and fetch entries through the admin API ", func(t *testing.T) {
+		defer lCache.Flush(ctx)
+
+		_ = lCache.Set(ctx, "user:1", "John", time.Minute)
+		_ = lCache.Set(ctx, "user:2", "Jane", time.Minute)
+		_ = lCache.Set(ctx, "order:1", "book", time.Minute)
+
+		resp, err := http.Get(server.URL + "/internal/cache/keys?prefix=user:")
+		assert.Nil(t, err, "Expected to list the keys without error, but got: %v", err)
+		var keys []string
+		_ = json.NewDecoder(resp.Body).Decode(&keys)
+		resp.Body.Close()
+		assert.ElementsMatch(t, []string{"user:1", "user:2"}, keys, "Expected the keys of the prefix")
+
+		resp, err = http.Get(server.URL + "/internal/cache/keys/user:1")
+		assert.Nil(t, err, "Expected to get the entry without error, but got: %v", err)
+		var entry admin.Entry
+		_ = json.NewDecoder(resp.Body).Decode(&entry)
+		resp.Body.Close()
+		assert.Equal(t, "John", entry.Value, "Expected the value of the entry")
+	})
+
+	t.Run("Should delete entries through the admin API ", func(t *testing.T) {
+		_ = lCache.Set(ctx, "key", "value", time.Minute)
+
+		req, _ := http.NewRequest(http.MethodDelete, server.URL+"/internal/cache/keys/key", nil)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err, "Expected to delete the entry without error, but got: %v", err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode, "Expected status 204")
+
+		_, err = lCache.Get(ctx, "key")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the entry to be deleted")
+	})
+
+	t.Run("Should purge and compact through the admin API ", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"/internal/cache/purge", "", nil)
+		assert.Nil(t, err, "Expected to purge without error, but got: %v", err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode, "Expected status 204")
+
+		resp, err = http.Post(server.URL+"/internal/cache/compact", "", nil)
+		assert.Nil(t, err, "Expected to compact without error, but got: %v", err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected status 200")
+	})
+}
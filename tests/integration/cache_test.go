@@ -1,13 +1,26 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lucasvillarinho/litepack"
 	lPCache "github.com/lucasvillarinho/litepack/cache"
+	"github.com/lucasvillarinho/litepack/internal/cron"
 )
 
 func TestCache(t *testing.T) {
@@ -64,4 +77,2174 @@ func TestCache(t *testing.T) {
 		)
 		assert.Emptyf(t, value, "Expected to get empty cache entry, but got: %v", value)
 	})
+	t.Run("Should successfully get multiple cache entries ", func(t *testing.T) {
+		defer lCache.Del(ctx, "key1")
+		defer lCache.Del(ctx, "key2")
+
+		_ = lCache.Set(ctx, "key1", "test1", 10*time.Second)
+		_ = lCache.Set(ctx, "key2", "test2", 10*time.Second)
+
+		values, err := lCache.MGet(ctx, "key1", "key2", "missing")
+
+		assert.Nil(t, err, "Expected to get cache entries without error, but got: %v", err)
+		assert.Equal(
+			t,
+			map[string]string{"key1": "test1", "key2": "test2"},
+			values,
+			"Expected to get only existing cache entries, but got: %v",
+			values,
+		)
+	})
+	t.Run("Should load and store missing cache entry ", func(t *testing.T) {
+		defer lCache.Del(ctx, "key")
+
+		loads := 0
+		loader := func(_ context.Context) (string, error) {
+			loads++
+			return "loaded", nil
+		}
+
+		value, err := lCache.GetOrSet(ctx, "key", 10*time.Second, loader)
+		assert.Nil(t, err, "Expected to load cache entry without error, but got: %v", err)
+		assert.Equal(t, "loaded", value, "Expected loaded value, but got: %v", value)
+
+		value, err = lCache.GetOrSet(ctx, "key", 10*time.Second, loader)
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "loaded", value, "Expected cached value, but got: %v", value)
+		assert.Equal(t, 1, loads, "Expected loader to run once, but ran %d times", loads)
+	})
+	t.Run("Should set cache entry only if it does not exist ", func(t *testing.T) {
+		defer lCache.Del(ctx, "key")
+
+		ok, err := lCache.SetNX(ctx, "key", "first", 10*time.Second)
+		assert.Nil(t, err, "Expected to set cache entry without error, but got: %v", err)
+		assert.True(t, ok, "Expected first SetNX to set the entry")
+
+		ok, err = lCache.SetNX(ctx, "key", "second", 10*time.Second)
+		assert.Nil(t, err, "Expected SetNX without error, but got: %v", err)
+		assert.False(t, ok, "Expected second SetNX not to set the entry")
+
+		value, _ := lCache.Get(ctx, "key")
+		assert.Equal(t, "first", value, "Expected first value to win, but got: %v", value)
+	})
+	t.Run("Should successfully get part of cache entry ", func(t *testing.T) {
+		defer lCache.Del(ctx, "key")
+
+		_ = lCache.Set(ctx, "key", "abcdefgh", 10*time.Second)
+
+		chunk, err := lCache.GetRange(ctx, "key", 2, 3)
+		assert.Nil(t, err, "Expected to get cache entry range without error, but got: %v", err)
+		assert.Equal(t, "cde", chunk, "Expected range 'cde', but got: %v", chunk)
+
+		chunk, err = lCache.GetRange(ctx, "key", 6, 10)
+		assert.Nil(t, err, "Expected to get cache entry range without error, but got: %v", err)
+		assert.Equal(t, "gh", chunk, "Expected range 'gh', but got: %v", chunk)
+	})
+	t.Run("Should successfully get remaining ttl of cache entry ", func(t *testing.T) {
+		defer lCache.Del(ctx, "key")
+
+		_ = lCache.Set(ctx, "key", "test", 10*time.Second)
+
+		ttl, err := lCache.GetTTL(ctx, "key")
+
+		assert.Nil(t, err, "Expected to get ttl without error, but got: %v", err)
+		assert.True(
+			t,
+			ttl > 0 && ttl <= 10*time.Second,
+			"Expected ttl between 0 and 10s, but got: %v",
+			ttl,
+		)
+	})
+	t.Run("Should successfully persist cache entry ", func(t *testing.T) {
+		defer lCache.Del(ctx, "key")
+
+		_ = lCache.Set(ctx, "key", "test", 10*time.Second)
+
+		err := lCache.Persist(ctx, "key")
+		assert.Nil(t, err, "Expected to persist cache entry without error, but got: %v", err)
+
+		ttl, err := lCache.GetTTL(ctx, "key")
+		assert.Nil(t, err, "Expected to get ttl without error, but got: %v", err)
+		assert.Equal(t, lPCache.NoTTL, ttl, "Expected NoTTL, but got: %v", ttl)
+	})
+	t.Run("Should successfully pin and unpin cache entry ", func(t *testing.T) {
+		defer lCache.Del(ctx, "key")
+
+		_ = lCache.Set(ctx, "key", "test", 10*time.Second)
+
+		err := lCache.Pin(ctx, "key")
+		assert.Nil(t, err, "Expected to pin cache entry without error, but got: %v", err)
+
+		err = lCache.Unpin(ctx, "key")
+		assert.Nil(t, err, "Expected to unpin cache entry without error, but got: %v", err)
+
+		err = lCache.Pin(ctx, "missing")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected ErrKeyNotFound for missing key")
+	})
+	t.Run("Should successfully rename cache entry ", func(t *testing.T) {
+		defer lCache.Del(ctx, "key")
+
+		_ = lCache.Set(ctx, "staged", "test", 10*time.Second)
+		_ = lCache.Set(ctx, "key", "old", 10*time.Second)
+
+		err := lCache.Rename(ctx, "staged", "key")
+		assert.Nil(t, err, "Expected to rename cache entry without error, but got: %v", err)
+
+		value, err := lCache.Get(ctx, "key")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "test", value, "Expected the value of the renamed entry")
+
+		_, err = lCache.Get(ctx, "staged")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the old key to be removed")
+
+		err = lCache.Rename(ctx, "missing", "key")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected ErrKeyNotFound for missing key")
+	})
+	t.Run("Should successfully delete cache entries by prefix and pattern ", func(t *testing.T) {
+		_ = lCache.Set(ctx, "user:1:name", "test", 10*time.Second)
+		_ = lCache.Set(ctx, "user:1:email", "test", 10*time.Second)
+		_ = lCache.Set(ctx, "user:2:name", "test", 10*time.Second)
+		defer lCache.Del(ctx, "user:2:name")
+
+		deleted, err := lCache.DelPrefix(ctx, "user:1:")
+		assert.Nil(t, err, "Expected to delete cache entries without error, but got: %v", err)
+		assert.Equal(t, int64(2), deleted, "Expected the entries with the prefix to be deleted")
+
+		deleted, err = lCache.DelPattern(ctx, "user:*:name")
+		assert.Nil(t, err, "Expected to delete cache entries without error, but got: %v", err)
+		assert.Equal(t, int64(1), deleted, "Expected the entries matching the pattern to be deleted")
+	})
+	t.Run("Should successfully append to cache entry ", func(t *testing.T) {
+		defer lCache.Del(ctx, "key")
+
+		_ = lCache.Set(ctx, "key", "test", 10*time.Second)
+
+		err := lCache.Append(ctx, "key", "-appended")
+		assert.Nil(t, err, "Expected to append without error, but got: %v", err)
+
+		value, _ := lCache.Get(ctx, "key")
+		assert.Equal(t, "test-appended", value, "Expected appended value, but got: %v", value)
+
+		err = lCache.Append(ctx, "missing", "-appended")
+		assert.Equal(t, lPCache.ErrKeyNotFound, err, "Expected 'key not found', but got: %v", err)
+	})
+	t.Run("Should successfully list cache keys by pattern ", func(t *testing.T) {
+		defer lCache.Del(ctx, "user:1")
+		defer lCache.Del(ctx, "user:2")
+		defer lCache.Del(ctx, "session:1")
+
+		_ = lCache.Set(ctx, "user:1", "test", 10*time.Second)
+		_ = lCache.Set(ctx, "user:2", "test", 10*time.Second)
+		_ = lCache.Set(ctx, "session:1", "test", 10*time.Second)
+
+		keys, err := lCache.Keys(ctx, "user:*")
+
+		assert.Nil(t, err, "Expected to list keys without error, but got: %v", err)
+		assert.Equal(t, []string{"user:1", "user:2"}, keys, "Expected user keys, but got: %v", keys)
+	})
+	t.Run("Should successfully flush the cache ", func(t *testing.T) {
+		_ = lCache.Set(ctx, "key1", "test", 10*time.Second)
+		_ = lCache.Set(ctx, "key2", "test", 10*time.Second)
+
+		err := lCache.Flush(ctx)
+		assert.Nil(t, err, "Expected to flush cache without error, but got: %v", err)
+
+		keys, _ := lCache.Keys(ctx, "")
+		assert.Empty(t, keys, "Expected no keys after flush, but got: %v", keys)
+	})
+	t.Run("Should successfully count cache entries ", func(t *testing.T) {
+		defer lCache.Flush(ctx)
+
+		_ = lCache.Set(ctx, "key1", "test", 10*time.Second)
+		_ = lCache.Set(ctx, "key2", "test", 10*time.Second)
+
+		count, err := lCache.Count(ctx)
+
+		assert.Nil(t, err, "Expected to count entries without error, but got: %v", err)
+		assert.Equal(t, int64(2), count, "Expected 2 entries, but got: %v", count)
+	})
+	t.Run("Should successfully report cache stats ", func(t *testing.T) {
+		defer lCache.Flush(ctx)
+
+		_ = lCache.Set(ctx, "key1", "value", 10*time.Second)
+		_ = lCache.Set(ctx, "key2", "value", 10*time.Second)
+
+		stats, err := lCache.Stats(ctx)
+
+		assert.Nil(t, err, "Expected to get stats without error, but got: %v", err)
+		assert.Equal(t, int64(2), stats.Entries, "Expected 2 entries, but got: %v", stats.Entries)
+		assert.Equal(t, int64(10), stats.ValueBytes, "Expected 10 bytes, but got: %v", stats.ValueBytes)
+		assert.Greater(t, stats.DBSize, int64(0), "Expected the database size to be reported")
+		assert.Greater(t, stats.PageSize, int64(0), "Expected the page size to be reported")
+	})
+	t.Run("Should successfully track cache metrics ", func(t *testing.T) {
+		defer lCache.Flush(ctx)
+
+		before := lCache.Metrics()
+
+		_ = lCache.Set(ctx, "key1", "value", 10*time.Second)
+		_, _ = lCache.Get(ctx, "key1")
+		_, _ = lCache.Get(ctx, "missing")
+		_ = lCache.Del(ctx, "key1")
+
+		after := lCache.Metrics()
+
+		assert.Equal(t, int64(1), after.Sets-before.Sets, "Expected one set")
+		assert.Equal(t, int64(1), after.Hits-before.Hits, "Expected one hit")
+		assert.Equal(t, int64(1), after.Misses-before.Misses, "Expected one miss")
+		assert.Equal(t, int64(1), after.Deletes-before.Deletes, "Expected one delete")
+	})
+	t.Run("Should successfully set and get binary values ", func(t *testing.T) {
+		defer lCache.Flush(ctx)
+
+		value := []byte{0x1f, 0x8b, 0x00, 0xff, 0x00}
+
+		err := lCache.SetBytes(ctx, "binary", value, 10*time.Second)
+		assert.Nil(t, err, "Expected to set binary value without error, but got: %v", err)
+
+		got, err := lCache.GetBytes(ctx, "binary")
+
+		assert.Nil(t, err, "Expected to get binary value without error, but got: %v", err)
+		assert.Equal(t, value, got, "Expected the binary value to round-trip unchanged")
+	})
+	t.Run("Should successfully set and get encoded values ", func(t *testing.T) {
+		defer lCache.Flush(ctx)
+
+		type user struct {
+			Name string
+			Age  int
+		}
+
+		err := lCache.SetValue(ctx, "user:1", user{Name: "John", Age: 30}, 10*time.Second)
+		assert.Nil(t, err, "Expected to set value without error, but got: %v", err)
+
+		var got user
+		err = lCache.GetValue(ctx, "user:1", &got)
+
+		assert.Nil(t, err, "Expected to get value without error, but got: %v", err)
+		assert.Equal(t, user{Name: "John", Age: 30}, got, "Expected the value to round-trip unchanged")
+	})
+	t.Run("Should isolate the keys of namespaces ", func(t *testing.T) {
+		defer lCache.Flush(ctx)
+
+		users := lCache.Namespace("users")
+		orders := lCache.Namespace("orders")
+
+		_ = users.Set(ctx, "1", "John", 10*time.Second)
+		_ = users.Set(ctx, "2", "Jane", 10*time.Second)
+		_ = orders.Set(ctx, "1", "order", 10*time.Second)
+
+		value, err := users.Get(ctx, "1")
+		assert.Nil(t, err, "Expected to get namespaced value without error, but got: %v", err)
+		assert.Equal(t, "John", value, "Expected the value of the users namespace")
+
+		keys, err := users.Keys(ctx, "")
+		assert.Nil(t, err, "Expected to list namespaced keys without error, but got: %v", err)
+		assert.Equal(t, []string{"1", "2"}, keys, "Expected only the keys of the users namespace")
+
+		err = users.Flush(ctx)
+		assert.Nil(t, err, "Expected to flush the namespace without error, but got: %v", err)
+
+		count, err := users.Count(ctx)
+		assert.Nil(t, err, "Expected to count namespaced entries without error, but got: %v", err)
+		assert.Equal(t, int64(0), count, "Expected the users namespace to be empty")
+
+		value, err = orders.Get(ctx, "1")
+		assert.Nil(t, err, "Expected the orders namespace to be kept, but got: %v", err)
+		assert.Equal(t, "order", value, "Expected the value of the orders namespace")
+	})
+	t.Run("Should successfully update values with versions ", func(t *testing.T) {
+		defer lCache.Flush(ctx)
+
+		err := lCache.SetIfVersion(ctx, "counter", "1", 0, 10*time.Second)
+		assert.Nil(t, err, "Expected to create the entry without error, but got: %v", err)
+
+		value, version, err := lCache.GetWithVersion(ctx, "counter")
+		assert.Nil(t, err, "Expected to get the entry without error, but got: %v", err)
+		assert.Equal(t, "1", value, "Expected the created value")
+
+		err = lCache.SetIfVersion(ctx, "counter", "2", version, 10*time.Second)
+		assert.Nil(t, err, "Expected to update the entry without error, but got: %v", err)
+
+		err = lCache.SetIfVersion(ctx, "counter", "3", version, 10*time.Second)
+		assert.ErrorIs(t, err, lPCache.ErrVersionMismatch, "Expected a stale version to be rejected")
+
+		value, newVersion, err := lCache.GetWithVersion(ctx, "counter")
+		assert.Nil(t, err, "Expected to get the entry without error, but got: %v", err)
+		assert.Equal(t, "2", value, "Expected the value of the accepted update")
+		assert.Greater(t, newVersion, version, "Expected the version to increase")
+	})
+}
+
+func TestCacheWithMemoryTier(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithMemoryTier(100, 1024*1024),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Destroy(ctx)
+
+	t.Run("Should serve values written through the memory tier ", func(t *testing.T) {
+		defer lCache.Flush(ctx)
+
+		_ = lCache.Set(ctx, "key", "test", 10*time.Second)
+
+		value, err := lCache.Get(ctx, "key")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "test", value, "Expected the value written through the memory tier")
+
+		stats, err := lCache.Stats(ctx)
+		assert.Nil(t, err, "Expected to get stats without error, but got: %v", err)
+		assert.Equal(t, int64(1), stats.MemoryEntries, "Expected the entry to be kept in memory")
+	})
+
+	t.Run("Should not serve deleted values from the memory tier ", func(t *testing.T) {
+		_ = lCache.Set(ctx, "key", "test", 10*time.Second)
+		_ = lCache.Del(ctx, "key")
+
+		_, err := lCache.Get(ctx, "key")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the deleted key to be missing")
+	})
+}
+
+func TestCacheInMemory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should keep entries in memory without creating files ", func(t *testing.T) {
+		dir := t.TempDir()
+		wd, err := os.Getwd()
+		assert.Nil(t, err, "Expected to get the working directory without error, but got: %v", err)
+		assert.Nil(t, os.Chdir(dir), "Expected to change the working directory")
+		defer os.Chdir(wd)
+
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+		assert.Nil(t, err, "Expected to create the in-memory cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		err = lCache.Set(ctx, "key", "test", 10*time.Second)
+		assert.Nil(t, err, "Expected to set cache entry without error, but got: %v", err)
+
+		value, err := lCache.Get(ctx, "key")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "test", value, "Expected the value stored in memory")
+
+		files, err := os.ReadDir(dir)
+		assert.Nil(t, err, "Expected to read the directory without error, but got: %v", err)
+		assert.Empty(t, files, "Expected no database files on disk")
+	})
+
+	t.Run("Should isolate in-memory caches ", func(t *testing.T) {
+		first, err := lPCache.NewCache(ctx, lPCache.WithPath(":memory:"))
+		assert.Nil(t, err, "Expected to create the first cache without error, but got: %v", err)
+		defer first.Destroy(ctx)
+
+		second, err := lPCache.NewCache(ctx, lPCache.WithPath(":memory:"))
+		assert.Nil(t, err, "Expected to create the second cache without error, but got: %v", err)
+		defer second.Destroy(ctx)
+
+		_ = first.Set(ctx, "key", "test", 10*time.Second)
+
+		_, err = second.Get(ctx, "key")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the caches not to share entries")
+	})
+
+	t.Run("Should share a named in-memory cache ", func(t *testing.T) {
+		uri := "file:lpack_cache_uri_test?mode=memory&cache=shared"
+		first, err := lPCache.NewCache(ctx, lPCache.WithPath(uri))
+		assert.Nil(t, err, "Expected to create the first cache without error, but got: %v", err)
+		defer first.Destroy(ctx)
+
+		second, err := lPCache.NewCache(ctx, lPCache.WithPath(uri))
+		assert.Nil(t, err, "Expected to create the second cache without error, but got: %v", err)
+		defer second.Destroy(ctx)
+
+		err = first.Set(ctx, "key", "test", 10*time.Second)
+		assert.Nil(t, err, "Expected to set cache entry without error, but got: %v", err)
+
+		value, err := second.Get(ctx, "key")
+		assert.Nil(t, err, "Expected the caches to share entries, but got: %v", err)
+		assert.Equal(t, "test", value)
+	})
+}
+
+func TestCacheWithMaxCacheBytes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should schedule the budget purge ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(
+			ctx,
+			lPCache.WithInMemory(),
+			lPCache.WithMaxCacheBytes(1024),
+		)
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		names := []string{}
+		for _, task := range lCache.SchedulerStats(ctx) {
+			names = append(names, task.Name)
+		}
+		assert.Contains(t, names, "purge-budget", "Expected the budget purge to be scheduled")
+	})
+}
+
+func TestCacheWithPurgeSchedule(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should schedule the watermark purge ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(
+			ctx,
+			lPCache.WithInMemory(),
+			lPCache.WithPurgeSchedule("*/5 * * * *", 0.8),
+		)
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		names := []string{}
+		for _, task := range lCache.SchedulerStats(ctx) {
+			names = append(names, task.Name)
+		}
+		assert.Contains(t, names, "purge-watermark", "Expected the watermark purge to be scheduled")
+	})
+}
+
+func TestCacheWithHooks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should call the hooks on writes and deletes ", func(t *testing.T) {
+		var set, deleted []string
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory(), lPCache.WithHooks(lPCache.Hooks{
+			OnSet:    func(_ context.Context, key string) { set = append(set, key) },
+			OnDelete: func(_ context.Context, key string) { deleted = append(deleted, key) },
+		}))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		_ = lCache.Set(ctx, "key", "test", 10*time.Second)
+		_ = lCache.Namespace("users").Set(ctx, "1", "John", 10*time.Second)
+		_ = lCache.Del(ctx, "key")
+
+		assert.Equal(t, []string{"key", "users:1"}, set, "Expected OnSet for each write")
+		assert.Equal(t, []string{"key"}, deleted, "Expected OnDelete for the delete")
+	})
+
+	t.Run("Should call OnExpire with the expired keys ", func(t *testing.T) {
+		dir := t.TempDir()
+
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(dir))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		_ = lCache.Set(ctx, "expired", "test", time.Millisecond)
+		_ = lCache.Set(ctx, "live", "test", time.Minute)
+		assert.Nil(t, lCache.Close(ctx), "Expected to close the cache without error")
+
+		time.Sleep(5 * time.Millisecond)
+
+		expired := make(chan []string, 1)
+		lCache, err = lPCache.NewCache(ctx, lPCache.WithPath(dir), lPCache.WithHooks(lPCache.Hooks{
+			OnExpire: func(_ context.Context, keys []string) { expired <- keys },
+		}))
+		assert.Nil(t, err, "Expected to reopen the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		select {
+		case keys := <-expired:
+			assert.Equal(t, []string{"expired"}, keys, "Expected OnExpire with the expired key")
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected OnExpire to be called by the expired entries purge")
+		}
+	})
+}
+
+func TestCacheWithQueryHook(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should call the query hook with the queries of the operations ", func(t *testing.T) {
+		var mu sync.Mutex
+		var queries []string
+		hook := func(_ context.Context, query string, _ []interface{}, _ time.Duration, _ error) {
+			mu.Lock()
+			defer mu.Unlock()
+			queries = append(queries, query)
+		}
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory(), lPCache.WithQueryHook(hook))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		mu.Lock()
+		queries = nil
+		mu.Unlock()
+
+		err = lCache.Set(ctx, "key", "test", 10*time.Second)
+		assert.Nil(t, err, "Expected to set cache entry without error, but got: %v", err)
+		_, err = lCache.Get(ctx, "key")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		joined := strings.Join(queries, "\n")
+		assert.Contains(t, joined, "INSERT INTO cache", "Expected the query of Set")
+		assert.Contains(t, joined, "FROM cache", "Expected the query of Get")
+	})
+}
+
+func TestCacheSetAsync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should store the async writes after a flush ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		for i := range 100 {
+			err = lCache.SetAsync(ctx, "key", fmt.Sprintf("value-%d", i), time.Minute)
+			assert.Nil(t, err, "Expected to queue the write without error, but got: %v", err)
+		}
+
+		err = lCache.FlushAsync(ctx)
+		assert.Nil(t, err, "Expected to flush the writes without error, but got: %v", err)
+
+		value, err := lCache.Get(ctx, "key")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "value-99", value, "Expected the latest queued value")
+	})
+
+	t.Run("Should store the async writes on close ", func(t *testing.T) {
+		dir := t.TempDir()
+
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(dir))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		_ = lCache.SetAsync(ctx, "key", "test", time.Minute)
+		assert.Nil(t, lCache.Close(ctx), "Expected to close the cache without error")
+
+		lCache, err = lPCache.NewCache(ctx, lPCache.WithPath(dir))
+		assert.Nil(t, err, "Expected to reopen the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		value, err := lCache.Get(ctx, "key")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "test", value, "Expected the value written on close")
+	})
+}
+
+func TestCacheWithBufferedAccessUpdates(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should store the buffered access times on close ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(
+			ctx,
+			lPCache.WithPath(t.TempDir()),
+			lPCache.WithBufferedAccessUpdates(),
+		)
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+
+		_ = lCache.Set(ctx, "key", "test", time.Minute)
+		for range 10 {
+			value, err := lCache.Get(ctx, "key")
+			assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+			assert.Equal(t, "test", value, "Expected the stored value")
+		}
+
+		err = lCache.Close(ctx)
+		assert.Nil(t, err, "Expected to close the cache without error, but got: %v", err)
+	})
+}
+
+func TestCacheBatch(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+	assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+	defer lCache.Destroy(ctx)
+
+	t.Run("Should apply the batch in a single transaction ", func(t *testing.T) {
+		_ = lCache.Set(ctx, "stale", "test", time.Minute)
+
+		batch := lCache.Batch()
+		for i := range 1000 {
+			batch.Set(fmt.Sprintf("key:%d", i), fmt.Sprintf("value-%d", i), time.Minute)
+		}
+		batch.Del("stale")
+
+		err := batch.Exec(ctx)
+		assert.Nil(t, err, "Expected to execute the batch without error, but got: %v", err)
+
+		count, err := lCache.Count(ctx)
+		assert.Nil(t, err, "Expected to count entries without error, but got: %v", err)
+		assert.Equal(t, int64(1000), count, "Expected the entries of the batch")
+
+		value, err := lCache.Get(ctx, "key:999")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "value-999", value, "Expected the value set by the batch")
+	})
+}
+
+func TestCacheExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	source, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+	assert.Nil(t, err, "Expected to create the source cache without error, but got: %v", err)
+	defer source.Destroy(ctx)
+
+	target, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+	assert.Nil(t, err, "Expected to create the target cache without error, but got: %v", err)
+	defer target.Destroy(ctx)
+
+	t.Run("Should move the entries to another cache ", func(t *testing.T) {
+		for i := range 1500 {
+			_ = source.Set(ctx, fmt.Sprintf("key:%d", i), fmt.Sprintf("value-%d", i), time.Minute)
+		}
+		_ = source.Set(ctx, "config", "test", time.Minute)
+		_ = source.Persist(ctx, "config")
+
+		var dump bytes.Buffer
+		err := source.Export(ctx, &dump)
+		assert.Nil(t, err, "Expected to export the cache without error, but got: %v", err)
+
+		err = target.Import(ctx, &dump)
+		assert.Nil(t, err, "Expected to import the cache without error, but got: %v", err)
+
+		count, err := target.Count(ctx)
+		assert.Nil(t, err, "Expected to count entries without error, but got: %v", err)
+		assert.Equal(t, int64(1501), count, "Expected the entries of the source cache")
+
+		value, err := target.Get(ctx, "key:1499")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "value-1499", value, "Expected the value of the source cache")
+
+		ttl, err := target.GetTTL(ctx, "config")
+		assert.Nil(t, err, "Expected to get the TTL without error, but got: %v", err)
+		assert.Equal(t, lPCache.NoTTL, ttl, "Expected the entry to never expire")
+	})
+}
+
+func TestCacheSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+	assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+	defer lCache.Destroy(ctx)
+
+	t.Run("Should copy the cache to a database that can be opened ", func(t *testing.T) {
+		_ = lCache.Set(ctx, "key", "test", time.Minute)
+
+		dir := t.TempDir()
+		err := lCache.Snapshot(ctx, filepath.Join(dir, "lpack_cache.db"))
+		assert.Nil(t, err, "Expected to take the snapshot without error, but got: %v", err)
+
+		_ = lCache.Set(ctx, "later", "test", time.Minute)
+
+		snapshot, err := lPCache.NewCache(ctx, lPCache.WithPath(dir))
+		assert.Nil(t, err, "Expected to open the snapshot without error, but got: %v", err)
+		defer snapshot.Destroy(ctx)
+
+		value, err := snapshot.Get(ctx, "key")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "test", value, "Expected the value of the snapshot")
+
+		_, err = snapshot.Get(ctx, "later")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected later writes not to be copied")
+	})
+}
+
+func TestCacheQuiesce(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should store the async writes in the database file ", func(t *testing.T) {
+		src := t.TempDir()
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(src))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		err = lCache.SetAsync(ctx, "key", "test", time.Minute)
+		assert.Nil(t, err, "Expected to queue the write without error, but got: %v", err)
+
+		resume, err := lCache.Quiesce(ctx)
+		assert.Nil(t, err, "Expected to quiesce the cache without error, but got: %v", err)
+
+		// Only the database file is copied, the WAL is left behind.
+		file, err := os.ReadFile(filepath.Join(src, "lpack_cache.db"))
+		resume()
+		assert.Nil(t, err, "Expected to read the database file without error, but got: %v", err)
+
+		dir := t.TempDir()
+		err = os.WriteFile(filepath.Join(dir, "lpack_cache.db"), file, 0o600)
+		assert.Nil(t, err, "Expected to copy the database file without error, but got: %v", err)
+
+		copied, err := lPCache.NewCache(ctx, lPCache.WithPath(dir))
+		assert.Nil(t, err, "Expected to open the copy without error, but got: %v", err)
+		defer copied.Destroy(ctx)
+
+		value, err := copied.Get(ctx, "key")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "test", value, "Expected the async write in the copy")
+	})
+}
+
+func TestCacheWithWarmup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should pre-populate the cache before it is returned ", func(t *testing.T) {
+		warmup := func(ctx context.Context, set lPCache.Setter) error {
+			return set.Set(ctx, "config", "test", time.Minute)
+		}
+
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory(), lPCache.WithWarmup(warmup))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		value, err := lCache.Get(ctx, "config")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "test", value, "Expected the value set by the warm-up")
+	})
+
+	t.Run("Should pre-populate the keys behind the key prefix ", func(t *testing.T) {
+		warmup := func(ctx context.Context, set lPCache.Setter) error {
+			return set.Set(ctx, "config", "test", time.Minute)
+		}
+
+		lCache, err := lPCache.NewCache(
+			ctx,
+			lPCache.WithInMemory(),
+			lPCache.WithKeyPrefix("svc-a:"),
+			lPCache.WithWarmup(warmup),
+		)
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		value, err := lCache.Get(ctx, "config")
+		assert.Nil(t, err, "Expected to get the warmed up key through the prefix, but got: %v", err)
+		assert.Equal(t, "test", value, "Expected the value set by the warm-up")
+	})
+
+	t.Run("Should fail to create the cache if the warm-up fails ", func(t *testing.T) {
+		warmup := func(context.Context, lPCache.Setter) error {
+			return fmt.Errorf("upstream unavailable")
+		}
+
+		_, err := lPCache.NewCache(ctx, lPCache.WithInMemory(), lPCache.WithWarmup(warmup))
+		assert.EqualError(t, err, "warming up cache: upstream unavailable")
+	})
+
+	t.Run("Should pre-populate the cache in the background ", func(t *testing.T) {
+		done := make(chan struct{})
+		warmup := func(ctx context.Context, set lPCache.Setter) error {
+			defer close(done)
+			return set.Set(ctx, "config", "test", time.Minute)
+		}
+
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory(), lPCache.WithAsyncWarmup(warmup))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Destroy(ctx)
+
+		<-done
+		value, err := lCache.Get(ctx, "config")
+		assert.Nil(t, err, "Expected to get cache entry without error, but got: %v", err)
+		assert.Equal(t, "test", value, "Expected the value set by the warm-up")
+	})
+}
+
+func TestCacheClose(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should wait for the background jobs before closing ", func(t *testing.T) {
+		stopped := make(chan struct{})
+		warmup := func(ctx context.Context, _ lPCache.Setter) error {
+			<-ctx.Done()
+			close(stopped)
+			return ctx.Err()
+		}
+
+		lCache, err := lPCache.NewCache(
+			ctx,
+			lPCache.WithPath(t.TempDir()),
+			lPCache.WithAsyncWarmup(warmup),
+		)
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+
+		err = lCache.Close(ctx)
+		assert.Nil(t, err, "Expected to close the cache without error, but got: %v", err)
+
+		select {
+		case <-stopped:
+		default:
+			t.Fatal("Expected the warm-up to be stopped before Close returns")
+		}
+	})
+
+	t.Run("Should be closed by the manager it is registered in ", func(t *testing.T) {
+		manager := litepack.NewManager()
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()), lPCache.WithManager(manager))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		assert.Len(t, manager.Stores(), 1, "Expected the cache to be registered in the manager")
+
+		err = manager.CloseAll(ctx)
+		assert.Nil(t, err, "Expected to close the cache without error, but got: %v", err)
+
+		err = lCache.Set(ctx, "key", "value", time.Minute)
+		assert.Error(t, err, "Expected the cache to be closed")
+	})
+
+	t.Run("Should not be registered in the default manager ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+		defer lCache.Close(ctx)
+
+		assert.Empty(t, litepack.Default().Stores(), "Expected no implicit registration")
+	})
+
+	t.Run("Should unregister itself from the manager when closed ", func(t *testing.T) {
+		manager := litepack.NewManager()
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()), lPCache.WithManager(manager))
+		assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+
+		err = lCache.Close(ctx)
+		assert.Nil(t, err, "Expected to close the cache without error, but got: %v", err)
+
+		assert.Empty(t, manager.Stores(), "Expected the cache to be unregistered")
+	})
+}
+
+func TestCacheCompact(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+	assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+	defer lCache.Destroy(ctx)
+
+	t.Run("Should delete expired entries and vacuum the database ", func(t *testing.T) {
+		// Wait for the expired entries purge run on startup, so it does not race with Compact.
+		assert.Eventually(t, func() bool {
+			for _, task := range lCache.SchedulerStats(ctx) {
+				if task.Name == "purge-expired" {
+					return task.Runs > 0
+				}
+			}
+			return false
+		}, time.Second, time.Millisecond, "Expected the startup purge to run")
+
+		value := strings.Repeat("x", 1024)
+		for i := range 500 {
+			_ = lCache.Set(ctx, fmt.Sprintf("key:%d", i), value, time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		stats, err := lCache.Compact(ctx)
+		assert.Nil(t, err, "Expected to compact the cache without error, but got: %v", err)
+		assert.Equal(t, int64(500), stats.ExpiredEntries, "Expected the expired entries to be deleted")
+		assert.Less(t, stats.SizeAfter, stats.SizeBefore, "Expected the database to shrink")
+		assert.Len(t, stats.Steps, 4, "Expected every step to run")
+	})
+}
+
+func TestCacheWithAutoVacuumIncremental(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithAutoVacuumIncremental(1000),
+	)
+	assert.Nil(t, err, "Expected to create the cache without error, but got: %v", err)
+	defer lCache.Destroy(ctx)
+
+	t.Run("Should return the free pages to the file system ", func(t *testing.T) {
+		value := strings.Repeat("x", 1024)
+		for i := range 500 {
+			_ = lCache.Set(ctx, fmt.Sprintf("key:%d", i), value, time.Minute)
+		}
+		_ = lCache.Flush(ctx)
+
+		before, err := lCache.Stats(ctx)
+		assert.Nil(t, err, "Expected to get the stats without error, but got: %v", err)
+		assert.Positive(t, before.FreePages, "Expected free pages after the flush")
+
+		err = lCache.IncrementalVacuum(ctx, 1000)
+		assert.Nil(t, err, "Expected to vacuum without error, but got: %v", err)
+
+		after, err := lCache.Stats(ctx)
+		assert.Nil(t, err, "Expected to get the stats without error, but got: %v", err)
+		assert.Zero(t, after.FreePages, "Expected the free pages to be returned")
+		assert.Less(t, after.DBSize, before.DBSize, "Expected the database to shrink")
+	})
+}
+
+func TestCacheWithLogger(t *testing.T) {
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	logger := lPCache.NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()), lPCache.WithLogger(logger))
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should not create the log table ", func(t *testing.T) {
+		var count int
+		err := lCache.GetEngine(ctx).
+			QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'log'").
+			Scan(&count)
+
+		assert.Nil(t, err, "Expected to query the schema without error, but got: %v", err)
+		assert.Zero(t, count, "Expected no log table")
+	})
+}
+
+func TestCachePing(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+	if err != nil {
+		panic(err)
+	}
+
+	t.Run("Should ping an open cache ", func(t *testing.T) {
+		err := lCache.Ping(ctx)
+
+		assert.Nil(t, err, "Expected to ping the cache without error, but got: %v", err)
+	})
+
+	t.Run("Should fail to ping a closed cache ", func(t *testing.T) {
+		_ = lCache.Close(ctx)
+
+		err := lCache.Ping(ctx)
+
+		assert.NotNil(t, err, "Expected an error when pinging a closed cache")
+	})
+}
+
+func TestCacheGetSet(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should return the previous value ", func(t *testing.T) {
+		defer lCache.Del(ctx, "token")
+
+		old, err := lCache.GetSet(ctx, "token", "a", time.Minute)
+		assert.Nil(t, err, "Expected to swap the value without error, but got: %v", err)
+		assert.Empty(t, old, "Expected no previous value")
+
+		old, err = lCache.GetSet(ctx, "token", "b", time.Minute)
+		assert.Nil(t, err, "Expected to swap the value without error, but got: %v", err)
+		assert.Equal(t, "a", old, "Expected the previous value")
+
+		value, _ := lCache.Get(ctx, "token")
+		assert.Equal(t, "b", value, "Expected the new value")
+	})
+
+	t.Run("Should not lose values when swapped concurrently ", func(t *testing.T) {
+		defer lCache.Del(ctx, "token")
+
+		const writers = 20
+		olds := make(chan string, writers)
+		var wg sync.WaitGroup
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				old, err := lCache.GetSet(ctx, "token", strconv.Itoa(i), time.Minute)
+				assert.Nil(t, err, "Expected to swap the value without error, but got: %v", err)
+				olds <- old
+			}()
+		}
+		wg.Wait()
+		close(olds)
+
+		seen := make(map[string]bool)
+		for old := range olds {
+			assert.False(t, seen[old], "Expected each value to be swapped out once: %q", old)
+			seen[old] = true
+		}
+		last, _ := lCache.Get(ctx, "token")
+		assert.False(t, seen[last], "Expected the last value not to be swapped out")
+		assert.Len(t, seen, writers, "Expected every previous value to be returned once")
+	})
+}
+
+func TestCacheUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	incr := func(old []byte, exists bool) ([]byte, time.Duration, error) {
+		count := 0
+		if exists {
+			count, _ = strconv.Atoi(string(old))
+		}
+		return []byte(strconv.Itoa(count + 1)), time.Minute, nil
+	}
+
+	t.Run("Should not lose concurrent updates ", func(t *testing.T) {
+		defer lCache.Del(ctx, "counter")
+
+		const updates = 50
+		var wg sync.WaitGroup
+		for range updates {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := lCache.Update(ctx, "counter", incr)
+				assert.Nil(t, err, "Expected to update the key without error, but got: %v", err)
+			}()
+		}
+		wg.Wait()
+
+		value, err := lCache.Get(ctx, "counter")
+		assert.Nil(t, err, "Expected to get the key without error, but got: %v", err)
+		assert.Equal(t, strconv.Itoa(updates), value, "Expected every update to be applied")
+	})
+}
+
+func TestCacheExpiredKeys(t *testing.T) {
+	ctx := context.Background()
+
+	countRows := func(lCache lPCache.Cache, key string) int {
+		var count int
+		_ = lCache.GetEngine(ctx).
+			QueryRowContext(ctx, "SELECT COUNT(*) FROM cache WHERE key = ?", key).
+			Scan(&count)
+		return count
+	}
+
+	t.Run("Should tell expired keys from missing keys ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+		if err != nil {
+			panic(err)
+		}
+		defer lCache.Close(ctx)
+
+		_ = lCache.Set(ctx, "session", "value", 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		_, err = lCache.Get(ctx, "session")
+		assert.ErrorIs(t, err, lPCache.ErrKeyExpired, "Expected ErrKeyExpired for an expired key")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected ErrKeyExpired to wrap ErrKeyNotFound")
+		assert.Equal(t, 1, countRows(lCache, "session"), "Expected the expired entry to be kept")
+
+		_, err = lCache.Get(ctx, "missing")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected ErrKeyNotFound for a missing key")
+		assert.NotErrorIs(t, err, lPCache.ErrKeyExpired, "Expected a missing key not to be expired")
+	})
+
+	t.Run("Should delete expired keys on read ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx,
+			lPCache.WithPath(t.TempDir()),
+			lPCache.WithDeleteExpiredOnRead(),
+		)
+		if err != nil {
+			panic(err)
+		}
+		defer lCache.Close(ctx)
+
+		_ = lCache.Set(ctx, "session", "value", 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		_, err = lCache.Get(ctx, "session")
+		assert.ErrorIs(t, err, lPCache.ErrKeyExpired, "Expected ErrKeyExpired for an expired key")
+		assert.Zero(t, countRows(lCache, "session"), "Expected the expired entry to be deleted")
+
+		_, err = lCache.Get(ctx, "session")
+		assert.NotErrorIs(t, err, lPCache.ErrKeyExpired, "Expected the deleted key to be missing")
+	})
+}
+
+func TestCacheEvents(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should return ErrEventLogDisabled without an event log ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+		if err != nil {
+			panic(err)
+		}
+		defer lCache.Close(ctx)
+
+		_, err = lCache.Events(ctx, 0)
+
+		assert.ErrorIs(t, err, lPCache.ErrEventLogDisabled)
+	})
+
+	t.Run("Should record the changes of the keyspace in order ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx,
+			lPCache.WithPath(t.TempDir()),
+			lPCache.WithEventLog(time.Hour),
+			lPCache.WithDeleteExpiredOnRead(),
+		)
+		if err != nil {
+			panic(err)
+		}
+		defer lCache.Close(ctx)
+
+		_ = lCache.Set(ctx, "a", "1", time.Minute)
+		_ = lCache.Set(ctx, "b", "2", 10*time.Millisecond)
+		_ = lCache.Del(ctx, "a")
+		time.Sleep(20 * time.Millisecond)
+		_, _ = lCache.Get(ctx, "b")
+		_ = lCache.Flush(ctx)
+
+		events, err := lCache.Events(ctx, 0)
+		assert.NoError(t, err, "Expected no error while getting the events")
+
+		types := make([]string, len(events))
+		for i, event := range events {
+			types[i] = string(event.Type) + " " + event.Key
+		}
+		assert.Equal(t, []string{"set a", "set b", "delete a", "expire b", "flush "}, types)
+
+		events, err = lCache.Events(ctx, events[2].ID)
+		assert.NoError(t, err, "Expected no error while getting the events")
+		assert.Len(t, events, 2, "Expected only the events after the given ID")
+	})
+
+	t.Run("Should scope the events to the namespace ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx,
+			lPCache.WithPath(t.TempDir()),
+			lPCache.WithEventLog(time.Hour),
+		)
+		if err != nil {
+			panic(err)
+		}
+		defer lCache.Close(ctx)
+
+		users := lCache.Namespace("users")
+		_ = users.Set(ctx, "1", "John", time.Minute)
+		_ = lCache.Set(ctx, "other", "value", time.Minute)
+		_ = lCache.Flush(ctx)
+
+		events, err := users.Events(ctx, 0)
+		assert.NoError(t, err, "Expected no error while getting the events")
+		assert.Len(t, events, 2, "Expected the events of the namespace and the flush")
+		assert.Equal(t, lPCache.EventSet, events[0].Type)
+		assert.Equal(t, "1", events[0].Key)
+		assert.Equal(t, lPCache.EventFlush, events[1].Type)
+		assert.Empty(t, events[1].Key, "Expected the flush to cover the whole namespace")
+	})
+}
+
+func TestCacheSlidingTTL(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should keep read entries alive ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx,
+			lPCache.WithPath(t.TempDir()),
+			lPCache.WithSlidingTTL(),
+		)
+		if err != nil {
+			panic(err)
+		}
+		defer lCache.Close(ctx)
+
+		_ = lCache.Set(ctx, "session", "token", 200*time.Millisecond)
+		_ = lCache.Set(ctx, "idle", "token", 200*time.Millisecond)
+
+		for i := 0; i < 3; i++ {
+			time.Sleep(100 * time.Millisecond)
+			_, err = lCache.Get(ctx, "session")
+			assert.NoError(t, err, "Expected the read entry to be alive")
+		}
+
+		_, err = lCache.Get(ctx, "idle")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the idle entry to expire")
+	})
+
+	t.Run("Should not slide persisted entries ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx,
+			lPCache.WithPath(t.TempDir()),
+			lPCache.WithSlidingTTL(),
+		)
+		if err != nil {
+			panic(err)
+		}
+		defer lCache.Close(ctx)
+
+		_ = lCache.Set(ctx, "config", "value", time.Minute)
+		_ = lCache.Persist(ctx, "config")
+		_, _ = lCache.Get(ctx, "config")
+
+		ttl, err := lCache.GetTTL(ctx, "config")
+		assert.NoError(t, err, "Expected no error while getting the ttl")
+		assert.Equal(t, lPCache.NoTTL, ttl, "Expected the entry to keep never expiring")
+	})
+}
+
+func TestCachePurgeQueryPlan(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should read the least recently used entries from the index ", func(t *testing.T) {
+		rows, err := lCache.GetEngine(ctx).QueryContext(ctx, `EXPLAIN QUERY PLAN
+			SELECT key FROM cache WHERE pinned = 0 ORDER BY last_accessed_at ASC LIMIT 10`)
+		assert.NoError(t, err, "Expected no error while explaining the purge query")
+		defer rows.Close()
+
+		var plan []string
+		for rows.Next() {
+			var id, parent, notUsed int
+			var detail string
+			assert.NoError(t, rows.Scan(&id, &parent, &notUsed, &detail))
+			plan = append(plan, detail)
+		}
+
+		joined := strings.Join(plan, "\n")
+		assert.Contains(t, joined, "idx_last_accessed_at", "Expected the purge to use the index")
+		assert.NotContains(t, joined, "TEMP B-TREE", "Expected the purge not to sort the table")
+	})
+}
+
+func TestCacheNamespaceOptions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should use the default TTL of the namespace ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+		if err != nil {
+			panic(err)
+		}
+		defer lCache.Close(ctx)
+
+		thumbs := lCache.Namespace("thumbs", lPCache.WithNamespaceDefaultTTL(time.Hour))
+		_ = thumbs.Set(ctx, "1", "data", 0)
+		_ = lCache.Namespace("thumbs").Set(ctx, "2", "data", time.Minute)
+
+		ttl, err := thumbs.GetTTL(ctx, "1")
+		assert.NoError(t, err, "Expected the entry to use the default TTL")
+		assert.InDelta(t, time.Hour, ttl, float64(time.Second))
+
+		ttl, err = thumbs.GetTTL(ctx, "2")
+		assert.NoError(t, err, "Expected the entry to use the given TTL")
+		assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+	})
+
+	t.Run("Should schedule the budget purge of the namespaces once ", func(t *testing.T) {
+		lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+		if err != nil {
+			panic(err)
+		}
+		defer lCache.Close(ctx)
+
+		lCache.Namespace("thumbs", lPCache.WithNamespaceMaxBytes(1024))
+		lCache.Namespace("avatars", lPCache.WithNamespaceMaxBytes(2048))
+
+		budgetTasks := 0
+		for _, task := range lCache.SchedulerStats(ctx) {
+			if task.Name == "purge-budget" {
+				budgetTasks++
+			}
+		}
+		assert.Equal(t, 1, budgetTasks, "Expected the budget purge to be scheduled once")
+	})
+}
+
+func TestCacheDatabaseOptions(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithPageSize(8192),
+		lPCache.WithSQLiteCacheSize(8192*128),
+		lPCache.WithMaxDBSize(8192*1024),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	pragma := func(name string) int64 {
+		var value int64
+		err := lCache.GetEngine(ctx).QueryRowContext(ctx, "PRAGMA "+name).Scan(&value)
+		assert.NoError(t, err, "Expected no error while reading the pragma")
+		return value
+	}
+
+	t.Run("Should size the database with the options", func(t *testing.T) {
+		assert.Equal(t, int64(8192), pragma("page_size"))
+		assert.Equal(t, int64(1024), pragma("max_page_count"))
+
+		stats, err := lCache.Stats(ctx)
+		assert.NoError(t, err, "Expected no error while getting the stats")
+		assert.Equal(t, int64(8192*1024), stats.MaxDBSize)
+	})
+}
+
+func TestCacheClock(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()), lPCache.WithClock(clock))
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should expire the entries with the clock", func(t *testing.T) {
+		err := lCache.Set(ctx, "clock:key", "value", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		advance(30 * time.Second)
+		value, err := lCache.Get(ctx, "clock:key")
+		assert.NoError(t, err, "Expected the key to be live before its TTL")
+		assert.Equal(t, "value", value)
+
+		ttl, err := lCache.GetTTL(ctx, "clock:key")
+		assert.NoError(t, err, "Expected no error while getting the TTL")
+		assert.Equal(t, 30*time.Second, ttl, "Expected the TTL to follow the clock")
+
+		advance(time.Minute)
+		_, err = lCache.Get(ctx, "clock:key")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the key to expire")
+	})
+}
+
+func TestCacheScheduler(t *testing.T) {
+	ctx := context.Background()
+	scheduler := cron.New(time.UTC)
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithScheduler(scheduler),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should schedule the background jobs on the given scheduler", func(t *testing.T) {
+		scheduled := func() bool {
+			for _, task := range scheduler.Tasks() {
+				if task.Name == "purge-expired" {
+					return true
+				}
+			}
+			return false
+		}
+
+		assert.Eventually(t, scheduled, time.Second, 10*time.Millisecond,
+			"Expected the purge to be scheduled")
+		assert.Len(t, lCache.SchedulerStats(ctx), len(scheduler.Tasks()))
+	})
+
+	t.Run("Should keep the shared scheduler running after Close", func(t *testing.T) {
+		other, err := lPCache.NewCache(
+			ctx,
+			lPCache.WithPath(t.TempDir()),
+			lPCache.WithScheduler(scheduler),
+		)
+		assert.NoError(t, err, "Expected no error while creating the second cache")
+
+		owned := len(lCache.SchedulerStats(ctx))
+		assert.Len(t, scheduler.Tasks(), owned+len(other.SchedulerStats(ctx)))
+
+		err = other.Close(ctx)
+		assert.NoError(t, err, "Expected no error while closing the second cache")
+
+		assert.Len(t, scheduler.Tasks(), owned, "Expected only the tasks of the closed cache to be removed")
+		for _, task := range scheduler.Tasks() {
+			assert.False(t, task.NextRun.IsZero(), "Expected the scheduler to keep running")
+		}
+	})
+}
+
+// operationsObserver counts the operations and the hits reported by the cache.
+type operationsObserver struct {
+	mu   sync.Mutex
+	ops  []string
+	hits int
+}
+
+func (o *operationsObserver) Begin(ctx context.Context, _ lPCache.Operation) context.Context {
+	return ctx
+}
+
+func (o *operationsObserver) End(
+	_ context.Context,
+	op lPCache.Operation,
+	res lPCache.OperationResult,
+) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ops = append(o.ops, op.Name+" "+op.Key)
+	if res.Hit {
+		o.hits++
+	}
+}
+
+func TestCacheInstrumentation(t *testing.T) {
+	ctx := context.Background()
+	observer := &operationsObserver{}
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithInstrumentation(observer),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should report the operations to the observer", func(t *testing.T) {
+		err := lCache.Set(ctx, "key", "value", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		_, err = lCache.Get(ctx, "key")
+		assert.NoError(t, err, "Expected no error while getting the key")
+
+		_, err = lCache.Namespace("users").Get(ctx, "missing")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the key to be missing")
+
+		assert.Equal(t, []string{"Set key", "Get key", "Get missing"}, observer.ops)
+		assert.Equal(t, 1, observer.hits, "Expected one hit")
+	})
+}
+
+func TestCacheSharedFile(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	first, err := lPCache.NewCache(ctx, lPCache.WithPath(path))
+	if err != nil {
+		panic(err)
+	}
+	defer first.Close(ctx)
+
+	second, err := lPCache.NewCache(ctx, lPCache.WithPath(path))
+	if err != nil {
+		panic(err)
+	}
+	defer second.Close(ctx)
+
+	t.Run("Should not fail the concurrent writes of caches sharing the file", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 200)
+		for i, lCache := range []lPCache.Cache{first, second} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 100 {
+					key := fmt.Sprintf("shared:%d:%d", i, j)
+					errs <- lCache.Set(ctx, key, "value", time.Minute)
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			assert.NoError(t, err, "Expected the writes to wait for the lock")
+		}
+
+		count, err := first.Count(ctx)
+		assert.NoError(t, err, "Expected no error while counting the keys")
+		assert.Equal(t, int64(200), count)
+	})
+}
+
+func TestCacheReadPool(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()), lPCache.WithReadPool(2))
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should not stall the reads while a write is in progress", func(t *testing.T) {
+		err := lCache.Set(ctx, "read-pool:key", "value", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		err = lCache.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM cache WHERE key = ?", "read-pool:key")
+			assert.NoError(t, err, "Expected no error while deleting the key")
+
+			readCtx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+
+			values, err := lCache.MGet(readCtx, "read-pool:key")
+			assert.NoError(t, err, "Expected the read not to wait for the write")
+			assert.Equal(t, map[string]string{"read-pool:key": "value"}, values)
+
+			value, err := lCache.Get(readCtx, "read-pool:key")
+			assert.NoError(t, err, "Expected the read not to wait for the write")
+			assert.Equal(t, "value", value, "Expected the read to see the committed value")
+			return nil
+		})
+		assert.NoError(t, err, "Expected no error while running the write")
+
+		_, err = lCache.Get(ctx, "read-pool:key")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the committed delete")
+	})
+}
+
+func TestCacheChecksums(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()), lPCache.WithChecksums())
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	corrupt := func(t *testing.T, key string) {
+		err := lCache.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE cache SET value = ? WHERE key = ?", []byte("corrupt"), key)
+			return err
+		})
+		assert.NoError(t, err, "Expected no error while corrupting the value")
+	}
+
+	t.Run("Should return the values matching their checksums", func(t *testing.T) {
+		err := lCache.Set(ctx, "checksum:valid", "value", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		value, err := lCache.Get(ctx, "checksum:valid")
+		assert.NoError(t, err, "Expected no error while getting the key")
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("Should return ErrCorruptValue for a corrupted value", func(t *testing.T) {
+		err := lCache.Set(ctx, "checksum:corrupt", "value", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+		corrupt(t, "checksum:corrupt")
+
+		_, err = lCache.Get(ctx, "checksum:corrupt")
+		assert.ErrorIs(t, err, lPCache.ErrCorruptValue, "Expected the corruption to be detected")
+
+		_, err = lCache.MGet(ctx, "checksum:valid", "checksum:corrupt")
+		assert.ErrorIs(t, err, lPCache.ErrCorruptValue, "Expected the corruption to be detected")
+
+		_, _, err = lCache.GetWithVersion(ctx, "checksum:corrupt")
+		assert.ErrorIs(t, err, lPCache.ErrCorruptValue, "Expected the corruption to be detected")
+	})
+
+	t.Run("Should verify the values changed by Append", func(t *testing.T) {
+		err := lCache.Set(ctx, "checksum:append", "value", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+		err = lCache.Append(ctx, "checksum:append", "-suffix")
+		assert.NoError(t, err, "Expected no error while appending to the key")
+
+		value, err := lCache.Get(ctx, "checksum:append")
+		assert.NoError(t, err, "Expected no error while getting the key")
+		assert.Equal(t, "value-suffix", value)
+
+		corrupt(t, "checksum:append")
+		_, err = lCache.Get(ctx, "checksum:append")
+		assert.ErrorIs(t, err, lPCache.ErrCorruptValue, "Expected the corruption to be detected")
+
+		err = lCache.Append(ctx, "checksum:append", "-suffix")
+		assert.ErrorIs(t, err, lPCache.ErrCorruptValue, "Expected Append to detect the corruption")
+	})
+}
+
+func TestCacheChecksumsReadPool(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithChecksums(),
+		lPCache.WithReadPool(2),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	tamper := func(t *testing.T, key string) {
+		err := lCache.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE cache SET checksum = checksum + 1 WHERE key = ?", key)
+			return err
+		})
+		assert.NoError(t, err, "Expected no error while tampering with the checksum")
+	}
+
+	// returns fails the test if op doesn't return: a write that logs through the
+	// connection held by its own transaction never does.
+	returns := func(t *testing.T, op func() error) error {
+		done := make(chan error, 1)
+		go func() { done <- op() }()
+
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the write to return")
+			return nil
+		}
+	}
+
+	t.Run("Should return ErrCorruptValue from the writes that read in a transaction", func(t *testing.T) {
+		err := lCache.Set(ctx, "read-pool:corrupt", "value", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+		tamper(t, "read-pool:corrupt")
+
+		err = returns(t, func() error {
+			_, err := lCache.GetSet(ctx, "read-pool:corrupt", "new", time.Minute)
+			return err
+		})
+		assert.ErrorIs(t, err, lPCache.ErrCorruptValue, "Expected GetSet to detect the corruption")
+
+		err = returns(t, func() error {
+			return lCache.Update(ctx, "read-pool:corrupt", func(old []byte, _ bool) ([]byte, time.Duration, error) {
+				return old, time.Minute, nil
+			})
+		})
+		assert.ErrorIs(t, err, lPCache.ErrCorruptValue, "Expected Update to detect the corruption")
+
+		err = returns(t, func() error {
+			return lCache.Append(ctx, "read-pool:corrupt", "-suffix")
+		})
+		assert.ErrorIs(t, err, lPCache.ErrCorruptValue, "Expected Append to detect the corruption")
+	})
+}
+
+func TestCacheAppendMaxValueSize(t *testing.T) {
+	ctx := context.Background()
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory(), lPCache.WithMaxValueSize(8))
+	assert.NoError(t, err, "Expected no error while creating the cache")
+	defer lCache.Destroy(ctx)
+
+	t.Run("Should not grow a value past the limit", func(t *testing.T) {
+		err := lCache.Set(ctx, "log", "abc", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		err = lCache.Append(ctx, "log", "defgh")
+		assert.NoError(t, err, "Expected the value to reach the limit")
+
+		err = lCache.Append(ctx, "log", "i")
+		assert.ErrorIs(t, err, lPCache.ErrValueTooLarge, "Expected the value to stay within the limit")
+
+		value, err := lCache.Get(ctx, "log")
+		assert.NoError(t, err, "Expected no error while getting the key")
+		assert.Equal(t, "abcdefgh", value, "Expected the value not to be changed")
+	})
+
+	t.Run("Should return ErrKeyNotFound for a missing key", func(t *testing.T) {
+		err := lCache.Append(ctx, "missing", "a")
+
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound)
+	})
+}
+
+func TestCacheNoTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should keep the entries written with a zero or negative TTL", func(t *testing.T) {
+		err := lCache.Set(ctx, "no-ttl:zero", "value", 0)
+		assert.NoError(t, err, "Expected no error while setting the key")
+		err = lCache.Set(ctx, "no-ttl:none", "value", lPCache.NoTTL)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		now = now.Add(24 * time.Hour)
+		err = lCache.PurgeExpiredItems(ctx)
+		assert.NoError(t, err, "Expected no error while purging the expired entries")
+
+		for _, key := range []string{"no-ttl:zero", "no-ttl:none"} {
+			value, err := lCache.Get(ctx, key)
+			assert.NoError(t, err, "Expected the entry not to expire")
+			assert.Equal(t, "value", value)
+
+			ttl, err := lCache.GetTTL(ctx, key)
+			assert.NoError(t, err, "Expected no error while getting the TTL")
+			assert.Equal(t, lPCache.NoTTL, ttl)
+		}
+	})
+
+	t.Run("Should use the default TTL of a namespace only for a zero TTL", func(t *testing.T) {
+		sessions := lCache.Namespace("sessions", lPCache.WithNamespaceDefaultTTL(time.Minute))
+
+		err := sessions.Set(ctx, "default", "value", 0)
+		assert.NoError(t, err, "Expected no error while setting the key")
+		err = sessions.Set(ctx, "forever", "value", lPCache.NoTTL)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		ttl, err := sessions.GetTTL(ctx, "default")
+		assert.NoError(t, err, "Expected no error while getting the TTL")
+		assert.Equal(t, time.Minute, ttl)
+
+		ttl, err = sessions.GetTTL(ctx, "forever")
+		assert.NoError(t, err, "Expected no error while getting the TTL")
+		assert.Equal(t, lPCache.NoTTL, ttl)
+	})
+}
+
+func TestCacheKeyValidation(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithMaxKeyLength(16),
+		lPCache.WithKeyPattern(regexp.MustCompile(`^[a-z0-9:]+$`)),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should not store the entries of invalid keys", func(t *testing.T) {
+		for _, key := range []string{"", "user 1", "user:0123456789abcdef"} {
+			err := lCache.Set(ctx, key, "value", time.Minute)
+			assert.ErrorIs(t, err, lPCache.ErrInvalidKey, "Expected ErrInvalidKey for %q", key)
+		}
+
+		count, err := lCache.Count(ctx)
+		assert.NoError(t, err, "Expected no error while counting the keys")
+		assert.Equal(t, int64(0), count, "Expected no entry to be stored")
+	})
+
+	t.Run("Should validate the keys with the prefix of their namespace", func(t *testing.T) {
+		users := lCache.Namespace("users")
+
+		err := users.Set(ctx, "1", "value", time.Minute)
+		assert.NoError(t, err, "Expected no error for a valid key")
+
+		err = users.Set(ctx, "0123456789abcdef", "value", time.Minute)
+		assert.ErrorIs(t, err, lPCache.ErrInvalidKey, "Expected the prefix to count in the length")
+	})
+}
+
+func TestCacheSetWithExpireAt(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should expire the entry at the given time", func(t *testing.T) {
+		expireAt := now.Add(time.Hour).In(time.FixedZone("BRT", -3*60*60))
+
+		err := lCache.SetWithExpireAt(ctx, "token", "value", expireAt)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		ttl, err := lCache.GetTTL(ctx, "token")
+		assert.NoError(t, err, "Expected no error while getting the TTL")
+		assert.Equal(t, time.Hour, ttl)
+
+		now = now.Add(time.Hour)
+		_, err = lCache.Get(ctx, "token")
+		assert.ErrorIs(t, err, lPCache.ErrKeyExpired, "Expected the entry to expire at the time")
+	})
+}
+
+func TestCacheGetStale(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should return the expired value with its age", func(t *testing.T) {
+		err := lCache.Set(ctx, "rates", "old", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+		now = now.Add(time.Minute)
+		err = lCache.Set(ctx, "rates", "new", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		now = now.Add(time.Hour)
+		_, err = lCache.Get(ctx, "rates")
+		assert.ErrorIs(t, err, lPCache.ErrKeyExpired, "Expected the entry to be expired")
+
+		value, age, err := lCache.GetStale(ctx, "rates")
+		assert.NoError(t, err, "Expected no error while getting the stale value")
+		assert.Equal(t, "new", value)
+		assert.Equal(t, time.Hour, age, "Expected the age of the last write")
+	})
+
+	t.Run("Should return ErrKeyNotFound once the entry is purged", func(t *testing.T) {
+		err := lCache.PurgeExpiredItems(ctx)
+		assert.NoError(t, err, "Expected no error while purging the expired entries")
+
+		_, _, err = lCache.GetStale(ctx, "rates")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the purged entry to be gone")
+	})
+}
+
+func TestCacheKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	svcA, err := lPCache.NewCache(ctx, lPCache.WithPath(path), lPCache.WithKeyPrefix("svc-a:"))
+	if err != nil {
+		panic(err)
+	}
+	defer svcA.Close(ctx)
+
+	svcB, err := lPCache.NewCache(ctx, lPCache.WithPath(path), lPCache.WithKeyPrefix("svc-b:"))
+	if err != nil {
+		panic(err)
+	}
+	defer svcB.Close(ctx)
+
+	t.Run("Should isolate the keys of the services", func(t *testing.T) {
+		err := svcA.Set(ctx, "user:1", "John", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+		err = svcB.Set(ctx, "user:1", "Jane", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		value, err := svcA.Get(ctx, "user:1")
+		assert.NoError(t, err, "Expected no error while getting the key")
+		assert.Equal(t, "John", value)
+
+		value, err = svcB.Get(ctx, "user:1")
+		assert.NoError(t, err, "Expected no error while getting the key")
+		assert.Equal(t, "Jane", value)
+
+		keys, err := svcA.Keys(ctx, "*")
+		assert.NoError(t, err, "Expected no error while listing the keys")
+		assert.Equal(t, []string{"user:1"}, keys, "Expected the keys without the prefix")
+	})
+
+	t.Run("Should flush only the entries of the service", func(t *testing.T) {
+		err := svcA.Flush(ctx)
+		assert.NoError(t, err, "Expected no error while flushing the cache")
+
+		_, err = svcA.Get(ctx, "user:1")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the entry to be flushed")
+
+		count, err := svcB.Count(ctx)
+		assert.NoError(t, err, "Expected no error while counting the entries")
+		assert.Equal(t, int64(1), count, "Expected the entries of the other service to remain")
+	})
+}
+
+func TestCacheBlobKeys(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	digest := sha256.Sum256([]byte("content"))
+
+	t.Run("Should store and retrieve the values of binary keys", func(t *testing.T) {
+		err := lCache.SetB(ctx, digest[:], []byte("thumbnail"), time.Minute)
+		assert.NoError(t, err, "Expected no error while setting a binary key")
+
+		value, err := lCache.GetB(ctx, digest[:])
+		assert.NoError(t, err, "Expected no error while getting a binary key")
+		assert.Equal(t, []byte("thumbnail"), value)
+	})
+
+	t.Run("Should not match the string keys with the same bytes", func(t *testing.T) {
+		err := lCache.SetB(ctx, []byte("user"), []byte("binary"), time.Minute)
+		assert.NoError(t, err, "Expected no error while setting a binary key")
+		err = lCache.Set(ctx, "user", "text", time.Minute)
+		assert.NoError(t, err, "Expected no error while setting a string key")
+
+		value, err := lCache.GetB(ctx, []byte("user"))
+		assert.NoError(t, err, "Expected no error while getting a binary key")
+		assert.Equal(t, []byte("binary"), value)
+
+		text, err := lCache.Get(ctx, "user")
+		assert.NoError(t, err, "Expected no error while getting a string key")
+		assert.Equal(t, "text", text)
+	})
+
+	t.Run("Should delete the entries of binary keys", func(t *testing.T) {
+		err := lCache.DelB(ctx, digest[:])
+		assert.NoError(t, err, "Expected no error while deleting a binary key")
+
+		_, err = lCache.GetB(ctx, digest[:])
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the entry to be deleted")
+	})
+}
+
+func TestCachePurgePreview(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithPurgePercent(0.5),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	for i := range 10 {
+		err := lCache.Set(ctx, fmt.Sprintf("users:%d", i), "0123456789", time.Hour)
+		assert.NoError(t, err, "Expected no error while setting the key")
+	}
+
+	t.Run("Should report the entries to purge without deleting them", func(t *testing.T) {
+		report, err := lCache.PurgePreview(ctx)
+		assert.NoError(t, err, "Expected no error while previewing the purge")
+		assert.Equal(t, lPCache.PurgeReport{
+			Entries:  5,
+			Bytes:    50,
+			Prefixes: []lPCache.PurgePrefix{{Prefix: "users", Entries: 5}},
+		}, report)
+
+		count, err := lCache.Count(ctx)
+		assert.NoError(t, err, "Expected no error while counting the entries")
+		assert.Equal(t, int64(10), count, "Expected the preview to keep the entries")
+	})
+
+	t.Run("Should report the entries deleted by the purge", func(t *testing.T) {
+		report, err := lCache.PurgeItens(ctx)
+		assert.NoError(t, err, "Expected no error while purging the cache")
+		assert.Equal(t, int64(5), report.Entries)
+		assert.Equal(t, int64(50), report.Bytes)
+
+		count, err := lCache.Count(ctx)
+		assert.NoError(t, err, "Expected no error while counting the entries")
+		assert.Equal(t, int64(5), count, "Expected the purge to delete the entries")
+	})
+}
+
+func TestCacheInvalidOptions(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+
+	_, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(path),
+		lPCache.WithPurgePercent(25.0),
+	)
+
+	assert.ErrorIs(t, err, lPCache.ErrInvalidOption, "Expected ErrInvalidOption")
+	assert.EqualError(t, err, "invalid option: purge percent 25 must be between 0 and 1")
+	_, statErr := os.Stat(filepath.Join(path, "lpack_cache.db"))
+	assert.True(t, os.IsNotExist(statErr), "Expected the database not to be created")
+}
+
+func TestCacheMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should return the metadata stored with the entry", func(t *testing.T) {
+		metadata := map[string]string{
+			"source":       "https://example.com",
+			"content-type": "text/html",
+		}
+		err := lCache.Set(ctx, "page:home", "<html></html>", time.Hour, lPCache.WithMetadata(metadata))
+		assert.NoError(t, err, "Expected no error while setting the key with metadata")
+
+		entry, err := lCache.GetEntry(ctx, "page:home")
+		assert.NoError(t, err, "Expected no error while getting the entry")
+		assert.Equal(t, "<html></html>", entry.Value)
+		assert.Equal(t, metadata, entry.Metadata)
+		assert.False(t, entry.ExpiresAt.IsZero(), "Expected the expiration of the entry")
+
+		value, err := lCache.Get(ctx, "page:home")
+		assert.NoError(t, err, "Expected no error while getting the key")
+		assert.Equal(t, "<html></html>", value, "Expected the value without the metadata")
+	})
+
+	t.Run("Should replace the metadata on the next write", func(t *testing.T) {
+		err := lCache.Set(ctx, "page:home", "<html>v2</html>", 0)
+		assert.NoError(t, err, "Expected no error while setting the key")
+
+		entry, err := lCache.GetEntry(ctx, "page:home")
+		assert.NoError(t, err, "Expected no error while getting the entry")
+		assert.Equal(t, "<html>v2</html>", entry.Value)
+		assert.Nil(t, entry.Metadata, "Expected the metadata to be cleared")
+		assert.True(t, entry.ExpiresAt.IsZero(), "Expected an entry that never expires")
+	})
+
+	t.Run("Should return ErrKeyNotFound for a missing key", func(t *testing.T) {
+		_, err := lCache.GetEntry(ctx, "page:missing")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected ErrKeyNotFound for a missing key")
+	})
+}
+
+func TestCacheDependencies(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()), lPCache.WithDependencies())
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	set := func(t *testing.T, keys ...string) {
+		for _, key := range keys {
+			err := lCache.Set(ctx, key, "value", time.Hour)
+			assert.NoError(t, err, "Expected no error while setting %s", key)
+		}
+	}
+	assertKeys := func(t *testing.T, want ...string) {
+		keys, err := lCache.Keys(ctx, "*")
+		assert.NoError(t, err, "Expected no error while listing the keys")
+		assert.ElementsMatch(t, want, keys)
+	}
+
+	t.Run("Should delete the derived entries in cascade when a parent is written", func(t *testing.T) {
+		set(t, "user:1", "orders:1", "report:1", "page:1")
+		assert.NoError(t, lCache.SetDerived(ctx, "report:1", "user:1", "orders:1"))
+		assert.NoError(t, lCache.SetDerived(ctx, "page:1", "report:1"))
+
+		set(t, "orders:1")
+
+		assertKeys(t, "user:1", "orders:1")
+	})
+
+	t.Run("Should delete the derived entries when a parent is deleted", func(t *testing.T) {
+		set(t, "report:1")
+		assert.NoError(t, lCache.SetDerived(ctx, "report:1", "user:1"))
+
+		err := lCache.Del(ctx, "user:1")
+		assert.NoError(t, err, "Expected no error while deleting the parent")
+
+		assertKeys(t, "orders:1")
+	})
+
+	t.Run("Should keep the written key of a dependency cycle", func(t *testing.T) {
+		set(t, "a", "b")
+		assert.NoError(t, lCache.SetDerived(ctx, "a", "b"))
+		assert.NoError(t, lCache.SetDerived(ctx, "b", "a"))
+
+		set(t, "a")
+
+		assertKeys(t, "orders:1", "a")
+	})
+
+	t.Run("Should return ErrDependenciesDisabled without WithDependencies", func(t *testing.T) {
+		other, err := lPCache.NewCache(ctx, lPCache.WithPath(t.TempDir()))
+		if err != nil {
+			panic(err)
+		}
+		defer other.Close(ctx)
+
+		err = other.SetDerived(ctx, "report:1", "user:1")
+		assert.ErrorIs(t, err, lPCache.ErrDependenciesDisabled)
+	})
+}
+
+func TestCacheTombstones(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 11, 22, 12, 0, 0, 0, time.UTC)
+
+	lCache, err := lPCache.NewCache(
+		ctx,
+		lPCache.WithPath(t.TempDir()),
+		lPCache.WithClock(func() time.Time { return now }),
+		lPCache.WithTombstones(time.Hour),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	t.Run("Should keep a tombstone of the deleted keys", func(t *testing.T) {
+		assert.NoError(t, lCache.Set(ctx, "user:1", "John", time.Minute))
+		assert.NoError(t, lCache.Set(ctx, "user:2", "Jane", time.Minute))
+		assert.NoError(t, lCache.Set(ctx, "session:1", "token", time.Minute))
+
+		now = now.Add(time.Second)
+		assert.NoError(t, lCache.Del(ctx, "session:1"))
+		now = now.Add(time.Second)
+		deleted, err := lCache.DelPrefix(ctx, "user:")
+		assert.NoError(t, err, "Expected no error while deleting the prefix")
+		assert.Equal(t, int64(2), deleted)
+
+		_, err = lCache.Get(ctx, "user:1")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the deleted key to be missing")
+		assert.NotErrorIs(t, err, lPCache.ErrKeyExpired, "Expected the deleted key not to be expired")
+		_, _, err = lCache.GetStale(ctx, "user:1")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected no stale value for a deleted key")
+
+		tombstones, err := lCache.Tombstones(ctx, time.Time{})
+		assert.NoError(t, err, "Expected no error while listing the tombstones")
+		assert.Equal(t, []lPCache.Tombstone{
+			{Key: "session:1", DeletedAt: now.Add(-time.Second)},
+			{Key: "user:1", DeletedAt: now},
+			{Key: "user:2", DeletedAt: now},
+		}, tombstones)
+
+		tombstones, err = lCache.Tombstones(ctx, now.Add(-time.Second))
+		assert.NoError(t, err, "Expected no error while listing the tombstones")
+		assert.Len(t, tombstones, 2, "Expected the tombstones after the given time")
+	})
+
+	t.Run("Should keep the tombstones when the expired entries are purged", func(t *testing.T) {
+		now = now.Add(10 * time.Minute)
+		assert.NoError(t, lCache.PurgeExpiredItems(ctx))
+
+		tombstones, err := lCache.Tombstones(ctx, time.Time{})
+		assert.NoError(t, err, "Expected no error while listing the tombstones")
+		assert.Len(t, tombstones, 3, "Expected the tombstones to be kept")
+	})
+
+	t.Run("Should replace the tombstone when the key is written again", func(t *testing.T) {
+		assert.NoError(t, lCache.Set(ctx, "user:1", "Johnny", time.Minute))
+
+		value, err := lCache.Get(ctx, "user:1")
+		assert.NoError(t, err, "Expected no error while getting the key")
+		assert.Equal(t, "Johnny", value)
+
+		tombstones, err := lCache.Tombstones(ctx, time.Time{})
+		assert.NoError(t, err, "Expected no error while listing the tombstones")
+		assert.Len(t, tombstones, 2, "Expected the tombstone of the key to be removed")
+
+		now = now.Add(2 * time.Minute)
+		assert.NoError(t, lCache.PurgeExpiredItems(ctx))
+		_, _, err = lCache.GetStale(ctx, "user:1")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the entry to expire unpinned")
+	})
 }
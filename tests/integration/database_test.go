@@ -3,7 +3,12 @@ package tests
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -33,7 +38,7 @@ func TestDatabase(t *testing.T) {
 		assert.Nil(t, err, "Expected insert query to succeed, but got: %v", err)
 
 		var value string
-		err = db.ExecWithTx(ctx, func(tx *sql.Tx) error {
+		err = db.WithTx(ctx, nil, func(tx *sql.Tx) error {
 			return tx.QueryRowContext(ctx, selectQuery).Scan(&value)
 		})
 		assert.Nil(t, err, "Expected select query to succeed, but got: %v", err)
@@ -67,17 +72,74 @@ func TestDatabase(t *testing.T) {
 		assert.Nil(t, err, "Expected insert query to succeed, but got: %v", err)
 	})
 
-	t.Run("ExecWithTx", func(t *testing.T) {
+	t.Run("WithTx", func(t *testing.T) {
 		selectQuery := `SELECT value FROM test_table WHERE id = ?`
 		var value string
-		err := db.ExecWithTx(ctx, func(tx *sql.Tx) error {
+		err := db.WithTx(ctx, nil, func(tx *sql.Tx) error {
 			return tx.QueryRowContext(ctx, selectQuery, 1).Scan(&value)
 		})
 
-		assert.Nil(t, err, "Expected ExecWithTx to succeed, but got: %v", err)
+		assert.Nil(t, err, "Expected WithTx to succeed, but got: %v", err)
 		assert.Equal(t, "test_value", value, "Expected retrieved value to be 'test_value', but got: %v", value)
 	})
 
+	t.Run("WithTx should commit the writes", func(t *testing.T) {
+		err := db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO test_table (value) VALUES (?)`, "committed")
+			return err
+		})
+		assert.Nil(t, err, "Expected WithTx to succeed, but got: %v", err)
+
+		var count int
+		err = db.QueryRow(ctx, `SELECT COUNT(*) FROM test_table WHERE value = ?`, "committed").Scan(&count)
+		assert.Nil(t, err, "Expected to read the committed row, but got: %v", err)
+		assert.Equal(t, 1, count, "Expected the row to be committed")
+
+		assert.Nil(t, db.Exec(ctx, `DELETE FROM test_table WHERE value = ?`, "committed"))
+	})
+
+	t.Run("WithTx should roll back the writes of a failed function", func(t *testing.T) {
+		err := db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO test_table (value) VALUES (?)`, "rolled back")
+			assert.Nil(t, err, "Expected the insert to succeed, but got: %v", err)
+			return errors.New("failed")
+		})
+		assert.NotNil(t, err, "Expected WithTx to fail")
+
+		var count int
+		err = db.QueryRow(ctx, `SELECT COUNT(*) FROM test_table WHERE value = ?`, "rolled back").Scan(&count)
+		assert.Nil(t, err, "Expected to read the table, but got: %v", err)
+		assert.Equal(t, 0, count, "Expected the row to be rolled back")
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		rows, err := db.Query(ctx, `SELECT value FROM test_table WHERE value = ? ORDER BY id`, "test_value")
+		assert.Nil(t, err, "Expected Query to succeed, but got: %v", err)
+		defer rows.Close()
+
+		var values []string
+		for rows.Next() {
+			var value string
+			assert.Nil(t, rows.Scan(&value), "Expected the row to be scanned")
+			values = append(values, value)
+		}
+		assert.Nil(t, rows.Err(), "Expected the rows to be read, but got: %v", rows.Err())
+		assert.Equal(t, []string{"test_value", "test_value"}, values)
+
+		_, err = db.Query(ctx, `SELECT value FROM missing_table`)
+		assert.ErrorContains(t, err, "querying: no such table: missing_table")
+	})
+
+	t.Run("QueryRow", func(t *testing.T) {
+		var value string
+		err := db.QueryRow(ctx, `SELECT value FROM test_table WHERE id = ?`, 1).Scan(&value)
+		assert.Nil(t, err, "Expected QueryRow to succeed, but got: %v", err)
+		assert.Equal(t, "test_value", value)
+
+		err = db.QueryRow(ctx, `SELECT value FROM test_table WHERE id = ?`, -1).Scan(&value)
+		assert.ErrorIs(t, err, sql.ErrNoRows, "Expected no row for a missing id")
+	})
+
 	t.Run("Vacuum", func(t *testing.T) {
 		err := db.Vacuum(ctx)
 		assert.Nil(t, err, "Expected Vacuum to succeed, but got: %v", err)
@@ -88,6 +150,27 @@ func TestDatabase(t *testing.T) {
 		assert.Nil(t, err, "Expected SetJournalModeWal to succeed, but got: %v", err)
 	})
 
+	t.Run("Should checkpoint the WAL", func(t *testing.T) {
+		err := db.Checkpoint(ctx)
+		assert.Nil(t, err, "Expected Checkpoint to succeed, but got: %v", err)
+	})
+
+	t.Run("Should vacuum incrementally", func(t *testing.T) {
+		err := db.SetAutoVacuumIncremental(ctx)
+		assert.Nil(t, err, "Expected SetAutoVacuumIncremental to succeed, but got: %v", err)
+
+		var mode int
+		err = db.GetEngine(ctx).QueryRowContext(ctx, "PRAGMA auto_vacuum;").Scan(&mode)
+		assert.Nil(t, err, "Expected to read the auto vacuum mode, but got: %v", err)
+		assert.Equal(t, 2, mode, "Expected the incremental auto vacuum mode")
+
+		err = db.IncrementalVacuum(ctx, 100)
+		assert.Nil(t, err, "Expected IncrementalVacuum to succeed, but got: %v", err)
+
+		err = db.IncrementalVacuum(ctx, 0)
+		assert.EqualError(t, err, "invalid incremental vacuum pages: 0")
+	})
+
 	t.Run("Should set page size", func(t *testing.T) {
 		err := db.SetPageSize(ctx, 4096)
 		assert.Nil(t, err, "Expected SetPageSize to succeed with valid page size, but got: %v", err)
@@ -100,4 +183,542 @@ func TestDatabase(t *testing.T) {
 		assert.NotNil(t, err, "Expected SetPageSize to fail with negative page size")
 		assert.Equal(t, "invalid page size: -1", err.Error(), "Expected specific error for negative page size")
 	})
+
+	t.Run("Should ping the database", func(t *testing.T) {
+		err := db.Ping(ctx)
+		assert.Nil(t, err, "Expected Ping to succeed, but got: %v", err)
+	})
+
+	t.Run("Should set the user version", func(t *testing.T) {
+		err := db.SetUserVersion(ctx, 7)
+		assert.Nil(t, err, "Expected SetUserVersion to succeed, but got: %v", err)
+
+		version, err := db.GetUserVersion(ctx)
+		assert.Nil(t, err, "Expected GetUserVersion to succeed, but got: %v", err)
+		assert.Equal(t, 7, version, "Expected the user version to be stored")
+
+		err = db.SetUserVersion(ctx, -1)
+		assert.EqualError(t, err, "invalid user version: -1")
+	})
+}
+
+func TestDatabasePragma(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewDatabase(ctx, t.TempDir(), "pragma.db")
+	assert.Nil(t, err, "Failed to initialize database")
+	defer db.Close(ctx)
+
+	t.Run("Should set and get a pragma", func(t *testing.T) {
+		err := db.SetPragma(ctx, "main.application_id", "42")
+		assert.Nil(t, err, "Expected SetPragma to succeed, but got: %v", err)
+
+		value, err := db.Pragma(ctx, "main.application_id")
+		assert.Nil(t, err, "Expected Pragma to succeed, but got: %v", err)
+		assert.Equal(t, "42", value)
+	})
+
+	t.Run("Should fail for a pragma without value", func(t *testing.T) {
+		_, err := db.Pragma(ctx, "unknown_pragma")
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestDatabaseSize(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should return the pages and the size of the files", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, t.TempDir(), "size.db")
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+		assert.Nil(t, db.SetJournalModeWal(ctx), "Failed to enable WAL mode")
+		assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+		assert.Nil(t, db.Exec(ctx, `INSERT INTO items (value) VALUES (?)`, strings.Repeat("x", 8192)))
+
+		size, err := db.Size(ctx)
+
+		assert.Nil(t, err, "Expected Size to succeed, but got: %v", err)
+		assert.Positive(t, size.PageCount)
+		assert.Equal(t, int64(4096), size.PageSize)
+		assert.Positive(t, size.WALSize, "Expected the writes to be in the WAL")
+
+		assert.Nil(t, db.Checkpoint(ctx), "Failed to checkpoint")
+		size, err = db.Size(ctx)
+		assert.Nil(t, err, "Expected Size to succeed, but got: %v", err)
+		assert.Equal(t, int64(0), size.WALSize, "Expected the checkpoint to truncate the WAL")
+		assert.Equal(t, size.PageCount*size.PageSize, size.FileSize)
+	})
+
+	t.Run("Should return no file size for an in-memory database", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, database.InMemory, "")
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+
+		size, err := db.Size(ctx)
+
+		assert.Nil(t, err, "Expected Size to succeed, but got: %v", err)
+		assert.Equal(t, int64(0), size.FileSize)
+		assert.Equal(t, int64(0), size.WALSize)
+	})
+}
+
+func TestDatabaseIntegrityCheck(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewDatabase(ctx, t.TempDir(), "integrity.db")
+	assert.Nil(t, err, "Failed to initialize database")
+	defer db.Close(ctx)
+	assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+	assert.Nil(t, db.Exec(ctx, `CREATE INDEX idx_items_value ON items(value)`))
+	assert.Nil(t, db.Exec(ctx, `INSERT INTO items (value) VALUES ('a'), ('b')`))
+
+	for _, quick := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Should find no problem with quick %v", quick), func(t *testing.T) {
+			problems, err := db.IntegrityCheck(ctx, quick)
+
+			assert.Nil(t, err, "Expected IntegrityCheck to succeed, but got: %v", err)
+			assert.Empty(t, problems, "Expected the database to be intact")
+		})
+	}
+}
+
+func TestDatabaseBackupTo(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := database.NewDatabase(ctx, dir, "source.db")
+	assert.Nil(t, err, "Failed to initialize database")
+	defer db.Close(ctx)
+
+	assert.Nil(t, db.SetJournalModeWal(ctx), "Failed to enable WAL mode")
+	assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+	for i := 0; i < 50; i++ {
+		assert.Nil(t, db.Exec(ctx, `INSERT INTO items (value) VALUES (?)`, "value"))
+	}
+
+	t.Run("Should copy the database", func(t *testing.T) {
+		destPath := filepath.Join(dir, "backup.db")
+		steps := 0
+
+		err := db.BackupTo(ctx, destPath, func(remaining, total int) {
+			steps++
+		})
+		assert.Nil(t, err, "Expected BackupTo to succeed, but got: %v", err)
+		assert.Positive(t, steps, "Expected the progress to be reported")
+
+		backup, err := database.NewDatabase(ctx, dir, "backup.db")
+		assert.Nil(t, err, "Failed to open the backup")
+		defer backup.Close(ctx)
+
+		var count int
+		err = backup.QueryRow(ctx, `SELECT COUNT(*) FROM items`).Scan(&count)
+		assert.Nil(t, err, "Expected to read the backup, but got: %v", err)
+		assert.Equal(t, 50, count, "Expected the backup to hold the rows")
+	})
+
+	t.Run("Should fail for an empty path", func(t *testing.T) {
+		err := db.BackupTo(ctx, "", nil)
+		assert.EqualError(t, err, `invalid backup path: ""`)
+	})
+}
+
+func TestDatabaseBusyTimeout(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	t.Run("Should wait for the lock held by another connection", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, dir, "busy.db", database.WithBusyTimeout(5*time.Second))
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+		assert.Nil(t, db.SetJournalModeWal(ctx), "Failed to enable WAL mode")
+		assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+
+		other, err := database.NewDatabase(ctx, dir, "busy.db")
+		assert.Nil(t, err, "Failed to open the second connection")
+		defer other.Close(ctx)
+
+		locked := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- other.WithTx(ctx, nil, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `INSERT INTO items (value) VALUES ('other')`)
+				close(locked)
+				time.Sleep(200 * time.Millisecond)
+				return err
+			})
+		}()
+		<-locked
+
+		err = db.Exec(ctx, `INSERT INTO items (value) VALUES ('waiting')`)
+		assert.Nil(t, err, "Expected the write to wait for the lock, but got: %v", err)
+		assert.Nil(t, <-done, "Expected the transaction holding the lock to succeed")
+	})
+
+	t.Run("Should keep the data of an in-memory database", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, database.InMemory, "")
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+		assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+		assert.Nil(t, db.Exec(ctx, `INSERT INTO items (value) VALUES ('kept')`))
+
+		err = db.SetBusyTimeout(ctx, time.Second)
+		assert.Nil(t, err, "Expected SetBusyTimeout to succeed, but got: %v", err)
+
+		var value string
+		err = db.QueryRow(ctx, `SELECT value FROM items`).Scan(&value)
+		assert.Nil(t, err, "Expected the table to be kept, but got: %v", err)
+		assert.Equal(t, "kept", value)
+	})
+
+	t.Run("Should fail for a negative timeout", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, database.InMemory, "")
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+
+		err = db.SetBusyTimeout(ctx, -time.Second)
+		assert.EqualError(t, err, "invalid busy timeout: -1s")
+	})
+}
+
+func TestDatabaseSynchronous(t *testing.T) {
+	ctx := context.Background()
+
+	// synchronous reads 0 for OFF, 1 for NORMAL and 2 for FULL
+	synchronous := func(t *testing.T, db database.Database) string {
+		value, err := db.Pragma(ctx, "synchronous")
+		assert.Nil(t, err, "Expected Pragma to succeed, but got: %v", err)
+		return value
+	}
+
+	t.Run("Should open the connections with the synchronous mode", func(t *testing.T) {
+		db, err := database.NewDatabase(
+			ctx,
+			t.TempDir(),
+			"sync.db",
+			database.WithSynchronous(database.SynchronousNormal),
+		)
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+
+		assert.Equal(t, "1", synchronous(t, db))
+	})
+
+	t.Run("Should set the synchronous mode", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, t.TempDir(), "sync.db")
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+
+		err = db.SetSynchronous(ctx, database.SynchronousOff)
+		assert.Nil(t, err, "Expected SetSynchronous to succeed, but got: %v", err)
+		assert.Equal(t, "0", synchronous(t, db))
+
+		err = db.SetSynchronous(ctx, "SOMETIMES")
+		assert.EqualError(t, err, `invalid synchronous mode: "SOMETIMES"`)
+	})
+
+	t.Run("Should fail for an invalid mode", func(t *testing.T) {
+		_, err := database.NewDatabase(ctx, t.TempDir(), "sync.db", database.WithSynchronous("SOMETIMES"))
+
+		assert.EqualError(t, err, `invalid synchronous mode: "SOMETIMES"`)
+	})
+}
+
+func TestDatabaseMmapSize(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should map the database in memory on each connection", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, t.TempDir(), "mmap.db", database.WithMmapSize(1<<20))
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+		assert.Nil(t, db.SetJournalModeWal(ctx), "Failed to enable WAL mode")
+		assert.Nil(t, db.OpenReadPool(ctx, 2), "Failed to open the read pool")
+
+		value, err := db.Pragma(ctx, "mmap_size")
+		assert.Nil(t, err, "Expected Pragma to succeed, but got: %v", err)
+		assert.Equal(t, "1048576", value)
+
+		var size int
+		err = db.GetReadEngine(ctx).QueryRowContext(ctx, "PRAGMA mmap_size;").Scan(&size)
+		assert.Nil(t, err, "Expected the read pool to be readable, but got: %v", err)
+		assert.Equal(t, 1<<20, size, "Expected the read pool to map the database")
+	})
+
+	t.Run("Should set the mmap size", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, t.TempDir(), "mmap.db", database.WithMmapSize(1<<20))
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+
+		err = db.SetMmapSize(ctx, 0)
+		assert.Nil(t, err, "Expected SetMmapSize to succeed, but got: %v", err)
+
+		value, err := db.Pragma(ctx, "mmap_size")
+		assert.Nil(t, err, "Expected Pragma to succeed, but got: %v", err)
+		assert.Equal(t, "0", value)
+
+		err = db.SetMmapSize(ctx, -1)
+		assert.EqualError(t, err, "invalid mmap size: -1")
+	})
+}
+
+func TestDatabaseReadPool(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewDatabase(ctx, t.TempDir(), "read pool.db")
+	assert.Nil(t, err, "Failed to initialize database")
+	defer db.Close(ctx)
+
+	assert.Nil(t, db.SetJournalModeWal(ctx), "Failed to enable WAL mode")
+	assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+
+	t.Run("Should read through the read pool while a write is in progress", func(t *testing.T) {
+		err := db.OpenReadPool(ctx, 2)
+		assert.Nil(t, err, "Expected OpenReadPool to succeed, but got: %v", err)
+		assert.NotSame(t, db.GetEngine(ctx), db.GetReadEngine(ctx), "Expected a separate read pool")
+
+		err = db.Exec(ctx, `INSERT INTO items (value) VALUES (?)`, "committed")
+		assert.Nil(t, err, "Expected the insert to succeed, but got: %v", err)
+
+		err = db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO items (value) VALUES (?)`, "pending")
+			assert.Nil(t, err, "Expected the insert to succeed, but got: %v", err)
+
+			var count int
+			err = db.GetReadEngine(ctx).
+				QueryRowContext(ctx, `SELECT COUNT(*) FROM items`).
+				Scan(&count)
+			assert.Nil(t, err, "Expected the read not to wait for the write, but got: %v", err)
+			assert.Equal(t, 1, count, "Expected the read to see the committed rows only")
+			return nil
+		})
+		assert.Nil(t, err, "Expected the transaction to succeed, but got: %v", err)
+	})
+
+	t.Run("Should not write through the read pool", func(t *testing.T) {
+		_, err := db.GetReadEngine(ctx).
+			ExecContext(ctx, `INSERT INTO items (value) VALUES (?)`, "value")
+
+		assert.ErrorContains(t, err, "readonly database")
+	})
+
+	t.Run("Should fail for an invalid size", func(t *testing.T) {
+		err := db.OpenReadPool(ctx, 0)
+
+		assert.EqualError(t, err, "invalid read pool size: 0")
+	})
+
+	t.Run("Should keep a single pool in memory", func(t *testing.T) {
+		memDB, err := database.NewDatabase(ctx, database.InMemory, "")
+		assert.Nil(t, err, "Failed to initialize database")
+		defer memDB.Close(ctx)
+
+		err = memDB.OpenReadPool(ctx, 2)
+
+		assert.Nil(t, err, "Expected OpenReadPool to succeed, but got: %v", err)
+		assert.Same(t, memDB.GetEngine(ctx), memDB.GetReadEngine(ctx))
+	})
+}
+
+func TestDatabaseReadOnly(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	db, err := database.NewDatabase(ctx, dir, "read only.db")
+	assert.Nil(t, err, "Failed to initialize database")
+	assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+	assert.Nil(t, db.Exec(ctx, `INSERT INTO items (value) VALUES (?)`, "value"))
+	assert.Nil(t, db.Close(ctx))
+
+	t.Run("Should read but not write the database", func(t *testing.T) {
+		roDB, err := database.NewDatabase(ctx, dir, "read only.db", database.WithReadOnly())
+		assert.Nil(t, err, "Expected NewDatabase to succeed, but got: %v", err)
+		defer roDB.Close(ctx)
+
+		var value string
+		err = roDB.GetEngine(ctx).QueryRowContext(ctx, `SELECT value FROM items`).Scan(&value)
+		assert.Nil(t, err, "Expected the read to succeed, but got: %v", err)
+		assert.Equal(t, "value", value)
+
+		err = roDB.Exec(ctx, `INSERT INTO items (value) VALUES (?)`, "other")
+		assert.True(t, database.IsReadOnlyError(err), "Expected a read-only error, got: %v", err)
+
+		err = roDB.Destroy(ctx)
+		assert.EqualError(t, err, "error destroying database: the database is read-only")
+	})
+
+	t.Run("Should read an immutable database", func(t *testing.T) {
+		roDB, err := database.NewDatabase(ctx, dir, "read only.db", database.WithImmutable())
+		assert.Nil(t, err, "Expected NewDatabase to succeed, but got: %v", err)
+		defer roDB.Close(ctx)
+
+		var count int
+		err = roDB.GetEngine(ctx).QueryRowContext(ctx, `SELECT COUNT(*) FROM items`).Scan(&count)
+		assert.Nil(t, err, "Expected the read to succeed, but got: %v", err)
+		assert.Equal(t, 1, count)
+
+		err = roDB.Exec(ctx, `DELETE FROM items`)
+		assert.True(t, database.IsReadOnlyError(err), "Expected a read-only error, got: %v", err)
+	})
+
+	t.Run("Should fail for a missing database", func(t *testing.T) {
+		_, err := database.NewDatabase(ctx, dir, "missing.db", database.WithReadOnly())
+
+		assert.ErrorContains(t, err, "error opening read-only database")
+	})
+
+	t.Run("Should fail in memory", func(t *testing.T) {
+		_, err := database.NewDatabase(ctx, database.InMemory, "", database.WithReadOnly())
+
+		assert.EqualError(t, err, "invalid read-only database: an in-memory database can't be read-only")
+	})
+}
+
+func TestDatabaseURI(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should share an in-memory database between the connections", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, "file::memory:", "")
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+		assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+
+		// the first transaction holds its connection, so that the second one is opened
+		for i := 0; i < 2; i++ {
+			tx, err := db.GetEngine(ctx).BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+			assert.Nil(t, err, "Expected a transaction, but got: %v", err)
+			defer tx.Rollback()
+
+			var count int
+			err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM items`).Scan(&count)
+			assert.Nil(t, err, "Expected connection %d to see the table, but got: %v", i, err)
+		}
+	})
+
+	t.Run("Should open a named in-memory database", func(t *testing.T) {
+		uri := "file:lpack_uri_test?mode=memory&cache=shared"
+		db, err := database.NewDatabase(ctx, "", uri)
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+		assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+
+		other, err := database.NewDatabase(ctx, uri, "")
+		assert.Nil(t, err, "Failed to initialize database")
+		defer other.Close(ctx)
+
+		var count int
+		err = other.GetEngine(ctx).QueryRowContext(ctx, `SELECT COUNT(*) FROM items`).Scan(&count)
+		assert.Nil(t, err, "Expected the databases to share the table, but got: %v", err)
+
+		_, err = database.NewDatabase(ctx, uri, "", database.WithReadOnly())
+		assert.EqualError(t, err, "invalid read-only database: an in-memory database can't be read-only")
+	})
+
+	t.Run("Should open a database file with parameters", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "uri.db")
+		db, err := database.NewDatabase(ctx, "file:"+path+"?cache=private", "")
+		assert.Nil(t, err, "Failed to initialize database")
+		assert.Nil(t, db.Exec(ctx, `CREATE TABLE items (id INTEGER PRIMARY KEY, value TEXT)`))
+
+		size, err := db.Size(ctx)
+		assert.Nil(t, err, "Expected Size to succeed, but got: %v", err)
+		assert.Positive(t, size.FileSize, "Expected the size of the file of the URI")
+
+		assert.Nil(t, db.OpenReadPool(ctx, 2), "Failed to open the read pool")
+		var count int
+		err = db.GetReadEngine(ctx).QueryRowContext(ctx, `SELECT COUNT(*) FROM items`).Scan(&count)
+		assert.Nil(t, err, "Expected the read pool to read the file, but got: %v", err)
+
+		err = db.Destroy(ctx)
+		assert.Nil(t, err, "Expected Destroy to succeed, but got: %v", err)
+		assert.NoFileExists(t, path)
+	})
+}
+
+func TestDatabaseDSNParams(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should apply the parameters to every connection", func(t *testing.T) {
+		dir := t.TempDir()
+		db, err := database.NewDatabase(ctx, dir, "params.db",
+			database.WithDSNParam("_journal_mode", "WAL"),
+			database.WithDSNParam("_foreign_keys", "1"),
+			database.WithDSNParam("mode", "rwc"),
+		)
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+
+		value, err := db.Pragma(ctx, "journal_mode")
+		assert.Nil(t, err, "Expected Pragma to succeed, but got: %v", err)
+		assert.Equal(t, "wal", value)
+
+		// the first transaction holds its connection, so that the second one is opened
+		for i := 0; i < 2; i++ {
+			tx, err := db.GetEngine(ctx).BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+			assert.Nil(t, err, "Expected a transaction, but got: %v", err)
+			defer tx.Rollback()
+
+			var foreignKeys int
+			err = tx.QueryRowContext(ctx, "PRAGMA foreign_keys;").Scan(&foreignKeys)
+			assert.Nil(t, err, "Expected the pragma to be read, but got: %v", err)
+			assert.Equal(t, 1, foreignKeys, "Expected connection %d to enforce foreign keys", i)
+		}
+
+		size, err := db.Size(ctx)
+		assert.Nil(t, err, "Expected Size to succeed, but got: %v", err)
+		assert.Positive(t, size.FileSize, "Expected the size of the database file")
+		assert.FileExists(t, filepath.Join(dir, "params.db"))
+	})
+
+	t.Run("Should apply the parameters of the modernc driver", func(t *testing.T) {
+		db, err := database.NewDatabase(ctx, t.TempDir(), "params.db",
+			database.WithDSNParam("_pragma", "foreign_keys(1)"),
+		)
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+		assert.Nil(t, db.SetEngine(ctx, database.DriverModernc), "Failed to set the engine")
+
+		value, err := db.Pragma(ctx, "foreign_keys")
+		assert.Nil(t, err, "Expected Pragma to succeed, but got: %v", err)
+		assert.Equal(t, "1", value)
+	})
+
+	t.Run("Should fail for a parameter without a name", func(t *testing.T) {
+		_, err := database.NewDatabase(ctx, t.TempDir(), "params.db", database.WithDSNParam("", "1"))
+
+		assert.EqualError(t, err, "invalid DSN parameter: empty name")
+	})
+}
+
+func TestDatabaseWithQueryHook(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should call the hooks in order with each query", func(t *testing.T) {
+		var calls []string
+		db, err := database.NewDatabase(
+			ctx,
+			database.InMemory,
+			"",
+			database.WithQueryHook(func(_ context.Context, query string, args []interface{}, _ time.Duration, err error) {
+				calls = append(calls, fmt.Sprintf("first: %s %v %v", query, args, err != nil))
+			}),
+			database.WithQueryHook(func(_ context.Context, query string, _ []interface{}, _ time.Duration, _ error) {
+				calls = append(calls, "second: "+query)
+			}),
+		)
+		assert.Nil(t, err, "Failed to initialize database")
+		defer db.Close(ctx)
+
+		_ = db.Exec(ctx, `CREATE TABLE items (value TEXT)`)
+		calls = nil
+
+		err = db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `INSERT INTO items (value) VALUES (?)`, "a")
+			return err
+		})
+		assert.Nil(t, err)
+		_ = db.Exec(ctx, `SELECT * FROM missing`)
+
+		assert.Equal(t, []string{
+			"first: INSERT INTO items (value) VALUES (?) [a] false",
+			"second: INSERT INTO items (value) VALUES (?)",
+			"first: SELECT * FROM missing [] true",
+			"second: SELECT * FROM missing",
+		}, calls)
+	})
 }
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	lPCache "github.com/lucasvillarinho/litepack/cache"
+	"github.com/lucasvillarinho/litepack/middleware"
+)
+
+func TestResponseCacheMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	lCache, err := lPCache.NewCache(ctx, lPCache.WithInMemory())
+	if err != nil {
+		panic(err)
+	}
+	defer lCache.Close(ctx)
+
+	newResponseCache := func(name string) *middleware.ResponseCache {
+		return middleware.New(lCache.Namespace(name),
+			middleware.WithTTL(time.Minute),
+			middleware.WithRouteTTL("/nocache/:id", 0),
+		)
+	}
+
+	t.Run("Should cache the responses of net/http handlers ", func(t *testing.T) {
+		calls := 0
+		handler := newResponseCache("http").Handler(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				_, _ = w.Write([]byte("user " + r.URL.Path))
+			}))
+
+		for range 2 {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			assert.Equal(t, "user /users/1", rec.Body.String(), "Expected the response body")
+		}
+
+		assert.Equal(t, 1, calls, "Expected the handler to be called once")
+	})
+}
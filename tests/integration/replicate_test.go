@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucasvillarinho/litepack/admin"
+	lPCache "github.com/lucasvillarinho/litepack/cache"
+	"github.com/lucasvillarinho/litepack/replicate"
+)
+
+func TestReplicate(t *testing.T) {
+	ctx := context.Background()
+
+	newCache := func(opts ...lPCache.Option) lPCache.Cache {
+		c, err := lPCache.NewCache(ctx, append(opts, lPCache.WithPath(t.TempDir()))...)
+		if err != nil {
+			panic(err)
+		}
+		t.Cleanup(func() { _ = c.Close(ctx) })
+
+		return c
+	}
+
+	assertReplicated := func(t *testing.T, target lPCache.Cache) {
+		entry, err := target.GetEntry(ctx, "config")
+		assert.NoError(t, err, "Expected the entry to be copied")
+		assert.Equal(t, "on", entry.Value)
+		assert.Equal(t, map[string]string{"source": "file"}, entry.Metadata)
+		assert.True(t, entry.ExpiresAt.IsZero(), "Expected an entry that never expires")
+
+		value, err := target.Get(ctx, "user:1")
+		assert.NoError(t, err, "Expected the entry to be copied")
+		assert.Equal(t, "Johnny", value, "Expected the latest value")
+
+		ttl, err := target.GetTTL(ctx, "user:1")
+		assert.NoError(t, err, "Expected no error while getting the TTL")
+		assert.InDelta(t, time.Hour, ttl, float64(time.Minute), "Expected the remaining TTL")
+
+		_, err = target.Get(ctx, "user:2")
+		assert.ErrorIs(t, err, lPCache.ErrKeyNotFound, "Expected the deleted entry to be gone")
+	}
+
+	write := func(t *testing.T, source lPCache.Cache) {
+		assert.NoError(t, source.Set(ctx, "config", "on", 0, lPCache.WithMetadata(map[string]string{
+			"source": "file",
+		})))
+		assert.NoError(t, source.Set(ctx, "user:1", "John", time.Hour))
+		assert.NoError(t, source.Set(ctx, "user:2", "Jane", time.Hour))
+		assert.NoError(t, source.Set(ctx, "user:1", "Johnny", time.Hour))
+		assert.NoError(t, source.Del(ctx, "user:2"))
+	}
+
+	t.Run("Should copy the changes of a cache on the same host", func(t *testing.T) {
+		source := newCache(lPCache.WithEventLog(time.Hour))
+		target := newCache()
+		assert.NoError(t, target.Set(ctx, "user:2", "stale", time.Hour))
+		write(t, source)
+
+		r := replicate.New(source, target)
+		applied, err := r.Sync(ctx)
+
+		assert.NoError(t, err, "Expected no error while replicating")
+		assert.Equal(t, 5, applied, "Expected every event to be applied")
+		assertReplicated(t, target)
+
+		applied, err = r.Sync(ctx)
+		assert.NoError(t, err, "Expected no error while replicating")
+		assert.Equal(t, 0, applied, "Expected the target to be up to date")
+	})
+
+	t.Run("Should copy the changes of a cache through the admin API", func(t *testing.T) {
+		source := newCache(lPCache.WithEventLog(time.Hour))
+		target := newCache()
+		write(t, source)
+
+		mux := http.NewServeMux()
+		mux.Handle("/internal/cache/", http.StripPrefix("/internal/cache", admin.Handler(source)))
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		r := replicate.New(replicate.HTTPSource(server.URL+"/internal/cache", server.Client()), target)
+		_, err := r.Sync(ctx)
+
+		assert.NoError(t, err, "Expected no error while replicating")
+		assertReplicated(t, target)
+	})
+}